@@ -71,7 +71,7 @@ class ApiService {
 
   async updateService(id: string, data: Partial<CreateServiceData>) {
     return this.request<Service>(`/services/${id}`, {
-      method: 'PUT',
+      method: 'PATCH',
       body: JSON.stringify(data),
     });
   }
@@ -202,7 +202,7 @@ class ApiService {
 
   async updateHost(id: string, data: Partial<CreateHostData>) {
     return this.request<Host>(`/hosts/${id}`, {
-      method: 'PUT',
+      method: 'PATCH',
       body: JSON.stringify(data),
     });
   }
@@ -385,6 +385,7 @@ export interface CreateServiceData {
   tags?: string[];
   scheduleType?: 'interval' | 'cron';
   cronExpression?: string;
+  profile?: string;
 }
 
 export interface Metric {
@@ -679,5 +680,15 @@ export interface AppSettings {
   retention: {
     metrics: string;
     logs: string;
+    systemMetrics?: string;
+    logLevels?: Record<string, string>;
+  };
+  system: {
+    collectInterval: number;
+    storeInterval: number;
+    ssh: {
+      connectionTimeout: number;
+      commandTimeout: number;
+    };
   };
 }
@@ -0,0 +1,196 @@
+// Command server runs the monitoring API: service checks, system metric
+// collection and the HTTP and WebSocket server. When the first argument
+// names a maintenance subcommand (see internal/cli) it runs that instead,
+// e.g. "server healthcheck -q" for container probes.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/api/handlers"
+	"github.com/mt-monitoring/api/internal/api/middleware"
+	"github.com/mt-monitoring/api/internal/api/websocket"
+	"github.com/mt-monitoring/api/internal/archive"
+	"github.com/mt-monitoring/api/internal/backup"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/cli"
+	"github.com/mt-monitoring/api/internal/cluster"
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/diagnostics"
+	"github.com/mt-monitoring/api/internal/eventstream"
+	"github.com/mt-monitoring/api/internal/exporter"
+	"github.com/mt-monitoring/api/internal/gitops"
+	"github.com/mt-monitoring/api/internal/mqtt"
+	"github.com/mt-monitoring/api/internal/reload"
+	"github.com/mt-monitoring/api/internal/settings"
+	"github.com/mt-monitoring/api/internal/telemetry"
+)
+
+// shutdownTimeout bounds how long in-flight requests and queued exports
+// get to finish on SIGINT or SIGTERM
+const shutdownTimeout = 10 * time.Second
+
+// localHostID is the host ID system metrics of this machine are stored
+// under
+const localHostID = "local"
+
+func main() {
+	if handled, code := cli.Run(os.Args[1:]); handled {
+		os.Exit(code)
+	}
+
+	configPath := flag.String("config", "", "path to config file, JSON or YAML (default: ./config.{json,yaml,yml} or ./config/config.*)")
+	flag.Parse()
+
+	diagnostics.CaptureLogs()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+	for _, e := range config.Validate(cfg) {
+		log.Printf("Config problem: %s: %s", e.Field, e.Message)
+	}
+	if err := crypto.Init(cfg.Security.EncryptionKey); err != nil {
+		log.Fatalf("Invalid encryption key: %v", err)
+	}
+
+	store, err := openStore(cfg.Database)
+	if err != nil {
+		log.Fatalf("Failed to open database: %v", err)
+	}
+
+	shutdownTelemetry, err := telemetry.Setup(context.Background(), handlers.Version)
+	if err != nil {
+		log.Fatalf("Failed to set up telemetry: %v", err)
+	}
+	shutdownCluster, err := cluster.Setup(store)
+	if err != nil {
+		log.Fatalf("Failed to join the cluster: %v", err)
+	}
+	shutdownMQTT := mqtt.Setup()
+	shutdownStream := eventstream.Setup()
+
+	// Stored runtime settings apply on top of the config file
+	collectorMgr := collector.NewCollectorManager(store, cfg.System.CollectInterval, cfg.System.StoreInterval)
+	settingsMgr := settings.NewManager(store, collectorMgr)
+	if err := settingsMgr.Load(context.Background()); err != nil {
+		log.Fatalf("%v", err)
+	}
+	cfg = config.Get()
+	collectorMgr.SetIntervals(cfg.System.CollectInterval, cfg.System.StoreInterval)
+
+	hub := websocket.NewHub()
+	go hub.Run()
+	unsubscribeHub := hub.SubscribeEvents()
+
+	alerts := alerter.NewManager(store)
+	unsubscribeHostRules := alerter.NewRuleEvaluator(store, alerts, cfg.System.CollectInterval).SubscribeEvents()
+	unsubscribeServiceRules := alerter.NewServiceRuleEvaluator(store, alerts).SubscribeEvents()
+
+	metricExporter, err := exporter.NewExporter(cfg.Export)
+	if err != nil {
+		log.Fatalf("Failed to set up metric export: %v", err)
+	}
+	if metricExporter != nil {
+		metricExporter.Start()
+	}
+
+	scheduler := checker.NewScheduler(store)
+	scheduler.SetBroadcastStats(func() int64 { return hub.Stats().DroppedBroadcasts })
+	backupMgr := backup.NewManager(store)
+	archiveMgr := archive.NewManager(store)
+	scheduler.AddJob("backup", cfg.Backup.Schedule, backupMgr.RunScheduled)
+	scheduler.AddJob("archive", cfg.Archive.Schedule, archiveMgr.RunScheduled)
+	if err := scheduler.Start(cfg.Services); err != nil {
+		log.Fatalf("Failed to start scheduler: %v", err)
+	}
+
+	if cfg.System.Enabled {
+		collectorMgr.Register(collector.NewLocalCollector(localHostID))
+		collectorMgr.Rebalance()
+		collectorMgr.Start()
+	}
+
+	reconciler := gitops.NewReconciler(store, scheduler, collectorMgr)
+	reconciler.Start()
+	reloader := reload.NewReloader(scheduler, collectorMgr)
+	reloader.Start()
+
+	app := fiber.New(fiber.Config{
+		ErrorHandler:          apierror.FromError,
+		DisableStartupMessage: true,
+	})
+	// Request contexts end with the request, or at shutdown for requests
+	// still running when the grace period is over
+	requests, cancelRequests := context.WithCancel(context.Background())
+	app.Use(middleware.RequestContext(requests))
+	api.SetupRoutes(app, store, scheduler, collectorMgr, hub, backupMgr, archiveMgr, settingsMgr, reconciler)
+
+	addr := net.JoinHostPort(cfg.Server.Host, strconv.Itoa(cfg.Server.Port))
+	go func() {
+		if err := app.Listen(addr); err != nil {
+			log.Fatalf("Server failed: %v", err)
+		}
+	}()
+	log.Printf("Server listening on %s", addr)
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("Shutting down...")
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+
+	if err := app.ShutdownWithContext(ctx); err != nil {
+		log.Printf("HTTP server shutdown: %v", err)
+	}
+	cancelRequests()
+	reloader.Stop()
+	reconciler.Stop()
+	scheduler.Stop()
+	collectorMgr.Stop()
+	unsubscribeServiceRules()
+	unsubscribeHostRules()
+	unsubscribeHub()
+	if metricExporter != nil {
+		metricExporter.Stop()
+	}
+	// The checks have stopped: give up the cluster lease so another node
+	// takes over at once, then flush what the streams still hold
+	for _, shutdown := range []func(context.Context) error{shutdownCluster, shutdownStream, shutdownMQTT, shutdownTelemetry} {
+		if err := shutdown(ctx); err != nil {
+			log.Printf("Shutdown: %v", err)
+		}
+	}
+	if err := store.Close(); err != nil {
+		log.Printf("Failed to close database: %v", err)
+	}
+}
+
+// openStore opens the configured database, applying pending migrations
+// unless database.autoMigrate is off, in which case it refuses to start on
+// an outdated schema
+func openStore(cfg config.DatabaseConfig) (*database.Store, error) {
+	timeout := time.Duration(cfg.QueryTimeout) * time.Second
+	if !cfg.AutoMigrate {
+		return database.OpenExisting(cfg.Path, timeout)
+	}
+	return database.Open(cfg.Path, timeout)
+}
@@ -0,0 +1,276 @@
+package alerter
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// AlertmanagerReceiver turns Prometheus Alertmanager webhook deliveries
+// into notifications and incidents. Alerts are tracked by fingerprint:
+// Alertmanager re-sends firing alerts on every group update and
+// repeat_interval, and those redeliveries notify only once. An alert whose
+// service label names a known service opens an incident on it, which its
+// resolve closes.
+type AlertmanagerReceiver struct {
+	manager   *Manager
+	alerts    database.ExternalAlertRepository
+	services  database.ServiceRepository
+	incidents database.IncidentRepository
+
+	mu sync.Mutex // deliveries are applied one at a time
+}
+
+// NewAlertmanagerReceiver creates a new Alertmanager receiver
+func NewAlertmanagerReceiver(store *database.Store, manager *Manager) *AlertmanagerReceiver {
+	return &AlertmanagerReceiver{
+		manager:   manager,
+		alerts:    store.ExternalAlerts,
+		services:  store.Services,
+		incidents: store.Incidents,
+	}
+}
+
+// Receive applies a webhook delivery. Alerts are processed independently;
+// the first storage error stops the delivery so Alertmanager retries it,
+// and the alerts already applied are skipped as redeliveries then.
+func (r *AlertmanagerReceiver) Receive(ctx context.Context, payload *models.AlertmanagerWebhook) (*models.AlertmanagerResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := alertmanagerConfig()
+	result := &models.AlertmanagerResult{Received: len(payload.Alerts)}
+	for _, a := range payload.Alerts {
+		if err := r.receiveAlert(ctx, cfg, a, result); err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (r *AlertmanagerReceiver) receiveAlert(ctx context.Context, cfg config.AlertmanagerConfig, a models.AlertmanagerAlert, result *models.AlertmanagerResult) error {
+	fingerprint := a.Fingerprint
+	if fingerprint == "" {
+		fingerprint = labelsFingerprint(a.Labels)
+	}
+	if a.StartsAt.IsZero() {
+		a.StartsAt = time.Now()
+	}
+
+	stored, err := r.alerts.Get(ctx, fingerprint)
+	if err != nil {
+		return err
+	}
+
+	switch a.Status {
+	case models.AlertmanagerFiring:
+		if stored != nil && stored.Status == models.AlertmanagerFiring {
+			if stored.StartsAt.Equal(a.StartsAt) {
+				result.Skipped++
+				return nil
+			}
+			// Same alert firing again after a resolve that never arrived
+			if err := r.resolveIncident(ctx, stored); err != nil {
+				return err
+			}
+		}
+		return r.fire(ctx, cfg, fingerprint, a, result)
+
+	case models.AlertmanagerResolved:
+		if stored == nil || stored.Status != models.AlertmanagerFiring {
+			result.Skipped++
+			return nil
+		}
+		return r.resolve(ctx, cfg, stored, a, result)
+	}
+
+	log.Printf("[Alertmanager] Ignoring alert %s with unknown status %q", fingerprint, a.Status)
+	result.Skipped++
+	return nil
+}
+
+// fire records a newly firing alert, opens an incident on its service and
+// notifies
+func (r *AlertmanagerReceiver) fire(ctx context.Context, cfg config.AlertmanagerConfig, fingerprint string, a models.AlertmanagerAlert, result *models.AlertmanagerResult) error {
+	alert := &models.ExternalAlert{
+		Fingerprint: fingerprint,
+		AlertName:   alertName(a.Labels),
+		Status:      models.AlertmanagerFiring,
+		Severity:    strings.ToLower(a.Labels["severity"]),
+		Labels:      a.Labels,
+		Annotations: a.Annotations,
+		StartsAt:    a.StartsAt,
+	}
+
+	service, err := r.lookupService(ctx, cfg, a.Labels)
+	if err != nil {
+		return err
+	}
+	if service != nil {
+		alert.ServiceID = &service.ID
+		incident := &models.Incident{
+			ServiceID: service.ID,
+			Type:      models.IncidentTypeDegraded,
+			Message:   alertmanagerMessage(alert),
+			StartedAt: a.StartsAt,
+		}
+		if alert.Severity == "critical" {
+			incident.Type = models.IncidentTypeDown
+		}
+		if err := r.incidents.Create(ctx, incident); err != nil {
+			return err
+		}
+		alert.IncidentID = &incident.ID
+		result.Incidents++
+	}
+
+	if err := r.alerts.Save(ctx, alert); err != nil {
+		return err
+	}
+	result.Fired++
+
+	log.Printf("[Alertmanager] FIRING %s [%s] (fingerprint: %s)", alert.AlertName, alert.Severity, fingerprint)
+	r.manager.DispatchToChannels(alertmanagerNotification(alert, service, a.StartsAt), cfg.ChannelIDs)
+	return nil
+}
+
+// resolve closes a firing alert and its incident and notifies the recovery
+func (r *AlertmanagerReceiver) resolve(ctx context.Context, cfg config.AlertmanagerConfig, stored *models.ExternalAlert, a models.AlertmanagerAlert, result *models.AlertmanagerResult) error {
+	endsAt := a.EndsAt
+	if endsAt.IsZero() {
+		endsAt = time.Now()
+	}
+
+	if err := r.resolveIncident(ctx, stored); err != nil {
+		return err
+	}
+	stored.Status = models.AlertmanagerResolved
+	stored.EndsAt = &endsAt
+	if len(a.Annotations) > 0 {
+		stored.Annotations = a.Annotations
+	}
+	if err := r.alerts.Save(ctx, stored); err != nil {
+		return err
+	}
+	result.Resolved++
+
+	var service *models.Service
+	if stored.ServiceID != nil {
+		var err error
+		if service, err = r.services.GetByID(ctx, *stored.ServiceID); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("[Alertmanager] RESOLVED %s (fingerprint: %s)", stored.AlertName, stored.Fingerprint)
+	r.manager.DispatchToChannels(alertmanagerNotification(stored, service, endsAt), cfg.ChannelIDs)
+	return nil
+}
+
+func (r *AlertmanagerReceiver) resolveIncident(ctx context.Context, a *models.ExternalAlert) error {
+	if a.IncidentID == nil {
+		return nil
+	}
+	return r.incidents.ResolveByID(ctx, *a.IncidentID)
+}
+
+// lookupService returns the service named by the configured service label,
+// nil when the label is missing or names no service
+func (r *AlertmanagerReceiver) lookupService(ctx context.Context, cfg config.AlertmanagerConfig, labels map[string]string) (*models.Service, error) {
+	id := labels[cfg.ServiceLabel]
+	if id == "" {
+		return nil, nil
+	}
+	return r.services.GetByID(ctx, id)
+}
+
+// alertmanagerNotification builds the notification for a firing or
+// resolved alert. service may be nil.
+func alertmanagerNotification(a *models.ExternalAlert, service *models.Service, at time.Time) Notification {
+	n := Notification{
+		AlertType: AlertTypeAlertmanager,
+		Status:    models.StatusUnhealthy,
+		Metric:    a.AlertName,
+		Severity:  a.Severity,
+		Message:   alertmanagerMessage(a),
+		Time:      at,
+		Metadata:  make(map[string]interface{}, len(a.Labels)),
+	}
+	if a.Status == models.AlertmanagerResolved {
+		n.Status = models.StatusHealthy
+	}
+	if service != nil {
+		n.ServiceID = service.ID
+		n.ServiceName = service.Name
+	}
+	for k, v := range a.Labels {
+		if k != "alertname" && k != "severity" {
+			n.Metadata[k] = v
+		}
+	}
+	return n
+}
+
+// alertmanagerMessage describes an alert by its summary or description
+// annotation, falling back to the alert name
+func alertmanagerMessage(a *models.ExternalAlert) string {
+	for _, key := range []string{"summary", "description", "message"} {
+		if text := a.Annotations[key]; text != "" {
+			return text
+		}
+	}
+	return a.AlertName
+}
+
+func alertName(labels map[string]string) string {
+	if name := labels["alertname"]; name != "" {
+		return name
+	}
+	return "unnamed"
+}
+
+// labelsFingerprint identifies an alert sent without a fingerprint by its
+// label set, like Alertmanager does
+func labelsFingerprint(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, k := range keys {
+		fmt.Fprintf(h, "%s=%s\x00", k, labels[k])
+	}
+	return fmt.Sprintf("%x", h.Sum(nil)[:8])
+}
+
+// alertmanagerConfig returns the receiver configuration with defaults applied
+func alertmanagerConfig() config.AlertmanagerConfig {
+	var cfg config.AlertmanagerConfig
+	if c := config.Get(); c != nil {
+		cfg = c.Alerts.Alertmanager
+	}
+	if cfg.ServiceLabel == "" {
+		cfg.ServiceLabel = "service"
+	}
+	return cfg
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,87 @@
+package alerter
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// maxReportErrors is the number of errors listed per channel in a delivery
+// report
+const maxReportErrors = 3
+
+// urlPattern matches URLs in delivery errors, which may hold webhook tokens
+var urlPattern = regexp.MustCompile(`https?://[^/\s"]+[^\s"]*`)
+
+// SendDeliveryReport sends the report of the notification deliveries that
+// failed in the last alerts.deliveryReport.window hours, so that a broken
+// channel is noticed before it misses an alert. It sends nothing when no
+// delivery failed.
+func (m *Manager) SendDeliveryReport() {
+	cfg := config.Get()
+	if cfg == nil {
+		return
+	}
+	rc := cfg.Alerts.DeliveryReport
+	window := max(rc.Window, 1)
+
+	until := time.Now()
+	since := until.Add(-time.Duration(window) * time.Hour)
+	failures, err := m.historyRepo.GetFailures(context.Background(), since)
+	if err != nil {
+		log.Printf("Failed to get notification failures: %v", err)
+		return
+	}
+	report := models.NewDeliveryReport(since, until, failures)
+	if report.Failed == 0 {
+		log.Printf("No notification deliveries failed in the last %dh", window)
+		return
+	}
+
+	notification := Notification{
+		AlertType: AlertTypeSystem,
+		Metric:    "notification_failures",
+		Value:     float64(report.Failed),
+		Severity:  "warning",
+		Time:      until,
+	}
+	notification.SetMessage("msg_delivery_report", report.Failed, window, formatDeliveryReport(report))
+
+	var channelIDs []string
+	if rc.ChannelID != "" {
+		channelIDs = []string{rc.ChannelID}
+	}
+	m.DispatchToChannels(notification, channelIDs)
+}
+
+// formatDeliveryReport lists the channels of a report with their most
+// frequent errors, URLs reduced to their host
+func formatDeliveryReport(report *models.DeliveryReport) string {
+	var b strings.Builder
+	for _, ch := range report.Channels {
+		fmt.Fprintf(&b, "\n• %s (%s): %d", ch.ChannelName, ch.ChannelType, ch.Failed)
+		for i, e := range ch.Errors {
+			if i == maxReportErrors {
+				fmt.Fprintf(&b, "\n   …")
+				break
+			}
+			fmt.Fprintf(&b, "\n   %d× %s", e.Count, redactURLs(e.Error))
+		}
+	}
+	return b.String()
+}
+
+// redactURLs replaces the URLs in s with their scheme and host
+func redactURLs(s string) string {
+	return urlPattern.ReplaceAllStringFunc(s, func(u string) string {
+		scheme, rest, _ := strings.Cut(u, "://")
+		host, _, _ := strings.Cut(rest, "/")
+		return scheme + "://" + host + "/…"
+	})
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
@@ -13,12 +14,17 @@ import (
 // DiscordProvider sends alerts to Discord via webhook
 type DiscordProvider struct {
 	WebhookURL string
+	tr         Translator
+	loc        *time.Location
 }
 
-// NewDiscordProvider creates a new Discord provider
-func NewDiscordProvider(webhookURL string) *DiscordProvider {
+// NewDiscordProvider creates a new Discord provider sending in language,
+// with times in timezone
+func NewDiscordProvider(webhookURL, language, timezone string) *DiscordProvider {
 	return &DiscordProvider{
 		WebhookURL: webhookURL,
+		tr:         NewTranslator(language),
+		loc:        location(timezone),
 	}
 }
 
@@ -33,9 +39,14 @@ func (p *DiscordProvider) Send(notification Notification) error {
 		embed = p.buildResourceEmbed(notification)
 	case AlertTypeEndpoint:
 		embed = p.buildEndpointEmbed(notification)
+	case AlertTypeSystem:
+		embed = p.buildSystemEmbed(notification)
+	case AlertTypeAlertmanager:
+		embed = p.buildAlertmanagerEmbed(notification)
 	default:
 		embed = p.buildHealthCheckEmbed(notification)
 	}
+	addEmbedFields(embed, ownershipContext(p.tr, notification))
 
 	payload, err := json.Marshal(embed)
 	if err != nil {
@@ -55,6 +66,23 @@ func (p *DiscordProvider) Send(notification Notification) error {
 	return nil
 }
 
+// addEmbedFields appends label and value pairs to the embed of a payload
+func addEmbedFields(payload map[string]interface{}, pairs [][2]string) {
+	embeds, _ := payload["embeds"].([]map[string]interface{})
+	if len(pairs) == 0 || len(embeds) == 0 {
+		return
+	}
+	fields, _ := embeds[0]["fields"].([]map[string]interface{})
+	for _, f := range pairs {
+		fields = append(fields, map[string]interface{}{
+			"name":   f[0],
+			"value":  f[1],
+			"inline": true,
+		})
+	}
+	embeds[0]["fields"] = fields
+}
+
 // buildHealthCheckEmbed creates a health check Discord embed
 func (p *DiscordProvider) buildHealthCheckEmbed(n Notification) map[string]interface{} {
 	color := 15158332 // Red for DOWN
@@ -64,28 +92,40 @@ func (p *DiscordProvider) buildHealthCheckEmbed(n Notification) map[string]inter
 		statusEmoji = "✅"
 	}
 
+	fields := []map[string]interface{}{
+		{
+			"name":   p.tr.T("label_service_id"),
+			"value":  n.ServiceID,
+			"inline": true,
+		},
+		{
+			"name":   p.tr.T("label_status"),
+			"value":  p.tr.Label("status_", string(n.Status)),
+			"inline": true,
+		},
+	}
+	for _, f := range checkContext(p.tr, n) {
+		fields = append(fields, map[string]interface{}{
+			"name":   f[0],
+			"value":  f[1],
+			"inline": true,
+		})
+	}
+
+	embed := map[string]interface{}{
+		"title":       fmt.Sprintf("%s %s", statusEmoji, p.tr.T("title_service_status", p.tr.Label("status_", string(n.Status)), n.ServiceName)),
+		"description": p.tr.Message(n),
+		"color":       color,
+		"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
+		"fields":      fields,
+	}
+	if n.URL != "" {
+		embed["url"] = n.URL
+	}
+
 	return map[string]interface{}{
 		"username": "MT-Monitor",
-		"embeds": []map[string]interface{}{
-			{
-				"title":       fmt.Sprintf("%s Service %s: %s", statusEmoji, n.Status, n.ServiceName),
-				"description": n.Message,
-				"color":       color,
-				"timestamp":   n.Time.Format("2006-01-02T15:04:05Z07:00"),
-				"fields": []map[string]interface{}{
-					{
-						"name":   "Service ID",
-						"value":  n.ServiceID,
-						"inline": true,
-					},
-					{
-						"name":   "Status",
-						"value":  string(n.Status),
-						"inline": true,
-					},
-				},
-			},
-		},
+		"embeds":   []map[string]interface{}{embed},
 	}
 }
 
@@ -100,12 +140,12 @@ func (p *DiscordProvider) buildLogEmbed(n Notification) map[string]interface{} {
 
 	fields := []map[string]interface{}{
 		{
-			"name":   "Service",
+			"name":   p.tr.T("label_service"),
 			"value":  n.ServiceName,
 			"inline": true,
 		},
 		{
-			"name":   "Level",
+			"name":   p.tr.T("label_level"),
 			"value":  strings.ToUpper(n.LogLevel),
 			"inline": true,
 		},
@@ -124,10 +164,10 @@ func (p *DiscordProvider) buildLogEmbed(n Notification) map[string]interface{} {
 		"username": "MT-Monitor",
 		"embeds": []map[string]interface{}{
 			{
-				"title":       fmt.Sprintf("%s Log Alert [%s] — %s", levelEmoji, strings.ToUpper(n.LogLevel), n.ServiceName),
-				"description": n.Message,
+				"title":       fmt.Sprintf("%s %s [%s] — %s", levelEmoji, p.tr.T("title_log_alert"), strings.ToUpper(n.LogLevel), n.ServiceName),
+				"description": p.tr.Message(n),
 				"color":       color,
-				"timestamp":   n.Time.Format("2006-01-02T15:04:05Z07:00"),
+				"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
 				"fields":      fields,
 			},
 		},
@@ -154,37 +194,41 @@ func (p *DiscordProvider) buildEndpointEmbed(n Notification) map[string]interfac
 	if n.Metric == string(models.AlertMetricResponseTime) {
 		currentValue = fmt.Sprintf("%.0fms", n.Value)
 		thresholdValue = fmt.Sprintf("%.0fms", n.Threshold)
-		metricLabel = "Response Time"
+		metricLabel = p.tr.T("metric_response_time")
 	} else if n.Metric == string(models.AlertMetricHTTPStatus) {
-		metricLabel = "HTTP Status"
+		metricLabel = p.tr.T("metric_http_status")
+	} else if n.Metric == string(models.AlertMetricBurnRate) {
+		currentValue = fmt.Sprintf("%.1fx", n.Value)
+		thresholdValue = fmt.Sprintf("%.1fx", n.Threshold)
+		metricLabel = p.tr.T("metric_burn_rate")
 	}
 
 	return map[string]interface{}{
 		"username": "MT-Monitor",
 		"embeds": []map[string]interface{}{
 			{
-				"title":       fmt.Sprintf("%s Endpoint Alert [%s] — %s", severityEmoji, strings.ToUpper(n.Severity), n.ServiceName),
-				"description": n.Message,
+				"title":       fmt.Sprintf("%s %s [%s] — %s", severityEmoji, p.tr.T("title_endpoint_alert"), strings.ToUpper(p.tr.Label("severity_", n.Severity)), n.ServiceName),
+				"description": p.tr.Message(n),
 				"color":       color,
-				"timestamp":   n.Time.Format("2006-01-02T15:04:05Z07:00"),
+				"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
 				"fields": []map[string]interface{}{
 					{
-						"name":   "Service",
+						"name":   p.tr.T("label_service"),
 						"value":  n.ServiceName,
 						"inline": true,
 					},
 					{
-						"name":   "Metric",
+						"name":   p.tr.T("label_metric"),
 						"value":  metricLabel,
 						"inline": true,
 					},
 					{
-						"name":   "Current",
+						"name":   p.tr.T("label_current"),
 						"value":  currentValue,
 						"inline": true,
 					},
 					{
-						"name":   "Threshold",
+						"name":   p.tr.T("label_threshold"),
 						"value":  thresholdValue,
 						"inline": true,
 					},
@@ -211,34 +255,72 @@ func (p *DiscordProvider) buildResourceEmbed(n Notification) map[string]interfac
 		"username": "MT-Monitor",
 		"embeds": []map[string]interface{}{
 			{
-				"title":       fmt.Sprintf("%s Resource Alert [%s] — %s", severityEmoji, strings.ToUpper(n.Severity), n.HostName),
-				"description": n.Message,
+				"title":       fmt.Sprintf("%s %s [%s] — %s", severityEmoji, p.tr.T("title_resource_alert"), strings.ToUpper(p.tr.Label("severity_", n.Severity)), n.HostName),
+				"description": p.tr.Message(n),
 				"color":       color,
-				"timestamp":   n.Time.Format("2006-01-02T15:04:05Z07:00"),
+				"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
 				"fields": []map[string]interface{}{
 					{
-						"name":   "Host",
+						"name":   p.tr.T("label_host"),
 						"value":  n.HostName,
 						"inline": true,
 					},
 					{
-						"name":   "Metric",
+						"name":   p.tr.T("label_metric"),
 						"value":  strings.ToUpper(n.Metric),
 						"inline": true,
 					},
 					{
-						"name":   "Current",
+						"name":   p.tr.T("label_current"),
 						"value":  fmt.Sprintf("%.1f%%", n.Value),
 						"inline": true,
 					},
 					{
-						"name":   "Threshold",
+						"name":   p.tr.T("label_threshold"),
 						"value":  fmt.Sprintf("%.1f%%", n.Threshold),
 						"inline": true,
 					},
 					{
-						"name":   "Severity",
-						"value":  strings.ToUpper(n.Severity),
+						"name":   p.tr.T("label_severity"),
+						"value":  strings.ToUpper(p.tr.Label("severity_", n.Severity)),
+						"inline": true,
+					},
+				},
+			},
+		},
+	}
+}
+
+// buildSystemEmbed creates an alert embed about the monitoring server itself
+func (p *DiscordProvider) buildSystemEmbed(n Notification) map[string]interface{} {
+	color := 3447003 // Blue for info
+	severityEmoji := "ℹ️"
+	switch strings.ToLower(n.Severity) {
+	case "critical":
+		color = 15158332 // Red
+		severityEmoji = "🔴"
+	case "warning":
+		color = 16776960 // Yellow
+		severityEmoji = "🟡"
+	}
+
+	return map[string]interface{}{
+		"username": "MT-Monitor",
+		"embeds": []map[string]interface{}{
+			{
+				"title":       fmt.Sprintf("%s %s [%s]", severityEmoji, p.tr.T("title_system_alert"), strings.ToUpper(p.tr.Label("severity_", n.Severity))),
+				"description": p.tr.Message(n),
+				"color":       color,
+				"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
+				"fields": []map[string]interface{}{
+					{
+						"name":   p.tr.T("label_metric"),
+						"value":  n.Metric,
+						"inline": true,
+					},
+					{
+						"name":   p.tr.T("label_severity"),
+						"value":  strings.ToUpper(p.tr.Label("severity_", n.Severity)),
 						"inline": true,
 					},
 				},
@@ -246,3 +328,51 @@ func (p *DiscordProvider) buildResourceEmbed(n Notification) map[string]interfac
 		},
 	}
 }
+
+// buildAlertmanagerEmbed creates an embed for an alert received from Prometheus Alertmanager
+func (p *DiscordProvider) buildAlertmanagerEmbed(n Notification) map[string]interface{} {
+	color := 3447003 // Blue for info
+	severityEmoji := "ℹ️"
+	switch strings.ToLower(n.Severity) {
+	case "critical":
+		color = 15158332 // Red
+		severityEmoji = "🔴"
+	case "warning":
+		color = 16776960 // Yellow
+		severityEmoji = "🟡"
+	}
+	title := fmt.Sprintf("%s %s [%s] — %s", severityEmoji, p.tr.T("title_alertmanager"), strings.ToUpper(p.tr.Label("severity_", n.Severity)), n.Metric)
+	if n.Status == models.StatusHealthy {
+		color = 3066993 // Green for resolved
+		title = fmt.Sprintf("✅ %s — %s", p.tr.T("title_alertmanager_resolved"), n.Metric)
+	}
+
+	var fields []map[string]interface{}
+	if n.ServiceName != "" {
+		fields = append(fields, map[string]interface{}{
+			"name":   p.tr.T("label_service"),
+			"value":  n.ServiceName,
+			"inline": true,
+		})
+	}
+	for _, k := range sortedKeys(n.Metadata) {
+		fields = append(fields, map[string]interface{}{
+			"name":   k,
+			"value":  fmt.Sprintf("%v", n.Metadata[k]),
+			"inline": true,
+		})
+	}
+
+	return map[string]interface{}{
+		"username": "MT-Monitor",
+		"embeds": []map[string]interface{}{
+			{
+				"title":       title,
+				"description": p.tr.Message(n),
+				"color":       color,
+				"timestamp":   n.Time.In(p.loc).Format("2006-01-02T15:04:05Z07:00"),
+				"fields":      fields,
+			},
+		},
+	}
+}
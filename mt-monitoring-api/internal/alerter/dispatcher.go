@@ -0,0 +1,200 @@
+package alerter
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/telemetry"
+)
+
+// maxAttempts is the number of times a delivery is tried before it fails
+const maxAttempts = 3
+
+// delivery is a notification on its way to one channel. Its state is kept
+// between attempts so a retry is queued again instead of holding a worker.
+type delivery struct {
+	manager      *Manager
+	channel      models.NotificationChannel
+	notification Notification
+	personal     bool // to the channel of a user, which has no history
+
+	// set by the first attempt
+	provider AlertProvider
+	history  *models.NotificationHistory
+	ctx      context.Context
+	op       *telemetry.Operation
+	attempt  int
+}
+
+// DispatchStats are the counters of the notification worker pool
+type DispatchStats struct {
+	Workers   int   `json:"workers"`
+	QueueSize int   `json:"queueSize"`
+	Queued    int   `json:"queued"`   // deliveries waiting for a worker
+	Active    int64 `json:"active"`   // deliveries being sent
+	Retrying  int64 `json:"retrying"` // deliveries waiting for their next attempt
+	Sent      int64 `json:"sent"`
+	Failed    int64 `json:"failed"`
+	Dropped   int64 `json:"dropped"` // deliveries dropped while the queue was full
+}
+
+// dispatcher is the worker pool delivering notifications. A fixed number of
+// workers take deliveries from a bounded queue; a failed attempt is queued
+// again after its backoff by a timer, so an alert storm queues deliveries
+// instead of spawning sleeping goroutines.
+type dispatcher struct {
+	queue   chan *delivery
+	workers int
+
+	active   atomic.Int64
+	retrying atomic.Int64
+	sent     atomic.Int64
+	failed   atomic.Int64
+	dropped  atomic.Int64
+}
+
+var (
+	pool     *dispatcher
+	poolOnce sync.Once
+)
+
+// deliveries returns the worker pool, started on first use with the
+// alerts.dispatch settings. Every Manager shares it.
+func deliveries() *dispatcher {
+	poolOnce.Do(func() {
+		workers, queueSize := 4, 1000
+		if cfg := config.Get(); cfg != nil {
+			if cfg.Alerts.Dispatch.Workers > 0 {
+				workers = cfg.Alerts.Dispatch.Workers
+			}
+			if cfg.Alerts.Dispatch.QueueSize > 0 {
+				queueSize = cfg.Alerts.Dispatch.QueueSize
+			}
+		}
+		pool = &dispatcher{
+			queue:   make(chan *delivery, queueSize),
+			workers: workers,
+		}
+		for i := 0; i < workers; i++ {
+			go pool.work()
+		}
+		telemetry.Gauge("mt.notification.queue.depth", "Notification deliveries waiting for a worker",
+			func() int64 { return int64(len(pool.queue)) })
+		telemetry.Gauge("mt.notification.retrying", "Notification deliveries waiting for a retry",
+			pool.retrying.Load)
+	})
+	return pool
+}
+
+// QueueStats returns the counters of the notification worker pool
+func QueueStats() DispatchStats {
+	d := deliveries()
+	return DispatchStats{
+		Workers:   d.workers,
+		QueueSize: cap(d.queue),
+		Queued:    len(d.queue),
+		Active:    d.active.Load(),
+		Retrying:  d.retrying.Load(),
+		Sent:      d.sent.Load(),
+		Failed:    d.failed.Load(),
+		Dropped:   d.dropped.Load(),
+	}
+}
+
+// enqueue queues a delivery without blocking, dropping it when the queue
+// is full
+func (d *dispatcher) enqueue(dl *delivery) {
+	select {
+	case d.queue <- dl:
+	default:
+		d.dropped.Add(1)
+		log.Printf("Notification queue full, dropping alert to %s (%s)", dl.channel.Name, dl.channel.Type)
+		dl.finish("failed", "notification queue full")
+	}
+}
+
+func (d *dispatcher) work() {
+	for dl := range d.queue {
+		d.active.Add(1)
+		d.attempt(dl)
+		d.active.Add(-1)
+	}
+}
+
+// attempt sends a delivery once, scheduling the next attempt with
+// exponential backoff (4s, 8s) when it fails
+func (d *dispatcher) attempt(dl *delivery) {
+	if dl.provider == nil {
+		if !dl.start() {
+			return
+		}
+	} else if dl.history != nil && dl.history.ID > 0 {
+		dl.manager.historyRepo.IncrementRetry(dl.ctx, dl.history.ID)
+	}
+
+	err := dl.provider.Send(dl.notification)
+	dl.attempt++
+	if err == nil {
+		log.Printf("Alert sent to %s (%s) for service %s", dl.channel.Name, dl.channel.Type, dl.notification.ServiceName)
+		d.sent.Add(1)
+		dl.finish("sent", "")
+		return
+	}
+
+	log.Printf("Failed to send alert to %s (%s) (attempt %d/%d): %v",
+		dl.channel.Name, dl.channel.Type, dl.attempt, maxAttempts, err)
+	if dl.attempt >= maxAttempts {
+		log.Printf("All retries exhausted for alert to %s (%s): %v", dl.channel.Name, dl.channel.Type, err)
+		d.failed.Add(1)
+		dl.finish("failed", err.Error())
+		return
+	}
+
+	backoff := time.Duration(1<<uint(dl.attempt)) * 2 * time.Second
+	log.Printf("Retrying alert to %s (%s) in %v (attempt %d/%d)",
+		dl.channel.Name, dl.channel.Type, backoff, dl.attempt+1, maxAttempts)
+	d.retrying.Add(1)
+	time.AfterFunc(backoff, func() {
+		d.retrying.Add(-1)
+		d.enqueue(dl)
+	})
+}
+
+// start creates the provider and history record of a delivery before its
+// first attempt, false if the channel cannot be used
+func (dl *delivery) start() bool {
+	provider, err := newProvider(dl.channel)
+	if err != nil {
+		log.Printf("Channel %s: %v", dl.channel.Name, err)
+		return false
+	}
+	dl.provider = provider
+	dl.ctx, dl.op = telemetry.StartNotification(context.Background(), dl.channel.Type, string(dl.notification.AlertType))
+	if dl.personal {
+		return true
+	}
+	dl.history = newHistory(dl.channel, dl.notification)
+	if err := dl.manager.historyRepo.Create(dl.ctx, dl.history); err != nil {
+		log.Printf("Failed to create notification history: %v", err)
+	}
+	return true
+}
+
+// finish records the outcome of a delivery that has started
+func (dl *delivery) finish(status, errMsg string) {
+	if dl.provider == nil {
+		return
+	}
+	if status != "sent" {
+		dl.op.Fail(errMsg)
+	}
+	dl.op.End()
+	if dl.history != nil && dl.history.ID > 0 {
+		dl.manager.historyRepo.UpdateStatus(dl.ctx, dl.history.ID, status, errMsg)
+	}
+}
@@ -0,0 +1,132 @@
+package alerter
+
+import (
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// EmailProvider sends alerts by email through the alerts.channels.email
+// SMTP server
+type EmailProvider struct {
+	To  string
+	tr  Translator
+	loc *time.Location
+}
+
+// NewEmailProvider creates a new email provider sending to to in language,
+// with times in timezone
+func NewEmailProvider(to, language, timezone string) *EmailProvider {
+	return &EmailProvider{
+		To:  to,
+		tr:  NewTranslator(language),
+		loc: location(timezone),
+	}
+}
+
+// Send sends a notification as a plain-text email
+func (p *EmailProvider) Send(notification Notification) error {
+	cfg := config.Get()
+	if cfg == nil || cfg.Alerts.Channels.Email.SMTP.Host == "" {
+		return errors.New("alerts.channels.email.smtp is not configured")
+	}
+	server := cfg.Alerts.Channels.Email.SMTP
+	from := cfg.Alerts.Channels.Email.From
+	if from == "" {
+		from = server.Username
+	}
+	if from == "" {
+		return errors.New("alerts.channels.email.from is not configured")
+	}
+
+	msg := "From: " + from + "\r\n" +
+		"To: " + p.To + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", p.subject(notification)) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + p.body(notification)
+
+	var auth smtp.Auth
+	if server.Username != "" {
+		auth = smtp.PlainAuth("", server.Username, server.Password, server.Host)
+	}
+	addr := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+	if err := smtp.SendMail(addr, auth, from, []string{p.To}, []byte(msg)); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+	return nil
+}
+
+// subject returns e.g. "[Critical] Resource Alert: web-1"
+func (p *EmailProvider) subject(n Notification) string {
+	var title string
+	switch n.AlertType {
+	case AlertTypeLog:
+		title = p.tr.T("title_log_alert")
+	case AlertTypeResource:
+		title = p.tr.T("title_resource_alert")
+	case AlertTypeEndpoint:
+		title = p.tr.T("title_endpoint_alert")
+	case AlertTypeSystem:
+		title = p.tr.T("title_system_alert")
+	case AlertTypeAlertmanager:
+		title = p.tr.T("title_alertmanager")
+	default:
+		title = p.tr.T("title_service_down")
+		if n.Status == models.StatusHealthy {
+			title = p.tr.T("title_service_recovered")
+		}
+	}
+	if n.Severity != "" {
+		title = "[" + p.tr.Label("severity_", n.Severity) + "] " + title
+	}
+	if n.ServiceName != "" {
+		return title + ": " + n.ServiceName
+	}
+	if n.HostName != "" {
+		return title + ": " + n.HostName
+	}
+	return title
+}
+
+func (p *EmailProvider) body(n Notification) string {
+	var body strings.Builder
+	fmt.Fprintf(&body, "%s\r\n\r\n", p.tr.Message(n))
+
+	line := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(&body, "%s: %s\r\n", p.tr.T(label), value)
+		}
+	}
+	line("label_service", n.ServiceName)
+	line("label_host", n.HostName)
+	if n.Metric != "" {
+		line("label_metric", strings.ToUpper(n.Metric))
+		line("label_current", strconv.FormatFloat(n.Value, 'f', -1, 64))
+		line("label_threshold", strconv.FormatFloat(n.Threshold, 'f', -1, 64))
+	}
+	if n.Severity != "" {
+		line("label_severity", p.tr.Label("severity_", n.Severity))
+	}
+	line("label_level", strings.ToUpper(n.LogLevel))
+	line("label_time", n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"))
+	for _, f := range checkContext(p.tr, n) {
+		fmt.Fprintf(&body, "%s: %s\r\n", f[0], f[1])
+	}
+	for _, f := range ownershipContext(p.tr, n) {
+		fmt.Fprintf(&body, "%s: %s\r\n", f[0], f[1])
+	}
+	if n.URL != "" {
+		fmt.Fprintf(&body, "\r\n%s: %s\r\n", p.tr.T("label_service_page"), n.URL)
+	}
+	return body.String()
+}
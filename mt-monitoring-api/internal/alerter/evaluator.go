@@ -1,42 +1,50 @@
 package alerter
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"strings"
 	"sync"
 	"time"
 
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/events"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // RuleEvaluator evaluates alert rules against incoming metrics.
 type RuleEvaluator struct {
 	manager         *Manager
-	repo            *database.AlertRuleRepository
-	stateRepo       *database.AlertRuleStateRepository
+	repo            database.AlertRuleRepository
+	stateRepo       database.AlertRuleStateRepository
+	remediator      *Remediator
 	collectInterval int // seconds
 
 	mu           sync.Mutex
 	breachCounts map[string]int       // ruleKey → consecutive breach count
 	lastAlerted  map[string]time.Time // ruleKey → last alert time (for cooldown)
 	wasAlerting  map[string]bool      // ruleKey → whether an alert was fired (for recovery)
+	lastValues   map[string]float64   // ruleKey → last evaluated value
+	breachSince  map[string]time.Time // ruleKey → start of the current breach
 }
 
 // NewRuleEvaluator creates a new evaluator.
-func NewRuleEvaluator(manager *Manager, collectInterval int) *RuleEvaluator {
+func NewRuleEvaluator(store *database.Store, manager *Manager, collectInterval int) *RuleEvaluator {
 	if collectInterval <= 0 {
 		collectInterval = 5
 	}
 	evaluator := &RuleEvaluator{
 		manager:         manager,
-		repo:            database.NewAlertRuleRepository(),
-		stateRepo:       database.NewAlertRuleStateRepository(),
+		repo:            store.AlertRules,
+		stateRepo:       store.AlertRuleStates,
+		remediator:      NewRemediator(store),
 		collectInterval: collectInterval,
 		breachCounts:    make(map[string]int),
 		lastAlerted:     make(map[string]time.Time),
 		wasAlerting:     make(map[string]bool),
+		lastValues:      make(map[string]float64),
+		breachSince:     make(map[string]time.Time),
 	}
 
 	// Load persisted state
@@ -45,36 +53,158 @@ func NewRuleEvaluator(manager *Manager, collectInterval int) *RuleEvaluator {
 	return evaluator
 }
 
+// SubscribeEvents evaluates the rules against every collected host metric,
+// ping round, updates check and ports check until unsubscribe is called. Each evaluation runs on its
+// own goroutine so collection never waits for rule lookups or alerts.
+func (e *RuleEvaluator) SubscribeEvents() (unsubscribe func()) {
+	unsubMetric := events.MetricCollected.Subscribe(func(m events.SystemMetric) {
+		go e.Evaluate(m.HostID, m.HostName, m.Metric, m.Interval)
+	})
+	unsubPing := events.HostPinged.Subscribe(func(p events.Ping) {
+		go e.EvaluatePing(p.HostID, p.HostName, p.Result)
+	})
+	unsubUpdates := events.UpdatesChecked.Subscribe(func(u events.Updates) {
+		go e.EvaluateUpdates(u.HostID, u.HostName, u.Updates)
+	})
+	unsubPorts := events.PortsChecked.Subscribe(func(p events.Ports) {
+		go e.EvaluatePorts(p.HostID, p.HostName, p.Ports)
+	})
+	return func() {
+		unsubMetric()
+		unsubPing()
+		unsubUpdates()
+		unsubPorts()
+	}
+}
+
 // Evaluate checks all enabled rules for a host against the given metric snapshot.
-// This is called by CollectorManager after each metric collection.
-func (e *RuleEvaluator) Evaluate(hostID, hostName string, metric *models.SystemMetric) {
+// This is called for each metric.collected event. interval is the collection
+// interval of the host in seconds, 0 for the global one.
+func (e *RuleEvaluator) Evaluate(hostID, hostName string, metric *models.SystemMetric, interval int) {
 	if metric == nil {
 		return
 	}
+	if interval <= 0 {
+		interval = e.collectInterval
+	}
+
+	rules, err := e.repo.GetEnabledByHostID(context.Background(), hostID)
+	if err != nil {
+		log.Printf("[Evaluator] Failed to get rules for host %s: %v", hostID, err)
+		return
+	}
+
+	for _, rule := range rules {
+		switch rule.Metric {
+		case models.AlertMetricPacketLoss:
+			continue // evaluated per ping round
+		case models.AlertMetricSecurityUpdates:
+			continue // evaluated per updates check
+		case models.AlertMetricPortChange:
+			continue // evaluated per ports check
+		}
+		e.evaluateRule(rule, hostID, hostName, extractMetricValue(rule.Metric, metric), "", interval)
+	}
+}
+
+// EvaluatePing checks the packet loss rules of a host against a ping round.
+// This is called for each host.pinged event.
+func (e *RuleEvaluator) EvaluatePing(hostID, hostName string, result *models.PingResult) {
+	if result == nil {
+		return
+	}
+
+	rules, err := e.repo.GetEnabledByHostID(context.Background(), hostID)
+	if err != nil {
+		log.Printf("[Evaluator] Failed to get rules for host %s: %v", hostID, err)
+		return
+	}
+
+	interval := 30
+	if cfg := config.Get(); cfg != nil && cfg.System.Ping.Interval > 0 {
+		interval = cfg.System.Ping.Interval
+	}
+	for _, rule := range rules {
+		if rule.Metric == models.AlertMetricPacketLoss {
+			e.evaluateRule(rule, hostID, hostName, result.Loss, "", interval)
+		}
+	}
+}
+
+// EvaluateUpdates checks the security updates rules of a host against an
+// updates check. This is called for each host.updates_checked event; checks
+// are hours apart, so a single breach fires the alert.
+func (e *RuleEvaluator) EvaluateUpdates(hostID, hostName string, updates *models.SecurityUpdates) {
+	if updates == nil {
+		return
+	}
+
+	rules, err := e.repo.GetEnabledByHostID(context.Background(), hostID)
+	if err != nil {
+		log.Printf("[Evaluator] Failed to get rules for host %s: %v", hostID, err)
+		return
+	}
+
+	interval := 6 * 3600
+	if cfg := config.Get(); cfg != nil && cfg.System.SecurityUpdates.Interval > 0 {
+		interval = cfg.System.SecurityUpdates.Interval * 3600
+	}
+	for _, rule := range rules {
+		if rule.Metric == models.AlertMetricSecurityUpdates {
+			e.evaluateRule(rule, hostID, hostName, float64(updates.Security), "", interval)
+		}
+	}
+}
+
+// EvaluatePorts checks the port change rules of a host against a ports
+// check. This is called for each host.ports_checked event; the rule value is
+// the number of unexpected and missing ports.
+func (e *RuleEvaluator) EvaluatePorts(hostID, hostName string, ports *models.HostPorts) {
+	if ports == nil {
+		return
+	}
 
-	rules, err := e.repo.GetEnabledByHostID(hostID)
+	rules, err := e.repo.GetEnabledByHostID(context.Background(), hostID)
 	if err != nil {
 		log.Printf("[Evaluator] Failed to get rules for host %s: %v", hostID, err)
 		return
 	}
 
+	interval := 300
+	if cfg := config.Get(); cfg != nil && cfg.System.Ports.Interval > 0 {
+		interval = cfg.System.Ports.Interval
+	}
+	changes := make([]string, 0, len(ports.Unexpected)+len(ports.Missing))
+	for _, key := range ports.Unexpected {
+		changes = append(changes, "+"+key)
+	}
+	for _, key := range ports.Missing {
+		changes = append(changes, "-"+key)
+	}
 	for _, rule := range rules {
-		e.evaluateRule(rule, hostID, hostName, metric)
+		if rule.Metric == models.AlertMetricPortChange {
+			e.evaluateRule(rule, hostID, hostName, float64(len(changes)), strings.Join(changes, " "), interval)
+		}
 	}
 }
 
-// evaluateRule evaluates a single rule against the metric.
-func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName string, metric *models.SystemMetric) {
-	value := extractMetricValue(rule.Metric, metric)
+// evaluateRule evaluates a single rule against a value sampled every
+// interval seconds. detail describes the value in the alert message of
+// port change rules.
+func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName string, value float64, detail string, interval int) {
 	breached := compareValue(value, rule.Operator, rule.Threshold)
 	ruleKey := e.ruleKey(rule.ID, hostID)
 
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.lastValues[ruleKey] = value
 	if breached {
+		if _, ok := e.breachSince[ruleKey]; !ok {
+			e.breachSince[ruleKey] = time.Now()
+		}
 		e.breachCounts[ruleKey]++
-		requiredCount := (rule.Duration * 60) / e.collectInterval
+		requiredCount := (rule.Duration * 60) / interval
 		if requiredCount < 1 {
 			requiredCount = 1
 		}
@@ -83,7 +213,9 @@ func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName str
 			// Check cooldown
 			if last, ok := e.lastAlerted[ruleKey]; ok {
 				if time.Since(last) < time.Duration(rule.Cooldown)*time.Second {
-					return // Still in cooldown
+					// Still in cooldown; persist the value of the ongoing breach
+					go e.SaveState(rule.ID, hostID)
+					return
 				}
 			}
 
@@ -99,15 +231,25 @@ func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName str
 				Value:     value,
 				Threshold: rule.Threshold,
 				Severity:  string(rule.Severity),
-				Message: fmt.Sprintf("%s usage %.1f%% exceeds threshold %.1f%% for %d min on %s",
-					strings.ToUpper(string(rule.Metric)), value, rule.Threshold, rule.Duration, hostName),
-				Time: time.Now(),
+				Time:      time.Now(),
+			}
+			switch rule.Metric {
+			case models.AlertMetricPacketLoss:
+				notification.SetMessage("msg_packet_loss_alert", value, rule.Threshold, rule.Duration, hostName)
+			case models.AlertMetricSecurityUpdates:
+				notification.SetMessage("msg_updates_alert", value, rule.Threshold, hostName)
+			case models.AlertMetricPortChange:
+				notification.SetMessage("msg_ports_alert", hostName, detail)
+			default:
+				notification.SetMessage("msg_resource_alert",
+					strings.ToUpper(string(rule.Metric)), value, rule.Threshold, rule.Duration, hostName)
 			}
 
 			log.Printf("[Evaluator] ALERT %s: %s %.1f%% > %.1f%% (host: %s, rule: %s)",
 				rule.Severity, rule.Metric, value, rule.Threshold, hostName, rule.Name)
 
 			go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
+			go e.remediator.Run(rule, notification)
 
 			// Persist state after firing alert
 			go e.SaveState(rule.ID, hostID)
@@ -129,9 +271,18 @@ func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName str
 				Value:     value,
 				Threshold: rule.Threshold,
 				Severity:  "info",
-				Message: fmt.Sprintf("%s usage recovered to %.1f%% (threshold: %.1f%%) on %s",
-					strings.ToUpper(string(rule.Metric)), value, rule.Threshold, hostName),
-				Time: time.Now(),
+				Time:      time.Now(),
+			}
+			switch rule.Metric {
+			case models.AlertMetricPacketLoss:
+				notification.SetMessage("msg_packet_loss_recovered", value, rule.Threshold, hostName)
+			case models.AlertMetricSecurityUpdates:
+				notification.SetMessage("msg_updates_recovered", value, rule.Threshold, hostName)
+			case models.AlertMetricPortChange:
+				notification.SetMessage("msg_ports_recovered", hostName)
+			default:
+				notification.SetMessage("msg_resource_recovered",
+					strings.ToUpper(string(rule.Metric)), value, rule.Threshold, hostName)
 			}
 
 			log.Printf("[Evaluator] RECOVERED: %s %.1f%% < %.1f%% (host: %s, rule: %s)",
@@ -140,6 +291,7 @@ func (e *RuleEvaluator) evaluateRule(rule models.AlertRule, hostID, hostName str
 			go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
 		}
 		e.breachCounts[ruleKey] = 0
+		delete(e.breachSince, ruleKey)
 
 		// Persist reset state
 		go e.SaveState(rule.ID, hostID)
@@ -156,16 +308,18 @@ func (e *RuleEvaluator) ResetRule(ruleID string) {
 			delete(e.breachCounts, key)
 			delete(e.lastAlerted, key)
 			delete(e.wasAlerting, key)
+			delete(e.lastValues, key)
+			delete(e.breachSince, key)
 		}
 	}
 
 	// Also delete from database
-	e.stateRepo.DeleteByRule(ruleID)
+	e.stateRepo.DeleteByRule(context.Background(), ruleID)
 }
 
-// LoadState loads persisted state from database on startup
+// LoadState loads the persisted host states from database on startup
 func (e *RuleEvaluator) LoadState() {
-	states, err := e.stateRepo.GetAll()
+	states, err := e.stateRepo.GetAllByType(context.Background(), models.AlertTargetHost)
 	if err != nil {
 		log.Printf("[Evaluator] Failed to load persisted state: %v", err)
 		return
@@ -175,12 +329,16 @@ func (e *RuleEvaluator) LoadState() {
 	defer e.mu.Unlock()
 
 	for _, state := range states {
-		key := e.ruleKey(state.RuleID, state.HostID)
+		key := e.ruleKey(state.RuleID, state.TargetID)
 		e.breachCounts[key] = state.BreachCount
 		if state.LastAlertedAt != nil {
 			e.lastAlerted[key] = *state.LastAlertedAt
 		}
 		e.wasAlerting[key] = state.IsAlerting
+		e.lastValues[key] = state.Value
+		if state.BreachStartedAt != nil {
+			e.breachSince[key] = *state.BreachStartedAt
+		}
 	}
 
 	log.Printf("[Evaluator] Loaded %d persisted alert states", len(states))
@@ -190,18 +348,24 @@ func (e *RuleEvaluator) LoadState() {
 func (e *RuleEvaluator) SaveState(ruleID, hostID string) {
 	key := e.ruleKey(ruleID, hostID)
 
+	e.mu.Lock()
 	state := &models.AlertRuleState{
 		RuleID:      ruleID,
-		HostID:      hostID,
+		TargetType:  models.AlertTargetHost,
+		TargetID:    hostID,
 		BreachCount: e.breachCounts[key],
 		IsAlerting:  e.wasAlerting[key],
+		Value:       e.lastValues[key],
 	}
-
 	if lastAlerted, ok := e.lastAlerted[key]; ok {
 		state.LastAlertedAt = &lastAlerted
 	}
+	if since, ok := e.breachSince[key]; ok {
+		state.BreachStartedAt = &since
+	}
+	e.mu.Unlock()
 
-	if err := e.stateRepo.SaveState(state); err != nil {
+	if err := e.stateRepo.SaveState(context.Background(), state); err != nil {
 		log.Printf("[Evaluator] Failed to save state for %s: %v", key, err)
 	}
 }
@@ -0,0 +1,340 @@
+package alerter
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// DefaultLanguage is the language notifications fall back to
+const DefaultLanguage = "en"
+
+// messages is the built-in message catalog by language and key. Values are
+// fmt formats; translations may reorder arguments with indexed verbs such
+// as %[2]s or %.1[3]f.
+var messages = map[string]map[string]string{
+	"en": {
+		"title_service_status":        "Service %s: %s",
+		"title_service_down":          "Service Down",
+		"title_service_recovered":     "Service Recovered",
+		"title_log_alert":             "Log Alert",
+		"title_endpoint_alert":        "Endpoint Alert",
+		"title_resource_alert":        "Resource Alert",
+		"title_system_alert":          "System Alert",
+		"title_alertmanager":          "Alertmanager",
+		"title_alertmanager_resolved": "Alertmanager Resolved",
+		"label_service":               "Service",
+		"label_service_id":            "Service ID",
+		"label_status":                "Status",
+		"label_level":                 "Level",
+		"label_host":                  "Host",
+		"label_metric":                "Metric",
+		"label_current":               "Current",
+		"label_threshold":             "Threshold",
+		"label_severity":              "Severity",
+		"label_time":                  "Time",
+		"label_message":               "Message",
+		"label_metadata":              "Metadata",
+		"label_labels":                "Labels",
+		"label_alert":                 "Alert",
+		"label_uptime_24h":            "Uptime (24h)",
+		"label_downtime":              "Downtime",
+		"label_team":                  "Team",
+		"label_owner":                 "Owner",
+		"label_contact":               "Contact",
+		"label_escalation":            "Escalation",
+		"label_service_page":          "Open service",
+		"severity_critical":           "Critical",
+		"severity_warning":            "Warning",
+		"severity_info":               "Info",
+		"severity_resolved":           "Resolved",
+		"status_healthy":              "healthy",
+		"status_unhealthy":            "unhealthy",
+		"metric_response_time":        "Response Time",
+		"metric_burn_rate":            "Burn Rate",
+		"metric_http_status":          "HTTP Status",
+		"msg_service_healthy":         "Service is healthy",
+		"msg_resource_alert":          "%s usage %.1f%% exceeds threshold %.1f%% for %d min on %s",
+		"msg_resource_recovered":      "%s usage recovered to %.1f%% (threshold: %.1f%%) on %s",
+		"msg_packet_loss_alert":       "Packet loss %.1f%% exceeds threshold %.1f%% for %d min on %s",
+		"msg_packet_loss_recovered":   "Packet loss recovered to %.1f%% (threshold: %.1f%%) on %s",
+		"msg_updates_alert":           "%.0f pending security updates exceed threshold %.0f on %s",
+		"msg_updates_recovered":       "Pending security updates down to %.0f (threshold: %.0f) on %s",
+		"msg_ports_alert":             "Listening ports on %s differ from the expected ports: %s",
+		"msg_ports_recovered":         "Listening ports on %s match the expected ports again",
+		"msg_http_status_alert":       "HTTP %d response on %s (threshold: %s %.0f)",
+		"msg_response_time_alert":     "Response time %.0fms on %s exceeds threshold %s %.0fms",
+		"msg_endpoint_alert":          "Endpoint alert on %s: %.0f %s %.0f",
+		"msg_http_status_recovered":   "HTTP response recovered to %d on %s",
+		"msg_response_time_recovered": "Response time recovered to %.0fms on %s",
+		"msg_endpoint_recovered":      "Endpoint metric recovered on %s: %.0f",
+		"msg_slo_burn_alert":          "Error budget of %s (objective %.2f%%) burning %.1fx over %d min and %.1fx over %d min (threshold: %.1fx)",
+		"msg_slo_burn_recovered":      "Error budget burn of %s back to %.1fx over %d min (threshold: %.1fx)",
+		"msg_db_size_alert":           "Database size %.1f MB exceeds limit of %.0f MB",
+		"msg_db_size_recovered":       "Database size back to %.1f MB (limit %.0f MB)",
+		"msg_log_rule_alert":          "Log rule %s matched %d times in %d min on %s: %s",
+		"msg_delivery_report":         "%d notification deliveries failed in the last %d hours:%s",
+		"msg_test":                    "This is a test notification from MT-Monitor",
+	},
+	"ko": {
+		"title_service_status":        "서비스 %s: %s",
+		"title_service_down":          "서비스 장애",
+		"title_service_recovered":     "서비스 복구",
+		"title_log_alert":             "로그 알림",
+		"title_endpoint_alert":        "엔드포인트 알림",
+		"title_resource_alert":        "리소스 알림",
+		"title_system_alert":          "시스템 알림",
+		"title_alertmanager":          "Alertmanager",
+		"title_alertmanager_resolved": "Alertmanager 해결됨",
+		"label_service":               "서비스",
+		"label_service_id":            "서비스 ID",
+		"label_status":                "상태",
+		"label_level":                 "레벨",
+		"label_host":                  "호스트",
+		"label_metric":                "메트릭",
+		"label_current":               "현재값",
+		"label_threshold":             "임계값",
+		"label_severity":              "심각도",
+		"label_time":                  "시간",
+		"label_message":               "메시지",
+		"label_metadata":              "메타데이터",
+		"label_labels":                "레이블",
+		"label_alert":                 "알림",
+		"label_uptime_24h":            "가동률 (24시간)",
+		"label_downtime":              "장애 시간",
+		"label_team":                  "담당 팀",
+		"label_owner":                 "담당자",
+		"label_contact":               "연락처",
+		"label_escalation":            "에스컬레이션",
+		"label_service_page":          "서비스 보기",
+		"severity_critical":           "심각",
+		"severity_warning":            "경고",
+		"severity_info":               "정보",
+		"severity_resolved":           "해결됨",
+		"status_healthy":              "정상",
+		"status_unhealthy":            "장애",
+		"metric_response_time":        "응답 시간",
+		"metric_burn_rate":            "소진 속도",
+		"metric_http_status":          "HTTP 상태",
+		"msg_service_healthy":         "서비스가 정상입니다",
+		"msg_resource_alert":          "%[5]s의 %[1]s 사용률 %.1[2]f%%가 %[4]d분 동안 임계값 %.1[3]f%%를 넘었습니다",
+		"msg_resource_recovered":      "%[4]s의 %[1]s 사용률이 %.1[2]f%%로 회복되었습니다 (임계값: %.1[3]f%%)",
+		"msg_packet_loss_alert":       "%[4]s의 패킷 손실 %.1[1]f%%가 %[3]d분 동안 임계값 %.1[2]f%%를 넘었습니다",
+		"msg_packet_loss_recovered":   "%[3]s의 패킷 손실이 %.1[1]f%%로 회복되었습니다 (임계값: %.1[2]f%%)",
+		"msg_updates_alert":           "%[3]s에 대기 중인 보안 업데이트 %.0[1]f건이 임계값 %.0[2]f건을 넘었습니다",
+		"msg_updates_recovered":       "%[3]s의 대기 중인 보안 업데이트가 %.0[1]f건으로 줄었습니다 (임계값: %.0[2]f건)",
+		"msg_ports_alert":             "%s의 대기 포트가 예상과 다릅니다: %s",
+		"msg_ports_recovered":         "%s의 대기 포트가 다시 예상과 같습니다",
+		"msg_http_status_alert":       "%[2]s에서 HTTP %[1]d 응답 (임계값: %[3]s %.0[4]f)",
+		"msg_response_time_alert":     "%[2]s의 응답 시간 %.0[1]fms가 임계값 %[3]s %.0[4]fms를 넘었습니다",
+		"msg_endpoint_alert":          "%s 엔드포인트 알림: %.0f %s %.0f",
+		"msg_http_status_recovered":   "%[2]s의 HTTP 응답이 %[1]d로 회복되었습니다",
+		"msg_response_time_recovered": "%[2]s의 응답 시간이 %.0[1]fms로 회복되었습니다",
+		"msg_endpoint_recovered":      "%s의 엔드포인트 메트릭이 회복되었습니다: %.0f",
+		"msg_slo_burn_alert":          "%[1]s의 오류 예산(목표 %.2[2]f%%)이 %[4]d분 동안 %.1[3]fx, %[6]d분 동안 %.1[5]fx 속도로 소진되고 있습니다 (임계값: %.1[7]fx)",
+		"msg_slo_burn_recovered":      "%[1]s의 오류 예산 소진 속도가 %[3]d분 기준 %.1[2]fx로 돌아왔습니다 (임계값: %.1[4]fx)",
+		"msg_db_size_alert":           "데이터베이스 크기 %.1f MB가 한도 %.0f MB를 넘었습니다",
+		"msg_db_size_recovered":       "데이터베이스 크기가 %.1f MB로 돌아왔습니다 (한도 %.0f MB)",
+		"msg_log_rule_alert":          "%[4]s에서 로그 규칙 %[1]s이(가) %[3]d분 동안 %[2]d번 일치했습니다: %[5]s",
+		"msg_delivery_report":         "최근 %[2]d시간 동안 알림 전송 %[1]d건이 실패했습니다:%[3]s",
+		"msg_test":                    "MT-Monitor 테스트 알림입니다",
+	},
+	"ja": {
+		"title_service_status":        "サービス %s: %s",
+		"title_service_down":          "サービス障害",
+		"title_service_recovered":     "サービス復旧",
+		"title_log_alert":             "ログアラート",
+		"title_endpoint_alert":        "エンドポイントアラート",
+		"title_resource_alert":        "リソースアラート",
+		"title_system_alert":          "システムアラート",
+		"title_alertmanager":          "Alertmanager",
+		"title_alertmanager_resolved": "Alertmanager 解決",
+		"label_service":               "サービス",
+		"label_service_id":            "サービスID",
+		"label_status":                "ステータス",
+		"label_level":                 "レベル",
+		"label_host":                  "ホスト",
+		"label_metric":                "メトリクス",
+		"label_current":               "現在値",
+		"label_threshold":             "しきい値",
+		"label_severity":              "重大度",
+		"label_time":                  "時刻",
+		"label_message":               "メッセージ",
+		"label_metadata":              "メタデータ",
+		"label_labels":                "ラベル",
+		"label_alert":                 "アラート",
+		"label_uptime_24h":            "稼働率 (24時間)",
+		"label_downtime":              "ダウンタイム",
+		"label_team":                  "担当チーム",
+		"label_owner":                 "担当者",
+		"label_contact":               "連絡先",
+		"label_escalation":            "エスカレーション",
+		"label_service_page":          "サービスを開く",
+		"severity_critical":           "重大",
+		"severity_warning":            "警告",
+		"severity_info":               "情報",
+		"severity_resolved":           "解決",
+		"status_healthy":              "正常",
+		"status_unhealthy":            "異常",
+		"metric_response_time":        "応答時間",
+		"metric_burn_rate":            "バーンレート",
+		"metric_http_status":          "HTTPステータス",
+		"msg_service_healthy":         "サービスは正常です",
+		"msg_resource_alert":          "%[5]s の %[1]s 使用率 %.1[2]f%% が %[4]d 分間しきい値 %.1[3]f%% を超えています",
+		"msg_resource_recovered":      "%[4]s の %[1]s 使用率が %.1[2]f%% に回復しました (しきい値: %.1[3]f%%)",
+		"msg_packet_loss_alert":       "%[4]s のパケットロス %.1[1]f%% が %[3]d 分間しきい値 %.1[2]f%% を超えています",
+		"msg_packet_loss_recovered":   "%[3]s のパケットロスが %.1[1]f%% に回復しました (しきい値: %.1[2]f%%)",
+		"msg_updates_alert":           "%[3]s の保留中のセキュリティ更新 %.0[1]f 件がしきい値 %.0[2]f 件を超えています",
+		"msg_updates_recovered":       "%[3]s の保留中のセキュリティ更新が %.0[1]f 件に減りました (しきい値: %.0[2]f 件)",
+		"msg_ports_alert":             "%s の待ち受けポートが想定と異なります: %s",
+		"msg_ports_recovered":         "%s の待ち受けポートが想定どおりに戻りました",
+		"msg_http_status_alert":       "%[2]s で HTTP %[1]d 応答 (しきい値: %[3]s %.0[4]f)",
+		"msg_response_time_alert":     "%[2]s の応答時間 %.0[1]fms がしきい値 %[3]s %.0[4]fms を超えています",
+		"msg_endpoint_alert":          "%s のエンドポイントアラート: %.0f %s %.0f",
+		"msg_http_status_recovered":   "%[2]s の HTTP 応答が %[1]d に回復しました",
+		"msg_response_time_recovered": "%[2]s の応答時間が %.0[1]fms に回復しました",
+		"msg_endpoint_recovered":      "%s のエンドポイントメトリクスが回復しました: %.0f",
+		"msg_slo_burn_alert":          "%[1]s のエラーバジェット (目標 %.2[2]f%%) が %[4]d 分間 %.1[3]fx、%[6]d 分間 %.1[5]fx の速さで消費されています (しきい値: %.1[7]fx)",
+		"msg_slo_burn_recovered":      "%[1]s のエラーバジェット消費速度が %[3]d 分間で %.1[2]fx に戻りました (しきい値: %.1[4]fx)",
+		"msg_db_size_alert":           "データベースサイズ %.1f MB が上限 %.0f MB を超えています",
+		"msg_db_size_recovered":       "データベースサイズが %.1f MB に戻りました (上限 %.0f MB)",
+		"msg_log_rule_alert":          "%[4]s でログルール %[1]s が %[3]d 分間に %[2]d 回一致しました: %[5]s",
+		"msg_delivery_report":         "直近%[2]d時間で通知の送信が%[1]d件失敗しました:%[3]s",
+		"msg_test":                    "MT-Monitor からのテスト通知です",
+	},
+}
+
+// Translator renders catalog messages in a language. Custom translations
+// from alerts.translations take precedence over the built-in catalog, and
+// missing messages fall back to the base language (ko for ko-KR), then
+// alerts.language, then English.
+type Translator struct {
+	languages []string // lookup order
+}
+
+// NewTranslator creates a translator for language, alerts.language when
+// empty
+func NewTranslator(language string) Translator {
+	var fallback string
+	if cfg := config.Get(); cfg != nil {
+		fallback = normalizeLanguage(cfg.Alerts.Language)
+	}
+	var languages []string
+	add := func(lang string) {
+		if lang == "" {
+			return
+		}
+		for _, l := range languages {
+			if l == lang {
+				return
+			}
+		}
+		languages = append(languages, lang)
+	}
+	for _, lang := range []string{normalizeLanguage(language), fallback, DefaultLanguage} {
+		add(lang)
+		if base, _, ok := strings.Cut(lang, "-"); ok {
+			add(base)
+		}
+	}
+	return Translator{languages: languages}
+}
+
+// T returns the message of key formatted with args, the key itself if no
+// language has it
+func (t Translator) T(key string, args ...interface{}) string {
+	format, ok := t.lookup(key)
+	if !ok {
+		return key
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// Label returns the message of prefix+value (e.g. severity_critical),
+// value itself if there is none
+func (t Translator) Label(prefix, value string) string {
+	if text, ok := t.lookup(prefix + strings.ToLower(value)); ok {
+		return text
+	}
+	return value
+}
+
+// Message returns the message of a notification: its catalog message when
+// it has one, Message otherwise
+func (t Translator) Message(n Notification) string {
+	if n.MessageKey == "" {
+		return n.Message
+	}
+	if _, ok := t.lookup(n.MessageKey); !ok {
+		return n.Message
+	}
+	return t.T(n.MessageKey, n.MessageArgs...)
+}
+
+func (t Translator) lookup(key string) (string, bool) {
+	var custom map[string]map[string]string
+	if cfg := config.Get(); cfg != nil {
+		custom = cfg.Alerts.Translations
+	}
+	for _, lang := range t.languages {
+		if text, ok := custom[lang][key]; ok && text != "" {
+			return text, true
+		}
+		if text, ok := messages[lang][key]; ok {
+			return text, true
+		}
+	}
+	return "", false
+}
+
+// SetMessage sets the message of n from the catalog. Message gets the
+// English text, kept in history and event streams; providers render the
+// message in the language of each channel.
+func (n *Notification) SetMessage(key string, args ...interface{}) {
+	n.MessageKey = key
+	n.MessageArgs = args
+	n.Message = NewTranslator(DefaultLanguage).T(key, args...)
+}
+
+// Languages returns the built-in languages and those with custom
+// translations
+func Languages() []string {
+	seen := make(map[string]bool)
+	for lang := range messages {
+		seen[lang] = true
+	}
+	if cfg := config.Get(); cfg != nil {
+		for lang := range cfg.Alerts.Translations {
+			seen[normalizeLanguage(lang)] = true
+		}
+	}
+	languages := make([]string, 0, len(seen))
+	for lang := range seen {
+		languages = append(languages, lang)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// IsLanguage reports whether notifications can be rendered in language,
+// itself or its base language
+func IsLanguage(language string) bool {
+	language = normalizeLanguage(language)
+	base, _, _ := strings.Cut(language, "-")
+	for _, lang := range Languages() {
+		if lang == language || lang == base {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizeLanguage lowercases a language tag and writes ko_KR as ko-kr
+func normalizeLanguage(language string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(language)), "_", "-")
+}
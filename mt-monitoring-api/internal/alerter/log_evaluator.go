@@ -0,0 +1,157 @@
+package alerter
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// logMatcher is an enabled log rule with its compiled pattern
+type logMatcher struct {
+	rule    models.AlertRule
+	pattern *regexp.Regexp
+}
+
+// applies reports whether the rule watches logs of a service of projectID
+// and level
+func (m *logMatcher) applies(projectID, serviceID string, level models.LogLevel) bool {
+	if m.rule.ProjectID != projectID {
+		return false
+	}
+	if m.rule.ServiceID != nil && *m.rule.ServiceID != "" && *m.rule.ServiceID != serviceID {
+		return false
+	}
+	return m.rule.LogLevel == "" || m.rule.LogLevel == level
+}
+
+// LogRuleEvaluator evaluates log rules against ingested log entries as they
+// arrive. Rule patterns are compiled once and cached until the rules change,
+// so matching a line costs only the regular expressions of the rules that
+// apply to it.
+type LogRuleEvaluator struct {
+	manager    *Manager
+	repo       database.AlertRuleRepository
+	remediator *Remediator
+
+	mu          sync.Mutex
+	loaded      bool
+	version     int64 // repository version the matchers were compiled at
+	matchers    []*logMatcher
+	hits        map[string][]time.Time // ruleKey → times of recent matches, at most Threshold
+	lastAlerted map[string]time.Time   // ruleKey → last alert time (for cooldown)
+}
+
+// NewLogRuleEvaluator creates a new log rule evaluator
+func NewLogRuleEvaluator(store *database.Store, manager *Manager) *LogRuleEvaluator {
+	return &LogRuleEvaluator{
+		manager:     manager,
+		repo:        store.AlertRules,
+		remediator:  NewRemediator(store),
+		hits:        make(map[string][]time.Time),
+		lastAlerted: make(map[string]time.Time),
+	}
+}
+
+// Evaluate matches a log entry of a service against the log rules of its
+// project and dispatches the alerts of the rules reaching their threshold
+func (e *LogRuleEvaluator) Evaluate(projectID, serviceID, serviceName string, entry *models.Log) {
+	now := time.Now()
+	for _, m := range e.load() {
+		if !m.applies(projectID, serviceID, entry.Level) || !m.pattern.MatchString(entry.Message) {
+			continue
+		}
+		e.hit(m.rule, serviceID, serviceName, entry, now)
+	}
+}
+
+// load returns the compiled matchers, compiling them again when rules were
+// written since the last load. Rules with invalid patterns are skipped.
+func (e *LogRuleEvaluator) load() []*logMatcher {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	version := e.repo.Version()
+	if e.loaded && version == e.version {
+		return e.matchers
+	}
+	rules, err := e.repo.GetEnabledLogRules(context.Background())
+	if err != nil {
+		log.Printf("[LogEvaluator] Failed to get log rules: %v", err)
+		return e.matchers
+	}
+
+	matchers := make([]*logMatcher, 0, len(rules))
+	ids := make(map[string]bool, len(rules))
+	for _, rule := range rules {
+		pattern, err := regexp.Compile(rule.Pattern)
+		if err != nil {
+			log.Printf("[LogEvaluator] Skipping rule %s: invalid pattern: %v", rule.Name, err)
+			continue
+		}
+		matchers = append(matchers, &logMatcher{rule: rule, pattern: pattern})
+		ids[rule.ID] = true
+	}
+	// Forget the matches of rules that were deleted or disabled
+	for key := range e.hits {
+		if ruleID, _, _ := strings.Cut(key, ":"); !ids[ruleID] {
+			delete(e.hits, key)
+			delete(e.lastAlerted, key)
+		}
+	}
+
+	e.matchers = matchers
+	e.version = version
+	e.loaded = true
+	return matchers
+}
+
+// hit records a match of rule and alerts once Threshold matches fell within
+// Duration minutes, unless the rule is cooling down
+func (e *LogRuleEvaluator) hit(rule models.AlertRule, serviceID, serviceName string, entry *models.Log, now time.Time) {
+	threshold := max(int(rule.Threshold), 1)
+	window := time.Duration(max(rule.Duration, 1)) * time.Minute
+	ruleKey := rule.ID + ":" + serviceID
+
+	e.mu.Lock()
+	hits := append(e.hits[ruleKey], now)
+	for len(hits) > 0 && (now.Sub(hits[0]) > window || len(hits) > threshold) {
+		hits = hits[1:]
+	}
+	e.hits[ruleKey] = hits
+	if len(hits) < threshold {
+		e.mu.Unlock()
+		return
+	}
+	if last, ok := e.lastAlerted[ruleKey]; ok && now.Sub(last) < time.Duration(rule.Cooldown)*time.Second {
+		e.mu.Unlock()
+		return
+	}
+	e.lastAlerted[ruleKey] = now
+	delete(e.hits, ruleKey)
+	e.mu.Unlock()
+
+	notification := Notification{
+		AlertType:   AlertTypeLog,
+		ServiceID:   serviceID,
+		ServiceName: serviceName,
+		LogLevel:    string(entry.Level),
+		Metric:      string(models.AlertMetricLogMatch),
+		Value:       float64(len(hits)),
+		Threshold:   rule.Threshold,
+		Severity:    string(rule.Severity),
+		Time:        now,
+	}
+	notification.SetMessage("msg_log_rule_alert", rule.Name, len(hits), int(window.Minutes()), serviceName, entry.Message)
+
+	log.Printf("[LogEvaluator] ALERT %s: %d matches of %q in %v (service: %s, rule: %s)",
+		rule.Severity, len(hits), rule.Pattern, window, serviceName, rule.Name)
+
+	go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
+	go e.remediator.Run(rule, notification)
+}
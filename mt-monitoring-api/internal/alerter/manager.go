@@ -1,50 +1,64 @@
 package alerter
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"log"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/eventstream"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/mqtt"
 )
 
 // Manager manages alert dispatching to multiple providers
 type Manager struct {
-	repo        *database.NotificationRepository
-	historyRepo *database.NotificationHistoryRepository
+	repo        database.NotificationRepository
+	historyRepo database.NotificationHistoryRepository
+	prefs       database.PreferenceRepository
+	services    database.ServiceRepository
+	hosts       database.HostRepository
 	dedup       *Deduplicator
 }
 
 // NewManager creates a new alert manager
-func NewManager() *Manager {
+func NewManager(store *database.Store) *Manager {
 	cooldown := 5 * time.Minute
 	if cfg := config.Get(); cfg != nil && cfg.Alerts.LogAlertCooldown > 0 {
 		cooldown = time.Duration(cfg.Alerts.LogAlertCooldown) * time.Minute
 	}
 
 	return &Manager{
-		repo:        database.NewNotificationRepository(),
-		historyRepo: database.NewNotificationHistoryRepository(),
+		repo:        store.Notifications,
+		historyRepo: store.NotificationHistory,
+		prefs:       store.Preferences,
+		services:    store.Services,
+		hosts:       store.Hosts,
 		dedup:       NewDeduplicator(cooldown),
 	}
 }
 
-// Dispatch sends a notification to all enabled channels
+// Dispatch sends a notification to all enabled channels of its project and
+// the users taking it (see notifyUsers)
 func (m *Manager) Dispatch(notification Notification) {
 	if notification.AlertType == "" {
 		notification.AlertType = AlertTypeHealthCheck
 	}
+	m.attachOwnership(&notification)
+	publishAlert(notification)
+	m.notifyUsers(notification)
 
-	channels, err := m.repo.GetEnabled()
+	channels, err := m.repo.GetEnabled(context.Background(), notification.ProjectID)
 	if err != nil {
 		log.Printf("Failed to get enabled channels: %v", err)
 		return
 	}
 
 	for _, ch := range channels {
-		go m.sendToChannel(ch, notification)
+		m.sendToChannel(ch, notification)
 	}
 }
 
@@ -70,50 +84,106 @@ func (m *Manager) DispatchLogAlert(serviceID, serviceName, level, message string
 	m.Dispatch(notification)
 }
 
-// DispatchToChannels sends a notification to specific channels by ID.
+// DispatchToChannels sends a notification to specific channels by ID of
+// its project, and to the users taking it.
 // If channelIDs is empty, falls back to broadcasting to all enabled channels.
 func (m *Manager) DispatchToChannels(notification Notification, channelIDs []string) {
 	if len(channelIDs) == 0 {
 		m.Dispatch(notification)
 		return
 	}
+	if notification.AlertType == "" {
+		notification.AlertType = AlertTypeHealthCheck
+	}
+	m.attachOwnership(&notification)
+	publishAlert(notification)
+	m.notifyUsers(notification)
 
 	for _, chID := range channelIDs {
-		ch, err := m.repo.GetByID(chID)
-		if err != nil || ch == nil || !ch.IsEnabled {
+		ch, err := m.repo.GetByID(context.Background(), chID)
+		if err != nil || ch == nil || !ch.IsEnabled || ch.ProjectID != notification.ProjectID {
 			continue
 		}
-		go m.sendToChannel(*ch, notification)
+		m.sendToChannel(*ch, notification)
+	}
+}
+
+// publishAlert publishes a notification to MQTT and the event stream.
+// Health check alerts are left out of MQTT: service status messages
+// already carry them.
+func publishAlert(n Notification) {
+	if eventstream.Enabled() {
+		eventstream.PublishAlert(eventstream.AlertData{
+			Type:        n.AlertType,
+			Severity:    n.Severity,
+			Status:      string(n.Status),
+			HostID:      n.HostID,
+			HostName:    n.HostName,
+			ServiceID:   n.ServiceID,
+			ServiceName: n.ServiceName,
+			Metric:      n.Metric,
+			Value:       n.Value,
+			Threshold:   n.Threshold,
+			Message:     n.Message,
+			Time:        n.Time,
+		})
+	}
+	if n.AlertType == AlertTypeHealthCheck || !mqtt.Enabled() {
+		return
 	}
+	mqtt.PublishAlert(mqtt.AlertMessage{
+		Type:        n.AlertType,
+		Severity:    n.Severity,
+		Status:      string(n.Status),
+		HostID:      n.HostID,
+		HostName:    n.HostName,
+		ServiceID:   n.ServiceID,
+		ServiceName: n.ServiceName,
+		Metric:      n.Metric,
+		Value:       n.Value,
+		Threshold:   n.Threshold,
+		Message:     n.Message,
+		Time:        n.Time,
+	})
 }
 
-// sendToChannel sends notification to a specific channel
+// sendToChannel queues notification for delivery to a channel
 func (m *Manager) sendToChannel(ch models.NotificationChannel, notification Notification) {
-	var provider AlertProvider
+	deliveries().enqueue(&delivery{manager: m, channel: ch, notification: notification})
+}
 
+// newProvider creates the provider sending to a channel
+func newProvider(ch models.NotificationChannel) (AlertProvider, error) {
 	switch ch.Type {
 	case "discord":
 		var config models.DiscordConfig
 		if err := json.Unmarshal([]byte(ch.Config), &config); err != nil {
-			log.Printf("Failed to parse Discord config for channel %s: %v", ch.Name, err)
-			return
+			return nil, fmt.Errorf("failed to parse Discord config: %w", err)
 		}
-		provider = NewDiscordProvider(config.WebhookURL)
+		return NewDiscordProvider(config.WebhookURL, config.Language, config.Timezone), nil
 
 	case "telegram":
 		var config models.TelegramConfig
 		if err := json.Unmarshal([]byte(ch.Config), &config); err != nil {
-			log.Printf("Failed to parse Telegram config for channel %s: %v", ch.Name, err)
-			return
+			return nil, fmt.Errorf("failed to parse Telegram config: %w", err)
+		}
+		return NewTelegramProvider(config.BotToken, config.ChatID, config.Language, config.Timezone), nil
+
+	case "email":
+		var config models.EmailConfig
+		if err := json.Unmarshal([]byte(ch.Config), &config); err != nil {
+			return nil, fmt.Errorf("failed to parse email config: %w", err)
 		}
-		provider = NewTelegramProvider(config.BotToken, config.ChatID)
+		return NewEmailProvider(config.To, config.Language, config.Timezone), nil
 
 	default:
-		log.Printf("Unknown channel type: %s", ch.Type)
-		return
+		return nil, fmt.Errorf("unknown channel type: %s", ch.Type)
 	}
+}
 
-	// Create history record
+// newHistory creates the pending history record of a notification sent to
+// a channel
+func newHistory(ch models.NotificationChannel, notification Notification) *models.NotificationHistory {
 	history := &models.NotificationHistory{
 		ChannelID:   ch.ID,
 		ChannelName: ch.Name,
@@ -139,50 +209,5 @@ func (m *Manager) sendToChannel(ch models.NotificationChannel, notification Noti
 	if notification.ServiceName != "" {
 		history.ServiceName = &notification.ServiceName
 	}
-
-	// Save history
-	if err := m.historyRepo.Create(history); err != nil {
-		log.Printf("Failed to create notification history: %v", err)
-	}
-
-	// Send notification with retry logic
-	maxRetries := 3
-	var lastErr error
-
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			// Exponential backoff: 2s, 4s, 8s
-			backoffDuration := time.Duration(1<<uint(attempt)) * 2 * time.Second
-			log.Printf("Retrying alert to %s (%s) in %v (attempt %d/%d)",
-				ch.Name, ch.Type, backoffDuration, attempt+1, maxRetries)
-			time.Sleep(backoffDuration)
-
-			// Update retry count
-			if history.ID > 0 {
-				m.historyRepo.IncrementRetry(history.ID)
-			}
-		}
-
-		// Attempt to send
-		if err := provider.Send(notification); err != nil {
-			lastErr = err
-			log.Printf("Failed to send alert to %s (%s) (attempt %d/%d): %v",
-				ch.Name, ch.Type, attempt+1, maxRetries, err)
-			continue // Retry
-		}
-
-		// Success!
-		log.Printf("Alert sent to %s (%s) for service %s", ch.Name, ch.Type, notification.ServiceName)
-		if history.ID > 0 {
-			m.historyRepo.UpdateStatus(history.ID, "sent", "")
-		}
-		return
-	}
-
-	// All retries failed
-	log.Printf("All retries exhausted for alert to %s (%s): %v", ch.Name, ch.Type, lastErr)
-	if history.ID > 0 {
-		errMsg := lastErr.Error()
-		m.historyRepo.UpdateStatus(history.ID, "failed", errMsg)
-	}
+	return history
 }
@@ -0,0 +1,50 @@
+package alerter
+
+import (
+	"context"
+	"log"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// attachOwnership sets the project and ownership of a notification to those
+// of its service, or of its host when the service has none. Notifications
+// of neither, like external alerts, belong to the default project.
+func (m *Manager) attachOwnership(n *Notification) {
+	ctx := context.Background()
+	if n.ServiceID != "" {
+		service, err := m.services.GetByID(ctx, n.ServiceID)
+		if err != nil {
+			log.Printf("Failed to get ownership of service %s: %v", n.ServiceID, err)
+		} else if service != nil {
+			if n.ProjectID == "" {
+				n.ProjectID = service.ProjectID
+			}
+			if n.Ownership == nil {
+				n.Ownership = service.Ownership
+			}
+		}
+	}
+	if n.HostID != "" && (n.ProjectID == "" || n.Ownership == nil) {
+		host, err := m.hosts.GetByID(ctx, n.HostID)
+		if err != nil {
+			log.Printf("Failed to get ownership of host %s: %v", n.HostID, err)
+		} else if host != nil {
+			if n.ProjectID == "" {
+				n.ProjectID = host.ProjectID
+			}
+			if n.Ownership == nil {
+				n.Ownership = host.Ownership
+			}
+		}
+	}
+	if n.ProjectID == "" {
+		n.ProjectID = models.DefaultProject
+	}
+}
+
+// ownedBy reports whether the service or host of a notification is owned
+// by a user
+func ownedBy(n Notification, owner string) bool {
+	return n.Ownership != nil && n.Ownership.Owner != "" && n.Ownership.Matches("", owner)
+}
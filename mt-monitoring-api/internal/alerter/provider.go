@@ -1,8 +1,12 @@
 package alerter
 
 import (
+	"fmt"
+	"net/url"
+	"strings"
 	"time"
 
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
@@ -13,10 +17,12 @@ type AlertProvider interface {
 
 // Alert types
 const (
-	AlertTypeHealthCheck = "healthcheck"
-	AlertTypeLog         = "log"
-	AlertTypeResource    = "resource"
-	AlertTypeEndpoint    = "endpoint"
+	AlertTypeHealthCheck  = "healthcheck"
+	AlertTypeLog          = "log"
+	AlertTypeResource     = "resource"
+	AlertTypeEndpoint     = "endpoint"
+	AlertTypeSystem       = "system"
+	AlertTypeAlertmanager = "alertmanager"
 )
 
 // Notification represents an alert notification
@@ -27,19 +33,97 @@ type Notification struct {
 	Message     string
 	Time        time.Time
 
+	// Catalog message (see SetMessage), rendered in the channel language
+	MessageKey  string
+	MessageArgs []interface{}
+
 	// Log alert fields
-	AlertType string // "healthcheck" | "log" | "resource" | "endpoint"
+	AlertType string // "healthcheck" | "log" | "resource" | "endpoint" | "system" | "alertmanager"
 	LogLevel  string // "error" | "warn"
 	Metadata  map[string]interface{}
 
 	// Resource alert fields
 	HostID    string
 	HostName  string
-	Metric    string  // "cpu" | "memory" | "disk" | "http_status" | "response_time"
+	Metric    string  // "cpu" | "memory" | "disk" | "http_status" | "response_time" | "db_size"
 	Value     float64
 	Threshold float64
 	Severity  string // "critical" | "warning" | "info"
 
 	// Endpoint alert fields
-	StatusCode int // HTTP status code (endpoint rules)
+	StatusCode int // HTTP status code (endpoint rules and health checks)
+
+	// Health check context, of the check that changed the status
+	ResponseTime int           // milliseconds
+	Uptime24h    *float64      // percent over the last 24 hours, nil without checks
+	Downtime     time.Duration // how long the service was down, on recovery
+	URL          string        // service page, empty without alerts.dashboardUrl
+
+	// Team and user responsible for the service or host, set on dispatch
+	Ownership *models.Ownership
+
+	// Project of the service or host, set on dispatch. Only the channels
+	// and users of the project receive the notification.
+	ProjectID string
+}
+
+// ServiceURL returns the dashboard page of a service, empty when
+// alerts.dashboardUrl is not set
+func ServiceURL(serviceID string) string {
+	cfg := config.Get()
+	if cfg == nil || cfg.Alerts.DashboardURL == "" {
+		return ""
+	}
+	return strings.TrimRight(cfg.Alerts.DashboardURL, "/") + "/services/" + url.PathEscape(serviceID)
+}
+
+// checkContext returns the known health check context of a notification
+// as translated label and value pairs
+func checkContext(tr Translator, n Notification) [][2]string {
+	var fields [][2]string
+	if n.StatusCode > 0 {
+		fields = append(fields, [2]string{tr.T("metric_http_status"), fmt.Sprintf("%d", n.StatusCode)})
+	}
+	if n.ResponseTime > 0 {
+		fields = append(fields, [2]string{tr.T("metric_response_time"), fmt.Sprintf("%dms", n.ResponseTime)})
+	}
+	if n.Uptime24h != nil {
+		fields = append(fields, [2]string{tr.T("label_uptime_24h"), fmt.Sprintf("%.2f%%", *n.Uptime24h)})
+	}
+	if n.Downtime > 0 {
+		fields = append(fields, [2]string{tr.T("label_downtime"), n.Downtime.Round(time.Second).String()})
+	}
+	return fields
+}
+
+// ownershipContext returns the ownership of the service or host of a
+// notification as translated label and value pairs
+func ownershipContext(tr Translator, n Notification) [][2]string {
+	o := n.Ownership
+	if o == nil {
+		return nil
+	}
+	var fields [][2]string
+	for _, f := range [][2]string{
+		{"label_team", o.Team},
+		{"label_owner", o.Owner},
+		{"label_contact", o.Contact},
+		{"label_escalation", o.Escalation},
+	} {
+		if f[1] != "" {
+			fields = append(fields, [2]string{tr.T(f[0]), f[1]})
+		}
+	}
+	return fields
+}
+
+// location returns the location of a channel timezone, the display
+// timezone (server.timezone) when empty or unknown
+func location(timezone string) *time.Location {
+	if timezone != "" {
+		if loc, err := time.LoadLocation(timezone); err == nil {
+			return loc
+		}
+	}
+	return config.GetLocation()
 }
@@ -0,0 +1,258 @@
+package alerter
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// remediationOutputLimit caps the command output or webhook response kept
+// with a remediation run
+const remediationOutputLimit = 64 << 10
+
+// CommandRunner runs a remediation command on a host and returns its
+// output. The collector manager registers it at startup, see
+// SetCommandRunner.
+type CommandRunner func(ctx context.Context, hostID, command string) (string, error)
+
+var commandRunner atomic.Pointer[CommandRunner]
+
+// SetCommandRunner sets how ssh remediation actions run their commands
+func SetCommandRunner(run CommandRunner) {
+	commandRunner.Store(&run)
+}
+
+// RemediationWebhook is the JSON body posted by webhook remediation actions
+type RemediationWebhook struct {
+	RuleID     string    `json:"ruleId"`
+	RuleName   string    `json:"ruleName"`
+	Severity   string    `json:"severity"`
+	HostID     string    `json:"hostId,omitempty"`
+	ServiceID  string    `json:"serviceId,omitempty"`
+	Metric     string    `json:"metric"`
+	Value      float64   `json:"value"`
+	Threshold  float64   `json:"threshold"`
+	Message    string    `json:"message"`
+	Time       time.Time `json:"time"`
+	IncidentID *int64    `json:"incidentId,omitempty"`
+}
+
+// Remediator runs the remediation actions of alert rules when they fire.
+// Every run, skipped ones included, is recorded and logged as an audit
+// trail, and attached to the open incident of the service.
+type Remediator struct {
+	runs      database.RemediationRepository
+	incidents database.IncidentRepository
+	client    *http.Client
+
+	mu      sync.Mutex
+	running map[string]int // ruleKey → runs in progress, counted against MaxRuns
+}
+
+// NewRemediator creates a new remediator
+func NewRemediator(store *database.Store) *Remediator {
+	return &Remediator{
+		runs:      store.Remediations,
+		incidents: store.Incidents,
+		client:    &http.Client{},
+		running:   make(map[string]int),
+	}
+}
+
+// Run runs the remediation action of a rule that fired with notification,
+// unless remediation is disabled or the action ran MaxRuns times within
+// its window for the same host or service. It blocks until the action
+// finishes; call it on its own goroutine.
+func (r *Remediator) Run(rule models.AlertRule, n Notification) {
+	action := rule.Remediation
+	if action == nil || action.Type == "" {
+		return
+	}
+	cfg := config.Get()
+	if cfg == nil || !cfg.Alerts.Remediation.Enabled {
+		log.Printf("[Remediation] Rule %s fired, remediation is disabled (alerts.remediation.enabled)", rule.Name)
+		return
+	}
+
+	ctx := context.Background()
+	run := &models.RemediationRun{
+		RuleID:    rule.ID,
+		RuleName:  rule.Name,
+		HostID:    n.HostID,
+		ServiceID: n.ServiceID,
+		Type:      action.Type,
+		Command:   action.Command,
+		StartedAt: time.Now(),
+	}
+	switch action.Type {
+	case models.RemediationSSH:
+		if action.HostID != "" {
+			run.HostID = action.HostID
+		}
+		run.Target = run.HostID
+	case models.RemediationWebhook:
+		run.Target = action.URL
+	}
+	run.IncidentID = r.openIncident(ctx, n.ServiceID)
+
+	ruleKey := rule.ID + ":" + run.HostID + ":" + run.ServiceID
+	if !r.acquire(ctx, ruleKey, action, run) {
+		run.Status = models.RemediationSkipped
+		run.Error = fmt.Sprintf("run limit of %d per %d minutes reached", action.MaxRuns, action.Window)
+		r.record(ctx, run)
+		return
+	}
+	defer r.release(ruleKey)
+
+	log.Printf("[Audit] Remediation started: rule=%s type=%s target=%s command=%q",
+		rule.Name, run.Type, run.Target, run.Command)
+
+	runCtx, cancel := context.WithTimeout(ctx, time.Duration(cfg.Alerts.Remediation.Timeout)*time.Second)
+	defer cancel()
+
+	var output string
+	var err error
+	switch action.Type {
+	case models.RemediationSSH:
+		output, err = runCommand(runCtx, run.HostID, action.Command)
+	case models.RemediationWebhook:
+		output, err = r.post(runCtx, action.URL, remediationWebhook(rule, n, run.IncidentID))
+	default:
+		err = fmt.Errorf("unknown remediation type %q", action.Type)
+	}
+
+	run.Duration = int(time.Since(run.StartedAt).Milliseconds())
+	run.Output = truncateOutput(output)
+	run.Status = models.RemediationSucceeded
+	if err != nil {
+		run.Status = models.RemediationFailed
+		run.Error = err.Error()
+	}
+	r.record(ctx, run)
+}
+
+// acquire reports whether the action may run, counting the recorded runs
+// within its window and the runs still in progress
+func (r *Remediator) acquire(ctx context.Context, ruleKey string, action *models.Remediation, run *models.RemediationRun) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	since := run.StartedAt.Add(-time.Duration(action.Window) * time.Minute)
+	count, err := r.runs.CountSince(ctx, run.RuleID, run.HostID, run.ServiceID, since)
+	if err != nil {
+		// Without the count the limit cannot be enforced, so do not run
+		log.Printf("[Remediation] Failed to count runs of rule %s: %v", run.RuleName, err)
+		return false
+	}
+	if count+r.running[ruleKey] >= action.MaxRuns {
+		return false
+	}
+	r.running[ruleKey]++
+	return true
+}
+
+func (r *Remediator) release(ruleKey string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.running[ruleKey]--; r.running[ruleKey] <= 0 {
+		delete(r.running, ruleKey)
+	}
+}
+
+// openIncident returns the ID of the open incident of a service, nil when
+// it has none
+func (r *Remediator) openIncident(ctx context.Context, serviceID string) *int64 {
+	if serviceID == "" {
+		return nil
+	}
+	incidents, err := r.incidents.GetActive(ctx)
+	if err != nil {
+		log.Printf("[Remediation] Failed to get active incidents: %v", err)
+		return nil
+	}
+	for _, incident := range incidents {
+		if incident.ServiceID == serviceID {
+			id := incident.ID
+			return &id
+		}
+	}
+	return nil
+}
+
+// record stores a run and writes its audit log line
+func (r *Remediator) record(ctx context.Context, run *models.RemediationRun) {
+	log.Printf("[Audit] Remediation %s: rule=%s type=%s target=%s command=%q duration=%dms error=%q",
+		run.Status, run.RuleName, run.Type, run.Target, run.Command, run.Duration, run.Error)
+	if err := r.runs.Create(ctx, run); err != nil {
+		log.Printf("[Remediation] Failed to record run of rule %s: %v", run.RuleName, err)
+	}
+}
+
+func runCommand(ctx context.Context, hostID, command string) (string, error) {
+	if hostID == "" {
+		return "", fmt.Errorf("no host to run the command on")
+	}
+	run := commandRunner.Load()
+	if run == nil {
+		return "", fmt.Errorf("host collectors are not running")
+	}
+	return (*run)(ctx, hostID, command)
+}
+
+func (r *Remediator) post(ctx context.Context, url string, body RemediationWebhook) (string, error) {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MT-Monitoring/1.0")
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	response, _ := io.ReadAll(io.LimitReader(resp.Body, remediationOutputLimit+1))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return string(response), fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return string(response), nil
+}
+
+func remediationWebhook(rule models.AlertRule, n Notification, incidentID *int64) RemediationWebhook {
+	return RemediationWebhook{
+		RuleID:     rule.ID,
+		RuleName:   rule.Name,
+		Severity:   n.Severity,
+		HostID:     n.HostID,
+		ServiceID:  n.ServiceID,
+		Metric:     n.Metric,
+		Value:      n.Value,
+		Threshold:  n.Threshold,
+		Message:    n.Message,
+		Time:       n.Time,
+		IncidentID: incidentID,
+	}
+}
+
+func truncateOutput(output string) string {
+	if len(output) <= remediationOutputLimit {
+		return output
+	}
+	return output[:remediationOutputLimit] + "\n[truncated]"
+}
@@ -1,38 +1,45 @@
 package alerter
 
 import (
-	"fmt"
+	"context"
 	"log"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/events"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // ServiceRuleEvaluator evaluates alert rules against incoming endpoint check results.
 // It mirrors RuleEvaluator but operates on service metrics (HTTP status codes and response times).
 type ServiceRuleEvaluator struct {
-	manager   *Manager
-	repo      *database.AlertRuleRepository
-	stateRepo *database.AlertRuleStateRepository
+	manager    *Manager
+	repo       database.AlertRuleRepository
+	stateRepo  database.AlertRuleStateRepository
+	remediator *Remediator
 
 	mu           sync.Mutex
 	breachCounts map[string]int       // ruleKey → consecutive breach count
 	lastAlerted  map[string]time.Time // ruleKey → last alert time (for cooldown)
 	wasAlerting  map[string]bool      // ruleKey → whether an alert was fired (for recovery)
+	lastValues   map[string]float64   // ruleKey → last evaluated value
+	breachSince  map[string]time.Time // ruleKey → start of the current breach
 }
 
 // NewServiceRuleEvaluator creates a new service rule evaluator.
-func NewServiceRuleEvaluator(manager *Manager) *ServiceRuleEvaluator {
+func NewServiceRuleEvaluator(store *database.Store, manager *Manager) *ServiceRuleEvaluator {
 	evaluator := &ServiceRuleEvaluator{
 		manager:      manager,
-		repo:         database.NewAlertRuleRepository(),
-		stateRepo:    database.NewAlertRuleStateRepository(),
+		repo:         store.AlertRules,
+		stateRepo:    store.AlertRuleStates,
+		remediator:   NewRemediator(store),
 		breachCounts: make(map[string]int),
 		lastAlerted:  make(map[string]time.Time),
 		wasAlerting:  make(map[string]bool),
+		lastValues:   make(map[string]float64),
+		breachSince:  make(map[string]time.Time),
 	}
 
 	evaluator.loadState()
@@ -40,10 +47,21 @@ func NewServiceRuleEvaluator(manager *Manager) *ServiceRuleEvaluator {
 	return evaluator
 }
 
+// SubscribeEvents evaluates the rules against every completed check until
+// unsubscribe is called. Checks of services in status page maintenance are
+// skipped.
+func (e *ServiceRuleEvaluator) SubscribeEvents() (unsubscribe func()) {
+	return events.CheckCompleted.Subscribe(func(c events.Check) {
+		if !c.Held {
+			e.Evaluate(c.Service.ID, c.Service.Name, c.Metric.StatusCode, c.Metric.ResponseTime)
+		}
+	})
+}
+
 // Evaluate checks all enabled service rules for a service against the given check result.
-// This is called by Scheduler after each service check.
+// This is called for each check.completed event.
 func (e *ServiceRuleEvaluator) Evaluate(serviceID, serviceName string, statusCode, responseTimeMs int) {
-	rules, err := e.repo.GetEnabledByServiceID(serviceID)
+	rules, err := e.repo.GetEnabledByServiceID(context.Background(), serviceID)
 	if err != nil {
 		log.Printf("[ServiceEvaluator] Failed to get rules for service %s: %v", serviceID, err)
 		return
@@ -67,7 +85,11 @@ func (e *ServiceRuleEvaluator) evaluateRule(
 	e.mu.Lock()
 	defer e.mu.Unlock()
 
+	e.lastValues[ruleKey] = value
 	if breached {
+		if _, ok := e.breachSince[ruleKey]; !ok {
+			e.breachSince[ruleKey] = time.Now()
+		}
 		e.breachCounts[ruleKey]++
 
 		// For service rules, Duration = number of consecutive failing checks (not minutes)
@@ -80,7 +102,9 @@ func (e *ServiceRuleEvaluator) evaluateRule(
 			// Check cooldown
 			if last, ok := e.lastAlerted[ruleKey]; ok {
 				if time.Since(last) < time.Duration(rule.Cooldown)*time.Second {
-					return // Still in cooldown
+					// Still in cooldown; persist the value of the ongoing breach
+					go e.saveState(rule.ID, serviceID)
+					return
 				}
 			}
 
@@ -97,14 +121,16 @@ func (e *ServiceRuleEvaluator) evaluateRule(
 				Threshold:   rule.Threshold,
 				Severity:    string(rule.Severity),
 				StatusCode:  statusCode,
-				Message:     buildEndpointAlertMessage(rule, serviceName, value),
 				Time:        time.Now(),
 			}
+			key, args := endpointAlertMessage(rule, serviceName, value)
+			notification.SetMessage(key, args...)
 
 			log.Printf("[ServiceEvaluator] ALERT %s: %s=%.0f > %.0f (service: %s, rule: %s)",
 				rule.Severity, rule.Metric, value, rule.Threshold, serviceName, rule.Name)
 
 			go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
+			go e.remediator.Run(rule, notification)
 			go e.saveState(rule.ID, serviceID)
 		} else {
 			go e.saveState(rule.ID, serviceID)
@@ -123,9 +149,10 @@ func (e *ServiceRuleEvaluator) evaluateRule(
 				Threshold:   rule.Threshold,
 				Severity:    "info",
 				StatusCode:  statusCode,
-				Message:     buildEndpointRecoveryMessage(rule, serviceName, value),
 				Time:        time.Now(),
 			}
+			key, args := endpointRecoveryMessage(rule, serviceName, value)
+			notification.SetMessage(key, args...)
 
 			log.Printf("[ServiceEvaluator] RECOVERED: %s=%.0f recovered (service: %s, rule: %s)",
 				rule.Metric, value, serviceName, rule.Name)
@@ -133,6 +160,7 @@ func (e *ServiceRuleEvaluator) evaluateRule(
 			go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
 		}
 		e.breachCounts[ruleKey] = 0
+		delete(e.breachSince, ruleKey)
 		go e.saveState(rule.ID, serviceID)
 	}
 }
@@ -147,10 +175,12 @@ func (e *ServiceRuleEvaluator) ResetRule(ruleID string) {
 			delete(e.breachCounts, key)
 			delete(e.lastAlerted, key)
 			delete(e.wasAlerting, key)
+			delete(e.lastValues, key)
+			delete(e.breachSince, key)
 		}
 	}
 
-	e.stateRepo.DeleteByRule(ruleID)
+	e.stateRepo.DeleteByRule(context.Background(), ruleID)
 }
 
 // loadState is a no-op for service rules.
@@ -160,22 +190,27 @@ func (e *ServiceRuleEvaluator) loadState() {
 }
 
 // saveState persists current state to database.
-// ServiceID is stored in the HostID field for reuse of existing schema.
 func (e *ServiceRuleEvaluator) saveState(ruleID, serviceID string) {
 	key := e.ruleKey(ruleID, serviceID)
 
+	e.mu.Lock()
 	state := &models.AlertRuleState{
 		RuleID:      ruleID,
-		HostID:      serviceID, // serviceID stored in host_id column
+		TargetType:  models.AlertTargetService,
+		TargetID:    serviceID,
 		BreachCount: e.breachCounts[key],
 		IsAlerting:  e.wasAlerting[key],
+		Value:       e.lastValues[key],
 	}
-
 	if lastAlerted, ok := e.lastAlerted[key]; ok {
 		state.LastAlertedAt = &lastAlerted
 	}
+	if since, ok := e.breachSince[key]; ok {
+		state.BreachStartedAt = &since
+	}
+	e.mu.Unlock()
 
-	if err := e.stateRepo.SaveState(state); err != nil {
+	if err := e.stateRepo.SaveState(context.Background(), state); err != nil {
 		log.Printf("[ServiceEvaluator] Failed to save state for %s: %v", key, err)
 	}
 }
@@ -197,30 +232,32 @@ func extractServiceMetricValue(metric models.AlertMetric, statusCode, responseTi
 	}
 }
 
-// buildEndpointAlertMessage creates a human-readable alert message.
-func buildEndpointAlertMessage(rule models.AlertRule, serviceName string, value float64) string {
+// endpointAlertMessage returns the catalog key and arguments of an alert
+// message.
+func endpointAlertMessage(rule models.AlertRule, serviceName string, value float64) (string, []interface{}) {
 	switch rule.Metric {
 	case models.AlertMetricHTTPStatus:
-		return fmt.Sprintf("HTTP %d response on %s (threshold: %s %.0f)",
-			int(value), serviceName, operatorLabel(rule.Operator), rule.Threshold)
+		return "msg_http_status_alert",
+			[]interface{}{int(value), serviceName, operatorLabel(rule.Operator), rule.Threshold}
 	case models.AlertMetricResponseTime:
-		return fmt.Sprintf("Response time %.0fms on %s exceeds threshold %s %.0fms",
-			value, serviceName, operatorLabel(rule.Operator), rule.Threshold)
+		return "msg_response_time_alert",
+			[]interface{}{value, serviceName, operatorLabel(rule.Operator), rule.Threshold}
 	default:
-		return fmt.Sprintf("Endpoint alert on %s: %.0f %s %.0f",
-			serviceName, value, operatorLabel(rule.Operator), rule.Threshold)
+		return "msg_endpoint_alert",
+			[]interface{}{serviceName, value, operatorLabel(rule.Operator), rule.Threshold}
 	}
 }
 
-// buildEndpointRecoveryMessage creates a human-readable recovery message.
-func buildEndpointRecoveryMessage(rule models.AlertRule, serviceName string, value float64) string {
+// endpointRecoveryMessage returns the catalog key and arguments of a
+// recovery message.
+func endpointRecoveryMessage(rule models.AlertRule, serviceName string, value float64) (string, []interface{}) {
 	switch rule.Metric {
 	case models.AlertMetricHTTPStatus:
-		return fmt.Sprintf("HTTP response recovered to %d on %s", int(value), serviceName)
+		return "msg_http_status_recovered", []interface{}{int(value), serviceName}
 	case models.AlertMetricResponseTime:
-		return fmt.Sprintf("Response time recovered to %.0fms on %s", value, serviceName)
+		return "msg_response_time_recovered", []interface{}{value, serviceName}
 	default:
-		return fmt.Sprintf("Endpoint metric recovered on %s: %.0f", serviceName, value)
+		return "msg_endpoint_recovered", []interface{}{serviceName, value}
 	}
 }
 
@@ -0,0 +1,275 @@
+package alerter
+
+import (
+	"context"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// SLOEvaluator evaluates SLO rules once a minute. Unlike threshold rules it
+// does not look at single checks: it compares how fast each service spends
+// its error budget over a short and a long window, and alerts only while
+// both burn faster than the rule allows. The long window keeps a brief
+// blip from paging; the short one ends the alert soon after the service
+// recovers.
+type SLOEvaluator struct {
+	manager    *Manager
+	repo       database.AlertRuleRepository
+	services   database.ServiceRepository
+	metrics    database.MetricRepository
+	stateRepo  database.AlertRuleStateRepository
+	remediator *Remediator
+
+	mu           sync.Mutex
+	breachCounts map[string]int       // ruleKey → consecutive breaching evaluations
+	breachSince  map[string]time.Time // ruleKey → start of the current breach
+	lastAlerted  map[string]time.Time // ruleKey → last alert time (for cooldown)
+	wasAlerting  map[string]bool      // ruleKey → whether an alert was fired (for recovery)
+}
+
+// NewSLOEvaluator creates a new SLO rule evaluator
+func NewSLOEvaluator(store *database.Store, manager *Manager) *SLOEvaluator {
+	return &SLOEvaluator{
+		manager:      manager,
+		repo:         store.AlertRules,
+		services:     store.Services,
+		metrics:      store.Metrics,
+		stateRepo:    store.AlertRuleStates,
+		remediator:   NewRemediator(store),
+		breachCounts: make(map[string]int),
+		breachSince:  make(map[string]time.Time),
+		lastAlerted:  make(map[string]time.Time),
+		wasAlerting:  make(map[string]bool),
+	}
+}
+
+// EvaluateAll evaluates every enabled SLO rule against the services it
+// covers: its service, or every active service of its project when it has
+// none
+func (e *SLOEvaluator) EvaluateAll() {
+	ctx := context.Background()
+	rules, err := e.repo.GetEnabledSLORules(ctx)
+	if err != nil {
+		log.Printf("[SLOEvaluator] Failed to get SLO rules: %v", err)
+		return
+	}
+	if len(rules) == 0 {
+		e.forget(nil)
+		return
+	}
+	services, err := e.services.GetAll(ctx)
+	if err != nil {
+		log.Printf("[SLOEvaluator] Failed to get services: %v", err)
+		return
+	}
+
+	now := time.Now()
+	keys := make(map[string]bool)
+	for _, rule := range rules {
+		for i := range services {
+			svc := &services[i]
+			if !svc.IsActive || svc.ProjectID != rule.ProjectID || (rule.ServiceID != nil && *rule.ServiceID != svc.ID) {
+				continue
+			}
+			keys[rule.ID+":"+svc.ID] = true
+			e.evaluateRule(ctx, rule, svc, now)
+		}
+	}
+	e.forget(keys)
+}
+
+// forget drops the state of rules and services no longer evaluated, along
+// with the persisted state of those in breach
+func (e *SLOEvaluator) forget(keep map[string]bool) {
+	e.mu.Lock()
+	var dropped []string
+	for key := range e.breachCounts {
+		if !keep[key] {
+			if e.breachCounts[key] > 0 || e.wasAlerting[key] {
+				dropped = append(dropped, key)
+			}
+			delete(e.breachCounts, key)
+			delete(e.breachSince, key)
+			delete(e.wasAlerting, key)
+			delete(e.lastAlerted, key)
+		}
+	}
+	e.mu.Unlock()
+
+	for _, key := range dropped {
+		ruleID, serviceID, _ := strings.Cut(key, ":")
+		if err := e.stateRepo.Delete(context.Background(), ruleID, models.AlertTargetService, serviceID); err != nil {
+			log.Printf("[SLOEvaluator] Failed to delete state for %s: %v", key, err)
+		}
+	}
+}
+
+// evaluateRule computes the burn rates of a service over the rule's windows
+// and alerts or recovers
+func (e *SLOEvaluator) evaluateRule(ctx context.Context, rule models.AlertRule, svc *models.Service, now time.Time) {
+	budget := 1 - rule.Objective/100
+	if budget <= 0 || budget >= 1 {
+		return
+	}
+	shortWindow := time.Duration(max(rule.ShortWindow, 1)) * time.Minute
+	longWindow := time.Duration(max(rule.LongWindow, 1)) * time.Minute
+
+	shortRatio, shortChecks, err := e.errorRatio(ctx, svc.ID, rule.LatencyTarget, shortWindow, now)
+	if err != nil {
+		log.Printf("[SLOEvaluator] Failed to read checks of %s: %v", svc.Name, err)
+		return
+	}
+	longRatio, longChecks, err := e.errorRatio(ctx, svc.ID, rule.LatencyTarget, longWindow, now)
+	if err != nil {
+		log.Printf("[SLOEvaluator] Failed to read checks of %s: %v", svc.Name, err)
+		return
+	}
+	if shortChecks == 0 || longChecks == 0 {
+		return // not enough data to judge either way
+	}
+	shortBurn := math.Round(shortRatio/budget*10) / 10
+	longBurn := math.Round(longRatio/budget*10) / 10
+	breached := shortBurn >= rule.Threshold && longBurn >= rule.Threshold
+	ruleKey := rule.ID + ":" + svc.ID
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if breached {
+		if _, ok := e.breachSince[ruleKey]; !ok {
+			e.breachSince[ruleKey] = now
+		}
+		e.breachCounts[ruleKey]++
+	} else {
+		delete(e.breachSince, ruleKey)
+		e.breachCounts[ruleKey] = 0
+	}
+	defer e.saveState(rule.ID, svc.ID, longBurn)
+
+	notification := Notification{
+		AlertType:   AlertTypeEndpoint,
+		ServiceID:   svc.ID,
+		ServiceName: svc.Name,
+		Metric:      string(models.AlertMetricBurnRate),
+		Value:       longBurn,
+		Threshold:   rule.Threshold,
+		Time:        now,
+	}
+
+	if breached {
+		if last, ok := e.lastAlerted[ruleKey]; ok && now.Sub(last) < time.Duration(rule.Cooldown)*time.Second {
+			return // Still in cooldown
+		}
+		e.lastAlerted[ruleKey] = now
+		e.wasAlerting[ruleKey] = true
+
+		notification.Severity = string(rule.Severity)
+		notification.SetMessage("msg_slo_burn_alert", svc.Name, rule.Objective,
+			shortBurn, rule.ShortWindow, longBurn, rule.LongWindow, rule.Threshold)
+
+		log.Printf("[SLOEvaluator] ALERT %s: burn rate %.1fx (%v) and %.1fx (%v) >= %.1fx (service: %s, rule: %s)",
+			rule.Severity, shortBurn, shortWindow, longBurn, longWindow, rule.Threshold, svc.Name, rule.Name)
+
+		go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
+		go e.remediator.Run(rule, notification)
+		return
+	}
+
+	if e.wasAlerting[ruleKey] {
+		e.wasAlerting[ruleKey] = false
+
+		notification.Value = shortBurn
+		notification.Severity = "info"
+		notification.SetMessage("msg_slo_burn_recovered", svc.Name, shortBurn, rule.ShortWindow, rule.Threshold)
+
+		log.Printf("[SLOEvaluator] RECOVERED: burn rate %.1fx over %v (service: %s, rule: %s)",
+			shortBurn, shortWindow, svc.Name, rule.Name)
+
+		go e.manager.DispatchToChannels(notification, rule.ChannelIDs)
+	}
+}
+
+// saveState persists the state of a rule for a service, with value its long
+// window burn rate. Callers hold e.mu.
+func (e *SLOEvaluator) saveState(ruleID, serviceID string, value float64) {
+	key := ruleID + ":" + serviceID
+	state := &models.AlertRuleState{
+		RuleID:      ruleID,
+		TargetType:  models.AlertTargetService,
+		TargetID:    serviceID,
+		BreachCount: e.breachCounts[key],
+		IsAlerting:  e.wasAlerting[key],
+		Value:       value,
+	}
+	if lastAlerted, ok := e.lastAlerted[key]; ok {
+		state.LastAlertedAt = &lastAlerted
+	}
+	if since, ok := e.breachSince[key]; ok {
+		state.BreachStartedAt = &since
+	}
+
+	go func() {
+		if err := e.stateRepo.SaveState(context.Background(), state); err != nil {
+			log.Printf("[SLOEvaluator] Failed to save state for %s: %v", key, err)
+		}
+	}()
+}
+
+// errorRatio returns the fraction of bad checks of a service in the window
+// ending now, and how many checks it saw. A check is bad when it failed or
+// took longer than latencyTarget ms (when set).
+//
+// Windows under an hour read the raw checks. Longer ones read the hourly
+// rollups, counting the hour the window starts in by the part of it that
+// falls inside the window. Rollups only know the histogram bucket of a
+// response, so a latencyTarget between two bucket bounds counts the whole
+// bucket it falls in as slow.
+func (e *SLOEvaluator) errorRatio(ctx context.Context, serviceID string, latencyTarget int, window time.Duration, now time.Time) (float64, float64, error) {
+	from := now.Add(-window)
+
+	if window < time.Hour {
+		checks, err := e.metrics.GetRange(ctx, serviceID, from, now)
+		if err != nil || len(checks) == 0 {
+			return 0, 0, err
+		}
+		bad := 0
+		for _, m := range checks {
+			if m.Status != models.CheckStatusSuccess || (latencyTarget > 0 && m.ResponseTime > latencyTarget) {
+				bad++
+			}
+		}
+		return float64(bad) / float64(len(checks)), float64(len(checks)), nil
+	}
+
+	rollups, err := e.metrics.GetRollups(ctx, serviceID, from, now)
+	if err != nil {
+		return 0, 0, err
+	}
+	var total, bad float64
+	for _, ru := range rollups {
+		weight := 1.0
+		if ru.Hour.Before(from) {
+			weight = ru.Hour.Add(time.Hour).Sub(from).Hours()
+		}
+		slow := 0
+		if latencyTarget > 0 {
+			for i, n := range ru.Counts {
+				if i >= len(models.LatencyBuckets) || models.LatencyBuckets[i] > latencyTarget {
+					slow += n
+				}
+			}
+		}
+		total += weight * float64(ru.Checks)
+		bad += weight * float64(min(ru.Failures+slow, ru.Checks))
+	}
+	if total == 0 {
+		return 0, 0, nil
+	}
+	return bad / total, total, nil
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
@@ -14,13 +15,18 @@ import (
 type TelegramProvider struct {
 	BotToken string
 	ChatID   string
+	tr       Translator
+	loc      *time.Location
 }
 
-// NewTelegramProvider creates a new Telegram provider
-func NewTelegramProvider(botToken, chatID string) *TelegramProvider {
+// NewTelegramProvider creates a new Telegram provider sending in language,
+// with times in timezone
+func NewTelegramProvider(botToken, chatID, language, timezone string) *TelegramProvider {
 	return &TelegramProvider{
 		BotToken: botToken,
 		ChatID:   chatID,
+		tr:       NewTranslator(language),
+		loc:      location(timezone),
 	}
 }
 
@@ -35,9 +41,19 @@ func (p *TelegramProvider) Send(notification Notification) error {
 		message = p.buildResourceMessage(notification)
 	case AlertTypeEndpoint:
 		message = p.buildEndpointMessage(notification)
+	case AlertTypeSystem:
+		message = p.buildSystemMessage(notification)
+	case AlertTypeAlertmanager:
+		message = p.buildAlertmanagerMessage(notification)
 	default:
 		message = p.buildHealthCheckMessage(notification)
 	}
+	if owners := ownershipContext(p.tr, notification); len(owners) > 0 {
+		message += "\n"
+		for _, f := range owners {
+			message += fmt.Sprintf("\n%s: %s", f[0], f[1])
+		}
+	}
 
 
 	payload := map[string]interface{}{
@@ -68,23 +84,31 @@ func (p *TelegramProvider) Send(notification Notification) error {
 // buildHealthCheckMessage creates a health check alert message
 func (p *TelegramProvider) buildHealthCheckMessage(n Notification) string {
 	statusEmoji := "🚨"
-	statusText := "Service Down"
+	statusText := p.tr.T("title_service_down")
 	if n.Status == models.StatusHealthy {
 		statusEmoji = "✅"
-		statusText = "Service Recovered"
+		statusText = p.tr.T("title_service_recovered")
 	}
 
-	return fmt.Sprintf(
+	msg := fmt.Sprintf(
 		"%s *%s*\n\n"+
-			"Service: %s\n"+
-			"Time: %s\n"+
-			"Message: %s",
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s",
 		statusEmoji,
 		statusText,
-		n.ServiceName,
-		n.Time.Format("2006-01-02 15:04:05"),
-		n.Message,
+		p.tr.T("label_service"), n.ServiceName,
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
 	)
+	for _, f := range checkContext(p.tr, n) {
+		msg += fmt.Sprintf("\n%s: %s", f[0], f[1])
+	}
+	if n.URL != "" {
+		msg += fmt.Sprintf("\n\n[%s](%s)", p.tr.T("label_service_page"), n.URL)
+	}
+
+	return msg
 }
 
 // buildLogMessage creates a log alert message
@@ -95,17 +119,18 @@ func (p *TelegramProvider) buildLogMessage(n Notification) string {
 	}
 
 	msg := fmt.Sprintf(
-		"%s *Log Alert \\[%s\\]*\n\n"+
-			"Service: %s\n"+
-			"Level: %s\n"+
-			"Time: %s\n"+
-			"Message: %s",
+		"%s *%s \\[%s\\]*\n\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s",
 		levelEmoji,
+		p.tr.T("title_log_alert"),
 		strings.ToUpper(n.LogLevel),
-		n.ServiceName,
-		strings.ToUpper(n.LogLevel),
-		n.Time.Format("2006-01-02 15:04:05"),
-		n.Message,
+		p.tr.T("label_service"), n.ServiceName,
+		p.tr.T("label_level"), strings.ToUpper(n.LogLevel),
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
 	)
 
 	if len(n.Metadata) > 0 {
@@ -113,7 +138,7 @@ func (p *TelegramProvider) buildLogMessage(n Notification) string {
 		for k, v := range n.Metadata {
 			metaParts = append(metaParts, fmt.Sprintf("  %s: %v", k, v))
 		}
-		msg += "\n\nMetadata:\n" + strings.Join(metaParts, "\n")
+		msg += "\n\n" + p.tr.T("label_metadata") + ":\n" + strings.Join(metaParts, "\n")
 	}
 
 	return msg
@@ -122,76 +147,155 @@ func (p *TelegramProvider) buildLogMessage(n Notification) string {
 // buildEndpointMessage creates an endpoint health alert message
 func (p *TelegramProvider) buildEndpointMessage(n Notification) string {
 	severityEmoji := "ℹ️"
-	severityText := "Info"
+	severityText := p.tr.T("severity_info")
 	switch strings.ToLower(n.Severity) {
 	case "critical":
 		severityEmoji = "🔴"
-		severityText = "Critical"
+		severityText = p.tr.T("severity_critical")
 	case "warning":
 		severityEmoji = "🟡"
-		severityText = "Warning"
+		severityText = p.tr.T("severity_warning")
 	}
 
 	var currentValue, thresholdValue, metricLabel string
 	if n.Metric == string(models.AlertMetricResponseTime) {
 		currentValue = fmt.Sprintf("%.0fms", n.Value)
 		thresholdValue = fmt.Sprintf("%.0fms", n.Threshold)
-		metricLabel = "Response Time"
+		metricLabel = p.tr.T("metric_response_time")
+	} else if n.Metric == string(models.AlertMetricBurnRate) {
+		currentValue = fmt.Sprintf("%.1fx", n.Value)
+		thresholdValue = fmt.Sprintf("%.1fx", n.Threshold)
+		metricLabel = p.tr.T("metric_burn_rate")
 	} else {
 		currentValue = fmt.Sprintf("%.0f", n.Value)
 		thresholdValue = fmt.Sprintf("%.0f", n.Threshold)
-		metricLabel = "HTTP Status"
+		metricLabel = p.tr.T("metric_http_status")
 	}
 
 	return fmt.Sprintf(
-		"%s *Endpoint Alert \\[%s\\]*\n\n"+
-			"Service: %s\n"+
-			"Metric: %s\n"+
-			"Current: %s\n"+
-			"Threshold: %s\n"+
-			"Time: %s\n"+
-			"Message: %s",
+		"%s *%s \\[%s\\]*\n\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s",
 		severityEmoji,
+		p.tr.T("title_endpoint_alert"),
 		severityText,
-		n.ServiceName,
-		metricLabel,
-		currentValue,
-		thresholdValue,
-		n.Time.Format("2006-01-02 15:04:05"),
-		n.Message,
+		p.tr.T("label_service"), n.ServiceName,
+		p.tr.T("label_metric"), metricLabel,
+		p.tr.T("label_current"), currentValue,
+		p.tr.T("label_threshold"), thresholdValue,
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
 	)
 }
 
 // buildResourceMessage creates a resource threshold alert message
 func (p *TelegramProvider) buildResourceMessage(n Notification) string {
 	severityEmoji := "ℹ️"
-	severityText := "Info"
+	severityText := p.tr.T("severity_info")
 	switch strings.ToLower(n.Severity) {
 	case "critical":
 		severityEmoji = "🔴"
-		severityText = "Critical"
+		severityText = p.tr.T("severity_critical")
 	case "warning":
 		severityEmoji = "🟡"
-		severityText = "Warning"
+		severityText = p.tr.T("severity_warning")
 	}
 
 	metricName := strings.ToUpper(n.Metric)
 
 	return fmt.Sprintf(
-		"%s *Resource Alert \\[%s\\]*\n\n"+
-			"Host: %s\n"+
-			"Metric: %s\n"+
-			"Current: %.1f%%\n"+
-			"Threshold: %.1f%%\n"+
-			"Time: %s\n"+
-			"Message: %s",
+		"%s *%s \\[%s\\]*\n\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %.1f%%\n"+
+			"%s: %.1f%%\n"+
+			"%s: %s\n"+
+			"%s: %s",
+		severityEmoji,
+		p.tr.T("title_resource_alert"),
+		severityText,
+		p.tr.T("label_host"), n.HostName,
+		p.tr.T("label_metric"), metricName,
+		p.tr.T("label_current"), n.Value,
+		p.tr.T("label_threshold"), n.Threshold,
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
+	)
+}
+
+// buildSystemMessage creates an alert message about the monitoring server itself
+func (p *TelegramProvider) buildSystemMessage(n Notification) string {
+	severityEmoji := "ℹ️"
+	severityText := p.tr.T("severity_info")
+	switch strings.ToLower(n.Severity) {
+	case "critical":
+		severityEmoji = "🔴"
+		severityText = p.tr.T("severity_critical")
+	case "warning":
+		severityEmoji = "🟡"
+		severityText = p.tr.T("severity_warning")
+	}
+
+	return fmt.Sprintf(
+		"%s *%s \\[%s\\]*\n\n"+
+			"%s: %s\n"+
+			"%s: %s\n"+
+			"%s: %s",
 		severityEmoji,
+		p.tr.T("title_system_alert"),
 		severityText,
-		n.HostName,
-		metricName,
-		n.Value,
-		n.Threshold,
-		n.Time.Format("2006-01-02 15:04:05"),
-		n.Message,
+		p.tr.T("label_metric"), n.Metric,
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
 	)
 }
+
+// buildAlertmanagerMessage creates a message for an alert received from Prometheus Alertmanager
+func (p *TelegramProvider) buildAlertmanagerMessage(n Notification) string {
+	severityEmoji := "ℹ️"
+	severityText := p.tr.T("severity_info")
+	switch strings.ToLower(n.Severity) {
+	case "critical":
+		severityEmoji = "🔴"
+		severityText = p.tr.T("severity_critical")
+	case "warning":
+		severityEmoji = "🟡"
+		severityText = p.tr.T("severity_warning")
+	}
+	if n.Status == models.StatusHealthy {
+		severityEmoji = "✅"
+		severityText = p.tr.T("severity_resolved")
+	}
+
+	msg := fmt.Sprintf(
+		"%s *%s \\[%s\\]*\n\n"+
+			"%s: %s\n",
+		severityEmoji,
+		p.tr.T("title_alertmanager"),
+		severityText,
+		p.tr.T("label_alert"), n.Metric,
+	)
+	if n.ServiceName != "" {
+		msg += fmt.Sprintf("%s: %s\n", p.tr.T("label_service"), n.ServiceName)
+	}
+	msg += fmt.Sprintf(
+		"%s: %s\n"+
+			"%s: %s",
+		p.tr.T("label_time"), n.Time.In(p.loc).Format("2006-01-02 15:04:05 MST"),
+		p.tr.T("label_message"), p.tr.Message(n),
+	)
+
+	if len(n.Metadata) > 0 {
+		labels := make([]string, 0, len(n.Metadata))
+		for _, k := range sortedKeys(n.Metadata) {
+			labels = append(labels, fmt.Sprintf("  %s: %v", k, n.Metadata[k]))
+		}
+		msg += "\n\n" + p.tr.T("label_labels") + ":\n" + strings.Join(labels, "\n")
+	}
+
+	return msg
+}
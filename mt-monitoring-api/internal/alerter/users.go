@@ -0,0 +1,111 @@
+package alerter
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"slices"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// notifyUsers queues notification to the personal channels of the users of
+// its project whose notification preferences take it: its severity is one
+// they chose, and its service or host is in their scopes or owned by them
+func (m *Manager) notifyUsers(notification Notification) {
+	ctx := context.Background()
+	prefs, err := m.prefs.GetEnabledNotifications(ctx)
+	if err != nil {
+		log.Printf("Failed to get notification preferences: %v", err)
+		return
+	}
+	if len(prefs) == 0 {
+		return
+	}
+
+	severity := notificationSeverity(notification)
+	var tags []string
+	tagsLoaded := false
+	for i := range prefs {
+		p := &prefs[i]
+		if p.ProjectID != notification.ProjectID || !p.Receives(severity) {
+			continue
+		}
+		if !p.AllAlerts && !ownedBy(notification, p.Owner) &&
+			!slices.Contains(p.ServiceIDs, notification.ServiceID) && !slices.Contains(p.HostIDs, notification.HostID) {
+			if len(p.Tags) == 0 || notification.ServiceID == "" {
+				continue
+			}
+			if !tagsLoaded {
+				tags = m.serviceTags(ctx, notification.ServiceID)
+				tagsLoaded = true
+			}
+			if !slices.ContainsFunc(p.Tags, func(tag string) bool { return slices.Contains(tags, tag) }) {
+				continue
+			}
+		}
+		for _, ch := range personalChannels(p) {
+			deliveries().enqueue(&delivery{manager: m, channel: ch, notification: notification, personal: true})
+		}
+	}
+}
+
+// notificationSeverity returns the severity of a notification. Service
+// down alerts and error logs carry none and are critical, recoveries info.
+func notificationSeverity(n Notification) models.AlertSeverity {
+	switch {
+	case n.Severity != "":
+		return models.AlertSeverity(strings.ToLower(n.Severity))
+	case n.Status == models.StatusHealthy:
+		return models.AlertSeverityInfo
+	case strings.EqualFold(n.LogLevel, "warn"):
+		return models.AlertSeverityWarning
+	default:
+		return models.AlertSeverityCritical
+	}
+}
+
+func (m *Manager) serviceTags(ctx context.Context, serviceID string) []string {
+	service, err := m.services.GetByID(ctx, serviceID)
+	if err != nil {
+		log.Printf("Failed to get tags of service %s: %v", serviceID, err)
+		return nil
+	}
+	if service == nil {
+		return nil
+	}
+	return service.Tags
+}
+
+// personalChannels returns the channels of a user: their Telegram chat,
+// sent to by the alerts.channels.telegram bot, and their email address
+func personalChannels(p *models.NotificationPreferences) []models.NotificationChannel {
+	var channels []models.NotificationChannel
+	add := func(channelType string, cfg interface{}) {
+		data, _ := json.Marshal(cfg)
+		channels = append(channels, models.NotificationChannel{
+			ID:        "user:" + p.Owner,
+			Name:      "user " + p.Owner,
+			Type:      channelType,
+			Config:    string(data),
+			IsEnabled: true,
+		})
+	}
+	if p.TelegramChatID != "" {
+		var botToken string
+		if cfg := config.Get(); cfg != nil {
+			botToken = cfg.Alerts.Channels.Telegram.BotToken
+		}
+		if botToken == "" {
+			log.Printf("Not sending to the Telegram chat of %s: alerts.channels.telegram.botToken is not set", p.Owner)
+		} else {
+			add("telegram", models.TelegramConfig{BotToken: botToken, ChatID: p.TelegramChatID, Language: p.Language, Timezone: p.Timezone})
+		}
+	}
+	if p.Email != "" {
+		add("email", models.EmailConfig{To: p.Email, Language: p.Language, Timezone: p.Timezone})
+	}
+	return channels
+}
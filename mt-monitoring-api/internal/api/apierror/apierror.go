@@ -0,0 +1,101 @@
+// Package apierror defines the error body of the API and the catalog of its
+// error codes. Every failed request answers
+//
+//	{"success": false, "error": {"code": "...", "message": "...", "fields": [...], "requestId": "..."}}
+//
+// where code is one of the catalog, fields lists the invalid request fields
+// of a VALIDATION_ERROR and requestId is the X-Request-ID of the request.
+package apierror
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestIDKey is the Locals key holding the correlation ID of a request,
+// set by the request ID middleware
+const RequestIDKey = "requestid"
+
+// FieldError is an invalid field of a request body or query, named by its
+// JSON path (e.g. "ownership.owner"). Request validators return it; Message
+// is the whole sentence answered to the client.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Invalid returns the FieldError of field. An empty field is for errors
+// that are not about a single field.
+func Invalid(field, message string) *FieldError {
+	return &FieldError{Field: field, Message: message}
+}
+
+// Error is the error object of a failed response
+type Error struct {
+	Code      Code         `json:"code"`
+	Message   string       `json:"message"`
+	Fields    []FieldError `json:"fields,omitempty"`
+	RequestID string       `json:"requestId,omitempty"`
+}
+
+// New returns the error object of a request, for responses that carry
+// data along with it
+func New(c *fiber.Ctx, code Code, message string, fields ...FieldError) Error {
+	return Error{
+		Code:      code,
+		Message:   message,
+		Fields:    fields,
+		RequestID: RequestID(c),
+	}
+}
+
+// Send answers the request with status and the error body
+func Send(c *fiber.Ctx, status int, code Code, message string, fields ...FieldError) error {
+	return c.Status(status).JSON(fiber.Map{
+		"success": false,
+		"error":   New(c, code, message, fields...),
+	})
+}
+
+// Validation answers 400 VALIDATION_ERROR with the message of a validator
+// error and, when it names one, its field
+func Validation(c *fiber.Ctx, err *FieldError) error {
+	if err.Field == "" {
+		return Send(c, fiber.StatusBadRequest, ValidationError, err.Message)
+	}
+	return Send(c, fiber.StatusBadRequest, ValidationError, err.Message, *err)
+}
+
+// FromError answers the request for an error returned by a handler or
+// middleware instead of a response: a *fiber.Error keeps its status,
+// anything else (including recovered panics) is a 500
+func FromError(c *fiber.Ctx, err error) error {
+	var e *fiber.Error
+	if !errors.As(err, &e) {
+		return Send(c, fiber.StatusInternalServerError, InternalError, err.Error())
+	}
+	code := InvalidRequest
+	switch {
+	case e.Code >= 500:
+		code = InternalError
+	case e.Code == fiber.StatusUnauthorized:
+		code = Unauthorized
+	case e.Code == fiber.StatusForbidden:
+		code = Forbidden
+	case e.Code == fiber.StatusNotFound:
+		code = NotFound
+	case e.Code == fiber.StatusRequestEntityTooLarge:
+		code = PayloadTooLarge
+	case e.Code == fiber.StatusTooManyRequests:
+		code = RateLimited
+	}
+	return Send(c, e.Code, code, e.Message)
+}
+
+// RequestID returns the correlation ID of a request, "" before the request
+// ID middleware ran
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDKey).(string)
+	return id
+}
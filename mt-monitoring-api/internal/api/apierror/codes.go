@@ -0,0 +1,145 @@
+package apierror
+
+import "net/http"
+
+// Code is a machine-readable error code. Clients branch on it rather than
+// on the message, which is meant for people and may change.
+type Code string
+
+// Error codes. Each is answered with the status of its catalog entry.
+const (
+	// Request errors
+	ValidationError          Code = "VALIDATION_ERROR"
+	InvalidRequest           Code = "INVALID_REQUEST"
+	InvalidConfig            Code = "INVALID_CONFIG"
+	InvalidType              Code = "INVALID_TYPE"
+	AuthConfigError          Code = "AUTH_CONFIG_ERROR"
+	CannotDeleteLocal        Code = "CANNOT_DELETE_LOCAL"
+	CannotDeleteDefault      Code = "CANNOT_DELETE_DEFAULT"
+	Unauthorized             Code = "UNAUTHORIZED"
+	Forbidden                Code = "FORBIDDEN"
+	PayloadTooLarge          Code = "PAYLOAD_TOO_LARGE"
+	RateLimited              Code = "RATE_LIMITED"
+	NotSupported             Code = "NOT_SUPPORTED"
+	NoCollector              Code = "NO_COLLECTOR"
+	StreamUnavailable        Code = "STREAM_UNAVAILABLE"
+	ManagedByGitOps          Code = "MANAGED_BY_GITOPS"
+	MaintenanceNotInProgress Code = "MAINTENANCE_NOT_IN_PROGRESS"
+	KeyRotationFailed        Code = "KEY_ROTATION_FAILED"
+
+	// Missing resources
+	NotFound            Code = "NOT_FOUND"
+	ServiceNotFound     Code = "SERVICE_NOT_FOUND"
+	HostNotFound        Code = "HOST_NOT_FOUND"
+	IncidentNotFound    Code = "INCIDENT_NOT_FOUND"
+	PostmortemNotFound  Code = "POSTMORTEM_NOT_FOUND"
+	MaintenanceNotFound Code = "MAINTENANCE_NOT_FOUND"
+	ComponentNotFound   Code = "COMPONENT_NOT_FOUND"
+	SubscriberNotFound  Code = "SUBSCRIBER_NOT_FOUND"
+	TagNotFound         Code = "TAG_NOT_FOUND"
+	ViewNotFound        Code = "VIEW_NOT_FOUND"
+	PortsNotFound       Code = "PORTS_NOT_FOUND"
+	FactsNotFound       Code = "FACTS_NOT_FOUND"
+	UpdatesNotFound     Code = "UPDATES_NOT_FOUND"
+	SampleNotFound      Code = "SAMPLE_NOT_FOUND"
+	ProjectNotFound     Code = "PROJECT_NOT_FOUND"
+
+	// Conflicts
+	ServiceExists   Code = "SERVICE_EXISTS"
+	HostExists      Code = "HOST_EXISTS"
+	ComponentExists Code = "COMPONENT_EXISTS"
+	TagExists       Code = "TAG_EXISTS"
+	PingKeyExists   Code = "PING_KEY_EXISTS"
+	ProjectExists   Code = "PROJECT_EXISTS"
+	ProjectNotEmpty Code = "PROJECT_NOT_EMPTY"
+
+	// Server errors
+	InternalError       Code = "INTERNAL_ERROR"
+	DatabaseError       Code = "DATABASE_ERROR"
+	FetchError          Code = "FETCH_ERROR"
+	CreateError         Code = "CREATE_ERROR"
+	UpdateError         Code = "UPDATE_ERROR"
+	DeleteError         Code = "DELETE_ERROR"
+	ToggleError         Code = "TOGGLE_ERROR"
+	SendError           Code = "SEND_ERROR"
+	CollectFailed       Code = "COLLECT_FAILED"
+	HistoryFetchFailed  Code = "HISTORY_FETCH_FAILED"
+	ProcessFetchFailed  Code = "PROCESS_FETCH_FAILED"
+	SSHConnectionFailed Code = "SSH_CONNECTION_FAILED"
+	DiagnosticsFailed   Code = "DIAGNOSTICS_FAILED"
+	BackupFailed        Code = "BACKUP_FAILED"
+	ReconcileFailed     Code = "RECONCILE_FAILED"
+)
+
+// Entry describes an error code in the catalog
+type Entry struct {
+	Code        Code   `json:"code"`
+	Status      int    `json:"status"`
+	Description string `json:"description"`
+}
+
+// catalog lists every error code the API answers with
+var catalog = []Entry{
+	{ValidationError, http.StatusBadRequest, "A request field is missing or invalid; fields names it when known"},
+	{InvalidRequest, http.StatusBadRequest, "The request body, path or query can't be parsed"},
+	{InvalidConfig, http.StatusBadRequest, "The settings of a notification channel are invalid"},
+	{InvalidType, http.StatusBadRequest, "The notification channel type is not supported"},
+	{AuthConfigError, http.StatusBadRequest, "The SSH authentication settings are invalid"},
+	{CannotDeleteLocal, http.StatusBadRequest, "The local host can't be deleted"},
+	{CannotDeleteDefault, http.StatusBadRequest, "The default project can't be deleted"},
+	{Unauthorized, http.StatusUnauthorized, "The API key or bearer token is missing or invalid"},
+	{Forbidden, http.StatusForbidden, "Project tokens can't use instance-wide endpoints; they need the admin token"},
+	{PayloadTooLarge, http.StatusRequestEntityTooLarge, "The request body exceeds the ingestion limit of the service"},
+	{RateLimited, http.StatusTooManyRequests, "The ingestion rate limit of the service is reached; see Retry-After"},
+	{NotSupported, http.StatusNotImplemented, "The collector of the host doesn't support the request"},
+	{NoCollector, http.StatusServiceUnavailable, "The host has no active collector"},
+	{StreamUnavailable, http.StatusServiceUnavailable, "Live streaming is not enabled"},
+	{ManagedByGitOps, http.StatusConflict, "The resource is declared in the GitOps repository and can't be changed through the API"},
+	{MaintenanceNotInProgress, http.StatusConflict, "The maintenance window has already ended"},
+	{KeyRotationFailed, http.StatusConflict, "The encryption key can't be rotated in the current state"},
+
+	{NotFound, http.StatusNotFound, "The resource or route doesn't exist"},
+	{ServiceNotFound, http.StatusNotFound, "The service doesn't exist"},
+	{HostNotFound, http.StatusNotFound, "The host doesn't exist"},
+	{IncidentNotFound, http.StatusNotFound, "The incident doesn't exist"},
+	{PostmortemNotFound, http.StatusNotFound, "The incident has no postmortem"},
+	{MaintenanceNotFound, http.StatusNotFound, "The maintenance window doesn't exist"},
+	{ComponentNotFound, http.StatusNotFound, "The status page component doesn't exist"},
+	{SubscriberNotFound, http.StatusNotFound, "The status page subscriber doesn't exist"},
+	{TagNotFound, http.StatusNotFound, "No service has the tag"},
+	{ViewNotFound, http.StatusNotFound, "The saved view doesn't exist"},
+	{PortsNotFound, http.StatusNotFound, "No listening ports were collected for the host"},
+	{FactsNotFound, http.StatusNotFound, "No facts were collected for the host"},
+	{UpdatesNotFound, http.StatusNotFound, "No security updates were collected for the host"},
+	{SampleNotFound, http.StatusNotFound, "No process sample exists at the requested time"},
+	{ProjectNotFound, http.StatusNotFound, "The project doesn't exist"},
+
+	{ServiceExists, http.StatusConflict, "A service with the ID already exists"},
+	{HostExists, http.StatusConflict, "A host with the ID already exists"},
+	{ComponentExists, http.StatusConflict, "A status page component with the ID already exists"},
+	{TagExists, http.StatusConflict, "The new tag name is already in use"},
+	{PingKeyExists, http.StatusConflict, "Another heartbeat service uses the ping key"},
+	{ProjectExists, http.StatusConflict, "A project with the ID already exists"},
+	{ProjectNotEmpty, http.StatusConflict, "The project still has services, hosts, alert rules or notification channels"},
+
+	{InternalError, http.StatusInternalServerError, "An unexpected server error occurred"},
+	{DatabaseError, http.StatusInternalServerError, "A database query failed"},
+	{FetchError, http.StatusInternalServerError, "Notification channels can't be loaded"},
+	{CreateError, http.StatusInternalServerError, "The resource can't be created"},
+	{UpdateError, http.StatusInternalServerError, "The resource can't be updated"},
+	{DeleteError, http.StatusInternalServerError, "The resource can't be deleted"},
+	{ToggleError, http.StatusInternalServerError, "The notification channel can't be enabled or disabled"},
+	{SendError, http.StatusInternalServerError, "The test notification can't be sent"},
+	{CollectFailed, http.StatusInternalServerError, "Collecting from the host failed"},
+	{HistoryFetchFailed, http.StatusInternalServerError, "The metric history of the host can't be loaded"},
+	{ProcessFetchFailed, http.StatusInternalServerError, "The processes of the host can't be loaded"},
+	{SSHConnectionFailed, http.StatusOK, "The SSH connection test ran and failed to connect"},
+	{DiagnosticsFailed, http.StatusInternalServerError, "Running the diagnostics failed"},
+	{BackupFailed, http.StatusInternalServerError, "Creating the backup failed"},
+	{ReconcileFailed, http.StatusInternalServerError, "Syncing with the GitOps repository failed"},
+}
+
+// Catalog returns every error code with its status and description
+func Catalog() []Entry {
+	return catalog
+}
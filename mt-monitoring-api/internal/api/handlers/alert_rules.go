@@ -1,36 +1,44 @@
 package handlers
 
 import (
+	"net/url"
+	"regexp"
+	"slices"
+	"strconv"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // AlertRuleHandler handles alert rule CRUD operations
 type AlertRuleHandler struct {
-	repo *database.AlertRuleRepository
+	repo         database.AlertRuleRepository
+	remediations database.RemediationRepository
+	projects     database.ProjectRepository
+	states       database.AlertRuleStateRepository
 }
 
 // NewAlertRuleHandler creates a new alert rule handler
-func NewAlertRuleHandler() *AlertRuleHandler {
+func NewAlertRuleHandler(store *database.Store) *AlertRuleHandler {
 	return &AlertRuleHandler{
-		repo: database.NewAlertRuleRepository(),
+		repo:         store.AlertRules,
+		remediations: store.Remediations,
+		projects:     store.Projects,
+		states:       store.AlertRuleStates,
 	}
 }
 
-// GetAll returns all alert rules
+// GetAll returns the alert rules of the request's project
 func (h *AlertRuleHandler) GetAll(c *fiber.Ctx) error {
-	rules, err := h.repo.GetAll()
+	rules, err := h.repo.GetAll(c.UserContext())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch alert rules",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rules")
 	}
+	rules = slices.DeleteFunc(rules, func(r models.AlertRule) bool { return !inRequestProject(c, r.ProjectID) })
 	if rules == nil {
 		rules = []models.AlertRule{}
 	}
@@ -41,28 +49,116 @@ func (h *AlertRuleHandler) GetAll(c *fiber.Ctx) error {
 	})
 }
 
+// GetStates returns the hosts and services in breach of a host or service
+// rule, or alerting, as persisted by the evaluators: the rule, the target,
+// the consecutive breaches and when it last alerted. ?ruleId= narrows it to
+// one rule.
+// GET /alert-rules/states
+func (h *AlertRuleHandler) GetStates(c *fiber.Ctx) error {
+	states, err := h.states.GetActive(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	ruleID := c.Query("ruleId")
+	states = slices.DeleteFunc(states, func(s models.AlertRuleState) bool {
+		return !inRequestProject(c, s.ProjectID) || (ruleID != "" && s.RuleID != ruleID)
+	})
+	if states == nil {
+		states = []models.AlertRuleState{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    states,
+	})
+}
+
+// GetActive returns every rule currently in breach or alerting for a host
+// or service, with its last value, how long it has been in breach and its
+// target, from the state persisted by the evaluators. Log rules keep no
+// state and are not listed. ?severity= and ?status= (alerting or pending)
+// narrow the list.
+// GET /alerts/active
+func (h *AlertRuleHandler) GetActive(c *fiber.Ctx) error {
+	severity := models.AlertSeverity(c.Query("severity"))
+	if severity != "" && !severity.IsValid() {
+		return invalidField(c, "severity", "severity must be one of: critical, warning, info")
+	}
+	status := c.Query("status")
+	if status != "" && status != models.ActiveAlertAlerting && status != models.ActiveAlertPending {
+		return invalidField(c, "status", "status must be one of: alerting, pending")
+	}
+
+	states, err := h.states.GetActive(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	rules, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rules")
+	}
+	byID := make(map[string]*models.AlertRule, len(rules))
+	for i := range rules {
+		byID[rules[i].ID] = &rules[i]
+	}
+
+	now := time.Now()
+	alerts := []models.ActiveAlert{}
+	for _, s := range states {
+		rule := byID[s.RuleID]
+		if rule == nil || !inRequestProject(c, s.ProjectID) || (severity != "" && s.Severity != severity) {
+			continue
+		}
+		alert := models.ActiveAlert{
+			RuleID:        s.RuleID,
+			RuleName:      s.RuleName,
+			RuleType:      rule.Type,
+			ProjectID:     s.ProjectID,
+			Severity:      s.Severity,
+			Status:        models.ActiveAlertPending,
+			TargetType:    s.TargetType,
+			TargetID:      s.TargetID,
+			TargetName:    s.TargetName,
+			Metric:        rule.Metric,
+			Operator:      rule.Operator,
+			Threshold:     rule.Threshold,
+			Value:         s.Value,
+			BreachCount:   s.BreachCount,
+			Since:         s.BreachStartedAt,
+			LastAlertedAt: s.LastAlertedAt,
+			UpdatedAt:     s.UpdatedAt,
+		}
+		if s.IsAlerting {
+			alert.Status = models.ActiveAlertAlerting
+		}
+		if status != "" && alert.Status != status {
+			continue
+		}
+		if alert.Since == nil {
+			alert.Since = s.LastAlertedAt
+		}
+		if alert.Since != nil {
+			alert.Duration = int64(now.Sub(*alert.Since).Seconds())
+		}
+		alerts = append(alerts, alert)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    alerts,
+	})
+}
+
 // GetByID returns a single alert rule
 func (h *AlertRuleHandler) GetByID(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	rule, err := h.repo.GetByID(id)
+	rule, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch alert rule",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rule")
 	}
 	if rule == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Alert rule not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Alert rule not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -75,58 +171,58 @@ func (h *AlertRuleHandler) GetByID(c *fiber.Ctx) error {
 func (h *AlertRuleHandler) Create(c *fiber.Ctx) error {
 	var req models.AlertRuleCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
 	}
 
 	// Validate required fields
 	if req.Name == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "name is required",
-			},
-		})
+		return invalidField(c, "name", "name is required")
 	}
 	if req.Type == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "type is required",
-			},
-		})
+		return invalidField(c, "type", "type is required")
+	}
+	if req.Type == models.AlertRuleTypeLog {
+		if invalid := validateLogRule(req.Pattern, req.LogLevel); invalid != nil {
+			return validationError(c, invalid)
+		}
+		req.Metric = models.AlertMetricLogMatch
+	}
+	if req.Type == models.AlertRuleTypeSLO {
+		if invalid := validateSLORule(req.Objective, req.LatencyTarget, req.ShortWindow, req.LongWindow); invalid != nil {
+			return validationError(c, invalid)
+		}
+		req.Metric = models.AlertMetricBurnRate
 	}
 	if req.Metric == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "metric is required",
-			},
-		})
+		return invalidField(c, "metric", "metric is required")
+	}
+	if invalid := validateResourceCategory(req.Type, req.ResourceCategory); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	if req.Remediation != nil {
+		if !isAdmin(c) {
+			return errorResponse(c, 403, apierror.Forbidden, remediationForbidden)
+		}
+		if invalid := validateRemediation(req.Type, req.Remediation); invalid != nil {
+			return validationError(c, invalid)
+		}
 	}
 
 	rule := req.ToAlertRule(uuid.New().String())
+	rule.ProjectID = newResourceProject(c)
+	if invalid, err := h.foreignReference(c, rule.ProjectID, rule.HostID, rule.ServiceID, rule.ChannelIDs, rule.Remediation); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
 
-	if err := h.repo.Create(rule); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "CREATE_ERROR",
-				"message": "Failed to create alert rule",
-			},
-		})
+	if err := h.repo.Create(c.UserContext(), rule); err != nil {
+		return errorResponse(c, 500, apierror.CreateError, "Failed to create alert rule")
 	}
 
 	// Re-fetch to include channel IDs
-	created, _ := h.repo.GetByID(rule.ID)
+	created, _ := h.repo.GetByID(c.UserContext(), rule.ID)
 	if created == nil {
 		created = rule
 	}
@@ -141,86 +237,130 @@ func (h *AlertRuleHandler) Create(c *fiber.Ctx) error {
 func (h *AlertRuleHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	existing, err := h.repo.GetByID(id)
+	existing, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch alert rule",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rule")
 	}
 	if existing == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Alert rule not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Alert rule not found")
 	}
 
 	var req models.AlertRuleUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
-	}
-
-	if err := h.repo.Update(id, &req); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "UPDATE_ERROR",
-				"message": "Failed to update alert rule",
-			},
-		})
-	}
-
-	updated, _ := h.repo.GetByID(id)
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+
+	if existing.Type == models.AlertRuleTypeLog && (req.Pattern != nil || req.LogLevel != nil) {
+		pattern, level := existing.Pattern, existing.LogLevel
+		if req.Pattern != nil {
+			pattern = *req.Pattern
+		}
+		if req.LogLevel != nil {
+			level = *req.LogLevel
+		}
+		if invalid := validateLogRule(pattern, level); invalid != nil {
+			return validationError(c, invalid)
+		}
+	}
+
+	if existing.Type == models.AlertRuleTypeSLO &&
+		(req.Objective != nil || req.LatencyTarget != nil || req.ShortWindow != nil || req.LongWindow != nil) {
+		objective, latencyTarget := existing.Objective, existing.LatencyTarget
+		shortWindow, longWindow := existing.ShortWindow, existing.LongWindow
+		if req.Objective != nil {
+			objective = *req.Objective
+		}
+		if req.LatencyTarget != nil {
+			latencyTarget = *req.LatencyTarget
+		}
+		if req.ShortWindow != nil {
+			shortWindow = *req.ShortWindow
+		}
+		if req.LongWindow != nil {
+			longWindow = *req.LongWindow
+		}
+		if invalid := validateSLORule(objective, latencyTarget, shortWindow, longWindow); invalid != nil {
+			return validationError(c, invalid)
+		}
+	}
+
+	if req.ResourceCategory != nil {
+		if invalid := validateResourceCategory(existing.Type, *req.ResourceCategory); invalid != nil {
+			return validationError(c, invalid)
+		}
+	}
+
+	// Moving a rule to another host moves where its command runs
+	retargeted := existing.Remediation != nil && req.HostID != nil &&
+		(existing.HostID == nil || *req.HostID != *existing.HostID)
+	if (req.Remediation != nil || retargeted) && !isAdmin(c) {
+		return errorResponse(c, 403, apierror.Forbidden, remediationForbidden)
+	}
+	if req.Remediation != nil && req.Remediation.Type != "" {
+		if invalid := validateRemediation(existing.Type, req.Remediation); invalid != nil {
+			return validationError(c, invalid)
+		}
+		req.Remediation.ApplyDefaults()
+	}
+
+	var channelIDs []string
+	if req.ChannelIDs != nil {
+		channelIDs = *req.ChannelIDs
+	}
+	if invalid, err := h.foreignReference(c, existing.ProjectID, req.HostID, req.ServiceID, channelIDs, req.Remediation); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	if err := h.repo.Update(c.UserContext(), id, &req); err != nil {
+		return errorResponse(c, 500, apierror.UpdateError, "Failed to update alert rule")
+	}
+
+	updated, _ := h.repo.GetByID(c.UserContext(), id)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    updated,
 	})
 }
 
+// GetRemediations returns the latest remediation runs of an alert rule,
+// newest first
+func (h *AlertRuleHandler) GetRemediations(c *fiber.Ctx) error {
+	id := c.Params("id")
+	limit, _ := strconv.Atoi(c.Query("limit", "50"))
+	if limit <= 0 || limit > 500 {
+		limit = 50
+	}
+
+	runs, err := h.remediations.GetByRule(c.UserContext(), id, limit)
+	if err != nil {
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch remediation runs")
+	}
+	if runs == nil {
+		runs = []models.RemediationRun{}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    runs,
+	})
+}
+
 // Delete deletes an alert rule
 func (h *AlertRuleHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	existing, err := h.repo.GetByID(id)
+	existing, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch alert rule",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rule")
 	}
 	if existing == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Alert rule not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Alert rule not found")
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DELETE_ERROR",
-				"message": "Failed to delete alert rule",
-			},
-		})
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return errorResponse(c, 500, apierror.DeleteError, "Failed to delete alert rule")
 	}
 
 	return c.JSON(fiber.Map{
@@ -233,35 +373,17 @@ func (h *AlertRuleHandler) Delete(c *fiber.Ctx) error {
 func (h *AlertRuleHandler) Toggle(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	existing, err := h.repo.GetByID(id)
+	existing, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch alert rule",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch alert rule")
 	}
 	if existing == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Alert rule not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Alert rule not found")
 	}
 
 	newEnabled := !existing.IsEnabled
-	if err := h.repo.SetEnabled(id, newEnabled); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "UPDATE_ERROR",
-				"message": "Failed to toggle alert rule",
-			},
-		})
+	if err := h.repo.SetEnabled(c.UserContext(), id, newEnabled); err != nil {
+		return errorResponse(c, 500, apierror.UpdateError, "Failed to toggle alert rule")
 	}
 
 	return c.JSON(fiber.Map{
@@ -272,3 +394,124 @@ func (h *AlertRuleHandler) Toggle(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// validateLogRule checks the pattern and level of a log rule, returning the
+// problem or nil
+func validateLogRule(pattern string, level models.LogLevel) *apierror.FieldError {
+	if pattern == "" {
+		return apierror.Invalid("pattern", "pattern is required for log rules")
+	}
+	if _, err := regexp.Compile(pattern); err != nil {
+		return apierror.Invalid("pattern", "invalid pattern: "+err.Error())
+	}
+	if level != "" && !level.IsValid() {
+		return apierror.Invalid("logLevel", "logLevel must be one of: error, warn, info")
+	}
+	return nil
+}
+
+// validateResourceCategory checks the host resource category a rule
+// targets, returning the problem or nil
+func validateResourceCategory(ruleType models.AlertRuleType, category models.HostResourceCategory) *apierror.FieldError {
+	if category == "" {
+		return nil
+	}
+	if ruleType != models.AlertRuleTypeResource {
+		return apierror.Invalid("resourceCategory", "resourceCategory only applies to resource rules")
+	}
+	if !category.IsValid() {
+		return apierror.Invalid("resourceCategory", "resourceCategory must be one of: server, database, container")
+	}
+	return nil
+}
+
+// validateSLORule checks the objective and windows of an SLO rule, returning
+// the problem or nil. Zero windows take their defaults.
+func validateSLORule(objective float64, latencyTarget, shortWindow, longWindow int) *apierror.FieldError {
+	if objective <= 0 || objective >= 100 {
+		return apierror.Invalid("objective", "objective must be a percentage between 0 and 100, e.g. 99.9")
+	}
+	if latencyTarget < 0 {
+		return apierror.Invalid("latencyTarget", "latencyTarget must not be negative")
+	}
+	if shortWindow < 0 {
+		return apierror.Invalid("shortWindow", "shortWindow must not be negative")
+	}
+	if longWindow < 0 {
+		return apierror.Invalid("longWindow", "longWindow must not be negative")
+	}
+	if shortWindow > 0 && longWindow > 0 && shortWindow >= longWindow {
+		return apierror.Invalid("shortWindow", "shortWindow must be shorter than longWindow")
+	}
+	return nil
+}
+
+// remediationForbidden answers requests without the admin token that set
+// or retarget a remediation action, which runs commands on the hosts
+const remediationForbidden = "Remediation actions can only be set or changed with the admin token"
+
+// validateRemediation checks the remediation action of a rule, returning
+// the problem or nil. Commands of rules on services need the host to run
+// on; resource rules run them on the alerting host by default.
+func validateRemediation(ruleType models.AlertRuleType, action *models.Remediation) *apierror.FieldError {
+	if action.Type == "" {
+		return nil
+	}
+	switch action.Type {
+	case models.RemediationSSH:
+		if action.Command == "" {
+			return apierror.Invalid("remediation.command", "remediation.command is required for ssh remediation")
+		}
+		if action.HostID == "" && ruleType != models.AlertRuleTypeResource {
+			return apierror.Invalid("remediation.hostId", "remediation.hostId is required for ssh remediation of "+string(ruleType)+" rules")
+		}
+	case models.RemediationWebhook:
+		u, err := url.Parse(action.URL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return apierror.Invalid("remediation.url", "remediation.url must be an http or https URL")
+		}
+	default:
+		return apierror.Invalid("remediation.type", "remediation.type must be one of: ssh, webhook")
+	}
+	if action.MaxRuns < 0 {
+		return apierror.Invalid("remediation.maxRuns", "remediation.maxRuns must not be negative")
+	}
+	if action.Window < 0 {
+		return apierror.Invalid("remediation.window", "remediation.window must not be negative")
+	}
+	return nil
+}
+
+// foreignReference returns the invalid field for a host, service,
+// notification channel or remediation host of a rule that belongs to
+// another project than projectID, or nil. Rules only watch and notify
+// within their project; IDs that don't exist are not checked.
+func (h *AlertRuleHandler) foreignReference(c *fiber.Ctx, projectID string, hostID, serviceID *string, channelIDs []string, remediation *models.Remediation) (*apierror.FieldError, error) {
+	type reference struct {
+		kind, id, field, message string
+	}
+	var refs []reference
+	if hostID != nil && *hostID != "" {
+		refs = append(refs, reference{models.ProjectHost, *hostID, "hostId", "hostId must be a host of the rule's project"})
+	}
+	if serviceID != nil && *serviceID != "" {
+		refs = append(refs, reference{models.ProjectService, *serviceID, "serviceId", "serviceId must be a service of the rule's project"})
+	}
+	for _, id := range channelIDs {
+		refs = append(refs, reference{models.ProjectChannel, id, "channelIds", "channelIds must be notification channels of the rule's project"})
+	}
+	if remediation != nil && remediation.HostID != "" {
+		refs = append(refs, reference{models.ProjectHost, remediation.HostID, "remediation.hostId", "remediation.hostId must be a host of the rule's project"})
+	}
+
+	for _, ref := range refs {
+		owner, err := h.projects.GetResourceProject(c.UserContext(), ref.kind, ref.id)
+		if err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != projectID {
+			return apierror.Invalid(ref.field, ref.message), nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// AlertmanagerHandler receives Prometheus Alertmanager webhooks
+type AlertmanagerHandler struct {
+	receiver *alerter.AlertmanagerReceiver
+	alerts   database.ExternalAlertRepository
+}
+
+// NewAlertmanagerHandler creates a new Alertmanager handler
+func NewAlertmanagerHandler(store *database.Store) *AlertmanagerHandler {
+	return &AlertmanagerHandler{
+		receiver: alerter.NewAlertmanagerReceiver(store, alerter.NewManager(store)),
+		alerts:   store.ExternalAlerts,
+	}
+}
+
+// Webhook applies an Alertmanager webhook delivery. A 5xx answer makes
+// Alertmanager retry the delivery.
+// POST /alertmanager/webhook
+func (h *AlertmanagerHandler) Webhook(c *fiber.Ctx) error {
+	var payload models.AlertmanagerWebhook
+	if err := c.BodyParser(&payload); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body: "+err.Error())
+	}
+
+	result, err := h.receiver.Receive(c.UserContext(), &payload)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// GetAlerts returns the received alerts, optionally filtered by status
+// GET /alertmanager/alerts?status=firing|resolved
+func (h *AlertmanagerHandler) GetAlerts(c *fiber.Ctx) error {
+	status := c.Query("status")
+	if status != "" && status != models.AlertmanagerFiring && status != models.AlertmanagerResolved {
+		return invalidField(c, "status", "status must be firing or resolved")
+	}
+
+	alerts, err := h.alerts.GetByStatus(c.UserContext(), status)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    alerts,
+	})
+}
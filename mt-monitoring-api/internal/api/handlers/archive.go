@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/archive"
+)
+
+// ArchiveHandler serves read-only access to archived incidents and notification history
+type ArchiveHandler struct {
+	manager *archive.Manager
+}
+
+// NewArchiveHandler creates a new archive handler
+func NewArchiveHandler(manager *archive.Manager) *ArchiveHandler {
+	return &ArchiveHandler{
+		manager: manager,
+	}
+}
+
+// GetMonths lists archived months and their files
+// GET /archives
+func (h *ArchiveHandler) GetMonths(c *fiber.Ctx) error {
+	months, err := h.manager.Months()
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, "Failed to list archives")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    months,
+	})
+}
+
+// GetRecords returns the archived records of one kind for a month
+// GET /archives/:month/:kind
+func (h *ArchiveHandler) GetRecords(c *fiber.Ctx) error {
+	records, err := h.manager.Read(c.Params("month"), c.Params("kind"))
+	if err != nil {
+		switch {
+		case errors.Is(err, archive.ErrInvalidMonth), errors.Is(err, archive.ErrInvalidKind):
+			return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+		case errors.Is(err, archive.ErrNotFound):
+			return errorResponse(c, 404, apierror.NotFound, "Archive not found")
+		}
+		return errorResponse(c, 500, apierror.InternalError, "Failed to read archive")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"items": records,
+			"total": len(records),
+		},
+	})
+}
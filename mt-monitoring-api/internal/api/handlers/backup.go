@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/backup"
+)
+
+// BackupHandler handles database backup requests
+type BackupHandler struct {
+	manager *backup.Manager
+}
+
+// NewBackupHandler creates a new backup handler
+func NewBackupHandler(manager *backup.Manager) *BackupHandler {
+	return &BackupHandler{
+		manager: manager,
+	}
+}
+
+// Create takes a consistent database snapshot and uploads it when S3 is configured
+func (h *BackupHandler) Create(c *fiber.Ctx) error {
+	result, err := h.manager.Run(c.UserContext())
+	if err != nil {
+		resp := fiber.Map{
+			"success": false,
+			"error":   apierror.New(c, apierror.BackupFailed, err.Error()),
+		}
+		// The local snapshot may exist even if the upload failed
+		if result != nil {
+			resp["data"] = result
+		}
+		return c.Status(500).JSON(resp)
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,33 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/cluster"
+)
+
+// ClusterHandler reports cluster membership
+type ClusterHandler struct{}
+
+// NewClusterHandler creates a new cluster handler
+func NewClusterHandler() *ClusterHandler {
+	return &ClusterHandler{}
+}
+
+// Status returns the live nodes as this node sees them, whether it is the
+// leader and, with ?id=, which node checks a service or collects a host
+// GET /admin/cluster
+func (h *ClusterHandler) Status(c *fiber.Ctx) error {
+	status := cluster.Status()
+	data := fiber.Map{"cluster": status}
+	if id := c.Query("id"); id != "" && status.Enabled {
+		owner := status.NodeID
+		if node, ok := cluster.Owner(id); ok {
+			owner = node.ID
+		}
+		data["owner"] = owner
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    data,
+	})
+}
@@ -1,78 +1,66 @@
 package handlers
 
 import (
+	"sort"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // DashboardHandler handles dashboard-related requests
 type DashboardHandler struct {
-	serviceRepo  *database.ServiceRepository
-	metricRepo   *database.MetricRepository
-	incidentRepo *database.IncidentRepository
+	dashboardRepo database.DashboardRepository
+	metricRepo    database.MetricRepository
+	incidentRepo  database.IncidentRepository
 }
 
+// slowestServicesLimit is the number of services listed in slowestServices
+const slowestServicesLimit = 5
+
 // NewDashboardHandler creates a new dashboard handler
-func NewDashboardHandler() *DashboardHandler {
+func NewDashboardHandler(store *database.Store) *DashboardHandler {
 	return &DashboardHandler{
-		serviceRepo:  database.NewServiceRepository(),
-		metricRepo:   database.NewMetricRepository(),
-		incidentRepo: database.NewIncidentRepository(),
+		dashboardRepo: store.Dashboard,
+		metricRepo:    store.Metrics,
+		incidentRepo:  store.Incidents,
 	}
 }
 
-// GetSummary returns dashboard KPI summary
+// GetSummary returns dashboard KPI summary, or 304 when the client has it
 func (h *DashboardHandler) GetSummary(c *fiber.Ctx) error {
-	services, err := h.serviceRepo.GetAll()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if v, err := h.dashboardRepo.GetVersion(c.UserContext()); err == nil &&
+		notModified(c, v.Services, v.Checks, v.Incidents, v.Windows, versionMinute(), config.GetApdexThreshold()) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
-	summary := models.DashboardSummary{
-		TotalServices: len(services),
+	// Counts, uptime, incidents and slowest services in aggregate queries
+	summary, err := h.dashboardRepo.GetSummary(c.UserContext(), 24*time.Hour, slowestServicesLimit)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
-	var totalResponseTime float64
-	var totalUptime float64
-	validMetrics := 0
-
-	for _, service := range services {
-		// Get metrics summary for last 24h
-		metricSummary, err := h.metricRepo.GetSummary(service.ID, 24*time.Hour)
-		if err == nil && metricSummary.TotalChecks > 0 {
-			// Determine health from actual check results (service.Status from GetAll is always unknown)
-			if metricSummary.SuccessfulChecks > 0 {
-				summary.HealthyServices++
-			} else {
-				summary.UnhealthyServices++
-			}
-			totalResponseTime += metricSummary.AvgResponseTime
-			totalUptime += metricSummary.Uptime
-			validMetrics++
-		}
+	// Percentiles and Apdex per service
+	summary.ApdexThreshold = config.GetApdexThreshold()
+	performance, err := h.metricRepo.GetPerformanceStats(c.UserContext(), 24*time.Hour, summary.ApdexThreshold)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
-	if validMetrics > 0 {
-		summary.AvgResponseTime = totalResponseTime / float64(validMetrics)
-		summary.OverallUptime = totalUptime / float64(validMetrics)
+	summary.Performance = make([]models.PerformanceStats, 0, len(performance))
+	var totalApdex float64
+	for _, p := range performance {
+		summary.Performance = append(summary.Performance, p)
+		totalApdex += p.Apdex
 	}
-
-	// Get active incidents count
-	incidents, _ := h.incidentRepo.GetActive()
-	summary.CriticalAlerts = len(incidents)
-	// When exactly one incident is active, surface its service ID so the
-	// frontend can navigate directly to that service's detail page.
-	if len(incidents) == 1 {
-		summary.CriticalServiceID = incidents[0].ServiceID
+	sort.Slice(summary.Performance, func(i, j int) bool {
+		return summary.Performance[i].ServiceID < summary.Performance[j].ServiceID
+	})
+	if len(summary.Performance) > 0 {
+		summary.OverallApdex = totalApdex / float64(len(summary.Performance))
 	}
 
 	return c.JSON(fiber.Map{
@@ -85,15 +73,9 @@ func (h *DashboardHandler) GetSummary(c *fiber.Ctx) error {
 func (h *DashboardHandler) GetTimeline(c *fiber.Ctx) error {
 	limit := 20
 
-	events, err := h.incidentRepo.GetTimeline(limit)
+	events, err := h.incidentRepo.GetTimeline(c.UserContext(), limit)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -104,15 +86,9 @@ func (h *DashboardHandler) GetTimeline(c *fiber.Ctx) error {
 
 // GetIncidents returns all incidents
 func (h *DashboardHandler) GetIncidents(c *fiber.Ctx) error {
-	incidents, err := h.incidentRepo.GetActive()
+	incidents, err := h.incidentRepo.GetActive(c.UserContext())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
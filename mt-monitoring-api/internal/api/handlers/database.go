@@ -0,0 +1,75 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// DatabaseHandler handles database statistics and integrity requests
+type DatabaseHandler struct {
+	store *database.Store
+}
+
+// NewDatabaseHandler creates a new database handler
+func NewDatabaseHandler(store *database.Store) *DatabaseHandler {
+	return &DatabaseHandler{
+		store: store,
+	}
+}
+
+// Stats returns file sizes, per-table row counts and the last maintenance runs
+func (h *DatabaseHandler) Stats(c *fiber.Ctx) error {
+	stats, err := h.store.Stats(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	if cfg := config.Get(); cfg != nil && cfg.Database.MaxSizeMB > 0 {
+		stats.MaxSize = int64(cfg.Database.MaxSizeMB) * 1024 * 1024
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    stats,
+	})
+}
+
+// Integrity checks page structure, orphaned references and missing indexes
+// GET /admin/database/integrity?full=true
+func (h *DatabaseHandler) Integrity(c *fiber.Ctx) error {
+	report, err := h.store.CheckIntegrity(c.UserContext(), c.QueryBool("full"))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// RepairIntegrity removes orphaned rows and recreates missing indexes
+// POST /admin/database/integrity/repair
+func (h *DatabaseHandler) RepairIntegrity(c *fiber.Ctx) error {
+	var opts models.IntegrityRepairOptions
+	if err := c.BodyParser(&opts); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+
+	if !opts.DeleteOrphans && !opts.RecreateIndexes {
+		return invalidField(c, "", "Select at least one of deleteOrphans or recreateIndexes")
+	}
+
+	result, err := h.store.RepairIntegrity(c.UserContext(), opts)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
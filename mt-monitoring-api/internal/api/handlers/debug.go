@@ -0,0 +1,83 @@
+package handlers
+
+import (
+	"encoding/json"
+	"expvar"
+	"runtime"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api/websocket"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/collector"
+)
+
+// DebugHandler serves internal counters for profiling in production
+type DebugHandler struct {
+	scheduler    *checker.Scheduler
+	collectorMgr *collector.CollectorManager
+	hub          *websocket.Hub
+}
+
+// NewDebugHandler creates a new debug handler. collectorMgr may be nil
+// when system metric collection is disabled.
+func NewDebugHandler(scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager, hub *websocket.Hub) *DebugHandler {
+	return &DebugHandler{
+		scheduler:    scheduler,
+		collectorMgr: collectorMgr,
+		hub:          hub,
+	}
+}
+
+// Vars returns the published expvar variables (cmdline, memstats) plus
+// goroutine, WebSocket hub, notification queue, collector and scheduler
+// counters and the last self check, in the format of /debug/vars
+// GET /admin/debug/vars
+func (h *DebugHandler) Vars(c *fiber.Ctx) error {
+	vars := make(map[string]interface{})
+	expvar.Do(func(kv expvar.KeyValue) {
+		vars[kv.Key] = json.RawMessage(kv.Value.String())
+	})
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	vars["runtime"] = fiber.Map{
+		"goroutines":  runtime.NumGoroutine(),
+		"heapAlloc":   mem.HeapAlloc,
+		"heapInuse":   mem.HeapInuse,
+		"heapObjects": mem.HeapObjects,
+		"numGc":       mem.NumGC,
+		"uptime":      int64(time.Since(startTime).Seconds()),
+	}
+
+	if h.hub != nil {
+		vars["websocket"] = h.hub.Stats()
+	}
+	vars["notifications"] = alerter.QueueStats()
+	if h.collectorMgr != nil {
+		statuses := h.collectorMgr.Statuses()
+		buffered := 0
+		for _, s := range statuses {
+			buffered += s.Buffered
+		}
+		vars["collector"] = fiber.Map{
+			"collectors": len(statuses),
+			"buffered":   buffered,
+			"storage":    h.collectorMgr.StorageStats(),
+		}
+	}
+	if h.scheduler != nil {
+		state := h.scheduler.State()
+		vars["scheduler"] = fiber.Map{
+			"checks":      len(state.Checks),
+			"jobs":        len(state.Jobs),
+			"cronEntries": state.CronEntries,
+		}
+		if self := h.scheduler.SelfStatus(); self != nil {
+			vars["self"] = self
+		}
+	}
+
+	return c.JSON(vars)
+}
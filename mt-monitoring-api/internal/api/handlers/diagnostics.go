@@ -0,0 +1,38 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/diagnostics"
+)
+
+// DiagnosticsHandler handles support bundle requests
+type DiagnosticsHandler struct {
+	collector *diagnostics.Collector
+}
+
+// NewDiagnosticsHandler creates a new diagnostics handler
+func NewDiagnosticsHandler(collector *diagnostics.Collector) *DiagnosticsHandler {
+	return &DiagnosticsHandler{
+		collector: collector,
+	}
+}
+
+// Bundle returns a zip with recent logs, profiles, scheduler and collector
+// state, the redacted config and database statistics
+// GET /admin/diagnostics
+func (h *DiagnosticsHandler) Bundle(c *fiber.Ctx) error {
+	var buf bytes.Buffer
+	if err := h.collector.WriteBundle(c.UserContext(), &buf); err != nil {
+		return errorResponse(c, 500, apierror.DiagnosticsFailed, err.Error())
+	}
+
+	name := fmt.Sprintf("mt-diagnostics-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	c.Set(fiber.HeaderContentType, "application/zip")
+	c.Set(fiber.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", name))
+	return c.Send(buf.Bytes())
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"bytes"
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// EncryptionHandler handles encryption key administration
+type EncryptionHandler struct {
+	store *database.Store
+}
+
+// NewEncryptionHandler creates a new encryption handler
+func NewEncryptionHandler(store *database.Store) *EncryptionHandler {
+	return &EncryptionHandler{store: store}
+}
+
+// RotateKey re-encrypts stored secrets from the current (or given old) key
+// to a new one. On success the running server switches to the new key;
+// security.encryptionKey must be updated before the next restart.
+// POST /admin/encryption/rotate
+func (h *EncryptionHandler) RotateKey(c *fiber.Ctx) error {
+	var req models.KeyRotationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+
+	oldKey, newKey, invalid := rotationKeys(req)
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	result, err := h.store.RotateEncryptionKey(c.UserContext(), oldKey, newKey, req.DryRun)
+	if errors.Is(err, database.ErrKeyRotationFailed) {
+		return c.Status(409).JSON(fiber.Map{
+			"success": false,
+			"error":   apierror.New(c, apierror.KeyRotationFailed, err.Error()),
+			"data":    result,
+		})
+	}
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	if !result.Applied {
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    result,
+			"message": "Dry run: all values decrypt with the old key, nothing was changed",
+		})
+	}
+
+	crypto.SetKey(newKey)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+		"message": "Secrets re-encrypted. Set security.encryptionKey (or MT_SECURITY_ENCRYPTIONKEY) to the new key before restarting",
+	})
+}
+
+// rotationKeys parses the keys of a rotation request; the old key defaults
+// to the one in use (nil when encryption is disabled)
+func rotationKeys(req models.KeyRotationRequest) (oldKey, newKey []byte, invalid *apierror.FieldError) {
+	if req.NewKey == "" {
+		return nil, nil, apierror.Invalid("newKey", "newKey is required")
+	}
+	newKey, err := crypto.ParseKey(req.NewKey)
+	if err != nil {
+		return nil, nil, apierror.Invalid("newKey", "newKey: "+err.Error())
+	}
+
+	oldKey = crypto.Key()
+	if req.OldKey != "" {
+		if oldKey, err = crypto.ParseKey(req.OldKey); err != nil {
+			return nil, nil, apierror.Invalid("oldKey", "oldKey: "+err.Error())
+		}
+	}
+	if bytes.Equal(oldKey, newKey) {
+		return nil, nil, apierror.Invalid("newKey", "newKey must differ from the old key")
+	}
+	return oldKey, newKey, nil
+}
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+)
+
+// errorResponse sends the API error body with the given status
+func errorResponse(c *fiber.Ctx, status int, code apierror.Code, message string) error {
+	return apierror.Send(c, status, code, message)
+}
+
+// validationError sends a 400 VALIDATION_ERROR for the error a validator
+// returned
+func validationError(c *fiber.Ctx, err *apierror.FieldError) error {
+	return apierror.Validation(c, err)
+}
+
+// invalidField sends a 400 VALIDATION_ERROR about field
+func invalidField(c *fiber.Ctx, field, message string) error {
+	return apierror.Validation(c, apierror.Invalid(field, message))
+}
+
+// ErrorHandler serves the error code catalog
+type ErrorHandler struct{}
+
+// NewErrorHandler creates a new error catalog handler
+func NewErrorHandler() *ErrorHandler {
+	return &ErrorHandler{}
+}
+
+// GetCatalog returns every error code the API answers with, its status and
+// what it means
+// GET /errors
+func (h *ErrorHandler) GetCatalog(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    apierror.Catalog(),
+	})
+}
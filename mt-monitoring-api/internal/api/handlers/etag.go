@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// notModified sets the ETag of a read response, derived from version (the
+// parts of models.DataVersion and settings its body is computed from), the
+// path, the query string and the project of the request, and reports whether the request's
+// If-None-Match already holds it. The handler then answers 304 without
+// querying or encoding the body. The ETag is weak: equal tags promise equal
+// data, not equal bytes.
+func notModified(c *fiber.Ctx, version ...interface{}) bool {
+	hash := sha256.New()
+	for _, part := range version {
+		fmt.Fprintf(hash, "%v\x00", part)
+	}
+	hash.Write(c.Request().URI().Path())
+	hash.Write(c.Request().URI().QueryString())
+	hash.Write([]byte("\x00" + requestProject(c)))
+	etag := `W/"` + hex.EncodeToString(hash.Sum(nil)[:12]) + `"`
+
+	c.Set(fiber.HeaderETag, etag)
+	// Let browsers keep the response but revalidate it on every request
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	return etagMatches(c.Get(fiber.HeaderIfNoneMatch), etag)
+}
+
+// etagMatches reports whether an If-None-Match header holds etag, compared
+// weakly as RFC 9110 asks for GET requests
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
+
+// versionMinute is the current minute, added to the version of responses
+// holding values over a sliding window (24h uptime, hosts seen in the last
+// two minutes), which change without any write
+func versionMinute() int64 {
+	return time.Now().Unix() / 60
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"encoding/json"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+)
+
+// listQuery returns the comma-separated values of a query parameter, which
+// may also repeat: ?fields=id,name&fields=status
+func listQuery(c *fiber.Ctx, key string) []string {
+	var values []string
+	for _, v := range c.Context().QueryArgs().PeekMulti(key) {
+		for _, s := range strings.Split(string(v), ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				values = append(values, s)
+			}
+		}
+	}
+	return values
+}
+
+// embeds is the set of computed parts a read request embeds in its
+// response, see parseInclude
+type embeds map[string]bool
+
+// parseInclude returns the parts named by ?include=, out of the known
+// ones. Without the parameter every known part is embedded, as before it
+// existed; an empty ?include= embeds none. It returns the invalid field
+// for unknown parts.
+func parseInclude(c *fiber.Ctx, known ...string) (embeds, *apierror.FieldError) {
+	include := make(embeds, len(known))
+	if !c.Context().QueryArgs().Has("include") {
+		for _, part := range known {
+			include[part] = true
+		}
+		return include, nil
+	}
+	for _, part := range listQuery(c, "include") {
+		if !slices.Contains(known, part) {
+			return nil, apierror.Invalid("include", "include must be a list of "+strings.Join(known, ", "))
+		}
+		include[part] = true
+	}
+	return include, nil
+}
+
+// selectFields keeps only the ?fields= properties of data, an object or a
+// list of objects, plus "id". Data is returned as is without the
+// parameter. Unknown fields are ignored, as omitted empty ones would be.
+func selectFields(c *fiber.Ctx, data interface{}) (interface{}, error) {
+	fields := listQuery(c, "fields")
+	if len(fields) == 0 {
+		return data, nil
+	}
+	keep := map[string]bool{"id": true}
+	for _, f := range fields {
+		keep[f] = true
+	}
+	project := func(object map[string]json.RawMessage) {
+		for key := range object {
+			if !keep[key] {
+				delete(object, key)
+			}
+		}
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(string(raw), "[") {
+		var objects []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &objects); err != nil {
+			return nil, err
+		}
+		for _, object := range objects {
+			project(object)
+		}
+		return objects, nil
+	}
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &object); err != nil {
+		return nil, err
+	}
+	project(object)
+	return object, nil
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/gitops"
+)
+
+// GitOpsHandler handles GitOps sync requests
+type GitOpsHandler struct {
+	reconciler *gitops.Reconciler
+}
+
+// NewGitOpsHandler creates a new GitOps handler
+func NewGitOpsHandler(reconciler *gitops.Reconciler) *GitOpsHandler {
+	return &GitOpsHandler{
+		reconciler: reconciler,
+	}
+}
+
+// Status returns the result of the last reconcile
+// GET /admin/gitops
+func (h *GitOpsHandler) Status(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.reconciler.Last(),
+	})
+}
+
+// Reconcile runs a reconcile now. With ?dryRun=true drift is reported but
+// not corrected, even in enforce mode.
+// POST /admin/gitops/reconcile
+func (h *GitOpsHandler) Reconcile(c *fiber.Ctx) error {
+	result, err := h.reconciler.Reconcile(c.UserContext(), c.QueryBool("dryRun"))
+	if err != nil {
+		return c.Status(500).JSON(fiber.Map{
+			"success": false,
+			"error":   apierror.New(c, apierror.ReconcileFailed, err.Error()),
+			"data":    result,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
@@ -0,0 +1,404 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	grafanaService = "service"
+	grafanaHost    = "host"
+
+	// grafanaMaxPoints caps the points per series when the panel does not
+	// send maxDataPoints
+	grafanaMaxPoints = 1000
+)
+
+// The metrics of each target kind with the function extracting their value
+var (
+	grafanaServiceMetrics = map[string]func(m *models.Metric) float64{
+		"responseTime": func(m *models.Metric) float64 { return float64(m.ResponseTime) },
+		"uptime": func(m *models.Metric) float64 {
+			if m.Status == models.CheckStatusSuccess {
+				return 100
+			}
+			return 0
+		},
+	}
+	grafanaHostMetrics = map[string]func(m *models.SystemMetric) float64{
+		"cpu":       func(m *models.SystemMetric) float64 { return m.CPUUsage },
+		"memUsage":  func(m *models.SystemMetric) float64 { return m.MemUsage },
+		"memUsed":   func(m *models.SystemMetric) float64 { return m.MemUsed },
+		"diskUsage": func(m *models.SystemMetric) float64 { return m.DiskUsage },
+		"diskRead":  func(m *models.SystemMetric) float64 { return m.DiskRead },
+		"diskWrite": func(m *models.SystemMetric) float64 { return m.DiskWrite },
+		"netIn":     func(m *models.SystemMetric) float64 { return m.NetIn },
+		"netOut":    func(m *models.SystemMetric) float64 { return m.NetOut },
+	}
+)
+
+// GrafanaHandler implements the Grafana JSON datasource API over service
+// checks and host metrics
+type GrafanaHandler struct {
+	store *database.Store
+}
+
+// NewGrafanaHandler creates a new Grafana datasource handler
+func NewGrafanaHandler(store *database.Store) *GrafanaHandler {
+	return &GrafanaHandler{
+		store: store,
+	}
+}
+
+// Test answers the datasource connection test
+// GET /grafana
+func (h *GrafanaHandler) Test(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+	})
+}
+
+// Search returns the available targets
+// POST /grafana/search
+func (h *GrafanaHandler) Search(c *fiber.Ctx) error {
+	var req models.GrafanaSearchRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return grafanaBadRequest(c, apierror.InvalidRequest, "Invalid request body")
+		}
+	}
+
+	targets, err := h.targets(c.UserContext(), req.Target)
+	if err != nil {
+		return grafanaError(c, err)
+	}
+	return c.JSON(targets)
+}
+
+// Metrics returns the available targets as label/value options
+// POST /grafana/metrics
+func (h *GrafanaHandler) Metrics(c *fiber.Ctx) error {
+	var req models.GrafanaSearchRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return grafanaBadRequest(c, apierror.InvalidRequest, "Invalid request body")
+		}
+	}
+
+	targets, err := h.targets(c.UserContext(), req.Target)
+	if err != nil {
+		return grafanaError(c, err)
+	}
+	options := make([]models.GrafanaMetricOption, len(targets))
+	for i, t := range targets {
+		options[i] = models.GrafanaMetricOption{Label: t, Value: t}
+	}
+	return c.JSON(options)
+}
+
+// Query returns a time series per target, averaged into buckets of the
+// panel interval (at least range/maxDataPoints)
+// POST /grafana/query
+func (h *GrafanaHandler) Query(c *fiber.Ctx) error {
+	var req models.GrafanaQueryRequest
+	if err := c.BodyParser(&req); err != nil {
+		return grafanaBadRequest(c, apierror.InvalidRequest, "Invalid request body")
+	}
+	if req.Range.From.IsZero() {
+		return invalidField(c, "range.from", "range.from is required")
+	}
+	if !req.Range.To.After(req.Range.From) {
+		return invalidField(c, "range.to", "range.to is required and must be after range.from")
+	}
+
+	maxPoints := req.MaxDataPoints
+	if maxPoints <= 0 || maxPoints > grafanaMaxPoints {
+		maxPoints = grafanaMaxPoints
+	}
+	step := time.Duration(req.IntervalMs) * time.Millisecond
+	if minStep := req.Range.To.Sub(req.Range.From) / time.Duration(maxPoints); step < minStep {
+		step = minStep
+	}
+	if step < time.Second {
+		step = time.Second
+	}
+
+	q := &grafanaQuery{
+		store:    h.store,
+		from:     req.Range.From,
+		to:       req.Range.To,
+		step:     step,
+		checks:   make(map[string][]models.Metric),
+		hostData: make(map[string][]models.SystemMetric),
+	}
+	series := []models.GrafanaTimeSeries{}
+	for _, t := range req.Targets {
+		if t.Hide || t.Target == "" {
+			continue
+		}
+		result, err := q.run(c.UserContext(), t)
+		if err != nil {
+			if _, ok := err.(*grafanaTargetError); ok {
+				return invalidField(c, "targets", err.Error())
+			}
+			return grafanaError(c, err)
+		}
+		series = append(series, result...)
+	}
+	return c.JSON(series)
+}
+
+// Annotations returns the incidents in the range, optionally of the service
+// named by the annotation query
+// POST /grafana/annotations
+func (h *GrafanaHandler) Annotations(c *fiber.Ctx) error {
+	var req models.GrafanaAnnotationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return grafanaBadRequest(c, apierror.InvalidRequest, "Invalid request body")
+	}
+
+	incidents, err := h.store.Incidents.GetRange(c.UserContext(), req.Range.From, req.Range.To)
+	if err != nil {
+		return grafanaError(c, err)
+	}
+	names, err := h.serviceNames(c.UserContext())
+	if err != nil {
+		return grafanaError(c, err)
+	}
+
+	serviceID := strings.TrimSpace(req.Annotation.Query)
+	annotations := []models.GrafanaAnnotation{}
+	for _, i := range incidents {
+		if serviceID != "" && i.ServiceID != serviceID {
+			continue
+		}
+		name := names[i.ServiceID]
+		if name == "" {
+			name = i.ServiceID
+		}
+		a := models.GrafanaAnnotation{
+			Time:  i.StartedAt.UnixMilli(),
+			Title: fmt.Sprintf("%s %s", name, i.Type),
+			Text:  i.Message,
+			Tags:  []string{i.ServiceID, string(i.Type)},
+		}
+		if i.ResolvedAt != nil {
+			a.TimeEnd = i.ResolvedAt.UnixMilli()
+		}
+		annotations = append(annotations, a)
+	}
+	return c.JSON(annotations)
+}
+
+// targets lists every service and host target containing filter
+func (h *GrafanaHandler) targets(ctx context.Context, filter string) ([]string, error) {
+	services, err := h.store.Services.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	hosts, err := h.store.Hosts.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []string
+	for _, svc := range services {
+		for metric := range grafanaServiceMetrics {
+			targets = append(targets, grafanaService+":"+svc.ID+":"+metric)
+		}
+	}
+	for _, host := range hosts {
+		for metric := range grafanaHostMetrics {
+			targets = append(targets, grafanaHost+":"+host.ID+":"+metric)
+		}
+	}
+
+	matched := []string{}
+	for _, t := range targets {
+		if strings.Contains(t, filter) {
+			matched = append(matched, t)
+		}
+	}
+	sort.Strings(matched)
+	return matched, nil
+}
+
+func (h *GrafanaHandler) serviceNames(ctx context.Context) (map[string]string, error) {
+	services, err := h.store.Services.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[string]string, len(services))
+	for _, svc := range services {
+		names[svc.ID] = svc.Name
+	}
+	return names, nil
+}
+
+// grafanaTargetError is an invalid target in a query
+type grafanaTargetError struct {
+	target, reason string
+}
+
+func (e *grafanaTargetError) Error() string {
+	return fmt.Sprintf("invalid target %q: %s", e.target, e.reason)
+}
+
+// grafanaQuery runs the targets of one query request. Rows are loaded once
+// per service or host and shared by its metrics.
+type grafanaQuery struct {
+	store    *database.Store
+	from, to time.Time
+	step     time.Duration
+	checks   map[string][]models.Metric
+	hostData map[string][]models.SystemMetric
+}
+
+// run returns the series of a target; a * ID yields one series per
+// service or host
+func (q *grafanaQuery) run(ctx context.Context, t models.GrafanaTarget) ([]models.GrafanaTimeSeries, error) {
+	// IDs may contain colons, the kind and metric can't
+	first, last := strings.Index(t.Target, ":"), strings.LastIndex(t.Target, ":")
+	if first < 0 || first == last {
+		return nil, &grafanaTargetError{t.Target, "expected <service|host>:<id>:<metric>"}
+	}
+	kind, id, metric := t.Target[:first], t.Target[first+1:last], t.Target[last+1:]
+
+	var ids []string
+	switch kind {
+	case grafanaService:
+		if grafanaServiceMetrics[metric] == nil {
+			return nil, &grafanaTargetError{t.Target, "unknown service metric " + metric}
+		}
+		if id == "*" {
+			services, err := q.store.Services.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, svc := range services {
+				ids = append(ids, svc.ID)
+			}
+		}
+	case grafanaHost:
+		if grafanaHostMetrics[metric] == nil {
+			return nil, &grafanaTargetError{t.Target, "unknown host metric " + metric}
+		}
+		if id == "*" {
+			hosts, err := q.store.Hosts.GetAll(ctx)
+			if err != nil {
+				return nil, err
+			}
+			for _, host := range hosts {
+				ids = append(ids, host.ID)
+			}
+		}
+	default:
+		return nil, &grafanaTargetError{t.Target, "kind must be service or host"}
+	}
+	if id != "*" {
+		ids = []string{id}
+	}
+
+	series := make([]models.GrafanaTimeSeries, 0, len(ids))
+	for _, id := range ids {
+		var b grafanaBuckets
+		if kind == grafanaService {
+			checks, err := q.serviceChecks(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			value := grafanaServiceMetrics[metric]
+			for i := range checks {
+				b.add(q.bucket(checks[i].CheckedAt), value(&checks[i]))
+			}
+		} else {
+			points, err := q.hostMetrics(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			value := grafanaHostMetrics[metric]
+			for i := range points {
+				b.add(q.bucket(points[i].CreatedAt), value(&points[i]))
+			}
+		}
+		series = append(series, models.GrafanaTimeSeries{
+			Target:     kind + ":" + id + ":" + metric,
+			RefID:      t.RefID,
+			Datapoints: b.datapoints(q.from, q.step),
+		})
+	}
+	return series, nil
+}
+
+func (q *grafanaQuery) serviceChecks(ctx context.Context, id string) ([]models.Metric, error) {
+	if checks, ok := q.checks[id]; ok {
+		return checks, nil
+	}
+	checks, err := q.store.Metrics.GetRange(ctx, id, q.from, q.to)
+	if err != nil {
+		return nil, err
+	}
+	q.checks[id] = checks
+	return checks, nil
+}
+
+func (q *grafanaQuery) hostMetrics(ctx context.Context, id string) ([]models.SystemMetric, error) {
+	if points, ok := q.hostData[id]; ok {
+		return points, nil
+	}
+	points, err := q.store.SystemMetrics.GetRange(ctx, id, q.from, q.to)
+	if err != nil {
+		return nil, err
+	}
+	q.hostData[id] = points
+	return points, nil
+}
+
+// bucket returns the index of the step containing t
+func (q *grafanaQuery) bucket(t time.Time) int64 {
+	return int64(t.Sub(q.from) / q.step)
+}
+
+// grafanaBuckets averages values of rows that arrive in time order
+type grafanaBuckets struct {
+	index []int64
+	sum   []float64
+	count []int
+}
+
+func (b *grafanaBuckets) add(index int64, value float64) {
+	if n := len(b.index); n > 0 && b.index[n-1] == index {
+		b.sum[n-1] += value
+		b.count[n-1]++
+		return
+	}
+	b.index = append(b.index, index)
+	b.sum = append(b.sum, value)
+	b.count = append(b.count, 1)
+}
+
+// datapoints returns [average, bucket start in unix ms] pairs
+func (b *grafanaBuckets) datapoints(from time.Time, step time.Duration) [][2]float64 {
+	points := make([][2]float64, len(b.index))
+	for i, index := range b.index {
+		start := from.Add(time.Duration(index) * step)
+		points[i] = [2]float64{b.sum[i] / float64(b.count[i]), float64(start.UnixMilli())}
+	}
+	return points
+}
+
+func grafanaBadRequest(c *fiber.Ctx, code apierror.Code, message string) error {
+	return errorResponse(c, 400, code, message)
+}
+
+func grafanaError(c *fiber.Ctx, err error) error {
+	return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+}
@@ -5,6 +5,8 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/collector"
 	"github.com/mt-monitoring/api/internal/database"
 )
 
@@ -16,13 +18,17 @@ var (
 
 // HealthHandler handles health check requests
 type HealthHandler struct {
-	serviceRepo *database.ServiceRepository
+	store        *database.Store
+	serviceRepo  database.ServiceRepository
+	collectorMgr *collector.CollectorManager
 }
 
 // NewHealthHandler creates a new health handler
-func NewHealthHandler() *HealthHandler {
+func NewHealthHandler(store *database.Store, collectorMgr *collector.CollectorManager) *HealthHandler {
 	return &HealthHandler{
-		serviceRepo: database.NewServiceRepository(),
+		store:        store,
+		serviceRepo:  store.Services,
+		collectorMgr: collectorMgr,
 	}
 }
 
@@ -30,12 +36,12 @@ func NewHealthHandler() *HealthHandler {
 func (h *HealthHandler) Health(c *fiber.Ctx) error {
 	// Check database connection
 	dbStatus := "connected"
-	if err := database.DB.Ping(); err != nil {
+	if err := h.store.Ping(c.UserContext()); err != nil {
 		dbStatus = "disconnected"
 	}
 
 	// Get active services count
-	services, _ := h.serviceRepo.GetAll()
+	services, _ := h.serviceRepo.GetAll(c.UserContext())
 	activeServices := len(services)
 
 	// Calculate uptime
@@ -46,7 +52,7 @@ func (h *HealthHandler) Health(c *fiber.Ctx) error {
 	var memStats runtime.MemStats
 	runtime.ReadMemStats(&memStats)
 
-	return c.JSON(fiber.Map{
+	resp := fiber.Map{
 		"status":         "healthy",
 		"version":        Version,
 		"uptime":         uptimeStr,
@@ -61,7 +67,17 @@ func (h *HealthHandler) Health(c *fiber.Ctx) error {
 			"version":    runtime.Version(),
 			"goroutines": runtime.NumGoroutine(),
 		},
-	})
+	}
+
+	// Notification deliveries waiting, retrying or dropped while the queue was full
+	resp["notificationQueue"] = alerter.QueueStats()
+
+	// Aggregated system metrics waiting for retry or dropped after DB errors
+	if h.collectorMgr != nil {
+		resp["systemMetricStorage"] = h.collectorMgr.StorageStats()
+	}
+
+	return c.JSON(resp)
 }
 
 // Version returns version info
@@ -2,100 +2,120 @@ package handlers
 
 import (
 	"log"
+	"net/url"
+	"slices"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/collector"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/secrets"
 )
 
 // HostHandler handles host-related requests
 type HostHandler struct {
-	repo         *database.HostRepository
-	metricRepo   *database.SystemMetricRepository
+	repo         database.HostRepository
+	metricRepo   database.SystemMetricRepository
+	versionRepo  database.DashboardRepository
 	collectorMgr *collector.CollectorManager
 }
 
 // NewHostHandler creates a new host handler
-func NewHostHandler(collectorMgr *collector.CollectorManager) *HostHandler {
+func NewHostHandler(store *database.Store, collectorMgr *collector.CollectorManager) *HostHandler {
 	return &HostHandler{
-		repo:         database.NewHostRepository(),
-		metricRepo:   database.NewSystemMetricRepository(),
+		repo:         store.Hosts,
+		metricRepo:   store.SystemMetrics,
+		versionRepo:  store.Dashboard,
 		collectorMgr: collectorMgr,
 	}
 }
 
-// GetAll returns all hosts with computed status
+// GetAll returns all hosts, those owned by ?team= and ?owner= if given.
+// The computed status is left out unless ?include= has "metrics" or is
+// omitted; ?fields= chooses the properties returned. Unchanged lists are
+// answered with 304, see notModified.
 func (h *HostHandler) GetAll(c *fiber.Ctx) error {
-	hosts, err := h.repo.GetAll()
+	include, invalid := parseInclude(c, "metrics")
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
+	if h.notModified(c, include) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	hosts, err := h.repo.GetAll(c.UserContext())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
+	team, owner := ownershipQuery(c)
+	hosts = slices.DeleteFunc(hosts, func(h models.Host) bool {
+		return !inRequestProject(c, h.ProjectID) || !h.Ownership.Matches(team, owner)
+	})
 
 	// Enrich with computed status based on recent metrics
 	cutoff := time.Now().Add(-2 * time.Minute)
 	for i := range hosts {
-		if !hosts[i].IsActive {
+		hosts[i].MaskSecrets()
+		if !include["metrics"] {
+			hosts[i].Status = ""
+		} else if !hosts[i].IsActive {
 			hosts[i].Status = models.HostStatusOffline
 		} else if hosts[i].LastError != "" {
 			hosts[i].Status = models.HostStatusError
 		} else {
-			latest, _ := h.metricRepo.GetLatestByHost(hosts[i].ID)
+			latest, _ := h.metricRepo.GetLatestByHost(c.UserContext(), hosts[i].ID)
 			if latest != nil && latest.CreatedAt.After(cutoff) {
 				hosts[i].Status = models.HostStatusOnline
 			} else {
 				hosts[i].Status = models.HostStatusUnknown
 			}
 		}
-		hosts[i].MaskSecrets()
 	}
 
+	data, err := selectFields(c, hosts)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    hosts,
+		"data":    data,
 	})
 }
 
-// GetByID returns a host by ID
+// GetByID returns a host by ID, with the ?include= parts and ?fields=
+// properties as for GetAll
 func (h *HostHandler) GetByID(c *fiber.Ctx) error {
 	id := c.Params("hostId")
+	include, invalid := parseInclude(c, "metrics")
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
 
-	host, err := h.repo.GetByID(id)
+	host, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if host == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_NOT_FOUND",
-				"message": "Host not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.HostNotFound, "Host not found")
+	}
+
+	if h.notModified(c, include) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	// Compute status
 	cutoff := time.Now().Add(-2 * time.Minute)
-	if !host.IsActive {
+	if !include["metrics"] {
+		host.Status = ""
+	} else if !host.IsActive {
 		host.Status = models.HostStatusOffline
 	} else if host.LastError != "" {
 		host.Status = models.HostStatusError
 	} else {
-		latest, _ := h.metricRepo.GetLatestByHost(host.ID)
+		latest, _ := h.metricRepo.GetLatestByHost(c.UserContext(), host.ID)
 		if latest != nil && latest.CreatedAt.After(cutoff) {
 			host.Status = models.HostStatusOnline
 		} else {
@@ -104,63 +124,59 @@ func (h *HostHandler) GetByID(c *fiber.Ctx) error {
 	}
 	host.MaskSecrets()
 
+	data, err := selectFields(c, host)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    host,
+		"data":    data,
 	})
 }
 
+// notModified sets the ETag of a host response from the version of the
+// hosts, and of their metrics when include embeds the status, and reports
+// whether the client already has it
+func (h *HostHandler) notModified(c *fiber.Ctx, include embeds) bool {
+	v, err := h.versionRepo.GetVersion(c.UserContext())
+	if err != nil {
+		return false
+	}
+	version := []interface{}{v.Hosts}
+	if include["metrics"] {
+		version = append(version, v.HostMetrics, versionMinute())
+	}
+	return notModified(c, version...)
+}
+
 // Create creates a new host
 func (h *HostHandler) Create(c *fiber.Ctx) error {
 	var req models.HostCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
 
-	if req.ID == "" || req.Name == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "id and name are required",
-			},
-		})
+	if invalid := validateHostRequest(&req); invalid != nil {
+		return validationError(c, invalid)
 	}
 
 	// Check if host already exists
-	existing, _ := h.repo.GetByID(req.ID)
+	existing, _ := h.repo.GetByID(c.UserContext(), req.ID)
 	if existing != nil {
-		return c.Status(409).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_EXISTS",
-				"message": "Host with this ID already exists",
-			},
-		})
+		return errorResponse(c, 409, apierror.HostExists, "Host with this ID already exists")
 	}
 
 	host := req.ToHost()
+	host.ProjectID = newResourceProject(c)
 
-	if err := h.repo.Create(host); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.Create(c.UserContext(), host); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
-	// Auto-register SSH collector for active remote hosts
+	// Auto-register the collector of active remote hosts
 	if host.Type == models.HostTypeRemote && host.IsActive && h.collectorMgr != nil {
-		if err := h.collectorMgr.RegisterSSHHost(host); err != nil {
-			log.Printf("Warning: failed to register SSH collector for new host %s: %v", host.ID, err)
+		if err := h.collectorMgr.RegisterRemoteHost(host); err != nil {
+			log.Printf("Warning: failed to register collector for new host %s: %v", host.ID, err)
 		}
 	}
 
@@ -171,134 +187,256 @@ func (h *HostHandler) Create(c *fiber.Ctx) error {
 	})
 }
 
-// Update updates a host
+// Update updates the fields of a host present in the request (PATCH)
 func (h *HostHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("hostId")
 
-	host, err := h.repo.GetByID(id)
+	host, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if host == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_NOT_FOUND",
-				"message": "Host not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.HostNotFound, "Host not found")
 	}
 
-	var req models.HostCreateRequest
+	var req models.HostUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
-
-	if req.Name != "" {
-		host.Name = req.Name
+	if req.Name != nil && *req.Name == "" {
+		return invalidField(c, "name", "name must not be empty")
+	}
+	if req.Type != nil && *req.Type == "" {
+		return invalidField(c, "type", "type must not be empty")
 	}
-	if req.Type != "" {
-		host.Type = req.Type
+	if invalid := validateOwnership(req.Ownership); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	collector, exporterURL := host.Collector, host.ExporterURL
+	req.ApplyTo(host)
+	collectorChanged := host.Collector != collector || host.ExporterURL != exporterURL
+
+	if host.SSHAuthType == models.SSHAuthSecret && !secrets.ValidRef(host.SSHSecretRef) {
+		return invalidField(c, "sshSecretRef", "sshSecretRef: "+secrets.ErrInvalidRef.Error())
 	}
-	if req.IP != "" {
-		host.IP = req.IP
+	invalid := validateHostIntervals(host.CollectInterval, host.StoreInterval)
+	if invalid == nil {
+		invalid = validateHostCollector(host.Collector, host.ExporterURL)
 	}
-	if req.Port != 0 {
-		host.Port = req.Port
+	if invalid != nil {
+		return validationError(c, invalid)
 	}
-	if req.Group != "" {
-		host.Group = req.Group
+
+	if err := h.repo.Update(c.UserContext(), host); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
-	if req.IsActive != nil {
-		host.IsActive = *req.IsActive
+	if collectorChanged {
+		h.registerCollector(host)
+	} else if h.collectorMgr != nil {
+		h.collectorMgr.SetHostIntervals(host.ID, host.CollectInterval, host.StoreInterval)
 	}
-	if req.Description != "" {
-		host.Description = req.Description
+
+	host.MaskSecrets()
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    host,
+	})
+}
+
+// Upsert creates or replaces a host (PUT). The body is the complete host
+// as for Create: omitted fields, SSH credentials included, take their
+// defaults instead of keeping stored values. Nothing is written when the
+// host already matches; "changed" tells the client whether it did.
+func (h *HostHandler) Upsert(c *fiber.Ctx) error {
+	id := c.Params("hostId")
+
+	var req models.HostCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
-	// SSH fields
-	if req.SSHUser != "" {
-		host.SSHUser = req.SSHUser
+	if req.ID == "" {
+		req.ID = id
 	}
-	if req.SSHPort != 0 {
-		host.SSHPort = req.SSHPort
+	if req.ID != id {
+		return invalidField(c, "id", "id in the body does not match the URL")
 	}
-	if req.SSHAuthType != "" {
-		host.SSHAuthType = req.SSHAuthType
+	if invalid := validateHostRequest(&req); invalid != nil {
+		return validationError(c, invalid)
 	}
-	if req.SSHKeyPath != "" {
-		host.SSHKeyPath = req.SSHKeyPath
+
+	existing, err := h.repo.GetByID(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
-	if req.SSHKey != "" {
-		host.SSHKey = req.SSHKey
+
+	host := req.ToHost()
+	if existing == nil {
+		host.ProjectID = newResourceProject(c)
+		if err := h.repo.Create(c.UserContext(), host); err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		}
+		h.registerCollector(host)
+
+		host.MaskSecrets()
+		return c.Status(201).JSON(fiber.Map{
+			"success": true,
+			"data":    host,
+			"created": true,
+			"changed": true,
+		})
 	}
-	if req.SSHPassword != "" {
-		host.SSHPassword = req.SSHPassword
+
+	if (existing.Type == models.HostTypeLocal) != (host.Type == models.HostTypeLocal) {
+		return invalidField(c, "type", "type cannot be changed to or from local")
 	}
 
-	if err := h.repo.Update(host); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
+	fields := hostChanges(existing, host)
+	if len(fields) == 0 {
+		existing.MaskSecrets()
+		return c.JSON(fiber.Map{
+			"success":       true,
+			"data":          existing,
+			"created":       false,
+			"changed":       false,
+			"changedFields": fields,
 		})
 	}
 
+	// Status is operational, not part of the resource
+	host.ProjectID = existing.ProjectID
+	host.CreatedAt = existing.CreatedAt
+	host.LastError = existing.LastError
+	if err := h.repo.Update(c.UserContext(), host); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	h.registerCollector(host)
+
 	host.MaskSecrets()
 	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    host,
+		"success":       true,
+		"data":          host,
+		"created":       false,
+		"changed":       true,
+		"changedFields": fields,
 	})
 }
 
+// hostChanges returns the names of the configurable fields that differ
+// between a stored host and its replacement
+func hostChanges(have, want *models.Host) []string {
+	return changedFields(
+		fieldPair{"name", have.Name, want.Name},
+		fieldPair{"type", have.Type, want.Type},
+		fieldPair{"resourceCategory", have.ResourceCategory, want.ResourceCategory},
+		fieldPair{"ip", have.IP, want.IP},
+		fieldPair{"port", have.Port, want.Port},
+		fieldPair{"group", have.Group, want.Group},
+		fieldPair{"isActive", have.IsActive, want.IsActive},
+		fieldPair{"description", have.Description, want.Description},
+		fieldPair{"sshUser", have.SSHUser, want.SSHUser},
+		fieldPair{"sshPort", have.SSHPort, want.SSHPort},
+		fieldPair{"sshAuthType", have.SSHAuthType, want.SSHAuthType},
+		fieldPair{"sshKeyPath", have.SSHKeyPath, want.SSHKeyPath},
+		fieldPair{"sshKey", have.SSHKey, want.SSHKey},
+		fieldPair{"sshPassword", have.SSHPassword, want.SSHPassword},
+		fieldPair{"sshSecretRef", have.SSHSecretRef, want.SSHSecretRef},
+		fieldPair{"collectInterval", have.CollectInterval, want.CollectInterval},
+		fieldPair{"storeInterval", have.StoreInterval, want.StoreInterval},
+		fieldPair{"collector", have.Collector, want.Collector},
+		fieldPair{"exporterUrl", have.ExporterURL, want.ExporterURL},
+		fieldPair{"ownership", have.Ownership, want.Ownership},
+	)
+}
+
+// registerCollector restarts collection for an active remote host after a
+// replace, and stops it otherwise. The local host keeps its collector and
+// only takes the new intervals.
+func (h *HostHandler) registerCollector(host *models.Host) {
+	if h.collectorMgr == nil {
+		return
+	}
+	if host.Type != models.HostTypeRemote {
+		h.collectorMgr.SetHostIntervals(host.ID, host.CollectInterval, host.StoreInterval)
+		return
+	}
+	if !host.IsActive {
+		h.collectorMgr.Unregister(host.ID)
+		return
+	}
+	if err := h.collectorMgr.RegisterRemoteHost(host); err != nil {
+		log.Printf("Warning: failed to register collector for host %s: %v", host.ID, err)
+	}
+}
+
+// validateHostRequest returns the invalid field for a create or replace
+// request, or nil when it is valid
+func validateHostRequest(req *models.HostCreateRequest) *apierror.FieldError {
+	if req.ID == "" {
+		return apierror.Invalid("id", "id is required")
+	}
+	if req.Name == "" {
+		return apierror.Invalid("name", "name is required")
+	}
+	if req.SSHAuthType == models.SSHAuthSecret && !secrets.ValidRef(req.SSHSecretRef) {
+		return apierror.Invalid("sshSecretRef", "sshSecretRef: "+secrets.ErrInvalidRef.Error())
+	}
+	if invalid := validateHostIntervals(req.CollectInterval, req.StoreInterval); invalid != nil {
+		return invalid
+	}
+	if invalid := validateOwnership(req.Ownership); invalid != nil {
+		return invalid
+	}
+	return validateHostCollector(req.Collector, req.ExporterURL)
+}
+
+// validateHostIntervals returns the invalid field for the interval
+// overrides of a host, or nil when they are valid. 0 uses the global interval.
+func validateHostIntervals(collect, store int) *apierror.FieldError {
+	if collect < 0 {
+		return apierror.Invalid("collectInterval", "collectInterval must not be negative")
+	}
+	if store < 0 {
+		return apierror.Invalid("storeInterval", "storeInterval must not be negative")
+	}
+	if collect > 0 && store > 0 && store < collect {
+		return apierror.Invalid("storeInterval", "storeInterval must be at least collectInterval")
+	}
+	return nil
+}
+
+// validateHostCollector returns the invalid field for the collector of a
+// host, or nil when it is valid
+func validateHostCollector(collector models.HostCollector, exporterURL string) *apierror.FieldError {
+	if collector != "" && !collector.IsValid() {
+		return apierror.Invalid("collector", `collector must be "ssh" or "node_exporter"`)
+	}
+	if exporterURL != "" {
+		u, err := url.Parse(exporterURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apierror.Invalid("exporterUrl", "exporterUrl must be an http or https URL")
+		}
+	}
+	return nil
+}
+
 // Delete deletes a host
 func (h *HostHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("hostId")
 
-	host, err := h.repo.GetByID(id)
+	host, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if host == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_NOT_FOUND",
-				"message": "Host not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.HostNotFound, "Host not found")
 	}
 
 	// Prevent deleting the local host
 	if host.Type == models.HostTypeLocal {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "CANNOT_DELETE_LOCAL",
-				"message": "Cannot delete the local host",
-			},
-		})
+		return errorResponse(c, 400, apierror.CannotDeleteLocal, "Cannot delete the local host")
 	}
 
 	// Unregister collector before deleting
@@ -306,14 +444,8 @@ func (h *HostHandler) Delete(c *fiber.Ctx) error {
 		h.collectorMgr.Unregister(id)
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -326,35 +458,17 @@ func (h *HostHandler) Delete(c *fiber.Ctx) error {
 func (h *HostHandler) Pause(c *fiber.Ctx) error {
 	id := c.Params("hostId")
 
-	host, err := h.repo.GetByID(id)
+	host, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if host == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_NOT_FOUND",
-				"message": "Host not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.HostNotFound, "Host not found")
 	}
 
-	if err := h.repo.SetActive(id, false); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.SetActive(c.UserContext(), id, false); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	// Unregister collector when paused (for remote hosts)
@@ -372,44 +486,26 @@ func (h *HostHandler) Pause(c *fiber.Ctx) error {
 func (h *HostHandler) Resume(c *fiber.Ctx) error {
 	id := c.Params("hostId")
 
-	host, err := h.repo.GetByID(id)
+	host, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if host == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HOST_NOT_FOUND",
-				"message": "Host not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.HostNotFound, "Host not found")
 	}
 
-	if err := h.repo.SetActive(id, true); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.SetActive(c.UserContext(), id, true); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	// Re-register collector when resumed (for remote hosts)
 	if host.Type == models.HostTypeRemote && h.collectorMgr != nil {
 		// Re-read host to get SSH fields
-		updated, _ := h.repo.GetByID(id)
+		updated, _ := h.repo.GetByID(c.UserContext(), id)
 		if updated != nil {
-			if err := h.collectorMgr.RegisterSSHHost(updated); err != nil {
-				log.Printf("Warning: failed to re-register SSH collector for %s: %v", id, err)
+			if err := h.collectorMgr.RegisterRemoteHost(updated); err != nil {
+				log.Printf("Warning: failed to re-register collector for %s: %v", id, err)
 			}
 		}
 	}
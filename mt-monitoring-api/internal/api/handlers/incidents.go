@@ -1,38 +1,65 @@
 package handlers
 
 import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
 )
 
 // IncidentHandler handles incident-related requests
 type IncidentHandler struct {
-	repo *database.IncidentRepository
+	repo         database.IncidentRepository
+	tagRepo      database.TagRepository
+	remediations database.RemediationRepository
 }
 
 // NewIncidentHandler creates a new incident handler
-func NewIncidentHandler() *IncidentHandler {
+func NewIncidentHandler(store *database.Store) *IncidentHandler {
 	return &IncidentHandler{
-		repo: database.NewIncidentRepository(),
+		repo:         store.Incidents,
+		tagRepo:      store.Tags,
+		remediations: store.Remediations,
 	}
 }
 
-// GetAll returns all incidents
+// GetAll returns all incidents, those of services carrying every ?tag= if
+// given. Postmortems are left out unless ?include= has "postmortems" or is
+// omitted; ?fields= chooses the properties returned.
 func (h *IncidentHandler) GetAll(c *fiber.Ctx) error {
-	incidents, err := h.repo.GetActive()
+	include, invalid := parseInclude(c, "postmortems")
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	incidents, err := h.repo.GetActive(c.UserContext())
+	if err == nil {
+		var tagged map[string]bool
+		if tagged, err = taggedServices(c, h.tagRepo); tagged != nil {
+			incidents = slices.DeleteFunc(incidents, func(i models.Incident) bool { return !tagged[i.ServiceID] })
+		}
+	}
+	if err == nil && include["postmortems"] {
+		err = h.attachPostmortems(c, incidents)
+	}
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
+	data, err := selectFields(c, incidents)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    incidents,
+		"data":    data,
 	})
 }
 
@@ -40,3 +67,268 @@ func (h *IncidentHandler) GetAll(c *fiber.Ctx) error {
 func (h *IncidentHandler) GetActive(c *fiber.Ctx) error {
 	return h.GetAll(c)
 }
+
+// GetByID returns an incident and the incidents grouped under it, with
+// their postmortems, traces and remediation runs
+// GET /incidents/:id
+func (h *IncidentHandler) GetByID(c *fiber.Ctx) error {
+	incident, err := h.incident(c)
+	if err != nil || incident == nil {
+		return err
+	}
+
+	children, err := h.repo.GetChildren(c.UserContext(), incident.ID)
+	incidents := append([]models.Incident{*incident}, children...)
+	if err == nil {
+		err = h.attachPostmortems(c, incidents)
+	}
+	if err == nil {
+		err = h.attachTraces(c, incidents)
+	}
+	if err == nil {
+		err = h.attachRemediations(c, incidents)
+	}
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"incident": incidents[0],
+			"children": incidents[1:],
+		},
+	})
+}
+
+// SavePostmortem creates or replaces the postmortem of an incident, open
+// or resolved
+// PUT /incidents/:id/postmortem
+func (h *IncidentHandler) SavePostmortem(c *fiber.Ctx) error {
+	incident, err := h.incident(c)
+	if err != nil || incident == nil {
+		return err
+	}
+
+	var req models.PostmortemRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if invalid := validatePostmortem(&req); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	postmortem := &models.Postmortem{
+		IncidentID:  incident.ID,
+		RootCause:   req.RootCause,
+		Impact:      req.Impact,
+		ActionItems: req.ActionItems,
+		Body:        req.Body,
+	}
+	if err := h.repo.SavePostmortem(c.UserContext(), postmortem); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    postmortem,
+	})
+}
+
+// DeletePostmortem deletes the postmortem of an incident
+// DELETE /incidents/:id/postmortem
+func (h *IncidentHandler) DeletePostmortem(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid incident ID")
+	}
+
+	deleted, err := h.repo.DeletePostmortem(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.PostmortemNotFound, "Postmortem not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Postmortem deleted successfully",
+	})
+}
+
+// GetPostmortemReport summarizes the postmortems of incidents started in
+// the last days by root cause, with the open action items
+// GET /incidents/report?days=30
+func (h *IncidentHandler) GetPostmortemReport(c *fiber.Ctx) error {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+
+	to := time.Now()
+	from := to.AddDate(0, 0, -days)
+	incidents, err := h.repo.GetRange(c.UserContext(), from, to)
+	if err == nil {
+		err = h.attachPostmortems(c, incidents)
+	}
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    postmortemReport(incidents, from, to),
+	})
+}
+
+// postmortemReport summarizes the postmortems of the incidents started
+// between from and to. Open action items are ordered by due date, undated
+// last.
+func postmortemReport(incidents []models.Incident, from, to time.Time) *models.PostmortemReport {
+	report := &models.PostmortemReport{
+		From:            from,
+		To:              to,
+		RootCauses:      []models.RootCauseSummary{},
+		OpenActionItems: []models.PostmortemActionItem{},
+	}
+	byCause := make(map[models.RootCause]*models.RootCauseSummary)
+	for _, incident := range incidents {
+		if incident.StartedAt.Before(from) {
+			continue
+		}
+		report.Incidents++
+		p := incident.Postmortem
+		if p == nil {
+			continue
+		}
+		report.WithPostmortem++
+
+		summary, ok := byCause[p.RootCause]
+		if !ok {
+			summary = &models.RootCauseSummary{RootCause: p.RootCause}
+			byCause[p.RootCause] = summary
+		}
+		summary.Incidents++
+		if incident.ResolvedAt != nil {
+			summary.DowntimeSeconds += int64(incident.ResolvedAt.Sub(incident.StartedAt).Seconds())
+		}
+		for _, item := range p.ActionItems {
+			if !item.Done {
+				report.OpenActionItems = append(report.OpenActionItems, models.PostmortemActionItem{IncidentID: incident.ID, ActionItem: item})
+			}
+		}
+	}
+
+	for _, cause := range models.RootCauses {
+		if summary, ok := byCause[cause]; ok {
+			report.RootCauses = append(report.RootCauses, *summary)
+		}
+	}
+	sort.SliceStable(report.OpenActionItems, func(i, j int) bool {
+		a, b := report.OpenActionItems[i].DueDate, report.OpenActionItems[j].DueDate
+		if a == nil || b == nil {
+			return a != nil
+		}
+		return a.Before(*b)
+	})
+	return report
+}
+
+// attachPostmortems sets the postmortem of each incident that has one
+func (h *IncidentHandler) attachPostmortems(c *fiber.Ctx, incidents []models.Incident) error {
+	ids := make([]int64, len(incidents))
+	for i, incident := range incidents {
+		ids[i] = incident.ID
+	}
+	postmortems, err := h.repo.GetPostmortems(c.UserContext(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i].Postmortem = postmortems[incidents[i].ID]
+	}
+	return nil
+}
+
+// attachTraces sets the route trace of each incident that has one
+func (h *IncidentHandler) attachTraces(c *fiber.Ctx, incidents []models.Incident) error {
+	ids := make([]int64, len(incidents))
+	for i, incident := range incidents {
+		ids[i] = incident.ID
+	}
+	traces, err := h.repo.GetTraces(c.UserContext(), ids)
+	if err != nil {
+		return err
+	}
+	for i := range incidents {
+		incidents[i].Trace = traces[incidents[i].ID]
+	}
+	return nil
+}
+
+// attachRemediations sets the remediation runs of alert rules that fired
+// while each incident was open
+func (h *IncidentHandler) attachRemediations(c *fiber.Ctx, incidents []models.Incident) error {
+	ids := make([]int64, len(incidents))
+	for i, incident := range incidents {
+		ids[i] = incident.ID
+	}
+	runs, err := h.remediations.GetByIncidents(c.UserContext(), ids)
+	if err != nil {
+		return err
+	}
+	for _, run := range runs {
+		for i := range incidents {
+			if incidents[i].ID == *run.IncidentID {
+				incidents[i].Remediations = append(incidents[i].Remediations, run)
+			}
+		}
+	}
+	return nil
+}
+
+// incident loads the incident named in the URL. When it returns nil the
+// error response has been sent.
+func (h *IncidentHandler) incident(c *fiber.Ctx) (*models.Incident, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, errorResponse(c, 400, apierror.InvalidRequest, "Invalid incident ID")
+	}
+	incident, err := h.repo.GetByID(c.UserContext(), id)
+	if err != nil {
+		return nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if incident == nil {
+		return nil, errorResponse(c, 404, apierror.IncidentNotFound, "Incident not found")
+	}
+	return incident, nil
+}
+
+// validatePostmortem returns the invalid field for a postmortem
+// request, or nil when it is valid
+func validatePostmortem(req *models.PostmortemRequest) *apierror.FieldError {
+	if !req.RootCause.IsValid() {
+		causes := make([]string, len(models.RootCauses))
+		for i, cause := range models.RootCauses {
+			causes[i] = string(cause)
+		}
+		return apierror.Invalid("rootCause", "rootCause must be one of "+strings.Join(causes, ", "))
+	}
+	for i := range req.ActionItems {
+		item := &req.ActionItems[i]
+		item.Description = strings.TrimSpace(item.Description)
+		item.Owner = strings.TrimSpace(item.Owner)
+		if item.Description == "" {
+			field := fmt.Sprintf("actionItems[%d].description", i)
+			return apierror.Invalid(field, field+" is required")
+		}
+		if item.Owner == "" {
+			field := fmt.Sprintf("actionItems[%d].owner", i)
+			return apierror.Invalid(field, field+" is required")
+		}
+	}
+	return nil
+}
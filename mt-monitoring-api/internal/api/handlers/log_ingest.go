@@ -7,21 +7,26 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/events"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // LogIngestHandler handles external log ingestion via API key
 type LogIngestHandler struct {
-	logRepo      *database.LogRepository
+	logRepo      database.LogRepository
 	alertManager *alerter.Manager
+	logRules     *alerter.LogRuleEvaluator
 }
 
 // NewLogIngestHandler creates a new log ingest handler
-func NewLogIngestHandler() *LogIngestHandler {
+func NewLogIngestHandler(store *database.Store) *LogIngestHandler {
+	manager := alerter.NewManager(store)
 	return &LogIngestHandler{
-		logRepo:      database.NewLogRepository(),
-		alertManager: alerter.NewManager(),
+		logRepo:      store.Logs,
+		alertManager: manager,
+		logRules:     alerter.NewLogRuleEvaluator(store, manager),
 	}
 }
 
@@ -30,35 +35,17 @@ func (h *LogIngestHandler) Ingest(c *fiber.Ctx) error {
 	// Service is set by ApiKeyAuth middleware
 	service, ok := c.Locals("service").(*models.Service)
 	if !ok || service == nil {
-		return c.Status(401).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "UNAUTHORIZED",
-				"message": "Service not found in context",
-			},
-		})
+		return errorResponse(c, 401, apierror.Unauthorized, "Service not found in context")
 	}
 
 	var req models.LogIngestRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body: " + err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body: "+err.Error())
 	}
 
 	// Validate required fields
 	if req.Message == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "message is required",
-			},
-		})
+		return invalidField(c, "message", "message is required")
 	}
 
 	// Default level to error if not specified
@@ -67,14 +54,8 @@ func (h *LogIngestHandler) Ingest(c *fiber.Ctx) error {
 	}
 
 	// Validate level
-	if req.Level != models.LogLevelError && req.Level != models.LogLevelWarn && req.Level != models.LogLevelInfo {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "level must be one of: error, warn, info",
-			},
-		})
+	if !req.Level.IsValid() {
+		return invalidField(c, "level", "level must be one of: error, warn, info")
 	}
 
 	// Generate fingerprint for deduplication
@@ -85,13 +66,7 @@ func (h *LogIngestHandler) Ingest(c *fiber.Ctx) error {
 	if req.Metadata != nil {
 		data, err := json.Marshal(req.Metadata)
 		if err != nil {
-			return c.Status(400).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "VALIDATION_ERROR",
-					"message": "Invalid metadata format",
-				},
-			})
+			return invalidField(c, "metadata", "Invalid metadata format")
 		}
 		metadataJSON = data
 	}
@@ -107,17 +82,17 @@ func (h *LogIngestHandler) Ingest(c *fiber.Ctx) error {
 		CreatedAt:   time.Now(),
 	}
 
-	if err := h.logRepo.Create(logEntry); err != nil {
+	if err := h.logRepo.Create(c.UserContext(), logEntry); err != nil {
 		log.Printf("Failed to create log entry: %v", err)
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": "Failed to store log entry",
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to store log entry")
 	}
 
+	// Push to live log tail subscribers
+	events.LogWritten.Publish(logEntry)
+
+	// Match log rules while ingesting, with their cached compiled patterns
+	h.logRules.Evaluate(service.ProjectID, service.ID, service.Name, logEntry)
+
 	// Trigger alert for error/warn levels
 	if req.Level == models.LogLevelError || req.Level == models.LogLevelWarn {
 		go h.alertManager.DispatchLogAlert(
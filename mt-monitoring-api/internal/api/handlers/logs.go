@@ -1,22 +1,29 @@
 package handlers
 
 import (
+	"bufio"
+	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/api/websocket"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // LogHandler handles log-related requests
 type LogHandler struct {
-	repo *database.LogRepository
+	repo database.LogRepository
+	hub  *websocket.Hub
 }
 
 // NewLogHandler creates a new log handler
-func NewLogHandler() *LogHandler {
+func NewLogHandler(store *database.Store, hub *websocket.Hub) *LogHandler {
 	return &LogHandler{
-		repo: database.NewLogRepository(),
+		repo: store.Logs,
+		hub:  hub,
 	}
 }
 
@@ -24,19 +31,13 @@ func NewLogHandler() *LogHandler {
 func (h *LogHandler) GetAll(c *fiber.Ctx) error {
 	filter := models.LogFilter{
 		ServiceID: c.Query("serviceId"),
+		Tags:      tagQuery(c),
 		Level:     models.LogLevel(c.Query("level")),
 		Search:    c.Query("search"),
 	}
 
 	// Parse pagination
-	if limit := c.Query("limit"); limit != "" {
-		if parsed, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = parsed
-		}
-	}
-	if filter.Limit <= 0 {
-		filter.Limit = 50
-	}
+	filter.Limit = pageLimit(c, 50)
 
 	if offset := c.Query("offset"); offset != "" {
 		if parsed, err := strconv.Atoi(offset); err == nil {
@@ -51,15 +52,9 @@ func (h *LogHandler) GetAll(c *fiber.Ctx) error {
 		}
 	}
 
-	logs, total, err := h.repo.GetAll(filter)
+	logs, total, err := h.repo.GetAll(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	// Calculate pagination info
@@ -88,29 +83,77 @@ func (h *LogHandler) GetByServiceID(c *fiber.Ctx) error {
 	filter := models.LogFilter{
 		ServiceID: serviceID,
 		Level:     models.LogLevel(c.Query("level")),
-		Limit:     50,
+		Limit:     pageLimit(c, 50),
 	}
-
-	if limit := c.Query("limit"); limit != "" {
-		if parsed, err := strconv.Atoi(limit); err == nil {
-			filter.Limit = parsed
-		}
+	if offset, err := strconv.Atoi(c.Query("offset")); err == nil && offset > 0 {
+		filter.Offset = offset
 	}
 
-	logs, total, err := h.repo.GetAll(filter)
+	logs, total, err := h.repo.GetAll(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    logs,
 		"total":   total,
+		"limit":   filter.Limit,
+		"offset":  filter.Offset,
 	})
 }
+
+// Stream tails logs in real time using Server-Sent Events.
+// GET /logs/stream?serviceId=xxx&level=warn&pattern=timeout
+// GET /services/:id/logs/stream?level=warn&pattern=timeout
+// level is a minimum severity; pattern is a regular expression matched against the message.
+func (h *LogHandler) Stream(c *fiber.Ctx) error {
+	if h.hub == nil {
+		return errorResponse(c, 503, apierror.StreamUnavailable, "Live log streaming is not enabled")
+	}
+
+	serviceID := c.Params("id")
+	if serviceID == "" {
+		serviceID = c.Query("serviceId")
+	}
+
+	filter, invalid := websocket.NewLogFilter(serviceID, c.Query("level"), c.Query("pattern"))
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	c.Set("Content-Type", "text/event-stream")
+	c.Set("Cache-Control", "no-cache")
+	c.Set("Connection", "keep-alive")
+	c.Set("X-Accel-Buffering", "no")
+
+	sub := h.hub.SubscribeLogs(filter)
+
+	c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+		defer h.hub.UnsubscribeLogs(sub)
+
+		keepAlive := time.NewTicker(15 * time.Second)
+		defer keepAlive.Stop()
+
+		// Initial comment so clients see the stream is open
+		fmt.Fprint(w, ": connected\n\n")
+		if err := w.Flush(); err != nil {
+			return
+		}
+
+		for {
+			select {
+			case message := <-sub.C:
+				fmt.Fprintf(w, "event: log\ndata: %s\n\n", message)
+			case <-keepAlive.C:
+				fmt.Fprint(w, ": keepalive\n\n")
+			}
+			// Flush fails once the client has disconnected
+			if err := w.Flush(); err != nil {
+				return
+			}
+		}
+	})
+
+	return nil
+}
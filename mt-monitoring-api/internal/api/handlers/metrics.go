@@ -5,20 +5,25 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
 )
 
 // MetricHandler handles metric-related requests
 type MetricHandler struct {
-	repo        *database.MetricRepository
-	serviceRepo *database.ServiceRepository
+	repo        database.MetricRepository
+	serviceRepo database.ServiceRepository
+	detailsRepo database.CheckDetailsRepository
 }
 
 // NewMetricHandler creates a new metric handler
-func NewMetricHandler() *MetricHandler {
+func NewMetricHandler(store *database.Store) *MetricHandler {
 	return &MetricHandler{
-		repo:        database.NewMetricRepository(),
-		serviceRepo: database.NewServiceRepository(),
+		repo:        store.Metrics,
+		serviceRepo: store.Services,
+		detailsRepo: store.CheckDetails,
 	}
 }
 
@@ -27,49 +32,60 @@ func (h *MetricHandler) GetByServiceID(c *fiber.Ctx) error {
 	serviceID := c.Params("id")
 
 	// Check if service exists
-	service, err := h.serviceRepo.GetByID(serviceID)
+	service, err := h.serviceRepo.GetByID(c.UserContext(), serviceID)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
-	// Get limit from query params
-	limit := 100
-	if l := c.Query("limit"); l != "" {
-		if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
-			limit = parsed
-		}
+	// Page backwards from ?before=, the ID of the oldest metric seen
+	limit := pageLimit(c, 100)
+	before, _ := strconv.ParseInt(c.Query("before"), 10, 64)
+
+	metrics, err := h.repo.GetBefore(c.UserContext(), serviceID, max(before, 0), limit)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	pagination := fiber.Map{"limit": limit}
+	if len(metrics) == limit {
+		pagination["nextBefore"] = metrics[len(metrics)-1].ID
 	}
 
-	metrics, err := h.repo.GetByServiceID(serviceID, limit)
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"data":       metrics,
+		"pagination": pagination,
+	})
+}
+
+// GetByID returns a single check result with its failure diagnostics
+// GET /services/:id/metrics/:metricId
+func (h *MetricHandler) GetByID(c *fiber.Ctx) error {
+	metricID, err := strconv.ParseInt(c.Params("metricId"), 10, 64)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid metric ID")
+	}
+
+	metric, err := h.repo.GetByID(c.UserContext(), c.Params("id"), metricID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if metric == nil {
+		return errorResponse(c, 404, apierror.NotFound, "Metric not found")
+	}
+
+	details, err := h.detailsRepo.GetByMetricID(c.UserContext(), metricID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    metrics,
+		"data":    models.MetricDetail{Metric: *metric, Details: details},
 	})
 }
 
@@ -77,32 +93,19 @@ func (h *MetricHandler) GetByServiceID(c *fiber.Ctx) error {
 func (h *MetricHandler) GetSummary(c *fiber.Ctx) error {
 	serviceID := c.Params("id")
 
-	// Get duration from query params (default 24h)
-	duration := 24 * time.Hour
-	if d := c.Query("duration"); d != "" {
-		switch d {
-		case "1h":
-			duration = time.Hour
-		case "6h":
-			duration = 6 * time.Hour
-		case "24h":
-			duration = 24 * time.Hour
-		case "7d":
-			duration = 7 * 24 * time.Hour
-		case "30d":
-			duration = 30 * 24 * time.Hour
+	duration := queryDuration(c)
+
+	// Apdex T in milliseconds (default from config)
+	apdexThreshold := config.GetApdexThreshold()
+	if t := c.Query("apdexThreshold"); t != "" {
+		if parsed, err := strconv.Atoi(t); err == nil && parsed > 0 {
+			apdexThreshold = parsed
 		}
 	}
 
-	summary, err := h.repo.GetSummary(serviceID, duration)
+	summary, err := h.repo.GetSummary(c.UserContext(), serviceID, duration, apdexThreshold)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -111,6 +114,70 @@ func (h *MetricHandler) GetSummary(c *fiber.Ctx) error {
 	})
 }
 
+// GetHistogram returns the response time distribution of a service from
+// its hourly rollups, by hour for heatmaps and in total
+// GET /services/:id/metrics/histogram?duration=24h
+func (h *MetricHandler) GetHistogram(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+	to := time.Now()
+	rollups, err := h.repo.GetRollups(c.UserContext(), serviceID, to.Add(-queryDuration(c)), to)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	histogram := models.LatencyHistogram{
+		ServiceID: serviceID,
+		Buckets:   models.LatencyBuckets,
+		Hours:     rollups,
+		Total:     make([]int, len(models.LatencyBuckets)+1),
+	}
+	for _, ru := range rollups {
+		histogram.Checks += ru.Checks
+		histogram.Failures += ru.Failures
+		for i, n := range ru.Counts {
+			if i < len(histogram.Total) {
+				histogram.Total[i] += n
+			}
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    histogram,
+	})
+}
+
+// GetTimeline returns the service's state-change intervals
+// GET /services/:id/timeline?duration=24h
+func (h *MetricHandler) GetTimeline(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+
+	service, err := h.serviceRepo.GetByID(c.UserContext(), serviceID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if service == nil {
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
+	}
+
+	to := time.Now()
+	from := to.Add(-queryDuration(c))
+
+	intervals, err := h.repo.GetStatusIntervals(c.UserContext(), serviceID, from)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"from":      from,
+			"to":        to,
+			"intervals": intervals,
+		},
+	})
+}
+
 // GetUptime returns uptime data for calendar view
 func (h *MetricHandler) GetUptime(c *fiber.Ctx) error {
 	serviceID := c.Params("id")
@@ -123,15 +190,17 @@ func (h *MetricHandler) GetUptime(c *fiber.Ctx) error {
 		}
 	}
 
-	data, err := h.repo.GetUptimeData(serviceID, days)
+	loc := config.GetLocation()
+	if tz := c.Query("tz"); tz != "" {
+		var err error
+		if loc, err = time.LoadLocation(tz); err != nil {
+			return errorResponse(c, 400, apierror.InvalidRequest, "Unknown timezone, use an IANA name such as Asia/Seoul")
+		}
+	}
+
+	data, err := h.repo.GetUptimeData(c.UserContext(), serviceID, days, loc)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	// Transform to frontend expected format
@@ -167,6 +236,24 @@ func (h *MetricHandler) GetUptime(c *fiber.Ctx) error {
 		"data": fiber.Map{
 			"percentage": percentage,
 			"days":       uptimeDays,
+			"timezone":   loc.String(),
 		},
 	})
 }
+
+// queryDuration reads the duration query param (1h, 6h, 24h, 7d, 30d),
+// defaulting to 24h
+func queryDuration(c *fiber.Ctx) time.Duration {
+	switch c.Query("duration") {
+	case "1h":
+		return time.Hour
+	case "6h":
+		return 6 * time.Hour
+	case "7d":
+		return 7 * 24 * time.Hour
+	case "30d":
+		return 30 * 24 * time.Hour
+	default:
+		return 24 * time.Hour
+	}
+}
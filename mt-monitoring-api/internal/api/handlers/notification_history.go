@@ -5,24 +5,25 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // NotificationHistoryHandler handles notification history endpoints
 type NotificationHistoryHandler struct {
-	repo *database.NotificationHistoryRepository
+	repo database.NotificationHistoryRepository
 }
 
 // NewNotificationHistoryHandler creates a new handler
-func NewNotificationHistoryHandler() *NotificationHistoryHandler {
+func NewNotificationHistoryHandler(store *database.Store) *NotificationHistoryHandler {
 	return &NotificationHistoryHandler{
-		repo: database.NewNotificationHistoryRepository(),
+		repo: store.NotificationHistory,
 	}
 }
 
 // GetAll returns paginated notification history
-// GET /notification-history?channel_id=xxx&alert_type=xxx&status=xxx&from=xxx&to=xxx&limit=50&offset=0
+// GET /notification-history?channel_id=xxx&alert_type=xxx&status=xxx&tag=xxx&from=xxx&to=xxx&limit=50&offset=0
 func (h *NotificationHistoryHandler) GetAll(c *fiber.Ctx) error {
 	filter := &models.NotificationHistoryFilter{}
 
@@ -36,6 +37,7 @@ func (h *NotificationHistoryHandler) GetAll(c *fiber.Ctx) error {
 	if status := c.Query("status"); status != "" {
 		filter.Status = &status
 	}
+	filter.Tags = tagQuery(c)
 	if fromStr := c.Query("from"); fromStr != "" {
 		if from, err := time.Parse(time.RFC3339, fromStr); err == nil {
 			filter.FromDate = &from
@@ -48,12 +50,7 @@ func (h *NotificationHistoryHandler) GetAll(c *fiber.Ctx) error {
 	}
 
 	// Parse pagination
-	limit := 50
-	if limitStr := c.Query("limit"); limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
+	limit := pageLimit(c, 50)
 	filter.Limit = limit
 
 	offset := 0
@@ -65,21 +62,15 @@ func (h *NotificationHistoryHandler) GetAll(c *fiber.Ctx) error {
 	filter.Offset = offset
 
 	// Get history
-	histories, err := h.repo.GetAll(filter)
+	histories, err := h.repo.GetAll(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch notification history",
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to fetch notification history")
 	}
 
 	// Get total count
-	total, err := h.repo.GetCount(filter)
+	total, err := h.repo.GetCount(c.UserContext(), filter)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to count notifications",
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to count notifications")
 	}
 
 	return c.JSON(fiber.Map{
@@ -98,25 +89,16 @@ func (h *NotificationHistoryHandler) GetAll(c *fiber.Ctx) error {
 func (h *NotificationHistoryHandler) GetByID(c *fiber.Ctx) error {
 	id, err := strconv.Atoi(c.Params("id"))
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error":   "Invalid ID",
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid ID")
 	}
 
-	history, err := h.repo.GetByID(id)
+	history, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch notification",
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to fetch notification")
 	}
 
 	if history == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error":   "Notification not found",
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Notification not found")
 	}
 
 	return c.JSON(fiber.Map{
@@ -135,12 +117,9 @@ func (h *NotificationHistoryHandler) GetStats(c *fiber.Ctx) error {
 		}
 	}
 
-	stats, err := h.repo.GetStats(days)
+	stats, err := h.repo.GetStats(c.UserContext(), days)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to fetch statistics",
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to fetch statistics")
 	}
 
 	return c.JSON(fiber.Map{
@@ -149,6 +128,30 @@ func (h *NotificationHistoryHandler) GetStats(c *fiber.Ctx) error {
 	})
 }
 
+// GetFailures returns the deliveries that failed in the last hours grouped
+// by channel and error
+// GET /notification-history/failures?hours=24
+func (h *NotificationHistoryHandler) GetFailures(c *fiber.Ctx) error {
+	hours := 24
+	if hoursStr := c.Query("hours"); hoursStr != "" {
+		if v, err := strconv.Atoi(hoursStr); err == nil && v > 0 {
+			hours = v
+		}
+	}
+
+	until := time.Now()
+	since := until.Add(-time.Duration(hours) * time.Hour)
+	failures, err := h.repo.GetFailures(c.UserContext(), since)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to fetch delivery failures")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    models.NewDeliveryReport(since, until, failures),
+	})
+}
+
 // Cleanup deletes old notification history
 // DELETE /notification-history/cleanup?days=30
 func (h *NotificationHistoryHandler) Cleanup(c *fiber.Ctx) error {
@@ -159,12 +162,9 @@ func (h *NotificationHistoryHandler) Cleanup(c *fiber.Ctx) error {
 		}
 	}
 
-	deleted, err := h.repo.DeleteOlderThan(days)
+	deleted, err := h.repo.DeleteOlderThan(c.UserContext(), days)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error":   "Failed to cleanup history",
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to cleanup history")
 	}
 
 	return c.JSON(fiber.Map{
@@ -2,41 +2,39 @@ package handlers
 
 import (
 	"encoding/json"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/database"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // NotificationHandler handles notification channel operations
 type NotificationHandler struct {
-	repo    *database.NotificationRepository
+	repo    database.NotificationRepository
 	manager *alerter.Manager
 }
 
 // NewNotificationHandler creates a new notification handler
-func NewNotificationHandler() *NotificationHandler {
+func NewNotificationHandler(store *database.Store) *NotificationHandler {
 	return &NotificationHandler{
-		repo:    database.NewNotificationRepository(),
-		manager: alerter.NewManager(),
+		repo:    store.Notifications,
+		manager: alerter.NewManager(store),
 	}
 }
 
-// GetAll returns all notification channels
+// GetAll returns the notification channels of the request's project
 func (h *NotificationHandler) GetAll(c *fiber.Ctx) error {
-	channels, err := h.repo.GetAll()
+	channels, err := h.repo.GetAll(c.UserContext())
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch notification channels",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch notification channels")
 	}
+	channels = slices.DeleteFunc(channels, func(ch models.NotificationChannel) bool { return !inRequestProject(c, ch.ProjectID) })
 
 	return c.JSON(fiber.Map{
 		"success": true,
@@ -44,44 +42,48 @@ func (h *NotificationHandler) GetAll(c *fiber.Ctx) error {
 	})
 }
 
+// Languages returns the languages notifications can be sent in, set per
+// channel with the "language" config key
+func (h *NotificationHandler) Languages(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    alerter.Languages(),
+	})
+}
+
 // Create creates a new notification channel
 func (h *NotificationHandler) Create(c *fiber.Ctx) error {
 	var req models.NotificationChannelCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
 	}
 
 	// Validate type
 	if req.Type != "telegram" && req.Type != "discord" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_TYPE",
-				"message": "Type must be 'telegram' or 'discord'",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidType, "Type must be 'telegram' or 'discord'")
+	}
+
+	// Validate language
+	if language, _ := req.Config["language"].(string); language != "" && !alerter.IsLanguage(language) {
+		return errorResponse(c, 400, apierror.InvalidConfig, "Unknown language, use one of: "+strings.Join(alerter.Languages(), ", "))
+	}
+
+	// Validate timezone
+	if timezone, _ := req.Config["timezone"].(string); timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return errorResponse(c, 400, apierror.InvalidConfig, "Unknown timezone, use an IANA name such as Asia/Seoul")
+		}
 	}
 
 	// Marshal config to JSON
 	configJSON, err := json.Marshal(req.Config)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_CONFIG",
-				"message": "Invalid configuration",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidConfig, "Invalid configuration")
 	}
 
 	channel := &models.NotificationChannel{
 		ID:        uuid.New().String(),
+		ProjectID: newResourceProject(c),
 		Name:      req.Name,
 		Type:      req.Type,
 		Config:    string(configJSON),
@@ -89,14 +91,8 @@ func (h *NotificationHandler) Create(c *fiber.Ctx) error {
 		CreatedAt: time.Now(),
 	}
 
-	if err := h.repo.Create(channel); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "CREATE_ERROR",
-				"message": "Failed to create notification channel",
-			},
-		})
+	if err := h.repo.Create(c.UserContext(), channel); err != nil {
+		return errorResponse(c, 500, apierror.CreateError, "Failed to create notification channel")
 	}
 
 	return c.Status(201).JSON(fiber.Map{
@@ -109,25 +105,13 @@ func (h *NotificationHandler) Create(c *fiber.Ctx) error {
 func (h *NotificationHandler) Test(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	channel, err := h.repo.GetByID(id)
+	channel, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch channel",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch channel")
 	}
 
 	if channel == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Channel not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Channel not found")
 	}
 
 	// Create test notification
@@ -135,9 +119,9 @@ func (h *NotificationHandler) Test(c *fiber.Ctx) error {
 		ServiceID:   "test",
 		ServiceName: "Test Service",
 		Status:      models.StatusHealthy,
-		Message:     "This is a test notification from MT-Monitor",
 		Time:        time.Now(),
 	}
+	notification.SetMessage("msg_test")
 
 	// Send via manager
 	var provider alerter.AlertProvider
@@ -145,38 +129,20 @@ func (h *NotificationHandler) Test(c *fiber.Ctx) error {
 	case "discord":
 		var config models.DiscordConfig
 		if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
-			return c.Status(400).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "INVALID_CONFIG",
-					"message": "Invalid Discord configuration",
-				},
-			})
+			return errorResponse(c, 400, apierror.InvalidConfig, "Invalid Discord configuration")
 		}
-		provider = alerter.NewDiscordProvider(config.WebhookURL)
+		provider = alerter.NewDiscordProvider(config.WebhookURL, config.Language, config.Timezone)
 
 	case "telegram":
 		var config models.TelegramConfig
 		if err := json.Unmarshal([]byte(channel.Config), &config); err != nil {
-			return c.Status(400).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "INVALID_CONFIG",
-					"message": "Invalid Telegram configuration",
-				},
-			})
+			return errorResponse(c, 400, apierror.InvalidConfig, "Invalid Telegram configuration")
 		}
-		provider = alerter.NewTelegramProvider(config.BotToken, config.ChatID)
+		provider = alerter.NewTelegramProvider(config.BotToken, config.ChatID, config.Language, config.Timezone)
 	}
 
 	if err := provider.Send(notification); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SEND_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.SendError, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -189,73 +155,49 @@ func (h *NotificationHandler) Test(c *fiber.Ctx) error {
 func (h *NotificationHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	channel, err := h.repo.GetByID(id)
+	channel, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch channel",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch channel")
 	}
 
 	if channel == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Channel not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Channel not found")
 	}
 
 	var req models.NotificationChannelCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": "Invalid request body",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
 	}
 
 	// Validate type
 	if req.Type != "telegram" && req.Type != "discord" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_TYPE",
-				"message": "Type must be 'telegram' or 'discord'",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidType, "Type must be 'telegram' or 'discord'")
+	}
+
+	// Validate language
+	if language, _ := req.Config["language"].(string); language != "" && !alerter.IsLanguage(language) {
+		return errorResponse(c, 400, apierror.InvalidConfig, "Unknown language, use one of: "+strings.Join(alerter.Languages(), ", "))
+	}
+
+	// Validate timezone
+	if timezone, _ := req.Config["timezone"].(string); timezone != "" {
+		if _, err := time.LoadLocation(timezone); err != nil {
+			return errorResponse(c, 400, apierror.InvalidConfig, "Unknown timezone, use an IANA name such as Asia/Seoul")
+		}
 	}
 
 	// Marshal config to JSON
 	configJSON, err := json.Marshal(req.Config)
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_CONFIG",
-				"message": "Invalid configuration",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidConfig, "Invalid configuration")
 	}
 
 	channel.Name = req.Name
 	channel.Type = req.Type
 	channel.Config = string(configJSON)
 
-	if err := h.repo.Update(channel); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "UPDATE_ERROR",
-				"message": "Failed to update notification channel",
-			},
-		})
+	if err := h.repo.Update(c.UserContext(), channel); err != nil {
+		return errorResponse(c, 500, apierror.UpdateError, "Failed to update notification channel")
 	}
 
 	return c.JSON(fiber.Map{
@@ -268,36 +210,18 @@ func (h *NotificationHandler) Update(c *fiber.Ctx) error {
 func (h *NotificationHandler) Toggle(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	channel, err := h.repo.GetByID(id)
+	channel, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "FETCH_ERROR",
-				"message": "Failed to fetch channel",
-			},
-		})
+		return errorResponse(c, 500, apierror.FetchError, "Failed to fetch channel")
 	}
 
 	if channel == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NOT_FOUND",
-				"message": "Channel not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.NotFound, "Channel not found")
 	}
 
 	newState := !channel.IsEnabled
-	if err := h.repo.SetEnabled(id, newState); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "TOGGLE_ERROR",
-				"message": "Failed to toggle notification channel",
-			},
-		})
+	if err := h.repo.SetEnabled(c.UserContext(), id, newState); err != nil {
+		return errorResponse(c, 500, apierror.ToggleError, "Failed to toggle notification channel")
 	}
 
 	return c.JSON(fiber.Map{
@@ -313,14 +237,8 @@ func (h *NotificationHandler) Toggle(c *fiber.Ctx) error {
 func (h *NotificationHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	if err := h.repo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DELETE_ERROR",
-				"message": "Failed to delete notification channel",
-			},
-		})
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return errorResponse(c, 500, apierror.DeleteError, "Failed to delete notification channel")
 	}
 
 	return c.JSON(fiber.Map{
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ownershipFieldLimit caps the length of each ownership field
+const ownershipFieldLimit = 200
+
+// validateOwnership returns the invalid field for the ownership of a
+// service or host, or nil when it is valid
+func validateOwnership(o *models.Ownership) *apierror.FieldError {
+	if o == nil {
+		return nil
+	}
+	limited := []struct{ field, value string }{
+		{"ownership.team", o.Team}, {"ownership.contact", o.Contact}, {"ownership.escalation", o.Escalation},
+	}
+	for _, f := range limited {
+		if len(f.value) > ownershipFieldLimit {
+			return apierror.Invalid(f.field, f.field+" must be at most 200 characters")
+		}
+	}
+	if len(strings.TrimSpace(o.Owner)) > maxOwnerLength {
+		return apierror.Invalid("ownership.owner", "ownership.owner must be at most 64 characters")
+	}
+	return nil
+}
+
+// ownershipQuery returns the ?team= and ?owner= filters of a list request
+func ownershipQuery(c *fiber.Ctx) (team, owner string) {
+	return strings.TrimSpace(c.Query("team")), strings.TrimSpace(c.Query("owner"))
+}
@@ -0,0 +1,19 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// pageLimit returns the ?limit= of a list request, def when it is missing
+// or invalid. It is lowered to server.maxPageSize so a single request can't
+// load an unbounded number of rows; clients page through the rest.
+func pageLimit(c *fiber.Ctx, def int) int {
+	limit := def
+	if parsed, err := strconv.Atoi(c.Query("limit")); err == nil && parsed > 0 {
+		limit = parsed
+	}
+	return min(limit, config.GetMaxPageSize())
+}
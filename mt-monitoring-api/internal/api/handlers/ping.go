@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/cluster"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// maxPingMessage bounds the failure message taken from a ping body
+const maxPingMessage = 500
+
+// PingHandler receives healthchecks.io-compatible pings for heartbeat
+// services. Answers are plain text like healthchecks.io's, so existing
+// cron wrappers and client libraries work unchanged.
+type PingHandler struct {
+	repo      database.ServiceRepository
+	scheduler *checker.Scheduler
+}
+
+// NewPingHandler creates a new ping handler
+func NewPingHandler(store *database.Store, scheduler *checker.Scheduler) *PingHandler {
+	return &PingHandler{
+		repo:      store.Services,
+		scheduler: scheduler,
+	}
+}
+
+// Ping records a ping. The optional action is "start", "fail" or a
+// process exit status, where 0 is success.
+// GET|POST /ping/:key/:action?
+func (h *PingHandler) Ping(c *fiber.Ctx) error {
+	kind, message, ok := parsePingAction(c.Params("action"))
+	if !ok {
+		return c.Status(404).SendString("not found")
+	}
+
+	service, err := h.repo.GetByPingKey(c.UserContext(), c.Params("key"))
+	if err != nil {
+		return c.Status(500).SendString("error")
+	}
+	if service == nil || service.Type != models.ServiceTypeHeartbeat {
+		return c.Status(404).SendString("not found")
+	}
+	// Pings of a paused heartbeat are accepted but not recorded
+	if !service.IsActive {
+		return c.SendString("OK")
+	}
+	// In a cluster the ping state lives on the node checking the service;
+	// pings that reach another node are passed on, or recorded here if the
+	// owner cannot be reached
+	if owner, ok := cluster.Owner(service.ID); ok && c.Get(cluster.ForwardedHeader) == "" {
+		err := cluster.Forward(c.UserContext(), owner, c.Method(), c.OriginalURL(), c.Body())
+		if err == nil {
+			return c.SendString("OK")
+		}
+		log.Printf("Failed to forward ping of %s to node %s: %v", service.ID, owner.ID, err)
+	}
+
+	if kind == checker.PingFail {
+		if body := pingMessage(c.Body()); body != "" {
+			if message != "" {
+				message += ": "
+			}
+			message += body
+		}
+	}
+	h.scheduler.Ping(service, kind, message)
+	return c.SendString("OK")
+}
+
+// parsePingAction maps the ping URL suffix to a ping kind and, for a
+// non-zero exit status, a message
+func parsePingAction(action string) (checker.PingKind, string, bool) {
+	switch action {
+	case "":
+		return checker.PingSuccess, "", true
+	case "start":
+		return checker.PingStart, "", true
+	case "fail":
+		return checker.PingFail, "", true
+	}
+
+	status, err := strconv.Atoi(action)
+	if err != nil || status < 0 || status > 255 {
+		return "", "", false
+	}
+	if status == 0 {
+		return checker.PingSuccess, "", true
+	}
+	return checker.PingFail, "exit status " + action, true
+}
+
+// pingMessage returns the first line of a ping body, shortened to
+// maxPingMessage bytes
+func pingMessage(body []byte) string {
+	text := strings.TrimSpace(string(body))
+	if i := strings.IndexByte(text, '\n'); i >= 0 {
+		text = strings.TrimSpace(text[:i])
+	}
+	if len(text) > maxPingMessage {
+		text = text[:maxPingMessage]
+		for !utf8.ValidString(text) {
+			text = text[:len(text)-1]
+		}
+	}
+	return text
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// GetPorts returns the listening ports of a host from its last ports check,
+// compared with its expected ports.
+func (h *SystemHandler) GetPorts(c *fiber.Ctx) error {
+	ports, err := h.portsRepo.Get(c.UserContext(), h.getHostID(c))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if ports == nil {
+		return errorResponse(c, 404, apierror.PortsNotFound, "No ports check recorded for this host yet.")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ports,
+	})
+}
+
+// SetExpectedPorts replaces the expected ports of a host. They are compared
+// with the ports found by the next check.
+func (h *SystemHandler) SetExpectedPorts(c *fiber.Ctx) error {
+	var req models.ExpectedPortsRequest
+	if err := c.BodyParser(&req); err != nil || req.Ports == nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Body must be {\"ports\": [\"tcp/22\", ...]}")
+	}
+	for _, key := range req.Ports {
+		if _, _, ok := models.ParsePortKey(key); !ok {
+			return errorResponse(c, 400, apierror.InvalidRequest, "Invalid port "+key+", use protocol/port such as tcp/22 or udp/53")
+		}
+	}
+
+	hostID := h.getHostID(c)
+	ports, err := h.portsRepo.Get(c.UserContext(), hostID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if ports == nil {
+		return errorResponse(c, 404, apierror.PortsNotFound, "No ports check recorded for this host yet.")
+	}
+
+	expected := slices.Clone(req.Ports)
+	models.SortPortKeys(expected)
+	expected = slices.Compact(expected)
+	if err := h.portsRepo.SetExpected(c.UserContext(), hostID, expected); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	ports.Expected = expected
+	ports.Compare()
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    ports,
+	})
+}
+
+// GetPortHistory returns the listening ports of a host each time they
+// changed in the last ?days=N days (default 7, at most 90), newest first.
+func (h *SystemHandler) GetPortHistory(c *fiber.Ctx) error {
+	days := c.QueryInt("days", 7)
+	if days < 1 || days > 90 {
+		return errorResponse(c, 400, apierror.InvalidRequest, "days must be between 1 and 90")
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	snapshots, err := h.portsRepo.GetSnapshots(c.UserContext(), h.getHostID(c), since)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    snapshots,
+	})
+}
@@ -0,0 +1,417 @@
+package handlers
+
+import (
+	"net/mail"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	// maxOwnerLength bounds the X-User header naming a preference owner
+	maxOwnerLength = 64
+	// maxViewNameLength bounds the name of a saved view
+	maxViewNameLength = 100
+)
+
+// telegramChatID matches a numeric chat ID or a public @channel name
+var telegramChatID = regexp.MustCompile(`^(-?[0-9]+|@[A-Za-z0-9_]{5,})$`)
+
+// PreferenceHandler handles dashboard preferences, saved views and
+// notification preferences. The API has no users yet, so preferences belong
+// to the owner named by the X-User header, the global owner when it is
+// absent, within the project of the request.
+type PreferenceHandler struct {
+	repo        database.PreferenceRepository
+	serviceRepo database.ServiceRepository
+	hostRepo    database.HostRepository
+}
+
+// NewPreferenceHandler creates a new preference handler
+func NewPreferenceHandler(store *database.Store) *PreferenceHandler {
+	return &PreferenceHandler{
+		repo:        store.Preferences,
+		serviceRepo: store.Services,
+		hostRepo:    store.Hosts,
+	}
+}
+
+// Get returns the dashboard preferences, without services and hosts that
+// no longer exist
+// GET /preferences
+func (h *PreferenceHandler) Get(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	prefs, err := h.repo.Get(c.UserContext(), newResourceProject(c), owner)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	services, hosts, err := h.knownIDs(c)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	unknown := func(known map[string]bool) func(string) bool {
+		return func(id string) bool { return !known[id] }
+	}
+	prefs.PinnedServices = slices.DeleteFunc(prefs.PinnedServices, unknown(services))
+	prefs.ServiceOrder = slices.DeleteFunc(prefs.ServiceOrder, unknown(services))
+	prefs.PinnedHosts = slices.DeleteFunc(prefs.PinnedHosts, unknown(hosts))
+	prefs.HostOrder = slices.DeleteFunc(prefs.HostOrder, unknown(hosts))
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    prefs,
+	})
+}
+
+// Save replaces the dashboard preferences
+// PUT /preferences
+func (h *PreferenceHandler) Save(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	var req models.DashboardPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+
+	prefs := &models.DashboardPreferences{
+		ProjectID:      newResourceProject(c),
+		Owner:          owner,
+		PinnedServices: uniqueIDs(req.PinnedServices),
+		PinnedHosts:    uniqueIDs(req.PinnedHosts),
+		ServiceOrder:   uniqueIDs(req.ServiceOrder),
+		HostOrder:      uniqueIDs(req.HostOrder),
+	}
+	services, hosts, err := h.knownIDs(c)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	for _, invalid := range []*apierror.FieldError{
+		unknownID(services, "pinnedServices", "service", prefs.PinnedServices),
+		unknownID(services, "serviceOrder", "service", prefs.ServiceOrder),
+		unknownID(hosts, "pinnedHosts", "host", prefs.PinnedHosts),
+		unknownID(hosts, "hostOrder", "host", prefs.HostOrder),
+	} {
+		if invalid != nil {
+			return validationError(c, invalid)
+		}
+	}
+
+	if err := h.repo.Save(c.UserContext(), prefs); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    prefs,
+	})
+}
+
+// GetViews returns the saved filter views
+// GET /preferences/views
+func (h *PreferenceHandler) GetViews(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	views, err := h.repo.GetViews(c.UserContext(), newResourceProject(c), owner)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    views,
+	})
+}
+
+// CreateView saves a filter view
+// POST /preferences/views
+func (h *PreferenceHandler) CreateView(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	var req models.DashboardViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return invalidField(c, "name", "name is required")
+	}
+	if len(req.Name) > maxViewNameLength {
+		return invalidField(c, "name", "name must be at most 100 characters")
+	}
+
+	view := &models.DashboardView{ProjectID: newResourceProject(c), Owner: owner, Name: req.Name, Filters: req.Filters}
+	if err := h.repo.CreateView(c.UserContext(), view); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if view.Filters == nil {
+		view.Filters = map[string]interface{}{}
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    view,
+	})
+}
+
+// UpdateView renames a view or replaces its filters
+// PUT /preferences/views/:id
+func (h *PreferenceHandler) UpdateView(c *fiber.Ctx) error {
+	view, err := h.view(c)
+	if err != nil || view == nil {
+		return err
+	}
+	var req models.DashboardViewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	if name := strings.TrimSpace(req.Name); name != "" {
+		if len(name) > maxViewNameLength {
+			return invalidField(c, "name", "name must be at most 100 characters")
+		}
+		view.Name = name
+	}
+	if req.Filters != nil {
+		view.Filters = req.Filters
+	}
+
+	if err := h.repo.UpdateView(c.UserContext(), view); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    view,
+	})
+}
+
+// DeleteView deletes a saved view
+// DELETE /preferences/views/:id
+func (h *PreferenceHandler) DeleteView(c *fiber.Ctx) error {
+	view, err := h.view(c)
+	if err != nil || view == nil {
+		return err
+	}
+	if _, err := h.repo.DeleteView(c.UserContext(), view.ProjectID, view.Owner, view.ID); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "View deleted",
+	})
+}
+
+// GetNotifications returns the notification preferences
+// GET /preferences/notifications
+func (h *PreferenceHandler) GetNotifications(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	prefs, err := h.repo.GetNotifications(c.UserContext(), newResourceProject(c), owner)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    prefs,
+	})
+}
+
+// SaveNotifications replaces the notification preferences: the personal
+// channels of the user and the severities and scopes of the alerts sent
+// to them
+// PUT /preferences/notifications
+func (h *PreferenceHandler) SaveNotifications(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	var req models.NotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+
+	prefs := &models.NotificationPreferences{
+		ProjectID:      newResourceProject(c),
+		Owner:          owner,
+		Enabled:        req.Enabled == nil || *req.Enabled,
+		TelegramChatID: strings.TrimSpace(req.TelegramChatID),
+		Email:          strings.TrimSpace(req.Email),
+		Language:       req.Language,
+		Timezone:       req.Timezone,
+		Severities:     slices.Compact(slices.Sorted(slices.Values(req.Severities))),
+		ServiceIDs:     uniqueIDs(req.ServiceIDs),
+		HostIDs:        uniqueIDs(req.HostIDs),
+		Tags:           uniqueIDs(req.Tags),
+		AllAlerts:      req.AllAlerts,
+	}
+	if invalid := validateNotificationPreferences(prefs); invalid != nil {
+		return validationError(c, invalid)
+	}
+	services, hosts, err := h.knownIDs(c)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if invalid := unknownID(services, "serviceIds", "service", prefs.ServiceIDs); invalid != nil {
+		return validationError(c, invalid)
+	}
+	if invalid := unknownID(hosts, "hostIds", "host", prefs.HostIDs); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	if err := h.repo.SaveNotifications(c.UserContext(), prefs); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    prefs,
+	})
+}
+
+// DeleteNotifications deletes the notification preferences, stopping the
+// alerts to the personal channels
+// DELETE /preferences/notifications
+func (h *PreferenceHandler) DeleteNotifications(c *fiber.Ctx) error {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	deleted, err := h.repo.DeleteNotifications(c.UserContext(), newResourceProject(c), owner)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.NotFound, "No notification preferences saved")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Notification preferences deleted",
+	})
+}
+
+// validateNotificationPreferences checks the channels, language, timezone
+// and severities of notification preferences, returning the problem or nil
+func validateNotificationPreferences(p *models.NotificationPreferences) *apierror.FieldError {
+	cfg := config.Get()
+	if p.TelegramChatID != "" {
+		if !telegramChatID.MatchString(p.TelegramChatID) {
+			return apierror.Invalid("telegramChatId", "telegramChatId must be a numeric chat ID or an @channel name")
+		}
+		if cfg == nil || cfg.Alerts.Channels.Telegram.BotToken == "" {
+			return apierror.Invalid("telegramChatId", "alerts.channels.telegram.botToken must be configured to send to Telegram chats")
+		}
+	}
+	if p.Email != "" {
+		if addr, err := mail.ParseAddress(p.Email); err != nil || addr.Address != p.Email {
+			return apierror.Invalid("email", "email must be a plain email address")
+		}
+		if cfg == nil || cfg.Alerts.Channels.Email.SMTP.Host == "" {
+			return apierror.Invalid("email", "alerts.channels.email.smtp must be configured to send email")
+		}
+	}
+	if p.Language != "" && !alerter.IsLanguage(p.Language) {
+		return apierror.Invalid("language", "Unknown language, use one of: "+strings.Join(alerter.Languages(), ", "))
+	}
+	if p.Timezone != "" {
+		if _, err := time.LoadLocation(p.Timezone); err != nil {
+			return apierror.Invalid("timezone", "Unknown timezone, use an IANA name such as Asia/Seoul")
+		}
+	}
+	for _, s := range p.Severities {
+		if !s.IsValid() {
+			return apierror.Invalid("severities", "severities must be any of: critical, warning, info")
+		}
+	}
+	return nil
+}
+
+// view loads the view of the :id parameter, sending the error response and
+// returning nil when it cannot
+func (h *PreferenceHandler) view(c *fiber.Ctx) (*models.DashboardView, error) {
+	owner, ok := preferenceOwner(c)
+	if !ok {
+		return nil, errorResponse(c, 400, apierror.InvalidRequest, "X-User must be at most 64 characters")
+	}
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, errorResponse(c, 400, apierror.InvalidRequest, "Invalid view ID")
+	}
+	view, err := h.repo.GetView(c.UserContext(), newResourceProject(c), owner, id)
+	if err != nil {
+		return nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if view == nil {
+		return nil, errorResponse(c, 404, apierror.ViewNotFound, "View not found")
+	}
+	return view, nil
+}
+
+// knownIDs returns the IDs of the services and hosts of the project the
+// preferences of the request belong to
+func (h *PreferenceHandler) knownIDs(c *fiber.Ctx) (services, hosts map[string]bool, err error) {
+	serviceList, err := h.serviceRepo.GetAll(c.UserContext())
+	if err != nil {
+		return nil, nil, err
+	}
+	hostList, err := h.hostRepo.GetAll(c.UserContext())
+	if err != nil {
+		return nil, nil, err
+	}
+	projectID := newResourceProject(c)
+	services = make(map[string]bool, len(serviceList))
+	for _, s := range serviceList {
+		services[s.ID] = s.ProjectID == projectID
+	}
+	hosts = make(map[string]bool, len(hostList))
+	for _, host := range hostList {
+		hosts[host.ID] = host.ProjectID == projectID
+	}
+	return services, hosts, nil
+}
+
+// unknownID reports the first of ids that isn't in known against field
+func unknownID(known map[string]bool, field, kind string, ids []string) *apierror.FieldError {
+	for _, id := range ids {
+		if !known[id] {
+			return apierror.Invalid(field, "unknown "+kind+": "+id)
+		}
+	}
+	return nil
+}
+
+// preferenceOwner returns the owner named by the X-User header, the global
+// owner when it is absent; false if it is too long
+func preferenceOwner(c *fiber.Ctx) (string, bool) {
+	owner := strings.TrimSpace(c.Get("X-User"))
+	if owner == "" {
+		return models.GlobalPreferenceOwner, true
+	}
+	return owner, len(owner) <= maxOwnerLength
+}
+
+// uniqueIDs drops repeated IDs, keeping the first of each
+func uniqueIDs(ids []string) []string {
+	seen := make(map[string]bool, len(ids))
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			list = append(list, id)
+		}
+	}
+	return list
+}
@@ -0,0 +1,110 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	defaultProbeTimeout = 5000  // milliseconds
+	maxProbeTimeout     = 60000 // milliseconds
+)
+
+// ProbeHandler runs ad-hoc checks without creating a service
+type ProbeHandler struct{}
+
+// NewProbeHandler creates a new probe handler
+func NewProbeHandler() *ProbeHandler {
+	return &ProbeHandler{}
+}
+
+// Probe checks a target immediately and returns the result. With
+// format=prometheus the result is in the Prometheus text format, so the
+// endpoint can be scraped like blackbox_exporter's /probe.
+// GET /probe?target=&module=http|tcp|icmp|dns&timeout=&format=json|prometheus
+func (h *ProbeHandler) Probe(c *fiber.Ctx) error {
+	module := models.ProbeModule(strings.ToLower(c.Query("module", string(models.ProbeModuleHTTP))))
+	format := c.Query("format", "json")
+	if format != "json" && format != "prometheus" {
+		return invalidField(c, "format", "format must be json or prometheus")
+	}
+	switch module {
+	case models.ProbeModuleHTTP, models.ProbeModuleTCP, models.ProbeModuleICMP, models.ProbeModuleDNS:
+	default:
+		return invalidField(c, "module", "module must be http, tcp, icmp or dns")
+	}
+
+	timeout, err := probeTimeout(c)
+	if err != nil {
+		return invalidField(c, "timeout", err.Error())
+	}
+
+	// With a known module only the target can be unusable
+	result, err := checker.Probe(module, c.Query("target"), timeout)
+	if err != nil {
+		return invalidField(c, "target", err.Error())
+	}
+
+	if format == "prometheus" {
+		c.Set(fiber.HeaderContentType, "text/plain; version=0.0.4; charset=utf-8")
+		return c.SendString(probeMetrics(result))
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    result,
+	})
+}
+
+// probeTimeout returns the timeout query parameter in milliseconds. Without
+// one, a Prometheus scrape timeout header leaves half a second for the
+// response, like blackbox_exporter.
+func probeTimeout(c *fiber.Ctx) (int, error) {
+	if s := c.Query("timeout"); s != "" {
+		timeout, err := strconv.Atoi(s)
+		if err != nil || timeout < 1 || timeout > maxProbeTimeout {
+			return 0, fmt.Errorf("timeout must be between 1 and %d milliseconds", maxProbeTimeout)
+		}
+		return timeout, nil
+	}
+
+	if s := c.Get("X-Prometheus-Scrape-Timeout-Seconds"); s != "" {
+		if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+			timeout := int(seconds*1000) - 500
+			if timeout > maxProbeTimeout {
+				timeout = maxProbeTimeout
+			}
+			if timeout >= 1 {
+				return timeout, nil
+			}
+		}
+	}
+	return defaultProbeTimeout, nil
+}
+
+// probeMetrics formats a probe result in the Prometheus text format with
+// blackbox_exporter's metric names
+func probeMetrics(r *models.ProbeResult) string {
+	var b strings.Builder
+	gauge := func(name, help string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s gauge\n%s %g\n", name, help, name, name, value)
+	}
+
+	success := 0.0
+	if r.Success {
+		success = 1
+	}
+	gauge("probe_success", "Displays whether or not the probe was a success", success)
+	gauge("probe_duration_seconds", "Returns how long the probe took to complete in seconds", float64(r.ResponseTime)/1000)
+	if r.Module == models.ProbeModuleHTTP && r.StatusCode > 0 {
+		gauge("probe_http_status_code", "Response HTTP status code", float64(r.StatusCode))
+	}
+	if r.Module == models.ProbeModuleDNS {
+		gauge("probe_dns_answer_rrs", "Returns number of entries in the answer resource record list", float64(len(r.Addresses)))
+	}
+	return b.String()
+}
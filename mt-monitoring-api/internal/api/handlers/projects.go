@@ -0,0 +1,223 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// projectIDPattern is the form of project IDs, which clients send in the
+// X-Project header
+var projectIDPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,63}$`)
+
+// ProjectHandler manages projects and their tokens (admin token only)
+type ProjectHandler struct {
+	repo database.ProjectRepository
+}
+
+// NewProjectHandler creates a new project handler
+func NewProjectHandler(store *database.Store) *ProjectHandler {
+	return &ProjectHandler{repo: store.Projects}
+}
+
+// GetAll returns every project with its token
+// GET /admin/projects
+func (h *ProjectHandler) GetAll(c *fiber.Ctx) error {
+	projects, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    projects,
+	})
+}
+
+// GetByID returns a project with its token
+// GET /admin/projects/:id
+func (h *ProjectHandler) GetByID(c *fiber.Ctx) error {
+	project, err := h.repo.GetByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if project == nil {
+		return errorResponse(c, 404, apierror.ProjectNotFound, "Project not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    project,
+	})
+}
+
+// Create creates a project with a new token
+// POST /admin/projects
+func (h *ProjectHandler) Create(c *fiber.Ctx) error {
+	var req models.ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	req.ID = strings.TrimSpace(req.ID)
+	if !projectIDPattern.MatchString(req.ID) {
+		return invalidField(c, "id", "id must be 1-64 lowercase letters, digits and dashes, not starting with a dash")
+	}
+	if invalid := validateProjectRequest(&req); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	existing, err := h.repo.GetByID(c.UserContext(), req.ID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if existing != nil {
+		return errorResponse(c, 409, apierror.ProjectExists, "Project with this ID already exists")
+	}
+
+	project := &models.Project{
+		ID:          req.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Token:       crypto.GenerateProjectToken(),
+	}
+	if err := h.repo.Create(c.UserContext(), project); err != nil {
+		return errorResponse(c, 500, apierror.CreateError, err.Error())
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    project,
+	})
+}
+
+// Update replaces the name and description of a project
+// PUT /admin/projects/:id
+func (h *ProjectHandler) Update(c *fiber.Ctx) error {
+	project, err := h.repo.GetByID(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if project == nil {
+		return errorResponse(c, 404, apierror.ProjectNotFound, "Project not found")
+	}
+
+	var req models.ProjectRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	if invalid := validateProjectRequest(&req); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	project.Name = req.Name
+	project.Description = req.Description
+	if err := h.repo.Update(c.UserContext(), project); err != nil {
+		return errorResponse(c, 500, apierror.UpdateError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    project,
+	})
+}
+
+// Delete deletes an empty project with the preferences of its users. The
+// default project is kept.
+// DELETE /admin/projects/:id
+func (h *ProjectHandler) Delete(c *fiber.Ctx) error {
+	id := c.Params("id")
+	if id == models.DefaultProject {
+		return errorResponse(c, 400, apierror.CannotDeleteDefault, "The default project cannot be deleted")
+	}
+	project, err := h.repo.GetByID(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if project == nil {
+		return errorResponse(c, 404, apierror.ProjectNotFound, "Project not found")
+	}
+	count, err := h.repo.CountResources(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if count > 0 {
+		return errorResponse(c, 409, apierror.ProjectNotEmpty, "Delete the services, hosts, alert rules and notification channels of the project first")
+	}
+
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return errorResponse(c, 500, apierror.DeleteError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Project deleted",
+	})
+}
+
+// RegenerateToken replaces the token of a project, revoking the previous one
+// POST /admin/projects/:id/regenerate-token
+func (h *ProjectHandler) RegenerateToken(c *fiber.Ctx) error {
+	id := c.Params("id")
+	project, err := h.repo.GetByID(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if project == nil {
+		return errorResponse(c, 404, apierror.ProjectNotFound, "Project not found")
+	}
+
+	token := crypto.GenerateProjectToken()
+	if err := h.repo.UpdateToken(c.UserContext(), id, token); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to regenerate project token")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"token": token,
+		},
+	})
+}
+
+// validateProjectRequest trims the name and description of a project and
+// returns the invalid field, or nil when they are valid
+func validateProjectRequest(req *models.ProjectRequest) *apierror.FieldError {
+	req.Name = strings.TrimSpace(req.Name)
+	req.Description = strings.TrimSpace(req.Description)
+	if req.Name == "" {
+		return apierror.Invalid("name", "name is required")
+	}
+	if len(req.Name) > 100 || len(req.Description) > 500 {
+		return apierror.Invalid("name", "name must be at most 100 characters and description at most 500")
+	}
+	return nil
+}
+
+// requestProject returns the project of a request, "" when it spans every
+// project (see middleware.Project)
+func requestProject(c *fiber.Ctx) string {
+	projectID, _ := c.Locals("project").(string)
+	return projectID
+}
+
+// isAdmin reports whether the request carries the admin token (see
+// middleware.Project)
+func isAdmin(c *fiber.Ctx) bool {
+	admin, _ := c.Locals("admin").(bool)
+	return admin
+}
+
+// inRequestProject reports whether a resource of projectID is visible to
+// the request
+func inRequestProject(c *fiber.Ctx, projectID string) bool {
+	p := requestProject(c)
+	return p == "" || p == projectID
+}
+
+// newResourceProject returns the project of a resource created by the
+// request: that of the request, the default one when it spans every project
+func newResourceProject(c *fiber.Ctx) string {
+	if p := requestProject(c); p != "" {
+		return p
+	}
+	return models.DefaultProject
+}
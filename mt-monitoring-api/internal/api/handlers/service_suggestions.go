@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"slices"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// GetSuggestions proposes HTTP and TCP service monitors for the well-known
+// ports found by the last ports check of a host, with the service defaults
+// of ?profile= applied.
+// GET /hosts/:hostId/service-suggestions
+func (h *ServiceHandler) GetSuggestions(c *fiber.Ctx) error {
+	_, suggestions, err := h.suggestions(c)
+	if err != nil || suggestions == nil {
+		return err
+	}
+	profile := c.Query("profile")
+	for i := range suggestions {
+		suggestions[i].Service.Profile = profile
+		if !applyServiceDefaults(&suggestions[i].Service) {
+			return invalidField(c, "profile", "unknown service profile: "+profile)
+		}
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    suggestions,
+	})
+}
+
+// ApproveSuggestions creates the suggested services for the given ports of
+// a host. Ports without a suggestion, already monitored or whose service ID
+// is taken are skipped.
+// POST /hosts/:hostId/service-suggestions
+func (h *ServiceHandler) ApproveSuggestions(c *fiber.Ctx) error {
+	var req models.ServiceSuggestionRequest
+	if err := c.BodyParser(&req); err != nil || len(req.Ports) == 0 {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Body must be {\"ports\": [\"tcp/443\", ...]}")
+	}
+	for _, key := range req.Ports {
+		if _, _, ok := models.ParsePortKey(key); !ok {
+			return errorResponse(c, 400, apierror.InvalidRequest, "Invalid port "+key+", use protocol/port such as tcp/443")
+		}
+	}
+	if !applyServiceDefaults(&models.ServiceCreateRequest{Profile: req.Profile}) {
+		return invalidField(c, "profile", "unknown service profile: "+req.Profile)
+	}
+
+	host, suggestions, err := h.suggestions(c)
+	if err != nil || suggestions == nil {
+		return err
+	}
+	byPort := make(map[string]*models.ServiceSuggestion, len(suggestions))
+	for i := range suggestions {
+		byPort[suggestions[i].Port] = &suggestions[i]
+	}
+
+	results := make([]models.ServiceSuggestionResult, 0, len(req.Ports))
+	for _, key := range req.Ports {
+		result := models.ServiceSuggestionResult{Port: key}
+		suggestion := byPort[key]
+		switch {
+		case suggestion == nil:
+			result.Skipped = "no suggestion for this port"
+		case suggestion.MonitoredBy != "":
+			result.Skipped = "already monitored by " + suggestion.MonitoredBy
+		default:
+			suggestion.Service.Profile = req.Profile
+			service, skipped, err := h.createSuggested(c, host.ProjectID, &suggestion.Service)
+			if err != nil {
+				return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+			}
+			result.Service, result.Skipped = service, skipped
+			// A port listed twice is created once
+			suggestion.MonitoredBy = suggestion.Service.ID
+		}
+		results = append(results, result)
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    results,
+	})
+}
+
+// suggestions returns the host named in the URL and its service
+// suggestions, checked against the services of its project. When they are
+// nil the error response has been sent.
+func (h *ServiceHandler) suggestions(c *fiber.Ctx) (*models.Host, []models.ServiceSuggestion, error) {
+	hostID := c.Params("hostId")
+	host, err := h.hostRepo.GetByID(c.UserContext(), hostID)
+	if err != nil {
+		return nil, nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if host == nil {
+		return nil, nil, errorResponse(c, 404, apierror.HostNotFound, "Host not found")
+	}
+	address := suggestionAddress(host)
+	if address == "" {
+		return nil, nil, invalidField(c, "", "Host has no IP address to check services at.")
+	}
+
+	ports, err := h.portsRepo.Get(c.UserContext(), hostID)
+	if err != nil {
+		return nil, nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if ports == nil {
+		return nil, nil, errorResponse(c, 404, apierror.PortsNotFound, "No ports check recorded for this host yet.")
+	}
+	services, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return nil, nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	services = slices.DeleteFunc(services, func(s models.Service) bool { return s.ProjectID != host.ProjectID })
+	return host, models.SuggestServices(host, address, ports.Current, services), nil
+}
+
+// suggestionAddress returns where the services of a host are checked: its
+// IP, or loopback for the local host
+func suggestionAddress(host *models.Host) string {
+	if host.Type == models.HostTypeLocal {
+		return "127.0.0.1"
+	}
+	return host.IP
+}
+
+// createSuggested creates and schedules a suggested service in projectID,
+// that of its host. It returns why the service was skipped instead when its
+// ID is taken or it is invalid.
+func (h *ServiceHandler) createSuggested(c *fiber.Ctx, projectID string, req *models.ServiceCreateRequest) (*models.Service, string, error) {
+	if invalid := validateServiceRequest(req); invalid != nil {
+		return nil, invalid.Message, nil
+	}
+	existing, err := h.repo.GetByID(c.UserContext(), req.ID)
+	if err != nil {
+		return nil, "", err
+	}
+	if existing != nil {
+		return nil, "service " + req.ID + " already exists", nil
+	}
+
+	service := req.ToService()
+	service.ProjectID = projectID
+	service.ApiKey = crypto.GenerateApiKey()
+	if err := h.repo.Create(c.UserContext(), service); err != nil {
+		return nil, "", err
+	}
+	checker.AuditTLSVerification(service.ID, false, service.InsecureSkipVerify, "API client "+c.IP())
+	h.scheduler.AddService(service)
+	return maskedService(service), "", nil
+}
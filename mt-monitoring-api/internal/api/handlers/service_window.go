@@ -0,0 +1,214 @@
+package handlers
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ServiceWindowHandler handles pause, maintenance and exclusion windows
+// excluded from uptime
+type ServiceWindowHandler struct {
+	repo        database.ServiceWindowRepository
+	serviceRepo database.ServiceRepository
+}
+
+// NewServiceWindowHandler creates a new service window handler
+func NewServiceWindowHandler(store *database.Store) *ServiceWindowHandler {
+	return &ServiceWindowHandler{
+		repo:        store.ServiceWindows,
+		serviceRepo: store.Services,
+	}
+}
+
+// GetByServiceID returns the windows of a service, of one kind with ?kind=
+// GET /services/:id/windows?days=30&kind=
+func (h *ServiceWindowHandler) GetByServiceID(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+
+	kind := models.WindowKind(c.Query("kind"))
+	switch kind {
+	case "", models.WindowPaused, models.WindowMaintenance, models.WindowExcluded:
+	default:
+		return invalidField(c, "kind", "kind must be one of: paused, maintenance, excluded")
+	}
+
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+
+	windows, err := h.repo.GetByServiceID(c.UserContext(), serviceID, time.Now().AddDate(0, 0, -days))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if kind != "" {
+		filtered := []models.ServiceWindow{}
+		for _, w := range windows {
+			if w.Kind == kind {
+				filtered = append(filtered, w)
+			}
+		}
+		windows = filtered
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    windows,
+	})
+}
+
+// CreateMaintenance schedules a maintenance window for a service
+// POST /services/:id/maintenance
+func (h *ServiceWindowHandler) CreateMaintenance(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+
+	service, err := h.serviceRepo.GetByID(c.UserContext(), serviceID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if service == nil {
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
+	}
+
+	var req models.MaintenanceWindowCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+
+	if req.StartsAt.IsZero() {
+		return invalidField(c, "startsAt", "startsAt is required")
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return invalidField(c, "endsAt", "endsAt is required and must be after startsAt")
+	}
+
+	// Stored in local time like checked_at so range comparisons line up
+	endsAt := req.EndsAt.Local()
+	window := &models.ServiceWindow{
+		ServiceID: serviceID,
+		Kind:      models.WindowMaintenance,
+		StartsAt:  req.StartsAt.Local(),
+		EndsAt:    &endsAt,
+		Note:      req.Note,
+	}
+	if err := h.repo.Create(c.UserContext(), window); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    window,
+	})
+}
+
+// DeleteMaintenance removes a scheduled maintenance window
+// DELETE /services/:id/maintenance/:windowId
+func (h *ServiceWindowHandler) DeleteMaintenance(c *fiber.Ctx) error {
+	windowID, err := strconv.ParseInt(c.Params("windowId"), 10, 64)
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid window ID")
+	}
+
+	deleted, err := h.repo.Delete(c.UserContext(), c.Params("id"), models.WindowMaintenance, windowID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.NotFound, "Maintenance window not found")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Maintenance window deleted",
+	})
+}
+
+// CreateExclusion annotates a past range of a service whose checks don't
+// reflect it, such as failures caused by the monitor's network, excluding
+// them from uptime. The note giving the reason is required and shown with
+// the range on the timeline.
+// POST /services/:id/exclusions
+func (h *ServiceWindowHandler) CreateExclusion(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+
+	service, err := h.serviceRepo.GetByID(c.UserContext(), serviceID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if service == nil {
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
+	}
+
+	var req models.ExclusionCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+
+	req.Note = strings.TrimSpace(req.Note)
+	switch {
+	case req.StartsAt.IsZero():
+		return invalidField(c, "startsAt", "startsAt is required")
+	case !req.EndsAt.After(req.StartsAt):
+		return invalidField(c, "endsAt", "endsAt is required and must be after startsAt")
+	case req.EndsAt.After(time.Now()):
+		return invalidField(c, "endsAt", "endsAt must not be in the future; schedule upcoming work as maintenance")
+	case req.Note == "":
+		return invalidField(c, "note", "note is required to explain the exclusion")
+	case len(req.Note) > models.MaxWindowNoteLength:
+		return invalidField(c, "note", "note must be at most 500 characters")
+	}
+
+	// Stored in local time like checked_at so range comparisons line up
+	endsAt := req.EndsAt.Local()
+	window := &models.ServiceWindow{
+		ServiceID: serviceID,
+		Kind:      models.WindowExcluded,
+		StartsAt:  req.StartsAt.Local(),
+		EndsAt:    &endsAt,
+		Note:      req.Note,
+	}
+	if err := h.repo.Create(c.UserContext(), window); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	log.Printf("[Audit] Uptime exclusion %d added to service %s by API client %s: %s - %s %q",
+		window.ID, serviceID, c.IP(), window.StartsAt.Format(time.RFC3339), endsAt.Format(time.RFC3339), window.Note)
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    window,
+	})
+}
+
+// DeleteExclusion removes an exclusion, counting its checks towards uptime
+// again
+// DELETE /services/:id/exclusions/:windowId
+func (h *ServiceWindowHandler) DeleteExclusion(c *fiber.Ctx) error {
+	serviceID := c.Params("id")
+	windowID, err := strconv.ParseInt(c.Params("windowId"), 10, 64)
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid window ID")
+	}
+
+	deleted, err := h.repo.Delete(c.UserContext(), serviceID, models.WindowExcluded, windowID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.NotFound, "Exclusion not found")
+	}
+	log.Printf("[Audit] Uptime exclusion %d removed from service %s by API client %s", windowID, serviceID, c.IP())
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Exclusion deleted",
+	})
+}
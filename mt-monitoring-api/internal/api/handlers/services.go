@@ -1,340 +1,476 @@
 package handlers
 
 import (
+	"context"
+	"crypto/x509"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/crypto"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/gitops"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
 // ServiceHandler handles service-related requests
 type ServiceHandler struct {
-	repo       *database.ServiceRepository
-	metricRepo *database.MetricRepository
-	scheduler  *checker.Scheduler
+	repo             database.ServiceRepository
+	hostRepo         database.HostRepository
+	portsRepo        database.HostPortsRepository
+	tagRepo          database.TagRepository
+	metricRepo       database.MetricRepository
+	windowRepo       database.ServiceWindowRepository
+	alertRuleRepo    database.AlertRuleRepository
+	notificationRepo database.NotificationRepository
+	versionRepo      database.DashboardRepository
+	scheduler        *checker.Scheduler
+	reconciler       *gitops.Reconciler
 }
 
 // NewServiceHandler creates a new service handler
-func NewServiceHandler(scheduler *checker.Scheduler) *ServiceHandler {
+func NewServiceHandler(store *database.Store, scheduler *checker.Scheduler, reconciler *gitops.Reconciler) *ServiceHandler {
 	return &ServiceHandler{
-		repo:       database.NewServiceRepository(),
-		metricRepo: database.NewMetricRepository(),
-		scheduler:  scheduler,
+		repo:             store.Services,
+		hostRepo:         store.Hosts,
+		portsRepo:        store.HostPorts,
+		tagRepo:          store.Tags,
+		metricRepo:       store.Metrics,
+		windowRepo:       store.ServiceWindows,
+		alertRuleRepo:    store.AlertRules,
+		notificationRepo: store.Notifications,
+		versionRepo:      store.Dashboard,
+		scheduler:        scheduler,
+		reconciler:       reconciler,
 	}
 }
 
-// GetAll returns all services
+// GetAll returns all services, those carrying every ?tag= and owned by
+// ?team= and ?owner= if given. ?include= chooses the computed parts,
+// "metrics" (status, lastCheckAt) and "summary" (24h uptime and response
+// time), and ?fields= the properties returned. Unchanged lists are
+// answered with 304, see notModified.
 func (h *ServiceHandler) GetAll(c *fiber.Ctx) error {
-	services, err := h.repo.GetAll()
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
+	include, invalid := parseInclude(c, "metrics", "summary")
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
+	if h.notModified(c, include) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	services, err := h.repo.GetAll(c.UserContext())
+	if err == nil {
+		var tagged map[string]bool
+		if tagged, err = taggedServices(c, h.tagRepo); tagged != nil {
+			services = slices.DeleteFunc(services, func(s models.Service) bool { return !tagged[s.ID] })
+		}
+		team, owner := ownershipQuery(c)
+		services = slices.DeleteFunc(services, func(s models.Service) bool {
+			return !inRequestProject(c, s.ProjectID) || !s.Ownership.Matches(team, owner)
 		})
 	}
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+
+	// Enrich with latest status and 24h summary in one batched query
+	var snapshots map[string]models.ServiceStatusSnapshot
+	if include["metrics"] || include["summary"] {
+		snapshots, err = h.metricRepo.GetStatusSnapshots(c.UserContext(), 24*time.Hour)
+		if err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		}
+	}
 
-	// Enrich with metrics and compute status
 	for i := range services {
-		// Get latest metric for status
-		metrics, _ := h.metricRepo.GetByServiceID(services[i].ID, 1)
-		if len(metrics) > 0 {
-			if metrics[0].Status == "success" {
+		services[i].MaskSecrets()
+		if !include["metrics"] {
+			services[i].Status = ""
+		}
+		snapshot, ok := snapshots[services[i].ID]
+		if !ok {
+			continue
+		}
+
+		if include["metrics"] {
+			if snapshot.LastStatus == models.CheckStatusSuccess {
 				services[i].Status = models.StatusHealthy
 			} else {
 				services[i].Status = models.StatusUnhealthy
 			}
-			services[i].LastCheckAt = &metrics[0].CheckedAt
-		} else {
-			services[i].Status = models.StatusUnknown
+			lastCheckAt := snapshot.LastCheckAt
+			services[i].LastCheckAt = &lastCheckAt
 		}
-
-		// Get summary for uptime and response time
-		summary, _ := h.metricRepo.GetSummary(services[i].ID, 24*time.Hour)
-		if summary != nil {
-			services[i].Uptime = summary.Uptime
-			services[i].ResponseTime = int(summary.AvgResponseTime)
+		if include["summary"] {
+			services[i].Uptime = snapshot.Uptime()
+			services[i].ResponseTime = int(snapshot.AvgResponseTime)
 		}
 	}
 
+	data, err := selectFields(c, services)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    services,
+		"data":    data,
 	})
 }
 
-// GetByID returns a service by ID
+// GetByID returns a service by ID, with the ?include= parts and ?fields=
+// properties as for GetAll
 func (h *ServiceHandler) GetByID(c *fiber.Ctx) error {
 	id := c.Params("id")
+	include, invalid := parseInclude(c, "metrics", "summary")
+	if invalid != nil {
+		return validationError(c, invalid)
+	}
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
+	}
+
+	// Record the view for the stale service report
+	h.repo.MarkViewed(c.UserContext(), service.ID)
+	if h.notModified(c, include) {
+		return c.SendStatus(fiber.StatusNotModified)
 	}
 
 	// Get latest metric for status
-	metrics, _ := h.metricRepo.GetByServiceID(service.ID, 1)
-	if len(metrics) > 0 {
-		if metrics[0].Status == "success" {
-			service.Status = models.StatusHealthy
+	service.Status = ""
+	if include["metrics"] {
+		metrics, _ := h.metricRepo.GetByServiceID(c.UserContext(), service.ID, 1)
+		if len(metrics) > 0 {
+			if metrics[0].Status == "success" {
+				service.Status = models.StatusHealthy
+			} else {
+				service.Status = models.StatusUnhealthy
+			}
+			service.LastCheckAt = &metrics[0].CheckedAt
 		} else {
-			service.Status = models.StatusUnhealthy
+			service.Status = models.StatusUnknown
 		}
-		service.LastCheckAt = &metrics[0].CheckedAt
-	} else {
-		service.Status = models.StatusUnknown
 	}
 
 	// Enrich with metrics summary
-	summary, _ := h.metricRepo.GetSummary(service.ID, 24*time.Hour)
-	if summary != nil {
-		service.Uptime = summary.Uptime
-		service.ResponseTime = int(summary.AvgResponseTime)
+	if include["summary"] {
+		summary, _ := h.metricRepo.GetSummary(c.UserContext(), service.ID, 24*time.Hour, config.GetApdexThreshold())
+		if summary != nil {
+			service.Uptime = summary.Uptime
+			service.ResponseTime = int(summary.AvgResponseTime)
+		}
 	}
+	service.MaskSecrets()
 
+	data, err := selectFields(c, service)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data":    service,
+		"data":    data,
 	})
 }
 
+// notModified sets the ETag of a service response from the version of the
+// services, and of their checks and windows when include embeds them, and
+// reports whether the client already has it
+func (h *ServiceHandler) notModified(c *fiber.Ctx, include embeds) bool {
+	v, err := h.versionRepo.GetVersion(c.UserContext())
+	if err != nil {
+		return false
+	}
+	version := []interface{}{v.Services}
+	if include["metrics"] || include["summary"] {
+		version = append(version, v.Checks, v.Windows, versionMinute())
+	}
+	return notModified(c, version...)
+}
+
 // Create creates a new service
 func (h *ServiceHandler) Create(c *fiber.Ctx) error {
 	var req models.ServiceCreateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": err.Error(),
-			},
-		})
-	}
-
-	// Validate required fields
-	if req.ID == "" || req.Name == "" || req.Type == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "id, name, and type are required",
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
 
-	// Validate type-specific fields
-	if req.Type == models.ServiceTypeHTTP && req.URL == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "url is required for HTTP services",
-			},
-		})
-	}
-	if req.Type == models.ServiceTypeTCP && (req.URL == "" && req.Host == "") {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "host or url is required for TCP services",
-			},
-		})
-	}
-	if req.Type == models.ServiceTypeICMP && (req.URL == "" && req.Host == "") {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "host or url is required for ICMP services",
-			},
-		})
+	if invalid := validateServiceRequest(&req); invalid != nil {
+		return validationError(c, invalid)
 	}
 
 	// Check if service already exists
-	existing, _ := h.repo.GetByID(req.ID)
+	existing, _ := h.repo.GetByID(c.UserContext(), req.ID)
 	if existing != nil {
-		return c.Status(409).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_EXISTS",
-				"message": "Service with this ID already exists",
-			},
-		})
+		return errorResponse(c, 409, apierror.ServiceExists, "Service with this ID already exists")
 	}
 
 	service := req.ToService()
+	service.ProjectID = newResourceProject(c)
 	service.ApiKey = crypto.GenerateApiKey()
+	if service.Type == models.ServiceTypeHeartbeat && service.PingKey == "" {
+		service.PingKey = crypto.GeneratePingKey()
+	}
+	if taken, err := h.pingKeyTaken(c.UserContext(), service); err != nil || taken {
+		return pingKeyConflict(c, err)
+	}
+	if unknown, err := h.unknownChannel(c.UserContext(), service.ProjectID, service.ChannelIDs); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if unknown != "" {
+		return invalidField(c, "channelIds", "unknown notification channel: "+unknown)
+	}
 
-	if err := h.repo.Create(service); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.Create(c.UserContext(), service); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
+	checker.AuditTLSVerification(service.ID, false, service.InsecureSkipVerify, "API client "+c.IP())
+
 	// Add to scheduler
 	h.scheduler.AddService(service)
 
 	return c.Status(201).JSON(fiber.Map{
 		"success": true,
-		"data":    service,
+		"data":    maskedService(service),
 	})
 }
 
-// Update updates a service
+// Update updates the fields of a service present in the request (PATCH)
 func (h *ServiceHandler) Update(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
-	var req models.ServiceCreateRequest
+	var req models.ServiceUpdateRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if invalid := validateServiceUpdate(&req, service); invalid != nil {
+		return validationError(c, invalid)
 	}
 
-	// Update fields if provided
-	if req.Name != "" {
-		service.Name = req.Name
+	wasActive := service.IsActive
+	wasInsecure := service.InsecureSkipVerify
+	req.ApplyTo(service)
+
+	if invalid := validateUpdatedService(service); invalid != nil {
+		return validationError(c, invalid)
+	}
+	if service.Type == models.ServiceTypeHeartbeat {
+		if service.PingKey == "" {
+			service.PingKey = crypto.GeneratePingKey()
+		}
+		if invalid := validateHeartbeat(service.PingKey, service.Grace); invalid != nil {
+			return validationError(c, invalid)
+		}
+		if taken, err := h.pingKeyTaken(c.UserContext(), service); err != nil || taken {
+			return pingKeyConflict(c, err)
+		}
+	}
+	if req.ChannelIDs != nil {
+		if unknown, err := h.unknownChannel(c.UserContext(), service.ProjectID, service.ChannelIDs); err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		} else if unknown != "" {
+			return invalidField(c, "channelIds", "unknown notification channel: "+unknown)
+		}
 	}
-	if req.Type != "" {
-		service.Type = req.Type
+
+	if err := h.repo.Update(c.UserContext(), service); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
-	if req.IsActive != nil {
-		service.IsActive = *req.IsActive
+
+	h.trackPause(c.UserContext(), service.ID, wasActive, service.IsActive)
+	checker.AuditTLSVerification(service.ID, wasInsecure, service.InsecureSkipVerify, "API client "+c.IP())
+
+	// Update in scheduler
+	h.scheduler.UpdateService(service)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    maskedService(service),
+	})
+}
+
+// Upsert creates or replaces a service (PUT). The body is the complete
+// service as for Create: omitted fields take their defaults instead of
+// keeping stored values, so the same request always yields the same
+// service. The API key, creation time and a generated ping key are kept.
+// Nothing is written when the service already matches; "changed" tells
+// the client whether it did.
+func (h *ServiceHandler) Upsert(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	var req models.ServiceCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
-	if req.URL != "" {
-		service.URL = req.URL
+	if req.ID == "" {
+		req.ID = id
 	}
-	if req.Host != "" && service.URL == "" {
-		service.URL = req.Host
+	if req.ID != id {
+		return invalidField(c, "id", "id in the body does not match the URL")
 	}
-	if req.Port != 0 {
-		service.Port = req.Port
+	if invalid := validateServiceRequest(&req); invalid != nil {
+		return validationError(c, invalid)
 	}
-	if req.Method != "" {
-		service.Method = req.Method
+
+	existing, err := h.repo.GetByID(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
-	if req.Headers != nil {
-		service.Headers = req.Headers
+
+	service := req.ToService()
+	if existing == nil {
+		service.ProjectID = newResourceProject(c)
+		service.ApiKey = crypto.GenerateApiKey()
+	} else {
+		service.ProjectID = existing.ProjectID
+		service.ApiKey = existing.ApiKey
+		service.CreatedAt = existing.CreatedAt
+		service.Auth.KeepSecrets(existing.Auth)
+		if service.Type == models.ServiceTypeHeartbeat && service.PingKey == "" {
+			service.PingKey = existing.PingKey
+		}
 	}
-	if req.Body != "" {
-		service.Body = req.Body
+	if service.Type == models.ServiceTypeHeartbeat && service.PingKey == "" {
+		service.PingKey = crypto.GeneratePingKey()
 	}
-	if req.ExpectedStatus != 0 {
-		service.ExpectedStatus = req.ExpectedStatus
+	// Omitted channels are none, like any omitted field
+	if service.ChannelIDs == nil {
+		service.ChannelIDs = []string{}
 	}
-	if req.Interval != 0 {
-		service.Interval = req.Interval
+
+	var fields []string
+	if existing != nil {
+		fields = serviceChanges(existing, service)
+		if len(fields) == 0 {
+			return c.JSON(fiber.Map{
+				"success":       true,
+				"data":          maskedService(existing),
+				"created":       false,
+				"changed":       false,
+				"changedFields": fields,
+			})
+		}
 	}
-	if req.Timeout != 0 {
-		service.Timeout = req.Timeout
+
+	if taken, err := h.pingKeyTaken(c.UserContext(), service); err != nil || taken {
+		return pingKeyConflict(c, err)
 	}
-	if req.Tags != nil {
-		service.Tags = req.Tags
+	if unknown, err := h.unknownChannel(c.UserContext(), service.ProjectID, service.ChannelIDs); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if unknown != "" {
+		return invalidField(c, "channelIds", "unknown notification channel: "+unknown)
 	}
 
-	if err := h.repo.Update(service); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
+	if existing == nil {
+		if err := h.repo.Create(c.UserContext(), service); err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		}
+		checker.AuditTLSVerification(service.ID, false, service.InsecureSkipVerify, "API client "+c.IP())
+		h.scheduler.AddService(service)
+
+		return c.Status(201).JSON(fiber.Map{
+			"success": true,
+			"data":    maskedService(service),
+			"created": true,
+			"changed": true,
 		})
 	}
 
-	// Update in scheduler
+	if err := h.repo.Update(c.UserContext(), service); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	h.trackPause(c.UserContext(), service.ID, existing.IsActive, service.IsActive)
+	checker.AuditTLSVerification(service.ID, existing.InsecureSkipVerify, service.InsecureSkipVerify, "API client "+c.IP())
 	h.scheduler.UpdateService(service)
 
 	return c.JSON(fiber.Map{
-		"success": true,
-		"data":    service,
+		"success":       true,
+		"data":          maskedService(service),
+		"created":       false,
+		"changed":       true,
+		"changedFields": fields,
 	})
 }
 
+// serviceChanges returns the names of the configurable fields that differ
+// between a stored service and its replacement
+func serviceChanges(have, want *models.Service) []string {
+	return changedFields(
+		fieldPair{"name", have.Name, want.Name},
+		fieldPair{"type", have.Type, want.Type},
+		fieldPair{"isActive", have.IsActive, want.IsActive},
+		fieldPair{"url", have.URL, want.URL},
+		fieldPair{"port", have.Port, want.Port},
+		fieldPair{"method", have.Method, want.Method},
+		fieldPair{"headers", have.Headers, want.Headers},
+		fieldPair{"body", have.Body, want.Body},
+		fieldPair{"expectedStatus", have.ExpectedStatus, want.ExpectedStatus},
+		fieldPair{"timeout", have.Timeout, want.Timeout},
+		fieldPair{"interval", have.Interval, want.Interval},
+		fieldPair{"tags", have.Tags, want.Tags},
+		fieldPair{"channelIds", have.ChannelIDs, want.ChannelIDs},
+		fieldPair{"scheduleType", have.ScheduleType, want.ScheduleType},
+		fieldPair{"cronExpression", have.CronExpression, want.CronExpression},
+		fieldPair{"logRetention", have.LogRetention, want.LogRetention},
+		fieldPair{"ingestRateLimit", have.IngestRateLimit, want.IngestRateLimit},
+		fieldPair{"ingestMaxPayload", have.IngestMaxPayload, want.IngestMaxPayload},
+		fieldPair{"pingKey", have.PingKey, want.PingKey},
+		fieldPair{"grace", have.Grace, want.Grace},
+		fieldPair{"metricSampling", have.MetricSampling, want.MetricSampling},
+		fieldPair{"caCert", have.CACert, want.CACert},
+		fieldPair{"insecureSkipVerify", have.InsecureSkipVerify, want.InsecureSkipVerify},
+		fieldPair{"protocol", have.Protocol, want.Protocol},
+		fieldPair{"selector", have.Selector, want.Selector},
+		fieldPair{"callbackUrl", have.CallbackURL, want.CallbackURL},
+		fieldPair{"callbackOn", have.CallbackOn, want.CallbackOn},
+		fieldPair{"auth", have.Auth, want.Auth},
+		fieldPair{"ownership", have.Ownership, want.Ownership},
+	)
+}
+
+// maskedService returns a copy of service with its credentials masked, for
+// responses about services the scheduler holds
+func maskedService(service *models.Service) *models.Service {
+	masked := *service
+	masked.MaskSecrets()
+	return &masked
+}
+
 // Delete deletes a service
 func (h *ServiceHandler) Delete(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
-	if err := h.repo.Delete(id); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.Delete(c.UserContext(), id); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	// Remove from scheduler
@@ -350,37 +486,21 @@ func (h *ServiceHandler) Delete(c *fiber.Ctx) error {
 func (h *ServiceHandler) Pause(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
-	if err := h.repo.SetActive(id, false); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.SetActive(c.UserContext(), id, false); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
+	h.trackPause(c.UserContext(), id, service.IsActive, false)
+
 	// Update scheduler (will remove the entry)
 	service.IsActive = false
 	h.scheduler.UpdateService(service)
@@ -395,37 +515,21 @@ func (h *ServiceHandler) Pause(c *fiber.Ctx) error {
 func (h *ServiceHandler) Resume(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
-	if err := h.repo.SetActive(id, true); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+	if err := h.repo.SetActive(c.UserContext(), id, true); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
+	h.trackPause(c.UserContext(), id, service.IsActive, true)
+
 	// Update scheduler (will add the entry)
 	service.IsActive = true
 	h.scheduler.UpdateService(service)
@@ -440,36 +544,18 @@ func (h *ServiceHandler) Resume(c *fiber.Ctx) error {
 func (h *ServiceHandler) RegenerateKey(c *fiber.Ctx) error {
 	id := c.Params("id")
 
-	service, err := h.repo.GetByID(id)
+	service, err := h.repo.GetByID(c.UserContext(), id)
 	if err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
 	}
 
 	if service == nil {
-		return c.Status(404).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SERVICE_NOT_FOUND",
-				"message": "Service not found",
-			},
-		})
+		return errorResponse(c, 404, apierror.ServiceNotFound, "Service not found")
 	}
 
 	newKey := crypto.GenerateApiKey()
-	if err := h.repo.UpdateApiKey(id, newKey); err != nil {
-		return c.Status(500).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "DATABASE_ERROR",
-				"message": "Failed to regenerate API key",
-			},
-		})
+	if err := h.repo.UpdateApiKey(c.UserContext(), id, newKey); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, "Failed to regenerate API key")
 	}
 
 	return c.JSON(fiber.Map{
@@ -479,3 +565,323 @@ func (h *ServiceHandler) RegenerateKey(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// trackPause opens a pause window when monitoring stops and closes it when
+// monitoring resumes, so paused time is excluded from uptime
+func (h *ServiceHandler) trackPause(ctx context.Context, serviceID string, wasActive, isActive bool) {
+	now := time.Now()
+
+	var err error
+	switch {
+	case wasActive && !isActive:
+		err = h.windowRepo.Create(ctx, &models.ServiceWindow{
+			ServiceID: serviceID,
+			Kind:      models.WindowPaused,
+			StartsAt:  now,
+		})
+	case !wasActive && isActive:
+		err = h.windowRepo.CloseOpen(ctx, serviceID, models.WindowPaused, now)
+	}
+	if err != nil {
+		log.Printf("Warning: failed to record pause window for service %s: %v", serviceID, err)
+	}
+}
+
+// browserDisabled is the validation message for a browser service while
+// browser checks are unavailable
+const browserDisabled = "browser checks are disabled: set browser.enabled in a build with -tags browser"
+
+// validateServiceRequest returns the invalid field for a create or
+// replace request, or nil when it is valid. It applies the service defaults
+// to req.
+func validateServiceRequest(req *models.ServiceCreateRequest) *apierror.FieldError {
+	if req.ID == "" {
+		return apierror.Invalid("id", "id is required")
+	}
+	if req.Name == "" {
+		return apierror.Invalid("name", "name is required")
+	}
+	if req.Type == "" {
+		return apierror.Invalid("type", "type is required")
+	}
+
+	// Validate type-specific fields
+	if req.Type == models.ServiceTypeHTTP && req.URL == "" {
+		return apierror.Invalid("url", "url is required for HTTP services")
+	}
+	if req.Type == models.ServiceTypeTCP && (req.URL == "" && req.Host == "") {
+		return apierror.Invalid("host", "host or url is required for TCP services")
+	}
+	if req.Type == models.ServiceTypeICMP && (req.URL == "" && req.Host == "") {
+		return apierror.Invalid("host", "host or url is required for ICMP services")
+	}
+	if req.Type == models.ServiceTypeHeartbeat {
+		if invalid := validateHeartbeat(req.PingKey, req.Grace); invalid != nil {
+			return invalid
+		}
+	}
+	if req.Type == models.ServiceTypeSelf && req.ID != models.SelfServiceID {
+		return apierror.Invalid("type", "type self is reserved for the self-monitoring service")
+	}
+	if req.Type == models.ServiceTypeBrowser {
+		if req.URL == "" {
+			return apierror.Invalid("url", "url is required for browser services")
+		}
+		if !checker.BrowserEnabled() {
+			return apierror.Invalid("type", browserDisabled)
+		}
+	}
+
+	if req.IngestRateLimit < 0 {
+		return apierror.Invalid("ingestRateLimit", "ingestRateLimit must not be negative")
+	}
+	if req.IngestMaxPayload < 0 {
+		return apierror.Invalid("ingestMaxPayload", "ingestMaxPayload must not be negative")
+	}
+	if req.MetricSampling < 0 {
+		return apierror.Invalid("metricSampling", "metricSampling must not be negative")
+	}
+	if invalid := validateCACert(req.CACert); invalid != nil {
+		return invalid
+	}
+	if req.Protocol != "" {
+		if invalid := validateProtocol(req.Protocol, req.URL); invalid != nil {
+			return invalid
+		}
+	}
+	if req.Auth != nil && req.Auth.Type != "" {
+		if invalid := validateAuth(req.Auth, req.Type); invalid != nil {
+			return invalid
+		}
+	}
+	if invalid := validateCallback(req.CallbackURL, req.CallbackOn); invalid != nil {
+		return invalid
+	}
+	if invalid := validateOwnership(req.Ownership); invalid != nil {
+		return invalid
+	}
+	if req.LogRetention != "" && !config.IsValidRetention(req.LogRetention) {
+		return apierror.Invalid("logRetention", "logRetention must be a duration like 7d, 12h or 30m")
+	}
+	if !applyServiceDefaults(req) {
+		return apierror.Invalid("profile", "unknown service profile: "+req.Profile)
+	}
+	return nil
+}
+
+// validateServiceUpdate returns the invalid field for the values of an
+// update request to stored, or nil when they are valid
+func validateServiceUpdate(req *models.ServiceUpdateRequest, stored *models.Service) *apierror.FieldError {
+	if req.Name != nil && *req.Name == "" {
+		return apierror.Invalid("name", "name must not be empty")
+	}
+	if req.Type != nil {
+		switch {
+		case *req.Type == "":
+			return apierror.Invalid("type", "type must not be empty")
+		case *req.Type == models.ServiceTypeSelf && stored.ID != models.SelfServiceID:
+			return apierror.Invalid("type", "type self is reserved for the self-monitoring service")
+		case *req.Type == models.ServiceTypeBrowser && !checker.BrowserEnabled():
+			return apierror.Invalid("type", browserDisabled)
+		}
+	}
+	if req.IngestRateLimit != nil && *req.IngestRateLimit < 0 {
+		return apierror.Invalid("ingestRateLimit", "ingestRateLimit must not be negative")
+	}
+	if req.IngestMaxPayload != nil && *req.IngestMaxPayload < 0 {
+		return apierror.Invalid("ingestMaxPayload", "ingestMaxPayload must not be negative")
+	}
+	if req.MetricSampling != nil && *req.MetricSampling < 0 {
+		return apierror.Invalid("metricSampling", "metricSampling must not be negative")
+	}
+	if req.CACert != nil {
+		if invalid := validateCACert(*req.CACert); invalid != nil {
+			return invalid
+		}
+	}
+	if req.LogRetention != nil && *req.LogRetention != "" && !config.IsValidRetention(*req.LogRetention) {
+		return apierror.Invalid("logRetention", "logRetention must be a duration like 7d, 12h or 30m")
+	}
+	return validateOwnership(req.Ownership)
+}
+
+// validateUpdatedService returns the invalid field for a service after
+// an update was applied, which may have cleared fields it needs, or nil
+// when it is valid
+func validateUpdatedService(s *models.Service) *apierror.FieldError {
+	if s.URL == "" {
+		switch s.Type {
+		case models.ServiceTypeHTTP:
+			return apierror.Invalid("url", "url is required for HTTP services")
+		case models.ServiceTypeBrowser:
+			return apierror.Invalid("url", "url is required for browser services")
+		case models.ServiceTypeTCP:
+			return apierror.Invalid("host", "host or url is required for TCP services")
+		case models.ServiceTypeICMP:
+			return apierror.Invalid("host", "host or url is required for ICMP services")
+		}
+	}
+	switch s.ScheduleType {
+	case models.ScheduleTypeInterval:
+	case models.ScheduleTypeCron:
+		if s.CronExpression == "" {
+			return apierror.Invalid("cronExpression", "cronExpression is required for cron schedules")
+		}
+	default:
+		return apierror.Invalid("scheduleType", `scheduleType must be "interval" or "cron"`)
+	}
+	if invalid := validateCallback(s.CallbackURL, s.CallbackOn); invalid != nil {
+		return invalid
+	}
+	if invalid := validateProtocol(s.Protocol, s.URL); invalid != nil {
+		return invalid
+	}
+	return validateAuth(s.Auth, s.Type)
+}
+
+// validateCACert returns the invalid field when caCert is set but holds
+// no PEM certificate, or nil when it is valid
+func validateCACert(caCert string) *apierror.FieldError {
+	if caCert != "" && !x509.NewCertPool().AppendCertsFromPEM([]byte(caCert)) {
+		return apierror.Invalid("caCert", "caCert must contain a PEM certificate")
+	}
+	return nil
+}
+
+// validateProtocol returns the invalid field when protocol is unknown
+// or HTTP/3 is chosen for a plain HTTP URL, or nil when it is valid. Empty
+// stored protocols predate the setting and mean auto.
+func validateProtocol(protocol models.HTTPProtocol, url string) *apierror.FieldError {
+	if protocol != "" && !protocol.IsValid() {
+		return apierror.Invalid("protocol", "protocol must be auto, h1, h2 or h3")
+	}
+	if protocol == models.HTTPProtocolH3 && !strings.HasPrefix(strings.ToLower(url), "https://") {
+		return apierror.Invalid("protocol", "protocol h3 requires an https url")
+	}
+	return nil
+}
+
+// validateCallback returns the invalid field when the callback URL of a
+// service is not an absolute HTTP(S) URL or the callback mode is unknown
+func validateCallback(callbackURL string, mode models.CallbackMode) *apierror.FieldError {
+	if callbackURL != "" {
+		u, err := url.Parse(callbackURL)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return apierror.Invalid("callbackUrl", "callbackUrl must be an http or https URL")
+		}
+	}
+	if mode != "" && !mode.IsValid() {
+		return apierror.Invalid("callbackOn", "callbackOn must be all or change")
+	}
+	return nil
+}
+
+// validateAuth returns the invalid field when the credentials of a
+// service are incomplete, or nil when they are valid or there are none
+func validateAuth(auth *models.ServiceAuth, serviceType models.ServiceType) *apierror.FieldError {
+	if auth == nil {
+		return nil
+	}
+	if serviceType != models.ServiceTypeHTTP {
+		return apierror.Invalid("auth", "auth is only supported for HTTP services")
+	}
+	switch auth.Type {
+	case models.AuthTypeBasic:
+		if auth.Username == "" {
+			return apierror.Invalid("auth.username", "auth.username is required for basic auth")
+		}
+	case models.AuthTypeBearer:
+		if auth.Token == "" {
+			return apierror.Invalid("auth.token", "auth.token is required for bearer auth")
+		}
+	case models.AuthTypeOAuth2:
+		if auth.TokenURL == "" {
+			return apierror.Invalid("auth.tokenUrl", "auth.tokenUrl is required for oauth2 auth")
+		}
+		if auth.ClientID == "" {
+			return apierror.Invalid("auth.clientId", "auth.clientId is required for oauth2 auth")
+		}
+		if auth.ClientSecret == "" {
+			return apierror.Invalid("auth.clientSecret", "auth.clientSecret is required for oauth2 auth")
+		}
+		if u, err := url.Parse(auth.TokenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return apierror.Invalid("auth.tokenUrl", "auth.tokenUrl must be an http or https URL")
+		}
+	default:
+		return apierror.Invalid("auth.type", "auth.type must be basic, bearer or oauth2")
+	}
+	return nil
+}
+
+// validateHeartbeat returns the invalid field for heartbeat fields, or
+// nil when they are valid. An empty ping key is generated later.
+func validateHeartbeat(pingKey string, grace int) *apierror.FieldError {
+	if pingKey != "" && !models.IsValidPingKey(pingKey) {
+		return apierror.Invalid("pingKey", "pingKey must be a UUID")
+	}
+	if grace < 0 {
+		return apierror.Invalid("grace", "grace must not be negative")
+	}
+	return nil
+}
+
+// pingKeyTaken reports whether another service uses the ping key of service
+func (h *ServiceHandler) pingKeyTaken(ctx context.Context, service *models.Service) (bool, error) {
+	other, err := h.repo.GetByPingKey(ctx, service.PingKey)
+	if err != nil {
+		return false, err
+	}
+	return other != nil && other.ID != service.ID, nil
+}
+
+// unknownChannel returns the first of the channel IDs that matches no
+// notification channel of the project, empty when all do
+func (h *ServiceHandler) unknownChannel(ctx context.Context, projectID string, ids []string) (string, error) {
+	for _, id := range ids {
+		channel, err := h.notificationRepo.GetByID(ctx, id)
+		if err != nil {
+			return "", err
+		}
+		if channel == nil || channel.ProjectID != projectID {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// pingKeyConflict answers a failed pingKeyTaken lookup or a taken key
+func pingKeyConflict(c *fiber.Ctx, err error) error {
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return errorResponse(c, 409, apierror.PingKeyExists, "Another service uses this ping key")
+}
+
+// applyServiceDefaults fills fields omitted from a create request from the
+// configured service defaults and the selected profile. It returns false
+// for an unknown profile.
+func applyServiceDefaults(req *models.ServiceCreateRequest) bool {
+	cfg := config.Get()
+	if cfg == nil {
+		return req.Profile == ""
+	}
+	defaults, ok := cfg.ServiceDefaults.Profile(req.Profile)
+	if !ok {
+		return false
+	}
+
+	// A heartbeat's interval is the job's period, not a check interval
+	if req.Interval == 0 && req.Type != models.ServiceTypeHeartbeat {
+		req.Interval = defaults.Interval
+	}
+	if req.Timeout == 0 {
+		req.Timeout = defaults.Timeout
+	}
+	if req.ExpectedStatus == 0 {
+		req.ExpectedStatus = defaults.ExpectedStatus
+	}
+	if req.Tags == nil {
+		req.Tags = defaults.Tags
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// BulkUpdate changes the interval, timeout, expected status or alert rule
+// channels of every service matching a filter. With dryRun nothing is
+// written and the response previews the changes. Services managed by
+// GitOps are skipped.
+// PATCH /services
+func (h *ServiceHandler) BulkUpdate(c *fiber.Ctx) error {
+	var req models.ServiceBulkRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if req.Filter.IsEmpty() {
+		return invalidField(c, "filter", `filter must select services; use {"all": true} for every service`)
+	}
+	if req.Patch.IsEmpty() {
+		return invalidField(c, "patch", "patch must set interval, timeout, expectedStatus or channelIds")
+	}
+	if invalid := validateBulkPatch(&req.Patch); invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	services, err := h.bulkServices(c, &req.Filter)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	var rules []models.AlertRule
+	if req.Patch.ChannelIDs != nil {
+		// The channels must belong to the project of every service
+		checked := make(map[string]bool)
+		for _, service := range services {
+			if checked[service.ProjectID] {
+				continue
+			}
+			checked[service.ProjectID] = true
+			if unknown, err := h.unknownChannel(c.UserContext(), service.ProjectID, *req.Patch.ChannelIDs); err != nil {
+				return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+			} else if unknown != "" {
+				return invalidField(c, "patch.channelIds", "unknown notification channel: "+unknown)
+			}
+		}
+		if rules, err = h.alertRuleRepo.GetAll(c.UserContext()); err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		}
+	}
+
+	results := make([]models.ServiceBulkResult, 0, len(services))
+	var invalid []string
+	for i := range services {
+		service := &services[i]
+		result, ruleIDs := bulkChanges(service, &req.Patch, rules)
+		if h.reconciler != nil && h.reconciler.IsManaged(models.ManagedService, service.ID) {
+			result.Skipped = "managed by GitOps"
+		} else if (req.Patch.Interval != nil || req.Patch.Timeout != nil) && service.Timeout >= service.Interval*1000 {
+			invalid = append(invalid, service.ID)
+		}
+		result.Rules = ruleIDs
+		results = append(results, result)
+	}
+	if len(invalid) > 0 {
+		return invalidField(c, "patch.timeout", "timeout must be shorter than the interval; would not hold for: "+strings.Join(invalid, ", "))
+	}
+
+	updated := 0
+	if !req.DryRun {
+		for i := range services {
+			if results[i].Skipped != "" || len(results[i].Changes) == 0 {
+				continue
+			}
+			serviceChanged := len(results[i].Changes) > len(results[i].Rules)
+			if err := h.applyBulkChanges(c.UserContext(), &services[i], serviceChanged, req.Patch.ChannelIDs, results[i].Rules); err != nil {
+				return errorResponse(c, 500, apierror.DatabaseError,
+					fmt.Sprintf("updating %s failed after %d services were updated: %v", services[i].ID, updated, err))
+			}
+			updated++
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"dryRun":   req.DryRun,
+			"matched":  len(results),
+			"updated":  updated,
+			"services": results,
+		},
+	})
+}
+
+// bulkServices returns the services of the request project matching the
+// filter
+func (h *ServiceHandler) bulkServices(c *fiber.Ctx, filter *models.ServiceBulkFilter) ([]models.Service, error) {
+	services, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return nil, err
+	}
+	var tagged map[string]bool
+	if len(filter.Tags) > 0 {
+		if tagged, err = h.tagRepo.ServiceIDs(c.UserContext(), filter.Tags); err != nil {
+			return nil, err
+		}
+	}
+	return slices.DeleteFunc(services, func(s models.Service) bool {
+		switch {
+		case !inRequestProject(c, s.ProjectID):
+			return true
+		case len(filter.IDs) > 0 && !slices.Contains(filter.IDs, s.ID):
+			return true
+		case tagged != nil && !tagged[s.ID]:
+			return true
+		case filter.Type != "" && s.Type != filter.Type:
+			return true
+		case filter.Active != nil && s.IsActive != *filter.Active:
+			return true
+		}
+		return false
+	}), nil
+}
+
+// bulkChanges applies the patch to service in memory, returning the changed
+// fields and the alert rules of the service whose channels change
+func bulkChanges(service *models.Service, patch *models.ServiceBulkPatch, rules []models.AlertRule) (models.ServiceBulkResult, []string) {
+	result := models.ServiceBulkResult{ID: service.ID, Name: service.Name, Changes: []models.FieldChange{}}
+	set := func(field string, have *int, want *int) {
+		if want != nil && *have != *want {
+			result.Changes = append(result.Changes, models.FieldChange{Field: field, From: *have, To: *want})
+			*have = *want
+		}
+	}
+	set("interval", &service.Interval, patch.Interval)
+	set("timeout", &service.Timeout, patch.Timeout)
+	set("expectedStatus", &service.ExpectedStatus, patch.ExpectedStatus)
+
+	var ruleIDs []string
+	if patch.ChannelIDs != nil {
+		for _, rule := range rules {
+			if rule.ServiceID == nil || *rule.ServiceID != service.ID || sameChannels(rule.ChannelIDs, *patch.ChannelIDs) {
+				continue
+			}
+			ruleIDs = append(ruleIDs, rule.ID)
+			result.Changes = append(result.Changes, models.FieldChange{
+				Field: "alertRules." + rule.ID + ".channelIds",
+				From:  rule.ChannelIDs,
+				To:    *patch.ChannelIDs,
+			})
+		}
+	}
+	return result, ruleIDs
+}
+
+// applyBulkChanges stores a patched service and sets the channels of its
+// alert rules
+func (h *ServiceHandler) applyBulkChanges(ctx context.Context, service *models.Service, serviceChanged bool, channelIDs *[]string, ruleIDs []string) error {
+	if serviceChanged {
+		if err := h.repo.Update(ctx, service); err != nil {
+			return err
+		}
+		h.scheduler.UpdateService(service)
+	}
+	for _, id := range ruleIDs {
+		if err := h.alertRuleRepo.Update(ctx, id, &models.AlertRuleUpdateRequest{ChannelIDs: channelIDs}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateBulkPatch returns the invalid field of the patch values, or nil
+// when they are valid
+func validateBulkPatch(patch *models.ServiceBulkPatch) *apierror.FieldError {
+	if patch.Interval != nil && *patch.Interval < 1 {
+		return apierror.Invalid("patch.interval", "interval must be at least 1 second")
+	}
+	if patch.Timeout != nil && *patch.Timeout < 1 {
+		return apierror.Invalid("patch.timeout", "timeout must be at least 1 millisecond")
+	}
+	if patch.ExpectedStatus != nil && (*patch.ExpectedStatus < 100 || *patch.ExpectedStatus > 599) {
+		return apierror.Invalid("patch.expectedStatus", "expectedStatus must be an HTTP status code")
+	}
+	return nil
+}
+
+// sameChannels reports whether two channel lists hold the same IDs
+func sameChannels(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	a, b = slices.Clone(a), slices.Clone(b)
+	slices.Sort(a)
+	slices.Sort(b)
+	return slices.Equal(a, b)
+}
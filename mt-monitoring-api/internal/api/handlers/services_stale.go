@@ -0,0 +1,155 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	// staleDNSWorkers bounds the concurrent DNS lookups of a stale report
+	staleDNSWorkers = 16
+	// staleDNSTimeout bounds each lookup
+	staleDNSTimeout = 5 * time.Second
+)
+
+// GetStale reports services that look safe to remove: paused for longer
+// than the window, unused in the window (no incidents, not viewed and not
+// pinned on a dashboard) or checking a host name that no longer resolves.
+// Services created within the window are only reported for DNS.
+// GET /services/stale?days=30&dns=true
+func (h *ServiceHandler) GetStale(c *fiber.Ctx) error {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		parsed, err := strconv.Atoi(d)
+		if err != nil || parsed < 1 {
+			return errorResponse(c, 400, apierror.InvalidRequest, "days must be a positive number")
+		}
+		days = parsed
+	}
+	checkDNS := c.QueryBool("dns", true)
+	since := time.Now().AddDate(0, 0, -days)
+
+	services, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	services = slices.DeleteFunc(services, func(s models.Service) bool { return !inRequestProject(c, s.ProjectID) })
+	usage, err := h.repo.GetUsage(c.UserContext(), since)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	var unresolved map[string]string
+	if checkDNS {
+		unresolved = unresolvedHosts(c.UserContext(), services)
+	}
+
+	stale := []models.StaleService{}
+	for _, s := range services {
+		u := usage[s.ID]
+		var reasons []models.StaleReason
+
+		pausedSince := u.PausedSince
+		if !s.IsActive && pausedSince == nil {
+			pausedSince = &s.CreatedAt
+		}
+		if !s.IsActive && pausedSince.Before(since) {
+			reasons = append(reasons, models.StaleReason{
+				Reason: models.StaleReasonPaused,
+				Detail: fmt.Sprintf("paused for %d days", int(time.Since(*pausedSince).Hours()/24)),
+			})
+		}
+		viewed := u.LastViewedAt != nil && u.LastViewedAt.After(since)
+		if s.CreatedAt.Before(since) && u.Incidents == 0 && !viewed && !u.Pinned {
+			reasons = append(reasons, models.StaleReason{
+				Reason: models.StaleReasonUnused,
+				Detail: fmt.Sprintf("no incidents, views or dashboard pins in %d days", days),
+			})
+		}
+		if host, ok := unresolved[s.ID]; ok {
+			reasons = append(reasons, models.StaleReason{
+				Reason: models.StaleReasonDNS,
+				Detail: host + " does not resolve",
+			})
+		}
+		if len(reasons) == 0 {
+			continue
+		}
+
+		stale = append(stale, models.StaleService{
+			ID:           s.ID,
+			Name:         s.Name,
+			Type:         s.Type,
+			IsActive:     s.IsActive,
+			PausedSince:  u.PausedSince,
+			LastViewedAt: u.LastViewedAt,
+			Incidents:    u.Incidents,
+			Reasons:      reasons,
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"days":     days,
+			"dns":      checkDNS,
+			"services": stale,
+		},
+	})
+}
+
+// unresolvedHosts looks up the host names services check and returns the
+// IDs of the services whose host does not exist, with the host. Lookups
+// that fail for other reasons (timeouts, unreachable resolvers) are not
+// reported.
+func unresolvedHosts(ctx context.Context, services []models.Service) map[string]string {
+	byHost := make(map[string][]string)
+	for _, s := range services {
+		host := checker.TargetHost(&s)
+		if host == "" || net.ParseIP(host) != nil {
+			continue
+		}
+		byHost[host] = append(byHost[host], s.ID)
+	}
+
+	hosts := make(chan string)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	unresolved := make(map[string]string)
+	for i := 0; i < min(staleDNSWorkers, len(byHost)); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for host := range hosts {
+				lookupCtx, cancel := context.WithTimeout(ctx, staleDNSTimeout)
+				_, err := net.DefaultResolver.LookupHost(lookupCtx, host)
+				cancel()
+				var dnsErr *net.DNSError
+				if !errors.As(err, &dnsErr) || !dnsErr.IsNotFound {
+					continue
+				}
+				mu.Lock()
+				for _, id := range byHost[host] {
+					unresolved[id] = host
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for host := range byHost {
+		hosts <- host
+	}
+	close(hosts)
+	wg.Wait()
+	return unresolved
+}
@@ -1,107 +1,159 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/settings"
 )
 
 // SettingsHandler handles system settings requests
-type SettingsHandler struct{}
+type SettingsHandler struct {
+	settingsMgr *settings.Manager
+}
 
 // NewSettingsHandler creates a new settings handler
-func NewSettingsHandler() *SettingsHandler {
-	return &SettingsHandler{}
+func NewSettingsHandler(settingsMgr *settings.Manager) *SettingsHandler {
+	return &SettingsHandler{settingsMgr: settingsMgr}
 }
 
-// Get returns the current mutable system settings
+// Get returns the current runtime settings, nested by section
+// GET /settings
 func (h *SettingsHandler) Get(c *fiber.Ctx) error {
 	cfg := config.Get()
 	if cfg == nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "config not available",
-		})
+		return errorResponse(c, 500, apierror.InternalError, "config not available")
 	}
 	return c.JSON(fiber.Map{
 		"success": true,
-		"data": fiber.Map{
-			"alerts": fiber.Map{
-				"consecutiveFailures": cfg.Alerts.ConsecutiveFailures,
-			},
-			"retention": fiber.Map{
-				"metrics": cfg.Retention.Metrics,
-				"logs":    cfg.Retention.Logs,
-			},
-		},
+		"data":    settingsTree(cfg),
 	})
 }
 
-// UpdateSettingsRequest is the request body for updating settings
-type UpdateSettingsRequest struct {
-	Alerts *struct {
-		ConsecutiveFailures int `json:"consecutiveFailures"`
-	} `json:"alerts"`
-	Retention *struct {
-		Metrics string `json:"metrics"`
-		Logs    string `json:"logs"`
-	} `json:"retention"`
+// GetRuntime lists every runtime setting with its default from the config
+// file and whether it is overridden
+// GET /settings/runtime
+func (h *SettingsHandler) GetRuntime(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    h.settingsMgr.List(),
+	})
 }
 
-// Update updates mutable system settings
+// Update changes runtime settings. The body uses the same nested shape as
+// Get and may contain any subset of settings; null resets a setting to its
+// config file value. Changes are stored in the database and applied
+// without a restart.
+// PUT /settings
 func (h *SettingsHandler) Update(c *fiber.Ctx) error {
-	cfg := config.Get()
-	if cfg == nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "config not available",
-		})
+	var body map[string]interface{}
+	if err := c.BodyParser(&body); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
 	}
 
-	var req UpdateSettingsRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-			"success": false,
-			"error":   "invalid request body",
-		})
+	values := make(map[string]string)
+	if invalid := flattenSettings("", body, values); invalid != nil {
+		return validationError(c, invalid)
 	}
 
-	// Read current values as defaults
-	consecutiveFailures := cfg.Alerts.ConsecutiveFailures
-	metricsRetention := cfg.Retention.Metrics
-	logsRetention := cfg.Retention.Logs
+	if err := h.settingsMgr.Update(c.UserContext(), values); err != nil {
+		var invalid *settings.InvalidSettingError
+		if errors.As(err, &invalid) {
+			return invalidField(c, invalid.Key, invalid.Reason)
+		}
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
 
-	// Apply provided fields
-	if req.Alerts != nil {
-		if req.Alerts.ConsecutiveFailures > 0 {
-			consecutiveFailures = req.Alerts.ConsecutiveFailures
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    settingsTree(config.Get()),
+	})
+}
+
+// settingsTree nests the runtime settings of cfg by their dotted keys, e.g.
+// {"alerts": {"consecutiveFailures": 3}, "system": {"ssh": {...}}}
+func settingsTree(cfg *config.Config) fiber.Map {
+	tree := fiber.Map{}
+	for _, s := range config.RuntimeSettings() {
+		parts := strings.Split(s.Key, ".")
+		node := tree
+		for _, part := range parts[:len(parts)-1] {
+			child, ok := node[part].(fiber.Map)
+			if !ok {
+				child = fiber.Map{}
+				node[part] = child
+			}
+			node = child
 		}
+		node[parts[len(parts)-1]] = s.Value(cfg)
+	}
+	// Per-level log retention is read-only here (config file only)
+	if retention, ok := tree["retention"].(fiber.Map); ok {
+		retention["logLevels"] = cfg.Retention.LogLevels
 	}
-	if req.Retention != nil {
-		if req.Retention.Metrics != "" {
-			metricsRetention = req.Retention.Metrics
+	return tree
+}
+
+// flattenSettings turns a nested settings body into dotted keys with string
+// values; null becomes "" (reset to default). Unknown keys and values that
+// are neither strings nor numbers are reported against their dotted key.
+func flattenSettings(prefix string, node map[string]interface{}, out map[string]string) *apierror.FieldError {
+	for name, value := range node {
+		key := name
+		if prefix != "" {
+			key = prefix + "." + name
+		}
+		if key == "retention.logLevels" {
+			continue // read-only, echoed back from Get
+		}
+		switch v := value.(type) {
+		case map[string]interface{}:
+			if invalid := flattenSettings(key, v, out); invalid != nil {
+				return invalid
+			}
+			continue
+		case nil:
+			out[key] = ""
+		case string:
+			out[key] = v
+		case float64:
+			out[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		default:
+			return apierror.Invalid(key, "invalid value for "+key)
 		}
-		if req.Retention.Logs != "" {
-			logsRetention = req.Retention.Logs
+		if _, ok := config.LookupSetting(key); !ok {
+			return apierror.Invalid(key, fmt.Sprintf("unknown setting %q", key))
 		}
 	}
+	return nil
+}
 
-	if err := config.UpdateSettings(consecutiveFailures, metricsRetention, logsRetention); err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error":   "failed to save settings: " + err.Error(),
-		})
+// ValidateConfig validates a configuration document, or the config file on
+// disk when the body is empty, without applying it
+// POST /admin/config/validate
+func (h *SettingsHandler) ValidateConfig(c *fiber.Ctx) error {
+	var cfg *config.Config
+	var err error
+	if len(c.Body()) == 0 {
+		cfg, err = config.LoadFile(config.Path())
+	} else {
+		cfg, err = config.Parse(c.Body())
+	}
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
 
+	errs := config.Validate(cfg)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data": fiber.Map{
-			"alerts": fiber.Map{
-				"consecutiveFailures": consecutiveFailures,
-			},
-			"retention": fiber.Map{
-				"metrics": metricsRetention,
-				"logs":    logsRetention,
-			},
+			"valid":  len(errs) == 0,
+			"errors": errs,
 		},
 	})
 }
@@ -0,0 +1,40 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// sshCommandsResponse lists what the SSH collector runs on the hosts
+type sshCommandsResponse struct {
+	Restricted    bool                   `json:"restricted"`
+	RemoteScript  string                 `json:"remoteScript,omitempty"`
+	ScriptVersion string                 `json:"scriptVersion"`
+	Commands      []collector.SSHCommand `json:"commands"`
+}
+
+// GetCommands returns the fixed set of commands the SSH collector runs, for
+// review before granting it access to a host.
+func (h *SSHTestHandler) GetCommands(c *fiber.Ctx) error {
+	resp := sshCommandsResponse{
+		ScriptVersion: collector.RemoteScriptVersion(),
+		Commands:      collector.SSHCommands(),
+	}
+	if cfg := config.Get(); cfg != nil {
+		resp.Restricted = cfg.System.SSH.Restricted
+		resp.RemoteScript = cfg.System.SSH.RemoteScript
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    resp,
+	})
+}
+
+// GetScript downloads the collection script to install on the hosts at the
+// path of system.ssh.remoteScript.
+func (h *SSHTestHandler) GetScript(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, "text/x-shellscript; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="mt-collect.sh"`)
+	return c.SendString(collector.RemoteScript())
+}
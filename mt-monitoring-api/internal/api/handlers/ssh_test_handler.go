@@ -1,13 +1,17 @@
 package handlers
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/collector"
 	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/secrets"
 	"golang.org/x/crypto/ssh"
 )
 
@@ -21,13 +25,14 @@ func NewSSHTestHandler() *SSHTestHandler {
 
 // sshTestRequest is the request body for SSH connection test.
 type sshTestRequest struct {
-	IP          string              `json:"ip"`
-	SSHPort     int                 `json:"sshPort"`
-	SSHUser     string              `json:"sshUser"`
-	SSHAuthType models.SSHAuthType  `json:"sshAuthType"`
-	SSHKeyPath  string              `json:"sshKeyPath,omitempty"`
-	SSHKey      string              `json:"sshKey,omitempty"`
-	SSHPassword string              `json:"sshPassword,omitempty"`
+	IP           string             `json:"ip"`
+	SSHPort      int                `json:"sshPort"`
+	SSHUser      string             `json:"sshUser"`
+	SSHAuthType  models.SSHAuthType `json:"sshAuthType"`
+	SSHKeyPath   string             `json:"sshKeyPath,omitempty"`
+	SSHKey       string             `json:"sshKey,omitempty"`
+	SSHPassword  string             `json:"sshPassword,omitempty"`
+	SSHSecretRef string             `json:"sshSecretRef,omitempty"`
 }
 
 // sshTestResponse is the response body for SSH connection test.
@@ -43,23 +48,14 @@ type sshTestResponse struct {
 func (h *SSHTestHandler) TestConnection(c *fiber.Ctx) error {
 	var req sshTestRequest
 	if err := c.BodyParser(&req); err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "INVALID_REQUEST",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
 	}
 
-	if req.IP == "" || req.SSHUser == "" {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "VALIDATION_ERROR",
-				"message": "ip and sshUser are required",
-			},
-		})
+	if req.IP == "" {
+		return invalidField(c, "ip", "ip is required")
+	}
+	if req.SSHUser == "" {
+		return invalidField(c, "sshUser", "sshUser is required")
 	}
 
 	if req.SSHPort == 0 {
@@ -67,15 +63,15 @@ func (h *SSHTestHandler) TestConnection(c *fiber.Ctx) error {
 	}
 
 	// Build SSH auth method
-	authMethods, err := buildAuthMethods(req.SSHAuthType, req.SSHPassword, req.SSHKey, req.SSHKeyPath)
+	var authMethods []ssh.AuthMethod
+	var err error
+	if req.SSHAuthType == models.SSHAuthSecret {
+		authMethods, err = secretAuthMethods(c.UserContext(), &req)
+	} else {
+		authMethods, err = buildAuthMethods(req.SSHAuthType, req.SSHPassword, req.SSHKey, req.SSHKeyPath)
+	}
 	if err != nil {
-		return c.Status(400).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "AUTH_CONFIG_ERROR",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, 400, apierror.AuthConfigError, err.Error())
 	}
 
 	// Get timeout from config
@@ -99,13 +95,8 @@ func (h *SSHTestHandler) TestConnection(c *fiber.Ctx) error {
 	latency := time.Since(start).Milliseconds()
 
 	if err != nil {
-		return c.JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "SSH_CONNECTION_FAILED",
-				"message": err.Error(),
-			},
-		})
+		// The test ran: report its result with 200
+		return errorResponse(c, fiber.StatusOK, apierror.SSHConnectionFailed, err.Error())
 	}
 	defer client.Close()
 
@@ -118,7 +109,7 @@ func (h *SSHTestHandler) TestConnection(c *fiber.Ctx) error {
 	session, err := client.NewSession()
 	if err == nil {
 		defer session.Close()
-		output, err := session.CombinedOutput("hostname && cat /etc/os-release 2>/dev/null | head -2 || echo unknown")
+		output, err := session.CombinedOutput(collector.SSHCommandLine(collector.CommandIdentify))
 		if err == nil {
 			lines := splitLines(string(output))
 			if len(lines) > 0 {
@@ -148,6 +139,19 @@ func (h *SSHTestHandler) TestConnection(c *fiber.Ctx) error {
 	})
 }
 
+// secretAuthMethods fetches credentials for the request's secret reference.
+// A username stored in the secret replaces req.SSHUser.
+func secretAuthMethods(ctx context.Context, req *sshTestRequest) ([]ssh.AuthMethod, error) {
+	creds, err := secrets.Fetch(ctx, req.SSHSecretRef)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Username != "" {
+		req.SSHUser = creds.Username
+	}
+	return creds.AuthMethods()
+}
+
 // buildAuthMethods creates SSH auth methods from the request parameters.
 func buildAuthMethods(authType models.SSHAuthType, password, keyContent, keyPath string) ([]ssh.AuthMethod, error) {
 	switch authType {
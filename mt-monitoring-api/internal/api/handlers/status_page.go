@@ -0,0 +1,600 @@
+package handlers
+
+import (
+	"net/mail"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/statuspage"
+)
+
+// StatusPageHandler serves the public status page and the admin API for
+// its components, subscribers, incident updates and maintenances
+type StatusPageHandler struct {
+	store    *database.Store
+	repo     database.StatusPageRepository
+	notifier *statuspage.Notifier
+}
+
+// NewStatusPageHandler creates a new status page handler
+func NewStatusPageHandler(store *database.Store) *StatusPageHandler {
+	return &StatusPageHandler{
+		store:    store,
+		repo:     store.StatusPage,
+		notifier: statuspage.NewNotifier(store),
+	}
+}
+
+// GetPage returns the public status page
+// GET /status
+func (h *StatusPageHandler) GetPage(c *fiber.Ctx) error {
+	page, err := statuspage.Build(c.UserContext(), h.store)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    page,
+	})
+}
+
+// RSS returns the incidents and maintenances of the status page as an RSS
+// feed
+// GET /status/feed.rss
+func (h *StatusPageHandler) RSS(c *fiber.Ctx) error {
+	return h.sendFeed(c, "application/rss+xml; charset=utf-8", statuspage.RSS)
+}
+
+// Atom returns the incidents and maintenances of the status page as an
+// Atom feed
+// GET /status/feed.atom
+func (h *StatusPageHandler) Atom(c *fiber.Ctx) error {
+	return h.sendFeed(c, "application/atom+xml; charset=utf-8", statuspage.Atom)
+}
+
+// Calendar returns the incidents and maintenances of the status page as an
+// iCalendar file
+// GET /status/calendar.ics
+func (h *StatusPageHandler) Calendar(c *fiber.Ctx) error {
+	return h.sendFeed(c, "text/calendar; charset=utf-8", func(page *models.StatusPage) ([]byte, error) {
+		return statuspage.ICal(page), nil
+	})
+}
+
+func (h *StatusPageHandler) sendFeed(c *fiber.Ctx, contentType string, render func(*models.StatusPage) ([]byte, error)) error {
+	page, err := statuspage.Build(c.UserContext(), h.store)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	body, err := render(page)
+	if err != nil {
+		return errorResponse(c, 500, apierror.InternalError, err.Error())
+	}
+	c.Set(fiber.HeaderContentType, contentType)
+	return c.Send(body)
+}
+
+// Unsubscribe removes the subscriber with the token from a notification's
+// unsubscribe link. It answers in plain text for people following the link.
+// GET /status/unsubscribe/:token
+func (h *StatusPageHandler) Unsubscribe(c *fiber.Ctx) error {
+	deleted, err := h.repo.DeleteSubscriberByToken(c.UserContext(), c.Params("token"))
+	if err != nil {
+		return c.Status(500).SendString("Failed to unsubscribe, please try again later.")
+	}
+	if !deleted {
+		return c.Status(404).SendString("This subscription does not exist or was already removed.")
+	}
+	return c.SendString("You have been unsubscribed from " + statuspage.Title() + ".")
+}
+
+// GetComponents returns all components with their services and status
+// GET /status-page/components
+func (h *StatusPageHandler) GetComponents(c *fiber.Ctx) error {
+	components, err := statuspage.Components(c.UserContext(), h.store)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    components,
+	})
+}
+
+// CreateComponent creates a component
+// POST /status-page/components
+func (h *StatusPageHandler) CreateComponent(c *fiber.Ctx) error {
+	var req models.StatusComponentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if req.ID == "" {
+		return invalidField(c, "id", "id is required")
+	}
+	if invalid, err := h.validateComponent(c, &req); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	existing, err := h.repo.GetComponent(c.UserContext(), req.ID)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if existing != nil {
+		return errorResponse(c, 409, apierror.ComponentExists, "Component with this ID already exists")
+	}
+
+	component := &models.StatusComponent{
+		ID:          req.ID,
+		Name:        req.Name,
+		Description: req.Description,
+		Position:    req.Position,
+		ServiceIDs:  req.ServiceIDs,
+	}
+	if err := h.repo.CreateComponent(c.UserContext(), component); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    component,
+	})
+}
+
+// UpdateComponent replaces a component's name, description, position and
+// services
+// PUT /status-page/components/:id
+func (h *StatusPageHandler) UpdateComponent(c *fiber.Ctx) error {
+	component, err := h.repo.GetComponent(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if component == nil {
+		return errorResponse(c, 404, apierror.ComponentNotFound, "Component not found")
+	}
+
+	var req models.StatusComponentRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if invalid, err := h.validateComponent(c, &req); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	component.Name = req.Name
+	component.Description = req.Description
+	component.Position = req.Position
+	component.ServiceIDs = req.ServiceIDs
+	if err := h.repo.UpdateComponent(c.UserContext(), component); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    component,
+	})
+}
+
+// DeleteComponent deletes a component
+// DELETE /status-page/components/:id
+func (h *StatusPageHandler) DeleteComponent(c *fiber.Ctx) error {
+	deleted, err := h.repo.DeleteComponent(c.UserContext(), c.Params("id"))
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.ComponentNotFound, "Component not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Component deleted successfully",
+	})
+}
+
+// SetOverride sets a component's status manually until cleared
+// PUT /status-page/components/:id/override
+func (h *StatusPageHandler) SetOverride(c *fiber.Ctx) error {
+	var req models.StatusOverrideRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if !req.Status.IsValid() {
+		return invalidField(c, "status", "status must be operational, under_maintenance, degraded_performance, partial_outage or major_outage")
+	}
+
+	updated, err := h.repo.SetOverride(c.UserContext(), c.Params("id"), req.Status, req.Message)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !updated {
+		return errorResponse(c, 404, apierror.ComponentNotFound, "Component not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Component status overridden",
+	})
+}
+
+// ClearOverride returns a component to its computed status
+// DELETE /status-page/components/:id/override
+func (h *StatusPageHandler) ClearOverride(c *fiber.Ctx) error {
+	updated, err := h.repo.SetOverride(c.UserContext(), c.Params("id"), "", "")
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !updated {
+		return errorResponse(c, 404, apierror.ComponentNotFound, "Component not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Component status override cleared",
+	})
+}
+
+// GetSubscribers returns all subscribers
+// GET /status-page/subscribers
+func (h *StatusPageHandler) GetSubscribers(c *fiber.Ctx) error {
+	subscribers, err := h.repo.GetSubscribers(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    subscribers,
+	})
+}
+
+// CreateSubscriber adds an email or webhook subscriber
+// POST /status-page/subscribers
+func (h *StatusPageHandler) CreateSubscriber(c *fiber.Ctx) error {
+	var req models.StatusSubscriberCreateRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+
+	switch req.Type {
+	case models.SubscriberEmail:
+		addr, err := mail.ParseAddress(req.Target)
+		if err != nil {
+			return invalidField(c, "target", "target must be an email address")
+		}
+		req.Target = addr.Address
+	case models.SubscriberWebhook:
+		u, err := url.Parse(req.Target)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+			return invalidField(c, "target", "target must be an http or https URL")
+		}
+	default:
+		return invalidField(c, "type", "type must be email or webhook")
+	}
+
+	if unknown, err := h.unknownComponent(c, req.ComponentIDs); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if unknown != "" {
+		return invalidField(c, "componentIds", "unknown component: "+unknown)
+	}
+
+	subscriber := &models.StatusSubscriber{
+		Type:         req.Type,
+		Target:       req.Target,
+		ComponentIDs: req.ComponentIDs,
+		Token:        crypto.GenerateSubscriberToken(),
+	}
+	if err := h.repo.CreateSubscriber(c.UserContext(), subscriber); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    subscriber,
+	})
+}
+
+// DeleteSubscriber removes a subscriber
+// DELETE /status-page/subscribers/:id
+func (h *StatusPageHandler) DeleteSubscriber(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid subscriber ID")
+	}
+	deleted, err := h.repo.DeleteSubscriber(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.SubscriberNotFound, "Subscriber not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Subscriber deleted successfully",
+	})
+}
+
+// GetIncidentUpdates returns the updates posted on an incident
+// GET /incidents/:id/updates
+func (h *StatusPageHandler) GetIncidentUpdates(c *fiber.Ctx) error {
+	incident, err := h.incident(c)
+	if err != nil || incident == nil {
+		return err
+	}
+	updates, err := h.repo.GetIncidentUpdates(c.UserContext(), []int64{incident.ID})
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    updates,
+	})
+}
+
+// CreateIncidentUpdate posts an update on an incident and notifies the
+// subscribers of its components
+// POST /incidents/:id/updates
+func (h *StatusPageHandler) CreateIncidentUpdate(c *fiber.Ctx) error {
+	incident, err := h.incident(c)
+	if err != nil || incident == nil {
+		return err
+	}
+
+	var req struct {
+		Message string `json:"message"`
+	}
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	req.Message = strings.TrimSpace(req.Message)
+	if req.Message == "" {
+		return invalidField(c, "message", "message is required")
+	}
+
+	update := &models.IncidentUpdate{IncidentID: incident.ID, Message: req.Message}
+	if err := h.repo.CreateIncidentUpdate(c.UserContext(), update); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	go h.notifier.Notify(statuspage.EventUpdated, incident, update.Message)
+
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    update,
+	})
+}
+
+// GetMaintenances returns the maintenances that have not ended or ended
+// within the last days, by start
+// GET /status-page/maintenances?days=30
+func (h *StatusPageHandler) GetMaintenances(c *fiber.Ctx) error {
+	days := 30
+	if d := c.Query("days"); d != "" {
+		if parsed, err := strconv.Atoi(d); err == nil && parsed > 0 {
+			days = parsed
+		}
+	}
+
+	now := time.Now()
+	maintenances, err := statuspage.Maintenances(c.UserContext(), h.store, now.AddDate(0, 0, -days), now)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    maintenances,
+	})
+}
+
+// CreateMaintenance announces maintenance on the status page
+// POST /status-page/maintenances
+func (h *StatusPageHandler) CreateMaintenance(c *fiber.Ctx) error {
+	var req models.StatusMaintenanceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if invalid, err := h.validateMaintenance(c, &req); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	// Stored in local time like checked_at so range comparisons line up
+	maintenance := &models.StatusMaintenance{
+		Title:        req.Title,
+		Message:      req.Message,
+		ComponentIDs: req.ComponentIDs,
+		StartsAt:     req.StartsAt.Local(),
+		EndsAt:       req.EndsAt.Local(),
+	}
+	if err := h.repo.CreateMaintenance(c.UserContext(), maintenance); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	maintenance.Status = maintenance.StatusAt(time.Now())
+	return c.Status(201).JSON(fiber.Map{
+		"success": true,
+		"data":    maintenance,
+	})
+}
+
+// UpdateMaintenance replaces a maintenance's title, message, components
+// and times
+// PUT /status-page/maintenances/:id
+func (h *StatusPageHandler) UpdateMaintenance(c *fiber.Ctx) error {
+	maintenance, err := h.maintenance(c)
+	if err != nil || maintenance == nil {
+		return err
+	}
+
+	var req models.StatusMaintenanceRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, err.Error())
+	}
+	if invalid, err := h.validateMaintenance(c, &req); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	} else if invalid != nil {
+		return validationError(c, invalid)
+	}
+
+	maintenance.Title = req.Title
+	maintenance.Message = req.Message
+	maintenance.ComponentIDs = req.ComponentIDs
+	maintenance.StartsAt = req.StartsAt.Local()
+	maintenance.EndsAt = req.EndsAt.Local()
+	if err := h.repo.UpdateMaintenance(c.UserContext(), maintenance); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	maintenance.Status = maintenance.StatusAt(time.Now())
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    maintenance,
+	})
+}
+
+// CompleteMaintenance ends a maintenance in progress now, before its
+// scheduled end
+// POST /status-page/maintenances/:id/complete
+func (h *StatusPageHandler) CompleteMaintenance(c *fiber.Ctx) error {
+	maintenance, err := h.maintenance(c)
+	if err != nil || maintenance == nil {
+		return err
+	}
+
+	now := time.Now()
+	if maintenance.StatusAt(now) != models.MaintenanceInProgress {
+		return errorResponse(c, 409, apierror.MaintenanceNotInProgress, "Maintenance is not in progress")
+	}
+	maintenance.EndsAt = now
+	if err := h.repo.UpdateMaintenance(c.UserContext(), maintenance); err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	maintenance.Status = models.MaintenanceCompleted
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    maintenance,
+	})
+}
+
+// DeleteMaintenance cancels or removes a maintenance
+// DELETE /status-page/maintenances/:id
+func (h *StatusPageHandler) DeleteMaintenance(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid maintenance ID")
+	}
+	deleted, err := h.repo.DeleteMaintenance(c.UserContext(), id)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !deleted {
+		return errorResponse(c, 404, apierror.MaintenanceNotFound, "Maintenance not found")
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Maintenance deleted successfully",
+	})
+}
+
+// maintenance loads the maintenance named in the URL. When it returns nil
+// the error response has been sent.
+func (h *StatusPageHandler) maintenance(c *fiber.Ctx) (*models.StatusMaintenance, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, errorResponse(c, 400, apierror.InvalidRequest, "Invalid maintenance ID")
+	}
+	maintenance, err := h.repo.GetMaintenance(c.UserContext(), id)
+	if err != nil {
+		return nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if maintenance == nil {
+		return nil, errorResponse(c, 404, apierror.MaintenanceNotFound, "Maintenance not found")
+	}
+	return maintenance, nil
+}
+
+// validateMaintenance returns the invalid field for a maintenance
+// request, or nil when it is valid
+func (h *StatusPageHandler) validateMaintenance(c *fiber.Ctx, req *models.StatusMaintenanceRequest) (*apierror.FieldError, error) {
+	req.Title = strings.TrimSpace(req.Title)
+	if req.Title == "" {
+		return apierror.Invalid("title", "title is required"), nil
+	}
+	if req.StartsAt.IsZero() {
+		return apierror.Invalid("startsAt", "startsAt is required"), nil
+	}
+	if !req.EndsAt.After(req.StartsAt) {
+		return apierror.Invalid("endsAt", "endsAt is required and must be after startsAt"), nil
+	}
+	if len(req.ComponentIDs) == 0 {
+		return apierror.Invalid("componentIds", "componentIds is required"), nil
+	}
+	unknown, err := h.unknownComponent(c, req.ComponentIDs)
+	if err != nil || unknown == "" {
+		return nil, err
+	}
+	return apierror.Invalid("componentIds", "unknown component: "+unknown), nil
+}
+
+// unknownComponent returns the first of ids that is not a component, or ""
+func (h *StatusPageHandler) unknownComponent(c *fiber.Ctx, ids []string) (string, error) {
+	components, err := h.repo.GetComponents(c.UserContext())
+	if err != nil {
+		return "", err
+	}
+	known := make(map[string]bool, len(components))
+	for _, component := range components {
+		known[component.ID] = true
+	}
+	for _, id := range ids {
+		if !known[id] {
+			return id, nil
+		}
+	}
+	return "", nil
+}
+
+// incident loads the incident named in the URL. When it returns nil the
+// error response has been sent.
+func (h *StatusPageHandler) incident(c *fiber.Ctx) (*models.Incident, error) {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return nil, errorResponse(c, 400, apierror.InvalidRequest, "Invalid incident ID")
+	}
+	incident, err := h.store.Incidents.GetByID(c.UserContext(), id)
+	if err != nil {
+		return nil, errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if incident == nil {
+		return nil, errorResponse(c, 404, apierror.IncidentNotFound, "Incident not found")
+	}
+	return incident, nil
+}
+
+// validateComponent returns the invalid field for a component request,
+// or nil when it is valid. Duplicate service IDs are dropped.
+func (h *StatusPageHandler) validateComponent(c *fiber.Ctx, req *models.StatusComponentRequest) (*apierror.FieldError, error) {
+	if req.Name == "" {
+		return apierror.Invalid("name", "name is required"), nil
+	}
+
+	seen := make(map[string]bool, len(req.ServiceIDs))
+	serviceIDs := []string{}
+	for _, id := range req.ServiceIDs {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		service, err := h.store.Services.GetByID(c.UserContext(), id)
+		if err != nil {
+			return nil, err
+		}
+		if service == nil {
+			return apierror.Invalid("serviceIds", "unknown service: "+id), nil
+		}
+		serviceIDs = append(serviceIDs, id)
+	}
+	req.ServiceIDs = serviceIDs
+	return nil, nil
+}
@@ -1,25 +1,34 @@
 package handlers
 
 import (
+	"context"
+	"errors"
 	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
 	"github.com/mt-monitoring/api/internal/collector"
 	"github.com/mt-monitoring/api/internal/database"
 )
 
 // SystemHandler handles system resource monitoring requests.
 type SystemHandler struct {
-	manager    *collector.CollectorManager
-	metricRepo *database.SystemMetricRepository
+	manager     *collector.CollectorManager
+	metricRepo  database.SystemMetricRepository
+	factsRepo   database.HostFactsRepository
+	updatesRepo database.HostUpdatesRepository
+	portsRepo   database.HostPortsRepository
 }
 
 // NewSystemHandler creates a new system handler backed by a CollectorManager.
-func NewSystemHandler(mgr *collector.CollectorManager) *SystemHandler {
+func NewSystemHandler(store *database.Store, mgr *collector.CollectorManager) *SystemHandler {
 	return &SystemHandler{
-		manager:    mgr,
-		metricRepo: database.NewSystemMetricRepository(),
+		manager:     mgr,
+		metricRepo:  store.SystemMetrics,
+		factsRepo:   store.HostFacts,
+		updatesRepo: store.HostUpdates,
+		portsRepo:   store.HostPorts,
 	}
 }
 
@@ -50,13 +59,7 @@ func (h *SystemHandler) GetInfo(c *fiber.Ctx) error {
 	if coll != nil {
 		liveInfo, err := coll.GetSystemInfo()
 		if err != nil {
-			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "COLLECT_FAILED",
-					"message": err.Error(),
-				},
-			})
+			return errorResponse(c, fiber.StatusInternalServerError, apierror.CollectFailed, err.Error())
 		}
 		return c.JSON(fiber.Map{
 			"success": true,
@@ -65,13 +68,7 @@ func (h *SystemHandler) GetInfo(c *fiber.Ctx) error {
 	}
 
 	// No collector registered for this host
-	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-		"success": false,
-		"error": fiber.Map{
-			"code":    "NO_COLLECTOR",
-			"message": "No active collector for this host. The host may be offline or not yet configured.",
-		},
-	})
+	return errorResponse(c, fiber.StatusServiceUnavailable, apierror.NoCollector, "No active collector for this host. The host may be offline or not yet configured.")
 }
 
 // GetMetricsHistory returns time-series data for chart rendering.
@@ -81,13 +78,24 @@ func (h *SystemHandler) GetMetricsHistory(c *fiber.Ctx) error {
 
 	history, err := h.manager.GetHistory(hostID, rangeStr)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "HISTORY_FETCH_FAILED",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.HistoryFetchFailed, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// GetReachability returns the ping round-trip time and packet loss of a host
+// for the reachability chart.
+func (h *SystemHandler) GetReachability(c *fiber.Ctx) error {
+	hostID := h.getHostID(c)
+	rangeStr := c.Query("range", "6h")
+
+	history, err := h.manager.GetReachability(hostID, rangeStr)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.HistoryFetchFailed, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -102,27 +110,18 @@ func (h *SystemHandler) GetProcesses(c *fiber.Ctx) error {
 
 	coll := h.manager.GetCollector(hostID)
 	if coll == nil {
-		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "NO_COLLECTOR",
-				"message": "No active collector for this host.",
-			},
-		})
+		return errorResponse(c, fiber.StatusServiceUnavailable, apierror.NoCollector, "No active collector for this host.")
 	}
 
 	limit, _ := strconv.Atoi(c.Query("limit", "10"))
 	sortBy := c.Query("sort", "cpu")
 
 	processes, err := coll.GetProcesses(limit, sortBy)
+	if errors.Is(err, collector.ErrNotSupported) {
+		return errorResponse(c, fiber.StatusNotImplemented, apierror.NotSupported, "The collector of this host does not report processes.")
+	}
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"success": false,
-			"error": fiber.Map{
-				"code":    "PROCESS_FETCH_FAILED",
-				"message": err.Error(),
-			},
-		})
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.ProcessFetchFailed, err.Error())
 	}
 
 	return c.JSON(fiber.Map{
@@ -131,8 +130,95 @@ func (h *SystemHandler) GetProcesses(c *fiber.Ctx) error {
 	})
 }
 
+// GetProcessHistory returns the recorded top processes of a host.
+// ?range=6h|12h|24h returns every sample in the range; ?at=<RFC3339> returns
+// the last sample at or before that time.
+func (h *SystemHandler) GetProcessHistory(c *fiber.Ctx) error {
+	hostID := h.getHostID(c)
+
+	if at := c.Query("at"); at != "" {
+		t, err := time.Parse(time.RFC3339, at)
+		if err != nil {
+			return errorResponse(c, fiber.StatusBadRequest, apierror.InvalidRequest, "at must be an RFC 3339 time, e.g. 2026-01-02T03:12:00Z")
+		}
+		sample, err := h.manager.GetProcessSample(hostID, t)
+		if err != nil {
+			return errorResponse(c, fiber.StatusInternalServerError, apierror.HistoryFetchFailed, err.Error())
+		}
+		if sample == nil {
+			return errorResponse(c, fiber.StatusNotFound, apierror.SampleNotFound, "No processes recorded for this host at or before that time.")
+		}
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    sample,
+		})
+	}
+
+	history, err := h.manager.GetProcessHistory(hostID, c.Query("range", "6h"))
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.HistoryFetchFailed, err.Error())
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// GetFacts returns the inventory facts of a host, collected daily.
+// ?refresh=true collects them now.
+func (h *SystemHandler) GetFacts(c *fiber.Ctx) error {
+	hostID := h.getHostID(c)
+
+	if c.QueryBool("refresh") {
+		if h.manager.GetCollector(hostID) == nil {
+			return errorResponse(c, fiber.StatusServiceUnavailable, apierror.NoCollector, "No active collector for this host.")
+		}
+		facts, err := h.manager.RefreshFacts(hostID)
+		if err != nil {
+			return errorResponse(c, fiber.StatusInternalServerError, apierror.CollectFailed, err.Error())
+		}
+		return c.JSON(fiber.Map{
+			"success": true,
+			"data":    facts,
+		})
+	}
+
+	facts, err := h.factsRepo.Get(c.UserContext(), hostID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.DatabaseError, err.Error())
+	}
+	if facts == nil {
+		return errorResponse(c, fiber.StatusNotFound, apierror.FactsNotFound, "No facts collected for this host yet.")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    facts,
+	})
+}
+
+// GetUpdates returns the pending updates of a host from its last updates
+// check.
+func (h *SystemHandler) GetUpdates(c *fiber.Ctx) error {
+	hostID := h.getHostID(c)
+
+	updates, err := h.updatesRepo.Get(c.UserContext(), hostID)
+	if err != nil {
+		return errorResponse(c, fiber.StatusInternalServerError, apierror.DatabaseError, err.Error())
+	}
+	if updates == nil {
+		return errorResponse(c, fiber.StatusNotFound, apierror.UpdatesNotFound, "No updates check recorded for this host yet.")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    updates,
+	})
+}
+
 // getHistoryFromDB queries metrics history directly from DB for any host.
-func getHistoryFromDB(repo *database.SystemMetricRepository, hostID, rangeStr string) (fiber.Map, error) {
+func getHistoryFromDB(ctx context.Context, repo database.SystemMetricRepository, hostID, rangeStr string) (fiber.Map, error) {
 	var duration time.Duration
 	switch rangeStr {
 	case "12h":
@@ -145,7 +231,7 @@ func getHistoryFromDB(repo *database.SystemMetricRepository, hostID, rangeStr st
 	}
 
 	since := time.Now().Add(-duration)
-	points, err := repo.GetHistory(hostID, since)
+	points, err := repo.GetHistory(ctx, hostID, since)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,123 @@
+package handlers
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// TagHandler handles service tag requests
+type TagHandler struct {
+	repo database.TagRepository
+}
+
+// NewTagHandler creates a new tag handler
+func NewTagHandler(store *database.Store) *TagHandler {
+	return &TagHandler{repo: store.Tags}
+}
+
+// GetAll returns every tag in use with its service count
+// GET /tags
+func (h *TagHandler) GetAll(c *fiber.Ctx) error {
+	tags, err := h.repo.GetAll(c.UserContext())
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    tags,
+	})
+}
+
+// Rename renames a tag on every service. Renaming onto a tag in use is
+// rejected; merge the tags instead.
+// PUT /tags/:tag
+func (h *TagHandler) Rename(c *fiber.Ctx) error {
+	from, err := url.PathUnescape(c.Params("tag"))
+	if err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid tag")
+	}
+	var req models.TagRenameRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return invalidField(c, "name", "name is required")
+	}
+
+	exists, err := h.repo.Exists(c.UserContext(), from)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	if !exists {
+		return errorResponse(c, 404, apierror.TagNotFound, "Tag not found")
+	}
+	if req.Name != from {
+		taken, err := h.repo.Exists(c.UserContext(), req.Name)
+		if err != nil {
+			return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+		}
+		if taken {
+			return errorResponse(c, 409, apierror.TagExists, "Tag "+req.Name+" is already in use; merge the tags instead")
+		}
+	}
+
+	changed, err := h.repo.Merge(c.UserContext(), []string{from}, req.Name)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"name": req.Name, "services": changed},
+	})
+}
+
+// Merge replaces several tags with one on every service
+// POST /tags/merge
+func (h *TagHandler) Merge(c *fiber.Ctx) error {
+	var req models.TagMergeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return errorResponse(c, 400, apierror.InvalidRequest, "Invalid request body")
+	}
+	req.Into = strings.TrimSpace(req.Into)
+	req.Tags = models.NormalizeTags(req.Tags)
+	if req.Into == "" {
+		return invalidField(c, "into", "into is required")
+	}
+	if len(req.Tags) == 0 {
+		return invalidField(c, "tags", "tags must list at least one tag")
+	}
+
+	changed, err := h.repo.Merge(c.UserContext(), req.Tags, req.Into)
+	if err != nil {
+		return errorResponse(c, 500, apierror.DatabaseError, err.Error())
+	}
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data":    fiber.Map{"name": req.Into, "services": changed},
+	})
+}
+
+// tagQuery returns the ?tag= values of a list request; each may repeat
+func tagQuery(c *fiber.Ctx) []string {
+	var tags []string
+	for _, v := range c.Context().QueryArgs().PeekMulti("tag") {
+		tags = append(tags, string(v))
+	}
+	return models.NormalizeTags(tags)
+}
+
+// taggedServices returns the IDs of the services carrying every ?tag= of
+// the request, nil when the request has none
+func taggedServices(c *fiber.Ctx, repo database.TagRepository) (map[string]bool, error) {
+	tags := tagQuery(c)
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	return repo.ServiceIDs(c.UserContext(), tags)
+}
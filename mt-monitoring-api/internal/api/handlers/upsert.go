@@ -0,0 +1,36 @@
+package handlers
+
+import "reflect"
+
+// fieldPair pairs a stored and a requested value for comparison
+type fieldPair struct {
+	name       string
+	have, want interface{}
+}
+
+// changedFields returns the names of fields whose values differ, for PUT
+// handlers reporting what a replace changed. Nil and empty maps/slices are
+// equal.
+func changedFields(fields ...fieldPair) []string {
+	changed := []string{}
+	for _, f := range fields {
+		if isEmptyValue(f.have) && isEmptyValue(f.want) {
+			continue
+		}
+		if !reflect.DeepEqual(f.have, f.want) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+func isEmptyValue(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
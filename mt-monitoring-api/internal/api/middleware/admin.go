@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// AdminAuth returns a middleware that requires "Authorization: Bearer
+// <security.adminToken>". Without a configured token the routes behind it
+// are disabled. The token is read per request so a reload applies it.
+func AdminAuth() fiber.Handler {
+	return bearerAuth(func(cfg *config.Config) string {
+		return cfg.Security.AdminToken
+	}, "Admin endpoints are disabled; set security.adminToken to enable them", "Invalid or missing admin token")
+}
+
+// AlertmanagerAuth returns a middleware that requires "Authorization:
+// Bearer <alerts.alertmanager.token>" while the receiver is enabled
+func AlertmanagerAuth() fiber.Handler {
+	return bearerAuth(func(cfg *config.Config) string {
+		if !cfg.Alerts.Alertmanager.Enabled {
+			return ""
+		}
+		return cfg.Alerts.Alertmanager.Token
+	}, "Alertmanager receiver is disabled; set alerts.alertmanager.enabled and token", "Invalid or missing Alertmanager token")
+}
+
+// bearerAuth compares the bearer token with the one returned by token,
+// answering 404 when it is empty
+func bearerAuth(token func(cfg *config.Config) string, disabled, invalid string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		var want string
+		if cfg := config.Get(); cfg != nil {
+			want = token(cfg)
+		}
+		if want == "" {
+			return apierror.Send(c, 404, apierror.NotFound, disabled)
+		}
+
+		auth := c.Get("Authorization")
+		given, ok := strings.CutPrefix(auth, "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(given), []byte(want)) != 1 {
+			return apierror.Send(c, 401, apierror.Unauthorized, invalid)
+		}
+		return c.Next()
+	}
+}
@@ -1,60 +1,56 @@
 package middleware
 
 import (
+	"math"
+	"strconv"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
 )
 
 // ApiKeyAuth returns a middleware that validates API key from Authorization header
-func ApiKeyAuth() fiber.Handler {
-	repo := database.NewServiceRepository()
+// and enforces the service's ingestion quotas (events/minute and payload size).
+func ApiKeyAuth(repo database.ServiceRepository) fiber.Handler {
+	limiter := NewIngestLimiter()
+	go limiter.flushDropped(repo)
 
 	return func(c *fiber.Ctx) error {
 		auth := c.Get("Authorization")
 		if auth == "" {
-			return c.Status(401).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Missing Authorization header",
-				},
-			})
+			return apierror.Send(c, 401, apierror.Unauthorized, "Missing Authorization header")
 		}
 
 		// Expect "Bearer <api_key>"
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			return c.Status(401).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Invalid Authorization format. Expected: Bearer <api_key>",
-				},
-			})
+			return apierror.Send(c, 401, apierror.Unauthorized, "Invalid Authorization format. Expected: Bearer <api_key>")
 		}
 
 		apiKey := parts[1]
-		service, err := repo.GetByApiKey(apiKey)
+		service, err := repo.GetByApiKey(c.UserContext(), apiKey)
 		if err != nil {
-			return c.Status(500).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "INTERNAL_ERROR",
-					"message": "Failed to validate API key",
-				},
-			})
+			return apierror.Send(c, 500, apierror.InternalError, "Failed to validate API key")
 		}
 
 		if service == nil {
-			return c.Status(401).JSON(fiber.Map{
-				"success": false,
-				"error": fiber.Map{
-					"code":    "UNAUTHORIZED",
-					"message": "Invalid API key",
-				},
-			})
+			return apierror.Send(c, 401, apierror.Unauthorized, "Invalid API key")
+		}
+
+		rateLimit, maxPayload := ingestQuotas(service)
+
+		if maxPayload > 0 && len(c.Body()) > maxPayload {
+			limiter.Drop(service.ID)
+			return apierror.Send(c, fiber.StatusRequestEntityTooLarge, apierror.PayloadTooLarge, "Request body exceeds "+strconv.Itoa(maxPayload)+" bytes")
+		}
+
+		if allowed, retryAfter := limiter.Allow(service.ID, rateLimit); !allowed {
+			limiter.Drop(service.ID)
+			c.Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			return apierror.Send(c, fiber.StatusTooManyRequests, apierror.RateLimited, "Ingestion quota of "+strconv.Itoa(rateLimit)+" events/minute exceeded")
 		}
 
 		// Store service in context for downstream handlers
@@ -62,3 +58,19 @@ func ApiKeyAuth() fiber.Handler {
 		return c.Next()
 	}
 }
+
+// ingestQuotas returns the effective rate limit and payload limit for a service,
+// falling back to the server-wide defaults.
+func ingestQuotas(service *models.Service) (rateLimit, maxPayload int) {
+	if cfg := config.Get(); cfg != nil {
+		rateLimit = cfg.Ingest.RateLimit
+		maxPayload = cfg.Ingest.MaxPayloadBytes
+	}
+	if service.IngestRateLimit > 0 {
+		rateLimit = service.IngestRateLimit
+	}
+	if service.IngestMaxPayload > 0 {
+		maxPayload = service.IngestMaxPayload
+	}
+	return rateLimit, maxPayload
+}
@@ -0,0 +1,38 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/compress"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// compressionLevels maps server.compression.level to the levels of the
+// compress middleware
+var compressionLevels = map[string]compress.Level{
+	"speed":   compress.LevelBestSpeed,
+	"default": compress.LevelDefault,
+	"best":    compress.LevelBestCompression,
+}
+
+// Compress returns middleware compressing responses with brotli or gzip, as
+// the client accepts, per server.compression (read at startup). Log streams
+// and WebSocket upgrades are left alone: compressing them would hold events
+// back until the stream ends.
+func Compress() fiber.Handler {
+	level := compress.LevelDefault
+	if cfg := config.Get(); cfg != nil {
+		if !cfg.Server.Compression.Enabled {
+			level = compress.LevelDisabled
+		} else if l, ok := compressionLevels[cfg.Server.Compression.Level]; ok {
+			level = l
+		}
+	}
+	return compress.New(compress.Config{
+		Level: level,
+		Next: func(c *fiber.Ctx) bool {
+			return strings.HasSuffix(c.Path(), "/stream") || c.Get(fiber.HeaderUpgrade) != ""
+		},
+	})
+}
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"context"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestContext returns middleware giving every request a user context
+// derived from server, cancelled when the handler chain returns or server
+// ends. Handlers pass c.UserContext() to the repositories, so queries of a
+// request that is done, or still running when the server shuts down, are
+// abandoned instead of holding the database connection.
+func RequestContext(server context.Context) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx, cancel := context.WithCancel(server)
+		defer cancel()
+		c.SetUserContext(ctx)
+		return c.Next()
+	}
+}
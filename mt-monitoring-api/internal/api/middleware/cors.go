@@ -10,9 +10,9 @@ func CORS() fiber.Handler {
 	return cors.New(cors.Config{
 		AllowOrigins:     "*",
 		AllowMethods:     "GET,POST,PUT,DELETE,OPTIONS,PATCH",
-		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With",
+		AllowHeaders:     "Origin,Content-Type,Accept,Authorization,X-Requested-With,If-None-Match,X-Request-ID,X-Project",
 		AllowCredentials: false,
-		ExposeHeaders:    "Content-Length,Content-Type",
+		ExposeHeaders:    "Content-Length,Content-Type,ETag,X-Request-ID",
 		MaxAge:           86400, // 24 hours
 	})
 }
@@ -0,0 +1,18 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+)
+
+// Errors returns middleware answering errors returned down the chain
+// instead of a response, such as fiber.ErrUpgradeRequired or recovered
+// panics, with the API error body rather than fiber's plain text
+func Errors() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return apierror.FromError(c, err)
+		}
+		return nil
+	}
+}
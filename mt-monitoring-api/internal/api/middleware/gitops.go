@@ -0,0 +1,19 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/gitops"
+)
+
+// ManagedByGitOps returns a middleware that rejects changes to resources
+// declared in the GitOps source when gitops.blockApiMutations is set.
+// param names the route parameter holding the resource ID.
+func ManagedByGitOps(reconciler *gitops.Reconciler, kind, param string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if reconciler == nil || !reconciler.IsManaged(kind, c.Params(param)) {
+			return c.Next()
+		}
+		return apierror.Send(c, 409, apierror.ManagedByGitOps, "This "+kind+" is managed by GitOps; change it in the source instead")
+	}
+}
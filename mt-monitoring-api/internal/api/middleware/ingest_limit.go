@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"context"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+// droppedFlushInterval is how often rejected events are added to the
+// dropped counters of services, so a client flooding past its quota costs
+// no write per request
+const droppedFlushInterval = 10 * time.Second
+
+// ingestWindow tracks accepted events for one service in the current minute
+type ingestWindow struct {
+	start time.Time
+	count int
+}
+
+// IngestLimiter enforces a fixed one-minute window of log events per service
+// and counts the events it rejects
+type IngestLimiter struct {
+	mu      sync.Mutex
+	windows map[string]*ingestWindow
+	dropped map[string]*atomic.Int64 // serviceID → rejected events not yet flushed
+}
+
+// NewIngestLimiter creates a new ingestion rate limiter
+func NewIngestLimiter() *IngestLimiter {
+	l := &IngestLimiter{
+		windows: make(map[string]*ingestWindow),
+		dropped: make(map[string]*atomic.Int64),
+	}
+	// Start cleanup goroutine
+	go l.cleanup()
+	return l
+}
+
+// Allow records an event for the service and reports whether it is within limit.
+// When rejected, retryAfter is the time until the current window resets.
+func (l *IngestLimiter) Allow(serviceID string, limit int) (allowed bool, retryAfter time.Duration) {
+	if limit <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	w, ok := l.windows[serviceID]
+	if !ok || now.Sub(w.start) >= time.Minute {
+		w = &ingestWindow{start: now}
+		l.windows[serviceID] = w
+	}
+
+	if w.count >= limit {
+		return false, w.start.Add(time.Minute).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// Drop counts a rejected event of the service, written to its dropped
+// counter by the next flush
+func (l *IngestLimiter) Drop(serviceID string) {
+	l.mu.Lock()
+	n, ok := l.dropped[serviceID]
+	if !ok {
+		n = new(atomic.Int64)
+		l.dropped[serviceID] = n
+	}
+	l.mu.Unlock()
+	n.Add(1)
+}
+
+// flushDropped adds the rejected events counted since the last flush to the
+// services' dropped counters every droppedFlushInterval. Counts that fail
+// to be written are kept for the next flush.
+func (l *IngestLimiter) flushDropped(repo database.ServiceRepository) {
+	ticker := time.NewTicker(droppedFlushInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		counts := make(map[string]*atomic.Int64, len(l.dropped))
+		for id, n := range l.dropped {
+			counts[id] = n
+		}
+		l.mu.Unlock()
+
+		for id, n := range counts {
+			pending := n.Swap(0)
+			if pending == 0 {
+				continue
+			}
+			if err := repo.AddDroppedLogs(context.Background(), id, pending); err != nil {
+				n.Add(pending)
+				log.Printf("Failed to record %d dropped log events for %s: %v", pending, id, err)
+			}
+		}
+	}
+}
+
+// cleanup periodically removes expired windows
+func (l *IngestLimiter) cleanup() {
+	ticker := time.NewTicker(5 * time.Minute)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		l.mu.Lock()
+		now := time.Now()
+		for id, w := range l.windows {
+			if now.Sub(w.start) > 2*time.Minute {
+				delete(l.windows, id)
+			}
+		}
+		l.mu.Unlock()
+	}
+}
@@ -7,10 +7,11 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 )
 
-// Logger returns logger middleware configuration
+// Logger returns logger middleware configuration. Lines end with the
+// request ID, set by RequestID.
 func Logger() fiber.Handler {
 	return logger.New(logger.Config{
-		Format:     "${time} | ${status} | ${latency} | ${method} ${path}\n",
+		Format:     "${time} | ${status} | ${latency} | ${method} ${path} | ${locals:requestid}\n",
 		TimeFormat: time.RFC3339,
 		TimeZone:   "Local",
 	})
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// publicRoutes below /api/v1 are served without resolving a project: they
+// serve anyone or authenticate on their own
+var publicRoutes = []string{"/health", "/version", "/errors", "/status", "/logs/ingest", "/alertmanager/webhook", "/admin"}
+
+// projectRoutes below /api/v1 are those a project token may call: the
+// resources belonging to a project and the preferences of its users
+var projectRoutes = []string{"/services", "/hosts", "/alert-rules", "/alerts", "/notifications", "/preferences"}
+
+// Project returns a middleware resolving the project of an API request,
+// stored in Locals("project"):
+//   - "Authorization: Bearer mtp_..." scopes it to the project of the
+//     token, which may only call projectRoutes
+//   - the admin token, or no token unless security.requireProjectToken is
+//     set, spans every project ("") or the one named by X-Project
+//
+// Locals("admin") is true for requests with the admin token.
+func Project(repo database.ProjectRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		path := strings.TrimPrefix(c.Path(), "/api/v1")
+		if underRoutes(path, publicRoutes) {
+			return c.Next()
+		}
+
+		token, _ := strings.CutPrefix(c.Get("Authorization"), "Bearer ")
+		if strings.HasPrefix(token, models.ProjectTokenPrefix) {
+			project, err := repo.GetByToken(c.UserContext(), token)
+			if err != nil {
+				return apierror.Send(c, 500, apierror.InternalError, "Failed to validate project token")
+			}
+			if project == nil {
+				return apierror.Send(c, 401, apierror.Unauthorized, "Invalid project token")
+			}
+			if !underRoutes(path, projectRoutes) {
+				return apierror.Send(c, 403, apierror.Forbidden, "Project tokens can only use the services, hosts, alert rules, notification channels and preferences of their project")
+			}
+			c.Locals("project", project.ID)
+			return c.Next()
+		}
+
+		var adminToken string
+		var required bool
+		if cfg := config.Get(); cfg != nil {
+			adminToken = cfg.Security.AdminToken
+			required = cfg.Security.RequireProjectToken
+		}
+		admin := adminToken != "" && subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) == 1
+		if required && !admin {
+			return apierror.Send(c, 401, apierror.Unauthorized, "A project token or the admin token is required")
+		}
+		c.Locals("admin", admin)
+
+		projectID := strings.TrimSpace(c.Get("X-Project"))
+		if projectID != "" {
+			project, err := repo.GetByID(c.UserContext(), projectID)
+			if err != nil {
+				return apierror.Send(c, 500, apierror.DatabaseError, err.Error())
+			}
+			if project == nil {
+				return apierror.Send(c, 404, apierror.ProjectNotFound, "Project not found")
+			}
+		}
+		c.Locals("project", projectID)
+		return c.Next()
+	}
+}
+
+// projectNotFound is the answer of InProject per resource kind
+var projectNotFound = map[string]struct {
+	code    apierror.Code
+	message string
+}{
+	models.ProjectService:   {apierror.ServiceNotFound, "Service not found"},
+	models.ProjectHost:      {apierror.HostNotFound, "Host not found"},
+	models.ProjectAlertRule: {apierror.NotFound, "Alert rule not found"},
+	models.ProjectChannel:   {apierror.NotFound, "Channel not found"},
+}
+
+// InProject returns a middleware answering 404 for a resource of another
+// project than that of the request, as if it did not exist. param names
+// the route parameter holding the resource ID; missing resources are left
+// to the handler.
+func InProject(repo database.ProjectRepository, kind, param string) fiber.Handler {
+	notFound := projectNotFound[kind]
+	return func(c *fiber.Ctx) error {
+		projectID, _ := c.Locals("project").(string)
+		if projectID == "" {
+			return c.Next()
+		}
+		owner, err := repo.GetResourceProject(c.UserContext(), kind, c.Params(param))
+		if err != nil {
+			return apierror.Send(c, 500, apierror.DatabaseError, err.Error())
+		}
+		if owner != "" && owner != projectID {
+			return apierror.Send(c, 404, notFound.code, notFound.message)
+		}
+		return c.Next()
+	}
+}
+
+// underRoutes reports whether path is one of routes or below one
+func underRoutes(path string, routes []string) bool {
+	for _, route := range routes {
+		if path == route || strings.HasPrefix(path, route+"/") {
+			return true
+		}
+	}
+	return false
+}
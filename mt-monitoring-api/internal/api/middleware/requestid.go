@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/mt-monitoring/api/internal/api/apierror"
+)
+
+// maxRequestIDLength caps the length of a request ID sent by a client
+const maxRequestIDLength = 128
+
+// RequestID returns middleware giving every request a correlation ID: the
+// X-Request-ID of the client or proxy in front when it is a plain token, a
+// new UUID otherwise. The ID is echoed in the response header, the access
+// log and error bodies.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(fiber.HeaderXRequestID)
+		if !validRequestID(id) {
+			id = utils.UUIDv4()
+		}
+		c.Set(fiber.HeaderXRequestID, id)
+		c.Locals(apierror.RequestIDKey, id)
+		return c.Next()
+	}
+}
+
+// validRequestID reports whether a client request ID is safe to log and
+// echo: letters, digits and - _ . : only
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -5,77 +5,190 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/filesystem"
+	"github.com/gofiber/fiber/v2/middleware/pprof"
 	"github.com/mt-monitoring/api/internal/api/handlers"
 	"github.com/mt-monitoring/api/internal/api/middleware"
+	"github.com/mt-monitoring/api/internal/api/websocket"
+	"github.com/mt-monitoring/api/internal/archive"
+	"github.com/mt-monitoring/api/internal/backup"
 	"github.com/mt-monitoring/api/internal/checker"
 	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/diagnostics"
+	"github.com/mt-monitoring/api/internal/gitops"
+	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/settings"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(app *fiber.App, scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager) {
+func SetupRoutes(app *fiber.App, store *database.Store, scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager, hub *websocket.Hub, backupMgr *backup.Manager, archiveMgr *archive.Manager, settingsMgr *settings.Manager, reconciler *gitops.Reconciler) {
 	// Apply global middleware
-	app.Use(middleware.Recovery())
+	app.Use(middleware.RequestID())
 	app.Use(middleware.Logger())
+	app.Use(middleware.Errors())
+	app.Use(middleware.Recovery())
 	app.Use(middleware.CORS())
+	app.Use(middleware.Compress())
 
-	// API routes
+	// API routes, scoped to the project of the request's token or X-Project
 	api := app.Group("/api/v1")
+	api.Use(middleware.Project(store.Projects))
 
 	// Health endpoints
-	healthHandler := handlers.NewHealthHandler()
+	healthHandler := handlers.NewHealthHandler(store, collectorMgr)
 	api.Get("/health", healthHandler.Health)
 	api.Get("/version", healthHandler.Version)
 
+	// Error code catalog
+	errorHandler := handlers.NewErrorHandler()
+	api.Get("/errors", errorHandler.GetCatalog)
+
 	// Service endpoints
-	serviceHandler := handlers.NewServiceHandler(scheduler)
+	serviceHandler := handlers.NewServiceHandler(store, scheduler, reconciler)
+	managedService := middleware.ManagedByGitOps(reconciler, models.ManagedService, "id")
+	api.Use("/services/:id", middleware.InProject(store.Projects, models.ProjectService, "id"))
 	api.Get("/services", serviceHandler.GetAll)
+	api.Get("/services/stale", serviceHandler.GetStale)
 	api.Get("/services/:id", serviceHandler.GetByID)
 	api.Post("/services", serviceHandler.Create)
-	api.Put("/services/:id", serviceHandler.Update)
-	api.Delete("/services/:id", serviceHandler.Delete)
-	api.Post("/services/:id/pause", serviceHandler.Pause)
-	api.Post("/services/:id/resume", serviceHandler.Resume)
+	api.Patch("/services", serviceHandler.BulkUpdate)
+	api.Put("/services/:id", managedService, serviceHandler.Upsert)
+	api.Patch("/services/:id", managedService, serviceHandler.Update)
+	api.Delete("/services/:id", managedService, serviceHandler.Delete)
+	api.Post("/services/:id/pause", managedService, serviceHandler.Pause)
+	api.Post("/services/:id/resume", managedService, serviceHandler.Resume)
+
+	// Tag endpoints
+	tagHandler := handlers.NewTagHandler(store)
+	api.Get("/tags", tagHandler.GetAll)
+	api.Post("/tags/merge", tagHandler.Merge)
+	api.Put("/tags/:tag", tagHandler.Rename)
+
+	// Pause, maintenance and exclusion windows (excluded from uptime)
+	windowHandler := handlers.NewServiceWindowHandler(store)
+	api.Get("/services/:id/windows", windowHandler.GetByServiceID)
+	api.Post("/services/:id/maintenance", windowHandler.CreateMaintenance)
+	api.Delete("/services/:id/maintenance/:windowId", windowHandler.DeleteMaintenance)
+	api.Post("/services/:id/exclusions", windowHandler.CreateExclusion)
+	api.Delete("/services/:id/exclusions/:windowId", windowHandler.DeleteExclusion)
 
 	// Metric endpoints
-	metricHandler := handlers.NewMetricHandler()
+	metricHandler := handlers.NewMetricHandler(store)
 	api.Get("/services/:id/metrics", metricHandler.GetByServiceID)
 	api.Get("/services/:id/metrics/summary", metricHandler.GetSummary)
+	api.Get("/services/:id/metrics/histogram", metricHandler.GetHistogram)
+	api.Get("/services/:id/metrics/:metricId", metricHandler.GetByID)
 	api.Get("/services/:id/uptime", metricHandler.GetUptime)
+	api.Get("/services/:id/timeline", metricHandler.GetTimeline)
 
 	// Log endpoints
-	logHandler := handlers.NewLogHandler()
+	logHandler := handlers.NewLogHandler(store, hub)
 	api.Get("/logs", logHandler.GetAll)
+	api.Get("/logs/stream", logHandler.Stream)
 	api.Get("/services/:id/logs", logHandler.GetByServiceID)
+	api.Get("/services/:id/logs/stream", logHandler.Stream)
 
 	// Dashboard endpoints
-	dashboardHandler := handlers.NewDashboardHandler()
+	dashboardHandler := handlers.NewDashboardHandler(store)
 	api.Get("/dashboard/summary", dashboardHandler.GetSummary)
 	api.Get("/dashboard/timeline", dashboardHandler.GetTimeline)
 
+	// Preferences per X-User (dashboard pins, ordering, saved views, personal alert channels)
+	preferenceHandler := handlers.NewPreferenceHandler(store)
+	api.Get("/preferences", preferenceHandler.Get)
+	api.Put("/preferences", preferenceHandler.Save)
+	api.Get("/preferences/views", preferenceHandler.GetViews)
+	api.Post("/preferences/views", preferenceHandler.CreateView)
+	api.Put("/preferences/views/:id", preferenceHandler.UpdateView)
+	api.Delete("/preferences/views/:id", preferenceHandler.DeleteView)
+	api.Get("/preferences/notifications", preferenceHandler.GetNotifications)
+	api.Put("/preferences/notifications", preferenceHandler.SaveNotifications)
+	api.Delete("/preferences/notifications", preferenceHandler.DeleteNotifications)
+
+	// Grafana JSON datasource
+	grafanaHandler := handlers.NewGrafanaHandler(store)
+	api.Get("/grafana", grafanaHandler.Test)
+	api.Post("/grafana/search", grafanaHandler.Search)
+	api.Post("/grafana/metrics", grafanaHandler.Metrics)
+	api.Post("/grafana/query", grafanaHandler.Query)
+	api.Post("/grafana/annotations", grafanaHandler.Annotations)
+
 	// Incidents
-	incidentHandler := handlers.NewIncidentHandler()
+	incidentHandler := handlers.NewIncidentHandler(store)
 	api.Get("/incidents", incidentHandler.GetAll)
 	api.Get("/incidents/active", incidentHandler.GetActive)
+	api.Get("/incidents/report", incidentHandler.GetPostmortemReport)
+	api.Get("/incidents/:id", incidentHandler.GetByID)
+	api.Put("/incidents/:id/postmortem", incidentHandler.SavePostmortem)
+	api.Delete("/incidents/:id/postmortem", incidentHandler.DeletePostmortem)
+
+	// Status page (public page, feeds and unsubscribe links, admin components,
+	// subscribers, maintenances and incident updates)
+	statusPageHandler := handlers.NewStatusPageHandler(store)
+	api.Get("/status", statusPageHandler.GetPage)
+	api.Get("/status/feed.rss", statusPageHandler.RSS)
+	api.Get("/status/feed.atom", statusPageHandler.Atom)
+	api.Get("/status/calendar.ics", statusPageHandler.Calendar)
+	api.Get("/status/unsubscribe/:token", statusPageHandler.Unsubscribe)
+	api.Get("/status-page/components", statusPageHandler.GetComponents)
+	api.Post("/status-page/components", statusPageHandler.CreateComponent)
+	api.Put("/status-page/components/:id", statusPageHandler.UpdateComponent)
+	api.Delete("/status-page/components/:id", statusPageHandler.DeleteComponent)
+	api.Put("/status-page/components/:id/override", statusPageHandler.SetOverride)
+	api.Delete("/status-page/components/:id/override", statusPageHandler.ClearOverride)
+	api.Get("/status-page/subscribers", statusPageHandler.GetSubscribers)
+	api.Post("/status-page/subscribers", statusPageHandler.CreateSubscriber)
+	api.Delete("/status-page/subscribers/:id", statusPageHandler.DeleteSubscriber)
+	api.Get("/status-page/maintenances", statusPageHandler.GetMaintenances)
+	api.Post("/status-page/maintenances", statusPageHandler.CreateMaintenance)
+	api.Put("/status-page/maintenances/:id", statusPageHandler.UpdateMaintenance)
+	api.Delete("/status-page/maintenances/:id", statusPageHandler.DeleteMaintenance)
+	api.Post("/status-page/maintenances/:id/complete", statusPageHandler.CompleteMaintenance)
+	api.Get("/incidents/:id/updates", statusPageHandler.GetIncidentUpdates)
+	api.Post("/incidents/:id/updates", statusPageHandler.CreateIncidentUpdate)
+
+	// Prometheus Alertmanager receiver (alerts.alertmanager.token)
+	alertmanagerHandler := handlers.NewAlertmanagerHandler(store)
+	api.Post("/alertmanager/webhook", middleware.AlertmanagerAuth(), alertmanagerHandler.Webhook)
+	api.Get("/alertmanager/alerts", alertmanagerHandler.GetAlerts)
+
+	// Ad-hoc probe (blackbox_exporter-style, nothing is stored)
+	probeHandler := handlers.NewProbeHandler()
+	api.Get("/probe", probeHandler.Probe)
 
 	// Host endpoints
-	hostHandler := handlers.NewHostHandler(collectorMgr)
+	hostHandler := handlers.NewHostHandler(store, collectorMgr)
+	managedHost := middleware.ManagedByGitOps(reconciler, models.ManagedHost, "hostId")
+	api.Use("/hosts/:hostId", middleware.InProject(store.Projects, models.ProjectHost, "hostId"))
 	api.Get("/hosts", hostHandler.GetAll)
 	api.Get("/hosts/:hostId", hostHandler.GetByID)
 	api.Post("/hosts", hostHandler.Create)
-	api.Put("/hosts/:hostId", hostHandler.Update)
-	api.Delete("/hosts/:hostId", hostHandler.Delete)
-	api.Post("/hosts/:hostId/pause", hostHandler.Pause)
-	api.Post("/hosts/:hostId/resume", hostHandler.Resume)
+	api.Put("/hosts/:hostId", managedHost, hostHandler.Upsert)
+	api.Patch("/hosts/:hostId", managedHost, hostHandler.Update)
+	api.Delete("/hosts/:hostId", managedHost, hostHandler.Delete)
+	api.Post("/hosts/:hostId/pause", managedHost, hostHandler.Pause)
+	api.Post("/hosts/:hostId/resume", managedHost, hostHandler.Resume)
 
-	// SSH connection test
+	// SSH connection test and the reviewed SSH command set
 	sshTestHandler := handlers.NewSSHTestHandler()
 	api.Post("/hosts/test-connection", sshTestHandler.TestConnection)
+	api.Get("/system/ssh/commands", sshTestHandler.GetCommands)
+	api.Get("/system/ssh/script", sshTestHandler.GetScript)
 
 	// Host-scoped system resource monitoring
-	systemHandler := handlers.NewSystemHandler(collectorMgr)
+	systemHandler := handlers.NewSystemHandler(store, collectorMgr)
 	api.Get("/hosts/:hostId/system/info", systemHandler.GetInfo)
 	api.Get("/hosts/:hostId/system/metrics", systemHandler.GetMetricsHistory)
+	api.Get("/hosts/:hostId/system/reachability", systemHandler.GetReachability)
 	api.Get("/hosts/:hostId/system/processes", systemHandler.GetProcesses)
+	api.Get("/hosts/:hostId/system/processes/history", systemHandler.GetProcessHistory)
+	api.Get("/hosts/:hostId/facts", systemHandler.GetFacts)
+	api.Get("/hosts/:hostId/updates", systemHandler.GetUpdates)
+	api.Get("/hosts/:hostId/ports", systemHandler.GetPorts)
+	api.Put("/hosts/:hostId/ports/expected", systemHandler.SetExpectedPorts)
+	api.Get("/hosts/:hostId/ports/history", systemHandler.GetPortHistory)
+	api.Get("/hosts/:hostId/service-suggestions", serviceHandler.GetSuggestions)
+	api.Post("/hosts/:hostId/service-suggestions", serviceHandler.ApproveSuggestions)
 
 	// Legacy system endpoints (backward compatibility — defaults to local host)
 	api.Get("/system/info", systemHandler.GetInfo)
@@ -83,43 +196,108 @@ func SetupRoutes(app *fiber.App, scheduler *checker.Scheduler, collectorMgr *col
 	api.Get("/system/processes", systemHandler.GetProcesses)
 
 	// Notifications
-	notificationHandler := handlers.NewNotificationHandler()
+	notificationHandler := handlers.NewNotificationHandler(store)
+	api.Use("/notifications/:id", middleware.InProject(store.Projects, models.ProjectChannel, "id"))
 	api.Get("/notifications", notificationHandler.GetAll)
 	api.Post("/notifications", notificationHandler.Create)
+	api.Get("/notifications/languages", notificationHandler.Languages)
 	api.Put("/notifications/:id", notificationHandler.Update)
 	api.Post("/notifications/:id/test", notificationHandler.Test)
 	api.Post("/notifications/:id/toggle", notificationHandler.Toggle)
 	api.Delete("/notifications/:id", notificationHandler.Delete)
 
 	// Alert Rules
-	alertRuleHandler := handlers.NewAlertRuleHandler()
+	alertRuleHandler := handlers.NewAlertRuleHandler(store)
+	managedAlertRule := middleware.ManagedByGitOps(reconciler, models.ManagedAlertRule, "id")
+	api.Use("/alert-rules/:id", middleware.InProject(store.Projects, models.ProjectAlertRule, "id"))
 	api.Get("/alert-rules", alertRuleHandler.GetAll)
+	api.Get("/alert-rules/states", alertRuleHandler.GetStates)
 	api.Get("/alert-rules/:id", alertRuleHandler.GetByID)
+	api.Get("/alert-rules/:id/remediations", alertRuleHandler.GetRemediations)
 	api.Post("/alert-rules", alertRuleHandler.Create)
-	api.Put("/alert-rules/:id", alertRuleHandler.Update)
-	api.Delete("/alert-rules/:id", alertRuleHandler.Delete)
-	api.Post("/alert-rules/:id/toggle", alertRuleHandler.Toggle)
+	api.Put("/alert-rules/:id", managedAlertRule, alertRuleHandler.Update)
+	api.Delete("/alert-rules/:id", managedAlertRule, alertRuleHandler.Delete)
+	api.Post("/alert-rules/:id/toggle", managedAlertRule, alertRuleHandler.Toggle)
+	api.Get("/alerts/active", alertRuleHandler.GetActive)
 
 	// Settings
-	settingsHandler := handlers.NewSettingsHandler()
+	settingsHandler := handlers.NewSettingsHandler(settingsMgr)
 	api.Get("/settings", settingsHandler.Get)
+	api.Get("/settings/runtime", settingsHandler.GetRuntime)
 	api.Put("/settings", settingsHandler.Update)
 
 	// Notification History
-	notificationHistoryHandler := handlers.NewNotificationHistoryHandler()
+	notificationHistoryHandler := handlers.NewNotificationHistoryHandler(store)
 	api.Get("/notification-history", notificationHistoryHandler.GetAll)
 	api.Get("/notification-history/stats", notificationHistoryHandler.GetStats)
+	api.Get("/notification-history/failures", notificationHistoryHandler.GetFailures)
 	api.Get("/notification-history/:id", notificationHistoryHandler.GetByID)
 	api.Delete("/notification-history/cleanup", notificationHistoryHandler.Cleanup)
 
+	// Archived incidents and notification history (read-only)
+	archiveHandler := handlers.NewArchiveHandler(archiveMgr)
+	api.Get("/archives", archiveHandler.GetMonths)
+	api.Get("/archives/:month/:kind", archiveHandler.GetRecords)
+
+	// Admin (security.adminToken)
+	admin := api.Group("/admin", middleware.AdminAuth())
+	admin.Post("/config/validate", settingsHandler.ValidateConfig)
+
+	backupHandler := handlers.NewBackupHandler(backupMgr)
+	admin.Post("/backup", backupHandler.Create)
+
+	databaseHandler := handlers.NewDatabaseHandler(store)
+	admin.Get("/database", databaseHandler.Stats)
+	admin.Get("/database/integrity", databaseHandler.Integrity)
+	admin.Post("/database/integrity/repair", databaseHandler.RepairIntegrity)
+
+	// Support bundle with profiles and config
+	diagnosticsHandler := handlers.NewDiagnosticsHandler(diagnostics.NewCollector(store, scheduler, collectorMgr))
+	admin.Get("/diagnostics", diagnosticsHandler.Bundle)
+
+	encryptionHandler := handlers.NewEncryptionHandler(store)
+	admin.Post("/encryption/rotate", encryptionHandler.RotateKey)
+
+	gitopsHandler := handlers.NewGitOpsHandler(reconciler)
+	admin.Get("/gitops", gitopsHandler.Status)
+	admin.Post("/gitops/reconcile", gitopsHandler.Reconcile)
+
+	clusterHandler := handlers.NewClusterHandler()
+	admin.Get("/cluster", clusterHandler.Status)
+
+	// Projects and their tokens
+	projectHandler := handlers.NewProjectHandler(store)
+	projects := admin.Group("/projects")
+	projects.Get("", projectHandler.GetAll)
+	projects.Post("", projectHandler.Create)
+	projects.Get("/:id", projectHandler.GetByID)
+	projects.Put("/:id", projectHandler.Update)
+	projects.Delete("/:id", projectHandler.Delete)
+	projects.Post("/:id/regenerate-token", projectHandler.RegenerateToken)
+
+	// Profiling and runtime counters
+	debugHandler := handlers.NewDebugHandler(scheduler, collectorMgr, hub)
+	debug := admin.Group("/debug")
+	debug.Get("/vars", debugHandler.Vars)
+	debug.Use(pprof.New(pprof.Config{Prefix: "/api/v1/admin"}))
+
 	// Service API Key management
 	api.Post("/services/:id/regenerate-key", serviceHandler.RegenerateKey)
 
 	// Log Ingestion (API Key auth)
-	logIngestHandler := handlers.NewLogIngestHandler()
-	ingest := api.Group("/logs", middleware.ApiKeyAuth())
+	logIngestHandler := handlers.NewLogIngestHandler(store)
+	ingest := api.Group("/logs", middleware.ApiKeyAuth(store.Services))
 	ingest.Post("/ingest", logIngestHandler.Ingest)
 
+	// Heartbeat pings (healthchecks.io-compatible, outside /api/v1)
+	pingHandler := handlers.NewPingHandler(store, scheduler)
+	app.Get("/ping/:key/:action?", pingHandler.Ping)
+	app.Post("/ping/:key/:action?", pingHandler.Ping)
+
+	// Real-time updates
+	app.Use("/ws", websocket.WebSocketUpgrade())
+	app.Get("/ws", hub.Handler())
+
 	// Serve static files for frontend (if exists)
 	app.Use("/", filesystem.New(filesystem.Config{
 		Root:         http.Dir("./web"),
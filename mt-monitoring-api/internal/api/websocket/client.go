@@ -0,0 +1,127 @@
+package websocket
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/gofiber/websocket/v2"
+)
+
+const (
+	// sendBufferSize is how many messages may queue for a client before the
+	// hub evicts it as too slow
+	sendBufferSize = 256
+
+	// writeWait bounds a single write, so a stalled connection fails
+	// instead of blocking its writer
+	writeWait = 10 * time.Second
+
+	pingInterval = 30 * time.Second
+)
+
+// Client represents a WebSocket client
+type Client struct {
+	conn *websocket.Conn
+
+	// send is the client's queue, written by the hub without blocking and
+	// drained by writePump. The hub closes it when it removes the client.
+	send chan []byte
+
+	// logFilter is set when the client subscribes to the live log tail
+	logFilter *LogFilter
+
+	// since is the last sequence number the client saw before reconnecting,
+	// -1 when it did not ask for a replay
+	since int64
+
+	// binary is set when the client negotiated MessagePack
+	binary bool
+
+	// metrics batches system_metric events, guarded by the hub's mutex
+	metrics *metricBatch
+
+	// evicted is set when the hub removed the client for a full queue
+	evicted atomic.Bool
+}
+
+func newClient(conn *websocket.Conn, since int64, metricInterval time.Duration) *Client {
+	size := sendBufferSize
+	if since >= 0 {
+		// Room for the replay on top of the usual buffer
+		size += replayBufferSize + 1
+	}
+	return &Client{
+		conn:    conn,
+		send:    make(chan []byte, size),
+		since:   since,
+		metrics: newMetricBatch(metricInterval),
+	}
+}
+
+// enqueue queues a message without blocking and reports whether it fit.
+// Called with the hub's mutex held.
+func (c *Client) enqueue(message []byte) bool {
+	select {
+	case c.send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// remoteAddr returns the client's address for logging
+func (c *Client) remoteAddr() string {
+	if c.conn == nil || c.conn.Conn == nil {
+		return "unknown"
+	}
+	return c.conn.RemoteAddr().String()
+}
+
+// writePump writes queued messages and keepalive pings until the hub
+// closes the queue or a write fails. An evicted client gets a close frame
+// telling it to reconnect instead of the rest of its queue.
+func (c *Client) writePump() {
+	ticker := time.NewTicker(pingInterval)
+	defer func() {
+		ticker.Stop()
+		c.conn.Close()
+	}()
+
+	for {
+		select {
+		case message, ok := <-c.send:
+			if c.evicted.Load() {
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "send queue full"),
+					time.Now().Add(writeWait))
+				return
+			}
+			if !ok {
+				c.conn.WriteControl(websocket.CloseMessage,
+					websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
+					time.Now().Add(writeWait))
+				return
+			}
+			msgType := websocket.TextMessage
+			if c.binary {
+				packed, err := toMsgpack(message)
+				if err != nil {
+					log.Printf("Failed to encode WebSocket message as msgpack: %v", err)
+					continue
+				}
+				msgType, message = websocket.BinaryMessage, packed
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(msgType, message); err != nil {
+				return
+			}
+		case <-ticker.C:
+			// Send ping to keep connection alive
+			c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// SubscribeEvents forwards check results, incidents, host metrics and log
+// entries from the event bus to connected clients until unsubscribe is
+// called. The server entry point calls it once after creating the hub.
+func (h *Hub) SubscribeEvents() (unsubscribe func()) {
+	unsubscribes := []func(){
+		events.CheckCompleted.Subscribe(func(e events.Check) {
+			h.Broadcast(map[string]interface{}{
+				"type": "metric",
+				"data": map[string]interface{}{
+					"serviceId":    e.Service.ID,
+					"status":       string(e.Status),
+					"responseTime": e.Metric.ResponseTime,
+					"checkedAt":    e.Metric.CheckedAt,
+				},
+			})
+		}),
+		events.IncidentCreated.Subscribe(func(incident *models.Incident) {
+			h.Broadcast(map[string]interface{}{
+				"type": "incident",
+				"data": incident,
+			})
+		}),
+		events.MetricCollected.Subscribe(func(e events.SystemMetric) {
+			h.Broadcast(systemMetricMessage(e.HostID, e.Metric))
+		}),
+		events.LogWritten.Subscribe(h.PublishLog),
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// systemMetricMessage is the system_metric broadcast of a host snapshot
+func systemMetricMessage(hostID string, m *models.SystemMetric) map[string]interface{} {
+	return map[string]interface{}{
+		"type":   "system_metric",
+		"hostId": hostID,
+		"data": map[string]interface{}{
+			"cpu": m.CPUUsage,
+			"memory": map[string]interface{}{
+				"total": m.MemTotal,
+				"used":  m.MemUsed,
+				"usage": m.MemUsage,
+			},
+			"disk": map[string]interface{}{
+				"total":      m.DiskTotal,
+				"used":       m.DiskUsed,
+				"usage":      m.DiskUsage,
+				"readSpeed":  m.DiskRead,
+				"writeSpeed": m.DiskWrite,
+			},
+			"timestamp": m.CreatedAt.Format(time.RFC3339),
+		},
+	}
+}
@@ -3,47 +3,91 @@ package websocket
 import (
 	"encoding/json"
 	"log"
+	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/websocket/v2"
 )
 
-// Client represents a WebSocket client
-type Client struct {
-	conn *websocket.Conn
-	send chan []byte
-}
-
 // Hub maintains the set of active clients and broadcasts messages
 type Hub struct {
 	clients    map[*Client]bool
 	broadcast  chan []byte
+	metrics    chan metricEvent
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
+
+	// Log tail subscribers that are not WebSocket clients (SSE)
+	logSubscribers map[*LogSubscriber]bool
+
+	// Latest broadcasts with their sequence numbers, for replay
+	replay *replayBuffer
+
+	// Counters for the debug endpoints
+	broadcasts        atomic.Int64
+	droppedBroadcasts atomic.Int64 // broadcast channel full
+	evictedClients    atomic.Int64 // send queue full during a broadcast
+	droppedMessages   atomic.Int64 // messages lost to evictions and full queues
+	droppedLogs       atomic.Int64 // log tail messages dropped for slow receivers
+	replays           atomic.Int64 // reconnects that asked for a replay
+	incompleteReplays atomic.Int64 // replays missing evicted messages
+	metricBatches     atomic.Int64 // system_metrics messages sent
+	coalescedMetrics  atomic.Int64 // system_metric events replaced before their batch
+}
+
+// HubStats are counters since the hub started
+type HubStats struct {
+	Clients           int   `json:"clients"`
+	MsgpackClients    int   `json:"msgpackClients"`
+	LogSubscribers    int   `json:"logSubscribers"`
+	QueuedBroadcasts  int   `json:"queuedBroadcasts"`
+	Broadcasts        int64 `json:"broadcasts"`
+	DroppedBroadcasts int64 `json:"droppedBroadcasts"`
+	EvictedClients    int64 `json:"evictedClients"`
+	DroppedMessages   int64 `json:"droppedMessages"`
+	LongestQueue      int   `json:"longestQueue"`
+	DroppedLogs       int64 `json:"droppedLogs"`
+	Seq               int64 `json:"seq"`
+	ReplayBuffered    int   `json:"replayBuffered"`
+	Replays           int64 `json:"replays"`
+	IncompleteReplays int64 `json:"incompleteReplays"`
+	MetricBatches     int64 `json:"metricBatches"`
+	CoalescedMetrics  int64 `json:"coalescedMetrics"`
 }
 
 // NewHub creates a new WebSocket hub
 func NewHub() *Hub {
 	return &Hub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
+		clients:        make(map[*Client]bool),
+		broadcast:      make(chan []byte, 256),
+		metrics:        make(chan metricEvent, 256),
+		register:       make(chan *Client),
+		unregister:     make(chan *Client),
+		logSubscribers: make(map[*LogSubscriber]bool),
+		replay:         newReplayBuffer(replayBufferSize),
 	}
 }
 
 // Run starts the hub's main loop
 func (h *Hub) Run() {
+	ticker := time.NewTicker(metricFlushTick)
+	defer ticker.Stop()
+
 	for {
 		select {
 		case client := <-h.register:
 			h.mu.Lock()
+			if client.since >= 0 {
+				h.replayTo(client)
+			}
 			h.clients[client] = true
+			total := len(h.clients)
 			h.mu.Unlock()
-			log.Printf("WebSocket client connected. Total: %d", len(h.clients))
+			log.Printf("WebSocket client connected. Total: %d", total)
 
 		case client := <-h.unregister:
 			h.mu.Lock()
@@ -51,25 +95,72 @@ func (h *Hub) Run() {
 				delete(h.clients, client)
 				close(client.send)
 			}
+			total := len(h.clients)
 			h.mu.Unlock()
-			log.Printf("WebSocket client disconnected. Total: %d", len(h.clients))
+			log.Printf("WebSocket client disconnected. Total: %d", total)
 
 		case message := <-h.broadcast:
-			h.mu.RLock()
+			h.mu.Lock()
+			message = withSeq(h.replay.last+1, message)
+			h.replay.add(message)
 			for client := range h.clients {
-				select {
-				case client.send <- message:
-				default:
-					close(client.send)
-					delete(h.clients, client)
+				if !client.enqueue(message) {
+					h.evict(client)
 				}
 			}
-			h.mu.RUnlock()
+			h.mu.Unlock()
+
+		case event := <-h.metrics:
+			h.queueMetric(event)
+
+		case now := <-ticker.C:
+			h.flushMetrics(now)
 		}
 	}
 }
 
-// Broadcast sends a message to all connected clients
+// evict removes a client whose send queue is full. Its writer sends a close
+// frame (1013, try again later) rather than the stale queue, so the client
+// reconnects and replays what it missed. Called with h.mu held for writing.
+func (h *Hub) evict(client *Client) {
+	client.evicted.Store(true)
+	delete(h.clients, client)
+	close(client.send)
+	h.evictedClients.Add(1)
+	// The queued messages and the one that did not fit
+	h.droppedMessages.Add(int64(len(client.send)) + 1)
+	log.Printf("Evicting slow WebSocket client %s: send queue full (%d messages)", client.remoteAddr(), cap(client.send))
+}
+
+// replayTo queues the broadcasts a reconnecting client missed, preceded by
+// a "replay" message saying whether any were lost. Called with h.mu held,
+// before the client receives live broadcasts.
+func (h *Hub) replayTo(client *Client) {
+	missed, complete := h.replay.since(client.since)
+	h.replays.Add(1)
+	if !complete {
+		h.incompleteReplays.Add(1)
+	}
+
+	header, _ := json.Marshal(map[string]interface{}{
+		"type": "replay",
+		"data": map[string]interface{}{
+			"since":    client.since,
+			"seq":      h.replay.last,
+			"replayed": len(missed),
+			"complete": complete,
+		},
+	})
+	// The send buffer has room for a full replay
+	client.send <- header
+	for _, message := range missed {
+		client.send <- message
+	}
+}
+
+// Broadcast sends a message to all connected clients. The hub numbers
+// broadcasts in the order it delivers them. system_metric events are not
+// numbered; each client receives them batched per interval instead.
 func (h *Hub) Broadcast(data interface{}) {
 	message, err := json.Marshal(data)
 	if err != nil {
@@ -77,9 +168,23 @@ func (h *Hub) Broadcast(data interface{}) {
 		return
 	}
 
+	if m, ok := data.(map[string]interface{}); ok && m["type"] == "system_metric" {
+		hostID, _ := m["hostId"].(string)
+		select {
+		case h.metrics <- metricEvent{hostID: hostID, message: message}:
+			h.broadcasts.Add(1)
+		default:
+			h.droppedBroadcasts.Add(1)
+			log.Println("Metric channel full, dropping message")
+		}
+		return
+	}
+
 	select {
 	case h.broadcast <- message:
+		h.broadcasts.Add(1)
 	default:
+		h.droppedBroadcasts.Add(1)
 		log.Println("Broadcast channel full, dropping message")
 	}
 }
@@ -96,6 +201,37 @@ func (h *Hub) ClientCount() int {
 	return len(h.clients)
 }
 
+// Stats returns client counts and message counters
+func (h *Hub) Stats() HubStats {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	longest, msgpack := 0, 0
+	for client := range h.clients {
+		longest = max(longest, len(client.send))
+		if client.binary {
+			msgpack++
+		}
+	}
+	return HubStats{
+		Clients:           len(h.clients),
+		MsgpackClients:    msgpack,
+		LogSubscribers:    len(h.logSubscribers),
+		QueuedBroadcasts:  len(h.broadcast) + len(h.metrics),
+		Broadcasts:        h.broadcasts.Load(),
+		DroppedBroadcasts: h.droppedBroadcasts.Load(),
+		EvictedClients:    h.evictedClients.Load(),
+		DroppedMessages:   h.droppedMessages.Load(),
+		LongestQueue:      longest,
+		DroppedLogs:       h.droppedLogs.Load(),
+		Seq:               h.replay.last,
+		ReplayBuffered:    h.replay.count,
+		Replays:           h.replays.Load(),
+		IncompleteReplays: h.incompleteReplays.Load(),
+		MetricBatches:     h.metricBatches.Load(),
+		CoalescedMetrics:  h.coalescedMetrics.Load(),
+	}
+}
+
 // WebSocketUpgrade returns middleware to check if request can be upgraded
 func WebSocketUpgrade() fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -107,48 +243,47 @@ func WebSocketUpgrade() fiber.Handler {
 	}
 }
 
-// Handler returns the WebSocket handler
+// Handler returns the WebSocket handler. A reconnecting client passes the
+// last sequence number it saw as ?since= to receive the broadcasts it
+// missed before live ones, and ?metricInterval= (seconds) to receive
+// system metric batches less often. Clients requesting the
+// mt-monitoring.msgpack subprotocol receive MessagePack binary frames.
 func (h *Hub) Handler() fiber.Handler {
 	return websocket.New(func(c *websocket.Conn) {
-		client := &Client{
-			conn: c,
-			send: make(chan []byte, 256),
+		since := int64(-1)
+		if n, err := strconv.ParseInt(c.Query("since"), 10, 64); err == nil && n >= 0 {
+			since = n
+		}
+		interval := defaultMetricInterval
+		if n, err := strconv.Atoi(c.Query("metricInterval")); err == nil {
+			if d, ok := parseMetricInterval(n); ok {
+				interval = d
+			}
 		}
+		client := newClient(c, since, interval)
+		client.binary = c.Subprotocol() == msgpackProtocol
 
 		h.register <- client
 
-		// Start writer goroutine
+		written := make(chan struct{})
 		go func() {
-			ticker := time.NewTicker(30 * time.Second)
-			defer ticker.Stop()
-
-			for {
-				select {
-				case message, ok := <-client.send:
-					if !ok {
-						c.WriteMessage(websocket.CloseMessage, []byte{})
-						return
-					}
-					if err := c.WriteMessage(websocket.TextMessage, message); err != nil {
-						return
-					}
-				case <-ticker.C:
-					// Send ping to keep connection alive
-					if err := c.WriteMessage(websocket.PingMessage, nil); err != nil {
-						return
-					}
-				}
-			}
+			client.writePump()
+			close(written)
 		}()
 
-		// Read messages (mainly for keepalive pong responses)
+		// Read messages (keepalive pong responses and log tail control messages)
 		for {
-			_, _, err := c.ReadMessage()
+			msgType, data, err := c.ReadMessage()
 			if err != nil {
 				break
 			}
+			if msgType == websocket.TextMessage {
+				h.handleClientMessage(client, data)
+			}
 		}
 
 		h.unregister <- client
-	})
+		// The connection is released when the handler returns
+		<-written
+	}, websocket.Config{Subprotocols: []string{msgpackProtocol, jsonProtocol}})
 }
@@ -0,0 +1,194 @@
+package websocket
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/api/apierror"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// LogFilter narrows a live log tail to a service, a minimum level and an
+// optional message pattern. A zero-value filter matches every log entry.
+type LogFilter struct {
+	ServiceID string
+	MinLevel  models.LogLevel
+	Pattern   *regexp.Regexp
+}
+
+// NewLogFilter builds a LogFilter from raw request values.
+// Returns the invalid field if the level is unknown or the pattern does not compile.
+func NewLogFilter(serviceID, level, pattern string) (*LogFilter, *apierror.FieldError) {
+	f := &LogFilter{ServiceID: serviceID}
+
+	if level != "" {
+		lvl := models.LogLevel(level)
+		if !lvl.IsValid() {
+			return nil, apierror.Invalid("level", "level must be one of: error, warn, info")
+		}
+		f.MinLevel = lvl
+	}
+
+	if pattern != "" {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, apierror.Invalid("pattern", "invalid pattern: "+err.Error())
+		}
+		f.Pattern = re
+	}
+
+	return f, nil
+}
+
+// Matches reports whether the log entry passes the filter.
+func (f *LogFilter) Matches(l *models.Log) bool {
+	if f.ServiceID != "" && l.ServiceID != f.ServiceID {
+		return false
+	}
+	if f.MinLevel != "" && l.Level.Rank() < f.MinLevel.Rank() {
+		return false
+	}
+	if f.Pattern != nil && !f.Pattern.MatchString(l.Message) {
+		return false
+	}
+	return true
+}
+
+// LogSubscriber receives matching log entries outside of a WebSocket
+// connection (e.g. Server-Sent Events). Messages are dropped when C is full.
+type LogSubscriber struct {
+	filter *LogFilter
+	C      chan []byte
+}
+
+// controlMessage is a client → server message controlling the log tail or
+// the system metric batch interval.
+type controlMessage struct {
+	Type      string `json:"type"` // "subscribe_logs" | "unsubscribe_logs" | "set_metric_interval"
+	ServiceID string `json:"serviceId"`
+	Level     string `json:"level"`
+	Pattern   string `json:"pattern"`
+	Interval  int    `json:"interval"` // seconds
+}
+
+// SubscribeLogs registers a subscriber that receives every published log
+// matching the filter. Call UnsubscribeLogs when done.
+func (h *Hub) SubscribeLogs(filter *LogFilter) *LogSubscriber {
+	sub := &LogSubscriber{
+		filter: filter,
+		C:      make(chan []byte, 64),
+	}
+
+	h.mu.Lock()
+	h.logSubscribers[sub] = true
+	h.mu.Unlock()
+
+	return sub
+}
+
+// UnsubscribeLogs removes a subscriber registered with SubscribeLogs.
+func (h *Hub) UnsubscribeLogs(sub *LogSubscriber) {
+	h.mu.Lock()
+	delete(h.logSubscribers, sub)
+	h.mu.Unlock()
+}
+
+// PublishLog pushes a newly stored log entry to every WebSocket client and
+// subscriber whose log filter matches it.
+func (h *Hub) PublishLog(l *models.Log) {
+	message, err := json.Marshal(map[string]interface{}{
+		"type": "log",
+		"data": l,
+	})
+	if err != nil {
+		log.Printf("Failed to marshal log message: %v", err)
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for client := range h.clients {
+		if client.logFilter == nil || !client.logFilter.Matches(l) {
+			continue
+		}
+		if !client.enqueue(message) {
+			// Slow client — drop rather than block the ingestion path
+			h.droppedLogs.Add(1)
+		}
+	}
+
+	for sub := range h.logSubscribers {
+		if !sub.filter.Matches(l) {
+			continue
+		}
+		select {
+		case sub.C <- message:
+		default:
+			h.droppedLogs.Add(1)
+		}
+	}
+}
+
+// handleClientMessage processes a control message sent by a WebSocket client.
+func (h *Hub) handleClientMessage(client *Client, data []byte) {
+	var msg controlMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		return
+	}
+
+	switch msg.Type {
+	case "subscribe_logs":
+		filter, invalid := NewLogFilter(msg.ServiceID, msg.Level, msg.Pattern)
+		if invalid != nil {
+			h.sendToClient(client, map[string]interface{}{
+				"type":    "error",
+				"message": invalid.Message,
+			})
+			return
+		}
+		h.mu.Lock()
+		client.logFilter = filter
+		h.mu.Unlock()
+
+	case "unsubscribe_logs":
+		h.mu.Lock()
+		client.logFilter = nil
+		h.mu.Unlock()
+
+	case "set_metric_interval":
+		interval, ok := parseMetricInterval(msg.Interval)
+		if !ok {
+			h.sendToClient(client, map[string]interface{}{
+				"type": "error",
+				"message": fmt.Sprintf("interval must be between %d and %d seconds",
+					int(defaultMetricInterval.Seconds()), int(maxMetricInterval.Seconds())),
+			})
+			return
+		}
+		h.mu.Lock()
+		client.metrics.setInterval(interval, time.Now())
+		h.mu.Unlock()
+	}
+}
+
+// sendToClient queues a message for a single client.
+func (h *Hub) sendToClient(client *Client, data interface{}) {
+	message, err := json.Marshal(data)
+	if err != nil {
+		return
+	}
+
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if _, ok := h.clients[client]; !ok {
+		return
+	}
+	if !client.enqueue(message) {
+		h.droppedMessages.Add(1)
+	}
+}
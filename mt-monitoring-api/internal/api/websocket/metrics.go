@@ -0,0 +1,123 @@
+package websocket
+
+import (
+	"bytes"
+	"sort"
+	"time"
+)
+
+const (
+	// defaultMetricInterval is how often a client receives the system
+	// metrics collected since its last batch. Clients may ask for a longer
+	// interval, up to maxMetricInterval.
+	defaultMetricInterval = 5 * time.Second
+	maxMetricInterval     = 5 * time.Minute
+
+	metricFlushTick = time.Second
+)
+
+// metricEvent is a system_metric broadcast of a host
+type metricEvent struct {
+	hostID  string
+	message []byte
+}
+
+// metricBatch holds the latest system_metric event of each host until the
+// client's next batch. Guarded by the hub's mutex.
+type metricBatch struct {
+	interval time.Duration
+	due      time.Time
+	pending  map[string][]byte // host ID → latest event
+}
+
+func newMetricBatch(interval time.Duration) *metricBatch {
+	return &metricBatch{
+		interval: interval,
+		due:      time.Now().Add(interval),
+		pending:  make(map[string][]byte),
+	}
+}
+
+// add keeps the event, reporting whether it replaced an unsent one
+func (b *metricBatch) add(event metricEvent) bool {
+	_, replaced := b.pending[event.hostID]
+	b.pending[event.hostID] = event.message
+	return replaced
+}
+
+// flush returns the pending events as one "system_metrics" message, nil if
+// the batch is not due or empty
+func (b *metricBatch) flush(now time.Time) []byte {
+	if now.Before(b.due) {
+		return nil
+	}
+	b.due = now.Add(b.interval)
+	if len(b.pending) == 0 {
+		return nil
+	}
+
+	hosts := make([]string, 0, len(b.pending))
+	for hostID := range b.pending {
+		hosts = append(hosts, hostID)
+	}
+	sort.Strings(hosts)
+
+	var buf bytes.Buffer
+	buf.WriteString(`{"type":"system_metrics","data":[`)
+	for i, hostID := range hosts {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		buf.Write(b.pending[hostID])
+	}
+	buf.WriteString(`]}`)
+	clear(b.pending)
+	return buf.Bytes()
+}
+
+// setInterval changes the batch interval, sending the next batch no later
+// than one new interval from now
+func (b *metricBatch) setInterval(interval time.Duration, now time.Time) {
+	b.interval = interval
+	if due := now.Add(interval); due.Before(b.due) {
+		b.due = due
+	}
+}
+
+// parseMetricInterval validates a requested batch interval in seconds
+func parseMetricInterval(seconds int) (time.Duration, bool) {
+	interval := time.Duration(seconds) * time.Second
+	if interval < defaultMetricInterval || interval > maxMetricInterval {
+		return 0, false
+	}
+	return interval, true
+}
+
+// flushMetrics queues the due metric batches. Called from Run.
+func (h *Hub) flushMetrics(now time.Time) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		message := client.metrics.flush(now)
+		if message == nil {
+			continue
+		}
+		if !client.enqueue(message) {
+			h.evict(client)
+			continue
+		}
+		h.metricBatches.Add(1)
+	}
+}
+
+// queueMetric adds a system_metric event to every client's batch. Called
+// from Run.
+func (h *Hub) queueMetric(event metricEvent) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for client := range h.clients {
+		if client.metrics.add(event) {
+			h.coalescedMetrics.Add(1)
+		}
+	}
+}
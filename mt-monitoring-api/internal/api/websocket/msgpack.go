@@ -0,0 +1,137 @@
+package websocket
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Subprotocols a client may request. Clients negotiating msgpackProtocol
+// receive every message as a MessagePack binary frame; control messages
+// they send stay JSON text.
+const (
+	jsonProtocol    = "mt-monitoring.json"
+	msgpackProtocol = "mt-monitoring.msgpack"
+)
+
+// toMsgpack re-encodes a JSON message as MessagePack. Integers keep their
+// integer encoding, other numbers become float64, and map keys are sorted.
+func toMsgpack(message []byte) ([]byte, error) {
+	d := json.NewDecoder(bytes.NewReader(message))
+	d.UseNumber()
+	var v interface{}
+	if err := d.Decode(&v); err != nil {
+		return nil, err
+	}
+	var b bytes.Buffer
+	b.Grow(len(message))
+	if err := writeMsgpack(&b, v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func writeMsgpack(b *bytes.Buffer, v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		b.WriteByte(0xc0)
+	case bool:
+		if v {
+			b.WriteByte(0xc3)
+		} else {
+			b.WriteByte(0xc2)
+		}
+	case json.Number:
+		if n, err := v.Int64(); err == nil {
+			writeMsgpackInt(b, n)
+			return nil
+		}
+		f, err := v.Float64()
+		if err != nil {
+			return err
+		}
+		b.WriteByte(0xcb)
+		b.Write(binary.BigEndian.AppendUint64(nil, math.Float64bits(f)))
+	case string:
+		writeMsgpackHeader(b, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		b.WriteString(v)
+	case []interface{}:
+		writeMsgpackHeader(b, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			if err := writeMsgpack(b, item); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		writeMsgpackHeader(b, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			writeMsgpack(b, k)
+			if err := writeMsgpack(b, v[k]); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+	return nil
+}
+
+// writeMsgpackHeader writes the type and length of a string, array or map:
+// the fix format below fixMax, otherwise the 8 (strings only), 16 or 32 bit
+// format
+func writeMsgpackHeader(b *bytes.Buffer, n int, fix byte, fixMax int, f8, f16, f32 byte) {
+	switch {
+	case n < fixMax:
+		b.WriteByte(fix | byte(n))
+	case f8 != 0 && n <= math.MaxUint8:
+		b.WriteByte(f8)
+		b.WriteByte(byte(n))
+	case n <= math.MaxUint16:
+		b.WriteByte(f16)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	default:
+		b.WriteByte(f32)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	}
+}
+
+func writeMsgpackInt(b *bytes.Buffer, n int64) {
+	switch {
+	case n >= 0 && n <= math.MaxInt8:
+		b.WriteByte(byte(n)) // positive fixint
+	case n >= -32 && n < 0:
+		b.WriteByte(byte(n)) // negative fixint
+	case n >= 0 && n <= math.MaxUint8:
+		b.WriteByte(0xcc)
+		b.WriteByte(byte(n))
+	case n >= 0 && n <= math.MaxUint16:
+		b.WriteByte(0xcd)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n >= 0 && n <= math.MaxUint32:
+		b.WriteByte(0xce)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	case n >= 0:
+		b.WriteByte(0xcf)
+		b.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	case n >= math.MinInt8:
+		b.WriteByte(0xd0)
+		b.WriteByte(byte(n))
+	case n >= math.MinInt16:
+		b.WriteByte(0xd1)
+		b.Write(binary.BigEndian.AppendUint16(nil, uint16(n)))
+	case n >= math.MinInt32:
+		b.WriteByte(0xd2)
+		b.Write(binary.BigEndian.AppendUint32(nil, uint32(n)))
+	default:
+		b.WriteByte(0xd3)
+		b.Write(binary.BigEndian.AppendUint64(nil, uint64(n)))
+	}
+}
@@ -0,0 +1,69 @@
+package websocket
+
+import (
+	"bytes"
+	"strconv"
+)
+
+// replayBufferSize is how many broadcast messages are kept for clients
+// replaying after a reconnect
+const replayBufferSize = 512
+
+// replayBuffer is a ring of the latest broadcast messages with their
+// sequence numbers. It is guarded by the hub's mutex.
+type replayBuffer struct {
+	messages [][]byte
+	next     int   // index the next message is written to
+	count    int   // messages held, at most len(messages)
+	last     int64 // sequence number of the newest message
+}
+
+func newReplayBuffer(size int) *replayBuffer {
+	return &replayBuffer{messages: make([][]byte, size)}
+}
+
+// add appends the message with the next sequence number, evicting the
+// oldest when full
+func (b *replayBuffer) add(message []byte) {
+	b.last++
+	b.messages[b.next] = message
+	b.next = (b.next + 1) % len(b.messages)
+	if b.count < len(b.messages) {
+		b.count++
+	}
+}
+
+// since returns the messages after sequence number seq, oldest first.
+// complete is false when messages after seq were already evicted, or seq
+// is from before a server restart.
+func (b *replayBuffer) since(seq int64) (missed [][]byte, complete bool) {
+	if seq > b.last {
+		return nil, false
+	}
+	n := int(b.last - seq)
+	complete = n <= b.count
+	if !complete {
+		n = b.count
+	}
+	missed = make([][]byte, 0, n)
+	for i := b.count - n; i < b.count; i++ {
+		missed = append(missed, b.messages[(b.next-b.count+i+len(b.messages))%len(b.messages)])
+	}
+	return missed, complete
+}
+
+// withSeq adds a "seq" field to a JSON object message
+func withSeq(seq int64, message []byte) []byte {
+	if len(message) < 2 || message[0] != '{' {
+		return message
+	}
+	var b bytes.Buffer
+	b.Grow(len(message) + 24)
+	b.WriteString(`{"seq":`)
+	b.WriteString(strconv.FormatInt(seq, 10))
+	if rest := bytes.TrimSpace(message[1:]); len(rest) > 0 && rest[0] != '}' {
+		b.WriteByte(',')
+	}
+	b.Write(message[1:])
+	return b.Bytes()
+}
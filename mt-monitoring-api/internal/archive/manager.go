@@ -0,0 +1,401 @@
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/backup"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Archive kinds, also used as file name prefixes
+const (
+	KindIncidents     = "incidents"
+	KindNotifications = "notifications"
+)
+
+const (
+	batchSize  = 500 // rows per archive file, keeps DELETE ... IN (...) small
+	fileSuffix = ".json.gz"
+)
+
+var (
+	ErrInvalidMonth = errors.New("month must be in YYYY-MM format")
+	ErrInvalidKind  = errors.New("kind must be incidents or notifications")
+	ErrNotFound     = errors.New("archive not found")
+
+	monthPattern = regexp.MustCompile(`^\d{4}-(0[1-9]|1[0-2])$`)
+)
+
+// Result describes a completed archival run
+type Result struct {
+	Incidents     int64    `json:"incidents"`
+	Notifications int64    `json:"notifications"`
+	Files         []string `json:"files"`
+	Uploaded      int      `json:"uploaded"`
+}
+
+// File describes one archive file within a month
+type File struct {
+	Name      string    `json:"name"`
+	Kind      string    `json:"kind"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Month summarizes the archive files of one calendar month
+type Month struct {
+	Month string `json:"month"`
+	Files []File `json:"files"`
+}
+
+// Manager moves resolved incidents and old notification history out of
+// SQLite into gzip-compressed JSON files grouped by month. Rows are only
+// deleted after their archive file has been written.
+type Manager struct {
+	store *database.Store
+	mu    sync.Mutex // one run at a time
+}
+
+// NewManager creates a new archive manager
+func NewManager(store *database.Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Run archives everything older than the configured thresholds
+func (m *Manager) Run(ctx context.Context) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := archiveConfig()
+	now := time.Now().UTC()
+	result := &Result{Files: []string{}}
+
+	var uploader *backup.S3Uploader
+	if cfg.UploadToS3 {
+		if c := config.Get(); c != nil && c.Backup.S3.Bucket != "" {
+			uploader = backup.NewS3Uploader(c.Backup.S3)
+		} else {
+			log.Printf("[Archive] uploadToS3 is set but backup.s3.bucket is empty; keeping archives local only")
+		}
+	}
+
+	w := &writer{dir: cfg.Dir, stamp: now.Format("20060102-150405"), uploader: uploader, result: result}
+
+	if cfg.IncidentsAfter != "" {
+		cutoff := now.Add(-config.GetRetentionDuration(cfg.IncidentsAfter))
+		if err := m.archiveIncidents(ctx, w, cutoff); err != nil {
+			return result, err
+		}
+	}
+
+	if cfg.NotificationsAfter != "" {
+		cutoff := now.Add(-config.GetRetentionDuration(cfg.NotificationsAfter))
+		if err := m.archiveNotifications(ctx, w, cutoff); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// RunScheduled is the cron entry point for scheduled archival
+func (m *Manager) RunScheduled() {
+	result, err := m.Run(context.Background())
+	if err != nil {
+		log.Printf("Scheduled archival failed: %v", err)
+		return
+	}
+	if result.Incidents > 0 || result.Notifications > 0 {
+		log.Printf("Archived %d incidents and %d notification history rows into %d files",
+			result.Incidents, result.Notifications, len(result.Files))
+	}
+}
+
+func (m *Manager) archiveIncidents(ctx context.Context, w *writer, cutoff time.Time) error {
+	for {
+		incidents, err := m.store.Incidents.GetResolvedBefore(ctx, cutoff, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load resolved incidents: %w", err)
+		}
+		if len(incidents) == 0 {
+			return nil
+		}
+
+		// Postmortems are deleted with their incidents, so they are
+		// archived inside them
+		ids := make([]int64, len(incidents))
+		for n, i := range incidents {
+			ids[n] = i.ID
+		}
+		postmortems, err := m.store.Incidents.GetPostmortems(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("failed to load postmortems: %w", err)
+		}
+		for n := range incidents {
+			incidents[n].Postmortem = postmortems[incidents[n].ID]
+		}
+
+		byMonth := make(map[string][]models.Incident)
+		for _, i := range incidents {
+			month := i.StartedAt.UTC().Format("2006-01")
+			byMonth[month] = append(byMonth[month], i)
+		}
+		for month, rows := range byMonth {
+			if err := w.write(ctx, month, KindIncidents, rows); err != nil {
+				return err
+			}
+		}
+
+		deleted, err := m.store.Incidents.DeleteByIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("incidents archived but not deleted: %w", err)
+		}
+		w.result.Incidents += deleted
+
+		if len(incidents) < batchSize {
+			return nil
+		}
+	}
+}
+
+func (m *Manager) archiveNotifications(ctx context.Context, w *writer, cutoff time.Time) error {
+	for {
+		history, err := m.store.NotificationHistory.GetOlderThan(ctx, cutoff, batchSize)
+		if err != nil {
+			return fmt.Errorf("failed to load notification history: %w", err)
+		}
+		if len(history) == 0 {
+			return nil
+		}
+
+		byMonth := make(map[string][]models.NotificationHistory)
+		for _, h := range history {
+			month := h.CreatedAt.UTC().Format("2006-01")
+			byMonth[month] = append(byMonth[month], h)
+		}
+		for month, rows := range byMonth {
+			if err := w.write(ctx, month, KindNotifications, rows); err != nil {
+				return err
+			}
+		}
+
+		ids := make([]int, len(history))
+		for n, h := range history {
+			ids[n] = h.ID
+		}
+		deleted, err := m.store.NotificationHistory.DeleteByIDs(ctx, ids)
+		if err != nil {
+			return fmt.Errorf("notification history archived but not deleted: %w", err)
+		}
+		w.result.Notifications += deleted
+
+		if len(history) < batchSize {
+			return nil
+		}
+	}
+}
+
+// writer writes archive files for a single run
+type writer struct {
+	dir      string
+	stamp    string
+	seq      int
+	uploader *backup.S3Uploader
+	result   *Result
+}
+
+// write stores rows as <dir>/<month>/<kind>-<stamp>-<seq>.json.gz
+func (w *writer) write(ctx context.Context, month, kind string, rows interface{}) error {
+	monthDir := filepath.Join(w.dir, month)
+	if err := os.MkdirAll(monthDir, 0755); err != nil {
+		return fmt.Errorf("failed to create archive directory: %w", err)
+	}
+
+	w.seq++
+	name := fmt.Sprintf("%s-%s-%03d%s", kind, w.stamp, w.seq, fileSuffix)
+	dst := filepath.Join(monthDir, name)
+
+	if err := writeGzipJSON(dst, rows); err != nil {
+		return fmt.Errorf("failed to write %s: %w", dst, err)
+	}
+	w.result.Files = append(w.result.Files, path.Join(month, name))
+
+	if w.uploader != nil {
+		if _, err := w.uploader.Upload(ctx, path.Join("archives", month, name), dst); err != nil {
+			// The local file is the source of truth; upload failures don't block deletion
+			log.Printf("[Archive] Failed to upload %s: %v", name, err)
+		} else {
+			w.result.Uploaded++
+		}
+	}
+	return nil
+}
+
+// writeGzipJSON writes v to a temp file and renames it into place
+func writeGzipJSON(dst string, v interface{}) error {
+	tmp := dst + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(f)
+	err = json.NewEncoder(gz).Encode(v)
+	if cerr := gz.Close(); err == nil {
+		err = cerr
+	}
+	if serr := f.Sync(); err == nil {
+		err = serr
+	}
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return os.Rename(tmp, dst)
+}
+
+// Months lists archived months, newest first
+func (m *Manager) Months() ([]Month, error) {
+	dir := archiveConfig().Dir
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return []Month{}, nil
+		}
+		return nil, err
+	}
+
+	months := []Month{}
+	for _, e := range entries {
+		if !e.IsDir() || !monthPattern.MatchString(e.Name()) {
+			continue
+		}
+		files, err := listFiles(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if len(files) > 0 {
+			months = append(months, Month{Month: e.Name(), Files: files})
+		}
+	}
+
+	sort.Slice(months, func(i, j int) bool { return months[i].Month > months[j].Month })
+	return months, nil
+}
+
+// Read returns all archived records of a kind for a month
+func (m *Manager) Read(month, kind string) ([]json.RawMessage, error) {
+	if !monthPattern.MatchString(month) {
+		return nil, ErrInvalidMonth
+	}
+	if kind != KindIncidents && kind != KindNotifications {
+		return nil, ErrInvalidKind
+	}
+
+	monthDir := filepath.Join(archiveConfig().Dir, month)
+	files, err := listFiles(monthDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, err
+	}
+
+	records := []json.RawMessage{}
+	found := false
+	for _, f := range files {
+		if f.Kind != kind {
+			continue
+		}
+		found = true
+		var rows []json.RawMessage
+		if err := readGzipJSON(filepath.Join(monthDir, f.Name), &rows); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+		}
+		records = append(records, rows...)
+	}
+	if !found {
+		return nil, ErrNotFound
+	}
+	return records, nil
+}
+
+// listFiles returns the archive files in a month directory, oldest first
+func listFiles(dir string) ([]File, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasSuffix(name, fileSuffix) {
+			continue
+		}
+		kind, _, ok := strings.Cut(name, "-")
+		if !ok || (kind != KindIncidents && kind != KindNotifications) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, File{
+			Name:      name,
+			Kind:      kind,
+			Size:      info.Size(),
+			CreatedAt: info.ModTime().UTC(),
+		})
+	}
+
+	// Timestamped names sort chronologically within a kind
+	sort.Slice(files, func(i, j int) bool { return files[i].Name < files[j].Name })
+	return files, nil
+}
+
+func readGzipJSON(src string, v interface{}) error {
+	f, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	return json.NewDecoder(gz).Decode(v)
+}
+
+// archiveConfig returns the archive configuration with defaults applied
+func archiveConfig() config.ArchiveConfig {
+	var cfg config.ArchiveConfig
+	if c := config.Get(); c != nil {
+		cfg = c.Archive
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "./data/archives"
+	}
+	return cfg
+}
@@ -0,0 +1,356 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+const (
+	archiveSuffix   = ".tar.gz"
+	archiveVersion  = 1
+	manifestEntry   = "manifest.json"
+	databaseEntry   = "monitoring.db"
+	configEntryDir  = "config/"
+	restoredSuffix  = ".restore"
+	replacedSuffix  = ".before-restore"
+	maxConfigFileMB = 16
+)
+
+// Manifest describes the contents of a backup archive
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	Database  string    `json:"database"`
+	Config    []string  `json:"config"` // relative to the main config file's directory
+}
+
+// RestoreOptions controls where an archive is restored to
+type RestoreOptions struct {
+	DatabasePath string // replaced database; the old file is kept with a .before-restore suffix
+	ConfigDir    string // directory for the config files, empty to skip them
+}
+
+// RestoreResult describes a completed restore
+type RestoreResult struct {
+	Manifest     Manifest `json:"manifest"`
+	DatabasePath string   `json:"databasePath"`
+	PreviousPath string   `json:"previousPath,omitempty"` // the replaced database
+	ConfigFiles  []string `json:"configFiles"`
+}
+
+// RunArchive writes a full backup archive (database snapshot plus the
+// loaded config files) to dst, or to a timestamped file in backup.dir when
+// dst is empty. The snapshot is taken with VACUUM INTO, so it is safe to
+// run while the server is up. With upload the archive is sent to S3 when
+// configured, and old archives in backup.dir are pruned.
+func (m *Manager) RunArchive(ctx context.Context, dst string, upload bool) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := backupConfig()
+	now := time.Now().UTC()
+	name := filePrefix + now.Format("20060102-150405") + archiveSuffix
+	inBackupDir := dst == ""
+	if inBackupDir {
+		dst = filepath.Join(cfg.Dir, name)
+	} else {
+		name = filepath.Base(dst)
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp(filepath.Dir(dst), ".snapshot-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	snapshot := filepath.Join(tmpDir, databaseEntry)
+	if err := m.store.Backup(ctx, snapshot); err != nil {
+		return nil, err
+	}
+	if err := writeArchive(dst, snapshot, config.Files(), now); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(dst)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		File:      name,
+		Path:      dst,
+		Size:      info.Size(),
+		CreatedAt: now,
+	}
+
+	if upload && cfg.S3.Bucket != "" {
+		location, err := NewS3Uploader(cfg.S3).Upload(ctx, name, dst)
+		if err != nil {
+			return result, fmt.Errorf("archive saved to %s but upload failed: %w", dst, err)
+		}
+		result.Location = location
+	}
+
+	if inBackupDir && cfg.Keep > 0 {
+		pruned, err := prune(cfg.Dir, archiveSuffix, cfg.Keep)
+		if err != nil {
+			return result, fmt.Errorf("archive saved to %s but pruning failed: %w", dst, err)
+		}
+		result.Pruned = pruned
+	}
+	return result, nil
+}
+
+// writeArchive writes the manifest, the snapshot and the config files to a
+// temporary file and renames it to dst
+func writeArchive(dst, snapshot string, configFiles []string, createdAt time.Time) error {
+	manifest := Manifest{
+		Version:   archiveVersion,
+		CreatedAt: createdAt,
+		Database:  databaseEntry,
+		Config:    []string{},
+	}
+
+	// Keep the layout relative to the main config file; includes outside
+	// its directory are stored by name
+	type entry struct{ name, path string }
+	var entries []entry
+	if len(configFiles) > 0 {
+		baseDir := filepath.Dir(configFiles[0])
+		for _, file := range configFiles {
+			rel, err := filepath.Rel(baseDir, file)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				rel = filepath.Base(file)
+			}
+			rel = filepath.ToSlash(rel)
+			manifest.Config = append(manifest.Config, rel)
+			entries = append(entries, entry{name: configEntryDir + rel, path: file})
+		}
+	}
+
+	tmpPath := dst + ".tmp"
+	f, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600) // config files may hold secrets
+	if err != nil {
+		return err
+	}
+	err = func() error {
+		gz := gzip.NewWriter(f)
+		tw := tar.NewWriter(gz)
+
+		data, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{Name: manifestEntry, Mode: 0600, Size: int64(len(data)), ModTime: createdAt}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+
+		if err := addFile(tw, databaseEntry, snapshot); err != nil {
+			return err
+		}
+		for _, e := range entries {
+			if err := addFile(tw, e.name, e.path); err != nil {
+				return err
+			}
+		}
+
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		return gz.Close()
+	}()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dst); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move archive into place: %w", err)
+	}
+	return nil
+}
+
+func addFile(tw *tar.Writer, name, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: info.Size(), ModTime: info.ModTime()}); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Restore extracts a backup archive written by RunArchive. The database is
+// checked before it replaces opts.DatabasePath, and the replaced file is
+// kept next to it. The server must be stopped while restoring.
+func Restore(ctx context.Context, src string, opts RestoreOptions) (*RestoreResult, error) {
+	f, err := os.Open(src)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	gz, err := gzip.NewReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	defer gz.Close()
+
+	if err := os.MkdirAll(filepath.Dir(opts.DatabasePath), 0755); err != nil {
+		return nil, err
+	}
+	restored := opts.DatabasePath + restoredSuffix
+	defer removeDatabase(restored)
+
+	var manifest *Manifest
+	var hasDatabase bool
+	configFiles := make(map[string][]byte)
+
+	tr := tar.NewReader(gz)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read archive: %w", err)
+		}
+
+		switch {
+		case hdr.Name == manifestEntry:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+		case hdr.Name == databaseEntry:
+			if err := extractFile(tr, restored); err != nil {
+				return nil, err
+			}
+			hasDatabase = true
+		case strings.HasPrefix(hdr.Name, configEntryDir):
+			rel := strings.TrimPrefix(hdr.Name, configEntryDir)
+			if rel == "" || path.IsAbs(rel) || strings.HasPrefix(path.Clean(rel), "..") {
+				return nil, fmt.Errorf("invalid config entry %q in archive", hdr.Name)
+			}
+			data, err := io.ReadAll(io.LimitReader(tr, maxConfigFileMB<<20))
+			if err != nil {
+				return nil, err
+			}
+			configFiles[path.Clean(rel)] = data
+		}
+	}
+
+	if manifest == nil || !hasDatabase {
+		return nil, errors.New("not a backup archive: manifest or database missing")
+	}
+	if manifest.Version > archiveVersion {
+		return nil, fmt.Errorf("archive version %d is newer than supported (%d)", manifest.Version, archiveVersion)
+	}
+
+	if err := checkDatabase(ctx, restored); err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{
+		Manifest:     *manifest,
+		DatabasePath: opts.DatabasePath,
+		ConfigFiles:  []string{},
+	}
+
+	// A leftover WAL of the replaced database would be applied to the
+	// restored one, so it moves aside with it
+	if _, err := os.Stat(opts.DatabasePath); err == nil {
+		result.PreviousPath = opts.DatabasePath + replacedSuffix
+		removeDatabase(result.PreviousPath)
+		for _, suffix := range []string{"", "-wal", "-shm"} {
+			if err := os.Rename(opts.DatabasePath+suffix, result.PreviousPath+suffix); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to move the current database aside: %w", err)
+			}
+		}
+	}
+	if err := os.Rename(restored, opts.DatabasePath); err != nil {
+		return nil, fmt.Errorf("failed to move the restored database into place: %w", err)
+	}
+
+	if opts.ConfigDir != "" {
+		for rel, data := range configFiles {
+			dst := filepath.Join(opts.ConfigDir, filepath.FromSlash(rel))
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return result, err
+			}
+			if err := os.WriteFile(dst, data, 0600); err != nil {
+				return result, fmt.Errorf("database restored but writing %s failed: %w", dst, err)
+			}
+			result.ConfigFiles = append(result.ConfigFiles, dst)
+		}
+	}
+	return result, nil
+}
+
+func extractFile(r io.Reader, dst string) error {
+	f, err := os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to extract database: %w", err)
+	}
+	return f.Close()
+}
+
+// checkDatabase opens the extracted database, which also applies pending
+// migrations, and rejects it when SQLite reports corruption
+func checkDatabase(ctx context.Context, dbPath string) error {
+	store, err := database.Open(dbPath, 0)
+	if err != nil {
+		return fmt.Errorf("restored database cannot be opened: %w", err)
+	}
+	defer store.Close()
+
+	report, err := store.CheckIntegrity(ctx, false)
+	if err != nil {
+		return fmt.Errorf("restored database cannot be checked: %w", err)
+	}
+	if len(report.IntegrityErrors) > 0 {
+		return fmt.Errorf("restored database is corrupt: %s", report.IntegrityErrors[0])
+	}
+	return nil
+}
+
+// removeDatabase removes a database file with its WAL and shared memory files
+func removeDatabase(dbPath string) {
+	for _, suffix := range []string{"", "-wal", "-shm"} {
+		os.Remove(dbPath + suffix)
+	}
+}
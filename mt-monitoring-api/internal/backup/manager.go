@@ -0,0 +1,146 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+const (
+	filePrefix = "monitoring-"
+	fileSuffix = ".db"
+)
+
+// Result describes a completed snapshot
+type Result struct {
+	File      string    `json:"file"`
+	Path      string    `json:"path"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"createdAt"`
+	Location  string    `json:"location,omitempty"` // s3://bucket/key when uploaded
+	Pruned    int       `json:"pruned"`
+}
+
+// Manager creates database snapshots, uploads them to S3-compatible storage
+// when configured and prunes old local snapshots.
+type Manager struct {
+	store *database.Store
+	mu    sync.Mutex // one snapshot at a time
+}
+
+// NewManager creates a new backup manager
+func NewManager(store *database.Store) *Manager {
+	return &Manager{store: store}
+}
+
+// Run takes a snapshot using the current backup configuration
+func (m *Manager) Run(ctx context.Context) (*Result, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	cfg := backupConfig()
+
+	if err := os.MkdirAll(cfg.Dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	now := time.Now().UTC()
+	name := filePrefix + now.Format("20060102-150405") + fileSuffix
+	path := filepath.Join(cfg.Dir, name)
+
+	if err := m.store.Backup(ctx, path); err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		File:      name,
+		Path:      path,
+		Size:      info.Size(),
+		CreatedAt: now,
+	}
+
+	if cfg.S3.Bucket != "" {
+		location, err := NewS3Uploader(cfg.S3).Upload(ctx, name, path)
+		if err != nil {
+			return result, fmt.Errorf("snapshot saved to %s but upload failed: %w", path, err)
+		}
+		result.Location = location
+	}
+
+	if cfg.Keep > 0 {
+		pruned, err := prune(cfg.Dir, fileSuffix, cfg.Keep)
+		if err != nil {
+			log.Printf("Failed to prune old backups: %v", err)
+		}
+		result.Pruned = pruned
+	}
+
+	return result, nil
+}
+
+// RunScheduled is the cron entry point for scheduled snapshots
+func (m *Manager) RunScheduled() {
+	result, err := m.Run(context.Background())
+	if err != nil {
+		log.Printf("Scheduled backup failed: %v", err)
+		return
+	}
+	log.Printf("Scheduled backup written to %s (%d bytes)", result.Path, result.Size)
+}
+
+// backupConfig returns the backup configuration with defaults applied
+func backupConfig() config.BackupConfig {
+	var cfg config.BackupConfig
+	if c := config.Get(); c != nil {
+		cfg = c.Backup
+	}
+	if cfg.Dir == "" {
+		cfg.Dir = "./data/backups"
+	}
+	return cfg
+}
+
+// prune removes the oldest local snapshots (or archives, by suffix) beyond keep
+func prune(dir, suffix string, keep int) (int, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var names []string
+	for _, e := range entries {
+		name := e.Name()
+		if !e.IsDir() && strings.HasPrefix(name, filePrefix) && strings.HasSuffix(name, suffix) {
+			names = append(names, name)
+		}
+	}
+	if len(names) <= keep {
+		return 0, nil
+	}
+
+	// Timestamped names sort chronologically
+	sort.Strings(names)
+
+	pruned := 0
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return pruned, err
+		}
+		pruned++
+	}
+	return pruned, nil
+}
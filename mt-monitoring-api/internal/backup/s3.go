@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// S3Uploader uploads files to S3-compatible storage with AWS Signature V4.
+// Only single-part PUT is supported, which limits objects to 5 GB.
+type S3Uploader struct {
+	cfg    config.S3Config
+	client *http.Client
+}
+
+// NewS3Uploader creates a new uploader
+func NewS3Uploader(cfg config.S3Config) *S3Uploader {
+	return &S3Uploader{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Minute},
+	}
+}
+
+// Upload stores the file at path under the configured prefix and returns its s3:// location
+func (u *S3Uploader) Upload(ctx context.Context, name, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return "", err
+	}
+
+	// Payload hash is part of the signature
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	payloadHash := hex.EncodeToString(h.Sum(nil))
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	key := strings.TrimPrefix(strings.TrimSuffix(u.cfg.Prefix, "/")+"/"+name, "/")
+	target, err := u.objectURL(key)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target.String(), f)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", "application/vnd.sqlite3")
+	u.sign(req, payloadHash, time.Now().UTC())
+
+	resp, err := u.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return "", fmt.Errorf("S3 upload returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	return "s3://" + u.cfg.Bucket + "/" + key, nil
+}
+
+// objectURL builds the path-style or virtual-hosted-style object URL
+func (u *S3Uploader) objectURL(key string) (*url.URL, error) {
+	endpoint, err := url.Parse(u.cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint %q", u.cfg.Endpoint)
+	}
+
+	target := *endpoint
+	if u.cfg.UsePathStyle {
+		target.Path = "/" + u.cfg.Bucket + "/" + key
+	} else {
+		target.Host = u.cfg.Bucket + "." + endpoint.Host
+		target.Path = "/" + key
+	}
+	return &target, nil
+}
+
+// sign adds AWS Signature V4 headers to the request
+func (u *S3Uploader) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	signedHeaders := "content-type;host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := "content-type:" + req.Header.Get("Content-Type") + "\n" +
+		"host:" + req.URL.Host + "\n" +
+		"x-amz-content-sha256:" + payloadHash + "\n" +
+		"x-amz-date:" + amzDate + "\n"
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + u.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonicalRequest)
+
+	signingKey := hmacSHA256([]byte("AWS4"+u.cfg.SecretKey), date)
+	signingKey = hmacSHA256(signingKey, u.cfg.Region)
+	signingKey = hmacSHA256(signingKey, "s3")
+	signingKey = hmacSHA256(signingKey, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(signingKey, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		u.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,163 @@
+package checker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	// tokenExpiryMargin renews an OAuth2 token this long before it expires,
+	// so a check never sends one that expires on the way
+	tokenExpiryMargin = 30 * time.Second
+
+	// defaultTokenLifetime is how long a token is reused when the token
+	// endpoint does not say when it expires
+	defaultTokenLifetime = 5 * time.Minute
+
+	// maxTokenResponseBytes bounds the token endpoint response that is read
+	maxTokenResponseBytes = 64 << 10
+)
+
+// tokenKey identifies the client credentials an OAuth2 token was issued for
+type tokenKey struct {
+	tokenURL, clientID, clientSecret, scopes string
+}
+
+// oauthToken is a cached OAuth2 access token. Its mutex is held while the
+// token is fetched, so concurrent checks of services sharing the client
+// request it once.
+type oauthToken struct {
+	mu        sync.Mutex
+	value     string
+	expiresAt time.Time
+}
+
+// tokenResponse is the response of an OAuth2 token endpoint (RFC 6749 5.1)
+type tokenResponse struct {
+	AccessToken string `json:"access_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int64  `json:"expires_in"`
+}
+
+// authorize adds the credentials of auth to a check request. OAuth2 tokens
+// are requested through client, with the TLS settings of the check.
+func (c *HTTPChecker) authorize(ctx context.Context, client *http.Client, req *http.Request, auth *models.ServiceAuth) error {
+	switch auth.Type {
+	case models.AuthTypeBasic:
+		req.SetBasicAuth(auth.Username, auth.Password)
+	case models.AuthTypeBearer:
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+	case models.AuthTypeOAuth2:
+		token, err := c.oauthToken(ctx, client, auth)
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	default:
+		return fmt.Errorf("unknown auth type %q", auth.Type)
+	}
+	return nil
+}
+
+// tokenEntry returns the cache entry of the client credentials of auth
+func (c *HTTPChecker) tokenEntry(auth *models.ServiceAuth) *oauthToken {
+	key := tokenKey{
+		tokenURL:     auth.TokenURL,
+		clientID:     auth.ClientID,
+		clientSecret: auth.ClientSecret,
+		scopes:       strings.Join(auth.Scopes, " "),
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t, ok := c.tokens[key]
+	if !ok {
+		t = &oauthToken{}
+		c.tokens[key] = t
+	}
+	return t
+}
+
+// oauthToken returns a cached access token for the client credentials of
+// auth, requesting a new one when there is none or it is about to expire
+func (c *HTTPChecker) oauthToken(ctx context.Context, client *http.Client, auth *models.ServiceAuth) (string, error) {
+	t := c.tokenEntry(auth)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.value != "" && time.Now().Before(t.expiresAt) {
+		return t.value, nil
+	}
+	resp, err := requestToken(ctx, client, auth)
+	if err != nil {
+		return "", fmt.Errorf("OAuth2 token request failed: %w", err)
+	}
+
+	lifetime := defaultTokenLifetime
+	if resp.ExpiresIn > 0 {
+		lifetime = max(time.Duration(resp.ExpiresIn)*time.Second-tokenExpiryMargin, 0)
+	}
+	t.value = resp.AccessToken
+	t.expiresAt = time.Now().Add(lifetime)
+	return t.value, nil
+}
+
+// forgetToken drops the cached token of auth, e.g. after the service
+// rejected it, so the next check requests a new one
+func (c *HTTPChecker) forgetToken(auth *models.ServiceAuth) {
+	t := c.tokenEntry(auth)
+	t.mu.Lock()
+	t.value = ""
+	t.mu.Unlock()
+}
+
+// requestToken requests an access token with the client credentials grant,
+// authenticating the client with HTTP basic auth
+func requestToken(ctx context.Context, client *http.Client, auth *models.ServiceAuth) (*tokenResponse, error) {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(auth.Scopes) > 0 {
+		form.Set("scope", strings.Join(auth.Scopes, " "))
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, auth.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "MT-Monitoring/1.0")
+	req.SetBasicAuth(url.QueryEscape(auth.ClientID), url.QueryEscape(auth.ClientSecret))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxTokenResponseBytes))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("token endpoint returned status %d", resp.StatusCode)
+	}
+	var token tokenResponse
+	if err := json.Unmarshal(body, &token); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("token response has no access_token")
+	}
+	if token.TokenType != "" && !strings.EqualFold(token.TokenType, "bearer") {
+		return nil, fmt.Errorf("unsupported token type %q", token.TokenType)
+	}
+	return &token, nil
+}
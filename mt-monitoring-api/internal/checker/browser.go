@@ -0,0 +1,43 @@
+package checker
+
+import (
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// BrowserChecker performs browser checks: it loads the page in headless
+// Chrome, waits for the selector and measures the DOMContentLoaded and load
+// times. The Chrome-driving implementation is only built with -tags browser
+// (browser_chromedp.go) because of its footprint.
+type BrowserChecker struct {
+	slots chan struct{} // limits the browsers running at once
+}
+
+// NewBrowserChecker creates a new browser checker
+func NewBrowserChecker() *BrowserChecker {
+	slots := 2
+	if cfg := config.Get(); cfg != nil && cfg.Browser.MaxConcurrent > 0 {
+		slots = cfg.Browser.MaxConcurrent
+	}
+	return &BrowserChecker{slots: make(chan struct{}, slots)}
+}
+
+// BrowserSupported reports whether this build includes browser checks
+func BrowserSupported() bool {
+	return browserSupported
+}
+
+// BrowserEnabled reports whether browser services are checked: the build
+// includes browser checks and browser.enabled is on
+func BrowserEnabled() bool {
+	cfg := config.Get()
+	return browserSupported && cfg != nil && cfg.Browser.Enabled
+}
+
+// browserExecPath returns the configured Chrome binary, empty to look it
+// up on PATH
+func browserExecPath() string {
+	if cfg := config.Get(); cfg != nil {
+		return cfg.Browser.ExecPath
+	}
+	return ""
+}
@@ -0,0 +1,119 @@
+//go:build browser
+
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/chromedp/cdproto/network"
+	"github.com/chromedp/chromedp"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const browserSupported = true
+
+// navigationTimingScript returns the DOMContentLoaded and load times of the
+// page in milliseconds since navigation start
+const navigationTimingScript = `(() => {
+	const n = performance.getEntriesByType("navigation")[0];
+	return n ? {domContentLoaded: n.domContentLoadedEventEnd, load: n.loadEventEnd} : {};
+})()`
+
+// navigationTiming is the result of navigationTimingScript
+type navigationTiming struct {
+	DOMContentLoaded float64 `json:"domContentLoaded"`
+	Load             float64 `json:"load"`
+}
+
+// Check performs a browser check. Each check starts its own browser so no
+// cookies or cache carry over between checks; at most browser.maxConcurrent
+// run at once and the others wait for a slot.
+func (c *BrowserChecker) Check(config *models.BrowserConfig) *CheckResult {
+	c.slots <- struct{}{}
+	defer func() { <-c.slots }()
+
+	result := &CheckResult{
+		CheckedAt: time.Now(),
+	}
+
+	opts := append(chromedp.DefaultExecAllocatorOptions[:],
+		chromedp.Flag("ignore-certificate-errors", config.InsecureSkipVerify))
+	if path := browserExecPath(); path != "" {
+		opts = append(opts, chromedp.ExecPath(path))
+	}
+	allocCtx, cancelAlloc := chromedp.NewExecAllocator(context.Background(), opts...)
+	defer cancelAlloc()
+	ctx, cancel := chromedp.NewContext(allocCtx)
+	defer cancel()
+	ctx, cancelTimeout := context.WithTimeout(ctx, time.Duration(config.Timeout)*time.Millisecond)
+	defer cancelTimeout()
+
+	startTime := time.Now()
+	resp, err := c.load(ctx, config)
+	if resp != nil {
+		result.StatusCode = int(resp.Status)
+		result.Protocol = resp.Protocol
+	}
+	if err != nil {
+		result.ResponseTime = int(time.Since(startTime).Milliseconds())
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = browserError(err, config)
+		return result
+	}
+
+	var timing navigationTiming
+	if err := chromedp.Run(ctx, chromedp.Evaluate(navigationTimingScript, &timing)); err != nil {
+		result.ResponseTime = int(time.Since(startTime).Milliseconds())
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = browserError(err, config)
+		return result
+	}
+	result.ResponseTime = int(time.Since(startTime).Milliseconds())
+	domContentLoaded, load := int(timing.DOMContentLoaded), int(timing.Load)
+	result.Timings = &models.CheckTimings{DOMContentLoaded: &domContentLoaded, Load: &load}
+
+	if config.ExpectedStatus != 0 && result.StatusCode != config.ExpectedStatus {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("Unexpected status code: %d (expected %d)", result.StatusCode, config.ExpectedStatus)
+		return result
+	}
+	result.Status = models.CheckStatusSuccess
+	return result
+}
+
+// load navigates to the page, waits for the load event and then for the
+// selector. It returns the response of the page document.
+func (c *BrowserChecker) load(ctx context.Context, config *models.BrowserConfig) (*network.Response, error) {
+	if len(config.Headers) > 0 {
+		headers := make(network.Headers, len(config.Headers))
+		for k, v := range config.Headers {
+			headers[k] = v
+		}
+		if err := chromedp.Run(ctx, network.Enable(), network.SetExtraHTTPHeaders(headers)); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := chromedp.RunResponse(ctx, chromedp.Navigate(config.URL))
+	if err != nil || config.Selector == "" {
+		return resp, err
+	}
+	if err := chromedp.Run(ctx, chromedp.WaitVisible(config.Selector, chromedp.ByQuery)); err != nil {
+		return resp, fmt.Errorf("selector %q: %w", config.Selector, err)
+	}
+	return resp, nil
+}
+
+// browserError describes why a browser check failed
+func browserError(err error, config *models.BrowserConfig) string {
+	if errors.Is(err, context.DeadlineExceeded) {
+		if config.Selector != "" {
+			return fmt.Sprintf("Page did not load or %q did not appear within %dms", config.Selector, config.Timeout)
+		}
+		return fmt.Sprintf("Page did not load within %dms", config.Timeout)
+	}
+	return fmt.Sprintf("Browser check failed: %v", err)
+}
@@ -0,0 +1,20 @@
+//go:build !browser
+
+package checker
+
+import (
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const browserSupported = false
+
+// Check fails: this build does not include browser checks
+func (c *BrowserChecker) Check(config *models.BrowserConfig) *CheckResult {
+	return &CheckResult{
+		Status:       models.CheckStatusFailure,
+		ErrorMessage: "browser checks are not included in this build, rebuild with -tags browser",
+		CheckedAt:    time.Now(),
+	}
+}
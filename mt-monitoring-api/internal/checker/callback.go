@@ -0,0 +1,120 @@
+package checker
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Callback deliveries queued at most and sent at once. A full queue drops
+// results rather than delaying checks.
+const (
+	callbackQueueSize = 256
+	callbackWorkers   = 4
+)
+
+// CheckCallback is the JSON body posted to the callback URL of a service
+type CheckCallback struct {
+	ServiceID      string               `json:"serviceId"`
+	ServiceName    string               `json:"serviceName"`
+	Status         models.ServiceStatus `json:"status"`
+	PreviousStatus models.ServiceStatus `json:"previousStatus"` // "unknown" on the first check since startup
+	Changed        bool                 `json:"changed"`
+	Maintenance    bool                 `json:"maintenance,omitempty"` // alerts are held by status page maintenance
+	ResponseTime   int                  `json:"responseTime"`          // milliseconds
+	StatusCode     int                  `json:"statusCode,omitempty"`
+	Error          string               `json:"error,omitempty"`
+	CheckedAt      time.Time            `json:"checkedAt"`
+}
+
+type callbackJob struct {
+	url      string
+	callback CheckCallback
+}
+
+// CallbackSender posts check results to the callback URLs of services
+type CallbackSender struct {
+	client  *http.Client
+	queue   chan callbackJob
+	dropped atomic.Int64
+}
+
+// NewCallbackSender creates a sender and starts its workers
+func NewCallbackSender() *CallbackSender {
+	s := &CallbackSender{
+		client: &http.Client{Timeout: 10 * time.Second},
+		queue:  make(chan callbackJob, callbackQueueSize),
+	}
+	for range callbackWorkers {
+		go s.run()
+	}
+	return s
+}
+
+// Send queues the result of a check when the service has a callback URL
+// and, for services called back on status changes only, the status changed
+func (s *CallbackSender) Send(service *models.Service, metric *models.Metric, status, prevStatus models.ServiceStatus, held bool) {
+	if service.CallbackURL == "" {
+		return
+	}
+	changed := status != prevStatus
+	if service.CallbackOn == models.CallbackChange && !changed {
+		return
+	}
+
+	job := callbackJob{url: service.CallbackURL, callback: CheckCallback{
+		ServiceID:      service.ID,
+		ServiceName:    service.Name,
+		Status:         status,
+		PreviousStatus: prevStatus,
+		Changed:        changed,
+		Maintenance:    held,
+		ResponseTime:   metric.ResponseTime,
+		StatusCode:     metric.StatusCode,
+		Error:          metric.ErrorMessage,
+		CheckedAt:      metric.CheckedAt,
+	}}
+	select {
+	case s.queue <- job:
+	default:
+		if n := s.dropped.Add(1); n == 1 || n%100 == 0 {
+			log.Printf("[Callback] Queue full, dropped %d check results so far", n)
+		}
+	}
+}
+
+func (s *CallbackSender) run() {
+	for job := range s.queue {
+		if err := s.post(job); err != nil {
+			log.Printf("[Callback] Failed to call back for %s: %v", job.callback.ServiceID, err)
+		}
+	}
+}
+
+func (s *CallbackSender) post(job callbackJob) error {
+	payload, err := json.Marshal(job.callback)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(http.MethodPost, job.url, bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "MT-Monitoring/1.0")
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package checker
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http/httptrace"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// diagnosticsConfig returns the failure diagnostics settings with defaults applied
+func diagnosticsConfig() config.DiagnosticsConfig {
+	var cfg config.DiagnosticsConfig
+	if c := config.Get(); c != nil {
+		cfg = c.Diagnostics
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = 1024
+	}
+	return cfg
+}
+
+// traceTimings records connection phase timestamps of one HTTP request
+type traceTimings struct {
+	mu           sync.Mutex
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	wroteRequest time.Time
+	firstByte    time.Time
+	remoteAddr   string
+	tlsState     *tls.ConnectionState
+	tlsErr       error
+}
+
+// clientTrace returns hooks that fill in the timings
+func (t *traceTimings) clientTrace() *httptrace.ClientTrace {
+	now := func(dst *time.Time) {
+		t.mu.Lock()
+		*dst = time.Now()
+		t.mu.Unlock()
+	}
+
+	return &httptrace.ClientTrace{
+		DNSStart:     func(httptrace.DNSStartInfo) { now(&t.dnsStart) },
+		DNSDone:      func(httptrace.DNSDoneInfo) { now(&t.dnsDone) },
+		ConnectStart: func(string, string) { now(&t.connectStart) },
+		ConnectDone: func(network, addr string, err error) {
+			now(&t.connectDone)
+			t.mu.Lock()
+			t.remoteAddr = addr
+			t.mu.Unlock()
+		},
+		TLSHandshakeStart: func() { now(&t.tlsStart) },
+		TLSHandshakeDone: func(state tls.ConnectionState, err error) {
+			now(&t.tlsDone)
+			t.mu.Lock()
+			t.tlsState = &state
+			t.tlsErr = err
+			t.mu.Unlock()
+		},
+		WroteRequest:         func(httptrace.WroteRequestInfo) { now(&t.wroteRequest) },
+		GotFirstResponseByte: func() { now(&t.firstByte) },
+	}
+}
+
+// details converts the recorded timings into check diagnostics
+func (t *traceTimings) details(serverName string) *models.CheckDetails {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	d := &models.CheckDetails{
+		RemoteAddr: t.remoteAddr,
+		DNSMs:      phaseMs(t.dnsStart, t.dnsDone),
+		ConnectMs:  phaseMs(t.connectStart, t.connectDone),
+		TLSMs:      phaseMs(t.tlsStart, t.tlsDone),
+		TTFBMs:     phaseMs(t.wroteRequest, t.firstByte),
+	}
+	if !t.tlsStart.IsZero() {
+		d.TLS = tlsDetails(t.tlsState, t.tlsErr, serverName)
+	}
+	return d
+}
+
+// timings converts the recorded timestamps into check phases. end is when
+// the body was read, zero when it was not.
+func (t *traceTimings) timings(end time.Time) *models.CheckTimings {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &models.CheckTimings{
+		DNS:      phaseMs(t.dnsStart, t.dnsDone),
+		Connect:  phaseMs(t.connectStart, t.connectDone),
+		TLS:      phaseMs(t.tlsStart, t.tlsDone),
+		TTFB:     phaseMs(t.wroteRequest, t.firstByte),
+		Transfer: phaseMs(t.firstByte, end),
+	}
+}
+
+// phaseMs returns the phase duration, or nil when it did not complete
+func phaseMs(start, end time.Time) *int {
+	if start.IsZero() || end.IsZero() {
+		return nil
+	}
+	ms := int(end.Sub(start).Milliseconds())
+	return &ms
+}
+
+// tlsDetails summarizes a TLS handshake and its leaf certificate
+func tlsDetails(state *tls.ConnectionState, err error, serverName string) *models.TLSDetails {
+	d := &models.TLSDetails{ServerName: serverName}
+	if err != nil {
+		d.HandshakeError = err.Error()
+	}
+	if state == nil {
+		return d
+	}
+
+	if state.ServerName != "" {
+		d.ServerName = state.ServerName
+	}
+	if state.Version != 0 {
+		d.Version = tls.VersionName(state.Version)
+	}
+	if state.CipherSuite != 0 {
+		d.CipherSuite = tls.CipherSuiteName(state.CipherSuite)
+	}
+	if len(state.PeerCertificates) > 0 {
+		leaf := state.PeerCertificates[0]
+		notAfter := leaf.NotAfter
+		d.PeerSubject = leaf.Subject.String()
+		d.PeerIssuer = leaf.Issuer.String()
+		d.PeerNotAfter = &notAfter
+	}
+	return d
+}
+
+// readBodySnippet reads up to n bytes of a response body as valid UTF-8
+func readBodySnippet(r io.Reader, n int) string {
+	data, _ := io.ReadAll(io.LimitReader(r, int64(n)))
+	return strings.ToValidUTF8(string(data), "�")
+}
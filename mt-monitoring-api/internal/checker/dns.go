@@ -0,0 +1,47 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// DNSChecker performs name resolution checks with the system resolver
+type DNSChecker struct{}
+
+// NewDNSChecker creates a new DNS checker
+func NewDNSChecker() *DNSChecker {
+	return &DNSChecker{}
+}
+
+// Check resolves name and returns the result with the addresses found. A
+// name without addresses is a failure.
+func (c *DNSChecker) Check(name string, timeout int) (*CheckResult, []string) {
+	result := &CheckResult{
+		CheckedAt: time.Now(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(timeout)*time.Millisecond)
+	defer cancel()
+
+	startTime := time.Now()
+	addrs, err := net.DefaultResolver.LookupHost(ctx, name)
+	result.ResponseTime = int(time.Since(startTime).Milliseconds())
+
+	if err != nil {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("DNS lookup failed: %v", err)
+		return result, nil
+	}
+	if len(addrs) == 0 {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("No addresses for %s", name)
+		return result, nil
+	}
+
+	result.Status = models.CheckStatusSuccess
+	return result, addrs
+}
@@ -0,0 +1,121 @@
+package checker
+
+import (
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// incidentGroups correlates the failures of services checking the same
+// host (alerts.incidentGrouping). The first service to fail opens a group;
+// services failing within the window join it as members, whose incidents
+// link to the group's first incident and whose alerts are not sent.
+type incidentGroups struct {
+	mu       sync.Mutex
+	byHost   map[string]*incidentGroup // open group of each host
+	byMember map[string]*incidentGroup // group of each failing service
+}
+
+type incidentGroup struct {
+	host     string
+	leader   string // service that opened the group
+	opened   time.Time
+	incident int64 // first incident of the group, 0 until one opens
+	members  map[string]bool
+}
+
+func newIncidentGroups() *incidentGroups {
+	return &incidentGroups{
+		byHost:   make(map[string]*incidentGroup),
+		byMember: make(map[string]*incidentGroup),
+	}
+}
+
+// fail records a failed check and reports whether the service is a member
+// of another service's group, i.e. its alerts are held
+func (g *incidentGroups) fail(service *models.Service, at time.Time) bool {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Alerts.IncidentGrouping.Enabled {
+		return false
+	}
+	host := TargetHost(service)
+	if host == "" {
+		return false
+	}
+	window := time.Duration(cfg.Alerts.IncidentGrouping.Window) * time.Second
+
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if group, ok := g.byMember[service.ID]; ok {
+		return group.leader != service.ID
+	}
+	group, ok := g.byHost[host]
+	if !ok || at.Sub(group.opened) > window {
+		group = &incidentGroup{host: host, leader: service.ID, opened: at, members: make(map[string]bool)}
+		g.byHost[host] = group
+	}
+	group.members[service.ID] = true
+	g.byMember[service.ID] = group
+	return group.leader != service.ID
+}
+
+// recover removes a recovered service from its group and reports whether
+// it was a member of another service's group
+func (g *incidentGroups) recover(serviceID string) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	group, ok := g.byMember[serviceID]
+	if !ok {
+		return false
+	}
+	delete(g.byMember, serviceID)
+	delete(group.members, serviceID)
+	if len(group.members) == 0 && g.byHost[group.host] == group {
+		delete(g.byHost, group.host)
+	}
+	return group.leader != serviceID
+}
+
+// parent returns the first incident of the service's group, 0 when the
+// service is not grouped or no incident of its group has opened yet
+func (g *incidentGroups) parent(serviceID string) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if group, ok := g.byMember[serviceID]; ok {
+		return group.incident
+	}
+	return 0
+}
+
+// opened records an incident of a grouped service, the group's parent
+// incident if it is the first
+func (g *incidentGroups) opened(serviceID string, incidentID int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if group, ok := g.byMember[serviceID]; ok && group.incident == 0 {
+		group.incident = incidentID
+	}
+}
+
+// TargetHost returns the lower-case host name a service checks, "" for
+// heartbeats
+func TargetHost(service *models.Service) string {
+	var host string
+	switch service.Type {
+	case models.ServiceTypeHTTP, models.ServiceTypeBrowser:
+		if u, err := url.Parse(service.URL); err == nil {
+			host = u.Hostname()
+		}
+	case models.ServiceTypeTCP, models.ServiceTypeICMP:
+		host = service.URL
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+	}
+	return strings.ToLower(strings.Trim(host, "[]"))
+}
@@ -0,0 +1,114 @@
+package checker
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// PingKind is what a heartbeat ping reports
+type PingKind string
+
+const (
+	PingSuccess PingKind = "success"
+	PingStart   PingKind = "start"
+	PingFail    PingKind = "fail"
+)
+
+// heartbeatCheckInterval bounds how late a missed ping is noticed for
+// heartbeats with long periods
+const heartbeatCheckInterval = 60
+
+// heartbeatState is what the pings of a heartbeat service reported
+type heartbeatState struct {
+	lastPing  time.Time // last completion ping, or when tracking started
+	startedAt time.Time // last start not yet followed by a completion
+	failed    bool      // the last completion was a failure
+	message   string    // failure reported by the job
+	duration  int       // milliseconds between the last start and its completion
+}
+
+// HeartbeatChecker evaluates heartbeat services from the pings they
+// received. State is kept in memory, so after a restart every heartbeat
+// gets a full period and grace before it is reported late.
+type HeartbeatChecker struct {
+	mu     sync.Mutex
+	states map[string]*heartbeatState
+}
+
+// NewHeartbeatChecker creates a new heartbeat checker
+func NewHeartbeatChecker() *HeartbeatChecker {
+	return &HeartbeatChecker{states: make(map[string]*heartbeatState)}
+}
+
+// Record stores a ping. A completion after a start records the run time.
+func (c *HeartbeatChecker) Record(serviceID string, kind PingKind, message string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	st := c.state(serviceID, now)
+	if kind == PingStart {
+		st.startedAt = now
+		return
+	}
+
+	st.lastPing = now
+	st.failed = kind == PingFail
+	st.message = message
+	st.duration = 0
+	if !st.startedAt.IsZero() {
+		st.duration = int(now.Sub(st.startedAt).Milliseconds())
+		st.startedAt = time.Time{}
+	}
+}
+
+// Check reports a heartbeat service down when the job reported a failure,
+// no ping arrived within interval + grace seconds, or a started run has
+// not completed within grace
+func (c *HeartbeatChecker) Check(svc *models.Service) *CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	result := &CheckResult{
+		Status:    models.CheckStatusSuccess,
+		CheckedAt: now,
+	}
+
+	st := c.state(svc.ID, now)
+	period := time.Duration(svc.Interval) * time.Second
+	grace := time.Duration(svc.Grace) * time.Second
+	switch {
+	case st.failed:
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = "Job reported failure"
+		if st.message != "" {
+			result.ErrorMessage += ": " + st.message
+		}
+	case now.Sub(st.lastPing) > period+grace:
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("No ping received for %s (expected every %s)",
+			now.Sub(st.lastPing).Round(time.Second), period)
+	case !st.startedAt.IsZero() && grace > 0 && now.Sub(st.startedAt) > grace:
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("Job started %s ago and has not completed",
+			now.Sub(st.startedAt).Round(time.Second))
+	default:
+		result.ResponseTime = st.duration
+	}
+	return result
+}
+
+// state returns the state of a service, starting its first period at now.
+// The caller holds c.mu.
+func (c *HeartbeatChecker) state(serviceID string, now time.Time) *heartbeatState {
+	st, ok := c.states[serviceID]
+	if !ok {
+		st = &heartbeatState{lastPing: now}
+		c.states[serviceID] = st
+	}
+	return st
+}
@@ -2,28 +2,42 @@ package checker
 
 import (
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
+	"io"
+	"log"
 	"net/http"
+	"net/http/httptrace"
+	"sync"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
+// maxTransferBytes bounds how much of a response body is read to time its
+// transfer
+const maxTransferBytes = 1 << 20
+
 // HTTPChecker performs HTTP health checks
 type HTTPChecker struct {
 	client *http.Client
+
+	mu         sync.Mutex
+	transports map[transportKey]http.RoundTripper
+	tokens     map[tokenKey]*oauthToken // OAuth2 access tokens
+}
+
+// transportKey identifies the TLS settings and protocol of a transport
+type transportKey struct {
+	caCert   string
+	insecure bool
+	protocol models.HTTPProtocol
 }
 
 // NewHTTPChecker creates a new HTTP checker
 func NewHTTPChecker() *HTTPChecker {
 	return &HTTPChecker{
 		client: &http.Client{
-			Transport: &http.Transport{
-				TLSClientConfig: &tls.Config{
-					InsecureSkipVerify: true, // Allow self-signed certs
-				},
-				DisableKeepAlives: true,
-			},
 			CheckRedirect: func(req *http.Request, via []*http.Request) error {
 				if len(via) >= 10 {
 					return fmt.Errorf("too many redirects")
@@ -31,6 +45,70 @@ func NewHTTPChecker() *HTTPChecker {
 				return nil
 			},
 		},
+		transports: make(map[transportKey]http.RoundTripper),
+		tokens:     make(map[tokenKey]*oauthToken),
+	}
+}
+
+// transport returns the transport for the TLS settings and protocol of a
+// check, shared by the services with the same settings. A CA certificate is
+// trusted in addition to the system roots.
+func (c *HTTPChecker) transport(config *models.HTTPConfig) (http.RoundTripper, error) {
+	key := transportKey{caCert: config.CACert, insecure: config.InsecureSkipVerify, protocol: config.Protocol}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if t, ok := c.transports[key]; ok {
+		return t, nil
+	}
+
+	tlsConfig := &tls.Config{InsecureSkipVerify: key.insecure}
+	if key.caCert != "" {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM([]byte(key.caCert)) {
+			return nil, fmt.Errorf("no certificate found in caCert")
+		}
+		tlsConfig.RootCAs = pool
+	}
+	if key.protocol == models.HTTPProtocolH3 {
+		t := &http3Transport{tlsConfig: tlsConfig}
+		c.transports[key] = t
+		return t, nil
+	}
+
+	// auto negotiates HTTP/2 over TLS and falls back to HTTP/1.1; h2 on
+	// plain HTTP uses prior knowledge (h2c)
+	protocols := new(http.Protocols)
+	switch key.protocol {
+	case models.HTTPProtocolH1:
+		protocols.SetHTTP1(true)
+	case models.HTTPProtocolH2:
+		protocols.SetHTTP2(true)
+		protocols.SetUnencryptedHTTP2(true)
+	default:
+		protocols.SetHTTP1(true)
+		protocols.SetHTTP2(true)
+	}
+	t := &http.Transport{
+		TLSClientConfig:   tlsConfig,
+		DisableKeepAlives: true,
+		Protocols:         protocols,
+	}
+	c.transports[key] = t
+	return t, nil
+}
+
+// AuditTLSVerification logs when certificate verification of a service is
+// turned off or back on, and who did it
+func AuditTLSVerification(serviceID string, wasInsecure, insecure bool, by string) {
+	switch {
+	case insecure && !wasInsecure:
+		log.Printf("[Audit] Certificate verification disabled for service %s by %s", serviceID, by)
+	case !insecure && wasInsecure:
+		log.Printf("[Audit] Certificate verification enabled for service %s by %s", serviceID, by)
 	}
 }
 
@@ -40,8 +118,15 @@ func (c *HTTPChecker) Check(config *models.HTTPConfig) *CheckResult {
 		CheckedAt: time.Now(),
 	}
 
-	// Set timeout
-	c.client.Timeout = time.Duration(config.Timeout) * time.Millisecond
+	transport, err := c.transport(config)
+	if err != nil {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("Invalid transport settings: %v", err)
+		return result
+	}
+	client := *c.client
+	client.Transport = transport
+	client.Timeout = time.Duration(config.Timeout) * time.Millisecond
 
 	// Create request
 	req, err := http.NewRequest(config.Method, config.URL, nil)
@@ -61,19 +146,67 @@ func (c *HTTPChecker) Check(config *models.HTTPConfig) *CheckResult {
 		req.Header.Set("User-Agent", "MT-Monitoring/1.0")
 	}
 
+	// Authenticate, after the headers so the credentials win over a
+	// configured Authorization header
+	if config.Auth != nil {
+		if err := c.authorize(req.Context(), &client, req, config.Auth); err != nil {
+			result.Status = models.CheckStatusFailure
+			result.ErrorMessage = fmt.Sprintf("Authentication failed: %v", err)
+			return result
+		}
+	}
+
+	// Trace connection phases for the check timings and failure diagnostics
+	diag := diagnosticsConfig()
+	timings := &traceTimings{}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), timings.clientTrace()))
+
 	// Perform request
 	startTime := time.Now()
-	resp, err := c.client.Do(req)
+	resp, err := client.Do(req)
 	result.ResponseTime = int(time.Since(startTime).Milliseconds())
 
 	if err != nil {
 		result.Status = models.CheckStatusFailure
 		result.ErrorMessage = fmt.Sprintf("Request failed: %v", err)
+		result.Timings = timings.timings(time.Time{})
+		if diag.Enabled {
+			result.Details = timings.details(req.URL.Hostname())
+		}
 		return result
 	}
 	defer resp.Body.Close()
 
+	// Time the transfer by reading the rest of the body, after the
+	// diagnostics below took their snippet
+	defer func() {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxTransferBytes))
+		result.Timings = timings.timings(time.Now())
+	}()
+
+	// Capture diagnostics once the check is known to have failed
+	defer func() {
+		if !diag.Enabled || result.Status != models.CheckStatusFailure {
+			return
+		}
+		details := timings.details(req.URL.Hostname())
+		details.StatusLine = resp.Proto + " " + resp.Status
+		details.Protocol = resp.Proto
+		details.BodySnippet = readBodySnippet(resp.Body, diag.MaxBodyBytes)
+		if resp.TLS != nil {
+			details.TLS = tlsDetails(resp.TLS, nil, req.URL.Hostname())
+		}
+		result.Details = details
+	}()
+
 	result.StatusCode = resp.StatusCode
+	result.Protocol = resp.Proto
+
+	// A rejected OAuth2 token may have been revoked; request a new one
+	// for the next check
+	if resp.StatusCode == http.StatusUnauthorized && config.Auth != nil && config.Auth.Type == models.AuthTypeOAuth2 {
+		c.forgetToken(config.Auth)
+	}
 
 	// Check expected status
 	if config.ExpectedStatus > 0 && resp.StatusCode != config.ExpectedStatus {
@@ -100,6 +233,9 @@ type CheckResult struct {
 	StatusCode   int    // HTTP status code
 	ErrorMessage string
 	CheckedAt    time.Time
+	Details      *models.CheckDetails // failure diagnostics, when enabled
+	Timings      *models.CheckTimings // HTTP phases, nil for other checks
+	Protocol     string               // negotiated HTTP protocol, e.g. "HTTP/2.0"
 }
 
 // ToMetric converts CheckResult to Metric model
@@ -111,5 +247,7 @@ func (r *CheckResult) ToMetric(serviceID string) *models.Metric {
 		StatusCode:   r.StatusCode,
 		ErrorMessage: r.ErrorMessage,
 		CheckedAt:    r.CheckedAt,
+		Timings:      r.Timings,
+		Protocol:     r.Protocol,
 	}
 }
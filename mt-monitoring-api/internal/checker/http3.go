@@ -0,0 +1,499 @@
+package checker
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2/hpack"
+	"golang.org/x/net/quic"
+)
+
+// HTTP/3 frame and stream types (RFC 9114)
+const (
+	h3FrameData     = 0x0
+	h3FrameHeaders  = 0x1
+	h3FrameSettings = 0x4
+	h3StreamControl = 0x0
+)
+
+// maxH3HeadersBytes bounds the HEADERS frame of a response
+const maxH3HeadersBytes = 64 << 10
+
+// h3CloseTimeout bounds the wait for the server to acknowledge the close
+// of a connection
+const h3CloseTimeout = time.Second
+
+// http3Transport sends requests over HTTP/3. It speaks just enough of the
+// protocol for a check: one QUIC connection per request, a control stream
+// with empty SETTINGS and QPACK without the dynamic table, which the server
+// may not use since its capacity is not advertised. Request bodies are not
+// sent.
+type http3Transport struct {
+	tlsConfig *tls.Config
+}
+
+// RoundTrip implements http.RoundTripper. The connection is closed with the
+// response body.
+func (t *http3Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.URL.Scheme != "https" {
+		return nil, fmt.Errorf("HTTP/3 requires an https URL")
+	}
+	ctx := req.Context()
+	trace := httptrace.ContextClientTrace(ctx)
+
+	host := req.URL.Hostname()
+	port := req.URL.Port()
+	if port == "" {
+		port = "443"
+	}
+	addr, err := resolveHost(ctx, host, trace)
+	if err != nil {
+		return nil, err
+	}
+
+	tlsConfig := t.tlsConfig.Clone()
+	tlsConfig.NextProtos = []string{"h3"}
+	tlsConfig.MinVersion = tls.VersionTLS13
+	if tlsConfig.ServerName == "" {
+		tlsConfig.ServerName = host
+	}
+
+	endpoint, err := quic.Listen("udp", ":0", nil)
+	if err != nil {
+		return nil, err
+	}
+	closeEndpoint := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), h3CloseTimeout)
+		defer cancel()
+		endpoint.Close(ctx)
+	}
+	if trace != nil && trace.TLSHandshakeStart != nil {
+		trace.TLSHandshakeStart()
+	}
+	conn, err := endpoint.Dial(ctx, "udp", net.JoinHostPort(addr, port), &quic.Config{TLSConfig: tlsConfig})
+	if trace != nil && trace.TLSHandshakeDone != nil {
+		var state tls.ConnectionState
+		if conn != nil {
+			state = conn.ConnectionState()
+		}
+		trace.TLSHandshakeDone(state, err)
+	}
+	if err != nil {
+		closeEndpoint()
+		return nil, err
+	}
+	if trace != nil && trace.ConnectDone != nil {
+		trace.ConnectDone("udp", conn.RemoteAddr().String(), nil)
+	}
+
+	resp, err := h3Request(ctx, conn, req, trace)
+	if err != nil {
+		closeEndpoint()
+		return nil, err
+	}
+	state := conn.ConnectionState()
+	resp.TLS = &state
+	resp.Body.(*h3Body).close = closeEndpoint
+	return resp, nil
+}
+
+// resolveHost returns the first address of host, reporting the lookup to
+// the trace. IP addresses are returned as they are.
+func resolveHost(ctx context.Context, host string, trace *httptrace.ClientTrace) (string, error) {
+	if net.ParseIP(host) != nil {
+		return host, nil
+	}
+	if trace != nil && trace.DNSStart != nil {
+		trace.DNSStart(httptrace.DNSStartInfo{Host: host})
+	}
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err == nil && len(addrs) == 0 {
+		err = fmt.Errorf("no addresses for %s", host)
+	}
+	if trace != nil && trace.DNSDone != nil {
+		trace.DNSDone(httptrace.DNSDoneInfo{Addrs: addrs, Err: err})
+	}
+	if err != nil {
+		return "", err
+	}
+	return addrs[0].IP.String(), nil
+}
+
+// h3Request sends the request on a new stream of conn and reads the
+// response headers. The body of the returned response reads the DATA
+// frames that follow.
+func h3Request(ctx context.Context, conn *quic.Conn, req *http.Request, trace *httptrace.ClientTrace) (*http.Response, error) {
+	control, err := conn.NewSendOnlyStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	control.Write(appendH3Frame([]byte{h3StreamControl}, h3FrameSettings, nil))
+	control.Flush()
+
+	stream, err := conn.NewStream(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stream.SetReadContext(ctx)
+	stream.SetWriteContext(ctx)
+	if _, err := stream.Write(appendH3Frame(nil, h3FrameHeaders, encodeH3Headers(req))); err != nil {
+		return nil, err
+	}
+	stream.CloseWrite()
+	if trace != nil && trace.WroteRequest != nil {
+		trace.WroteRequest(httptrace.WroteRequestInfo{})
+	}
+
+	r := bufio.NewReader(stream)
+	first := true
+	for {
+		typ, length, err := readH3FrameHeader(r)
+		if err != nil {
+			return nil, fmt.Errorf("reading response: %w", err)
+		}
+		if first && trace != nil && trace.GotFirstResponseByte != nil {
+			trace.GotFirstResponseByte()
+		}
+		first = false
+
+		switch typ {
+		case h3FrameHeaders:
+			if length > maxH3HeadersBytes {
+				return nil, fmt.Errorf("response headers too large")
+			}
+			block := make([]byte, length)
+			if _, err := io.ReadFull(r, block); err != nil {
+				return nil, fmt.Errorf("reading response: %w", err)
+			}
+			header, err := decodeH3Headers(block)
+			if err != nil {
+				return nil, err
+			}
+			status, err := strconv.Atoi(header.Get(":status"))
+			if err != nil {
+				return nil, fmt.Errorf("invalid :status in response")
+			}
+			if status < 200 {
+				continue // informational, the final response follows
+			}
+			header.Del(":status")
+			return &http.Response{
+				Status:        strconv.Itoa(status) + " " + http.StatusText(status),
+				StatusCode:    status,
+				Proto:         "HTTP/3.0",
+				ProtoMajor:    3,
+				Header:        header,
+				Body:          &h3Body{r: r},
+				ContentLength: -1,
+				Request:       req,
+			}, nil
+		case h3FrameData:
+			return nil, fmt.Errorf("DATA frame before response headers")
+		default:
+			// Unknown and reserved frame types are skipped
+			if _, err := io.CopyN(io.Discard, r, int64(length)); err != nil {
+				return nil, fmt.Errorf("reading response: %w", err)
+			}
+		}
+	}
+}
+
+// h3Body reads the DATA frames of a response, skipping trailers and
+// unknown frames
+type h3Body struct {
+	r         *bufio.Reader
+	remaining uint64 // unread bytes of the current DATA frame
+	close     func()
+}
+
+func (b *h3Body) Read(p []byte) (int, error) {
+	for b.remaining == 0 {
+		typ, length, err := readH3FrameHeader(b.r)
+		if err != nil {
+			return 0, err
+		}
+		if typ == h3FrameData {
+			b.remaining = length
+			continue
+		}
+		if _, err := io.CopyN(io.Discard, b.r, int64(length)); err != nil {
+			return 0, err
+		}
+	}
+	if uint64(len(p)) > b.remaining {
+		p = p[:b.remaining]
+	}
+	n, err := b.r.Read(p)
+	b.remaining -= uint64(n)
+	if err == io.EOF && b.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func (b *h3Body) Close() error {
+	if b.close != nil {
+		b.close()
+	}
+	return nil
+}
+
+// readH3FrameHeader reads the type and length of the next frame. A stream
+// ending between frames returns io.EOF.
+func readH3FrameHeader(r *bufio.Reader) (typ, length uint64, err error) {
+	if typ, err = readVarint(r); err != nil {
+		return 0, 0, err
+	}
+	if length, err = readVarint(r); err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return typ, length, err
+}
+
+// appendH3Frame appends a frame to b
+func appendH3Frame(b []byte, typ uint64, payload []byte) []byte {
+	b = appendVarint(b, typ)
+	b = appendVarint(b, uint64(len(payload)))
+	return append(b, payload...)
+}
+
+// appendVarint appends a QUIC variable-length integer (RFC 9000 §16)
+func appendVarint(b []byte, v uint64) []byte {
+	switch {
+	case v < 1<<6:
+		return append(b, byte(v))
+	case v < 1<<14:
+		return append(b, 0x40|byte(v>>8), byte(v))
+	case v < 1<<30:
+		return append(b, 0x80|byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	default:
+		return append(b, 0xc0|byte(v>>56), byte(v>>48), byte(v>>40), byte(v>>32),
+			byte(v>>24), byte(v>>16), byte(v>>8), byte(v))
+	}
+}
+
+// readVarint reads a QUIC variable-length integer
+func readVarint(r io.ByteReader) (uint64, error) {
+	first, err := r.ReadByte()
+	if err != nil {
+		return 0, err
+	}
+	v := uint64(first & 0x3f)
+	for i := 1; i < 1<<(first>>6); i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, io.ErrUnexpectedEOF
+		}
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// encodeH3Headers encodes the request headers as a QPACK field section of
+// literal field lines, which need no table state
+func encodeH3Headers(req *http.Request) []byte {
+	b := []byte{0, 0} // required insert count and base: no dynamic table
+	field := func(name, value string) {
+		b = appendPrefixInt(b, 0x20, 3, uint64(len(name)))
+		b = append(b, name...)
+		b = appendPrefixInt(b, 0, 7, uint64(len(value)))
+		b = append(b, value...)
+	}
+
+	field(":method", req.Method)
+	field(":scheme", "https")
+	field(":authority", req.URL.Host)
+	field(":path", req.URL.RequestURI())
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "host" || name == "connection" {
+			continue
+		}
+		for _, v := range values {
+			field(name, v)
+		}
+	}
+	return b
+}
+
+var errQPACK = errors.New("invalid QPACK field section in response")
+
+// decodeH3Headers decodes a QPACK field section that refers only to the
+// static table
+func decodeH3Headers(block []byte) (http.Header, error) {
+	d := &qpackDecoder{b: block}
+	ric, err := d.prefixInt(8)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := d.prefixInt(7); err != nil { // base
+		return nil, err
+	}
+	if ric != 0 {
+		return nil, fmt.Errorf("response uses the QPACK dynamic table")
+	}
+
+	header := make(http.Header)
+	for len(d.b) > 0 {
+		first := d.b[0]
+		var name, value string
+		switch {
+		case first&0x80 != 0: // indexed field line
+			if first&0x40 == 0 {
+				return nil, fmt.Errorf("response uses the QPACK dynamic table")
+			}
+			index, err := d.prefixInt(6)
+			if err != nil {
+				return nil, err
+			}
+			if index >= uint64(len(qpackStaticTable)) {
+				return nil, errQPACK
+			}
+			name, value = qpackStaticTable[index][0], qpackStaticTable[index][1]
+		case first&0x40 != 0: // literal field line with name reference
+			if first&0x10 == 0 {
+				return nil, fmt.Errorf("response uses the QPACK dynamic table")
+			}
+			index, err := d.prefixInt(4)
+			if err != nil {
+				return nil, err
+			}
+			if index >= uint64(len(qpackStaticTable)) {
+				return nil, errQPACK
+			}
+			name = qpackStaticTable[index][0]
+			if value, err = d.str(7); err != nil {
+				return nil, err
+			}
+		case first&0x20 != 0: // literal field line with literal name
+			if name, err = d.str(3); err != nil {
+				return nil, err
+			}
+			if value, err = d.str(7); err != nil {
+				return nil, err
+			}
+		default: // post-base references
+			return nil, fmt.Errorf("response uses the QPACK dynamic table")
+		}
+		header.Add(name, value)
+	}
+	return header, nil
+}
+
+// qpackDecoder reads the integers and strings of a field section
+type qpackDecoder struct {
+	b []byte
+}
+
+// prefixInt reads an integer with an n-bit prefix (RFC 7541 §5.1)
+func (d *qpackDecoder) prefixInt(n uint) (uint64, error) {
+	if len(d.b) == 0 {
+		return 0, errQPACK
+	}
+	max := uint64(1)<<n - 1
+	v := uint64(d.b[0]) & max
+	d.b = d.b[1:]
+	if v < max {
+		return v, nil
+	}
+	for shift := uint(0); shift < 63; shift += 7 {
+		if len(d.b) == 0 {
+			return 0, errQPACK
+		}
+		c := d.b[0]
+		d.b = d.b[1:]
+		v += uint64(c&0x7f) << shift
+		if c&0x80 == 0 {
+			return v, nil
+		}
+	}
+	return 0, errQPACK
+}
+
+// str reads a string literal whose length has an n-bit prefix, preceded by
+// its Huffman flag
+func (d *qpackDecoder) str(n uint) (string, error) {
+	if len(d.b) == 0 {
+		return "", errQPACK
+	}
+	huffman := d.b[0]&(1<<n) != 0
+	length, err := d.prefixInt(n)
+	if err != nil {
+		return "", err
+	}
+	if length > uint64(len(d.b)) {
+		return "", errQPACK
+	}
+	s := d.b[:length]
+	d.b = d.b[length:]
+	if huffman {
+		return hpack.HuffmanDecodeToString(s)
+	}
+	return string(s), nil
+}
+
+// appendPrefixInt appends an integer with an n-bit prefix after the flag
+// bits of its first byte (RFC 7541 §5.1)
+func appendPrefixInt(b []byte, flags byte, n uint, v uint64) []byte {
+	max := uint64(1)<<n - 1
+	if v < max {
+		return append(b, flags|byte(v))
+	}
+	b = append(b, flags|byte(max))
+	for v -= max; v >= 0x80; v >>= 7 {
+		b = append(b, byte(v)|0x80)
+	}
+	return append(b, byte(v))
+}
+
+// qpackStaticTable is the QPACK static table (RFC 9204 Appendix A)
+var qpackStaticTable = [...][2]string{
+	{":authority", ""}, {":path", "/"}, {"age", "0"}, {"content-disposition", ""},
+	{"content-length", "0"}, {"cookie", ""}, {"date", ""}, {"etag", ""},
+	{"if-modified-since", ""}, {"if-none-match", ""}, {"last-modified", ""}, {"link", ""},
+	{"location", ""}, {"referer", ""}, {"set-cookie", ""}, {":method", "CONNECT"},
+	{":method", "DELETE"}, {":method", "GET"}, {":method", "HEAD"}, {":method", "OPTIONS"},
+	{":method", "POST"}, {":method", "PUT"}, {":scheme", "http"}, {":scheme", "https"},
+	{":status", "103"}, {":status", "200"}, {":status", "304"}, {":status", "404"},
+	{":status", "503"}, {"accept", "*/*"}, {"accept", "application/dns-message"},
+	{"accept-encoding", "gzip, deflate, br"}, {"accept-ranges", "bytes"},
+	{"access-control-allow-headers", "cache-control"}, {"access-control-allow-headers", "content-type"},
+	{"access-control-allow-origin", "*"}, {"cache-control", "max-age=0"},
+	{"cache-control", "max-age=2592000"}, {"cache-control", "max-age=604800"},
+	{"cache-control", "no-cache"}, {"cache-control", "no-store"},
+	{"cache-control", "public, max-age=31536000"}, {"content-encoding", "br"},
+	{"content-encoding", "gzip"}, {"content-type", "application/dns-message"},
+	{"content-type", "application/javascript"}, {"content-type", "application/json"},
+	{"content-type", "application/x-www-form-urlencoded"}, {"content-type", "image/gif"},
+	{"content-type", "image/jpeg"}, {"content-type", "image/png"}, {"content-type", "text/css"},
+	{"content-type", "text/html; charset=utf-8"}, {"content-type", "text/plain"},
+	{"content-type", "text/plain;charset=utf-8"}, {"range", "bytes=0-"},
+	{"strict-transport-security", "max-age=31536000"},
+	{"strict-transport-security", "max-age=31536000; includesubdomains"},
+	{"strict-transport-security", "max-age=31536000; includesubdomains; preload"},
+	{"vary", "accept-encoding"}, {"vary", "origin"}, {"x-content-type-options", "nosniff"},
+	{"x-xss-protection", "1; mode=block"}, {":status", "100"}, {":status", "204"},
+	{":status", "206"}, {":status", "302"}, {":status", "400"}, {":status", "403"},
+	{":status", "421"}, {":status", "425"}, {":status", "500"}, {"accept-language", ""},
+	{"access-control-allow-credentials", "FALSE"}, {"access-control-allow-credentials", "TRUE"},
+	{"access-control-allow-headers", "*"}, {"access-control-allow-methods", "get"},
+	{"access-control-allow-methods", "get, post, options"}, {"access-control-allow-methods", "options"},
+	{"access-control-expose-headers", "content-length"}, {"access-control-request-headers", "content-type"},
+	{"access-control-request-method", "get"}, {"access-control-request-method", "post"},
+	{"alt-svc", "clear"}, {"authorization", ""},
+	{"content-security-policy", "script-src 'none'; object-src 'none'; base-uri 'none'"},
+	{"early-data", "1"}, {"expect-ct", ""}, {"forwarded", ""}, {"if-range", ""}, {"origin", ""},
+	{"purpose", "prefetch"}, {"server", ""}, {"timing-allow-origin", "*"},
+	{"upgrade-insecure-requests", "1"}, {"user-agent", ""}, {"x-forwarded-for", ""},
+	{"x-frame-options", "deny"}, {"x-frame-options", "sameorigin"},
+}
@@ -0,0 +1,154 @@
+package checker
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// icmpSeq numbers echo requests so concurrent pings tell their replies apart
+var icmpSeq uint32
+
+// ICMPChecker performs ICMP echo (ping) checks. It uses unprivileged ICMP
+// sockets where the kernel allows them (net.ipv4.ping_group_range on
+// Linux) and falls back to raw sockets, which need root or CAP_NET_RAW.
+type ICMPChecker struct{}
+
+// NewICMPChecker creates a new ICMP checker
+func NewICMPChecker() *ICMPChecker {
+	return &ICMPChecker{}
+}
+
+// Check sends one echo request to host and waits up to timeout
+// milliseconds, name resolution included, for the reply
+func (c *ICMPChecker) Check(host string, timeout int) *CheckResult {
+	result := &CheckResult{
+		CheckedAt: time.Now(),
+	}
+	deadline := result.CheckedAt.Add(time.Duration(timeout) * time.Millisecond)
+
+	ip, err := resolveIP(host, deadline)
+	if err != nil {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("DNS lookup failed: %v", err)
+		return result
+	}
+
+	rtt, err := c.echo(ip, deadline)
+	if err != nil {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = fmt.Sprintf("ICMP echo failed: %v", err)
+		return result
+	}
+
+	result.ResponseTime = int(rtt.Milliseconds())
+	result.Status = models.CheckStatusSuccess
+	return result
+}
+
+// echo sends an echo request and returns the round-trip time of its reply
+func (c *ICMPChecker) echo(ip net.IP, deadline time.Time) (time.Duration, error) {
+	v4 := ip.To4() != nil
+	conn, dst, err := listenICMP(ip, v4)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+	if err := conn.SetDeadline(deadline); err != nil {
+		return 0, err
+	}
+
+	var request, reply icmp.Type = ipv4.ICMPTypeEcho, ipv4.ICMPTypeEchoReply
+	proto := 1 // ICMP
+	if !v4 {
+		request, reply = ipv6.ICMPTypeEchoRequest, ipv6.ICMPTypeEchoReply
+		proto = 58 // ICMPv6
+	}
+
+	// Unprivileged sockets rewrite the echo ID, so replies are matched by
+	// sequence number and payload
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return 0, err
+	}
+	seq := int(atomic.AddUint32(&icmpSeq, 1) & 0xffff)
+	msg := icmp.Message{
+		Type: request,
+		Body: &icmp.Echo{ID: os.Getpid() & 0xffff, Seq: seq, Data: token},
+	}
+	packet, err := msg.Marshal(nil)
+	if err != nil {
+		return 0, err
+	}
+
+	start := time.Now()
+	if _, err := conn.WriteTo(packet, dst); err != nil {
+		return 0, err
+	}
+
+	buf := make([]byte, 1500)
+	for {
+		n, _, err := conn.ReadFrom(buf)
+		if err != nil {
+			return 0, err
+		}
+		m, err := icmp.ParseMessage(proto, buf[:n])
+		if err != nil || m.Type != reply {
+			continue
+		}
+		if echo, ok := m.Body.(*icmp.Echo); ok && echo.Seq == seq && bytes.Equal(echo.Data, token) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// listenICMP opens an ICMP socket for ip and returns it with the
+// destination address in the form the socket expects
+func listenICMP(ip net.IP, v4 bool) (*icmp.PacketConn, net.Addr, error) {
+	unprivileged, raw, local := "udp4", "ip4:icmp", "0.0.0.0"
+	if !v4 {
+		unprivileged, raw, local = "udp6", "ip6:ipv6-icmp", "::"
+	}
+
+	if conn, err := icmp.ListenPacket(unprivileged, local); err == nil {
+		return conn, &net.UDPAddr{IP: ip}, nil
+	}
+	conn, err := icmp.ListenPacket(raw, local)
+	if err != nil {
+		return nil, nil, fmt.Errorf("no ICMP socket (allow net.ipv4.ping_group_range or grant CAP_NET_RAW): %w", err)
+	}
+	return conn, &net.IPAddr{IP: ip}, nil
+}
+
+// resolveIP returns the address of host, preferring IPv4
+func resolveIP(host string, deadline time.Time) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return ip, nil
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), deadline)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range addrs {
+		if a.IP.To4() != nil {
+			return a.IP, nil
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, fmt.Errorf("no addresses for %s", host)
+	}
+	return addrs[0].IP, nil
+}
@@ -0,0 +1,117 @@
+package checker
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// scheduleMaintenance registers WAL checkpoint and VACUUM jobs from config
+func (s *Scheduler) scheduleMaintenance() {
+	cfg := config.Get()
+	if cfg == nil {
+		return
+	}
+
+	if spec := cfg.Database.CheckpointSchedule; spec != "" {
+		if _, err := s.cron.AddFunc(spec, leaderOnly(s.checkpoint)); err != nil {
+			log.Printf("Invalid checkpoint schedule %q: %v", spec, err)
+		}
+	}
+	if spec := cfg.Database.VacuumSchedule; spec != "" {
+		if _, err := s.cron.AddFunc(spec, leaderOnly(s.vacuum)); err != nil {
+			log.Printf("Invalid vacuum schedule %q: %v", spec, err)
+		}
+	}
+}
+
+// checkpoint copies the WAL into the database file and truncates it
+func (s *Scheduler) checkpoint() {
+	start := time.Now()
+	result, err := s.store.Checkpoint(context.Background())
+	if err != nil {
+		log.Printf("WAL checkpoint failed: %v", err)
+		return
+	}
+
+	detail := fmt.Sprintf("%d/%d frames", result.Checkpointed, result.LogFrames)
+	if result.Busy {
+		detail += " (busy)"
+	}
+	s.recordMaintenance(models.MaintenanceCheckpoint, start, detail)
+	s.checkDatabaseSize()
+}
+
+// vacuum rebuilds the database file to reclaim space freed by cleanup
+func (s *Scheduler) vacuum() {
+	start := time.Now()
+	before, _ := s.store.Size()
+	if err := s.store.Vacuum(context.Background()); err != nil {
+		log.Printf("VACUUM failed: %v", err)
+		return
+	}
+	after, _ := s.store.Size()
+
+	detail := fmt.Sprintf("%d → %d bytes", before, after)
+	log.Printf("VACUUM completed in %s (%s)", time.Since(start).Round(time.Millisecond), detail)
+	s.recordMaintenance(models.MaintenanceVacuum, start, detail)
+}
+
+// recordMaintenance stores the last run of a maintenance task
+func (s *Scheduler) recordMaintenance(task models.MaintenanceTask, start time.Time, detail string) {
+	run := &models.MaintenanceRun{
+		Task:       task,
+		LastRunAt:  start,
+		DurationMs: time.Since(start).Milliseconds(),
+		Detail:     detail,
+	}
+	if err := s.store.Maintenance.Record(context.Background(), run); err != nil {
+		log.Printf("Failed to record %s run: %v", task, err)
+	}
+}
+
+// checkDatabaseSize alerts once when the database grows past the configured size
+// and again after it has dropped back below it
+func (s *Scheduler) checkDatabaseSize() {
+	cfg := config.Get()
+	if cfg == nil || cfg.Database.MaxSizeMB <= 0 {
+		return
+	}
+
+	fileSize, walSize := s.store.Size()
+	sizeMB := float64(fileSize+walSize) / (1024 * 1024)
+	limitMB := float64(cfg.Database.MaxSizeMB)
+
+	s.mu.Lock()
+	wasAlerting := s.dbSizeAlerting
+	s.dbSizeAlerting = sizeMB > limitMB
+	isAlerting := s.dbSizeAlerting
+	s.mu.Unlock()
+
+	if isAlerting == wasAlerting {
+		return
+	}
+
+	notification := alerter.Notification{
+		AlertType: alerter.AlertTypeSystem,
+		Metric:    "db_size",
+		Value:     sizeMB,
+		Threshold: limitMB,
+		Time:      time.Now(),
+	}
+	if isAlerting {
+		notification.Severity = "warning"
+		notification.SetMessage("msg_db_size_alert", sizeMB, limitMB)
+	} else {
+		notification.Severity = "info"
+		notification.SetMessage("msg_db_size_recovered", sizeMB, limitMB)
+	}
+
+	log.Print(notification.Message)
+	s.alerter.Dispatch(notification)
+}
@@ -0,0 +1,104 @@
+package checker
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Probe runs an ad-hoc check of target without creating a service, the
+// way blackbox_exporter's /probe does. Targets are a URL for http (http://
+// is assumed without a scheme), host:port for tcp and a host for icmp and
+// dns. The error reports an unusable module or target; a failed check is
+// a result.
+func Probe(module models.ProbeModule, target string, timeout int) (*models.ProbeResult, error) {
+	if target == "" {
+		return nil, fmt.Errorf("target is required")
+	}
+
+	probe := &models.ProbeResult{Module: module, Target: target}
+	var result *CheckResult
+	switch module {
+	case models.ProbeModuleHTTP:
+		u := target
+		if !strings.Contains(u, "://") {
+			u = "http://" + u
+		}
+		parsed, err := url.Parse(u)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return nil, fmt.Errorf("target must be an http or https URL")
+		}
+		// Probes report reachability, not certificate problems
+		result = NewHTTPChecker().Check(&models.HTTPConfig{
+			URL:                u,
+			Method:             "GET",
+			Timeout:            timeout,
+			InsecureSkipVerify: true,
+		})
+
+	case models.ProbeModuleTCP:
+		host, portStr, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("target must be host:port")
+		}
+		port, err := strconv.Atoi(portStr)
+		if err != nil || port < 1 || port > 65535 || host == "" {
+			return nil, fmt.Errorf("target must be host:port with a port between 1 and 65535")
+		}
+		result = NewTCPChecker().Check(&models.TCPConfig{
+			Host:    host,
+			Port:    port,
+			Timeout: timeout,
+		})
+
+	case models.ProbeModuleICMP:
+		host := strings.Trim(target, "[]")
+		if !validHost(host) {
+			return nil, fmt.Errorf("target must be a hostname or IP address")
+		}
+		result = NewICMPChecker().Check(host, timeout)
+
+	case models.ProbeModuleDNS:
+		if !validHost(target) {
+			return nil, fmt.Errorf("target must be a hostname")
+		}
+		result, probe.Addresses = NewDNSChecker().Check(target, timeout)
+
+	default:
+		return nil, fmt.Errorf("module must be http, tcp, icmp or dns")
+	}
+
+	probe.Success = result.Status == models.CheckStatusSuccess
+	probe.Status = result.Status
+	probe.ResponseTime = result.ResponseTime
+	probe.StatusCode = result.StatusCode
+	probe.ErrorMessage = result.ErrorMessage
+	probe.CheckedAt = result.CheckedAt
+	probe.Details = result.Details
+	return probe, nil
+}
+
+// validHost reports whether s is an IP address or looks like a hostname
+func validHost(s string) bool {
+	if net.ParseIP(s) != nil {
+		return true
+	}
+	if s == "" || len(s) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(strings.TrimSuffix(s, "."), ".") {
+		if label == "" || len(label) > 63 || strings.HasPrefix(label, "-") || strings.HasSuffix(label, "-") {
+			return false
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-' || r == '_') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,137 @@
+package checker
+
+import (
+	"context"
+	"log"
+	"reflect"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ServiceChanges lists the configured services touched by a reload
+type ServiceChanges struct {
+	Added   []string `json:"added"`
+	Updated []string `json:"updated"`
+	Removed []string `json:"removed"`
+}
+
+// ApplyServices diffs two configured service lists and applies the result
+// to the database and the running schedule. Services dropped from the
+// config are paused rather than deleted so their history is kept; adding
+// them back resumes them.
+func (s *Scheduler) ApplyServices(old, updated []config.ServiceConfig) (ServiceChanges, error) {
+	changes := ServiceChanges{Added: []string{}, Updated: []string{}, Removed: []string{}}
+
+	prev := make(map[string]config.ServiceConfig, len(old))
+	for _, svc := range old {
+		prev[svc.ID] = svc
+	}
+
+	var changed []config.ServiceConfig
+	added := make(map[string]bool)
+	next := make(map[string]bool, len(updated))
+	for _, svc := range updated {
+		if svc.ID == "" {
+			log.Printf("[Reload] Skipping configured service %q without an id", svc.Name)
+			continue
+		}
+		next[svc.ID] = true
+
+		before, existed := prev[svc.ID]
+		switch {
+		case !existed:
+			changes.Added = append(changes.Added, svc.ID)
+			added[svc.ID] = true
+		case !reflect.DeepEqual(before, svc):
+			changes.Updated = append(changes.Updated, svc.ID)
+		default:
+			continue
+		}
+		changed = append(changed, svc)
+	}
+
+	if err := s.syncServices(changed); err != nil {
+		return changes, err
+	}
+
+	ctx := context.Background()
+	for _, svc := range changed {
+		service, err := s.serviceRepo.GetByID(ctx, svc.ID)
+		if err != nil {
+			return changes, err
+		}
+		if service == nil {
+			continue
+		}
+		if !service.IsActive && added[svc.ID] {
+			// Re-added after an earlier reload paused it
+			service.IsActive = true
+			if err := s.serviceRepo.Update(ctx, service); err != nil {
+				return changes, err
+			}
+			if err := s.store.ServiceWindows.CloseOpen(ctx, service.ID, models.WindowPaused, time.Now()); err != nil {
+				log.Printf("Warning: failed to close pause window for service %s: %v", service.ID, err)
+			}
+		}
+		s.UpdateService(service)
+	}
+
+	for _, svc := range old {
+		if svc.ID == "" || next[svc.ID] {
+			continue
+		}
+		changes.Removed = append(changes.Removed, svc.ID)
+		if err := s.PauseService(ctx, svc.ID); err != nil {
+			return changes, err
+		}
+	}
+
+	return changes, nil
+}
+
+// SyncServices writes configured services to the database and reschedules
+// them. Unlike ApplyServices it never resumes a paused service.
+func (s *Scheduler) SyncServices(ctx context.Context, services []config.ServiceConfig) error {
+	if err := s.syncServices(services); err != nil {
+		return err
+	}
+	for _, svc := range services {
+		service, err := s.serviceRepo.GetByID(ctx, svc.ID)
+		if err != nil {
+			return err
+		}
+		if service != nil {
+			s.UpdateService(service)
+		}
+	}
+	return nil
+}
+
+// PauseService unschedules a service that is no longer configured and marks
+// it paused, recording a pause window so the gap is excluded from uptime.
+// History is kept.
+func (s *Scheduler) PauseService(ctx context.Context, id string) error {
+	s.RemoveService(id)
+
+	service, err := s.serviceRepo.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	if service == nil || !service.IsActive {
+		return nil
+	}
+	service.IsActive = false
+	if err := s.serviceRepo.Update(ctx, service); err != nil {
+		return err
+	}
+	if err := s.store.ServiceWindows.Create(ctx, &models.ServiceWindow{
+		ServiceID: service.ID,
+		Kind:      models.WindowPaused,
+		StartsAt:  time.Now(),
+	}); err != nil {
+		log.Printf("Warning: failed to record pause window for service %s: %v", service.ID, err)
+	}
+	return nil
+}
@@ -1,28 +1,44 @@
 package checker
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/cluster"
 	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/crypto"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/events"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/statuspage"
+	"github.com/mt-monitoring/api/internal/telemetry"
 	"github.com/robfig/cron/v3"
 )
 
 // Scheduler manages periodic health checks
 type Scheduler struct {
+	store        *database.Store
 	cron         *cron.Cron
 	entries      map[string]cron.EntryID
 	httpChecker  *HTTPChecker
 	tcpChecker   *TCPChecker
-	serviceRepo  *database.ServiceRepository
-	metricRepo   *database.MetricRepository
-	incidentRepo *database.IncidentRepository
-	logRepo      *database.LogRepository
+	browser      *BrowserChecker
+	heartbeats   *HeartbeatChecker
+	self         *SelfChecker
+	serviceRepo  database.ServiceRepository
+	metricRepo   database.MetricRepository
+	incidentRepo database.IncidentRepository
+	logRepo      database.LogRepository
+	sysRepo      database.SystemMetricRepository
+	portsRepo    database.HostPortsRepository
+	processRepo  database.ProcessMetricRepository
+	historyRepo  database.NotificationHistoryRepository
+	stateRepo    database.AlertRuleStateRepository
 
 	// Track consecutive failures
 	failureCounts map[string]int
@@ -31,41 +47,90 @@ type Scheduler struct {
 	// Track previous status for state change detection
 	prevStatus map[string]models.ServiceStatus
 
+	// When failing services went down, for the downtime of recovery alerts
+	downSince map[string]time.Time
+
 	// Alert manager
 	alerter *alerter.Manager
 
-	// Service rule evaluator for endpoint alert rules
-	serviceEvaluator *alerter.ServiceRuleEvaluator
+	// Evaluates SLO burn-rate rules every minute
+	sloRules *alerter.SLOEvaluator
+
+	// Notifies status page subscribers of incidents
+	statusPage *statuspage.Notifier
+
+	// Posts check results to the callback URLs of services
+	callbacks *CallbackSender
+
+	// Correlates failures of services checking the same host
+	groups *incidentGroups
+
+	// Jobs registered by other packages (backups, archival)
+	jobs []scheduledJob
 
-	// Broadcast function for WebSocket
-	broadcast func(interface{})
+	// Whether a database size alert is currently active
+	dbSizeAlerting bool
 }
 
 // NewScheduler creates a new scheduler
-func NewScheduler() *Scheduler {
+func NewScheduler(store *database.Store) *Scheduler {
+	manager := alerter.NewManager(store)
 	return &Scheduler{
+		store:         store,
 		cron:          cron.New(cron.WithSeconds()),
 		entries:       make(map[string]cron.EntryID),
 		httpChecker:   NewHTTPChecker(),
 		tcpChecker:    NewTCPChecker(),
-		serviceRepo:   database.NewServiceRepository(),
-		metricRepo:    database.NewMetricRepository(),
-		incidentRepo:  database.NewIncidentRepository(),
-		logRepo:       database.NewLogRepository(),
+		browser:       NewBrowserChecker(),
+		heartbeats:    NewHeartbeatChecker(),
+		self:          NewSelfChecker(store.DB()),
+		serviceRepo:   store.Services,
+		metricRepo:    store.Metrics,
+		incidentRepo:  store.Incidents,
+		logRepo:       store.Logs,
+		sysRepo:       store.SystemMetrics,
+		portsRepo:     store.HostPorts,
+		processRepo:   store.ProcessMetrics,
+		historyRepo:   store.NotificationHistory,
+		stateRepo:     store.AlertRuleStates,
 		failureCounts: make(map[string]int),
 		prevStatus:    make(map[string]models.ServiceStatus),
-		alerter:       alerter.NewManager(),
+		downSince:     make(map[string]time.Time),
+		alerter:       manager,
+		sloRules:      alerter.NewSLOEvaluator(store, manager),
+		statusPage:    statuspage.NewNotifier(store),
+		callbacks:     NewCallbackSender(),
+		groups:        newIncidentGroups(),
 	}
 }
 
-// SetServiceEvaluator sets the evaluator for endpoint-based alert rules
-func (s *Scheduler) SetServiceEvaluator(e *alerter.ServiceRuleEvaluator) {
-	s.serviceEvaluator = e
+// SetBroadcastStats sets the function returning the WebSocket broadcasts
+// dropped since startup, watched by the self-monitoring service
+func (s *Scheduler) SetBroadcastStats(dropped func() int64) {
+	s.self.droppedBroadcasts = dropped
 }
 
-// SetBroadcast sets the broadcast function for WebSocket notifications
-func (s *Scheduler) SetBroadcast(fn func(interface{})) {
-	s.broadcast = fn
+// SelfStatus returns what the last self check measured, nil before the
+// first
+func (s *Scheduler) SelfStatus() *SelfStatus {
+	return s.self.Status()
+}
+
+// scheduledJob is a cron job registered through AddJob
+type scheduledJob struct {
+	name  string
+	spec  string
+	fn    func()
+	entry cron.EntryID // set once scheduled
+}
+
+// AddJob registers a named job to run on the given cron spec (with seconds)
+// once the scheduler starts. Jobs with an empty spec are ignored.
+func (s *Scheduler) AddJob(name, spec string, fn func()) {
+	if spec == "" {
+		return
+	}
+	s.jobs = append(s.jobs, scheduledJob{name: name, spec: spec, fn: fn})
 }
 
 // Start starts the scheduler with configured services
@@ -74,9 +139,12 @@ func (s *Scheduler) Start(services []config.ServiceConfig) error {
 	if err := s.syncServices(services); err != nil {
 		return err
 	}
+	if err := s.registerSelf(); err != nil {
+		log.Printf("Failed to register self-monitoring service: %v", err)
+	}
 
 	// Schedule checks for each service from DB
-	allServices, err := s.serviceRepo.GetAll()
+	allServices, err := s.serviceRepo.GetAll(context.Background())
 	if err != nil {
 		return err
 	}
@@ -89,7 +157,31 @@ func (s *Scheduler) Start(services []config.ServiceConfig) error {
 	}
 
 	// Schedule cleanup job (run daily at midnight)
-	s.cron.AddFunc("0 0 0 * * *", s.cleanup)
+	s.cron.AddFunc("0 0 0 * * *", leaderOnly(s.cleanup))
+
+	// Schedule WAL checkpoints and VACUUM
+	s.scheduleMaintenance()
+
+	// Report failed notification deliveries
+	if cfg := config.Get(); cfg != nil {
+		s.AddJob("delivery report", cfg.Alerts.DeliveryReport.Schedule, s.alerter.SendDeliveryReport)
+	}
+
+	// Evaluate SLO burn rates at the start of every minute
+	s.AddJob("SLO burn rates", "0 * * * * *", s.sloRules.EvaluateAll)
+
+	// Schedule registered jobs (backups, archival, delivery report, SLOs)
+	for i, job := range s.jobs {
+		if entry, err := s.cron.AddFunc(job.spec, leaderOnly(job.fn)); err != nil {
+			log.Printf("Invalid %s schedule %q: %v", job.name, job.spec, err)
+		} else {
+			s.jobs[i].entry = entry
+			log.Printf("Scheduled %s (%s)", job.name, job.spec)
+		}
+	}
+
+	// Take over and hand off services as cluster nodes come and go
+	cluster.OnChange(s.Rebalance)
 
 	s.cron.Start()
 	log.Printf("Scheduler started with %d services", len(allServices))
@@ -97,6 +189,44 @@ func (s *Scheduler) Start(services []config.ServiceConfig) error {
 	return nil
 }
 
+// leaderOnly wraps a job so that, in a cluster, only the leader runs it
+func leaderOnly(fn func()) func() {
+	return func() {
+		if cluster.IsLeader() {
+			fn()
+		}
+	}
+}
+
+// Rebalance schedules the active services this node owns and removes the
+// ones another node took over. It runs when cluster membership changes.
+func (s *Scheduler) Rebalance() {
+	services, err := s.serviceRepo.GetAll(context.Background())
+	if err != nil {
+		log.Printf("Failed to rebalance services: %v", err)
+		return
+	}
+
+	added, removed := 0, 0
+	for _, svc := range services {
+		s.mu.Lock()
+		_, scheduled := s.entries[svc.ID]
+		s.mu.Unlock()
+
+		owned := svc.IsActive && cluster.Owns(svc.ID)
+		switch {
+		case owned && !scheduled:
+			service := svc
+			s.AddService(&service)
+			added++
+		case !owned && scheduled:
+			s.RemoveService(svc.ID)
+			removed++
+		}
+	}
+	log.Printf("Rebalanced services: %d added, %d removed", added, removed)
+}
+
 // AddService adds a service to the scheduler
 func (s *Scheduler) AddService(svc *models.Service) {
 	s.mu.Lock()
@@ -105,17 +235,30 @@ func (s *Scheduler) AddService(svc *models.Service) {
 	// Remove existing if any
 	if entryID, ok := s.entries[svc.ID]; ok {
 		s.cron.Remove(entryID)
+		delete(s.entries, svc.ID)
 	}
 
 	if !svc.IsActive {
 		return
 	}
+	// In a cluster, other nodes check the services they own
+	if !cluster.Owns(svc.ID) {
+		return
+	}
 
 	var spec string
 	var scheduleDesc string
 
 	// Determine schedule specification based on type
-	if svc.ScheduleType == models.ScheduleTypeCron && svc.CronExpression != "" {
+	if svc.Type == models.ServiceTypeHeartbeat {
+		// Pings are recorded as they arrive; the schedule only notices missed ones
+		every := svc.Interval
+		if every > heartbeatCheckInterval {
+			every = heartbeatCheckInterval
+		}
+		spec = fmt.Sprintf("@every %ds", every)
+		scheduleDesc = fmt.Sprintf("heartbeat: every %ds, grace %ds", svc.Interval, svc.Grace)
+	} else if svc.ScheduleType == models.ScheduleTypeCron && svc.CronExpression != "" {
 		// Use cron expression
 		spec = svc.CronExpression
 		scheduleDesc = fmt.Sprintf("cron: %s", svc.CronExpression)
@@ -165,35 +308,55 @@ func (s *Scheduler) Stop() {
 	log.Println("Scheduler stopped")
 }
 
+// ServiceFromConfig converts a configured service to a model with defaults applied
+func ServiceFromConfig(svc config.ServiceConfig) *models.Service {
+	req := &models.ServiceCreateRequest{
+		ID:             svc.ID,
+		Name:           svc.Name,
+		Type:           models.ServiceType(svc.Type),
+		URL:            svc.URL,
+		Method:         svc.Method,
+		Host:           svc.Host,
+		Port:           svc.Port,
+		Headers:        svc.Headers,
+		ExpectedStatus: svc.ExpectedStatus,
+		Timeout:        svc.Timeout,
+		Interval:       svc.Interval,
+		Tags:           svc.Tags,
+		PingKey:        svc.PingKey,
+		Grace:          svc.Grace,
+
+		CACert:             svc.CACert,
+		InsecureSkipVerify: svc.InsecureSkipVerify,
+		Protocol:           models.HTTPProtocol(svc.Protocol),
+		Selector:           svc.Selector,
+		CallbackURL:        svc.CallbackURL,
+		CallbackOn:         models.CallbackMode(svc.CallbackOn),
+	}
+	ownership := models.Ownership(svc.Ownership)
+	req.Ownership = &ownership
+	return req.ToService()
+}
+
 // syncServices syncs configured services to database
 func (s *Scheduler) syncServices(services []config.ServiceConfig) error {
 	for _, svc := range services {
-		existing, err := s.serviceRepo.GetByID(svc.ID)
+		existing, err := s.serviceRepo.GetByID(context.Background(), svc.ID)
 		if err != nil {
 			return err
 		}
 
-		req := &models.ServiceCreateRequest{
-			ID:             svc.ID,
-			Name:           svc.Name,
-			Type:           models.ServiceType(svc.Type),
-			URL:            svc.URL,
-			Method:         svc.Method,
-			Host:           svc.Host,
-			Port:           svc.Port,
-			Headers:        svc.Headers,
-			ExpectedStatus: svc.ExpectedStatus,
-			Timeout:        svc.Timeout,
-			Interval:       svc.Interval,
-			Tags:           svc.Tags,
-		}
-
-		service := req.ToService()
+		service := ServiceFromConfig(svc)
 
 		if existing == nil {
-			if err := s.serviceRepo.Create(service); err != nil {
+			if service.Type == models.ServiceTypeHeartbeat && service.PingKey == "" {
+				service.PingKey = crypto.GeneratePingKey()
+			}
+			if err := s.serviceRepo.Create(context.Background(), service); err != nil {
 				log.Printf("Failed to create service %s: %v", svc.ID, err)
+				continue
 			}
+			AuditTLSVerification(service.ID, false, service.InsecureSkipVerify, "config")
 		} else {
 			// Update existing service fields
 			existing.Name = service.Name
@@ -206,18 +369,76 @@ func (s *Scheduler) syncServices(services []config.ServiceConfig) error {
 			existing.Interval = service.Interval
 			existing.Timeout = service.Timeout
 			existing.Tags = service.Tags
-			if err := s.serviceRepo.Update(existing); err != nil {
+			existing.Grace = service.Grace
+			existing.CACert = service.CACert
+			existing.Protocol = service.Protocol
+			existing.Selector = service.Selector
+			existing.CallbackURL = service.CallbackURL
+			existing.CallbackOn = service.CallbackOn
+			existing.Ownership = service.Ownership
+			wasInsecure := existing.InsecureSkipVerify
+			if svc.InsecureSkipVerify != nil {
+				existing.InsecureSkipVerify = *svc.InsecureSkipVerify
+			}
+			// An undeclared ping key keeps the generated one
+			if service.PingKey != "" {
+				existing.PingKey = service.PingKey
+			}
+			if existing.Type == models.ServiceTypeHeartbeat && existing.PingKey == "" {
+				existing.PingKey = crypto.GeneratePingKey()
+			}
+			if err := s.serviceRepo.Update(context.Background(), existing); err != nil {
 				log.Printf("Failed to update service %s: %v", svc.ID, err)
+				continue
 			}
+			AuditTLSVerification(existing.ID, wasInsecure, existing.InsecureSkipVerify, "config")
 		}
 	}
 	return nil
 }
 
+// registerSelf creates the self-monitoring service when selfMonitor is
+// enabled and it does not exist yet. An existing one keeps its settings,
+// so it can be renamed, paused or rescheduled like any service.
+func (s *Scheduler) registerSelf() error {
+	cfg := config.Get()
+	if cfg == nil || !cfg.SelfMonitor.Enabled {
+		return nil
+	}
+	existing, err := s.serviceRepo.GetByID(context.Background(), models.SelfServiceID)
+	if err != nil || existing != nil {
+		return err
+	}
+	req := &models.ServiceCreateRequest{
+		ID:       models.SelfServiceID,
+		Name:     "MT-Monitor",
+		Type:     models.ServiceTypeSelf,
+		Interval: cfg.SelfMonitor.Interval,
+		Tags:     []string{"self"},
+	}
+	if err := s.serviceRepo.Create(context.Background(), req.ToService()); err != nil {
+		return err
+	}
+	log.Printf("Registered self-monitoring service %q", models.SelfServiceID)
+	return nil
+}
+
+// scheduledAt returns when the current cron run of a service was due,
+// zero when it has not run from cron yet
+func (s *Scheduler) scheduledAt(serviceID string) time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	entryID, ok := s.entries[serviceID]
+	if !ok {
+		return time.Time{}
+	}
+	return s.cron.Entry(entryID).Prev
+}
+
 // checkService performs a health check for a service
 func (s *Scheduler) checkService(svc *models.Service) {
 	// Re-fetch from DB to ensure we have latest IsActive status
-	service, err := s.serviceRepo.GetByID(svc.ID)
+	service, err := s.serviceRepo.GetByID(context.Background(), svc.ID)
 	if err != nil {
 		log.Printf("Failed to get service %s: %v", svc.ID, err)
 		return
@@ -226,6 +447,9 @@ func (s *Scheduler) checkService(svc *models.Service) {
 		return
 	}
 
+	ctx, op := telemetry.StartCheck(context.Background(), service.ID, string(service.Type))
+	defer op.End()
+
 	var result *CheckResult
 
 	switch service.Type {
@@ -233,71 +457,122 @@ func (s *Scheduler) checkService(svc *models.Service) {
 		result = s.httpChecker.Check(service.GetHTTPConfig())
 	case models.ServiceTypeTCP:
 		result = s.tcpChecker.Check(service.GetTCPConfig())
+	case models.ServiceTypeHeartbeat:
+		result = s.heartbeats.Check(service)
+	case models.ServiceTypeSelf:
+		if cfg := config.Get(); cfg == nil || !cfg.SelfMonitor.Enabled {
+			return
+		}
+		result = s.self.Check(s.scheduledAt(service.ID))
+	case models.ServiceTypeBrowser:
+		if !BrowserEnabled() {
+			return
+		}
+		result = s.browser.Check(service.GetBrowserConfig())
 	default:
 		log.Printf("Unknown service type: %s", service.Type)
+		op.Fail("unknown service type")
 		return
 	}
+	if result.Status != models.CheckStatusSuccess {
+		op.Fail(result.ErrorMessage)
+	}
 
 	// Save metric
 	metric := result.ToMetric(service.ID)
-	if err := s.metricRepo.Create(metric); err != nil {
+	if err := s.metricRepo.Create(ctx, metric); err != nil {
 		log.Printf("Failed to save metric for %s: %v", service.ID, err)
+	} else if result.Details != nil {
+		result.Details.MetricID = metric.ID
+		if err := s.store.CheckDetails.Create(ctx, result.Details); err != nil {
+			log.Printf("Failed to save check details for %s: %v", service.ID, err)
+		}
 	}
 
-	// Evaluate endpoint alert rules
-	if s.serviceEvaluator != nil {
-		s.serviceEvaluator.Evaluate(service.ID, service.Name, result.StatusCode, result.ResponseTime)
-	}
+	// Alerts and subscriber notifications are held while maintenance
+	// announced on the status page covers the service
+	held := s.inMaintenance(service.ID)
 
-	// Determine status for incident handling and broadcast
+	// Determine status for incident handling and broadcast. Members of
+	// an incident group leave alerting to the service that opened it.
 	var status models.ServiceStatus
+	var grouped bool
 	if result.Status == models.CheckStatusSuccess {
 		status = models.StatusHealthy
-		s.handleRecovery(service.ID)
+		grouped = s.groups.recover(service.ID)
+		s.handleRecovery(service.ID, failureThreshold(service), held || grouped)
 	} else {
 		status = models.StatusUnhealthy
-		s.handleFailure(service.ID, result.ErrorMessage)
+		grouped = s.groups.fail(service, result.CheckedAt)
+		s.handleFailure(service, result.ErrorMessage, failureThreshold(service), held || grouped)
 	}
 
 	// State change detection for alerts
 	s.mu.Lock()
 	prevStatus := s.prevStatus[service.ID]
 	s.prevStatus[service.ID] = status
+	var downtime time.Duration
+	if status == models.StatusUnhealthy {
+		if _, ok := s.downSince[service.ID]; !ok {
+			s.downSince[service.ID] = result.CheckedAt
+		}
+	} else if since, ok := s.downSince[service.ID]; ok {
+		downtime = result.CheckedAt.Sub(since)
+		delete(s.downSince, service.ID)
+	}
 	s.mu.Unlock()
 
 	// Dispatch alert only on state change
-	if prevStatus != models.StatusUnknown && prevStatus != status {
-		go s.dispatchAlert(service, status, result.ErrorMessage)
-	}
-
-	// Broadcast update
-	if s.broadcast != nil {
-		s.broadcast(map[string]interface{}{
-			"type": "metric",
-			"data": map[string]interface{}{
-				"serviceId":    service.ID,
-				"status":       string(status),
-				"responseTime": result.ResponseTime,
-				"checkedAt":    result.CheckedAt,
-			},
-		})
+	if prevStatus != models.StatusUnknown && prevStatus != status && !held && !grouped {
+		go s.dispatchAlert(service, status, result, downtime)
+	}
+
+	s.callbacks.Send(service, metric, status, prevStatus, held)
+
+	events.CheckCompleted.Publish(events.Check{
+		Service:    service,
+		Metric:     metric,
+		Status:     status,
+		PrevStatus: prevStatus,
+		Held:       held,
+	})
+}
+
+// inMaintenance reports whether maintenance announced on the status page
+// is in progress on a component containing the service
+func (s *Scheduler) inMaintenance(serviceID string) bool {
+	held, err := s.store.StatusPage.InMaintenance(context.Background(), serviceID, time.Now())
+	if err != nil {
+		log.Printf("Failed to check maintenance of %s: %v", serviceID, err)
+		return false
+	}
+	return held
+}
+
+// failureThreshold returns the consecutive failures that open an incident.
+// A heartbeat is down as soon as a ping is missed, since its checks repeat
+// the same verdict until the next ping.
+func failureThreshold(service *models.Service) int {
+	if service.Type == models.ServiceTypeHeartbeat {
+		return 1
+	}
+	if cfg := config.Get(); cfg != nil && cfg.Alerts.ConsecutiveFailures > 0 {
+		return cfg.Alerts.ConsecutiveFailures
 	}
+	return 3
 }
 
-// handleFailure handles service failure
-func (s *Scheduler) handleFailure(serviceID, errorMessage string) {
+// handleFailure handles service failure; held skips notifying status page
+// subscribers
+func (s *Scheduler) handleFailure(service *models.Service, errorMessage string, threshold int, held bool) {
+	serviceID := service.ID
 	s.mu.Lock()
 	s.failureCounts[serviceID]++
 	count := s.failureCounts[serviceID]
 	s.mu.Unlock()
 
-	cfg := config.Get()
-	threshold := 3
-	if cfg != nil && cfg.Alerts.ConsecutiveFailures > 0 {
-		threshold = cfg.Alerts.ConsecutiveFailures
-	}
-
-	// Create incident after consecutive failures
+	// Create incident after consecutive failures, under the first
+	// incident of the service's group
 	if count == threshold {
 		incident := &models.Incident{
 			ServiceID: serviceID,
@@ -305,8 +580,14 @@ func (s *Scheduler) handleFailure(serviceID, errorMessage string) {
 			Message:   errorMessage,
 			StartedAt: time.Now(),
 		}
-		if err := s.incidentRepo.Create(incident); err != nil {
+		if parent := s.groups.parent(serviceID); parent != 0 {
+			incident.ParentID = &parent
+		}
+		if err := s.incidentRepo.Create(context.Background(), incident); err != nil {
 			log.Printf("Failed to create incident for %s: %v", serviceID, err)
+		} else {
+			s.groups.opened(serviceID, incident.ID)
+			go s.traceIncident(service, incident)
 		}
 
 		// Log error
@@ -316,37 +597,45 @@ func (s *Scheduler) handleFailure(serviceID, errorMessage string) {
 			Message:   fmt.Sprintf("Service down: %s", errorMessage),
 			CreatedAt: time.Now(),
 		}
-		s.logRepo.Create(logEntry)
-
-		// Broadcast incident
-		if s.broadcast != nil {
-			s.broadcast(map[string]interface{}{
-				"type": "incident",
-				"data": incident,
-			})
+		s.writeLog(logEntry)
+
+		events.IncidentCreated.Publish(incident)
+		if !held {
+			go s.statusPage.Notify(statuspage.EventCreated, incident, "")
 		}
 
 		log.Printf("Incident created for service %s: %s", serviceID, errorMessage)
 	}
 }
 
-// handleRecovery handles service recovery
-func (s *Scheduler) handleRecovery(serviceID string) {
+// handleRecovery handles service recovery; held skips notifying status
+// page subscribers
+func (s *Scheduler) handleRecovery(serviceID string, threshold int, held bool) {
 	s.mu.Lock()
 	previousCount := s.failureCounts[serviceID]
 	s.failureCounts[serviceID] = 0
 	s.mu.Unlock()
 
-	cfg := config.Get()
-	threshold := 3
-	if cfg != nil && cfg.Alerts.ConsecutiveFailures > 0 {
-		threshold = cfg.Alerts.ConsecutiveFailures
-	}
-
 	// Resolve incident if there was one
 	if previousCount >= threshold {
-		if err := s.incidentRepo.Resolve(serviceID); err != nil {
+		// Read the incidents being resolved to publish them
+		active, err := s.incidentRepo.GetActive(context.Background())
+		if err != nil {
+			log.Printf("Failed to get active incidents: %v", err)
+		}
+		if err := s.incidentRepo.Resolve(context.Background(), serviceID); err != nil {
 			log.Printf("Failed to resolve incident for %s: %v", serviceID, err)
+		} else {
+			resolvedAt := time.Now()
+			for i := range active {
+				if active[i].ServiceID == serviceID {
+					active[i].ResolvedAt = &resolvedAt
+					events.IncidentResolved.Publish(&active[i])
+					if !held {
+						go s.statusPage.Notify(statuspage.EventResolved, &active[i], "")
+					}
+				}
+			}
 		}
 
 		// Log recovery
@@ -356,12 +645,21 @@ func (s *Scheduler) handleRecovery(serviceID string) {
 			Message:   "Service recovered",
 			CreatedAt: time.Now(),
 		}
-		s.logRepo.Create(logEntry)
+		s.writeLog(logEntry)
 
 		log.Printf("Service %s recovered", serviceID)
 	}
 }
 
+// writeLog stores a log entry and publishes it for live tail subscribers
+func (s *Scheduler) writeLog(entry *models.Log) {
+	if err := s.logRepo.Create(context.Background(), entry); err != nil {
+		log.Printf("Failed to store log for %s: %v", entry.ServiceID, err)
+		return
+	}
+	events.LogWritten.Publish(entry)
+}
+
 // cleanup removes old data based on retention settings
 func (s *Scheduler) cleanup() {
 	cfg := config.Get()
@@ -369,31 +667,141 @@ func (s *Scheduler) cleanup() {
 		return
 	}
 
+	start := time.Now()
+	defer func() {
+		s.recordMaintenance(models.MaintenanceCleanup, start, "")
+		s.checkDatabaseSize()
+	}()
+
 	// Delete old metrics
 	metricRetention := config.GetRetentionDuration(cfg.Retention.Metrics)
-	if deleted, err := s.metricRepo.DeleteOld(metricRetention); err == nil {
+	if deleted, err := s.metricRepo.DeleteOld(context.Background(), metricRetention); err == nil {
 		log.Printf("Cleaned up %d old metrics", deleted)
 	}
+	if cfg.Retention.Rollups != "" {
+		rollupRetention := config.GetRetentionDuration(cfg.Retention.Rollups)
+		if deleted, err := s.metricRepo.DeleteOldRollups(context.Background(), rollupRetention); err == nil {
+			log.Printf("Cleaned up %d old metric rollups", deleted)
+		}
+	}
+	s.sampleMetrics(cfg)
 
-	// Delete old logs
-	logRetention := config.GetRetentionDuration(cfg.Retention.Logs)
-	if deleted, err := s.logRepo.DeleteOld(logRetention); err == nil {
+	// Delete old logs (global default, per-level and per-service policies)
+	if deleted, err := s.logRepo.DeleteByPolicy(context.Background(), s.logRetentionPolicy(cfg)); err == nil {
 		log.Printf("Cleaned up %d old logs", deleted)
+	} else {
+		log.Printf("Failed to clean up old logs: %v", err)
 	}
 
 	// Delete old system metrics
 	if cfg.Retention.SystemMetrics != "" {
 		sysRetention := config.GetRetentionDuration(cfg.Retention.SystemMetrics)
-		sysRepo := database.NewSystemMetricRepository()
-		if deleted, err := sysRepo.DeleteOld(sysRetention); err == nil {
+		if deleted, err := s.sysRepo.DeleteOld(context.Background(), sysRetention); err == nil {
 			log.Printf("Cleaned up %d old system metrics", deleted)
 		}
+		if deleted, err := s.portsRepo.DeleteOldSnapshots(context.Background(), sysRetention); err == nil {
+			log.Printf("Cleaned up %d old port snapshots", deleted)
+		}
+		if deleted, err := s.processRepo.DeleteOld(context.Background(), sysRetention); err == nil {
+			log.Printf("Cleaned up %d old process samples", deleted)
+		}
+	}
+
+	// Delete old notification history and resolved incidents
+	if cfg.Retention.NotificationHistory != "" {
+		cutoff := time.Now().Add(-config.GetRetentionDuration(cfg.Retention.NotificationHistory))
+		if deleted, err := s.historyRepo.DeleteBefore(context.Background(), cutoff); err == nil {
+			log.Printf("Cleaned up %d old notification history records", deleted)
+		} else {
+			log.Printf("Failed to clean up old notification history: %v", err)
+		}
+	}
+	if cfg.Retention.Incidents != "" {
+		cutoff := time.Now().Add(-config.GetRetentionDuration(cfg.Retention.Incidents))
+		if deleted, err := s.incidentRepo.DeleteResolvedBefore(context.Background(), cutoff); err == nil {
+			log.Printf("Cleaned up %d old resolved incidents", deleted)
+		} else {
+			log.Printf("Failed to clean up old incidents: %v", err)
+		}
+	}
+
+	// Delete alert rule states of deleted hosts and services
+	if deleted, err := s.stateRepo.DeleteOrphans(context.Background()); err == nil {
+		log.Printf("Cleaned up %d alert rule states of deleted hosts and services", deleted)
+	} else {
+		log.Printf("Failed to clean up alert rule states: %v", err)
 	}
 }
 
+// sampleMetrics thins out the raw checks of each service older than
+// retention.sampleAfter to its metric sampling rate
+func (s *Scheduler) sampleMetrics(cfg *config.Config) {
+	services, err := s.serviceRepo.GetAll(context.Background())
+	if err != nil {
+		log.Printf("Failed to load services for metric sampling: %v", err)
+		return
+	}
+	age := 48 * time.Hour
+	if cfg.Retention.SampleAfter != "" {
+		age = config.GetRetentionDuration(cfg.Retention.SampleAfter)
+	}
+
+	var total int64
+	for _, svc := range services {
+		every := svc.MetricSampling
+		if every == 0 {
+			every = cfg.Retention.MetricSampling
+		}
+		// Cron-scheduled services have no fixed interval; sample by minute
+		interval := time.Duration(svc.Interval) * time.Second
+		if svc.ScheduleType == models.ScheduleTypeCron || interval <= 0 {
+			interval = time.Minute
+		}
+		deleted, err := s.metricRepo.SampleOld(context.Background(), svc.ID, age, every, interval)
+		if err != nil {
+			log.Printf("Failed to sample metrics of %s: %v", svc.Name, err)
+			continue
+		}
+		total += deleted
+	}
+	if total > 0 {
+		log.Printf("Sampled out %d raw metrics older than %v", total, age)
+	}
+}
+
+// logRetentionPolicy builds the log retention policy from config and per-service overrides
+func (s *Scheduler) logRetentionPolicy(cfg *config.Config) models.LogRetentionPolicy {
+	policy := models.LogRetentionPolicy{
+		Default:  config.GetRetentionDuration(cfg.Retention.Logs),
+		Levels:   make(map[models.LogLevel]time.Duration),
+		Services: make(map[string]time.Duration),
+	}
+
+	for level, retention := range cfg.Retention.LogLevels {
+		lvl := models.LogLevel(strings.ToLower(level))
+		if !lvl.IsValid() || !config.IsValidRetention(retention) {
+			log.Printf("Ignoring invalid log retention for level %q: %q", level, retention)
+			continue
+		}
+		policy.Levels[lvl] = config.GetRetentionDuration(retention)
+	}
+
+	overrides, err := s.serviceRepo.GetLogRetentionOverrides(context.Background())
+	if err != nil {
+		log.Printf("Failed to load per-service log retention: %v", err)
+	}
+	for serviceID, retention := range overrides {
+		if config.IsValidRetention(retention) {
+			policy.Services[serviceID] = config.GetRetentionDuration(retention)
+		}
+	}
+
+	return policy
+}
+
 // CheckNow performs an immediate check for a service
 func (s *Scheduler) CheckNow(serviceID string) (*CheckResult, error) {
-	service, err := s.serviceRepo.GetByID(serviceID)
+	service, err := s.serviceRepo.GetByID(context.Background(), serviceID)
 	if err != nil {
 		return nil, err
 	}
@@ -404,7 +812,7 @@ func (s *Scheduler) CheckNow(serviceID string) (*CheckResult, error) {
 	s.checkService(service)
 
 	// Return the latest result
-	metrics, err := s.metricRepo.GetByServiceID(serviceID, 1)
+	metrics, err := s.metricRepo.GetByServiceID(context.Background(), serviceID, 1)
 	if err != nil || len(metrics) == 0 {
 		return nil, fmt.Errorf("failed to get check result")
 	}
@@ -418,20 +826,47 @@ func (s *Scheduler) CheckNow(serviceID string) (*CheckResult, error) {
 	}, nil
 }
 
-// dispatchAlert sends an alert notification
-func (s *Scheduler) dispatchAlert(service *models.Service, status models.ServiceStatus, errorMessage string) {
-	message := "Service is healthy"
-	if status == models.StatusUnhealthy {
-		message = errorMessage
+// Ping records a ping of a heartbeat service. Completions are checked
+// right away so a failure or recovery is not held until the next
+// scheduled check.
+func (s *Scheduler) Ping(service *models.Service, kind PingKind, message string) {
+	s.heartbeats.Record(service.ID, kind, message)
+	if kind != PingStart {
+		s.checkService(service)
 	}
+}
 
+// dispatchAlert sends an alert notification to the channels of the
+// service, or to alerts.defaultChannelIds when it has none. The alert
+// carries the check that changed the status, the uptime of the last 24
+// hours, the downtime on recovery and a link to the service page.
+func (s *Scheduler) dispatchAlert(service *models.Service, status models.ServiceStatus, result *CheckResult, downtime time.Duration) {
 	notification := alerter.Notification{
-		ServiceID:   service.ID,
-		ServiceName: service.Name,
-		Status:      status,
-		Message:     message,
-		Time:        time.Now(),
+		ServiceID:    service.ID,
+		ServiceName:  service.Name,
+		Status:       status,
+		Message:      result.ErrorMessage,
+		Time:         time.Now(),
+		StatusCode:   result.StatusCode,
+		ResponseTime: result.ResponseTime,
+		Downtime:     downtime,
+		URL:          alerter.ServiceURL(service.ID),
+	}
+	if status != models.StatusUnhealthy {
+		notification.SetMessage("msg_service_healthy")
+	}
+	summary, err := s.metricRepo.GetSummary(context.Background(), service.ID, 24*time.Hour, config.GetApdexThreshold())
+	if err != nil {
+		log.Printf("Failed to get uptime of %s: %v", service.ID, err)
+	} else if summary.TotalChecks > 0 {
+		notification.Uptime24h = &summary.Uptime
 	}
 
-	s.alerter.Dispatch(notification)
+	channelIDs := service.ChannelIDs
+	if len(channelIDs) == 0 {
+		if cfg := config.Get(); cfg != nil {
+			channelIDs = cfg.Alerts.DefaultChannelIDs
+		}
+	}
+	s.alerter.DispatchToChannels(notification, channelIDs)
 }
@@ -0,0 +1,112 @@
+package checker
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// selfDBTimeout bounds the database round trip of a self check
+const selfDBTimeout = 5 * time.Second
+
+// SelfStatus is what the last self check measured
+type SelfStatus struct {
+	CheckedAt         time.Time `json:"checkedAt"`
+	SchedulerLag      int64     `json:"schedulerLag"` // ms the check started late
+	DBLatency         int64     `json:"dbLatency"`    // ms
+	Goroutines        int       `json:"goroutines"`
+	DroppedBroadcasts int64     `json:"droppedBroadcasts"` // since the previous check
+	Problems          []string  `json:"problems,omitempty"`
+}
+
+// SelfChecker checks the health of the monitoring server itself: how late
+// scheduled checks start, database latency, goroutine count and dropped
+// WebSocket broadcasts. A check fails when one exceeds its selfMonitor
+// limit; its response time is the database latency.
+type SelfChecker struct {
+	db *sql.DB
+
+	// droppedBroadcasts returns the broadcasts dropped since startup, nil
+	// without a WebSocket hub
+	droppedBroadcasts func() int64
+
+	mu          sync.Mutex
+	lastRun     time.Time
+	lastDropped int64
+	status      *SelfStatus
+}
+
+// NewSelfChecker creates a new self checker
+func NewSelfChecker(db *sql.DB) *SelfChecker {
+	return &SelfChecker{db: db}
+}
+
+// Check measures the server. scheduled is when the cron run being served
+// was due, zero outside scheduled runs; runs already measured (manual
+// checks after a scheduled one) report no lag.
+func (c *SelfChecker) Check(scheduled time.Time) *CheckResult {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	st := &SelfStatus{CheckedAt: now, Goroutines: runtime.NumGoroutine()}
+	if !scheduled.IsZero() && scheduled.After(c.lastRun) {
+		st.SchedulerLag = now.Sub(scheduled).Milliseconds()
+	}
+	c.lastRun = now
+
+	ctx, cancel := context.WithTimeout(context.Background(), selfDBTimeout)
+	start := time.Now()
+	err := c.db.QueryRowContext(ctx, "SELECT 1").Scan(new(int))
+	st.DBLatency = time.Since(start).Milliseconds()
+	cancel()
+	if err != nil {
+		st.Problems = append(st.Problems, "database: "+err.Error())
+	}
+
+	if c.droppedBroadcasts != nil {
+		dropped := c.droppedBroadcasts()
+		st.DroppedBroadcasts = max(dropped-c.lastDropped, 0)
+		c.lastDropped = dropped
+	}
+
+	var limits config.SelfMonitorConfig
+	if cfg := config.Get(); cfg != nil {
+		limits = cfg.SelfMonitor
+	}
+	exceeds := func(what string, value int64, limit int, unit string) {
+		if limit > 0 && value > int64(limit) {
+			st.Problems = append(st.Problems, fmt.Sprintf("%s %d%s exceeds %d%s", what, value, unit, limit, unit))
+		}
+	}
+	exceeds("scheduler lag", st.SchedulerLag, limits.MaxSchedulerLag, "ms")
+	exceeds("database latency", st.DBLatency, limits.MaxDBLatency, "ms")
+	exceeds("goroutines", int64(st.Goroutines), limits.MaxGoroutines, "")
+	exceeds("dropped broadcasts", st.DroppedBroadcasts, limits.MaxDroppedBroadcasts, "")
+	c.status = st
+
+	result := &CheckResult{
+		Status:       models.CheckStatusSuccess,
+		ResponseTime: int(st.DBLatency),
+		CheckedAt:    now,
+	}
+	if len(st.Problems) > 0 {
+		result.Status = models.CheckStatusFailure
+		result.ErrorMessage = strings.Join(st.Problems, "; ")
+	}
+	return result
+}
+
+// Status returns what the last self check measured, nil before the first
+func (c *SelfChecker) Status() *SelfStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
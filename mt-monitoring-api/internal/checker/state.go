@@ -0,0 +1,73 @@
+package checker
+
+import (
+	"sort"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ScheduledCheck describes a scheduled service check
+type ScheduledCheck struct {
+	ServiceID           string               `json:"serviceId"`
+	Next                *time.Time           `json:"next,omitempty"`
+	Prev                *time.Time           `json:"prev,omitempty"`
+	ConsecutiveFailures int                  `json:"consecutiveFailures"`
+	LastStatus          models.ServiceStatus `json:"lastStatus,omitempty"`
+}
+
+// ScheduledJobState describes a job registered with AddJob
+type ScheduledJobState struct {
+	Name string     `json:"name"`
+	Spec string     `json:"spec"`
+	Next *time.Time `json:"next,omitempty"` // nil when the spec was invalid or before Start
+	Prev *time.Time `json:"prev,omitempty"`
+}
+
+// SchedulerState is a snapshot of the scheduler for diagnostics
+type SchedulerState struct {
+	Checks      []ScheduledCheck    `json:"checks"`
+	Jobs        []ScheduledJobState `json:"jobs"`
+	CronEntries int                 `json:"cronEntries"` // including cleanup and maintenance
+}
+
+// State returns the scheduled checks and jobs with their next and previous
+// run times
+func (s *Scheduler) State() SchedulerState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	state := SchedulerState{
+		Checks:      make([]ScheduledCheck, 0, len(s.entries)),
+		Jobs:        make([]ScheduledJobState, 0, len(s.jobs)),
+		CronEntries: len(s.cron.Entries()),
+	}
+	for id, entryID := range s.entries {
+		entry := s.cron.Entry(entryID)
+		state.Checks = append(state.Checks, ScheduledCheck{
+			ServiceID:           id,
+			Next:                optionalTime(entry.Next),
+			Prev:                optionalTime(entry.Prev),
+			ConsecutiveFailures: s.failureCounts[id],
+			LastStatus:          s.prevStatus[id],
+		})
+	}
+	sort.Slice(state.Checks, func(i, j int) bool { return state.Checks[i].ServiceID < state.Checks[j].ServiceID })
+
+	for _, job := range s.jobs {
+		js := ScheduledJobState{Name: job.name, Spec: job.spec}
+		if job.entry != 0 {
+			entry := s.cron.Entry(job.entry)
+			js.Next, js.Prev = optionalTime(entry.Next), optionalTime(entry.Prev)
+		}
+		state.Jobs = append(state.Jobs, js)
+	}
+	return state
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
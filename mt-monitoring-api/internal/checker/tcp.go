@@ -3,6 +3,7 @@ package checker
 import (
 	"fmt"
 	"net"
+	"strconv"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
@@ -22,7 +23,7 @@ func (c *TCPChecker) Check(config *models.TCPConfig) *CheckResult {
 		CheckedAt: time.Now(),
 	}
 
-	address := fmt.Sprintf("%s:%d", config.Host, config.Port)
+	address := net.JoinHostPort(config.Host, strconv.Itoa(config.Port))
 	timeout := time.Duration(config.Timeout) * time.Millisecond
 
 	// Attempt connection
@@ -33,6 +34,10 @@ func (c *TCPChecker) Check(config *models.TCPConfig) *CheckResult {
 	if err != nil {
 		result.Status = models.CheckStatusFailure
 		result.ErrorMessage = fmt.Sprintf("TCP connection failed: %v", err)
+		if diagnosticsConfig().Enabled {
+			connectMs := result.ResponseTime
+			result.Details = &models.CheckDetails{RemoteAddr: address, ConnectMs: &connectMs}
+		}
 		return result
 	}
 
@@ -0,0 +1,86 @@
+package checker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// maxTraceOutput bounds the traceroute output kept with an incident
+const maxTraceOutput = 64 << 10
+
+// tracerouteConfig returns the traceroute settings, nil when disabled
+func tracerouteConfig() *config.TracerouteConfig {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Diagnostics.Traceroute.Enabled {
+		return nil
+	}
+	t := cfg.Diagnostics.Traceroute
+	return &t
+}
+
+// tracerouteArgs returns the command tracing the path to target. Names are
+// not resolved for the hops so the trace is not slowed down by reverse DNS.
+func tracerouteArgs(cfg *config.TracerouteConfig, target string) []string {
+	hops := strconv.Itoa(cfg.MaxHops)
+	if cfg.Tool == "mtr" {
+		return []string{"mtr", "--report", "--report-cycles", "3", "--no-dns", "--max-ttl", hops, target}
+	}
+	return []string{"traceroute", "-n", "-m", hops, "-w", "2", target}
+}
+
+// traceIncident traces the path to the target of a service whose incident
+// just opened and stores it with the incident. Incidents grouped under
+// another share its target and are not traced again.
+func (s *Scheduler) traceIncident(service *models.Service, incident *models.Incident) {
+	cfg := tracerouteConfig()
+	if cfg == nil || incident.ID == 0 || incident.ParentID != nil {
+		return
+	}
+	target := TargetHost(service)
+	if target == "" || strings.HasPrefix(target, "-") {
+		return
+	}
+
+	trace := runTraceroute(cfg, target)
+	trace.IncidentID = incident.ID
+	if err := s.incidentRepo.SaveTrace(context.Background(), trace); err != nil {
+		log.Printf("Failed to save trace of incident %d: %v", incident.ID, err)
+	}
+}
+
+// runTraceroute runs the configured tool against target. The output so far
+// is kept when it fails or runs out of time.
+func runTraceroute(cfg *config.TracerouteConfig, target string) *models.Trace {
+	args := tracerouteArgs(cfg, target)
+	trace := &models.Trace{
+		Target:    target,
+		Command:   strings.Join(args, " "),
+		CreatedAt: time.Now(),
+	}
+
+	timeout := time.Duration(cfg.Timeout) * time.Second
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	output, err := exec.CommandContext(ctx, args[0], args[1:]...).CombinedOutput()
+
+	if len(output) > maxTraceOutput {
+		output = output[:maxTraceOutput]
+	}
+	trace.Output = string(output)
+	switch {
+	case errors.Is(ctx.Err(), context.DeadlineExceeded):
+		trace.Error = fmt.Sprintf("stopped after %s", timeout)
+	case err != nil:
+		trace.Error = err.Error()
+	}
+	return trace
+}
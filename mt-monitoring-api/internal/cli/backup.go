@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mt-monitoring/api/internal/backup"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// runBackup writes a full backup archive (database snapshot plus config
+// files). It is safe to run while the server is up, e.g. from cron.
+func runBackup(args []string) int {
+	fs, configPath := newFlagSet("backup")
+	out := fs.String("out", "", "archive path (default: a timestamped file in backup.dir, pruned to backup.keep)")
+	noUpload := fs.Bool("no-upload", false, "don't upload to backup.s3 even when configured")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	store, err := openStore(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer store.Close()
+
+	result, err := backup.NewManager(store).RunArchive(context.Background(), *out, !*noUpload)
+	if err != nil {
+		// The archive may exist even if the upload failed
+		if result != nil && *asJSON {
+			printJSON(result)
+		}
+		return fail("%v", err)
+	}
+
+	if *asJSON {
+		printJSON(result)
+		return 0
+	}
+	fmt.Printf("Backup written to %s (%d bytes)\n", result.Path, result.Size)
+	if result.Location != "" {
+		fmt.Printf("Uploaded to %s\n", result.Location)
+	}
+	if result.Pruned > 0 {
+		fmt.Printf("Pruned %d old archive(s)\n", result.Pruned)
+	}
+	return 0
+}
+
+// runRestore restores a backup archive. The server must be stopped first.
+func runRestore(args []string) int {
+	fs, configPath := newFlagSet("restore")
+	from := fs.String("from", "", "backup archive to restore (or the first argument)")
+	dbPath := fs.String("db", "", "database to replace (default: database.path from the config)")
+	configDir := fs.String("config-dir", "", "also restore the config files into this directory")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *from == "" {
+		*from = fs.Arg(0)
+	}
+	if *from == "" {
+		return fail("an archive is required (-from)")
+	}
+
+	if *dbPath == "" {
+		cfg, err := config.Load(*configPath)
+		if err != nil {
+			return fail("cannot determine the database path, use -db: %v", err)
+		}
+		*dbPath = cfg.Database.Path
+	}
+
+	result, err := backup.Restore(context.Background(), *from, backup.RestoreOptions{
+		DatabasePath: *dbPath,
+		ConfigDir:    *configDir,
+	})
+	if err != nil {
+		return fail("restore failed: %v", err)
+	}
+
+	if *asJSON {
+		printJSON(result)
+		return 0
+	}
+	fmt.Printf("Database restored to %s from the backup of %s\n",
+		result.DatabasePath, result.Manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+	if result.PreviousPath != "" {
+		fmt.Printf("Previous database kept at %s\n", result.PreviousPath)
+	}
+	for _, file := range result.ConfigFiles {
+		fmt.Printf("Config restored to %s\n", file)
+	}
+	if *configDir == "" && len(result.Manifest.Config) > 0 {
+		fmt.Printf("Archive also holds %d config file(s); use -config-dir to restore them\n", len(result.Manifest.Config))
+	}
+	return 0
+}
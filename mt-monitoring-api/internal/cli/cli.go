@@ -0,0 +1,104 @@
+// Package cli implements maintenance subcommands of the server binary, such
+// as "server integrity --repair". The server entry point calls Run with
+// os.Args[1:] before starting the HTTP server.
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+// command is a maintenance subcommand
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) int
+}
+
+var commands = []command{
+	{name: "backup", summary: "Write a backup archive (database snapshot + config), safe while the server runs", run: runBackup},
+	{name: "diagnostics", summary: "Write a support bundle zip (logs, profiles, state, redacted config, DB stats)", run: runDiagnostics},
+	{name: "healthcheck", summary: "Exit non-zero unless the local server is healthy (for container probes)", run: runHealthcheck},
+	{name: "integrity", summary: "Check database integrity and optionally repair it", run: runIntegrity},
+	{name: "migrate", summary: "Show, apply or roll back schema migrations, or create a new one", run: runMigrate},
+	{name: "restore", summary: "Restore a backup archive (stop the server first)", run: runRestore},
+	{name: "rotate-key", summary: "Re-encrypt stored secrets with a new encryption key", run: runRotateKey},
+	{name: "validate-config", summary: "Validate the configuration file (also --validate-config)", run: runValidateConfig},
+}
+
+// Run executes the subcommand named by args[0] and returns its exit code.
+// handled is false when args do not name a subcommand, in which case the
+// caller should start the server as usual.
+func Run(args []string) (handled bool, code int) {
+	if len(args) == 0 {
+		return false, 0
+	}
+
+	switch args[0] {
+	case "help", "-h", "--help":
+		usage(os.Stdout)
+		return true, 0
+	case "--validate-config":
+		return true, runValidateConfig(args[1:])
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[0] {
+			return true, cmd.run(args[1:])
+		}
+	}
+	return false, 0
+}
+
+// usage prints the list of subcommands
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: server [command] [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Without a command the API server starts. Commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, `Run "server <command> -h" for command flags.`)
+}
+
+// newFlagSet creates a flag set with the shared -config flag
+func newFlagSet(name string) (*flag.FlagSet, *string) {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	configPath := fs.String("config", "", "path to config file, JSON or YAML (default: ./config.{json,yaml,yml} or ./config/config.*)")
+	return fs, configPath
+}
+
+// openStore loads configuration and opens the configured database,
+// applying pending migrations unless database.autoMigrate is off
+func openStore(configPath string) (*database.Store, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, err
+	}
+	timeout := time.Duration(cfg.Database.QueryTimeout) * time.Second
+	if !cfg.Database.AutoMigrate {
+		return database.OpenExisting(cfg.Database.Path, timeout)
+	}
+	return database.Open(cfg.Database.Path, timeout)
+}
+
+// printJSON writes v as indented JSON to stdout
+func printJSON(v interface{}) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+// fail prints an error to stderr and returns exit code 1
+func fail(format string, args ...interface{}) int {
+	fmt.Fprintf(os.Stderr, "error: "+format+"\n", args...)
+	return 1
+}
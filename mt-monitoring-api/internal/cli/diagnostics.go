@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/diagnostics"
+)
+
+// runDiagnostics downloads a support bundle from the running server. When
+// the server is not reachable it writes an offline bundle with the redacted
+// config and database statistics instead.
+func runDiagnostics(args []string) int {
+	fs, configPath := newFlagSet("diagnostics")
+	out := fs.String("out", "", "output file (default: mt-diagnostics-<timestamp>.zip)")
+	url := fs.String("url", "", "diagnostics endpoint (default: http://127.0.0.1:<server.port>/api/v1/admin/diagnostics)")
+	token := fs.String("token", "", "admin token (default: security.adminToken)")
+	timeout := fs.Duration("timeout", time.Minute, "request timeout")
+	offline := fs.Bool("offline", false, "don't contact the server, bundle config and database statistics only")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *out == "" {
+		*out = fmt.Sprintf("mt-diagnostics-%s.zip", time.Now().UTC().Format("20060102-150405"))
+	}
+	if *url == "" {
+		*url = localAPIURL(*configPath, "/api/v1/admin/diagnostics")
+	}
+	if *token == "" {
+		if cfg, err := config.LoadFile(*configPath); err == nil {
+			*token = cfg.Security.AdminToken
+		}
+	}
+
+	if !*offline {
+		err := downloadBundle(*url, *token, *out, *timeout)
+		if err == nil {
+			fmt.Printf("Wrote %s\n", *out)
+			return 0
+		}
+		var unreachable *serverUnreachableError
+		if !errors.As(err, &unreachable) {
+			return fail("%v", err)
+		}
+		fmt.Fprintf(os.Stderr, "warning: %v; writing an offline bundle without logs, profiles or runtime state\n", err)
+	}
+
+	store, err := openStore(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer store.Close()
+
+	err = writeFile(*out, func(w io.Writer) error {
+		return diagnostics.NewOfflineCollector(store).WriteBundle(context.Background(), w)
+	})
+	if err != nil {
+		return fail("%v", err)
+	}
+	fmt.Printf("Wrote %s (offline)\n", *out)
+	return 0
+}
+
+// serverUnreachableError means no server answered, as opposed to a server
+// that answered with an error
+type serverUnreachableError struct{ err error }
+
+func (e *serverUnreachableError) Error() string { return "server not reachable: " + e.err.Error() }
+
+func downloadBundle(url, token, dst string, timeout time.Duration) error {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return &serverUnreachableError{err: err}
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("diagnostics request failed: HTTP %d: %s", resp.StatusCode, body)
+	}
+	return writeFile(dst, func(w io.Writer) error {
+		_, err := io.Copy(w, resp.Body)
+		return err
+	})
+}
+
+// writeFile writes a file that may contain sensitive data, removing it
+// again when write fails
+func writeFile(path string, write func(w io.Writer) error) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	err = write(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(path)
+	}
+	return err
+}
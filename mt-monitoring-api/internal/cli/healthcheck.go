@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// runHealthcheck queries the local health endpoint for container probes
+// (Docker HEALTHCHECK, Kubernetes exec probes) so the image needs no curl.
+// Exit code 0 means healthy, 1 means the server is down or unhealthy.
+func runHealthcheck(args []string) int {
+	fs, configPath := newFlagSet("healthcheck")
+	url := fs.String("url", "", "health endpoint (default: http://127.0.0.1:<server.port>/api/v1/health)")
+	timeout := fs.Duration("timeout", 5*time.Second, "request timeout")
+	quiet := fs.Bool("q", false, "print nothing on success")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *url == "" {
+		*url = localAPIURL(*configPath, "/api/v1/health")
+	}
+
+	client := &http.Client{Timeout: *timeout}
+	resp, err := client.Get(*url)
+	if err != nil {
+		return fail("health check failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var health struct {
+		Status   string `json:"status"`
+		Version  string `json:"version"`
+		Uptime   string `json:"uptime"`
+		Database string `json:"database"`
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fail("health check failed: HTTP %d", resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&health); err != nil {
+		return fail("health check failed: invalid response: %v", err)
+	}
+	if health.Status != "healthy" {
+		return fail("health check failed: status %q", health.Status)
+	}
+	if health.Database != "connected" {
+		return fail("health check failed: database %s", health.Database)
+	}
+
+	if !*quiet {
+		fmt.Printf("healthy (version %s, up %s)\n", health.Version, health.Uptime)
+	}
+	return 0
+}
+
+// localAPIURL builds a URL on the local server from server.port
+// (MT_SERVER_PORT applies), falling back to the default port when the
+// config can't be read
+func localAPIURL(configPath, path string) string {
+	port := 3001
+	if cfg, err := config.LoadFile(configPath); err == nil && cfg.Server.Port > 0 {
+		port = cfg.Server.Port
+	}
+	return "http://" + net.JoinHostPort("127.0.0.1", strconv.Itoa(port)) + path
+}
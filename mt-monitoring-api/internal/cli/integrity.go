@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// runIntegrity checks the database and optionally repairs it.
+// Exit code 0 means healthy (after repair, if requested), 2 means problems remain.
+func runIntegrity(args []string) int {
+	fs, configPath := newFlagSet("integrity")
+	full := fs.Bool("full", false, "run PRAGMA integrity_check instead of quick_check")
+	repair := fs.Bool("repair", false, "delete orphaned rows and recreate missing indexes")
+	deleteOrphans := fs.Bool("delete-orphans", false, "delete orphaned rows (implied by -repair)")
+	recreateIndexes := fs.Bool("recreate-indexes", false, "recreate missing indexes (implied by -repair)")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	store, err := openStore(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer store.Close()
+
+	ctx := context.Background()
+	opts := models.IntegrityRepairOptions{
+		DeleteOrphans:   *repair || *deleteOrphans,
+		RecreateIndexes: *repair || *recreateIndexes,
+	}
+
+	var report *models.IntegrityReport
+	if opts.DeleteOrphans || opts.RecreateIndexes {
+		result, err := store.RepairIntegrity(ctx, opts)
+		if err != nil {
+			return fail("repair failed: %v", err)
+		}
+		if *asJSON {
+			printJSON(result)
+		} else {
+			fmt.Printf("Repair: %d orphans deleted, %d references cleared, %d indexes created\n",
+				result.OrphansDeleted, result.OrphansNulled, len(result.IndexesCreated))
+		}
+		report = result.Report
+		if *full {
+			if report, err = store.CheckIntegrity(ctx, true); err != nil {
+				return fail("%v", err)
+			}
+		}
+	} else {
+		if report, err = store.CheckIntegrity(ctx, *full); err != nil {
+			return fail("%v", err)
+		}
+		if *asJSON {
+			printJSON(report)
+		}
+	}
+
+	if !*asJSON {
+		printIntegrityReport(report)
+	}
+	if !report.Healthy {
+		return 2
+	}
+	return 0
+}
+
+func printIntegrityReport(r *models.IntegrityReport) {
+	if r.Healthy {
+		fmt.Println("Database integrity: OK")
+		return
+	}
+
+	fmt.Println("Database integrity: problems found")
+	for _, msg := range r.IntegrityErrors {
+		fmt.Printf("  corruption: %s\n", msg)
+	}
+	for _, o := range r.Orphans {
+		fmt.Printf("  orphans: %d rows in %s.%s reference missing %s (repair: %s)\n",
+			o.Count, o.Table, o.Column, o.Parent, o.Action)
+	}
+	for _, name := range r.MissingIndexes {
+		fmt.Printf("  missing index: %s\n", name)
+	}
+	if len(r.IntegrityErrors) > 0 {
+		fmt.Println("Page-level corruption cannot be repaired in place; restore a backup.")
+	}
+}
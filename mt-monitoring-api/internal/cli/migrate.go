@@ -0,0 +1,207 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+// migrationsDir is where new migrations are created, relative to the
+// module root
+const migrationsDir = "internal/database/migrations/" + database.DialectSQLite
+
+var migrationNameCleaner = regexp.MustCompile(`[^a-z0-9]+`)
+
+// runMigrate dispatches "migrate status|up|down|create"
+func runMigrate(args []string) int {
+	action := "status"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		action, args = args[0], args[1:]
+	}
+
+	switch action {
+	case "status":
+		return runMigrateStatus(args)
+	case "up":
+		return runMigrateUp(args)
+	case "down":
+		return runMigrateDown(args)
+	case "create":
+		return runMigrateCreate(args)
+	}
+	fmt.Fprintln(os.Stderr, "Usage: server migrate [status|up|down|create] [flags]")
+	return 2
+}
+
+// openMigrator connects to the configured database without applying
+// migrations and baselines a pre-versioning database
+func openMigrator(configPath string) (*database.Migrator, func() error, error) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	db, err := database.Connect(cfg.Database.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := database.NewMigrator(db)
+	if err == nil {
+		err = m.BaselineLegacy()
+	}
+	if err != nil {
+		db.Close()
+		return nil, nil, err
+	}
+	return m, db.Close, nil
+}
+
+// runMigrateStatus lists applied and pending migrations
+func runMigrateStatus(args []string) int {
+	fs, configPath := newFlagSet("migrate status")
+	asJSON := fs.Bool("json", false, "print the status as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	m, closeDB, err := openMigrator(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer closeDB()
+
+	statuses, err := m.Status()
+	if err != nil {
+		return fail("%v", err)
+	}
+	if *asJSON {
+		printJSON(statuses)
+		return 0
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		applied := "pending"
+		if s.Applied {
+			applied = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+		} else {
+			pending++
+		}
+		fmt.Printf("  %04d  %-32s %s\n", s.Version, s.Name, applied)
+	}
+	fmt.Printf("%d migration(s), %d pending\n", len(statuses), pending)
+	return 0
+}
+
+// runMigrateUp applies pending migrations, optionally up to -to
+func runMigrateUp(args []string) int {
+	fs, configPath := newFlagSet("migrate up")
+	to := fs.Int("to", 0, "apply migrations up to and including this version (default: latest)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	m, closeDB, err := openMigrator(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer closeDB()
+
+	count, err := m.UpTo(*to)
+	if err != nil {
+		return fail("%v (%d applied before the failure)", err, count)
+	}
+	return printVersion(m, "Applied", count)
+}
+
+// runMigrateDown rolls back the last migration, -steps migrations or every
+// migration newer than -to
+func runMigrateDown(args []string) int {
+	fs, configPath := newFlagSet("migrate down")
+	steps := fs.Int("steps", 1, "number of migrations to roll back")
+	to := fs.Int("to", -1, "roll back every migration newer than this version (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	m, closeDB, err := openMigrator(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer closeDB()
+
+	var count int
+	if *to >= 0 {
+		count, err = m.DownTo(*to)
+	} else {
+		count, err = m.Down(*steps)
+	}
+	if err != nil {
+		return fail("%v (%d rolled back before the failure)", err, count)
+	}
+	return printVersion(m, "Rolled back", count)
+}
+
+func printVersion(m *database.Migrator, verb string, count int) int {
+	version, err := m.Version()
+	if err != nil {
+		return fail("%v", err)
+	}
+	fmt.Printf("%s %d migration(s), schema version is now %d\n", verb, count, version)
+	return 0
+}
+
+// runMigrateCreate writes an empty up/down migration pair with the next
+// version number
+func runMigrateCreate(args []string) int {
+	fs := flag.NewFlagSet("migrate create", flag.ContinueOnError)
+	dir := fs.String("dir", migrationsDir, "migrations directory")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	name := strings.Trim(migrationNameCleaner.ReplaceAllString(strings.ToLower(strings.Join(fs.Args(), "_")), "_"), "_")
+	if name == "" {
+		return fail("a migration name is required, e.g. \"server migrate create add_service_owner\"")
+	}
+
+	existing, err := database.LoadMigrations(os.DirFS(*dir))
+	if err != nil {
+		return fail("%v", err)
+	}
+	version := 1
+	if len(existing) > 0 {
+		version = existing[len(existing)-1].Version + 1
+	}
+
+	base := filepath.Join(*dir, fmt.Sprintf("%04d_%s", version, name))
+	stubs := []struct{ path, content string }{
+		{base + ".up.sql", fmt.Sprintf("-- %04d_%s\n", version, name)},
+		{base + ".down.sql", fmt.Sprintf("-- Revert %04d_%s\n", version, name)},
+	}
+	for _, stub := range stubs {
+		if err := writeNewFile(stub.path, stub.content); err != nil {
+			return fail("%v", err)
+		}
+		fmt.Printf("Created %s\n", stub.path)
+	}
+	return 0
+}
+
+// writeNewFile creates path with content, failing if it already exists
+func writeNewFile(path, content string) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.WriteString(content); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// runRotateKey re-encrypts stored secrets from the configured key (or -old)
+// to -new. Exit code 0 means done (or a clean dry run), 2 means some values
+// did not decrypt with the old key and nothing was changed.
+func runRotateKey(args []string) int {
+	fs, configPath := newFlagSet("rotate-key")
+	newKeyHex := fs.String("new", "", "new encryption key, 64 hex chars (default: $MT_NEW_ENCRYPTION_KEY)")
+	oldKeyHex := fs.String("old", "", "old encryption key (default: security.encryptionKey / $MT_SECURITY_ENCRYPTIONKEY)")
+	dryRun := fs.Bool("dry-run", false, "decrypt and count every value without writing")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if *newKeyHex == "" {
+		*newKeyHex = os.Getenv("MT_NEW_ENCRYPTION_KEY")
+	}
+	newKey, err := crypto.ParseKey(*newKeyHex)
+	if err != nil {
+		return fail("-new: %v", err)
+	}
+
+	store, err := openStore(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+	defer store.Close()
+
+	var oldKey []byte
+	if *oldKeyHex != "" {
+		if oldKey, err = crypto.ParseKey(*oldKeyHex); err != nil {
+			return fail("-old: %v", err)
+		}
+	} else {
+		if err := crypto.Init(config.Get().Security.EncryptionKey); err != nil {
+			return fail("%v", err)
+		}
+		oldKey = crypto.Key()
+	}
+	if bytes.Equal(oldKey, newKey) {
+		return fail("the new key must differ from the old key")
+	}
+
+	result, err := store.RotateEncryptionKey(context.Background(), oldKey, newKey, *dryRun)
+	if err != nil && !errors.Is(err, database.ErrKeyRotationFailed) {
+		return fail("%v", err)
+	}
+
+	if *asJSON {
+		printJSON(result)
+	} else {
+		printKeyRotation(result)
+	}
+	if len(result.Failures) > 0 {
+		return 2
+	}
+	return 0
+}
+
+func printKeyRotation(r *models.KeyRotationResult) {
+	for _, col := range r.Columns {
+		fmt.Printf("  %s.%s: %d re-encrypted, %d plaintext encrypted\n",
+			col.Table, col.Column, col.Reencrypted, col.Plaintext)
+	}
+	for _, f := range r.Failures {
+		fmt.Printf("  cannot decrypt %s.%s for %s\n", f.Table, f.Column, f.RowID)
+	}
+
+	switch {
+	case len(r.Failures) > 0:
+		fmt.Println("Key rotation aborted: some values do not decrypt with the old key, nothing was changed")
+	case r.DryRun:
+		fmt.Println("Dry run: all values decrypt with the old key, nothing was changed")
+	default:
+		fmt.Println("Key rotated. Set security.encryptionKey (or MT_SECURITY_ENCRYPTIONKEY) to the new key before starting the server")
+	}
+}
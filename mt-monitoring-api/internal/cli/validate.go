@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// runValidateConfig loads the configuration without starting anything and
+// reports invalid settings. Exit code 0 means valid, 2 means invalid.
+func runValidateConfig(args []string) int {
+	fs, configPath := newFlagSet("validate-config")
+	asJSON := fs.Bool("json", false, "print the result as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	cfg, err := config.LoadFile(*configPath)
+	if err != nil {
+		return fail("%v", err)
+	}
+
+	errs := config.Validate(cfg)
+	if *asJSON {
+		printJSON(map[string]interface{}{"valid": len(errs) == 0, "errors": errs})
+	} else if len(errs) == 0 {
+		fmt.Println("Configuration is valid")
+	} else {
+		fmt.Printf("Configuration has %d problem(s):\n", len(errs))
+		for _, e := range errs {
+			fmt.Printf("  %s: %s\n", e.Field, e.Message)
+		}
+	}
+
+	if len(errs) > 0 {
+		return 2
+	}
+	return 0
+}
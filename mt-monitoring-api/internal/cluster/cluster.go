@@ -0,0 +1,318 @@
+// Package cluster shards service checks and remote host collection across
+// scheduler nodes sharing one database file on the same host; SQLite's
+// locking and WAL don't work over network filesystems. Every node renews a lease in the
+// cluster_nodes table; the live nodes form a consistent hash ring and each
+// service or host is handled by the node owning its ID. When a node joins,
+// stops or lets its lease expire, the others rebalance on their next
+// renewal, moving only the share that changed owner.
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ForwardedHeader marks a request forwarded by another node. It names the
+// forwarding node; forwarded requests are never forwarded again.
+const ForwardedHeader = "X-MT-Forwarded-By"
+
+// forwardTimeout bounds a request forwarded to another node
+const forwardTimeout = 5 * time.Second
+
+// Node is this server's membership in the cluster
+type Node struct {
+	id        string
+	address   string
+	startedAt time.Time
+	ttl       time.Duration
+	repo      database.ClusterRepository
+
+	mu    sync.RWMutex
+	nodes []models.ClusterNode // live nodes, oldest first
+	ring  *ring
+
+	stopCh chan struct{}
+	done   chan struct{}
+}
+
+// current is the node installed by Setup, nil while clustering is disabled
+var current atomic.Pointer[Node]
+
+var (
+	listenersMu sync.Mutex
+	listeners   []func()
+)
+
+// Enabled reports whether checks are sharded across nodes
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Setup joins the cluster when cluster.enabled is set: it takes a lease,
+// reads the live nodes and keeps renewing every third of the lease TTL.
+// The server entry point calls it at startup, before the scheduler and
+// collectors start, and the returned shutdown on exit, which gives up the
+// lease so the other nodes take over at once. It fails when the database is
+// on a network filesystem, as the nodes must run on the host holding the
+// file. Without clustering this node owns every service and host and
+// shutdown does nothing.
+func Setup(store *database.Store) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+	cfg := config.Get()
+	if cfg == nil || !cfg.Cluster.Enabled {
+		return noop, nil
+	}
+	c := cfg.Cluster
+
+	if fs := store.NetworkFilesystem(); fs != "" {
+		return nil, fmt.Errorf("cluster: database %s is on %s; run every node on the host holding the database file", store.Path(), fs)
+	}
+
+	id := c.NodeID
+	if id == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.Printf("[Cluster] Cannot determine hostname, set cluster.nodeId: %v", err)
+			return noop, nil
+		}
+		id = hostname
+	}
+	ttl := time.Duration(c.LeaseTTL) * time.Second
+	if ttl < 3*time.Second {
+		ttl = 30 * time.Second
+	}
+
+	n := &Node{
+		id:        id,
+		address:   strings.TrimRight(c.AdvertiseURL, "/"),
+		startedAt: time.Now().UTC().Truncate(time.Second),
+		ttl:       ttl,
+		repo:      store.Cluster,
+		stopCh:    make(chan struct{}),
+		done:      make(chan struct{}),
+	}
+	n.renew()
+	current.Store(n)
+	go n.run()
+
+	log.Printf("[Cluster] Node %s joined (lease TTL %v, %d live nodes)", n.id, ttl, len(n.Status().Nodes))
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		once.Do(func() {
+			current.CompareAndSwap(n, nil)
+			close(n.stopCh)
+		})
+		select {
+		case <-n.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}, nil
+}
+
+// Owns reports whether this node checks the service or collects the host
+// with the given ID. Without clustering it owns everything.
+func Owns(id string) bool {
+	n := current.Load()
+	if n == nil {
+		return true
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.ring.owner(id) == n.id
+}
+
+// Owner returns the node owning an ID when it is another node
+func Owner(id string) (models.ClusterNode, bool) {
+	n := current.Load()
+	if n == nil {
+		return models.ClusterNode{}, false
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	owner := n.ring.owner(id)
+	if owner == n.id {
+		return models.ClusterNode{}, false
+	}
+	for _, node := range n.nodes {
+		if node.ID == owner {
+			return node, true
+		}
+	}
+	return models.ClusterNode{}, false
+}
+
+// IsLeader reports whether this node runs the cluster-wide jobs: cleanup,
+// database maintenance, backups and archival. The leader is the oldest live
+// node; without clustering it is this one.
+func IsLeader() bool {
+	n := current.Load()
+	if n == nil {
+		return true
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return len(n.nodes) == 0 || n.nodes[0].ID == n.id
+}
+
+// OnChange registers a function called after the live nodes changed, to
+// start and stop the checks whose owner changed. It runs on the renewal
+// goroutine.
+func OnChange(fn func()) {
+	listenersMu.Lock()
+	defer listenersMu.Unlock()
+	listeners = append(listeners, fn)
+}
+
+// Status returns the membership as this node sees it
+func Status() models.ClusterStatus {
+	n := current.Load()
+	if n == nil {
+		return models.ClusterStatus{Leader: true, Nodes: []models.ClusterNode{}}
+	}
+	return n.Status()
+}
+
+// Status returns the membership as this node sees it
+func (n *Node) Status() models.ClusterStatus {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return models.ClusterStatus{
+		Enabled: true,
+		NodeID:  n.id,
+		Leader:  len(n.nodes) == 0 || n.nodes[0].ID == n.id,
+		Nodes:   slices.Clone(n.nodes),
+	}
+}
+
+// Forward sends a request to another node, marked with ForwardedHeader.
+// path is the request path with its query string.
+func Forward(ctx context.Context, to models.ClusterNode, method, path string, body []byte) error {
+	n := current.Load()
+	if n == nil || to.Address == "" {
+		return fmt.Errorf("node %s has no address", to.ID)
+	}
+	ctx, cancel := context.WithTimeout(ctx, forwardTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, method, to.Address+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(ForwardedHeader, n.id)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("node %s answered %s", to.ID, resp.Status)
+	}
+	return nil
+}
+
+func (n *Node) run() {
+	defer close(n.done)
+	ticker := time.NewTicker(n.ttl / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			n.renew()
+		case <-n.stopCh:
+			if err := n.repo.Delete(context.Background(), n.id); err != nil {
+				log.Printf("[Cluster] Failed to release lease: %v", err)
+			}
+			log.Printf("[Cluster] Node %s left", n.id)
+			return
+		}
+	}
+}
+
+// renew extends this node's lease and reloads the live nodes, notifying
+// the listeners when they changed. This node stays on its own ring even
+// when the database cannot be reached: checking a share twice while other
+// nodes still count it is better than leaving it unchecked.
+func (n *Node) renew() {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	self := models.ClusterNode{
+		ID:             n.id,
+		Address:        n.address,
+		StartedAt:      n.startedAt,
+		LeaseExpiresAt: now.Add(n.ttl),
+	}
+	if err := n.repo.Renew(ctx, &self); err != nil {
+		log.Printf("[Cluster] Failed to renew lease: %v", err)
+	}
+
+	all, err := n.repo.GetAll(ctx)
+	if err != nil {
+		log.Printf("[Cluster] Failed to get nodes: %v", err)
+		if n.ring != nil {
+			return
+		}
+	}
+	live := []models.ClusterNode{self}
+	for _, node := range all {
+		if node.ID != n.id && node.LeaseExpiresAt.After(now) {
+			live = append(live, node)
+		}
+	}
+	sort.SliceStable(live, func(i, j int) bool {
+		if !live[i].StartedAt.Equal(live[j].StartedAt) {
+			return live[i].StartedAt.Before(live[j].StartedAt)
+		}
+		return live[i].ID < live[j].ID
+	})
+
+	// Forget nodes that have been gone for a while
+	if _, err := n.repo.DeleteExpired(ctx, now.Add(-10*n.ttl)); err != nil {
+		log.Printf("[Cluster] Failed to delete expired nodes: %v", err)
+	}
+
+	ids := nodeIDs(live)
+	n.mu.Lock()
+	changed := n.ring == nil || !slices.Equal(ids, nodeIDs(n.nodes))
+	n.nodes = live
+	if changed {
+		n.ring = newRing(ids)
+	}
+	n.mu.Unlock()
+
+	if changed && current.Load() == n {
+		log.Printf("[Cluster] Live nodes: %s", strings.Join(ids, ", "))
+		listenersMu.Lock()
+		fns := slices.Clone(listeners)
+		listenersMu.Unlock()
+		for _, fn := range fns {
+			fn()
+		}
+	}
+}
+
+// nodeIDs returns the IDs of nodes in order
+func nodeIDs(nodes []models.ClusterNode) []string {
+	ids := make([]string, len(nodes))
+	for i, node := range nodes {
+		ids[i] = node.ID
+	}
+	return ids
+}
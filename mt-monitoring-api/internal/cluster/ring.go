@@ -0,0 +1,62 @@
+package cluster
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// virtualNodes is the number of points each node has on the ring. More
+// points spread IDs more evenly over few nodes.
+const virtualNodes = 160
+
+// ring is a consistent hash ring of node IDs. When a node joins or leaves
+// only the IDs between its points and their predecessors change owner.
+type ring struct {
+	points []uint32
+	owners map[uint32]string
+}
+
+// newRing builds the ring of the given nodes
+func newRing(nodes []string) *ring {
+	r := &ring{owners: make(map[uint32]string, len(nodes)*virtualNodes)}
+	for _, node := range nodes {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(node + "#" + strconv.Itoa(i))
+			// On a collision the smaller node ID keeps the point, so
+			// every node builds the same ring
+			if owner, ok := r.owners[point]; ok && owner < node {
+				continue
+			}
+			r.owners[point] = node
+		}
+	}
+	r.points = make([]uint32, 0, len(r.owners))
+	for point := range r.owners {
+		r.points = append(r.points, point)
+	}
+	sort.Slice(r.points, func(i, j int) bool { return r.points[i] < r.points[j] })
+	return r
+}
+
+// owner returns the node owning an ID: the one with the first point at or
+// after the ID's hash, wrapping around. Empty on an empty ring.
+func (r *ring) owner(id string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+	h := hash(id)
+	i := sort.Search(len(r.points), func(i int) bool { return r.points[i] >= h })
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+// hash places a key on the ring. Node IDs and service IDs tend to differ in
+// a few characters only, which cheap checksums spread unevenly.
+func hash(key string) uint32 {
+	sum := sha256.Sum256([]byte(key))
+	return binary.BigEndian.Uint32(sum[:4])
+}
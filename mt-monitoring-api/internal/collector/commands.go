@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// Names of the commands run over SSH. They are the arguments of the remote
+// script and the keys of sshCommands.
+const (
+	CommandIdentify         = "identify"
+	CommandUserland         = "userland"
+	CommandMetrics          = "metrics"
+	CommandFacts            = "facts"
+	CommandPorts            = "ports"
+	CommandUpdates          = "updates"
+	CommandProcessesCPU     = "processes-cpu"
+	CommandProcessesMem     = "processes-mem"
+	CommandProcessesBusybox = "processes-busybox"
+)
+
+// identifyCommand prints the hostname and the start of /etc/os-release, for
+// the SSH connection test.
+const identifyCommand = `hostname && cat /etc/os-release 2>/dev/null | head -2 || echo unknown`
+
+// userlandCommand prints "busybox" when ps is the busybox applet, whose ps
+// has no %CPU column and no --sort, and "gnu" otherwise.
+const userlandCommand = `case "$(readlink -f "$(command -v ps)" 2>/dev/null)" in *busybox*) echo busybox ;; *) echo gnu ;; esac`
+
+// maxSSHProcesses is how many processes the process commands return; larger
+// limits are capped to it.
+const maxSSHProcesses = 100
+
+// sshCommands is the fixed set of commands run over SSH, by name. Nothing
+// else is sent to a host, so reviewing this list reviews everything the
+// collector can do there; in restricted mode anything else is refused.
+var sshCommands = map[string]string{
+	CommandIdentify:         identifyCommand,
+	CommandUserland:         userlandCommand,
+	CommandMetrics:          combinedCommand,
+	CommandFacts:            factsCommand,
+	CommandPorts:            portsCommand,
+	CommandUpdates:          updatesCommand,
+	CommandProcessesCPU:     fmt.Sprintf("ps aux --sort=-%%cpu | head -%d", maxSSHProcesses+1),
+	CommandProcessesMem:     fmt.Sprintf("ps aux --sort=-%%mem | head -%d", maxSSHProcesses+1),
+	CommandProcessesBusybox: "top -b -n 1",
+}
+
+// SSHCommand is one entry of the SSH command list
+type SSHCommand struct {
+	Name    string `json:"name"`
+	Command string `json:"command"`
+}
+
+// SSHCommands returns the commands run over SSH, sorted by name.
+func SSHCommands() []SSHCommand {
+	list := make([]SSHCommand, 0, len(sshCommands))
+	for name, cmd := range sshCommands {
+		list = append(list, SSHCommand{Name: name, Command: cmd})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// SSHCommandLine returns what is sent to a host to run the named command:
+// the inline command, or "<script> <name>" when system.ssh.remoteScript is
+// set.
+func SSHCommandLine(name string) string {
+	if cfg := config.Get(); cfg != nil && cfg.System.SSH.RemoteScript != "" {
+		return cfg.System.SSH.RemoteScript + " " + name
+	}
+	return sshCommands[name]
+}
+
+// allowedCommandLine reports whether cmd is one of the command lines
+// SSHCommandLine returns.
+func allowedCommandLine(cmd string) bool {
+	for name := range sshCommands {
+		if cmd == SSHCommandLine(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// restrictedSSH reports whether system.ssh.restricted is on.
+func restrictedSSH() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.System.SSH.Restricted
+}
+
+// RemoteScript returns the collection script to install on the hosts for
+// system.ssh.remoteScript. It runs the command named by its argument, or
+// by the last word of SSH_ORIGINAL_COMMAND so it also works as a forced
+// command in authorized_keys. It only reads; it changes nothing on the host.
+func RemoteScript() string {
+	var b strings.Builder
+	b.WriteString("#!/bin/sh\n")
+	b.WriteString("# MT-Monitoring collection script, version " + RemoteScriptVersion() + "\n")
+	b.WriteString("# Read-only: prints metrics and inventory, changes nothing on the host.\n")
+	b.WriteString("action=${1:-${SSH_ORIGINAL_COMMAND##* }}\n")
+	b.WriteString("case \"$action\" in\n")
+	for _, c := range SSHCommands() {
+		fmt.Fprintf(&b, "%s)\n\t%s\n\t;;\n", c.Name, c.Command)
+	}
+	b.WriteString("*)\n\techo \"unknown command: $action\" >&2\n\texit 2\n\t;;\nesac\n")
+	return b.String()
+}
+
+// RemoteScriptVersion identifies the command set, changing whenever a command does.
+func RemoteScriptVersion() string {
+	h := sha256.New()
+	for _, c := range SSHCommands() {
+		fmt.Fprintf(h, "%s\x00%s\x00", c.Name, c.Command)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:12]
+}
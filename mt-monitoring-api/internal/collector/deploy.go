@@ -0,0 +1,73 @@
+package collector
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// deployDir is where deployed collection scripts are kept on the hosts,
+// relative to the home directory of the SSH user.
+const deployDir = ".cache/mt-monitoring"
+
+// deployCommand installs the script read from stdin as
+// collect-<version>.sh unless that version is already there, removes the
+// other versions and prints the path of the script.
+func deployCommand(version string) string {
+	return `d="$HOME/` + deployDir + `"; f="$d/collect-` + version + `.sh"; ` +
+		`if [ ! -x "$f" ]; then mkdir -p "$d" && cat > "$f.tmp" && chmod 0555 "$f.tmp" && mv -f "$f.tmp" "$f" || exit 1; fi; ` +
+		`for o in "$d"/collect-*.sh; do [ "$o" = "$f" ] || rm -f "$o"; done; echo "$f"`
+}
+
+// deployScriptEnabled reports whether system.ssh.deployScript is on.
+func deployScriptEnabled() bool {
+	cfg := config.Get()
+	return cfg != nil && cfg.System.SSH.DeployScript
+}
+
+// deployedScript returns the path of the collection script on the host,
+// deploying it on first use after each connect and whenever the release
+// changed the script.
+func (c *SSHCollector) deployedScript() (string, error) {
+	version := RemoteScriptVersion()
+
+	c.mu.Lock()
+	path, deployed := c.scriptPath, c.scriptVersion
+	c.mu.Unlock()
+	if path != "" && deployed == version {
+		return path, nil
+	}
+
+	output, err := c.runCommandInput(deployCommand(version), strings.NewReader(RemoteScript()))
+	if err != nil {
+		return "", err
+	}
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	path = strings.TrimSpace(lines[len(lines)-1])
+	if !strings.HasPrefix(path, "/") {
+		return "", fmt.Errorf("unexpected output %q", output)
+	}
+
+	c.mu.Lock()
+	c.scriptPath, c.scriptVersion = path, version
+	c.mu.Unlock()
+	if deployed != version {
+		log.Printf("Collection script %s ready on %s at %s", version, c.host.ID, path)
+	}
+	return path, nil
+}
+
+// forgetScript makes the next command check the deployed script again,
+// e.g. after a command failed because it was removed.
+func (c *SSHCollector) forgetScript() {
+	c.mu.Lock()
+	c.scriptPath = ""
+	c.mu.Unlock()
+}
+
+// shellQuote quotes s as a single word for the remote shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+const (
+	// factsInterval is how often the inventory facts of a host are
+	// collected
+	factsInterval = 24 * time.Hour
+
+	// factsRetryDelay is how long a failed facts collection waits before
+	// it is tried again
+	factsRetryDelay = time.Hour
+)
+
+// factsDue reports whether the facts of a host should be collected. After a
+// restart the stored facts count, so they are not collected again until
+// they are a day old.
+func (m *CollectorManager) factsDue(hostID string, mc *managedCollector) bool {
+	m.mu.RLock()
+	factsAt := mc.factsAt
+	m.mu.RUnlock()
+
+	if factsAt.IsZero() {
+		stored, err := m.facts.Get(context.Background(), hostID)
+		if err != nil {
+			return false
+		}
+		if stored != nil {
+			factsAt = stored.CollectedAt
+			m.mu.Lock()
+			mc.factsAt = factsAt
+			m.mu.Unlock()
+		}
+	}
+	return time.Since(factsAt) >= factsInterval
+}
+
+// collectFacts collects and stores the facts of a host. A failure is tried
+// again after factsRetryDelay.
+func (m *CollectorManager) collectFacts(hostID string, mc *managedCollector) (*models.HostFacts, error) {
+	facts, err := mc.collector.GetFacts()
+	if err == nil {
+		err = m.facts.Save(context.Background(), facts)
+	}
+
+	m.mu.Lock()
+	if err != nil {
+		mc.factsAt = time.Now().Add(factsRetryDelay - factsInterval)
+	} else {
+		mc.factsAt = facts.CollectedAt
+	}
+	m.mu.Unlock()
+	return facts, err
+}
+
+// RefreshFacts collects the facts of a host now, if this node collects it.
+func (m *CollectorManager) RefreshFacts(hostID string) (*models.HostFacts, error) {
+	m.mu.RLock()
+	mc, ok := m.collectors[hostID]
+	m.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("no active collector for host %s", hostID)
+	}
+	return m.collectFacts(hostID, mc)
+}
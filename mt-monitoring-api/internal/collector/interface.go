@@ -1,10 +1,18 @@
 package collector
 
-import "github.com/mt-monitoring/api/internal/models"
+import (
+	"errors"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ErrNotSupported is returned for data a collector has no source for, e.g.
+// the process list of a host scraped through node_exporter.
+var ErrNotSupported = errors.New("not supported by this collector")
 
 // MetricCollector is the common interface for all metric collection backends.
-// LocalCollector implements it using gopsutil, SSHCollector will implement it
-// using SSH + /proc parsing.
+// LocalCollector implements it using gopsutil, SSHCollector using SSH + /proc
+// parsing and NodeExporterCollector by scraping node_exporter.
 type MetricCollector interface {
 	// Collect gathers a single snapshot of system metrics.
 	// Delta-based metrics (CPU %, disk I/O, network I/O) are calculated
@@ -15,6 +23,14 @@ type MetricCollector interface {
 	// current resource snapshot (CPU, memory, disk).
 	GetSystemInfo() (*models.SystemInfo, error)
 
+	// GetFacts returns the inventory facts of the host: kernel, CPU model,
+	// virtualization, package counts and listening ports.
+	GetFacts() (*models.HostFacts, error)
+
+	// GetListeningPorts returns the listening TCP ports and unconnected UDP
+	// sockets of the host.
+	GetListeningPorts() ([]models.ListeningPort, error)
+
 	// GetProcesses returns the top N processes sorted by the given field.
 	GetProcesses(limit int, sortBy string) ([]models.ProcessInfo, error)
 
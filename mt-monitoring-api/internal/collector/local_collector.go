@@ -4,7 +4,10 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"os/exec"
+	"runtime"
 	"sort"
+	"syscall"
 	"time"
 
 	"github.com/shirou/gopsutil/v3/cpu"
@@ -14,6 +17,7 @@ import (
 	gopsnet "github.com/shirou/gopsutil/v3/net"
 	"github.com/shirou/gopsutil/v3/process"
 
+	"github.com/mt-monitoring/api/internal/collector/parser"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
@@ -190,6 +194,54 @@ func (c *LocalCollector) GetSystemInfo() (*models.SystemInfo, error) {
 	return info, nil
 }
 
+// GetFacts returns the inventory facts of the local host. Package counts
+// are only known on Linux.
+func (c *LocalCollector) GetFacts() (*models.HostFacts, error) {
+	facts := &models.HostFacts{
+		HostID:      c.hostID,
+		Packages:    map[string]int{},
+		CollectedAt: time.Now(),
+	}
+	facts.Kernel, _ = host.KernelVersion()
+	facts.CPUCores, _ = cpu.Counts(true)
+	if infos, err := cpu.Info(); err == nil && len(infos) > 0 {
+		facts.CPUModel = infos[0].ModelName
+	}
+	if system, role, err := host.Virtualization(); err == nil && role == "guest" {
+		facts.Virtualization = system
+	}
+	if runtime.GOOS == "linux" {
+		if out, err := exec.Command("sh", "-c", packagesScript).Output(); err == nil {
+			facts.Packages = parser.ParsePackageCounts(string(out))
+		}
+	}
+
+	ports, err := c.GetListeningPorts()
+	if err != nil {
+		return nil, err
+	}
+	facts.ListeningPorts = ports
+	return facts, nil
+}
+
+// GetListeningPorts returns the listening ports of the local host.
+func (c *LocalCollector) GetListeningPorts() ([]models.ListeningPort, error) {
+	conns, err := gopsnet.Connections("inet")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get connections: %w", err)
+	}
+	var ports []parser.ListeningPort
+	for _, conn := range conns {
+		switch {
+		case conn.Type == syscall.SOCK_STREAM && conn.Status == "LISTEN":
+			ports = append(ports, parser.ListeningPort{Protocol: "tcp", Address: conn.Laddr.IP, Port: int(conn.Laddr.Port)})
+		case conn.Type == syscall.SOCK_DGRAM && conn.Raddr.Port == 0:
+			ports = append(ports, parser.ListeningPort{Protocol: "udp", Address: conn.Laddr.IP, Port: int(conn.Laddr.Port)})
+		}
+	}
+	return listeningPorts(parser.NormalizeListeningPorts(ports)), nil
+}
+
 // GetProcesses returns the top N processes sorted by the given field.
 func (c *LocalCollector) GetProcesses(limit int, sortBy string) ([]models.ProcessInfo, error) {
 	procs, err := process.Processes()
@@ -1,13 +1,20 @@
 package collector
 
 import (
+	"context"
 	"log"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/mt-monitoring/api/internal/alerter"
+	"github.com/mt-monitoring/api/internal/cluster"
+	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/events"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/telemetry"
 )
 
 // managedCollector wraps a MetricCollector with its in-memory snapshot buffer
@@ -16,26 +23,47 @@ type managedCollector struct {
 	collector MetricCollector
 	snapshots []models.SystemMetric
 	latest    *models.SystemInfo
+	lastAt    time.Time // last successful collection
+	lastErr   string    // error of the last collection, empty on success
+	factsAt   time.Time // when the facts were last collected or attempted
+	updatesAt time.Time // when the pending updates were last checked or attempted
+	portsAt   time.Time // when the listening ports were last checked or attempted
+
+	processesAt time.Time // when the top processes were last recorded or attempted
+
+	collectEvery time.Duration // host override of the collection interval, 0 for the global one
+	storeEvery   time.Duration // host override of the storage interval, 0 for the global one
+	nextCollect  time.Time
+	nextStore    time.Time
+	collecting   bool // a collection is running
 }
 
+// schedulerTick is the resolution of the collection and storage schedule
+const schedulerTick = time.Second
+
 // CollectorManager manages multiple MetricCollectors and schedules periodic
 // collection and storage.
 type CollectorManager struct {
 	collectors         map[string]*managedCollector // hostID → managed collector
-	broadcast          func(interface{})
-	onMetricCollected  func(hostID, hostName string, metric *models.SystemMetric)
-	repo               *database.SystemMetricRepository
+	pings              map[string]*pingWindow       // hostID → ping rounds since the last store
+	repo               database.SystemMetricRepository
+	hosts              database.HostRepository
+	facts              database.HostFactsRepository
+	updates            database.HostUpdatesRepository
+	ports              database.HostPortsRepository
+	processes          database.ProcessMetricRepository
+	retry              *retryBuffer
 	mu                 sync.RWMutex
 
 	collectInterval time.Duration
 	storeInterval   time.Duration
-	collectTicker   *time.Ticker
-	storeTicker     *time.Ticker
+	nextStore       time.Time // next store of the ping rows of hosts without a collector and of the retry buffer
+	ticker          *time.Ticker
 	stopCh          chan struct{}
 }
 
 // NewCollectorManager creates a new CollectorManager.
-func NewCollectorManager(collectInterval, storeInterval int) *CollectorManager {
+func NewCollectorManager(store *database.Store, collectInterval, storeInterval int) *CollectorManager {
 	if collectInterval <= 0 {
 		collectInterval = 5
 	}
@@ -43,46 +71,106 @@ func NewCollectorManager(collectInterval, storeInterval int) *CollectorManager {
 		storeInterval = 60
 	}
 
+	retrySize := 1000
+	if cfg := config.Get(); cfg != nil && cfg.System.RetryBufferSize > 0 {
+		retrySize = cfg.System.RetryBufferSize
+	}
+
 	return &CollectorManager{
 		collectors:      make(map[string]*managedCollector),
-		repo:            database.NewSystemMetricRepository(),
+		pings:           make(map[string]*pingWindow),
+		repo:            store.SystemMetrics,
+		hosts:           store.Hosts,
+		facts:           store.HostFacts,
+		updates:         store.HostUpdates,
+		ports:           store.HostPorts,
+		processes:       store.ProcessMetrics,
+		retry:           newRetryBuffer(retrySize),
 		collectInterval: time.Duration(collectInterval) * time.Second,
 		storeInterval:   time.Duration(storeInterval) * time.Second,
 		stopCh:          make(chan struct{}),
 	}
 }
 
-// SetBroadcast sets the WebSocket broadcast function.
-func (m *CollectorManager) SetBroadcast(fn func(interface{})) {
-	m.broadcast = fn
-}
-
-// SetOnMetricCollected sets a callback invoked after every metric collection.
-// Used by RuleEvaluator to evaluate alert rules against fresh metrics.
-func (m *CollectorManager) SetOnMetricCollected(fn func(hostID, hostName string, metric *models.SystemMetric)) {
-	m.onMetricCollected = fn
-}
-
-// Register adds a MetricCollector to be managed. If a collector for the same
-// host ID already exists, it is replaced (the old one is closed).
+// Register adds a MetricCollector to be managed, with the interval
+// overrides of its host. If a collector for the same host ID already exists,
+// it is replaced (the old one is closed).
 func (m *CollectorManager) Register(c MetricCollector) {
+	hostID := c.HostID()
+	mc := &managedCollector{collector: c}
+	if host, err := m.hosts.GetByID(context.Background(), hostID); err == nil && host != nil {
+		mc.collectEvery = time.Duration(host.CollectInterval) * time.Second
+		mc.storeEvery = time.Duration(host.StoreInterval) * time.Second
+		mc.lastErr = host.LastError // cleared by the first successful collection
+	}
+
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
-	hostID := c.HostID()
 	if existing, ok := m.collectors[hostID]; ok {
 		existing.collector.Close()
 	}
 
-	maxSnapshots := int(m.storeInterval / m.collectInterval)
-	m.collectors[hostID] = &managedCollector{
-		collector: c,
-		snapshots: make([]models.SystemMetric, 0, maxSnapshots),
-	}
+	mc.snapshots = make([]models.SystemMetric, 0, m.maxSnapshots(mc))
+	mc.nextStore = time.Now().Add(m.storeEvery(mc))
+	m.collectors[hostID] = mc
 
 	log.Printf("Collector registered for host: %s", hostID)
 }
 
+// SetHostIntervals changes the collection and storage intervals (seconds,
+// 0 for the global ones) of a registered host. Shorter intervals take effect
+// immediately, longer ones after the next run.
+func (m *CollectorManager) SetHostIntervals(hostID string, collectInterval, storeInterval int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	mc, ok := m.collectors[hostID]
+	if !ok {
+		return
+	}
+	collect := time.Duration(collectInterval) * time.Second
+	store := time.Duration(storeInterval) * time.Second
+	if collect == mc.collectEvery && store == mc.storeEvery {
+		return
+	}
+	mc.collectEvery, mc.storeEvery = collect, store
+
+	now := time.Now()
+	if next := now.Add(m.collectEvery(mc)); next.Before(mc.nextCollect) {
+		mc.nextCollect = next
+	}
+	if next := now.Add(m.storeEvery(mc)); next.Before(mc.nextStore) {
+		mc.nextStore = next
+	}
+	log.Printf("Collector intervals changed for host %s (collect: %v, store: %v)",
+		hostID, m.collectEvery(mc), m.storeEvery(mc))
+}
+
+// collectEvery returns the collection interval of a host. The caller holds m.mu.
+func (m *CollectorManager) collectEvery(mc *managedCollector) time.Duration {
+	if mc.collectEvery > 0 {
+		return mc.collectEvery
+	}
+	return m.collectInterval
+}
+
+// storeEvery returns the storage interval of a host, never shorter than its
+// collection interval. The caller holds m.mu.
+func (m *CollectorManager) storeEvery(mc *managedCollector) time.Duration {
+	store := m.storeInterval
+	if mc.storeEvery > 0 {
+		store = mc.storeEvery
+	}
+	return max(store, m.collectEvery(mc))
+}
+
+// maxSnapshots returns how many snapshots of a host are buffered between
+// stores. The caller holds m.mu.
+func (m *CollectorManager) maxSnapshots(mc *managedCollector) int {
+	return max(int(m.storeEvery(mc)/m.collectEvery(mc)), 1)
+}
+
 // Unregister removes and closes the collector for the given host ID.
 func (m *CollectorManager) Unregister(hostID string) {
 	m.mu.Lock()
@@ -95,17 +183,62 @@ func (m *CollectorManager) Unregister(hostID string) {
 	}
 }
 
-// RegisterSSHHost creates and registers an SSHCollector for the given host.
-// Returns an error if the SSH configuration is invalid (does not attempt connection).
-func (m *CollectorManager) RegisterSSHHost(host *models.Host) error {
-	sshCollector, err := NewSSHCollector(host)
+// RegisterRemoteHost creates and registers the collector of the given
+// remote host: an SSHCollector, or a NodeExporterCollector for node_exporter
+// hosts. Returns an error if the configuration is invalid (does not attempt
+// connection). In a cluster the host is only collected by the node owning it.
+func (m *CollectorManager) RegisterRemoteHost(host *models.Host) error {
+	var c MetricCollector
+	var err error
+	if host.Collector == models.HostCollectorNodeExporter {
+		c, err = NewNodeExporterCollector(host)
+	} else {
+		c, err = NewSSHCollector(host)
+	}
 	if err != nil {
 		return err
 	}
-	m.Register(sshCollector)
+	if !cluster.Owns(host.ID) {
+		c.Close()
+		m.Unregister(host.ID)
+		return nil
+	}
+	m.Register(c)
 	return nil
 }
 
+// Rebalance starts collecting from the active remote hosts this node owns
+// and stops collecting from the ones another node took over. It runs when
+// cluster membership changes.
+func (m *CollectorManager) Rebalance() {
+	hosts, err := m.hosts.GetActive(context.Background())
+	if err != nil {
+		log.Printf("Failed to rebalance hosts: %v", err)
+		return
+	}
+
+	added, removed := 0, 0
+	for i := range hosts {
+		host := &hosts[i]
+		if host.Type != models.HostTypeRemote {
+			continue
+		}
+		owned, has := cluster.Owns(host.ID), m.HasCollector(host.ID)
+		switch {
+		case owned && !has:
+			if err := m.RegisterRemoteHost(host); err != nil {
+				log.Printf("Failed to register collector for host %s: %v", host.ID, err)
+				continue
+			}
+			added++
+		case !owned && has:
+			m.Unregister(host.ID)
+			removed++
+		}
+	}
+	log.Printf("Rebalanced hosts: %d added, %d removed", added, removed)
+}
+
 // GetCollector returns the MetricCollector for the given host, or nil.
 func (m *CollectorManager) GetCollector(hostID string) MetricCollector {
 	m.mu.RLock()
@@ -136,10 +269,16 @@ func (m *CollectorManager) HasCollector(hostID string) bool {
 	return ok
 }
 
-// Start begins the periodic collection and storage loops.
+// Start begins the periodic collection and storage loop. Every
+// schedulerTick it collects and stores the hosts that are due, so each host
+// keeps its own intervals.
 func (m *CollectorManager) Start() {
-	m.collectTicker = time.NewTicker(m.collectInterval)
-	m.storeTicker = time.NewTicker(m.storeInterval)
+	cluster.OnChange(m.Rebalance)
+	alerter.SetCommandRunner(m.RunRemediation)
+	m.mu.Lock()
+	m.nextStore = time.Now().Add(m.storeInterval)
+	m.mu.Unlock()
+	m.ticker = time.NewTicker(schedulerTick)
 
 	log.Printf("CollectorManager started (collect: %v, store: %v, hosts: %d)",
 		m.collectInterval, m.storeInterval, len(m.collectors))
@@ -147,25 +286,45 @@ func (m *CollectorManager) Start() {
 	go func() {
 		for {
 			select {
-			case <-m.collectTicker.C:
-				m.collectAll()
-			case <-m.storeTicker.C:
-				m.storeAll()
+			case now := <-m.ticker.C:
+				m.collectDue(now)
+				m.storeDue(now)
 			case <-m.stopCh:
 				return
 			}
 		}
 	}()
+	go m.pingLoop()
+}
+
+// SetIntervals changes the global collection and storage intervals
+// (seconds), taking effect from the next run of each host without its own.
+func (m *CollectorManager) SetIntervals(collectInterval, storeInterval int) {
+	if collectInterval <= 0 {
+		collectInterval = 5
+	}
+	if storeInterval <= 0 {
+		storeInterval = 60
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	collect := time.Duration(collectInterval) * time.Second
+	store := time.Duration(storeInterval) * time.Second
+	if collect == m.collectInterval && store == m.storeInterval {
+		return
+	}
+	m.collectInterval = collect
+	m.storeInterval = store
+	log.Printf("CollectorManager intervals changed (collect: %v, store: %v)", collect, store)
 }
 
 // Stop halts all collection and closes every registered collector.
 func (m *CollectorManager) Stop() {
 	close(m.stopCh)
-	if m.collectTicker != nil {
-		m.collectTicker.Stop()
-	}
-	if m.storeTicker != nil {
-		m.storeTicker.Stop()
+	if m.ticker != nil {
+		m.ticker.Stop()
 	}
 
 	m.mu.Lock()
@@ -180,40 +339,41 @@ func (m *CollectorManager) Stop() {
 	log.Println("CollectorManager stopped")
 }
 
-// collectAll runs Collect() on every registered collector in parallel.
-func (m *CollectorManager) collectAll() {
+// collectDue starts a collection on every host whose interval has passed,
+// each on its own goroutine. A host whose previous collection is still
+// running (a slow SSH call) is skipped until it finishes.
+func (m *CollectorManager) collectDue(now time.Time) {
 	m.mu.Lock()
+	defer m.mu.Unlock()
 
-	// Build a snapshot of collectors to iterate without holding the lock
-	// during potentially slow SSH calls.
-	type job struct {
-		hostID string
-		mc     *managedCollector
-	}
-	jobs := make([]job, 0, len(m.collectors))
 	for id, mc := range m.collectors {
-		jobs = append(jobs, job{hostID: id, mc: mc})
-	}
-	m.mu.Unlock()
-
-	var wg sync.WaitGroup
-	for _, j := range jobs {
-		wg.Add(1)
+		if mc.collecting || now.Before(mc.nextCollect) {
+			continue
+		}
+		mc.collecting = true
+		mc.nextCollect = now.Add(m.collectEvery(mc))
 		go func(hostID string, mc *managedCollector) {
-			defer wg.Done()
 			m.collectOne(hostID, mc)
-		}(j.hostID, j.mc)
+			m.mu.Lock()
+			mc.collecting = false
+			m.mu.Unlock()
+		}(id, mc)
 	}
-	wg.Wait()
 }
 
 // collectOne collects a single snapshot from one host.
 func (m *CollectorManager) collectOne(hostID string, mc *managedCollector) {
+	_, op := telemetry.StartCollect(context.Background(), hostID, collectorKind(mc.collector))
+	defer op.End()
+
 	snapshot, err := mc.collector.Collect()
 	if err != nil {
+		op.Fail(err.Error())
 		log.Printf("Collect failed for host %s: %v", hostID, err)
+		m.setLastError(hostID, mc, err.Error())
 		return
 	}
+	m.setLastError(hostID, mc, "")
 
 	// Also get system info (cached for handler use)
 	info, err := mc.collector.GetSystemInfo()
@@ -225,122 +385,244 @@ func (m *CollectorManager) collectOne(hostID string, mc *managedCollector) {
 
 	// Buffer the snapshot
 	m.mu.Lock()
+	mc.lastAt = time.Now()
 	mc.snapshots = append(mc.snapshots, *snapshot)
-	maxSnapshots := int(m.storeInterval / m.collectInterval)
+	maxSnapshots := m.maxSnapshots(mc)
 	if len(mc.snapshots) > maxSnapshots {
 		mc.snapshots = mc.snapshots[len(mc.snapshots)-maxSnapshots:]
 	}
 	m.mu.Unlock()
 
-	// Broadcast via WebSocket
-	if m.broadcast != nil {
-		m.broadcast(map[string]interface{}{
-			"type":   "system_metric",
-			"hostId": hostID,
-			"data": map[string]interface{}{
-				"cpu": snapshot.CPUUsage,
-				"memory": map[string]interface{}{
-					"total": snapshot.MemTotal,
-					"used":  snapshot.MemUsed,
-					"usage": snapshot.MemUsage,
-				},
-				"disk": map[string]interface{}{
-					"total":      snapshot.DiskTotal,
-					"used":       snapshot.DiskUsed,
-					"usage":      snapshot.DiskUsage,
-					"readSpeed":  snapshot.DiskRead,
-					"writeSpeed": snapshot.DiskWrite,
-				},
-				"timestamp": snapshot.CreatedAt.Format(time.RFC3339),
-			},
-		})
-	}
-
-	// Notify evaluator for alert rule evaluation
-	if m.onMetricCollected != nil {
-		hostName := hostID
-		m.mu.RLock()
-		if mc.latest != nil {
-			hostName = mc.latest.Hostname
+	// Publish for the WebSocket hub and alert rule evaluation
+	hostName := hostID
+	m.mu.RLock()
+	if mc.latest != nil {
+		hostName = mc.latest.Hostname
+	}
+	interval := int(m.collectEvery(mc) / time.Second)
+	m.mu.RUnlock()
+	events.MetricCollected.Publish(events.SystemMetric{HostID: hostID, HostName: hostName, Metric: snapshot, Interval: interval})
+
+	if m.factsDue(hostID, mc) {
+		if _, err := m.collectFacts(hostID, mc); err != nil {
+			log.Printf("Facts collection failed for host %s: %v", hostID, err)
+		}
+	}
+	if m.updatesDue(hostID, mc) {
+		if err := m.checkUpdates(hostID, hostName, mc); err != nil {
+			log.Printf("Updates check failed for host %s: %v", hostID, err)
+		}
+	}
+	if m.portsDue(mc) {
+		if err := m.checkPorts(hostID, hostName, mc); err != nil {
+			log.Printf("Ports check failed for host %s: %v", hostID, err)
+		}
+	}
+	if m.processesDue(mc) {
+		if err := m.recordProcesses(hostID, mc); err != nil {
+			log.Printf("Process recording failed for host %s: %v", hostID, err)
 		}
-		m.mu.RUnlock()
-		go m.onMetricCollected(hostID, hostName, snapshot)
 	}
 }
 
-// storeAll aggregates recent snapshots for each host and writes 1-minute
-// averages to the database.
-func (m *CollectorManager) storeAll() {
+// setLastError records the error of the last collection of a host, empty on
+// success, and saves it as the host's last error when it changed.
+func (m *CollectorManager) setLastError(hostID string, mc *managedCollector, lastErr string) {
 	m.mu.Lock()
+	changed := mc.lastErr != lastErr
+	mc.lastErr = lastErr
+	m.mu.Unlock()
 
-	type avgJob struct {
-		avg models.SystemMetric
+	if changed {
+		if err := m.hosts.SetLastError(context.Background(), hostID, lastErr); err != nil {
+			log.Printf("Failed to save last error of host %s: %v", hostID, err)
+		}
 	}
-	var toStore []avgJob
+}
 
-	for _, mc := range m.collectors {
-		if len(mc.snapshots) == 0 {
+// storeDue writes the averages of the snapshots of every host whose
+// storage interval has passed, with the ping averages of the host attached.
+// Every global storage interval it also writes ping-only rows for hosts
+// pinged without a collector on this node (SSH down, or owned elsewhere).
+func (m *CollectorManager) storeDue(now time.Time) {
+	m.mu.Lock()
+
+	var toStore []models.SystemMetric
+	for hostID, mc := range m.collectors {
+		if now.Before(mc.nextStore) {
 			continue
 		}
+		mc.nextStore = now.Add(m.storeEvery(mc))
 
-		n := float64(len(mc.snapshots))
-		avg := models.SystemMetric{
-			HostID:    mc.collector.HostID(),
-			CreatedAt: time.Now(),
+		avg, ok := averageSnapshots(mc, now)
+		w, pinged := m.pings[hostID]
+		if !ok && !pinged {
+			continue
 		}
-		for _, s := range mc.snapshots {
-			avg.CPUUsage += s.CPUUsage
-			avg.MemTotal += s.MemTotal
-			avg.MemUsed += s.MemUsed
-			avg.MemUsage += s.MemUsage
-			avg.DiskTotal += s.DiskTotal
-			avg.DiskUsed += s.DiskUsed
-			avg.DiskUsage += s.DiskUsage
-			avg.DiskRead += s.DiskRead
-			avg.DiskWrite += s.DiskWrite
-			avg.NetIn += s.NetIn
-			avg.NetOut += s.NetOut
+		if pinged {
+			rtt, loss := w.averages()
+			if !ok {
+				avg = models.SystemMetric{HostID: hostID, CreatedAt: now, PingOnly: true}
+			}
+			avg.PingRTT, avg.PacketLoss = rtt, &loss
+			delete(m.pings, hostID)
+		}
+		toStore = append(toStore, avg)
+	}
+
+	globalDue := !now.Before(m.nextStore)
+	if globalDue {
+		m.nextStore = now.Add(m.storeInterval)
+		for hostID, w := range m.pings {
+			if _, ok := m.collectors[hostID]; ok {
+				continue // stored with the host's averages
+			}
+			rtt, loss := w.averages()
+			toStore = append(toStore, models.SystemMetric{
+				HostID:     hostID,
+				CreatedAt:  now,
+				PingRTT:    rtt,
+				PacketLoss: &loss,
+				PingOnly:   true,
+			})
+			delete(m.pings, hostID)
 		}
-		avg.CPUUsage = math.Round(avg.CPUUsage/n*10) / 10
-		avg.MemTotal = math.Round(avg.MemTotal/n*10) / 10
-		avg.MemUsed = math.Round(avg.MemUsed/n*10) / 10
-		avg.MemUsage = math.Round(avg.MemUsage/n*10) / 10
-		avg.DiskTotal = math.Round(avg.DiskTotal/n*10) / 10
-		avg.DiskUsed = math.Round(avg.DiskUsed/n*10) / 10
-		avg.DiskUsage = math.Round(avg.DiskUsage/n*10) / 10
-		avg.DiskRead = math.Round(avg.DiskRead/n*10) / 10
-		avg.DiskWrite = math.Round(avg.DiskWrite/n*10) / 10
-		avg.NetIn = math.Round(avg.NetIn/n*10) / 10
-		avg.NetOut = math.Round(avg.NetOut/n*10) / 10
-
-		mc.snapshots = mc.snapshots[:0]
-		toStore = append(toStore, avgJob{avg: avg})
 	}
 	m.mu.Unlock()
 
-	for _, j := range toStore {
-		avg := j.avg
-		if err := m.repo.Create(&avg); err != nil {
-			log.Printf("Failed to store metric for host %s: %v", avg.HostID, err)
+	if !globalDue && len(toStore) == 0 {
+		return
+	}
+
+	// Retry earlier failures first so points are written in order
+	pending := m.retry.drain()
+	for i, avg := range pending {
+		if err := m.repo.Create(context.Background(), &avg); err != nil {
+			// Still failing; requeue this and everything after it
+			log.Printf("Retry of %d buffered metrics failed: %v", len(pending)-i, err)
+			for _, p := range pending[i:] {
+				m.retry.push(p)
+			}
+			break
+		}
+		m.retry.markRetried()
+		if !avg.PingOnly {
+			events.MetricStored.Publish(&avg)
+		}
+	}
+
+	for _, avg := range toStore {
+		if err := m.repo.Create(context.Background(), &avg); err != nil {
+			log.Printf("Failed to store metric for host %s, queued for retry: %v", avg.HostID, err)
+			m.retry.push(avg)
+			continue
+		}
+		if !avg.PingOnly {
+			events.MetricStored.Publish(&avg)
 		}
 	}
 }
 
-// GetHistory returns time-series data from the database for a host.
-func (m *CollectorManager) GetHistory(hostID, rangeStr string) (*models.SystemMetricsHistory, error) {
-	var duration time.Duration
-	switch rangeStr {
-	case "12h":
-		duration = 12 * time.Hour
-	case "24h":
-		duration = 24 * time.Hour
-	default:
-		duration = 6 * time.Hour
-		rangeStr = "6h"
+// averageSnapshots averages and clears the buffered snapshots of a host.
+// It returns false when none were buffered. The caller holds m.mu.
+func averageSnapshots(mc *managedCollector, at time.Time) (models.SystemMetric, bool) {
+	if len(mc.snapshots) == 0 {
+		return models.SystemMetric{}, false
+	}
+
+	n := float64(len(mc.snapshots))
+	avg := models.SystemMetric{
+		HostID:    mc.collector.HostID(),
+		CreatedAt: at,
+	}
+	for _, s := range mc.snapshots {
+		avg.CPUUsage += s.CPUUsage
+		avg.MemTotal += s.MemTotal
+		avg.MemUsed += s.MemUsed
+		avg.MemUsage += s.MemUsage
+		avg.DiskTotal += s.DiskTotal
+		avg.DiskUsed += s.DiskUsed
+		avg.DiskUsage += s.DiskUsage
+		avg.DiskRead += s.DiskRead
+		avg.DiskWrite += s.DiskWrite
+		avg.NetIn += s.NetIn
+		avg.NetOut += s.NetOut
+	}
+	avg.CPUUsage = math.Round(avg.CPUUsage/n*10) / 10
+	avg.MemTotal = math.Round(avg.MemTotal/n*10) / 10
+	avg.MemUsed = math.Round(avg.MemUsed/n*10) / 10
+	avg.MemUsage = math.Round(avg.MemUsage/n*10) / 10
+	avg.DiskTotal = math.Round(avg.DiskTotal/n*10) / 10
+	avg.DiskUsed = math.Round(avg.DiskUsed/n*10) / 10
+	avg.DiskUsage = math.Round(avg.DiskUsage/n*10) / 10
+	avg.DiskRead = math.Round(avg.DiskRead/n*10) / 10
+	avg.DiskWrite = math.Round(avg.DiskWrite/n*10) / 10
+	avg.NetIn = math.Round(avg.NetIn/n*10) / 10
+	avg.NetOut = math.Round(avg.NetOut/n*10) / 10
+
+	mc.snapshots = mc.snapshots[:0]
+	return avg, true
+}
+
+// CollectorStatus describes one registered collector, for diagnostics.
+type CollectorStatus struct {
+	HostID          string     `json:"hostId"`
+	Kind            string     `json:"kind"`     // "local", "ssh" or "node_exporter"
+	Buffered        int        `json:"buffered"` // snapshots waiting for the next store
+	CollectInterval int        `json:"collectInterval"` // seconds, the host's own or the global one
+	StoreInterval   int        `json:"storeInterval"`   // seconds
+	LastCollectedAt *time.Time `json:"lastCollectedAt,omitempty"`
+	LastError       string     `json:"lastError,omitempty"`
+}
+
+// Statuses returns the state of every registered collector, sorted by host.
+func (m *CollectorManager) Statuses() []CollectorStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	statuses := make([]CollectorStatus, 0, len(m.collectors))
+	for hostID, mc := range m.collectors {
+		status := CollectorStatus{
+			HostID:    hostID,
+			Kind:      collectorKind(mc.collector),
+			Buffered:  len(mc.snapshots),
+			LastError: mc.lastErr,
+
+			CollectInterval: int(m.collectEvery(mc) / time.Second),
+			StoreInterval:   int(m.storeEvery(mc) / time.Second),
+		}
+		if !mc.lastAt.IsZero() {
+			at := mc.lastAt
+			status.LastCollectedAt = &at
+		}
+		statuses = append(statuses, status)
 	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].HostID < statuses[j].HostID })
+	return statuses
+}
 
+// collectorKind returns "ssh" or "node_exporter" for remote hosts and
+// "local" otherwise.
+func collectorKind(c MetricCollector) string {
+	switch c.(type) {
+	case *SSHCollector:
+		return "ssh"
+	case *NodeExporterCollector:
+		return "node_exporter"
+	}
+	return "local"
+}
+
+// StorageStats returns retry buffer counters for aggregated metric inserts.
+func (m *CollectorManager) StorageStats() StorageStats {
+	return m.retry.stats()
+}
+
+// GetHistory returns time-series data from the database for a host.
+func (m *CollectorManager) GetHistory(hostID, rangeStr string) (*models.SystemMetricsHistory, error) {
+	duration, rangeStr := historyRange(rangeStr)
 	since := time.Now().Add(-duration)
-	points, err := m.repo.GetHistory(hostID, since)
+	points, err := m.repo.GetHistory(context.Background(), hostID, since)
 	if err != nil {
 		return nil, err
 	}
@@ -350,3 +632,26 @@ func (m *CollectorManager) GetHistory(hostID, rangeStr string) (*models.SystemMe
 		Points: points,
 	}, nil
 }
+
+// GetReachability returns the ping round-trip time and packet loss of a
+// host over the same ranges as GetHistory.
+func (m *CollectorManager) GetReachability(hostID, rangeStr string) (*models.ReachabilityHistory, error) {
+	duration, rangeStr := historyRange(rangeStr)
+	points, err := m.repo.GetReachability(context.Background(), hostID, time.Now().Add(-duration))
+	if err != nil {
+		return nil, err
+	}
+	return &models.ReachabilityHistory{Range: rangeStr, Points: points}, nil
+}
+
+// historyRange returns the duration of a history range, "6h" by default.
+func historyRange(rangeStr string) (time.Duration, string) {
+	switch rangeStr {
+	case "12h":
+		return 12 * time.Hour, rangeStr
+	case "24h":
+		return 24 * time.Hour, rangeStr
+	default:
+		return 6 * time.Hour, "6h"
+	}
+}
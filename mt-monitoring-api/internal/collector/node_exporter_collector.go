@@ -0,0 +1,351 @@
+package collector
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/collector/parser"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Compile-time check that NodeExporterCollector implements MetricCollector.
+var _ MetricCollector = (*NodeExporterCollector)(nil)
+
+const (
+	// defaultExporterPort is the node_exporter port used when the host has
+	// no exporterUrl
+	defaultExporterPort = 9100
+
+	// exporterTimeout bounds one scrape of node_exporter
+	exporterTimeout = 10 * time.Second
+
+	// maxExporterResponseBytes bounds the metrics page that is read
+	maxExporterResponseBytes = 16 << 20
+)
+
+// NodeExporterCollector collects metrics from a Prometheus node_exporter
+// already running on a remote host, over HTTP, for hosts that grant no SSH
+// access. node_exporter exports no process list or listening ports.
+type NodeExporterCollector struct {
+	host   *models.Host
+	url    string
+	client *http.Client
+	mu     sync.Mutex
+
+	// Previous counters for delta calculation
+	prevCPU     *parser.CPURaw
+	prevDiskIO  *parser.DiskIORaw
+	prevNetwork *parser.NetworkRaw
+	prevTime    time.Time
+}
+
+// NewNodeExporterCollector creates a collector scraping the node_exporter of
+// the given host.
+func NewNodeExporterCollector(host *models.Host) (*NodeExporterCollector, error) {
+	url := host.ExporterURL
+	if url == "" {
+		if host.IP == "" {
+			return nil, fmt.Errorf("host %s has neither an IP nor an exporterUrl", host.ID)
+		}
+		url = "http://" + net.JoinHostPort(host.IP, strconv.Itoa(defaultExporterPort)) + "/metrics"
+	}
+	return &NodeExporterCollector{
+		host:   host,
+		url:    url,
+		client: &http.Client{Timeout: exporterTimeout},
+	}, nil
+}
+
+// HostID returns the host ID.
+func (c *NodeExporterCollector) HostID() string {
+	return c.host.ID
+}
+
+// Close releases idle HTTP connections.
+func (c *NodeExporterCollector) Close() error {
+	c.client.CloseIdleConnections()
+	return nil
+}
+
+// nodeMetrics is one scrape of node_exporter
+type nodeMetrics map[string][]parser.Sample
+
+// scrape fetches and parses the metrics page.
+func (c *NodeExporterCollector) scrape() (nodeMetrics, error) {
+	req, err := http.NewRequest(http.MethodGet, c.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/plain")
+	req.Header.Set("User-Agent", "MT-Monitoring/1.0")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("node_exporter scrape failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("node_exporter returned status %d", resp.StatusCode)
+	}
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxExporterResponseBytes))
+	if err != nil {
+		return nil, fmt.Errorf("node_exporter scrape failed: %w", err)
+	}
+	return nodeMetrics(parser.ParsePrometheusText(string(body))), nil
+}
+
+// sum adds up the samples of a metric whose labels pass keep (all when nil).
+// ok is false when the metric has no such samples.
+func (n nodeMetrics) sum(name string, keep func(labels map[string]string) bool) (total float64, ok bool) {
+	for _, s := range n[name] {
+		if keep == nil || keep(s.Labels) {
+			total += s.Value
+			ok = true
+		}
+	}
+	return total, ok
+}
+
+// value returns the first sample of a metric with the given label value.
+func (n nodeMetrics) value(name, label, want string) (float64, bool) {
+	for _, s := range n[name] {
+		if label == "" || s.Labels[label] == want {
+			return s.Value, true
+		}
+	}
+	return 0, false
+}
+
+// label returns a label of the first sample of a metric, e.g. of the
+// node_uname_info info metric.
+func (n nodeMetrics) label(name, label string) string {
+	if samples := n[name]; len(samples) > 0 {
+		return samples[0].Labels[label]
+	}
+	return ""
+}
+
+// cpu sums node_cpu_seconds_total per mode over all CPUs, in jiffies.
+func (n nodeMetrics) cpu() (*parser.CPURaw, error) {
+	modes := make(map[string]uint64)
+	for _, s := range n["node_cpu_seconds_total"] {
+		modes[s.Labels["mode"]] += uint64(s.Value * 100)
+	}
+	if len(modes) == 0 {
+		return nil, fmt.Errorf("no node_cpu_seconds_total")
+	}
+	return &parser.CPURaw{
+		User: modes["user"], Nice: modes["nice"], System: modes["system"], Idle: modes["idle"],
+		IOWait: modes["iowait"], IRQ: modes["irq"], SoftIRQ: modes["softirq"], Steal: modes["steal"],
+	}, nil
+}
+
+// memory reads node_memory_MemTotal_bytes and MemAvailable_bytes, falling
+// back to free + buffers + cached on kernels without MemAvailable.
+func (n nodeMetrics) memory() (*parser.MemoryInfo, error) {
+	total, ok := n.value("node_memory_MemTotal_bytes", "", "")
+	if !ok || total == 0 {
+		return nil, fmt.Errorf("no node_memory_MemTotal_bytes")
+	}
+	available, ok := n.value("node_memory_MemAvailable_bytes", "", "")
+	if !ok {
+		free, _ := n.value("node_memory_MemFree_bytes", "", "")
+		buffers, _ := n.value("node_memory_Buffers_bytes", "", "")
+		cached, _ := n.value("node_memory_Cached_bytes", "", "")
+		available = free + buffers + cached
+	}
+	return parser.NewMemoryInfo(uint64(total)/1024, uint64(available)/1024), nil
+}
+
+// disk reads the size and free space of the root filesystem.
+func (n nodeMetrics) disk() (*parser.DiskUsageInfo, error) {
+	size, ok := n.value("node_filesystem_size_bytes", "mountpoint", "/")
+	if !ok || size == 0 {
+		return nil, fmt.Errorf("no node_filesystem_size_bytes for /")
+	}
+	free, _ := n.value("node_filesystem_free_bytes", "mountpoint", "/")
+	return parser.NewDiskUsageInfo(uint64(size), uint64(size-free)), nil
+}
+
+// diskIO sums the bytes read and written by all disks, in 512-byte sectors.
+func (n nodeMetrics) diskIO() (*parser.DiskIORaw, error) {
+	read, ok := n.sum("node_disk_read_bytes_total", nil)
+	if !ok {
+		return nil, fmt.Errorf("no node_disk_read_bytes_total")
+	}
+	written, _ := n.sum("node_disk_written_bytes_total", nil)
+	return &parser.DiskIORaw{ReadSectors: uint64(read) / 512, WriteSectors: uint64(written) / 512}, nil
+}
+
+// network sums the bytes received and sent by all interfaces but loopback.
+func (n nodeMetrics) network() (*parser.NetworkRaw, error) {
+	notLoopback := func(labels map[string]string) bool { return labels["device"] != "lo" }
+	recv, ok := n.sum("node_network_receive_bytes_total", notLoopback)
+	if !ok {
+		return nil, fmt.Errorf("no node_network_receive_bytes_total")
+	}
+	sent, _ := n.sum("node_network_transmit_bytes_total", notLoopback)
+	return &parser.NetworkRaw{BytesRecv: uint64(recv), BytesSent: uint64(sent)}, nil
+}
+
+// Collect gathers a single snapshot of system metrics from node_exporter. It
+// returns a *PartialCollectionError when some metrics are not exported.
+func (c *NodeExporterCollector) Collect() (*models.SystemMetric, error) {
+	metrics, err := c.scrape()
+	if err != nil {
+		return nil, fmt.Errorf("collect failed for %s: %w", c.host.ID, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	var failures []string
+	fail := func(subsystem string, err error) {
+		failures = append(failures, subsystem+": "+err.Error())
+	}
+
+	var cpuUsage float64
+	cpuRaw, err := metrics.cpu()
+	if err != nil {
+		fail("cpu", err)
+	} else if c.prevCPU != nil {
+		cpuUsage = parser.CalculateCPUUsage(c.prevCPU, cpuRaw)
+	}
+	c.prevCPU = cpuRaw
+
+	memInfo, err := metrics.memory()
+	if err != nil {
+		fail("memory", err)
+	}
+	diskUsage, err := metrics.disk()
+	if err != nil {
+		fail("disk", err)
+	}
+
+	elapsed := now.Sub(c.prevTime).Seconds()
+	var diskReadMBps, diskWriteMBps float64
+	diskIORaw, err := metrics.diskIO()
+	if err != nil {
+		fail("disk_io", err)
+	} else if c.prevDiskIO != nil && !c.prevTime.IsZero() {
+		diskReadMBps, diskWriteMBps = parser.CalculateDiskIO(c.prevDiskIO, diskIORaw, elapsed)
+	}
+	c.prevDiskIO = diskIORaw
+
+	var netInMBps, netOutMBps float64
+	netRaw, err := metrics.network()
+	if err != nil {
+		fail("network", err)
+	} else if c.prevNetwork != nil && !c.prevTime.IsZero() {
+		netInMBps, netOutMBps = parser.CalculateNetworkIO(c.prevNetwork, netRaw, elapsed)
+	}
+	c.prevNetwork = netRaw
+
+	c.prevTime = now
+
+	if len(failures) > 0 {
+		return nil, &PartialCollectionError{HostID: c.host.ID, Failures: failures}
+	}
+	return &models.SystemMetric{
+		HostID:    c.host.ID,
+		CPUUsage:  cpuUsage,
+		MemTotal:  memInfo.TotalGB,
+		MemUsed:   memInfo.UsedGB,
+		MemUsage:  memInfo.UsagePercent,
+		DiskTotal: diskUsage.TotalGB,
+		DiskUsed:  diskUsage.UsedGB,
+		DiskUsage: diskUsage.UsagePercent,
+		DiskRead:  diskReadMBps,
+		DiskWrite: diskWriteMBps,
+		NetIn:     netInMBps,
+		NetOut:    netOutMBps,
+		CreatedAt: now,
+	}, nil
+}
+
+// GetSystemInfo returns host information with the current resource snapshot.
+func (c *NodeExporterCollector) GetSystemInfo() (*models.SystemInfo, error) {
+	metrics, err := c.scrape()
+	if err != nil {
+		return nil, err
+	}
+
+	platform := metrics.label("node_os_info", "pretty_name")
+	if platform == "" {
+		platform = "linux"
+	}
+	info := &models.SystemInfo{
+		Hostname: metrics.label("node_uname_info", "nodename"),
+		OS:       strings.ToLower(metrics.label("node_uname_info", "sysname")),
+		Platform: platform,
+		IP:       c.host.IP,
+		CPU:      models.CPUInfo{Cores: metrics.cpuCores()},
+	}
+	if info.OS == "" {
+		info.OS = "linux"
+	}
+	now, okNow := metrics.value("node_time_seconds", "", "")
+	boot, okBoot := metrics.value("node_boot_time_seconds", "", "")
+	if okNow && okBoot && now > boot {
+		info.Uptime = uint64(now - boot)
+	}
+
+	if memInfo, err := metrics.memory(); err == nil {
+		info.Memory = models.MemInfo{
+			Total: memInfo.TotalGB,
+			Used:  memInfo.UsedGB,
+			Usage: memInfo.UsagePercent,
+		}
+	}
+	if diskUsage, err := metrics.disk(); err == nil {
+		info.Disk = models.DiskInfo{
+			Total: diskUsage.TotalGB,
+			Used:  diskUsage.UsedGB,
+			Usage: diskUsage.UsagePercent,
+		}
+	}
+	return info, nil
+}
+
+// cpuCores counts the CPUs of node_cpu_seconds_total.
+func (n nodeMetrics) cpuCores() int {
+	cpus := make(map[string]bool)
+	for _, s := range n["node_cpu_seconds_total"] {
+		cpus[s.Labels["cpu"]] = true
+	}
+	return len(cpus)
+}
+
+// GetFacts returns the inventory facts node_exporter exports: kernel, CPU
+// model (with --collector.cpu.info) and cores. Virtualization, packages and
+// listening ports stay empty.
+func (c *NodeExporterCollector) GetFacts() (*models.HostFacts, error) {
+	metrics, err := c.scrape()
+	if err != nil {
+		return nil, fmt.Errorf("facts failed for %s: %w", c.host.ID, err)
+	}
+	return &models.HostFacts{
+		HostID:      c.host.ID,
+		Kernel:      metrics.label("node_uname_info", "release"),
+		CPUModel:    metrics.label("node_cpu_info", "model_name"),
+		CPUCores:    metrics.cpuCores(),
+		CollectedAt: time.Now(),
+	}, nil
+}
+
+// GetListeningPorts is not supported: node_exporter exports no sockets.
+func (c *NodeExporterCollector) GetListeningPorts() ([]models.ListeningPort, error) {
+	return nil, fmt.Errorf("listening ports: %w", ErrNotSupported)
+}
+
+// GetProcesses is not supported: node_exporter exports no process list.
+func (c *NodeExporterCollector) GetProcesses(limit int, sortBy string) ([]models.ProcessInfo, error) {
+	return nil, fmt.Errorf("process list: %w", ErrNotSupported)
+}
@@ -0,0 +1,103 @@
+package parser
+
+import (
+	"cmp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ParseTopProcesses parses `top -b -n 1` output of busybox, whose ps has no
+// %CPU column and no --sort, and returns the top processes sorted by CPU or,
+// when sortBy is "memory", by virtual memory size (busybox top shows no RSS).
+// Format, after the summary lines:
+//
+//	PID  PPID USER     STAT   VSZ %VSZ CPU %CPU COMMAND
+//	  1     0 root     S     1600   0%   0   0% /sbin/init
+func ParseTopProcesses(topOutput string, limit int, sortBy string) []ProcessInfo {
+	var procs []ProcessInfo
+	var header []string
+	for _, line := range strings.Split(topOutput, "\n") {
+		fields := strings.Fields(line)
+		if header == nil {
+			if len(fields) > 0 && fields[0] == "PID" {
+				header = fields
+			}
+			continue
+		}
+		if len(fields) < len(header) {
+			continue
+		}
+
+		col := func(name string) string {
+			if i := slices.Index(header, name); i >= 0 {
+				return fields[i]
+			}
+			return ""
+		}
+		pid, err := strconv.ParseInt(col("PID"), 10, 32)
+		if err != nil {
+			continue
+		}
+		cpuPct, _ := strconv.ParseFloat(strings.TrimSuffix(col("%CPU"), "%"), 64)
+		vsz := parseBusyboxKB(col("VSZ"))
+
+		name := ""
+		if i := slices.Index(header, "COMMAND"); i >= 0 {
+			name = fields[i]
+		}
+		if idx := strings.LastIndex(name, "/"); idx >= 0 {
+			name = name[idx+1:]
+		}
+		name = strings.TrimLeft(name, "{[-")
+		name = strings.TrimRight(name, "]}")
+
+		status := "running"
+		if stat := col("STAT"); len(stat) > 0 {
+			switch stat[0] {
+			case 'S', 'I':
+				status = "sleeping"
+			case 'T':
+				status = "stopped"
+			case 'Z':
+				status = "zombie"
+			}
+		}
+
+		procs = append(procs, ProcessInfo{
+			PID:    int32(pid),
+			Name:   name,
+			CPU:    float64(int(cpuPct*10)) / 10,
+			Memory: formatKB(vsz),
+			MemKB:  vsz,
+			Status: status,
+		})
+	}
+
+	slices.SortStableFunc(procs, func(a, b ProcessInfo) int {
+		if sortBy == "memory" {
+			return cmp.Compare(b.MemKB, a.MemKB)
+		}
+		return cmp.Compare(b.CPU, a.CPU)
+	})
+	if limit > 0 && len(procs) > limit {
+		procs = procs[:limit]
+	}
+	return procs
+}
+
+// parseBusyboxKB parses a busybox size column: KB, or with an m, g or t
+// suffix once the value no longer fits the column.
+func parseBusyboxKB(s string) uint64 {
+	mult := uint64(1)
+	switch {
+	case strings.HasSuffix(s, "m"):
+		mult = 1 << 10
+	case strings.HasSuffix(s, "g"):
+		mult = 1 << 20
+	case strings.HasSuffix(s, "t"):
+		mult = 1 << 30
+	}
+	v, _ := strconv.ParseUint(strings.TrimRight(s, "mgt"), 10, 64)
+	return v * mult
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ListeningPort is a listening socket parsed from ss or netstat output.
+type ListeningPort struct {
+	Protocol string // "tcp" or "udp"
+	Address  string
+	Port     int
+}
+
+// ParseCPUInfo parses /proc/cpuinfo and returns the CPU model and the number
+// of logical cores. ARM kernels name the model differently, so the first of
+// the known model keys wins.
+func ParseCPUInfo(cpuinfoContent string) (model string, cores int) {
+	for _, line := range strings.Split(cpuinfoContent, "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			cores++
+		case "model name", "Model", "Hardware", "cpu model":
+			if model == "" {
+				model = value
+			}
+		}
+	}
+	return model, cores
+}
+
+// ParseVirtualization parses systemd-detect-virt output; "none" and missing
+// output are returned as "".
+func ParseVirtualization(output string) string {
+	virt := strings.TrimSpace(output)
+	if virt == "none" {
+		return ""
+	}
+	return virt
+}
+
+// ParsePackageCounts parses "<manager> <count>" lines into installed package
+// counts per package manager.
+func ParsePackageCounts(output string) map[string]int {
+	counts := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		if n, err := strconv.Atoi(fields[1]); err == nil && n > 0 {
+			counts[fields[0]] = n
+		}
+	}
+	return counts
+}
+
+// ParseUpdateCounts parses the "<manager> <security> <total>" line printed
+// by the updates check. ok is false when the host has no supported package
+// manager.
+func ParseUpdateCounts(output string) (manager string, security, total int, ok bool) {
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			continue
+		}
+		sec, err1 := strconv.Atoi(fields[1])
+		all, err2 := strconv.Atoi(fields[2])
+		if err1 == nil && err2 == nil {
+			return fields[0], sec, max(all, sec), true
+		}
+	}
+	return "", 0, 0, false
+}
+
+// ParseListeningPorts parses `ss -tuln` or, on hosts without ss,
+// `netstat -tuln` output: the listening TCP sockets and unconnected UDP
+// sockets, normalized by NormalizeListeningPorts.
+//
+//	Netid State  Recv-Q Send-Q Local Address:Port Peer Address:Port   (ss)
+//	tcp   LISTEN 0      4096   0.0.0.0:22         0.0.0.0:*
+//	Proto Recv-Q Send-Q Local Address Foreign Address State           (netstat)
+//	tcp6  0      0      :::22         :::*            LISTEN
+func ParseListeningPorts(output string) []ListeningPort {
+	var ports []ListeningPort
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 5 {
+			continue
+		}
+		proto := strings.TrimRight(fields[0], "46")
+		if proto != "tcp" && proto != "udp" {
+			continue
+		}
+
+		var local string
+		switch fields[1] {
+		case "LISTEN", "UNCONN":
+			local = fields[4]
+		default:
+			if proto == "tcp" && fields[len(fields)-1] != "LISTEN" {
+				continue
+			}
+			local = fields[3]
+		}
+
+		i := strings.LastIndex(local, ":")
+		if i < 0 {
+			continue
+		}
+		port, err := strconv.Atoi(local[i+1:])
+		if err != nil {
+			continue
+		}
+		addr := strings.Trim(local[:i], "[]")
+		if j := strings.Index(addr, "%"); j >= 0 {
+			addr = addr[:j] // interface suffix, e.g. 127.0.0.53%lo
+		}
+		ports = append(ports, ListeningPort{Protocol: proto, Address: addr, Port: port})
+	}
+	return NormalizeListeningPorts(ports)
+}
+
+// NormalizeListeningPorts writes wildcard addresses as "*", then sorts the
+// ports by protocol, port and address and drops repeated ones.
+func NormalizeListeningPorts(ports []ListeningPort) []ListeningPort {
+	for i := range ports {
+		switch ports[i].Address {
+		case "0.0.0.0", "::", "":
+			ports[i].Address = "*"
+		}
+	}
+	slices.SortFunc(ports, func(a, b ListeningPort) int {
+		if c := strings.Compare(a.Protocol, b.Protocol); c != 0 {
+			return c
+		}
+		if a.Port != b.Port {
+			return a.Port - b.Port
+		}
+		return strings.Compare(a.Address, b.Address)
+	})
+	return slices.Compact(ports)
+}
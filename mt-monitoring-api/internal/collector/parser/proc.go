@@ -119,6 +119,11 @@ func ParseMemory(meminfoContent string) (*MemoryInfo, error) {
 		available = values["MemFree"] + values["Buffers"] + values["Cached"]
 	}
 
+	return NewMemoryInfo(total, available), nil
+}
+
+// NewMemoryInfo returns the memory usage for total and available KB.
+func NewMemoryInfo(total, available uint64) *MemoryInfo {
 	used := total - available
 	totalGB := float64(int(float64(total)/(1024*1024)*10)) / 10
 	usedGB := float64(int(float64(used)/(1024*1024)*10)) / 10
@@ -130,7 +135,7 @@ func ParseMemory(meminfoContent string) (*MemoryInfo, error) {
 		TotalGB:      totalGB,
 		UsedGB:       usedGB,
 		UsagePercent: usage,
-	}, nil
+	}
 }
 
 // ParseDiskUsage parses `df -B1 /` output.
@@ -138,6 +143,12 @@ func ParseMemory(meminfoContent string) (*MemoryInfo, error) {
 //   Filesystem     1B-blocks        Used   Available Use% Mounted on
 //   /dev/sda1      214748364800 51539607552 152177049600  26% /
 func ParseDiskUsage(dfOutput string) (*DiskUsageInfo, error) {
+	return ParseDiskUsageBlocks(dfOutput, 1)
+}
+
+// ParseDiskUsageBlocks parses df output in blockSize-byte blocks, e.g.
+// `df -Pk /` (1024) on busybox hosts, whose df has no -B.
+func ParseDiskUsageBlocks(dfOutput string, blockSize uint64) (*DiskUsageInfo, error) {
 	lines := strings.Split(strings.TrimSpace(dfOutput), "\n")
 	// Find the data line (skip header)
 	for _, line := range lines {
@@ -151,22 +162,27 @@ func ParseDiskUsage(dfOutput string) (*DiskUsageInfo, error) {
 		// fields: Filesystem 1B-blocks Used Available Use% Mounted
 		total, _ := strconv.ParseUint(fields[1], 10, 64)
 		used, _ := strconv.ParseUint(fields[2], 10, 64)
+		total, used = total*blockSize, used*blockSize
 
 		if total == 0 {
 			continue
 		}
+		return NewDiskUsageInfo(total, used), nil
+	}
+	return nil, fmt.Errorf("no disk usage data found in df output")
+}
 
-		totalGB := float64(int(float64(total)/(1024*1024*1024)*10)) / 10
-		usedGB := float64(int(float64(used)/(1024*1024*1024)*10)) / 10
-		usage := float64(int(float64(used)/float64(total)*1000)) / 10
+// NewDiskUsageInfo returns the disk usage for total and used bytes.
+func NewDiskUsageInfo(total, used uint64) *DiskUsageInfo {
+	totalGB := float64(int(float64(total)/(1024*1024*1024)*10)) / 10
+	usedGB := float64(int(float64(used)/(1024*1024*1024)*10)) / 10
+	usage := float64(int(float64(used)/float64(total)*1000)) / 10
 
-		return &DiskUsageInfo{
-			TotalGB:      totalGB,
-			UsedGB:       usedGB,
-			UsagePercent: usage,
-		}, nil
+	return &DiskUsageInfo{
+		TotalGB:      totalGB,
+		UsedGB:       usedGB,
+		UsagePercent: usage,
 	}
-	return nil, fmt.Errorf("no disk usage data found in df output")
 }
 
 // ParseDiskIO parses /proc/diskstats and returns total read/write sectors.
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Sample is one sample of the Prometheus text exposition format.
+type Sample struct {
+	Name   string
+	Labels map[string]string
+	Value  float64
+}
+
+// ParsePrometheusText parses the Prometheus text exposition format, e.g. the
+// /metrics page of node_exporter, and returns the samples by metric name.
+// Comments, timestamps and lines that do not parse are skipped.
+// Format:
+//
+//	# HELP node_cpu_seconds_total Seconds the CPUs spent in each mode.
+//	node_cpu_seconds_total{cpu="0",mode="idle"} 1.23e+06
+func ParsePrometheusText(text string) map[string][]Sample {
+	samples := make(map[string][]Sample)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' {
+			continue
+		}
+		sample, ok := parseSampleLine(line)
+		if ok {
+			samples[sample.Name] = append(samples[sample.Name], sample)
+		}
+	}
+	return samples
+}
+
+// parseSampleLine parses `name{label="value",...} value [timestamp]`.
+func parseSampleLine(line string) (Sample, bool) {
+	var s Sample
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return s, false
+	}
+	s.Name = line[:end]
+	rest := line[end:]
+
+	if rest[0] == '{' {
+		labels, n, ok := parseLabels(rest[1:])
+		if !ok {
+			return s, false
+		}
+		s.Labels = labels
+		rest = rest[1+n:]
+	}
+
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return s, false
+	}
+	v, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return s, false
+	}
+	s.Value = v
+	return s, true
+}
+
+// parseLabels parses `label="value",...}` and returns the labels and the
+// length consumed, including the closing brace.
+func parseLabels(text string) (map[string]string, int, bool) {
+	labels := make(map[string]string)
+	i := 0
+	for {
+		for i < len(text) && (text[i] == ' ' || text[i] == ',') {
+			i++
+		}
+		if i < len(text) && text[i] == '}' {
+			return labels, i + 1, true
+		}
+
+		eq := strings.IndexByte(text[i:], '=')
+		if eq <= 0 || i+eq+1 >= len(text) || text[i+eq+1] != '"' {
+			return nil, 0, false
+		}
+		name := strings.TrimSpace(text[i : i+eq])
+		i += eq + 2
+
+		var value strings.Builder
+		for ; i < len(text) && text[i] != '"'; i++ {
+			if text[i] == '\\' && i+1 < len(text) {
+				i++
+				if text[i] == 'n' {
+					value.WriteByte('\n')
+					continue
+				}
+			}
+			value.WriteByte(text[i])
+		}
+		if i >= len(text) {
+			return nil, 0, false
+		}
+		labels[name] = value.String()
+		i++ // closing quote
+	}
+}
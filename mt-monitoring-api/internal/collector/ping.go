@@ -0,0 +1,122 @@
+package collector
+
+import (
+	"context"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/cluster"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// pingWindow sums the ping rounds of a host since the last store
+type pingWindow struct {
+	sent     int
+	received int
+	rttSum   float64 // ms, over received replies
+}
+
+// averages returns the mean round-trip time, nil when no reply came back,
+// and the packet loss percentage of the window
+func (w *pingWindow) averages() (*float64, float64) {
+	loss := math.Round(float64(w.sent-w.received)/float64(w.sent)*1000) / 10
+	if w.received == 0 {
+		return nil, loss
+	}
+	rtt := math.Round(w.rttSum/float64(w.received)*10) / 10
+	return &rtt, loss
+}
+
+// pingLoop pings the hosts every system.ping.interval seconds until the
+// manager stops. The settings are read again each round, so a reload can
+// turn pinging on and off or change its interval.
+func (m *CollectorManager) pingLoop() {
+	for {
+		interval := 30 * time.Second
+		if cfg := config.Get(); cfg != nil && cfg.System.Ping.Enabled {
+			if cfg.System.Ping.Interval > 0 {
+				interval = time.Duration(cfg.System.Ping.Interval) * time.Second
+			}
+			m.pingAll(cfg.System.Ping)
+		}
+
+		select {
+		case <-time.After(interval):
+		case <-m.stopCh:
+			return
+		}
+	}
+}
+
+// pingAll pings the active remote hosts this node owns in parallel
+func (m *CollectorManager) pingAll(cfg config.PingConfig) {
+	hosts, err := m.hosts.GetActive(context.Background())
+	if err != nil {
+		log.Printf("Failed to get hosts to ping: %v", err)
+		return
+	}
+
+	var wg sync.WaitGroup
+	for i := range hosts {
+		host := &hosts[i]
+		if host.Type != models.HostTypeRemote || host.IP == "" || !cluster.Owns(host.ID) {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := pingHost(host, cfg)
+			m.recordPing(result)
+			events.HostPinged.Publish(events.Ping{HostID: host.ID, HostName: host.Name, Result: result})
+		}()
+	}
+	wg.Wait()
+}
+
+// pingHost sends Count echo requests to a host, one after another
+func pingHost(host *models.Host, cfg config.PingConfig) *models.PingResult {
+	count := max(cfg.Count, 1)
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = 1000
+	}
+
+	icmp := checker.NewICMPChecker()
+	result := &models.PingResult{HostID: host.ID, Sent: count, CheckedAt: time.Now()}
+	var rttSum int
+	for i := 0; i < count; i++ {
+		r := icmp.Check(host.IP, timeout)
+		if r.Status != models.CheckStatusSuccess {
+			result.Error = r.ErrorMessage
+			continue
+		}
+		result.Received++
+		rttSum += r.ResponseTime
+	}
+	if result.Received > 0 {
+		result.RTT = math.Round(float64(rttSum)/float64(result.Received)*10) / 10
+		result.Error = ""
+	}
+	result.Loss = math.Round(float64(count-result.Received)/float64(count)*1000) / 10
+	return result
+}
+
+// recordPing adds a ping round to the host's window
+func (m *CollectorManager) recordPing(r *models.PingResult) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	w, ok := m.pings[r.HostID]
+	if !ok {
+		w = &pingWindow{}
+		m.pings[r.HostID] = w
+	}
+	w.sent += r.Sent
+	w.received += r.Received
+	w.rttSum += r.RTT * float64(r.Received)
+}
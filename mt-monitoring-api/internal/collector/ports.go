@@ -0,0 +1,71 @@
+package collector
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// portsInterval returns how often the listening ports of a host are
+// checked, or 0 when the check is disabled.
+func portsInterval() time.Duration {
+	cfg := config.Get()
+	if cfg == nil || !cfg.System.Ports.Enabled || cfg.System.Ports.Interval < 1 {
+		return 0
+	}
+	return time.Duration(cfg.System.Ports.Interval) * time.Second
+}
+
+// portsDue reports whether the listening ports of a host should be checked.
+// Hosts scraped through node_exporter have no port list.
+func (m *CollectorManager) portsDue(mc *managedCollector) bool {
+	interval := portsInterval()
+	if interval == 0 {
+		return false
+	}
+	if _, ok := mc.collector.(*NodeExporterCollector); ok {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(mc.portsAt) >= interval
+}
+
+// checkPorts compares the listening ports of a host with its expected
+// ports, stores them and publishes the result. The first check of a host
+// takes its ports as the expected ones.
+func (m *CollectorManager) checkPorts(hostID, hostName string, mc *managedCollector) error {
+	m.mu.Lock()
+	mc.portsAt = time.Now()
+	m.mu.Unlock()
+
+	current, err := mc.collector.GetListeningPorts()
+	if err != nil {
+		return err
+	}
+	stored, err := m.ports.Get(context.Background(), hostID)
+	if err != nil {
+		return err
+	}
+
+	ports := &models.HostPorts{HostID: hostID, Current: current, CheckedAt: time.Now()}
+	changed := true
+	if stored != nil {
+		ports.Expected = stored.Expected
+		changed = !slices.Equal(models.PortKeys(stored.Current), models.PortKeys(current))
+	} else {
+		ports.Expected = models.PortKeys(current)
+	}
+	ports.Compare()
+
+	if err := m.ports.Save(context.Background(), ports, changed); err != nil {
+		return err
+	}
+	events.PortsChecked.Publish(events.Ports{HostID: hostID, HostName: hostName, Ports: ports})
+	return nil
+}
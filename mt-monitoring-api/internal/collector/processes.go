@@ -0,0 +1,65 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// processHistoryTopK returns how many processes are recorded per sample, or
+// 0 when process history is disabled.
+func processHistoryTopK() int {
+	cfg := config.Get()
+	if cfg == nil || !cfg.System.ProcessHistory.Enabled {
+		return 0
+	}
+	return cfg.System.ProcessHistory.TopK
+}
+
+// processesDue reports whether a process sample of a host should be
+// recorded: once per store interval of the host, like the metric averages.
+// Hosts scraped through node_exporter have no process list.
+func (m *CollectorManager) processesDue(mc *managedCollector) bool {
+	if processHistoryTopK() < 1 {
+		return false
+	}
+	if _, ok := mc.collector.(*NodeExporterCollector); ok {
+		return false
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return time.Since(mc.processesAt) >= m.storeEvery(mc)
+}
+
+// recordProcesses stores the top processes of a host by CPU.
+func (m *CollectorManager) recordProcesses(hostID string, mc *managedCollector) error {
+	m.mu.Lock()
+	mc.processesAt = time.Now()
+	m.mu.Unlock()
+
+	processes, err := mc.collector.GetProcesses(processHistoryTopK(), "cpu")
+	if err != nil {
+		return err
+	}
+	return m.processes.CreateSample(context.Background(), hostID, time.Now(), processes)
+}
+
+// GetProcessHistory returns the recorded top processes of a host over the
+// same ranges as GetHistory.
+func (m *CollectorManager) GetProcessHistory(hostID, rangeStr string) (*models.ProcessHistory, error) {
+	duration, rangeStr := historyRange(rangeStr)
+	samples, err := m.processes.GetHistory(context.Background(), hostID, time.Now().Add(-duration))
+	if err != nil {
+		return nil, err
+	}
+	return &models.ProcessHistory{Range: rangeStr, Samples: samples}, nil
+}
+
+// GetProcessSample returns the last recorded top processes of a host at or
+// before a time, nil when none were recorded.
+func (m *CollectorManager) GetProcessSample(hostID string, at time.Time) (*models.ProcessSample, error) {
+	return m.processes.GetSampleAt(context.Background(), hostID, at)
+}
@@ -0,0 +1,81 @@
+package collector
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os/exec"
+)
+
+// RemediationRunner is implemented by the collectors that can run the
+// remediation commands of alert rules on their host.
+type RemediationRunner interface {
+	// RunRemediation runs a shell command and returns its combined output.
+	RunRemediation(ctx context.Context, cmd string) (string, error)
+}
+
+// RunRemediation runs a remediation command on the host of a collector
+// running on this server. Hosts scraped through node_exporter cannot run
+// commands; hosts collected by another cluster node are not found here.
+func (m *CollectorManager) RunRemediation(ctx context.Context, hostID, cmd string) (string, error) {
+	c := m.GetCollector(hostID)
+	if c == nil {
+		return "", fmt.Errorf("host %s is not collected by this server", hostID)
+	}
+	runner, ok := c.(RemediationRunner)
+	if !ok {
+		return "", fmt.Errorf("running commands on host %s: %w", hostID, ErrNotSupported)
+	}
+	return runner.RunRemediation(ctx, cmd)
+}
+
+// RunRemediation runs a command on the monitoring server itself. Like on
+// SSH hosts it is refused in restricted mode.
+func (c *LocalCollector) RunRemediation(ctx context.Context, cmd string) (string, error) {
+	if restrictedSSH() {
+		log.Printf("Refused local remediation command in restricted mode: %q", cmd)
+		return "", fmt.Errorf("remediation commands not allowed in restricted mode")
+	}
+	output, err := exec.CommandContext(ctx, "sh", "-c", cmd).CombinedOutput()
+	return string(output), err
+}
+
+// RunRemediation runs a command on the remote host over the collector's
+// connection, closing the session when ctx ends. Like every command
+// outside sshCommands it is refused in restricted mode.
+func (c *SSHCollector) RunRemediation(ctx context.Context, cmd string) (string, error) {
+	if restrictedSSH() {
+		log.Printf("Refused SSH command outside the allowlist for %s: %q", c.host.ID, cmd)
+		return "", fmt.Errorf("SSH command not allowed in restricted mode")
+	}
+	if err := c.ensureConnected(); err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	client := c.client
+	c.mu.Unlock()
+
+	session, err := client.NewSession()
+	if err != nil {
+		return "", fmt.Errorf("SSH session failed: %w", err)
+	}
+	defer session.Close()
+
+	var output bytes.Buffer
+	session.Stdout = &output
+	session.Stderr = &output
+	done := make(chan error, 1)
+	go func() { done <- session.Run(cmd) }()
+
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		// Closing the session makes Run return; output is written to until then
+		session.Close()
+		<-done
+		err = ctx.Err()
+	}
+	return output.String(), err
+}
@@ -0,0 +1,74 @@
+package collector
+
+import (
+	"sync"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// StorageStats reports how aggregated system metrics fared against the database
+type StorageStats struct {
+	Pending int   `json:"pending"` // failed inserts waiting for retry
+	Retried int64 `json:"retried"` // inserts that succeeded on retry
+	Dropped int64 `json:"dropped"` // points discarded because the buffer was full
+}
+
+// retryBuffer holds aggregated metrics whose insert failed (e.g. the database
+// was briefly locked) so the next store cycle can try again. It is bounded;
+// when full the oldest point is dropped and counted.
+type retryBuffer struct {
+	mu      sync.Mutex
+	items   []models.SystemMetric
+	size    int
+	retried int64
+	dropped int64
+}
+
+func newRetryBuffer(size int) *retryBuffer {
+	return &retryBuffer{size: size}
+}
+
+// push queues a failed metric, evicting the oldest one when full
+func (b *retryBuffer) push(m models.SystemMetric) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.size <= 0 {
+		b.dropped++
+		return
+	}
+	if len(b.items) >= b.size {
+		b.items = b.items[1:]
+		b.dropped++
+	}
+	b.items = append(b.items, m)
+}
+
+// drain removes and returns every queued metric, oldest first
+func (b *retryBuffer) drain() []models.SystemMetric {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	items := b.items
+	b.items = nil
+	return items
+}
+
+// markRetried counts a metric that was stored on retry
+func (b *retryBuffer) markRetried() {
+	b.mu.Lock()
+	b.retried++
+	b.mu.Unlock()
+}
+
+// stats returns the current counters
+func (b *retryBuffer) stats() StorageStats {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return StorageStats{
+		Pending: len(b.items),
+		Retried: b.retried,
+		Dropped: b.dropped,
+	}
+}
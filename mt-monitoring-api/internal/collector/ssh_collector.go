@@ -1,7 +1,10 @@
 package collector
 
 import (
+	"bytes"
+	"context"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
@@ -13,16 +16,38 @@ import (
 	"github.com/mt-monitoring/api/internal/collector/parser"
 	"github.com/mt-monitoring/api/internal/config"
 	"github.com/mt-monitoring/api/internal/models"
+	"github.com/mt-monitoring/api/internal/secrets"
 )
 
 // Compile-time check that SSHCollector implements MetricCollector.
 var _ MetricCollector = (*SSHCollector)(nil)
 
 // combinedCommand is a single SSH command that fetches all metrics at once.
-const combinedCommand = `echo "===STAT===" && head -1 /proc/stat && echo "===MEMINFO===" && cat /proc/meminfo && echo "===DF===" && df -B1 / && echo "===DISKSTATS===" && cat /proc/diskstats && echo "===NETDEV===" && cat /proc/net/dev && echo "===UPTIME===" && cat /proc/uptime && echo "===HOSTNAME===" && hostname && echo "===END==="`
-
-// processCommand fetches the top N processes sorted by CPU.
-const processCommand = `ps aux --sort=-%cpu | head -%d`
+// Each section runs even when an earlier one failed; Collect checks them
+// one by one. Busybox df has no -B, so it falls back to 1K blocks in a DFK
+// section.
+const combinedCommand = `echo "===STAT==="; head -1 /proc/stat; echo "===MEMINFO==="; cat /proc/meminfo; echo "===DF==="; (df -B1 / 2>/dev/null || (echo "===DFK===" && df -Pk /)); echo "===DISKSTATS==="; cat /proc/diskstats; echo "===NETDEV==="; cat /proc/net/dev; echo "===UPTIME==="; cat /proc/uptime; echo "===HOSTNAME==="; hostname; echo "===END==="`
+
+// packagesScript prints "<manager> <count>" for each package manager found
+// on the host.
+const packagesScript = `command -v dpkg-query >/dev/null && echo "dpkg $(dpkg-query -f '.\n' -W | wc -l)"; command -v rpm >/dev/null && echo "rpm $(rpm -qa | wc -l)"; command -v apk >/dev/null && echo "apk $(apk info | wc -l)"; true`
+
+// portsCommand lists the listening sockets, with netstat on hosts without ss.
+const portsCommand = `ss -tuln 2>/dev/null || netstat -tuln 2>/dev/null; true`
+
+// factsCommand is a single SSH command that fetches the inventory facts.
+const factsCommand = `echo "===KERNEL===" && uname -r && echo "===CPUINFO===" && cat /proc/cpuinfo && echo "===VIRT===" && (systemd-detect-virt 2>/dev/null; true) && echo "===PACKAGES===" && (` + packagesScript + `) && echo "===PORTS===" && (` + portsCommand + `) && echo "===END==="`
+
+// updatesCommand prints "<manager> <security> <total>" for the first package
+// manager found on the host. It reads the package lists the host already
+// has (apt's simulated upgrade, dnf/yum's metadata cache) and never
+// refreshes them, so it needs no root and no network.
+const updatesCommand = `if command -v apt-get >/dev/null; then ` +
+	`u=$(LANG=C apt-get -s -o Debug::NoLocking=1 upgrade 2>/dev/null | grep '^Inst '); ` +
+	`echo "apt $(echo "$u" | grep -ci securi) $(echo "$u" | grep -c .)"; ` +
+	`else for m in dnf yum; do command -v $m >/dev/null || continue; ` +
+	`echo "$m $($m -q -C updateinfo list --security 2>/dev/null | awk 'NF==3 {print $3}' | sort -u | grep -c .) $($m -q -C check-update 2>/dev/null | awk 'NF==3 && $1 ~ /\./' | grep -c .)"; ` +
+	`break; done; fi`
 
 // SSHCollector collects metrics from a remote Linux host via SSH.
 type SSHCollector struct {
@@ -40,40 +65,95 @@ type SSHCollector struct {
 	sshConfig  *ssh.ClientConfig
 	timeout    time.Duration
 	cmdTimeout time.Duration
+
+	// Credentials from the host's secret reference (SSHAuthSecret only)
+	secret          *secrets.Credentials
+	secretFetchedAt time.Time
+
+	// Deployed collection script (system.ssh.deployScript), checked again
+	// after each connect
+	scriptPath    string
+	scriptVersion string
+
+	// Userland of the host (userlandGNU or userlandBusybox), detected once
+	// per connection
+	userland string
 }
 
 // NewSSHCollector creates a new SSH collector for the given host.
 func NewSSHCollector(host *models.Host) (*SSHCollector, error) {
+	connTimeout, cmdTimeout := sshTimeouts()
+
+	c := &SSHCollector{
+		host: host,
+		sshConfig: &ssh.ClientConfig{
+			User:            host.SSHUser,
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout:         connTimeout,
+		},
+		timeout:    connTimeout,
+		cmdTimeout: cmdTimeout,
+	}
+
+	if host.SSHAuthType == models.SSHAuthSecret {
+		// Credentials live in an external secret store and are re-fetched
+		// periodically (see ensureConnected)
+		if _, err := c.refreshSecret(); err != nil {
+			return nil, fmt.Errorf("SSH auth config failed for %s: %w", host.ID, err)
+		}
+		return c, nil
+	}
+
 	authMethods, err := buildSSHAuth(host)
 	if err != nil {
 		return nil, fmt.Errorf("SSH auth config failed for %s: %w", host.ID, err)
 	}
+	c.sshConfig.Auth = authMethods
+	return c, nil
+}
+
+// refreshSecret fetches the host's credentials from its secret reference
+// and installs them when they changed. Callers other than the constructor
+// must hold c.mu.
+func (c *SSHCollector) refreshSecret() (bool, error) {
+	c.secretFetchedAt = time.Now()
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+	creds, err := secrets.Fetch(ctx, c.host.SSHSecretRef)
+	if err != nil {
+		return false, err
+	}
+	if creds.Equal(c.secret) {
+		return false, nil
+	}
+
+	authMethods, err := creds.AuthMethods()
+	if err != nil {
+		return false, err
+	}
+	c.sshConfig.Auth = authMethods
+	c.sshConfig.User = c.host.SSHUser
+	if creds.Username != "" {
+		c.sshConfig.User = creds.Username
+	}
+	c.secret = creds
+	return true, nil
+}
 
-	cfg := config.Get()
-	connTimeout := 10 * time.Second
-	cmdTimeout := 5 * time.Second
-	if cfg != nil {
+// sshTimeouts returns the configured connection and command timeouts
+func sshTimeouts() (conn, cmd time.Duration) {
+	conn = 10 * time.Second
+	cmd = 5 * time.Second
+	if cfg := config.Get(); cfg != nil {
 		if cfg.System.SSH.ConnectionTimeout > 0 {
-			connTimeout = time.Duration(cfg.System.SSH.ConnectionTimeout) * time.Second
+			conn = time.Duration(cfg.System.SSH.ConnectionTimeout) * time.Second
 		}
 		if cfg.System.SSH.CommandTimeout > 0 {
-			cmdTimeout = time.Duration(cfg.System.SSH.CommandTimeout) * time.Second
+			cmd = time.Duration(cfg.System.SSH.CommandTimeout) * time.Second
 		}
 	}
-
-	sshConfig := &ssh.ClientConfig{
-		User:            host.SSHUser,
-		Auth:            authMethods,
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
-		Timeout:         connTimeout,
-	}
-
-	return &SSHCollector{
-		host:       host,
-		sshConfig:  sshConfig,
-		timeout:    connTimeout,
-		cmdTimeout: cmdTimeout,
-	}, nil
+	return conn, cmd
 }
 
 // HostID returns the host identifier.
@@ -94,23 +174,42 @@ func (c *SSHCollector) Close() error {
 	return nil
 }
 
-// Collect gathers a single snapshot of system metrics via SSH.
+// PartialCollectionError reports the subsystems whose part of the combined
+// command output was missing or unparsable. Their values would read as
+// zeros, so the snapshot is dropped.
+type PartialCollectionError struct {
+	HostID   string
+	Failures []string // "<subsystem>: <reason>"
+}
+
+func (e *PartialCollectionError) Error() string {
+	return fmt.Sprintf("partial collection failure for %s: %s", e.HostID, strings.Join(e.Failures, "; "))
+}
+
+// Collect gathers a single snapshot of system metrics via SSH. It returns a
+// *PartialCollectionError when some subsystems could not be read.
 func (c *SSHCollector) Collect() (*models.SystemMetric, error) {
-	output, err := c.runCommand(combinedCommand)
+	output, err := c.run(CommandMetrics)
 	if err != nil {
 		return nil, fmt.Errorf("collect failed for %s: %w", c.host.ID, err)
 	}
 
 	now := time.Now()
 	sections := parseSections(output)
+	var failures []string
+	fail := func(subsystem string, err error) {
+		failures = append(failures, subsystem+": "+err.Error())
+	}
+	if !strings.Contains(output, "===END===") {
+		fail("output", fmt.Errorf("truncated"))
+	}
 
 	// CPU (delta-based)
+	var cpuUsage float64
 	cpuRaw, err := parser.ParseCPU(sections["STAT"])
 	if err != nil {
-		return nil, fmt.Errorf("CPU parse failed: %w", err)
-	}
-	var cpuUsage float64
-	if c.prevCPU != nil {
+		fail("cpu", err)
+	} else if c.prevCPU != nil {
 		cpuUsage = parser.CalculateCPUUsage(c.prevCPU, cpuRaw)
 	}
 	c.prevCPU = cpuRaw
@@ -118,36 +217,48 @@ func (c *SSHCollector) Collect() (*models.SystemMetric, error) {
 	// Memory
 	memInfo, err := parser.ParseMemory(sections["MEMINFO"])
 	if err != nil {
-		return nil, fmt.Errorf("memory parse failed: %w", err)
+		fail("memory", err)
 	}
 
 	// Disk usage
-	diskUsage, err := parser.ParseDiskUsage(sections["DF"])
+	diskUsage, err := parseDiskUsage(sections)
 	if err != nil {
-		log.Printf("Disk usage parse failed for %s: %v", c.host.ID, err)
-		diskUsage = &parser.DiskUsageInfo{}
+		fail("disk", err)
 	}
 
 	// Disk I/O (delta-based)
-	diskIORaw, _ := parser.ParseDiskIO(sections["DISKSTATS"])
+	var diskIORaw *parser.DiskIORaw
 	var diskReadMBps, diskWriteMBps float64
-	if c.prevDiskIO != nil && !c.prevTime.IsZero() {
-		elapsed := now.Sub(c.prevTime).Seconds()
-		diskReadMBps, diskWriteMBps = parser.CalculateDiskIO(c.prevDiskIO, diskIORaw, elapsed)
+	if err := requireSection(sections, "DISKSTATS"); err != nil {
+		fail("disk_io", err)
+	} else {
+		diskIORaw, _ = parser.ParseDiskIO(sections["DISKSTATS"])
+		if c.prevDiskIO != nil && !c.prevTime.IsZero() {
+			elapsed := now.Sub(c.prevTime).Seconds()
+			diskReadMBps, diskWriteMBps = parser.CalculateDiskIO(c.prevDiskIO, diskIORaw, elapsed)
+		}
 	}
 	c.prevDiskIO = diskIORaw
 
 	// Network (delta-based)
-	netRaw, _ := parser.ParseNetwork(sections["NETDEV"])
+	var netRaw *parser.NetworkRaw
 	var netInMBps, netOutMBps float64
-	if c.prevNetwork != nil && !c.prevTime.IsZero() {
-		elapsed := now.Sub(c.prevTime).Seconds()
-		netInMBps, netOutMBps = parser.CalculateNetworkIO(c.prevNetwork, netRaw, elapsed)
+	if err := requireSection(sections, "NETDEV"); err != nil {
+		fail("network", err)
+	} else {
+		netRaw, _ = parser.ParseNetwork(sections["NETDEV"])
+		if c.prevNetwork != nil && !c.prevTime.IsZero() {
+			elapsed := now.Sub(c.prevTime).Seconds()
+			netInMBps, netOutMBps = parser.CalculateNetworkIO(c.prevNetwork, netRaw, elapsed)
+		}
 	}
 	c.prevNetwork = netRaw
 
 	c.prevTime = now
 
+	if len(failures) > 0 {
+		return nil, &PartialCollectionError{HostID: c.host.ID, Failures: failures}
+	}
 	return &models.SystemMetric{
 		HostID:    c.host.ID,
 		CPUUsage:  cpuUsage,
@@ -167,7 +278,7 @@ func (c *SSHCollector) Collect() (*models.SystemMetric, error) {
 
 // GetSystemInfo returns host information with the current resource snapshot.
 func (c *SSHCollector) GetSystemInfo() (*models.SystemInfo, error) {
-	output, err := c.runCommand(combinedCommand)
+	output, err := c.run(CommandMetrics)
 	if err != nil {
 		return nil, err
 	}
@@ -175,7 +286,7 @@ func (c *SSHCollector) GetSystemInfo() (*models.SystemInfo, error) {
 	sections := parseSections(output)
 
 	memInfo, _ := parser.ParseMemory(sections["MEMINFO"])
-	diskUsage, _ := parser.ParseDiskUsage(sections["DF"])
+	diskUsage, _ := parseDiskUsage(sections)
 	uptime := parser.ParseUptime(sections["UPTIME"])
 	hostname := parser.ParseHostname(sections["HOSTNAME"])
 
@@ -207,22 +318,84 @@ func (c *SSHCollector) GetSystemInfo() (*models.SystemInfo, error) {
 	return info, nil
 }
 
-// GetProcesses returns the top N processes from the remote host.
+// GetFacts returns the inventory facts of the remote host.
+func (c *SSHCollector) GetFacts() (*models.HostFacts, error) {
+	output, err := c.run(CommandFacts)
+	if err != nil {
+		return nil, fmt.Errorf("facts failed for %s: %w", c.host.ID, err)
+	}
+
+	sections := parseSections(output)
+	facts := &models.HostFacts{
+		HostID:         c.host.ID,
+		Kernel:         strings.TrimSpace(sections["KERNEL"]),
+		Virtualization: parser.ParseVirtualization(sections["VIRT"]),
+		Packages:       parser.ParsePackageCounts(sections["PACKAGES"]),
+		ListeningPorts: listeningPorts(parser.ParseListeningPorts(sections["PORTS"])),
+		CollectedAt:    time.Now(),
+	}
+	facts.CPUModel, facts.CPUCores = parser.ParseCPUInfo(sections["CPUINFO"])
+	return facts, nil
+}
+
+// GetListeningPorts returns the listening ports of the remote host.
+func (c *SSHCollector) GetListeningPorts() ([]models.ListeningPort, error) {
+	output, err := c.run(CommandPorts)
+	if err != nil {
+		return nil, fmt.Errorf("ports failed for %s: %w", c.host.ID, err)
+	}
+	return listeningPorts(parser.ParseListeningPorts(output)), nil
+}
+
+// CheckUpdates counts the updates pending on the remote host.
+func (c *SSHCollector) CheckUpdates() (*models.SecurityUpdates, error) {
+	output, err := c.run(CommandUpdates)
+	if err != nil {
+		return nil, fmt.Errorf("updates check failed for %s: %w", c.host.ID, err)
+	}
+
+	manager, security, total, ok := parser.ParseUpdateCounts(output)
+	if !ok {
+		return nil, fmt.Errorf("updates check failed for %s: no apt, dnf or yum found", c.host.ID)
+	}
+	return &models.SecurityUpdates{
+		HostID:    c.host.ID,
+		Manager:   manager,
+		Security:  security,
+		Total:     total,
+		CheckedAt: time.Now(),
+	}, nil
+}
+
+// GetProcesses returns the top N processes from the remote host, at most
+// maxSSHProcesses.
 func (c *SSHCollector) GetProcesses(limit int, sortBy string) ([]models.ProcessInfo, error) {
 	if limit <= 0 {
 		limit = 10
 	}
-	sort := "-%cpu"
-	if sortBy == "memory" {
-		sort = "-%mem"
-	}
-	cmd := fmt.Sprintf("ps aux --sort=%s | head -%d", sort, limit+1)
-	output, err := c.runCommand(cmd)
+	userland, err := c.detectUserland()
 	if err != nil {
 		return nil, fmt.Errorf("process list failed: %w", err)
 	}
 
-	parsed := parser.ParseProcesses(output, limit)
+	var parsed []parser.ProcessInfo
+	if userland == userlandBusybox {
+		output, err := c.run(CommandProcessesBusybox)
+		if err != nil {
+			return nil, fmt.Errorf("process list failed: %w", err)
+		}
+		parsed = parser.ParseTopProcesses(output, min(limit, maxSSHProcesses), sortBy)
+	} else {
+		cmd := CommandProcessesCPU
+		if sortBy == "memory" {
+			cmd = CommandProcessesMem
+		}
+		output, err := c.run(cmd)
+		if err != nil {
+			return nil, fmt.Errorf("process list failed: %w", err)
+		}
+		parsed = parser.ParseProcesses(output, limit)
+	}
 	var result []models.ProcessInfo
 	for _, p := range parsed {
 		result = append(result, models.ProcessInfo{
@@ -237,11 +410,57 @@ func (c *SSHCollector) GetProcesses(limit int, sortBy string) ([]models.ProcessI
 	return result, nil
 }
 
+// Userlands told apart by CommandUserland
+const (
+	userlandGNU     = "gnu"
+	userlandBusybox = "busybox"
+)
+
+// detectUserland returns the userland of the host, detecting it on first use
+// after each connect.
+func (c *SSHCollector) detectUserland() (string, error) {
+	c.mu.Lock()
+	userland := c.userland
+	c.mu.Unlock()
+	if userland != "" {
+		return userland, nil
+	}
+
+	output, err := c.run(CommandUserland)
+	if err != nil {
+		return "", err
+	}
+	userland = userlandGNU
+	if strings.TrimSpace(output) == userlandBusybox {
+		userland = userlandBusybox
+		log.Printf("Busybox userland detected on %s, using busybox commands", c.host.ID)
+	}
+
+	c.mu.Lock()
+	c.userland = userland
+	c.mu.Unlock()
+	return userland, nil
+}
+
 // ensureConnected maintains a persistent SSH connection with keep-alive.
 func (c *SSHCollector) ensureConnected() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
+	// Pick up rotated credentials; a change forces a reconnect with them
+	if c.host.SSHAuthType == models.SSHAuthSecret && time.Since(c.secretFetchedAt) >= secrets.RefreshInterval() {
+		changed, err := c.refreshSecret()
+		if err != nil {
+			log.Printf("Failed to refresh SSH secret for %s, keeping current credentials: %v", c.host.ID, err)
+		} else if changed {
+			log.Printf("SSH credentials for %s rotated, reconnecting", c.host.ID)
+			if c.client != nil {
+				c.client.Close()
+				c.client = nil
+			}
+		}
+	}
+
 	if c.client != nil {
 		// Test if connection is still alive
 		_, _, err := c.client.SendRequest("keepalive@openssh.com", true, nil)
@@ -259,19 +478,57 @@ func (c *SSHCollector) ensureConnected() error {
 	}
 	addr := fmt.Sprintf("%s:%d", c.host.IP, sshPort)
 
+	// Timeouts may have been changed at runtime since the last connect
+	c.timeout, c.cmdTimeout = sshTimeouts()
+	c.sshConfig.Timeout = c.timeout
+
 	client, err := ssh.Dial("tcp", addr, c.sshConfig)
 	if err != nil {
+		if c.secret != nil && strings.Contains(err.Error(), "unable to authenticate") {
+			// The secret may have been rotated since the last fetch
+			c.secretFetchedAt = time.Time{}
+		}
 		return fmt.Errorf("SSH dial failed (%s): %w", addr, err)
 	}
 
 	c.client = client
+	c.scriptPath = "" // the host may have been reinstalled since
+	c.userland = ""
 	log.Printf("SSH connected to %s (%s)", c.host.ID, addr)
 	return nil
 }
 
+// run executes the named command of sshCommands on the remote host, through
+// the deployed collection script when system.ssh.deployScript is on.
+func (c *SSHCollector) run(name string) (string, error) {
+	if !deployScriptEnabled() {
+		return c.runCommand(SSHCommandLine(name))
+	}
+
+	path, err := c.deployedScript()
+	if err != nil {
+		return "", fmt.Errorf("collection script deploy failed: %w", err)
+	}
+	output, err := c.runCommand(shellQuote(path) + " " + name)
+	if err != nil {
+		c.forgetScript()
+	}
+	return output, err
+}
+
 // runCommand executes a command on the remote host via SSH.
 // It reuses the persistent connection and creates a new session per call.
+// In restricted mode, commands outside sshCommands are refused.
 func (c *SSHCollector) runCommand(cmd string) (string, error) {
+	return c.runCommandInput(cmd, nil)
+}
+
+// runCommandInput is runCommand with stdin of the command read from input.
+func (c *SSHCollector) runCommandInput(cmd string, input io.Reader) (string, error) {
+	if restrictedSSH() && !allowedCommandLine(cmd) {
+		log.Printf("Refused SSH command outside the allowlist for %s: %q", c.host.ID, cmd)
+		return "", fmt.Errorf("SSH command not allowed in restricted mode")
+	}
 	if err := c.ensureConnected(); err != nil {
 		return "", err
 	}
@@ -291,12 +548,24 @@ func (c *SSHCollector) runCommand(cmd string) (string, error) {
 	}
 	defer session.Close()
 
-	output, err := session.CombinedOutput(cmd)
-	if err != nil {
+	// Keep stderr out of the output, where it would end up in a section
+	var stdout, stderr bytes.Buffer
+	session.Stdin = input
+	session.Stdout = &stdout
+	session.Stderr = &stderr
+	if err := session.Run(cmd); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("SSH command failed: %w: %s", err, lastLine(msg))
+		}
 		return "", fmt.Errorf("SSH command failed: %w", err)
 	}
 
-	return string(output), nil
+	return stdout.String(), nil
+}
+
+// lastLine returns the last line of s.
+func lastLine(s string) string {
+	return s[strings.LastIndex(s, "\n")+1:]
 }
 
 // parseSections splits the combined command output into named sections.
@@ -327,6 +596,28 @@ func parseSections(output string) map[string]string {
 	return sections
 }
 
+// parseDiskUsage parses the disk usage section of the combined command
+// output, in bytes or, on busybox hosts, in 1K blocks.
+func parseDiskUsage(sections map[string]string) (*parser.DiskUsageInfo, error) {
+	if dfk, ok := sections["DFK"]; ok {
+		return parser.ParseDiskUsageBlocks(dfk, 1024)
+	}
+	return parser.ParseDiskUsage(sections["DF"])
+}
+
+// requireSection returns an error when a section of the combined command
+// output is missing or empty, i.e. its command failed.
+func requireSection(sections map[string]string, name string) error {
+	section, ok := sections[name]
+	if !ok {
+		return fmt.Errorf("no %s section", name)
+	}
+	if strings.TrimSpace(section) == "" {
+		return fmt.Errorf("empty %s section", name)
+	}
+	return nil
+}
+
 // buildSSHAuth creates SSH auth methods from a host model.
 func buildSSHAuth(host *models.Host) ([]ssh.AuthMethod, error) {
 	switch host.SSHAuthType {
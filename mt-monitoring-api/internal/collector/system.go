@@ -1,6 +1,11 @@
 package collector
 
-import "fmt"
+import (
+	"fmt"
+
+	"github.com/mt-monitoring/api/internal/collector/parser"
+	"github.com/mt-monitoring/api/internal/models"
+)
 
 // Common helper functions shared by all collector implementations.
 
@@ -45,3 +50,11 @@ func normalizeStatus(s string) string {
 		return "running"
 	}
 }
+
+func listeningPorts(parsed []parser.ListeningPort) []models.ListeningPort {
+	ports := make([]models.ListeningPort, 0, len(parsed))
+	for _, p := range parsed {
+		ports = append(ports, models.ListeningPort{Protocol: p.Protocol, Address: p.Address, Port: p.Port})
+	}
+	return ports
+}
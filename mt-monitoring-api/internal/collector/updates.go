@@ -0,0 +1,78 @@
+package collector
+
+import (
+	"context"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+)
+
+// updatesRetryDelay is how long a failed updates check waits before it is
+// tried again
+const updatesRetryDelay = time.Hour
+
+// updatesInterval returns how often the pending updates of a host are
+// checked, or 0 when the check is disabled.
+func updatesInterval() time.Duration {
+	cfg := config.Get()
+	if cfg == nil || !cfg.System.SecurityUpdates.Enabled || cfg.System.SecurityUpdates.Interval < 1 {
+		return 0
+	}
+	return time.Duration(cfg.System.SecurityUpdates.Interval) * time.Hour
+}
+
+// updatesDue reports whether the pending updates of a host should be
+// checked. Only SSH-collected hosts are checked; after a restart the stored
+// check counts, like for the facts.
+func (m *CollectorManager) updatesDue(hostID string, mc *managedCollector) bool {
+	interval := updatesInterval()
+	if interval == 0 {
+		return false
+	}
+	if _, ok := mc.collector.(*SSHCollector); !ok {
+		return false
+	}
+
+	m.mu.RLock()
+	updatesAt := mc.updatesAt
+	m.mu.RUnlock()
+
+	if updatesAt.IsZero() {
+		stored, err := m.updates.Get(context.Background(), hostID)
+		if err != nil {
+			return false
+		}
+		if stored != nil {
+			updatesAt = stored.CheckedAt
+			m.mu.Lock()
+			mc.updatesAt = updatesAt
+			m.mu.Unlock()
+		}
+	}
+	return time.Since(updatesAt) >= interval
+}
+
+// checkUpdates counts, stores and publishes the pending updates of an
+// SSH-collected host. A failure is tried again after updatesRetryDelay.
+func (m *CollectorManager) checkUpdates(hostID, hostName string, mc *managedCollector) error {
+	updates, err := mc.collector.(*SSHCollector).CheckUpdates()
+	if err == nil {
+		err = m.updates.Save(context.Background(), updates)
+	}
+
+	m.mu.Lock()
+	if err != nil {
+		interval := updatesInterval()
+		mc.updatesAt = time.Now().Add(min(updatesRetryDelay, interval) - interval)
+	} else {
+		mc.updatesAt = updates.CheckedAt
+	}
+	m.mu.Unlock()
+
+	if err != nil {
+		return err
+	}
+	events.UpdatesChecked.Publish(events.Updates{HostID: hostID, HostName: hostName, Updates: updates})
+	return nil
+}
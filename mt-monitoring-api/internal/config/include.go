@@ -0,0 +1,67 @@
+package config
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+
+	"github.com/spf13/viper"
+)
+
+// mergeIncludes loads the files matched by the top-level "includes" globs
+// into v. Relative patterns resolve against the main config file's
+// directory, matches are applied in lexical order, and any supported format
+// (JSON, YAML, ...) may be mixed. Services from every file are appended;
+// other keys are merged with later files winning. Included files cannot
+// include further files.
+func mergeIncludes(v *viper.Viper) error {
+	patterns := v.GetStringSlice("includes")
+	if len(patterns) == 0 {
+		return nil
+	}
+
+	baseDir := "."
+	if used := v.ConfigFileUsed(); used != "" {
+		baseDir = filepath.Dir(used)
+	}
+
+	services := toSlice(v.Get("services"))
+	for _, pattern := range patterns {
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(baseDir, pattern)
+		}
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			return fmt.Errorf("invalid include pattern %q: %w", pattern, err)
+		}
+		sort.Strings(files)
+
+		for _, file := range files {
+			sub := viper.New()
+			sub.SetConfigFile(file)
+			if err := sub.ReadInConfig(); err != nil {
+				return fmt.Errorf("error reading include %s: %w", file, err)
+			}
+
+			settings := sub.AllSettings()
+			if _, nested := settings["includes"]; nested {
+				return fmt.Errorf("include %s: nested includes are not supported", file)
+			}
+			services = append(services, toSlice(settings["services"])...)
+			delete(settings, "services")
+
+			if err := v.MergeConfigMap(settings); err != nil {
+				return fmt.Errorf("error merging include %s: %w", file, err)
+			}
+		}
+	}
+
+	v.Set("services", services)
+	return nil
+}
+
+// toSlice returns a config list value, or nil when it isn't a list
+func toSlice(value interface{}) []interface{} {
+	list, _ := value.([]interface{})
+	return list
+}
@@ -0,0 +1,181 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Runtime setting value types
+const (
+	SettingInt       = "int"
+	SettingRetention = "retention"
+)
+
+// RuntimeSetting is a setting that can be changed while the server runs.
+// Changed values are stored in the database and override the config file
+// and environment, which only provide the defaults.
+type RuntimeSetting struct {
+	Key  string `json:"key"`
+	Type string `json:"type"`
+	Min  int    `json:"min,omitempty"`
+
+	intField    func(*Config) *int
+	stringField func(*Config) *string
+}
+
+var runtimeSettings = []RuntimeSetting{
+	intSetting("alerts.consecutiveFailures", 1, func(c *Config) *int { return &c.Alerts.ConsecutiveFailures }),
+	retentionSetting("retention.metrics", func(c *Config) *string { return &c.Retention.Metrics }),
+	retentionSetting("retention.logs", func(c *Config) *string { return &c.Retention.Logs }),
+	retentionSetting("retention.systemMetrics", func(c *Config) *string { return &c.Retention.SystemMetrics }),
+	retentionSetting("retention.rollups", func(c *Config) *string { return &c.Retention.Rollups }),
+	retentionSetting("retention.notificationHistory", func(c *Config) *string { return &c.Retention.NotificationHistory }),
+	retentionSetting("retention.incidents", func(c *Config) *string { return &c.Retention.Incidents }),
+	intSetting("system.collectInterval", 1, func(c *Config) *int { return &c.System.CollectInterval }),
+	intSetting("system.storeInterval", 1, func(c *Config) *int { return &c.System.StoreInterval }),
+	intSetting("system.ssh.connectionTimeout", 1, func(c *Config) *int { return &c.System.SSH.ConnectionTimeout }),
+	intSetting("system.ssh.commandTimeout", 1, func(c *Config) *int { return &c.System.SSH.CommandTimeout }),
+}
+
+func intSetting(key string, min int, field func(*Config) *int) RuntimeSetting {
+	return RuntimeSetting{Key: key, Type: SettingInt, Min: min, intField: field}
+}
+
+func retentionSetting(key string, field func(*Config) *string) RuntimeSetting {
+	return RuntimeSetting{Key: key, Type: SettingRetention, stringField: field}
+}
+
+// Runtime overrides applied on top of the file configuration
+var (
+	runtimeMu sync.Mutex
+	baseCfg   *Config
+	overrides = map[string]string{}
+)
+
+// RuntimeSettings returns the settings that can be changed at runtime
+func RuntimeSettings() []RuntimeSetting {
+	return runtimeSettings
+}
+
+// LookupSetting returns the runtime setting with the given key
+func LookupSetting(key string) (RuntimeSetting, bool) {
+	for _, s := range runtimeSettings {
+		if s.Key == key {
+			return s, true
+		}
+	}
+	return RuntimeSetting{}, false
+}
+
+// Validate checks a string value for the setting
+func (s RuntimeSetting) Validate(value string) error {
+	switch s.Type {
+	case SettingInt:
+		n, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("%s must be an integer", s.Key)
+		}
+		if n < s.Min {
+			return fmt.Errorf("%s must be at least %d", s.Key, s.Min)
+		}
+	case SettingRetention:
+		if !IsValidRetention(value) {
+			return fmt.Errorf(`%s must look like "7d", "12h" or "30m"`, s.Key)
+		}
+	}
+	return nil
+}
+
+// Value returns the setting's value in c, typed as int or string
+func (s RuntimeSetting) Value(c *Config) interface{} {
+	if s.intField != nil {
+		return *s.intField(c)
+	}
+	return *s.stringField(c)
+}
+
+// set stores an already validated value in c
+func (s RuntimeSetting) set(c *Config, value string) {
+	if s.intField != nil {
+		n, _ := strconv.Atoi(strings.TrimSpace(value))
+		*s.intField(c) = n
+		return
+	}
+	*s.stringField(c) = strings.TrimSpace(strings.ToLower(value))
+}
+
+// Defaults returns the configuration from the file and environment, before
+// runtime overrides are applied
+func Defaults() *Config {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	return baseCfg
+}
+
+// Overrides returns a copy of the runtime overrides in effect
+func Overrides() map[string]string {
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+
+	out := make(map[string]string, len(overrides))
+	for k, v := range overrides {
+		out[k] = v
+	}
+	return out
+}
+
+// SetOverrides validates values and applies them on top of the file
+// configuration, replacing the running configuration. A key mapped to an
+// empty string removes its override. Nothing changes when any value is
+// invalid. Overrides survive Reload.
+func SetOverrides(values map[string]string) (*Config, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		s, ok := LookupSetting(key)
+		if !ok {
+			return nil, fmt.Errorf("unknown setting %q", key)
+		}
+		if values[key] == "" {
+			continue
+		}
+		if err := s.Validate(values[key]); err != nil {
+			return nil, err
+		}
+	}
+
+	runtimeMu.Lock()
+	defer runtimeMu.Unlock()
+	if baseCfg == nil {
+		return nil, fmt.Errorf("config not initialized")
+	}
+
+	for _, key := range keys {
+		if values[key] == "" {
+			delete(overrides, key)
+		} else {
+			overrides[key] = values[key]
+		}
+	}
+	cfg = withOverrides(baseCfg)
+	return cfg, nil
+}
+
+// withOverrides returns a copy of base with the runtime overrides applied.
+// Callers must hold runtimeMu.
+func withOverrides(base *Config) *Config {
+	effective := *base
+	for key, value := range overrides {
+		if s, ok := LookupSetting(key); ok {
+			s.set(&effective, value)
+		}
+	}
+	return &effective
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// secretRef matches ${env:NAME} and ${file:/path/to/secret}
+var secretRef = regexp.MustCompile(`\$\{(env|file):([^}]+)\}`)
+
+// resolveSecrets replaces secret references in every string value of c, so
+// passwords, tokens and keys don't have to be stored verbatim in the config
+// file. A reference to an unset variable or unreadable file is an error.
+func resolveSecrets(c *Config) error {
+	return resolveValue(reflect.ValueOf(c).Elem(), "")
+}
+
+func resolveValue(v reflect.Value, path string) error {
+	switch v.Kind() {
+	case reflect.String:
+		resolved, err := resolveString(v.String())
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		v.SetString(resolved)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			if !t.Field(i).IsExported() {
+				continue
+			}
+			name := t.Field(i).Tag.Get("mapstructure")
+			if name == "" {
+				name = t.Field(i).Name
+			}
+			if path != "" {
+				name = path + "." + name
+			}
+			if err := resolveValue(v.Field(i), name); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := resolveValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			return nil
+		}
+		iter := v.MapRange()
+		for iter.Next() {
+			resolved, err := resolveString(iter.Value().String())
+			if err != nil {
+				return fmt.Errorf("%s.%v: %w", path, iter.Key(), err)
+			}
+			v.SetMapIndex(iter.Key(), reflect.ValueOf(resolved).Convert(v.Type().Elem()))
+		}
+
+	case reflect.Ptr:
+		if !v.IsNil() {
+			return resolveValue(v.Elem(), path)
+		}
+	}
+	return nil
+}
+
+// resolveString expands the secret references in s. File contents have
+// their trailing newline removed.
+func resolveString(s string) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var firstErr error
+	resolved := secretRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRef.FindStringSubmatch(ref)
+		kind, target := m[1], strings.TrimSpace(m[2])
+
+		switch kind {
+		case "env":
+			value, ok := os.LookupEnv(target)
+			if !ok && firstErr == nil {
+				firstErr = fmt.Errorf("environment variable %s is not set", target)
+			}
+			return value
+		default:
+			data, err := os.ReadFile(target)
+			if err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to read secret file: %w", err)
+			}
+			return strings.TrimRight(string(data), "\r\n")
+		}
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+	return resolved, nil
+}
@@ -0,0 +1,590 @@
+package config
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+// ValidationError describes one invalid setting
+type ValidationError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// cronParser matches the scheduler's cron format (with seconds)
+var cronParser = cron.NewParser(cron.Second | cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow | cron.Descriptor)
+
+// Validate checks a configuration for mistakes that would otherwise only
+// surface at runtime: service definitions, schedules, alert channels,
+// retention strings and export sinks. It returns an empty slice when the
+// configuration is valid.
+func Validate(c *Config) []ValidationError {
+	v := &validator{errors: []ValidationError{}}
+
+	if c.Server.Port < 1 || c.Server.Port > 65535 {
+		v.add("server.port", "must be between 1 and 65535")
+	}
+	if tz := c.Server.Timezone; tz != "" {
+		if _, err := time.LoadLocation(tz); err != nil {
+			v.add("server.timezone", "unknown timezone, use an IANA name such as Asia/Seoul")
+		}
+	}
+	switch c.Server.Compression.Level {
+	case "", "speed", "default", "best":
+	default:
+		v.add("server.compression.level", "must be speed, default or best")
+	}
+	if c.Server.MaxPageSize < 0 {
+		v.add("server.maxPageSize", "must not be negative")
+	}
+	if key := c.Security.EncryptionKey; key != "" {
+		if b, err := hex.DecodeString(key); err != nil || len(b) != 32 {
+			v.add("security.encryptionKey", "must be 64 hex characters (32 bytes)")
+		}
+	}
+	if token := c.Security.AdminToken; token != "" && len(token) < 16 {
+		v.add("security.adminToken", "must be at least 16 characters")
+	}
+	if c.Security.RequireProjectToken && c.Security.AdminToken == "" {
+		v.add("security.requireProjectToken", "needs security.adminToken, or no one could manage projects")
+	}
+
+	v.services(c.Services)
+	v.serviceDefaults(c.ServiceDefaults)
+	v.hosts(c.Hosts)
+	v.alertRules(c.AlertRules)
+
+	v.cron("database.checkpointSchedule", c.Database.CheckpointSchedule)
+	v.cron("database.vacuumSchedule", c.Database.VacuumSchedule)
+	v.cron("backup.schedule", c.Backup.Schedule)
+	v.cron("archive.schedule", c.Archive.Schedule)
+	v.cron("alerts.deliveryReport.schedule", c.Alerts.DeliveryReport.Schedule)
+
+	v.retention("retention.metrics", c.Retention.Metrics, true)
+	v.retention("retention.logs", c.Retention.Logs, true)
+	v.retention("retention.systemMetrics", c.Retention.SystemMetrics, true)
+	v.retention("retention.rollups", c.Retention.Rollups, true)
+	for level, retention := range c.Retention.LogLevels {
+		if !models.LogLevel(level).IsValid() {
+			v.add("retention.logLevels."+level, "unknown log level")
+		}
+		v.retention("retention.logLevels."+level, retention, true)
+	}
+	v.retention("retention.sampleAfter", c.Retention.SampleAfter, false)
+	if c.Retention.MetricSampling < 0 {
+		v.add("retention.metricSampling", "must not be negative")
+	}
+	v.retention("retention.notificationHistory", c.Retention.NotificationHistory, false)
+	v.retention("retention.incidents", c.Retention.Incidents, false)
+	v.retention("archive.incidentsAfter", c.Archive.IncidentsAfter, false)
+	v.retention("archive.notificationsAfter", c.Archive.NotificationsAfter, false)
+	if c.Archive.Schedule != "" {
+		v.beforeArchive("retention.notificationHistory", c.Retention.NotificationHistory, c.Archive.NotificationsAfter)
+		v.beforeArchive("retention.incidents", c.Retention.Incidents, c.Archive.IncidentsAfter)
+	}
+
+	if c.Alerts.ConsecutiveFailures < 1 {
+		v.add("alerts.consecutiveFailures", "must be at least 1")
+	}
+	if slack := c.Alerts.Channels.Slack; slack.Enabled {
+		v.url("alerts.channels.slack.webhookUrl", slack.WebhookURL, "https")
+	}
+	if email := c.Alerts.Channels.Email; email.Enabled {
+		if email.SMTP.Host == "" {
+			v.add("alerts.channels.email.smtp.host", "is required")
+		}
+		if email.SMTP.Port < 1 || email.SMTP.Port > 65535 {
+			v.add("alerts.channels.email.smtp.port", "must be between 1 and 65535")
+		}
+		if len(email.Recipients) == 0 {
+			v.add("alerts.channels.email.recipients", "at least one recipient is required")
+		}
+		for i, r := range email.Recipients {
+			if _, err := mail.ParseAddress(r); err != nil {
+				v.add(fmt.Sprintf("alerts.channels.email.recipients[%d]", i), "invalid email address")
+			}
+		}
+	}
+	if from := c.Alerts.Channels.Email.From; from != "" {
+		if _, err := mail.ParseAddress(from); err != nil {
+			v.add("alerts.channels.email.from", "invalid email address")
+		}
+	}
+
+	if am := c.Alerts.Alertmanager; am.Enabled && len(am.Token) < 16 {
+		v.add("alerts.alertmanager.token", "must be at least 16 characters")
+	}
+	if g := c.Alerts.IncidentGrouping; g.Enabled && g.Window < 1 {
+		v.add("alerts.incidentGrouping.window", "must be at least 1 second")
+	}
+	if p := c.System.Ping; p.Enabled {
+		if p.Interval < 5 {
+			v.add("system.ping.interval", "must be at least 5 seconds")
+		}
+		if p.Count < 1 || p.Count > 20 {
+			v.add("system.ping.count", "must be between 1 and 20")
+		}
+		if p.Timeout < 100 {
+			v.add("system.ping.timeout", "must be at least 100 ms")
+		}
+	}
+	if u := c.System.SecurityUpdates; u.Enabled && u.Interval < 1 {
+		v.add("system.securityUpdates.interval", "must be at least 1 hour")
+	}
+	if p := c.System.Ports; p.Enabled && p.Interval < 10 {
+		v.add("system.ports.interval", "must be at least 10 seconds")
+	}
+	if p := c.System.ProcessHistory; p.Enabled && (p.TopK < 1 || p.TopK > 50) {
+		v.add("system.processHistory.topK", "must be between 1 and 50")
+	}
+	if s := c.System.SSH.RemoteScript; s != "" && !validScriptPath(s) {
+		v.add("system.ssh.remoteScript", "must be an absolute path of letters, digits, '.', '_', '-' and '/'")
+	}
+	if ssh := c.System.SSH; ssh.DeployScript {
+		if ssh.RemoteScript != "" {
+			v.add("system.ssh.deployScript", "cannot be combined with remoteScript")
+		}
+		if ssh.Restricted {
+			v.add("system.ssh.deployScript", "cannot be combined with restricted mode, install the script with remoteScript instead")
+		}
+	}
+	if c.SelfMonitor.Enabled && c.SelfMonitor.Interval < 1 {
+		v.add("selfMonitor.interval", "must be at least 1 second")
+	}
+	if t := c.Diagnostics.Traceroute; t.Enabled {
+		if t.Tool != "traceroute" && t.Tool != "mtr" {
+			v.add("diagnostics.traceroute.tool", `must be "traceroute" or "mtr"`)
+		}
+		if t.MaxHops < 1 || t.MaxHops > 64 {
+			v.add("diagnostics.traceroute.maxHops", "must be between 1 and 64")
+		}
+		if t.Timeout < 1 {
+			v.add("diagnostics.traceroute.timeout", "must be at least 1 second")
+		}
+	}
+	if c.Browser.Enabled && c.Browser.MaxConcurrent < 1 {
+		v.add("browser.maxConcurrent", "must be at least 1")
+	}
+	if c.Cluster.Enabled {
+		if c.Cluster.LeaseTTL < 3 {
+			v.add("cluster.leaseTtl", "must be at least 3 seconds")
+		}
+		if c.Cluster.AdvertiseURL != "" {
+			v.url("cluster.advertiseUrl", c.Cluster.AdvertiseURL, "http", "https")
+		}
+	}
+	if c.Alerts.Dispatch.Workers < 1 {
+		v.add("alerts.dispatch.workers", "must be at least 1")
+	}
+	if c.Alerts.Dispatch.QueueSize < 1 {
+		v.add("alerts.dispatch.queueSize", "must be at least 1")
+	}
+	if r := c.Alerts.Remediation; r.Enabled && r.Timeout < 1 {
+		v.add("alerts.remediation.timeout", "must be at least 1 second")
+	}
+	if r := c.Alerts.DeliveryReport; r.Schedule != "" && r.Window < 1 {
+		v.add("alerts.deliveryReport.window", "must be at least 1 hour")
+	}
+	if c.Alerts.DashboardURL != "" {
+		v.url("alerts.dashboardUrl", c.Alerts.DashboardURL, "http", "https")
+	}
+
+	if c.Export.Prometheus.Enabled {
+		v.url("export.prometheus.url", c.Export.Prometheus.URL, "http", "https")
+	}
+	if c.Export.Influx.Enabled {
+		v.url("export.influx.url", c.Export.Influx.URL, "http", "https")
+	}
+	if c.Export.Timescale.Enabled && c.Export.Timescale.DSN == "" {
+		v.add("export.timescale.dsn", "is required")
+	}
+
+	if c.GitOps.Enabled {
+		if c.GitOps.Mode != "report" && c.GitOps.Mode != "enforce" {
+			v.add("gitops.mode", `must be "report" or "enforce"`)
+		}
+		if c.GitOps.Interval < 1 {
+			v.add("gitops.interval", "must be at least 1 second")
+		}
+		if c.GitOps.Repo.URL != "" && c.GitOps.Repo.Path == "" {
+			v.add("gitops.repo.path", "is required")
+		}
+	}
+
+	if t := c.Telemetry; t.Enabled {
+		if t.Endpoint != "" {
+			v.url("telemetry.endpoint", t.Endpoint, "http", "https")
+		}
+		if t.SampleRatio < 0 || t.SampleRatio > 1 {
+			v.add("telemetry.sampleRatio", "must be between 0 and 1")
+		}
+		if t.MetricInterval < 1 {
+			v.add("telemetry.metricInterval", "must be at least 1 second")
+		}
+	}
+
+	if m := c.MQTT; m.Enabled {
+		v.url("mqtt.broker", m.Broker, "tcp", "mqtt", "ssl", "tls", "mqtts")
+		if m.QoS != 0 && m.QoS != 1 {
+			v.add("mqtt.qos", "must be 0 or 1")
+		}
+		if m.KeepAlive < 0 || m.KeepAlive > 65535 {
+			v.add("mqtt.keepAlive", "must be between 0 and 65535 seconds")
+		}
+		topic := func(field, value string) {
+			if strings.ContainsAny(value, "+#") {
+				v.add(field, "must not contain the wildcards + or #")
+			}
+		}
+		topic("mqtt.topics.status", m.Topics.Status)
+		topic("mqtt.topics.incidents", m.Topics.Incidents)
+		topic("mqtt.topics.alerts", m.Topics.Alerts)
+	}
+
+	if e := c.EventStream; e.Enabled {
+		if !e.NATS.Enabled && !e.Kafka.Enabled {
+			v.add("eventStream", "enable nats or kafka")
+		}
+		if e.NATS.Enabled {
+			v.url("eventStream.nats.url", e.NATS.URL, "nats", "tls")
+			if e.NATS.SubjectPrefix == "" || strings.ContainsAny(e.NATS.SubjectPrefix, " \t*>") {
+				v.add("eventStream.nats.subjectPrefix", "must be a subject without spaces or wildcards")
+			}
+		}
+		if e.Kafka.Enabled {
+			if len(e.Kafka.Brokers) == 0 {
+				v.add("eventStream.kafka.brokers", "is required")
+			}
+			for i, broker := range e.Kafka.Brokers {
+				if _, port, err := net.SplitHostPort(broker); err != nil || port == "" {
+					v.add(fmt.Sprintf("eventStream.kafka.brokers[%d]", i), "must be host:port")
+				}
+			}
+			if !validKafkaTopic(e.Kafka.Topic) {
+				v.add("eventStream.kafka.topic", "must be 1-249 characters of letters, digits, '.', '_' or '-'")
+			}
+		}
+	}
+
+	if c.StatusPage.PublicURL != "" {
+		v.url("statusPage.publicUrl", c.StatusPage.PublicURL, "http", "https")
+	}
+	if c.StatusPage.EmailFrom != "" {
+		if _, err := mail.ParseAddress(c.StatusPage.EmailFrom); err != nil {
+			v.add("statusPage.emailFrom", "invalid email address")
+		}
+	}
+
+	if c.Apdex.Threshold < 0 {
+		v.add("apdex.threshold", "must not be negative")
+	}
+	return v.errors
+}
+
+type validator struct {
+	errors []ValidationError
+}
+
+func (v *validator) add(field, message string) {
+	v.errors = append(v.errors, ValidationError{Field: field, Message: message})
+}
+
+func (v *validator) services(services []ServiceConfig) {
+	seen := make(map[string]bool, len(services))
+	for i, svc := range services {
+		field := fmt.Sprintf("services[%d]", i)
+		if svc.ID != "" {
+			field = fmt.Sprintf("services[%s]", svc.ID)
+		}
+
+		switch {
+		case svc.ID == "":
+			v.add(field+".id", "is required")
+		case seen[svc.ID]:
+			v.add(field+".id", "duplicate service id")
+		}
+		seen[svc.ID] = true
+
+		if svc.Name == "" {
+			v.add(field+".name", "is required")
+		}
+
+		switch models.ServiceType(svc.Type) {
+		case models.ServiceTypeHTTP, "":
+			v.url(field+".url", svc.URL, "http", "https")
+			switch strings.ToUpper(svc.Method) {
+			case "GET", "HEAD", "POST", "PUT", "PATCH", "DELETE", "OPTIONS":
+			default:
+				v.add(field+".method", "unsupported HTTP method")
+			}
+			if svc.ExpectedStatus < 100 || svc.ExpectedStatus > 599 {
+				v.add(field+".expectedStatus", "must be an HTTP status code (100-599)")
+			}
+			if p := models.HTTPProtocol(svc.Protocol); p != "" && !p.IsValid() {
+				v.add(field+".protocol", "must be auto, h1, h2 or h3")
+			} else if p == models.HTTPProtocolH3 && !strings.HasPrefix(strings.ToLower(svc.URL), "https://") {
+				v.add(field+".protocol", "h3 requires an https url")
+			}
+		case models.ServiceTypeTCP:
+			if svc.Host == "" {
+				v.add(field+".host", "is required for tcp services")
+			}
+			if svc.Port < 1 || svc.Port > 65535 {
+				v.add(field+".port", "must be between 1 and 65535")
+			}
+		case models.ServiceTypeBrowser:
+			v.url(field+".url", svc.URL, "http", "https")
+		case models.ServiceTypeHeartbeat:
+			if svc.PingKey != "" && !models.IsValidPingKey(svc.PingKey) {
+				v.add(field+".pingKey", "must be a UUID")
+			}
+			if svc.Grace < 0 {
+				v.add(field+".grace", "must not be negative")
+			}
+		default:
+			v.add(field+".type", `must be "http", "tcp", "heartbeat" or "browser"`)
+		}
+		if svc.CallbackURL != "" {
+			v.url(field+".callbackUrl", svc.CallbackURL, "http", "https")
+		}
+		if m := models.CallbackMode(svc.CallbackOn); m != "" && !m.IsValid() {
+			v.add(field+".callbackOn", "must be all or change")
+		}
+
+		if svc.Interval < 1 {
+			v.add(field+".interval", "must be at least 1 second")
+		}
+		// Heartbeats are pinged, never probed, so they have no timeout
+		if models.ServiceType(svc.Type) == models.ServiceTypeHeartbeat {
+			continue
+		}
+		if svc.Timeout < 1 {
+			v.add(field+".timeout", "must be positive")
+		} else if svc.Interval > 0 && svc.Timeout >= svc.Interval*1000 {
+			v.add(field+".timeout", fmt.Sprintf("%dms must be shorter than the %ds interval", svc.Timeout, svc.Interval))
+		}
+	}
+}
+
+func (v *validator) serviceDefaults(d ServiceDefaultsConfig) {
+	v.serviceProfile("serviceDefaults", d.ServiceProfile)
+	for name := range d.Profiles {
+		// Validate the merged profile so a timeout is checked against the
+		// interval it ends up with
+		profile, _ := d.Profile(name)
+		v.serviceProfile("serviceDefaults.profiles."+name, profile)
+	}
+}
+
+func (v *validator) serviceProfile(field string, p ServiceProfile) {
+	if p.Interval < 0 {
+		v.add(field+".interval", "must not be negative")
+	}
+	if p.Timeout < 0 {
+		v.add(field+".timeout", "must not be negative")
+	} else if p.Timeout > 0 && p.Interval > 0 && p.Timeout >= p.Interval*1000 {
+		v.add(field+".timeout", fmt.Sprintf("%dms must be shorter than the %ds interval", p.Timeout, p.Interval))
+	}
+	if p.ExpectedStatus != 0 && (p.ExpectedStatus < 100 || p.ExpectedStatus > 599) {
+		v.add(field+".expectedStatus", "must be an HTTP status code (100-599)")
+	}
+}
+
+func (v *validator) hosts(hosts []HostConfig) {
+	seen := make(map[string]bool, len(hosts))
+	for i, h := range hosts {
+		field := fmt.Sprintf("hosts[%d]", i)
+		if h.ID != "" {
+			field = fmt.Sprintf("hosts[%s]", h.ID)
+		}
+
+		switch {
+		case h.ID == "":
+			v.add(field+".id", "is required")
+		case seen[h.ID]:
+			v.add(field+".id", "duplicate host id")
+		}
+		seen[h.ID] = true
+
+		if h.Name == "" {
+			v.add(field+".name", "is required")
+		}
+		switch models.HostType(h.Type) {
+		case models.HostTypeRemote, "":
+			if h.IP == "" {
+				v.add(field+".ip", "is required for remote hosts")
+			}
+			switch models.HostCollector(h.Collector) {
+			case models.HostCollectorSSH, "":
+				switch models.SSHAuthType(h.SSHAuthType) {
+				case models.SSHAuthPassword, models.SSHAuthKey, models.SSHAuthKeyFile, "":
+				case models.SSHAuthSecret:
+					if h.SSHSecretRef == "" {
+						v.add(field+".sshSecretRef", "is required for secret authentication")
+					}
+				default:
+					v.add(field+".sshAuthType", "unknown SSH auth type")
+				}
+			case models.HostCollectorNodeExporter:
+				if h.ExporterURL != "" {
+					v.url(field+".exporterUrl", h.ExporterURL, "http", "https")
+				}
+			default:
+				v.add(field+".collector", `must be "ssh" or "node_exporter"`)
+			}
+		case models.HostTypeLocal:
+		default:
+			v.add(field+".type", `must be "remote" or "local"`)
+		}
+
+		if h.CollectInterval < 0 {
+			v.add(field+".collectInterval", "must not be negative")
+		}
+		if h.StoreInterval < 0 {
+			v.add(field+".storeInterval", "must not be negative")
+		}
+		if h.CollectInterval > 0 && h.StoreInterval > 0 && h.StoreInterval < h.CollectInterval {
+			v.add(field+".storeInterval", "must be at least collectInterval")
+		}
+	}
+}
+
+func (v *validator) alertRules(rules []AlertRuleConfig) {
+	seen := make(map[string]bool, len(rules))
+	for i, r := range rules {
+		field := fmt.Sprintf("alertRules[%d]", i)
+		if r.ID != "" {
+			field = fmt.Sprintf("alertRules[%s]", r.ID)
+		}
+
+		switch {
+		case r.ID == "":
+			v.add(field+".id", "is required")
+		case seen[r.ID]:
+			v.add(field+".id", "duplicate alert rule id")
+		}
+		seen[r.ID] = true
+
+		if r.Name == "" {
+			v.add(field+".name", "is required")
+		}
+		switch models.AlertRuleType(r.Type) {
+		case models.AlertRuleTypeResource, models.AlertRuleTypeService:
+		default:
+			v.add(field+".type", `must be "resource" or "service"`)
+		}
+		switch models.AlertMetric(r.Metric) {
+		case models.AlertMetricCPU, models.AlertMetricMemory, models.AlertMetricDisk, models.AlertMetricPacketLoss,
+			models.AlertMetricSecurityUpdates, models.AlertMetricPortChange, models.AlertMetricStatusChange, models.AlertMetricHTTPStatus, models.AlertMetricResponseTime:
+		default:
+			v.add(field+".metric", "unknown metric")
+		}
+		switch models.AlertOperator(r.Operator) {
+		case models.AlertOperatorGT, models.AlertOperatorLT, models.AlertOperatorGTE,
+			models.AlertOperatorLTE, models.AlertOperatorEQ, "":
+		default:
+			v.add(field+".operator", "unknown operator")
+		}
+		switch models.AlertSeverity(r.Severity) {
+		case models.AlertSeverityCritical, models.AlertSeverityWarning, models.AlertSeverityInfo, "":
+		default:
+			v.add(field+".severity", "unknown severity")
+		}
+		if category := models.HostResourceCategory(r.ResourceCategory); category != "" {
+			if models.AlertRuleType(r.Type) != models.AlertRuleTypeResource {
+				v.add(field+".resourceCategory", "only applies to resource rules")
+			} else if !category.IsValid() {
+				v.add(field+".resourceCategory", "unknown resource category")
+			}
+		}
+	}
+}
+
+// cron checks an optional cron spec
+func (v *validator) cron(field, spec string) {
+	if spec == "" {
+		return
+	}
+	if _, err := cronParser.Parse(spec); err != nil {
+		v.add(field, "invalid cron expression: "+err.Error())
+	}
+}
+
+// retention checks a <n>[d|h|m] retention string
+func (v *validator) retention(field, value string, required bool) {
+	if value == "" {
+		if required {
+			v.add(field, "is required")
+		}
+		return
+	}
+	if !IsValidRetention(value) {
+		v.add(field, `must look like "7d", "12h" or "30m"`)
+	}
+}
+
+// beforeArchive checks that a retention outlasts the age at which the
+// archive job takes the rows, which the cleanup would otherwise delete
+// unarchived
+func (v *validator) beforeArchive(field, retention, archiveAfter string) {
+	if !IsValidRetention(retention) || !IsValidRetention(archiveAfter) {
+		return
+	}
+	if GetRetentionDuration(retention) <= GetRetentionDuration(archiveAfter) {
+		v.add(field, "must be longer than the archive age ("+archiveAfter+"), or rows are deleted before they are archived")
+	}
+}
+
+// url checks that value is an absolute URL with one of the given schemes
+func (v *validator) url(field, value string, schemes ...string) {
+	if value == "" {
+		v.add(field, "is required")
+		return
+	}
+	u, err := url.Parse(value)
+	if err != nil || u.Host == "" {
+		v.add(field, "invalid URL")
+		return
+	}
+	for _, s := range schemes {
+		if u.Scheme == s {
+			return
+		}
+	}
+	v.add(field, "URL scheme must be "+strings.Join(schemes, " or "))
+}
+
+// validKafkaTopic reports whether name is a legal Kafka topic name
+func validKafkaTopic(name string) bool {
+	if name == "" || len(name) > 249 || name == "." || name == ".." {
+		return false
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// validScriptPath reports whether path is absolute and safe to pass to the
+// remote shell unquoted
+func validScriptPath(path string) bool {
+	if !strings.HasPrefix(path, "/") {
+		return false
+	}
+	for _, r := range path {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '_' || r == '-' || r == '/') {
+			return false
+		}
+	}
+	return true
+}
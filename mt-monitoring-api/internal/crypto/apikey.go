@@ -15,3 +15,36 @@ func GenerateApiKey() string {
 	}
 	return "mt_" + hex.EncodeToString(b)
 }
+
+// GeneratePingKey generates a random (version 4) UUID for heartbeat ping
+// URLs, the format healthchecks.io clients expect
+func GeneratePingKey() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	h := hex.EncodeToString(b)
+	return h[0:8] + "-" + h[8:12] + "-" + h[12:16] + "-" + h[16:20] + "-" + h[20:]
+}
+
+// GenerateSubscriberToken generates the unsubscribe token of a status page
+// subscriber: 64 hex chars (256 bits of entropy)
+func GenerateSubscriberToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return hex.EncodeToString(b)
+}
+
+// GenerateProjectToken generates the bearer token of a project.
+// Format: mtp_ + 64 hex chars (256 bits of entropy)
+func GenerateProjectToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic("crypto/rand unavailable: " + err.Error())
+	}
+	return "mtp_" + hex.EncodeToString(b)
+}
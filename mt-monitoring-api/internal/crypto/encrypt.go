@@ -14,6 +14,7 @@ import (
 
 var (
 	masterKey []byte
+	keyMu     sync.RWMutex
 	once      sync.Once
 	initErr   error
 )
@@ -33,40 +34,64 @@ func Init(configKey string) error {
 			return
 		}
 
-		key, err := hex.DecodeString(keyHex)
+		key, err := ParseKey(keyHex)
 		if err != nil {
-			initErr = fmt.Errorf("invalid encryption key (must be hex): %w", err)
+			initErr = err
 			return
 		}
-		if len(key) != 32 {
-			initErr = fmt.Errorf("encryption key must be 32 bytes (64 hex chars), got %d bytes", len(key))
-			return
-		}
-		masterKey = key
+		SetKey(key)
 	})
 	return initErr
 }
 
+// ParseKey decodes a 32-byte hex key (64 hex chars)
+func ParseKey(keyHex string) ([]byte, error) {
+	key, err := hex.DecodeString(keyHex)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key (must be hex): %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("encryption key must be 32 bytes (64 hex chars), got %d bytes", len(key))
+	}
+	return key, nil
+}
+
+// Key returns the master key in use, nil when encryption is disabled
+func Key() []byte {
+	keyMu.RLock()
+	defer keyMu.RUnlock()
+	return masterKey
+}
+
+// SetKey replaces the master key, e.g. after the stored secrets were
+// re-encrypted with a new one. nil disables encryption.
+func SetKey(key []byte) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	masterKey = key
+}
+
 // IsEnabled returns true if encryption is configured.
 func IsEnabled() bool {
-	return masterKey != nil
+	return Key() != nil
 }
 
 // Encrypt encrypts plaintext using AES-256-GCM.
 // Returns hex-encoded ciphertext. If encryption is disabled, returns plaintext as-is.
 func Encrypt(plaintext string) (string, error) {
-	if !IsEnabled() || plaintext == "" {
+	key := Key()
+	if key == nil || plaintext == "" {
 		return plaintext, nil
 	}
+	return EncryptWithKey(key, plaintext)
+}
 
-	block, err := aes.NewCipher(masterKey)
-	if err != nil {
-		return "", fmt.Errorf("cipher creation failed: %w", err)
-	}
-
-	aesGCM, err := cipher.NewGCM(block)
+// EncryptWithKey encrypts plaintext with the given AES-256 key and returns
+// hex-encoded nonce + ciphertext.
+func EncryptWithKey(key []byte, plaintext string) (string, error) {
+	aesGCM, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("GCM creation failed: %w", err)
+		return "", err
 	}
 
 	nonce := make([]byte, aesGCM.NonceSize())
@@ -81,38 +106,68 @@ func Encrypt(plaintext string) (string, error) {
 // Decrypt decrypts hex-encoded AES-256-GCM ciphertext.
 // If encryption is disabled, returns the input as-is.
 func Decrypt(ciphertextHex string) (string, error) {
-	if !IsEnabled() || ciphertextHex == "" {
+	key := Key()
+	if key == nil || ciphertextHex == "" {
 		return ciphertextHex, nil
 	}
 
-	ciphertext, err := hex.DecodeString(ciphertextHex)
-	if err != nil {
+	if !IsCiphertext(ciphertextHex) {
 		// Not encrypted — return as-is (backward compat with pre-encryption data)
 		return ciphertextHex, nil
 	}
 
-	block, err := aes.NewCipher(masterKey)
+	plaintext, err := DecryptWithKey(key, ciphertextHex)
+	if err != nil {
+		// Decryption failed — might be plaintext from before encryption was enabled
+		return ciphertextHex, errors.New("decryption failed, data may not be encrypted")
+	}
+	return plaintext, nil
+}
+
+// DecryptWithKey decrypts hex-encoded ciphertext produced by EncryptWithKey.
+// Unlike Decrypt it never passes values through: anything that does not
+// decrypt with key is an error.
+func DecryptWithKey(key []byte, ciphertextHex string) (string, error) {
+	ciphertext, err := hex.DecodeString(ciphertextHex)
 	if err != nil {
-		return "", fmt.Errorf("cipher creation failed: %w", err)
+		return "", fmt.Errorf("ciphertext is not hex: %w", err)
 	}
 
-	aesGCM, err := cipher.NewGCM(block)
+	aesGCM, err := newGCM(key)
 	if err != nil {
-		return "", fmt.Errorf("GCM creation failed: %w", err)
+		return "", err
 	}
 
 	nonceSize := aesGCM.NonceSize()
 	if len(ciphertext) < nonceSize {
-		// Too short to be encrypted — return as-is
-		return ciphertextHex, nil
+		return "", errors.New("ciphertext too short")
 	}
 
 	nonce, ciphertextBytes := ciphertext[:nonceSize], ciphertext[nonceSize:]
 	plaintext, err := aesGCM.Open(nil, nonce, ciphertextBytes, nil)
 	if err != nil {
-		// Decryption failed — might be plaintext from before encryption was enabled
-		return ciphertextHex, errors.New("decryption failed, data may not be encrypted")
+		return "", errors.New("decryption failed")
 	}
-
 	return string(plaintext), nil
 }
+
+// IsCiphertext reports whether s has the form of a value produced by
+// Encrypt (hex, at least nonce-sized). Values stored before encryption was
+// enabled usually don't.
+func IsCiphertext(s string) bool {
+	b, err := hex.DecodeString(s)
+	return err == nil && len(b) >= 12
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("cipher creation failed: %w", err)
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("GCM creation failed: %w", err)
+	}
+	return aesGCM, nil
+}
@@ -0,0 +1,41 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+)
+
+// Backup writes a consistent snapshot of the database to dstPath with
+// VACUUM INTO. Unlike copying the file, this is safe while the WAL contains
+// uncommitted pages. It runs on a connection of its own outside the pool, so
+// queries and check writes go on during the backup: in WAL mode the snapshot
+// is a read transaction that doesn't block writers. The snapshot is written
+// to a temporary file and renamed into place, so dstPath never holds a
+// partial backup.
+func (s *Store) Backup(ctx context.Context, dstPath string) error {
+	if s.path == "" {
+		return fmt.Errorf("backup needs a database file")
+	}
+	tmpPath := dstPath + ".tmp"
+	os.Remove(tmpPath)
+
+	db, err := sql.Open("sqlite", fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)", s.path))
+	if err != nil {
+		return fmt.Errorf("failed to open database for backup: %w", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1)
+
+	if _, err := db.ExecContext(ctx, `VACUUM INTO ?`, tmpPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("backup failed: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to move backup into place: %w", err)
+	}
+	return nil
+}
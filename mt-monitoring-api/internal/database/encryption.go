@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/mt-monitoring/api/internal/crypto"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// ErrKeyRotationFailed is returned when stored values don't decrypt with
+// the old key; the rotation is rolled back
+var ErrKeyRotationFailed = errors.New("some values could not be decrypted with the old key")
+
+// encryptedColumn is a column holding values encrypted with the master key.
+// New secret columns must be added here so key rotation covers them.
+type encryptedColumn struct {
+	table, key, column string
+}
+
+var encryptedColumns = []encryptedColumn{
+	{table: "hosts", key: "id", column: "ssh_key"},
+	{table: "hosts", key: "id", column: "ssh_password"},
+	{table: "services", key: "id", column: "auth"},
+}
+
+// RotateEncryptionKey re-encrypts every encrypted column from oldKey to
+// newKey in a single transaction. A nil oldKey means encryption was
+// disabled and values are stored in plaintext; values stored before
+// encryption was enabled are encrypted as well. With dryRun every value is
+// decrypted and counted but nothing is written. If any value fails to
+// decrypt, nothing is written and ErrKeyRotationFailed is returned with
+// the failures listed in the result.
+func (s *Store) RotateEncryptionKey(ctx context.Context, oldKey, newKey []byte, dryRun bool) (*models.KeyRotationResult, error) {
+	result := &models.KeyRotationResult{
+		DryRun:   dryRun,
+		Columns:  []models.KeyRotationColumn{},
+		Failures: []models.KeyRotationFailure{},
+	}
+
+	errRollback := errors.New("rollback")
+	err := s.Transaction(ctx, func(tx *sql.Tx) error {
+		for _, col := range encryptedColumns {
+			summary, err := rotateColumn(ctx, tx, col, oldKey, newKey, dryRun, result)
+			if err != nil {
+				return err
+			}
+			result.Columns = append(result.Columns, *summary)
+		}
+		if dryRun || len(result.Failures) > 0 {
+			return errRollback
+		}
+		return nil
+	})
+
+	switch {
+	case len(result.Failures) > 0:
+		return result, ErrKeyRotationFailed
+	case errors.Is(err, errRollback):
+		return result, nil
+	case err != nil:
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// rotateColumn re-encrypts one column inside tx, recording failures in result
+func rotateColumn(ctx context.Context, tx *sql.Tx, col encryptedColumn, oldKey, newKey []byte, dryRun bool, result *models.KeyRotationResult) (*models.KeyRotationColumn, error) {
+	summary := &models.KeyRotationColumn{Table: col.table, Column: col.column}
+
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(
+		`SELECT %s, %s FROM %s WHERE %s IS NOT NULL AND %s != ''`,
+		col.key, col.column, col.table, col.column, col.column))
+	if err != nil {
+		return nil, err
+	}
+
+	updated := make(map[string]string)
+	for rows.Next() {
+		var id, value string
+		if err := rows.Scan(&id, &value); err != nil {
+			rows.Close()
+			return nil, err
+		}
+
+		plaintext := value
+		if oldKey != nil && crypto.IsCiphertext(value) {
+			if plaintext, err = crypto.DecryptWithKey(oldKey, value); err != nil {
+				result.Failures = append(result.Failures, models.KeyRotationFailure{
+					Table: col.table, Column: col.column, RowID: id,
+				})
+				continue
+			}
+			summary.Reencrypted++
+		} else {
+			summary.Plaintext++
+		}
+
+		encrypted, err := crypto.EncryptWithKey(newKey, plaintext)
+		if err != nil {
+			rows.Close()
+			return nil, err
+		}
+		updated[id] = encrypted
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if dryRun {
+		return summary, nil
+	}
+	stmt := fmt.Sprintf(`UPDATE %s SET %s = ? WHERE %s = ?`, col.table, col.column, col.key)
+	for id, value := range updated {
+		if _, err := tx.ExecContext(ctx, stmt, value, id); err != nil {
+			return nil, err
+		}
+	}
+	return summary, nil
+}
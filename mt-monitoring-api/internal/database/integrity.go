@@ -0,0 +1,335 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// logicalRef is a reference that has no FOREIGN KEY constraint in the schema,
+// so PRAGMA foreign_key_check cannot see it
+type logicalRef struct {
+	table, column, parent string
+	filter                string // extra condition on the child row
+	action                string // repair action: "delete" or "none"
+}
+
+var logicalRefs = []logicalRef{
+	{table: "logs", column: "service_id", parent: "services", filter: "service_id IS NOT NULL AND service_id != ''", action: "delete"},
+	{table: "system_metrics", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "host_facts", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "host_updates", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "host_ports", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "port_snapshots", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "process_metrics", column: "host_id", parent: "hosts", filter: "host_id != 'local'", action: "delete"},
+	{table: "alert_rules", column: "service_id", parent: "services", filter: "service_id IS NOT NULL AND service_id != ''", action: "none"},
+	{table: "alert_rules", column: "host_id", parent: "hosts", filter: "host_id IS NOT NULL AND host_id != '' AND host_id != 'local'", action: "none"},
+	{table: "alert_rule_state", column: "target_id", parent: "hosts", filter: "target_type = 'host' AND target_id != 'local'", action: "delete"},
+	{table: "alert_rule_state", column: "target_id", parent: "services", filter: "target_type = 'service'", action: "delete"},
+}
+
+var createIndexPattern = regexp.MustCompile(`(?is)CREATE\s+(?:UNIQUE\s+)?INDEX\s+IF\s+NOT\s+EXISTS\s+(\w+)\s+ON\s+[^;]+;`)
+
+var dropIndexPattern = regexp.MustCompile(`(?i)DROP\s+INDEX\s+IF\s+EXISTS\s+(\w+)`)
+
+// expectedIndexes returns every index created by the embedded migrations,
+// keyed by name, with the statement that creates it. Indexes a later
+// migration drops without creating them again are left out.
+func expectedIndexes() (map[string]string, error) {
+	m, err := NewMigrator(nil)
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]string)
+	for _, mig := range m.Migrations() {
+		for _, match := range dropIndexPattern.FindAllStringSubmatch(mig.Up, -1) {
+			delete(indexes, match[1])
+		}
+		for _, match := range createIndexPattern.FindAllStringSubmatch(mig.Up, -1) {
+			indexes[match[1]] = match[0]
+		}
+	}
+	return indexes, nil
+}
+
+// CheckIntegrity validates page and index structure, foreign-key and logical
+// references, and the presence of every index the migrations create.
+// full runs PRAGMA integrity_check instead of the faster quick_check.
+func (s *Store) CheckIntegrity(ctx context.Context, full bool) (*models.IntegrityReport, error) {
+	report := &models.IntegrityReport{
+		CheckedAt:       time.Now(),
+		Full:            full,
+		IntegrityErrors: []string{},
+		Orphans:         []models.OrphanSummary{},
+		MissingIndexes:  []string{},
+	}
+
+	pragma := "PRAGMA quick_check"
+	if full {
+		pragma = "PRAGMA integrity_check"
+	}
+	rows, err := s.db.QueryContext(ctx, pragma)
+	if err != nil {
+		return nil, err
+	}
+	for rows.Next() {
+		var msg string
+		if err := rows.Scan(&msg); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if msg != "ok" {
+			report.IntegrityErrors = append(report.IntegrityErrors, msg)
+		}
+	}
+	rows.Close()
+
+	fkOrphans, err := s.foreignKeyOrphans(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Orphans = append(report.Orphans, fkOrphans...)
+
+	for _, ref := range logicalRefs {
+		var count int64
+		if err := s.db.QueryRowContext(ctx, ref.orphanQuery("COUNT(*)")).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to check %s.%s: %w", ref.table, ref.column, err)
+		}
+		if count > 0 {
+			report.Orphans = append(report.Orphans, models.OrphanSummary{
+				Table: ref.table, Column: ref.column, Parent: ref.parent, Count: count, Action: ref.action,
+			})
+		}
+	}
+
+	missing, err := s.missingIndexes(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for name := range missing {
+		report.MissingIndexes = append(report.MissingIndexes, name)
+	}
+	sort.Strings(report.MissingIndexes)
+
+	report.Healthy = len(report.IntegrityErrors) == 0 && len(report.Orphans) == 0 && len(report.MissingIndexes) == 0
+	return report, nil
+}
+
+// RepairIntegrity fixes orphaned rows and missing indexes as selected by opts,
+// then re-checks. Page-level corruption cannot be repaired here; restore a
+// backup instead.
+func (s *Store) RepairIntegrity(ctx context.Context, opts models.IntegrityRepairOptions) (*models.IntegrityRepairResult, error) {
+	result := &models.IntegrityRepairResult{IndexesCreated: []string{}}
+
+	if opts.DeleteOrphans {
+		violations, err := s.foreignKeyViolations(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		err = s.Transaction(ctx, func(tx *sql.Tx) error {
+			for _, v := range violations {
+				var res sql.Result
+				var err error
+				if v.onDelete == "SET NULL" {
+					res, err = tx.ExecContext(ctx, fmt.Sprintf(`UPDATE %q SET %q = NULL WHERE rowid = ?`, v.table, v.column), v.rowid)
+				} else {
+					res, err = tx.ExecContext(ctx, fmt.Sprintf(`DELETE FROM %q WHERE rowid = ?`, v.table), v.rowid)
+				}
+				if err != nil {
+					return err
+				}
+				n, _ := res.RowsAffected()
+				if v.onDelete == "SET NULL" {
+					result.OrphansNulled += n
+				} else {
+					result.OrphansDeleted += n
+				}
+			}
+
+			for _, ref := range logicalRefs {
+				if ref.action != "delete" {
+					continue
+				}
+				res, err := tx.ExecContext(ctx, ref.orphanQuery(""))
+				if err != nil {
+					return err
+				}
+				n, _ := res.RowsAffected()
+				result.OrphansDeleted += n
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to remove orphans: %w", err)
+		}
+	}
+
+	if opts.RecreateIndexes {
+		missing, err := s.missingIndexes(ctx)
+		if err != nil {
+			return nil, err
+		}
+		for name, stmt := range missing {
+			if _, err := s.db.ExecContext(ctx, stmt); err != nil {
+				return nil, fmt.Errorf("failed to create index %s: %w", name, err)
+			}
+			result.IndexesCreated = append(result.IndexesCreated, name)
+		}
+		sort.Strings(result.IndexesCreated)
+	}
+
+	report, err := s.CheckIntegrity(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	result.Report = report
+	return result, nil
+}
+
+// orphanQuery builds "SELECT <sel> FROM ..." for counting, or a DELETE when sel is empty
+func (ref logicalRef) orphanQuery(sel string) string {
+	where := fmt.Sprintf(`%s AND %s NOT IN (SELECT id FROM %s)`, ref.filter, ref.column, ref.parent)
+	if sel == "" {
+		return fmt.Sprintf(`DELETE FROM %s WHERE %s`, ref.table, where)
+	}
+	return fmt.Sprintf(`SELECT %s FROM %s WHERE %s`, sel, ref.table, where)
+}
+
+// fkViolation is one row reported by PRAGMA foreign_key_check
+type fkViolation struct {
+	table, parent, column, onDelete string
+	rowid                           int64
+}
+
+// foreignKeyViolations lists rows violating declared FOREIGN KEY constraints.
+// Constraints are only enforced on connections with foreign_keys enabled, so
+// edits made with other tools can leave these behind.
+func (s *Store) foreignKeyViolations(ctx context.Context) ([]fkViolation, error) {
+	rows, err := s.db.QueryContext(ctx, "PRAGMA foreign_key_check")
+	if err != nil {
+		return nil, err
+	}
+
+	type key struct {
+		table string
+		fkid  int
+	}
+	type checkRow struct {
+		key
+		parent string
+		rowid  sql.NullInt64
+	}
+	var raw []checkRow
+	for rows.Next() {
+		var v checkRow
+		if err := rows.Scan(&v.table, &v.rowid, &v.parent, &v.fkid); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		raw = append(raw, v)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// Resolve the column and ON DELETE action of each constraint
+	type fkInfo struct{ column, onDelete string }
+	infos := make(map[key]fkInfo)
+	for _, v := range raw {
+		if _, ok := infos[v.key]; ok {
+			continue
+		}
+		fkRows, err := s.db.QueryContext(ctx, fmt.Sprintf("PRAGMA foreign_key_list(%q)", v.table))
+		if err != nil {
+			return nil, err
+		}
+		for fkRows.Next() {
+			var id, seq int
+			var table, from string
+			var to sql.NullString
+			var onUpdate, onDelete, match string
+			if err := fkRows.Scan(&id, &seq, &table, &from, &to, &onUpdate, &onDelete, &match); err != nil {
+				fkRows.Close()
+				return nil, err
+			}
+			infos[key{v.table, id}] = fkInfo{column: from, onDelete: onDelete}
+		}
+		fkRows.Close()
+	}
+
+	violations := make([]fkViolation, 0, len(raw))
+	for _, v := range raw {
+		if !v.rowid.Valid {
+			continue // WITHOUT ROWID tables are not used by this schema
+		}
+		info := infos[v.key]
+		violations = append(violations, fkViolation{
+			table:    v.table,
+			parent:   v.parent,
+			column:   info.column,
+			onDelete: info.onDelete,
+			rowid:    v.rowid.Int64,
+		})
+	}
+	return violations, nil
+}
+
+// foreignKeyOrphans summarizes foreign key violations per table and column
+func (s *Store) foreignKeyOrphans(ctx context.Context) ([]models.OrphanSummary, error) {
+	violations, err := s.foreignKeyViolations(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var summaries []models.OrphanSummary
+	index := make(map[string]int)
+	for _, v := range violations {
+		k := v.table + "." + v.column + "->" + v.parent
+		i, ok := index[k]
+		if !ok {
+			action := "delete"
+			if v.onDelete == "SET NULL" {
+				action = "set null"
+			}
+			summaries = append(summaries, models.OrphanSummary{
+				Table: v.table, Column: v.column, Parent: v.parent, Action: action,
+			})
+			i = len(summaries) - 1
+			index[k] = i
+		}
+		summaries[i].Count++
+	}
+	return summaries, nil
+}
+
+// missingIndexes returns expected indexes that do not exist, with their
+// CREATE statements
+func (s *Store) missingIndexes(ctx context.Context) (map[string]string, error) {
+	expected, err := expectedIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'index'`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, err
+		}
+		delete(expected, name)
+	}
+	return expected, rows.Err()
+}
@@ -0,0 +1,763 @@
+package database
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// upgradeLegacySchema brings a database created before versioned migrations
+// existed up to the schema of migration 0001. It is only run once, before the
+// database is baselined in schema_migrations.
+func upgradeLegacySchema(db *sql.DB) error {
+	migrations := []string{
+		// Services table (v2: flattened schema)
+		`CREATE TABLE IF NOT EXISTS services (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL DEFAULT 'http',
+			is_active INTEGER DEFAULT 1,
+			url TEXT,
+			port INTEGER,
+			method TEXT DEFAULT 'GET',
+			headers TEXT,
+			body TEXT,
+			expected_status INTEGER DEFAULT 200,
+			interval INTEGER DEFAULT 60,
+			timeout INTEGER DEFAULT 5000,
+			tags TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Metrics table
+		`CREATE TABLE IF NOT EXISTS metrics (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			status TEXT NOT NULL,
+			response_time INTEGER,
+			status_code INTEGER,
+			error_message TEXT,
+			checked_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+		)`,
+
+		// Index for metrics queries
+		`CREATE INDEX IF NOT EXISTS idx_metrics_service_time ON metrics(service_id, checked_at)`,
+
+		// Logs table
+		`CREATE TABLE IF NOT EXISTS logs (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT,
+			level TEXT NOT NULL,
+			message TEXT NOT NULL,
+			metadata TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Index for logs queries
+		`CREATE INDEX IF NOT EXISTS idx_logs_level_time ON logs(level, created_at)`,
+		`CREATE INDEX IF NOT EXISTS idx_logs_service ON logs(service_id)`,
+
+		// Incidents table
+		`CREATE TABLE IF NOT EXISTS incidents (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			service_id TEXT NOT NULL,
+			type TEXT NOT NULL,
+			message TEXT,
+			started_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			resolved_at DATETIME,
+			FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+		)`,
+
+		// Index for incidents queries
+		`CREATE INDEX IF NOT EXISTS idx_incidents_service ON incidents(service_id)`,
+		`CREATE INDEX IF NOT EXISTS idx_incidents_active ON incidents(resolved_at) WHERE resolved_at IS NULL`,
+
+		// Notification channels table
+		`CREATE TABLE IF NOT EXISTS notification_channels (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			type TEXT NOT NULL,
+			config TEXT NOT NULL,
+			is_enabled INTEGER DEFAULT 1,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Hosts table
+		`CREATE TABLE IF NOT EXISTS hosts (
+			id            TEXT PRIMARY KEY,
+			name          TEXT NOT NULL,
+			type          TEXT NOT NULL DEFAULT 'local',
+			ip            TEXT NOT NULL DEFAULT '',
+			port          INTEGER DEFAULT 0,
+			"group"       TEXT NOT NULL DEFAULT '',
+			is_active     INTEGER DEFAULT 1,
+			description   TEXT DEFAULT '',
+			ssh_user      TEXT DEFAULT '',
+			ssh_port      INTEGER DEFAULT 22,
+			ssh_auth_type TEXT DEFAULT '',
+			ssh_key_path  TEXT DEFAULT '',
+			ssh_key       TEXT DEFAULT '',
+			ssh_password  TEXT DEFAULT '',
+			last_error    TEXT DEFAULT '',
+			created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// System metrics table (1-minute aggregates)
+		`CREATE TABLE IF NOT EXISTS system_metrics (
+			id          INTEGER PRIMARY KEY AUTOINCREMENT,
+			host_id     TEXT NOT NULL DEFAULT 'local',
+			cpu_usage   REAL NOT NULL,
+			mem_total   REAL NOT NULL,
+			mem_used    REAL NOT NULL,
+			mem_usage   REAL NOT NULL,
+			disk_total  REAL NOT NULL,
+			disk_used   REAL NOT NULL,
+			disk_usage  REAL NOT NULL,
+			disk_read   REAL DEFAULT 0,
+			disk_write  REAL DEFAULT 0,
+			net_in      REAL DEFAULT 0,
+			net_out     REAL DEFAULT 0,
+			created_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+
+		// Index for system metrics time-series queries
+		`CREATE INDEX IF NOT EXISTS idx_system_metrics_time ON system_metrics(created_at)`,
+		// NOTE: idx_system_metrics_host_time is created after migrateV3() adds host_id
+	}
+
+	for _, migration := range migrations {
+		if _, err := db.Exec(migration); err != nil {
+			return fmt.Errorf("migration failed: %w\nSQL: %s", err, migration)
+		}
+	}
+
+	// Run v2 migration for existing databases
+	if err := migrateV2(db); err != nil {
+		return fmt.Errorf("v2 migration failed: %w", err)
+	}
+
+	// Run v3 migration: add host_id to system_metrics for existing databases
+	if err := migrateV3(db); err != nil {
+		return fmt.Errorf("v3 migration failed: %w", err)
+	}
+	// Also on databases that had host_id before, as 0001 creates it
+	if _, err := db.Exec(`CREATE INDEX IF NOT EXISTS idx_system_metrics_host_time ON system_metrics(host_id, created_at)`); err != nil {
+		return fmt.Errorf("failed to create host_id index: %w", err)
+	}
+
+	// Run v4 migration: add SSH fields + last_error to hosts
+	if err := migrateV4(db); err != nil {
+		return fmt.Errorf("v4 migration failed: %w", err)
+	}
+
+	// Run v5 migration: add api_key to services, source/fingerprint to logs
+	if err := migrateV5(db); err != nil {
+		return fmt.Errorf("v5 migration failed: %w", err)
+	}
+
+	// Run v6 migration: alert rules system
+	if err := migrateV6(db); err != nil {
+		return fmt.Errorf("v6 migration failed: %w", err)
+	}
+
+	// Run v7 migration: notification history
+	if err := migrateV7(db); err != nil {
+		return fmt.Errorf("v7 migration failed: %w", err)
+	}
+
+	// Run v8 migration: scheduled health checks
+	if err := migrateV8(db); err != nil {
+		return fmt.Errorf("v8 migration failed: %w", err)
+	}
+
+	// Run v9 migration: remove warning presets
+	if err := migrateV9(db); err != nil {
+		return fmt.Errorf("v9 migration failed: %w", err)
+	}
+
+	// Run v10 migration: add resource_category to hosts
+	if err := migrateV10(db); err != nil {
+		return fmt.Errorf("v10 migration failed: %w", err)
+	}
+
+	// Run v11 migration: per-service log retention override
+	if err := migrateV11(db); err != nil {
+		return fmt.Errorf("v11 migration failed: %w", err)
+	}
+
+	// Run v12 migration: per-service log ingestion quotas
+	if err := migrateV12(db); err != nil {
+		return fmt.Errorf("v12 migration failed: %w", err)
+	}
+
+	return nil
+}
+
+// migrateV2 migrates existing services table from config JSON to flattened columns
+func migrateV2(db *sql.DB) error {
+	// Check if migration is needed by checking if 'config' column exists
+	var hasConfigColumn bool
+	rows, err := db.Query("PRAGMA table_info(services)")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dfltValue sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if name == "config" {
+			hasConfigColumn = true
+			break
+		}
+	}
+	rows.Close() // Must close before next query (SetMaxOpenConns=1)
+
+	if !hasConfigColumn {
+		// New database or already migrated
+		return nil
+	}
+
+	// Check if is_active column already exists (partial migration)
+	rows2, err := db.Query("PRAGMA table_info(services)")
+	if err != nil {
+		return err
+	}
+	var hasIsActiveColumn bool
+	for rows2.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dfltValue sql.NullString
+		if err := rows2.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err != nil {
+			rows2.Close()
+			return err
+		}
+		if name == "is_active" {
+			hasIsActiveColumn = true
+			break
+		}
+	}
+	rows2.Close() // Must close before next query
+
+	if hasIsActiveColumn {
+		// Already migrated
+		return nil
+	}
+
+	// Add new columns
+	alterStatements := []string{
+		"ALTER TABLE services ADD COLUMN is_active INTEGER DEFAULT 1",
+		"ALTER TABLE services ADD COLUMN url TEXT",
+		"ALTER TABLE services ADD COLUMN port INTEGER",
+		"ALTER TABLE services ADD COLUMN method TEXT DEFAULT 'GET'",
+		"ALTER TABLE services ADD COLUMN headers TEXT",
+		"ALTER TABLE services ADD COLUMN body TEXT",
+		"ALTER TABLE services ADD COLUMN expected_status INTEGER DEFAULT 200",
+		"ALTER TABLE services ADD COLUMN interval INTEGER DEFAULT 60",
+		"ALTER TABLE services ADD COLUMN timeout INTEGER DEFAULT 5000",
+		"ALTER TABLE services ADD COLUMN tags TEXT",
+	}
+
+	for _, stmt := range alterStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			// Ignore "duplicate column" errors
+			if !isDuplicateColumnError(err) {
+				return fmt.Errorf("migration failed: %w\nSQL: %s", err, stmt)
+			}
+		}
+	}
+
+	// Migrate data from config JSON to new columns
+	if err := migrateConfigData(db); err != nil {
+		return fmt.Errorf("data migration failed: %w", err)
+	}
+
+	return nil
+}
+
+// isDuplicateColumnError checks if the error is a duplicate column error
+func isDuplicateColumnError(err error) bool {
+	return err != nil && (
+	// SQLite duplicate column error messages
+	err.Error() == "duplicate column name: is_active" ||
+		err.Error() == "duplicate column name: url" ||
+		err.Error() == "duplicate column name: port" ||
+		err.Error() == "duplicate column name: method" ||
+		err.Error() == "duplicate column name: headers" ||
+		err.Error() == "duplicate column name: body" ||
+		err.Error() == "duplicate column name: expected_status" ||
+		err.Error() == "duplicate column name: interval" ||
+		err.Error() == "duplicate column name: timeout" ||
+		err.Error() == "duplicate column name: tags")
+}
+
+// migrateV3 adds host_id column to system_metrics for existing databases
+func migrateV3(db *sql.DB) error {
+	// Check if host_id column already exists
+	rows, err := db.Query("PRAGMA table_info(system_metrics)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	var hasHostID bool
+	for rows.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dfltValue sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == "host_id" {
+			hasHostID = true
+			break
+		}
+	}
+
+	if hasHostID {
+		return nil
+	}
+
+	// Add host_id column with default 'local' for existing rows
+	if _, err := db.Exec(`ALTER TABLE system_metrics ADD COLUMN host_id TEXT NOT NULL DEFAULT 'local'`); err != nil {
+		return fmt.Errorf("failed to add host_id column: %w", err)
+	}
+
+	return nil
+}
+
+// migrateV4 adds SSH fields and last_error to hosts table for existing databases
+func migrateV4(db *sql.DB) error {
+	alterStatements := []string{
+		"ALTER TABLE hosts ADD COLUMN ssh_user TEXT DEFAULT ''",
+		"ALTER TABLE hosts ADD COLUMN ssh_port INTEGER DEFAULT 22",
+		"ALTER TABLE hosts ADD COLUMN ssh_auth_type TEXT DEFAULT ''",
+		"ALTER TABLE hosts ADD COLUMN ssh_key_path TEXT DEFAULT ''",
+		"ALTER TABLE hosts ADD COLUMN ssh_key TEXT DEFAULT ''",
+		"ALTER TABLE hosts ADD COLUMN ssh_password TEXT DEFAULT ''",
+		"ALTER TABLE hosts ADD COLUMN last_error TEXT DEFAULT ''",
+	}
+
+	for _, stmt := range alterStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			// Ignore duplicate column errors (already migrated)
+			if err.Error() != fmt.Sprintf("duplicate column name: %s", extractColumnName(stmt)) {
+				// Try to check if column already exists by querying
+				continue
+			}
+		}
+	}
+
+	return nil
+}
+
+// extractColumnName extracts the column name from an ALTER TABLE ADD COLUMN statement
+func extractColumnName(stmt string) string {
+	// "ALTER TABLE hosts ADD COLUMN ssh_user TEXT DEFAULT ''"
+	// Find text between "COLUMN " and next space
+	const prefix = "COLUMN "
+	idx := len(prefix)
+	start := 0
+	for i := 0; i < len(stmt)-idx; i++ {
+		if stmt[i:i+idx] == prefix {
+			start = i + idx
+			break
+		}
+	}
+	if start == 0 {
+		return ""
+	}
+	end := start
+	for end < len(stmt) && stmt[end] != ' ' {
+		end++
+	}
+	return stmt[start:end]
+}
+
+// migrateConfigData migrates existing config JSON data to new columns
+func migrateConfigData(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, type, config FROM services WHERE config IS NOT NULL AND config != ''")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	type httpConfig struct {
+		URL            string            `json:"url"`
+		Method         string            `json:"method"`
+		Headers        map[string]string `json:"headers"`
+		ExpectedStatus int               `json:"expectedStatus"`
+		Timeout        int               `json:"timeout"`
+		Interval       int               `json:"interval"`
+	}
+
+	type tcpConfig struct {
+		Host     string `json:"host"`
+		Port     int    `json:"port"`
+		Timeout  int    `json:"timeout"`
+		Interval int    `json:"interval"`
+	}
+
+	for rows.Next() {
+		var id, svcType, configJSON string
+		if err := rows.Scan(&id, &svcType, &configJSON); err != nil {
+			continue
+		}
+
+		var url, method, headers string
+		var port, expectedStatus, interval, timeout int
+
+		if svcType == "http" {
+			var cfg httpConfig
+			if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+				continue
+			}
+			url = cfg.URL
+			method = cfg.Method
+			if method == "" {
+				method = "GET"
+			}
+			expectedStatus = cfg.ExpectedStatus
+			if expectedStatus == 0 {
+				expectedStatus = 200
+			}
+			timeout = cfg.Timeout
+			if timeout == 0 {
+				timeout = 5000
+			}
+			interval = cfg.Interval
+			if interval == 0 {
+				interval = 60
+			}
+			if cfg.Headers != nil {
+				headersBytes, _ := json.Marshal(cfg.Headers)
+				headers = string(headersBytes)
+			}
+		} else if svcType == "tcp" {
+			var cfg tcpConfig
+			if err := json.Unmarshal([]byte(configJSON), &cfg); err != nil {
+				continue
+			}
+			url = cfg.Host
+			port = cfg.Port
+			timeout = cfg.Timeout
+			if timeout == 0 {
+				timeout = 3000
+			}
+			interval = cfg.Interval
+			if interval == 0 {
+				interval = 60
+			}
+		}
+
+		_, err := db.Exec(`
+			UPDATE services
+			SET url = ?, port = ?, method = ?, headers = ?, expected_status = ?, interval = ?, timeout = ?
+			WHERE id = ?
+		`, url, port, method, headers, expectedStatus, interval, timeout, id)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// migrateV6 creates alert rules system tables and seeds default presets
+func migrateV6(db *sql.DB) error {
+	// Create alert_rules table
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS alert_rules (
+		id          TEXT PRIMARY KEY,
+		name        TEXT NOT NULL,
+		type        TEXT NOT NULL,
+		host_id     TEXT,
+		service_id  TEXT,
+		metric      TEXT NOT NULL,
+		operator    TEXT NOT NULL DEFAULT 'gt',
+		threshold   REAL NOT NULL DEFAULT 0,
+		duration    INTEGER NOT NULL DEFAULT 1,
+		severity    TEXT NOT NULL DEFAULT 'warning',
+		is_enabled  INTEGER DEFAULT 1,
+		cooldown    INTEGER DEFAULT 300,
+		created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create alert_rules table: %w", err)
+	}
+
+	// Create alert_rule_channels junction table
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS alert_rule_channels (
+		rule_id    TEXT NOT NULL,
+		channel_id TEXT NOT NULL,
+		PRIMARY KEY (rule_id, channel_id),
+		FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE,
+		FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create alert_rule_channels table: %w", err)
+	}
+
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_alert_rules_host ON alert_rules(host_id, is_enabled)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_alert_rules_service ON alert_rules(service_id, is_enabled)")
+
+	// Seed default preset rules (disabled)
+	seedDefaultAlertRules(db)
+
+	return nil
+}
+
+func seedDefaultAlertRules(db *sql.DB) {
+	var count int
+	db.QueryRow("SELECT COUNT(*) FROM alert_rules WHERE id LIKE 'preset-%'").Scan(&count)
+	if count > 0 {
+		return
+	}
+
+	presets := []struct {
+		id, name, metric, severity string
+		threshold                  float64
+		duration                   int
+	}{
+		{"preset-cpu-critical", "High CPU Usage", "cpu", "critical", 90, 3},
+		{"preset-mem-critical", "High Memory Usage", "memory", "critical", 85, 3},
+		{"preset-disk-critical", "Disk Almost Full", "disk", "critical", 90, 1},
+	}
+
+	now := time.Now()
+	for _, p := range presets {
+		db.Exec(`INSERT OR IGNORE INTO alert_rules
+			(id, name, type, metric, operator, threshold, duration, severity, is_enabled, cooldown, created_at, updated_at)
+			VALUES (?, ?, 'resource', ?, 'gt', ?, ?, ?, 0, 300, ?, ?)`,
+			p.id, p.name, p.metric, p.threshold, p.duration, p.severity, now, now)
+	}
+}
+
+// migrateV5 adds api_key to services and source/fingerprint to logs
+func migrateV5(db *sql.DB) error {
+	alterStatements := []string{
+		"ALTER TABLE services ADD COLUMN api_key TEXT DEFAULT ''",
+		"ALTER TABLE logs ADD COLUMN source TEXT DEFAULT 'internal'",
+		"ALTER TABLE logs ADD COLUMN fingerprint TEXT DEFAULT ''",
+	}
+
+	for _, stmt := range alterStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			// Ignore duplicate column errors (already migrated)
+			continue
+		}
+	}
+
+	// Add index for dedup lookups
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_logs_fingerprint_time ON logs(fingerprint, created_at)")
+
+	return nil
+}
+
+// migrateV7 adds notification_history and alert_rule_state tables
+func migrateV7(db *sql.DB) error {
+	// Create notification_history table
+	_, err := db.Exec(`CREATE TABLE IF NOT EXISTS notification_history (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		rule_id TEXT,
+		channel_id TEXT NOT NULL,
+		channel_name TEXT NOT NULL,
+		channel_type TEXT NOT NULL,
+		alert_type TEXT NOT NULL,
+		severity TEXT,
+		host_id TEXT,
+		host_name TEXT,
+		service_id TEXT,
+		service_name TEXT,
+		message TEXT NOT NULL,
+		status TEXT DEFAULT 'pending',
+		error_message TEXT,
+		retry_count INTEGER DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		sent_at DATETIME,
+		FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE SET NULL,
+		FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create notification_history table: %w", err)
+	}
+
+	// Create indexes for notification_history
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_notification_history_channel ON notification_history(channel_id, created_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_notification_history_type ON notification_history(alert_type, created_at)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_notification_history_status ON notification_history(status)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_notification_history_created ON notification_history(created_at)")
+
+	// Create alert_rule_state table for state persistence
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS alert_rule_state (
+		rule_id TEXT NOT NULL,
+		host_id TEXT NOT NULL,
+		breach_count INTEGER DEFAULT 0,
+		last_alerted_at DATETIME,
+		is_alerting INTEGER DEFAULT 0,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		PRIMARY KEY (rule_id, host_id),
+		FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create alert_rule_state table: %w", err)
+	}
+
+	// Create index for alert_rule_state
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_alert_rule_state_rule ON alert_rule_state(rule_id)")
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_alert_rule_state_host ON alert_rule_state(host_id)")
+
+	return nil
+}
+
+// migrateV8 adds schedule_type and cron_expression columns for scheduled health checks
+func migrateV8(db *sql.DB) error {
+	// Check if schedule_type column already exists
+	var hasScheduleType bool
+	rows, err := db.Query("PRAGMA table_info(services)")
+	if err != nil {
+		return err
+	}
+	for rows.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dfltValue sql.NullString
+		if err := rows.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err != nil {
+			rows.Close()
+			return err
+		}
+		if name == "schedule_type" {
+			hasScheduleType = true
+			break
+		}
+	}
+	rows.Close() // Must close before next query (SetMaxOpenConns=1)
+
+	// Add schedule_type column if it doesn't exist
+	if !hasScheduleType {
+		_, err := db.Exec(`ALTER TABLE services ADD COLUMN schedule_type TEXT DEFAULT 'interval'`)
+		if err != nil {
+			return fmt.Errorf("failed to add schedule_type column: %w", err)
+		}
+	}
+
+	// Check if cron_expression column already exists
+	var hasCronExpression bool
+	rows2, err := db.Query("PRAGMA table_info(services)")
+	if err != nil {
+		return err
+	}
+	for rows2.Next() {
+		var cid int
+		var name, ctype string
+		var notnull, pk int
+		var dfltValue sql.NullString
+		if err := rows2.Scan(&cid, &name, &ctype, &notnull, &dfltValue, &pk); err != nil {
+			rows2.Close()
+			return err
+		}
+		if name == "cron_expression" {
+			hasCronExpression = true
+			break
+		}
+	}
+	rows2.Close() // Must close before any further queries
+
+	// Add cron_expression column if it doesn't exist
+	if !hasCronExpression {
+		_, err := db.Exec(`ALTER TABLE services ADD COLUMN cron_expression TEXT`)
+		if err != nil {
+			return fmt.Errorf("failed to add cron_expression column: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// migrateV9 removes warning-level preset rules that are no longer seeded
+func migrateV9(db *sql.DB) error {
+	db.Exec(`DELETE FROM alert_rules WHERE id IN ('preset-cpu-warning', 'preset-mem-warning')`)
+	return nil
+}
+
+// migrateV10 adds resource_category column to hosts table
+func migrateV10(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(hosts)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull int
+		var dfltValue sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == "resource_category" {
+			return nil // already migrated
+		}
+	}
+
+	_, err = db.Exec(`ALTER TABLE hosts ADD COLUMN resource_category TEXT NOT NULL DEFAULT 'server'`)
+	return err
+}
+
+// migrateV11 adds log_retention column to services for per-service log retention overrides
+func migrateV11(db *sql.DB) error {
+	rows, err := db.Query("PRAGMA table_info(services)")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var cid int
+		var name, colType string
+		var notNull int
+		var dfltValue sql.NullString
+		var pk int
+		if err := rows.Scan(&cid, &name, &colType, &notNull, &dfltValue, &pk); err != nil {
+			return err
+		}
+		if name == "log_retention" {
+			return nil // already migrated
+		}
+	}
+	rows.Close() // Must close before next query (SetMaxOpenConns=1)
+
+	_, err = db.Exec(`ALTER TABLE services ADD COLUMN log_retention TEXT DEFAULT ''`)
+	return err
+}
+
+// migrateV12 adds log ingestion quota columns and the dropped event counter to services
+func migrateV12(db *sql.DB) error {
+	alterStatements := []string{
+		"ALTER TABLE services ADD COLUMN ingest_rate_limit INTEGER DEFAULT 0",
+		"ALTER TABLE services ADD COLUMN ingest_max_payload INTEGER DEFAULT 0",
+		"ALTER TABLE services ADD COLUMN ingest_dropped INTEGER DEFAULT 0",
+	}
+
+	for _, stmt := range alterStatements {
+		if _, err := db.Exec(stmt); err != nil {
+			// Ignore duplicate column errors (already migrated)
+			continue
+		}
+	}
+
+	return nil
+}
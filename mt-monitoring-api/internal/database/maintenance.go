@@ -0,0 +1,122 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// CheckpointResult is the outcome of PRAGMA wal_checkpoint
+type CheckpointResult struct {
+	Busy         bool  `json:"busy"`         // a reader or writer prevented a full checkpoint
+	LogFrames    int64 `json:"logFrames"`    // frames in the WAL
+	Checkpointed int64 `json:"checkpointed"` // frames copied back into the database
+}
+
+// Checkpoint copies the WAL back into the database file and truncates it.
+// The caller's context bounds the operation; the per-query timeout is not applied.
+func (s *Store) Checkpoint(ctx context.Context) (*CheckpointResult, error) {
+	var busy int
+	var result CheckpointResult
+	err := s.db.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").
+		Scan(&busy, &result.LogFrames, &result.Checkpointed)
+	if err != nil {
+		return nil, err
+	}
+	result.Busy = busy != 0
+	return &result, nil
+}
+
+// Vacuum rebuilds the database file to reclaim free pages.
+// It rewrites the whole file and blocks other queries until it finishes.
+func (s *Store) Vacuum(ctx context.Context) error {
+	_, err := s.db.ExecContext(ctx, "VACUUM")
+	return err
+}
+
+// Size returns the database and WAL file sizes in bytes
+func (s *Store) Size() (fileSize, walSize int64) {
+	if s.path == "" {
+		return 0, 0
+	}
+	if info, err := os.Stat(s.path); err == nil {
+		fileSize = info.Size()
+	}
+	if info, err := os.Stat(s.path + "-wal"); err == nil {
+		walSize = info.Size()
+	}
+	return fileSize, walSize
+}
+
+// Stats collects file sizes, page usage, per-table row counts and the last
+// maintenance runs
+func (s *Store) Stats(ctx context.Context) (*models.DatabaseStats, error) {
+	stats := &models.DatabaseStats{
+		Path:   s.path,
+		Tables: make(map[string]int64),
+	}
+	stats.FileSize, stats.WALSize = s.Size()
+	stats.TotalSize = stats.FileSize + stats.WALSize
+
+	for pragma, dest := range map[string]*int64{
+		"page_count":     &stats.PageCount,
+		"page_size":      &stats.PageSize,
+		"freelist_count": &stats.FreePages,
+	} {
+		if err := s.db.QueryRowContext(ctx, "PRAGMA "+pragma).Scan(dest); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", pragma, err)
+		}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT name FROM sqlite_master
+		WHERE type = 'table' AND name NOT LIKE 'sqlite_%'
+		ORDER BY name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+
+	// Count after closing the iterator — only one connection is available
+	for _, name := range tables {
+		var count int64
+		if err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM "`+name+`"`).Scan(&count); err != nil {
+			return nil, fmt.Errorf("failed to count %s: %w", name, err)
+		}
+		stats.Tables[name] = count
+	}
+
+	if m, err := NewMigrator(s.db); err == nil {
+		stats.SchemaVersion, _ = m.Version()
+	}
+
+	runs, err := s.Maintenance.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range runs {
+		at := runs[i].LastRunAt
+		switch runs[i].Task {
+		case models.MaintenanceCleanup:
+			stats.LastCleanupAt = &at
+		case models.MaintenanceCheckpoint:
+			stats.LastCheckpointAt = &at
+		case models.MaintenanceVacuum:
+			stats.LastVacuumAt = &at
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,11 @@
+DROP TABLE IF EXISTS alert_rule_state;
+DROP TABLE IF EXISTS notification_history;
+DROP TABLE IF EXISTS alert_rule_channels;
+DROP TABLE IF EXISTS alert_rules;
+DROP TABLE IF EXISTS system_metrics;
+DROP TABLE IF EXISTS hosts;
+DROP TABLE IF EXISTS notification_channels;
+DROP TABLE IF EXISTS incidents;
+DROP TABLE IF EXISTS logs;
+DROP TABLE IF EXISTS metrics;
+DROP TABLE IF EXISTS services;
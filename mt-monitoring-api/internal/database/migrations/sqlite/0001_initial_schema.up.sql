@@ -0,0 +1,188 @@
+-- Initial schema. Equivalent to the last hand-rolled migration (v12), so
+-- databases upgraded through the legacy path are baselined at this version.
+
+CREATE TABLE IF NOT EXISTS services (
+	id                 TEXT PRIMARY KEY,
+	name               TEXT NOT NULL,
+	type               TEXT NOT NULL DEFAULT 'http',
+	is_active          INTEGER DEFAULT 1,
+	url                TEXT,
+	port               INTEGER,
+	method             TEXT DEFAULT 'GET',
+	headers            TEXT,
+	body               TEXT,
+	expected_status    INTEGER DEFAULT 200,
+	interval           INTEGER DEFAULT 60,
+	timeout            INTEGER DEFAULT 5000,
+	tags               TEXT,
+	created_at         DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at         DATETIME DEFAULT CURRENT_TIMESTAMP,
+	api_key            TEXT DEFAULT '',
+	schedule_type      TEXT DEFAULT 'interval',
+	cron_expression    TEXT,
+	log_retention      TEXT DEFAULT '',
+	ingest_rate_limit  INTEGER DEFAULT 0,
+	ingest_max_payload INTEGER DEFAULT 0,
+	ingest_dropped     INTEGER DEFAULT 0
+);
+
+CREATE TABLE IF NOT EXISTS metrics (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	service_id    TEXT NOT NULL,
+	status        TEXT NOT NULL,
+	response_time INTEGER,
+	status_code   INTEGER,
+	error_message TEXT,
+	checked_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_metrics_service_time ON metrics(service_id, checked_at);
+
+CREATE TABLE IF NOT EXISTS logs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	service_id  TEXT,
+	level       TEXT NOT NULL,
+	message     TEXT NOT NULL,
+	metadata    TEXT,
+	created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	source      TEXT DEFAULT 'internal',
+	fingerprint TEXT DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS idx_logs_level_time ON logs(level, created_at);
+CREATE INDEX IF NOT EXISTS idx_logs_service ON logs(service_id);
+CREATE INDEX IF NOT EXISTS idx_logs_fingerprint_time ON logs(fingerprint, created_at);
+
+CREATE TABLE IF NOT EXISTS incidents (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	service_id  TEXT NOT NULL,
+	type        TEXT NOT NULL,
+	message     TEXT,
+	started_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	resolved_at DATETIME,
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_incidents_service ON incidents(service_id);
+CREATE INDEX IF NOT EXISTS idx_incidents_active ON incidents(resolved_at) WHERE resolved_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS notification_channels (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	config     TEXT NOT NULL,
+	is_enabled INTEGER DEFAULT 1,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS hosts (
+	id                TEXT PRIMARY KEY,
+	name              TEXT NOT NULL,
+	type              TEXT NOT NULL DEFAULT 'local',
+	ip                TEXT NOT NULL DEFAULT '',
+	port              INTEGER DEFAULT 0,
+	"group"           TEXT NOT NULL DEFAULT '',
+	is_active         INTEGER DEFAULT 1,
+	description       TEXT DEFAULT '',
+	ssh_user          TEXT DEFAULT '',
+	ssh_port          INTEGER DEFAULT 22,
+	ssh_auth_type     TEXT DEFAULT '',
+	ssh_key_path      TEXT DEFAULT '',
+	ssh_key           TEXT DEFAULT '',
+	ssh_password      TEXT DEFAULT '',
+	last_error        TEXT DEFAULT '',
+	created_at        DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at        DATETIME DEFAULT CURRENT_TIMESTAMP,
+	resource_category TEXT NOT NULL DEFAULT 'server'
+);
+
+CREATE TABLE IF NOT EXISTS system_metrics (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	host_id    TEXT NOT NULL DEFAULT 'local',
+	cpu_usage  REAL NOT NULL,
+	mem_total  REAL NOT NULL,
+	mem_used   REAL NOT NULL,
+	mem_usage  REAL NOT NULL,
+	disk_total REAL NOT NULL,
+	disk_used  REAL NOT NULL,
+	disk_usage REAL NOT NULL,
+	disk_read  REAL DEFAULT 0,
+	disk_write REAL DEFAULT 0,
+	net_in     REAL DEFAULT 0,
+	net_out    REAL DEFAULT 0,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_system_metrics_time ON system_metrics(created_at);
+CREATE INDEX IF NOT EXISTS idx_system_metrics_host_time ON system_metrics(host_id, created_at);
+
+CREATE TABLE IF NOT EXISTS alert_rules (
+	id         TEXT PRIMARY KEY,
+	name       TEXT NOT NULL,
+	type       TEXT NOT NULL,
+	host_id    TEXT,
+	service_id TEXT,
+	metric     TEXT NOT NULL,
+	operator   TEXT NOT NULL DEFAULT 'gt',
+	threshold  REAL NOT NULL DEFAULT 0,
+	duration   INTEGER NOT NULL DEFAULT 1,
+	severity   TEXT NOT NULL DEFAULT 'warning',
+	is_enabled INTEGER DEFAULT 1,
+	cooldown   INTEGER DEFAULT 300,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_host ON alert_rules(host_id, is_enabled);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_service ON alert_rules(service_id, is_enabled);
+
+CREATE TABLE IF NOT EXISTS alert_rule_channels (
+	rule_id    TEXT NOT NULL,
+	channel_id TEXT NOT NULL,
+	PRIMARY KEY (rule_id, channel_id),
+	FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE,
+	FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS notification_history (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	rule_id       TEXT,
+	channel_id    TEXT NOT NULL,
+	channel_name  TEXT NOT NULL,
+	channel_type  TEXT NOT NULL,
+	alert_type    TEXT NOT NULL,
+	severity      TEXT,
+	host_id       TEXT,
+	host_name     TEXT,
+	service_id    TEXT,
+	service_name  TEXT,
+	message       TEXT NOT NULL,
+	status        TEXT DEFAULT 'pending',
+	error_message TEXT,
+	retry_count   INTEGER DEFAULT 0,
+	created_at    DATETIME DEFAULT CURRENT_TIMESTAMP,
+	sent_at       DATETIME,
+	FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE SET NULL,
+	FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_notification_history_channel ON notification_history(channel_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_notification_history_type ON notification_history(alert_type, created_at);
+CREATE INDEX IF NOT EXISTS idx_notification_history_status ON notification_history(status);
+CREATE INDEX IF NOT EXISTS idx_notification_history_created ON notification_history(created_at);
+
+CREATE TABLE IF NOT EXISTS alert_rule_state (
+	rule_id         TEXT NOT NULL,
+	host_id         TEXT NOT NULL,
+	breach_count    INTEGER DEFAULT 0,
+	last_alerted_at DATETIME,
+	is_alerting     INTEGER DEFAULT 0,
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (rule_id, host_id),
+	FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_rule ON alert_rule_state(rule_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_host ON alert_rule_state(host_id);
+
+-- Default preset rules (disabled)
+INSERT OR IGNORE INTO alert_rules
+	(id, name, type, metric, operator, threshold, duration, severity, is_enabled, cooldown)
+VALUES
+	('preset-cpu-critical', 'High CPU Usage', 'resource', 'cpu', 'gt', 90, 3, 'critical', 0, 300),
+	('preset-mem-critical', 'High Memory Usage', 'resource', 'memory', 'gt', 85, 3, 'critical', 0, 300),
+	('preset-disk-critical', 'Disk Almost Full', 'resource', 'disk', 'gt', 90, 1, 'critical', 0, 300);
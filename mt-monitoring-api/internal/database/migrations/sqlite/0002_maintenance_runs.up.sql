@@ -0,0 +1,7 @@
+-- Last run of each database maintenance task (cleanup, checkpoint, vacuum)
+CREATE TABLE IF NOT EXISTS maintenance_runs (
+	task        TEXT PRIMARY KEY,
+	last_run_at DATETIME NOT NULL,
+	duration_ms INTEGER DEFAULT 0,
+	detail      TEXT DEFAULT ''
+);
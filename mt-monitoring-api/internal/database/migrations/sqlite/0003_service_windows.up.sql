@@ -0,0 +1,14 @@
+-- Periods excluded from uptime math: pauses (opened/closed by pause/resume)
+-- and scheduled maintenance. ends_at is NULL while a pause is open.
+CREATE TABLE IF NOT EXISTS service_windows (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	service_id TEXT NOT NULL,
+	kind       TEXT NOT NULL,
+	starts_at  DATETIME NOT NULL,
+	ends_at    DATETIME,
+	note       TEXT DEFAULT '',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_service_windows_service ON service_windows(service_id, starts_at);
@@ -0,0 +1,13 @@
+-- Diagnostics captured for failed checks (see diagnostics config)
+CREATE TABLE IF NOT EXISTS check_details (
+	metric_id    INTEGER PRIMARY KEY,
+	status_line  TEXT DEFAULT '',
+	body_snippet TEXT DEFAULT '',
+	remote_addr  TEXT DEFAULT '',
+	tls          TEXT,
+	dns_ms       INTEGER,
+	connect_ms   INTEGER,
+	tls_ms       INTEGER,
+	ttfb_ms      INTEGER,
+	FOREIGN KEY (metric_id) REFERENCES metrics(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- Hosts can reference SSH credentials in Vault or AWS Secrets Manager
+-- ("vault:<path>" / "aws:<secret-id>") instead of storing them here
+ALTER TABLE hosts ADD COLUMN ssh_secret_ref TEXT DEFAULT '';
@@ -0,0 +1,7 @@
+-- Runtime settings changed through the API. Values override the config
+-- file and environment, which provide the defaults.
+CREATE TABLE IF NOT EXISTS settings (
+	key        TEXT PRIMARY KEY,
+	value      TEXT NOT NULL,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
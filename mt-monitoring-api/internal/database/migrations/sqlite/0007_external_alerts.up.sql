@@ -0,0 +1,18 @@
+-- Alerts received from Prometheus Alertmanager, keyed by fingerprint so
+-- repeated deliveries of a firing alert are recognized
+CREATE TABLE IF NOT EXISTS external_alerts (
+	fingerprint TEXT PRIMARY KEY,
+	alert_name  TEXT NOT NULL,
+	status      TEXT NOT NULL,
+	severity    TEXT DEFAULT '',
+	service_id  TEXT,
+	incident_id INTEGER,
+	labels      TEXT NOT NULL,
+	annotations TEXT NOT NULL,
+	starts_at   DATETIME NOT NULL,
+	ends_at     DATETIME,
+	updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE SET NULL,
+	FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_external_alerts_status ON external_alerts(status);
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_services_ping_key;
+ALTER TABLE services DROP COLUMN grace;
+ALTER TABLE services DROP COLUMN ping_key;
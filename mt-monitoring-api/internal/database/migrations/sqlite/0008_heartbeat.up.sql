@@ -0,0 +1,6 @@
+-- Heartbeat services are pinged by the monitored job instead of being
+-- checked. ping_key is the secret in the ping URL (/ping/<key>), grace
+-- the seconds a ping may be late before the service is down.
+ALTER TABLE services ADD COLUMN ping_key TEXT DEFAULT '';
+ALTER TABLE services ADD COLUMN grace INTEGER DEFAULT 0;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_services_ping_key ON services(ping_key) WHERE ping_key != '';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS incident_updates;
+DROP TABLE IF EXISTS status_subscribers;
+DROP TABLE IF EXISTS status_component_services;
+DROP TABLE IF EXISTS status_components;
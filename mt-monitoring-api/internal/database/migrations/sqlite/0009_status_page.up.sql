@@ -0,0 +1,42 @@
+-- Status page components group services under a public name. A non-empty
+-- status_override replaces the status computed from the services.
+CREATE TABLE IF NOT EXISTS status_components (
+	id               TEXT PRIMARY KEY,
+	name             TEXT NOT NULL,
+	description      TEXT DEFAULT '',
+	position         INTEGER DEFAULT 0,
+	status_override  TEXT DEFAULT '',
+	override_message TEXT DEFAULT '',
+	created_at       DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at       DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS status_component_services (
+	component_id TEXT NOT NULL,
+	service_id   TEXT NOT NULL,
+	PRIMARY KEY (component_id, service_id),
+	FOREIGN KEY (component_id) REFERENCES status_components(id) ON DELETE CASCADE,
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_status_component_services_service ON status_component_services(service_id);
+
+-- Email addresses and webhook URLs notified of incidents on the listed
+-- components (JSON array, empty for all). token authorizes unsubscribing.
+CREATE TABLE IF NOT EXISTS status_subscribers (
+	id            INTEGER PRIMARY KEY AUTOINCREMENT,
+	type          TEXT NOT NULL,
+	target        TEXT NOT NULL,
+	component_ids TEXT DEFAULT '[]',
+	token         TEXT NOT NULL UNIQUE,
+	created_at    DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Messages posted on an incident for the status page and its subscribers
+CREATE TABLE IF NOT EXISTS incident_updates (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	incident_id INTEGER NOT NULL,
+	message     TEXT NOT NULL,
+	created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_incident_updates_incident ON incident_updates(incident_id, created_at);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS status_maintenance_components;
+DROP TABLE IF EXISTS status_maintenances;
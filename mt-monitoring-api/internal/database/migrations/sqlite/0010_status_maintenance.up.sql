@@ -0,0 +1,23 @@
+-- Maintenance announced on the status page. It is scheduled before
+-- starts_at, in progress until ends_at and holds the alerts of the services
+-- of its components while in progress. Times are stored in local time like
+-- checked_at so range comparisons line up.
+CREATE TABLE IF NOT EXISTS status_maintenances (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	title      TEXT NOT NULL,
+	message    TEXT DEFAULT '',
+	starts_at  DATETIME NOT NULL,
+	ends_at    DATETIME NOT NULL,
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_status_maintenances_ends ON status_maintenances(ends_at);
+
+CREATE TABLE IF NOT EXISTS status_maintenance_components (
+	maintenance_id INTEGER NOT NULL,
+	component_id   TEXT NOT NULL,
+	PRIMARY KEY (maintenance_id, component_id),
+	FOREIGN KEY (maintenance_id) REFERENCES status_maintenances(id) ON DELETE CASCADE,
+	FOREIGN KEY (component_id) REFERENCES status_components(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_status_maintenance_components_component ON status_maintenance_components(component_id);
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_incidents_parent;
+ALTER TABLE incidents DROP COLUMN parent_id;
@@ -0,0 +1,4 @@
+-- Incidents of services sharing a target host that open within the
+-- grouping window link to the first of them (alerts.incidentGrouping)
+ALTER TABLE incidents ADD COLUMN parent_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_incidents_parent ON incidents(parent_id) WHERE parent_id IS NOT NULL;
@@ -0,0 +1,14 @@
+-- Postmortem of an incident: root-cause category, impact summary, action
+-- items (JSON array of {description, owner, dueDate, done}) and a markdown
+-- body. Editable at any time, including after the incident is resolved.
+CREATE TABLE IF NOT EXISTS incident_postmortems (
+	incident_id  INTEGER PRIMARY KEY,
+	root_cause   TEXT NOT NULL,
+	impact       TEXT DEFAULT '',
+	action_items TEXT DEFAULT '[]',
+	body         TEXT DEFAULT '',
+	created_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at   DATETIME DEFAULT CURRENT_TIMESTAMP,
+	FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_incident_postmortems_root_cause ON incident_postmortems(root_cause);
@@ -0,0 +1,16 @@
+-- Service tags as rows, so services can be listed and filtered by tag.
+-- services.tags keeps each service's tag list in order; this table mirrors
+-- it and is rewritten with it.
+CREATE TABLE IF NOT EXISTS service_tags (
+	service_id TEXT NOT NULL,
+	tag        TEXT NOT NULL,
+	PRIMARY KEY (service_id, tag),
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_service_tags_tag ON service_tags(tag);
+
+INSERT OR IGNORE INTO service_tags (service_id, tag)
+SELECT s.id, TRIM(j.value)
+FROM services s, json_each(s.tags) j
+WHERE s.tags IS NOT NULL AND s.tags != '' AND json_valid(s.tags)
+  AND j.type = 'text' AND TRIM(j.value) != '';
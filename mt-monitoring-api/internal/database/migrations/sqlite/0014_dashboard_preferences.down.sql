@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS dashboard_views;
+DROP TABLE IF EXISTS dashboard_preferences;
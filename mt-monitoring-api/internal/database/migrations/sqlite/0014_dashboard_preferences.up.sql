@@ -0,0 +1,20 @@
+-- Dashboard preferences and saved filter views. owner is "global" until the
+-- API has users; clients may pass another owner to keep separate layouts.
+CREATE TABLE IF NOT EXISTS dashboard_preferences (
+	owner           TEXT PRIMARY KEY,
+	pinned_services TEXT DEFAULT '[]',
+	pinned_hosts    TEXT DEFAULT '[]',
+	service_order   TEXT DEFAULT '[]',
+	host_order      TEXT DEFAULT '[]',
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS dashboard_views (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	owner      TEXT NOT NULL,
+	name       TEXT NOT NULL,
+	filters    TEXT DEFAULT '{}',
+	created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX IF NOT EXISTS idx_dashboard_views_owner ON dashboard_views(owner);
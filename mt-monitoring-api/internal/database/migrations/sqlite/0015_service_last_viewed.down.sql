@@ -0,0 +1 @@
+ALTER TABLE services DROP COLUMN last_viewed_at;
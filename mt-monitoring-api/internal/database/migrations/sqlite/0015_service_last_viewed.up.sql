@@ -0,0 +1,3 @@
+-- When a service was last opened in the dashboard, so services nobody
+-- looks at can be reported as stale
+ALTER TABLE services ADD COLUMN last_viewed_at DATETIME;
@@ -0,0 +1,16 @@
+-- Hourly rollups of service checks with a response time histogram, kept
+-- longer than the raw metrics so latency distributions can be charted.
+-- hour is the UTC start of the hour; buckets is a JSON array of check
+-- counts per models.LatencyBuckets bound, the last one unbounded.
+CREATE TABLE IF NOT EXISTS metric_rollups (
+	service_id    TEXT NOT NULL,
+	hour          DATETIME NOT NULL,
+	checks        INTEGER NOT NULL DEFAULT 0,
+	failures      INTEGER NOT NULL DEFAULT 0,
+	response_sum  INTEGER NOT NULL DEFAULT 0,
+	response_max  INTEGER NOT NULL DEFAULT 0,
+	buckets       TEXT NOT NULL DEFAULT '[]',
+	PRIMARY KEY (service_id, hour),
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_metric_rollups_hour ON metric_rollups(hour);
@@ -0,0 +1,2 @@
+ALTER TABLE alert_rules DROP COLUMN log_level;
+ALTER TABLE alert_rules DROP COLUMN pattern;
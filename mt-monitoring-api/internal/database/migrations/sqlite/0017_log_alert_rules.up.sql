@@ -0,0 +1,4 @@
+-- Log alert rules (type 'log') fire when ingested log messages match a
+-- regular expression, optionally only for one log level
+ALTER TABLE alert_rules ADD COLUMN pattern TEXT DEFAULT '';
+ALTER TABLE alert_rules ADD COLUMN log_level TEXT DEFAULT '';
@@ -0,0 +1,10 @@
+-- Scheduler nodes sharing this database. Each node renews its lease while
+-- it runs; nodes whose lease expired are no longer given services or hosts.
+-- Times are UTC. address is the URL other nodes forward heartbeat pings to.
+CREATE TABLE IF NOT EXISTS cluster_nodes (
+	node_id           TEXT PRIMARY KEY,
+	address           TEXT NOT NULL DEFAULT '',
+	started_at        DATETIME NOT NULL,
+	lease_expires_at  DATETIME NOT NULL
+);
+CREATE INDEX IF NOT EXISTS idx_cluster_nodes_lease ON cluster_nodes(lease_expires_at);
@@ -0,0 +1,11 @@
+-- Notification channels of the down and recovery alerts of a service.
+-- Services without rows alert the alerts.defaultChannelIds channels, or
+-- every enabled channel when that is empty.
+CREATE TABLE IF NOT EXISTS service_channels (
+	service_id TEXT NOT NULL,
+	channel_id TEXT NOT NULL,
+	PRIMARY KEY (service_id, channel_id),
+	FOREIGN KEY (service_id) REFERENCES services(id) ON DELETE CASCADE,
+	FOREIGN KEY (channel_id) REFERENCES notification_channels(id) ON DELETE CASCADE
+);
+CREATE INDEX IF NOT EXISTS idx_service_channels_channel ON service_channels(channel_id);
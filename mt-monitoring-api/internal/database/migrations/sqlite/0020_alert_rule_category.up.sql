@@ -0,0 +1,3 @@
+-- Resource rules may target a host resource category (server, database,
+-- container) instead of one host or all hosts
+ALTER TABLE alert_rules ADD COLUMN resource_category TEXT DEFAULT '';
@@ -0,0 +1,4 @@
+DELETE FROM system_metrics WHERE ping_only = 1;
+ALTER TABLE system_metrics DROP COLUMN ping_only;
+ALTER TABLE system_metrics DROP COLUMN packet_loss;
+ALTER TABLE system_metrics DROP COLUMN ping_rtt;
@@ -0,0 +1,7 @@
+-- Hosts are pinged independently of SSH collection. Each row holds the
+-- average round-trip time and the packet loss of the pings since the
+-- previous row; rows of hosts that could not be collected hold only these
+-- (ping_only = 1).
+ALTER TABLE system_metrics ADD COLUMN ping_rtt REAL;
+ALTER TABLE system_metrics ADD COLUMN packet_loss REAL;
+ALTER TABLE system_metrics ADD COLUMN ping_only INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DELETE FROM alert_rules WHERE type = 'slo';
+ALTER TABLE alert_rules DROP COLUMN slo_long_window;
+ALTER TABLE alert_rules DROP COLUMN slo_short_window;
+ALTER TABLE alert_rules DROP COLUMN slo_latency_target;
+ALTER TABLE alert_rules DROP COLUMN slo_objective;
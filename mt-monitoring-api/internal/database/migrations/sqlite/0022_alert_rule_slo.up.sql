@@ -0,0 +1,7 @@
+-- SLO rules alert on the burn rate of a service's error budget over a
+-- short and a long window (minutes). A check is good when it succeeds
+-- within slo_latency_target ms; slo_objective is the percent of good checks.
+ALTER TABLE alert_rules ADD COLUMN slo_objective REAL DEFAULT 0;
+ALTER TABLE alert_rules ADD COLUMN slo_latency_target INTEGER DEFAULT 0;
+ALTER TABLE alert_rules ADD COLUMN slo_short_window INTEGER DEFAULT 0;
+ALTER TABLE alert_rules ADD COLUMN slo_long_window INTEGER DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE services DROP COLUMN metric_sampling;
@@ -0,0 +1,3 @@
+-- Services may keep only 1 in N raw checks once they are older than
+-- retention.sampleAfter; 0 uses retention.metricSampling
+ALTER TABLE services ADD COLUMN metric_sampling INTEGER DEFAULT 0;
@@ -0,0 +1,5 @@
+ALTER TABLE metrics DROP COLUMN transfer_ms;
+ALTER TABLE metrics DROP COLUMN ttfb_ms;
+ALTER TABLE metrics DROP COLUMN tls_ms;
+ALTER TABLE metrics DROP COLUMN connect_ms;
+ALTER TABLE metrics DROP COLUMN dns_ms;
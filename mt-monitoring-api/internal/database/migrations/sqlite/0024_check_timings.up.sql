@@ -0,0 +1,7 @@
+-- Phases of HTTP checks in milliseconds, NULL for other check types and
+-- for phases that did not happen
+ALTER TABLE metrics ADD COLUMN dns_ms INTEGER;
+ALTER TABLE metrics ADD COLUMN connect_ms INTEGER;
+ALTER TABLE metrics ADD COLUMN tls_ms INTEGER;
+ALTER TABLE metrics ADD COLUMN ttfb_ms INTEGER;
+ALTER TABLE metrics ADD COLUMN transfer_ms INTEGER;
@@ -0,0 +1,2 @@
+ALTER TABLE services DROP COLUMN insecure_skip_verify;
+ALTER TABLE services DROP COLUMN ca_cert;
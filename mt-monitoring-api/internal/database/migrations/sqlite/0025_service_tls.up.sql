@@ -0,0 +1,6 @@
+-- HTTP services may trust a private CA (PEM) or skip certificate
+-- verification. Existing services never verified certificates, so they keep
+-- skipping verification until it is turned off.
+ALTER TABLE services ADD COLUMN ca_cert TEXT DEFAULT '';
+ALTER TABLE services ADD COLUMN insecure_skip_verify INTEGER DEFAULT 0;
+UPDATE services SET insecure_skip_verify = 1 WHERE type = 'http';
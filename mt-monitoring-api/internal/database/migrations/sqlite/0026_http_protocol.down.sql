@@ -0,0 +1,3 @@
+ALTER TABLE check_details DROP COLUMN protocol;
+ALTER TABLE metrics DROP COLUMN protocol;
+ALTER TABLE services DROP COLUMN protocol;
@@ -0,0 +1,5 @@
+-- HTTP services choose the protocol of their checks (auto, h1, h2 or h3);
+-- checks record the protocol the server answered with
+ALTER TABLE services ADD COLUMN protocol TEXT DEFAULT 'auto';
+ALTER TABLE metrics ADD COLUMN protocol TEXT;
+ALTER TABLE check_details ADD COLUMN protocol TEXT;
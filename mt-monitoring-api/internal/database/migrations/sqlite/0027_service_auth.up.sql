@@ -0,0 +1,3 @@
+-- Credentials of HTTP checks (basic, bearer or OAuth2 client credentials),
+-- stored as JSON encrypted with the master key
+ALTER TABLE services ADD COLUMN auth TEXT;
@@ -0,0 +1,14 @@
+-- Inventory facts of each host, replaced by a daily collection. packages
+-- maps package managers to installed package counts; listening_ports is a
+-- JSON array of {protocol, address, port}. Like system_metrics, rows have
+-- no foreign key: the local host may have no hosts row.
+CREATE TABLE IF NOT EXISTS host_facts (
+	host_id         TEXT PRIMARY KEY,
+	kernel          TEXT NOT NULL DEFAULT '',
+	cpu_model       TEXT NOT NULL DEFAULT '',
+	cpu_cores       INTEGER NOT NULL DEFAULT 0,
+	virtualization  TEXT NOT NULL DEFAULT '',
+	packages        TEXT NOT NULL DEFAULT '{}',
+	listening_ports TEXT NOT NULL DEFAULT '[]',
+	collected_at    DATETIME NOT NULL
+);
@@ -0,0 +1,9 @@
+-- Pending updates of each SSH-collected host, replaced by every updates
+-- check. Like host_facts, rows have no foreign key.
+CREATE TABLE IF NOT EXISTS host_updates (
+	host_id    TEXT PRIMARY KEY,
+	manager    TEXT NOT NULL,
+	security   INTEGER NOT NULL DEFAULT 0,
+	total      INTEGER NOT NULL DEFAULT 0,
+	checked_at DATETIME NOT NULL
+);
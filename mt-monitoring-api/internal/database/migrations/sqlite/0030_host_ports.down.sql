@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS port_snapshots;
+DROP TABLE IF EXISTS host_ports;
@@ -0,0 +1,19 @@
+-- Listening ports of each host, replaced by every ports check. expected is
+-- a JSON array of port keys ("tcp/22"), current a JSON array of
+-- {protocol, address, port}. Like host_facts, rows have no foreign key.
+CREATE TABLE IF NOT EXISTS host_ports (
+	host_id    TEXT PRIMARY KEY,
+	expected   TEXT NOT NULL DEFAULT '[]',
+	current    TEXT NOT NULL DEFAULT '[]',
+	checked_at DATETIME NOT NULL
+);
+
+-- The listening ports of a host each time they changed
+CREATE TABLE IF NOT EXISTS port_snapshots (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	host_id    TEXT NOT NULL,
+	ports      TEXT NOT NULL DEFAULT '[]',
+	created_at DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_port_snapshots_host ON port_snapshots(host_id, created_at);
@@ -0,0 +1,15 @@
+-- Top processes of each host, one sample per store interval. The rows of a
+-- sample share created_at. Like system_metrics, rows have no foreign key.
+CREATE TABLE IF NOT EXISTS process_metrics (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT,
+	host_id      TEXT NOT NULL,
+	pid          INTEGER NOT NULL,
+	name         TEXT NOT NULL,
+	cpu          REAL NOT NULL DEFAULT 0,
+	memory       TEXT NOT NULL DEFAULT '',
+	memory_bytes INTEGER NOT NULL DEFAULT 0,
+	status       TEXT NOT NULL DEFAULT '',
+	created_at   DATETIME NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_process_metrics_host ON process_metrics(host_id, created_at);
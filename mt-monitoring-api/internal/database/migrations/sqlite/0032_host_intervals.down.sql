@@ -0,0 +1,2 @@
+ALTER TABLE hosts DROP COLUMN store_interval;
+ALTER TABLE hosts DROP COLUMN collect_interval;
@@ -0,0 +1,4 @@
+-- Per-host collection and storage intervals in seconds; 0 uses the global
+-- system.collectInterval and system.storeInterval
+ALTER TABLE hosts ADD COLUMN collect_interval INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE hosts ADD COLUMN store_interval INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE hosts DROP COLUMN exporter_url;
+ALTER TABLE hosts DROP COLUMN collector;
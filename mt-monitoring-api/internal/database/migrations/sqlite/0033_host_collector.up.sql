@@ -0,0 +1,5 @@
+-- Collector of a remote host ('ssh' or 'node_exporter') and the
+-- node_exporter metrics URL, http://<ip>:9100/metrics when empty
+ALTER TABLE hosts ADD COLUMN collector TEXT NOT NULL DEFAULT '';
+ALTER TABLE hosts ADD COLUMN exporter_url TEXT NOT NULL DEFAULT '';
+UPDATE hosts SET collector = 'ssh' WHERE type = 'remote';
@@ -0,0 +1,3 @@
+ALTER TABLE metrics DROP COLUMN load_ms;
+ALTER TABLE metrics DROP COLUMN dom_content_loaded_ms;
+ALTER TABLE services DROP COLUMN selector;
@@ -0,0 +1,5 @@
+-- Browser services wait for a CSS selector; their checks record the
+-- DOMContentLoaded and load times of the page, NULL for other check types
+ALTER TABLE services ADD COLUMN selector TEXT;
+ALTER TABLE metrics ADD COLUMN dom_content_loaded_ms INTEGER;
+ALTER TABLE metrics ADD COLUMN load_ms INTEGER;
@@ -0,0 +1,11 @@
+-- Network path to the target of a service, traced by traceroute or mtr
+-- when its incident opened
+CREATE TABLE IF NOT EXISTS incident_traces (
+	incident_id INTEGER PRIMARY KEY,
+	target      TEXT NOT NULL,
+	command     TEXT NOT NULL,
+	output      TEXT NOT NULL DEFAULT '',
+	error       TEXT NOT NULL DEFAULT '',
+	created_at  DATETIME NOT NULL,
+	FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE CASCADE
+);
@@ -0,0 +1,2 @@
+ALTER TABLE services DROP COLUMN callback_on;
+ALTER TABLE services DROP COLUMN callback_url;
@@ -0,0 +1,4 @@
+-- Check results of a service can be posted to a callback URL, either every
+-- result ('all') or only status changes ('change')
+ALTER TABLE services ADD COLUMN callback_url TEXT;
+ALTER TABLE services ADD COLUMN callback_on TEXT;
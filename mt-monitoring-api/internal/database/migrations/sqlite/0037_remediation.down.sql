@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS remediation_runs;
+ALTER TABLE alert_rules DROP COLUMN remediation;
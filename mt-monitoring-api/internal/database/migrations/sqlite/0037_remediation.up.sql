@@ -0,0 +1,26 @@
+-- Remediation action of an alert rule (JSON {type, command, hostId, url,
+-- maxRuns, window}), NULL for none
+ALTER TABLE alert_rules ADD COLUMN remediation TEXT;
+
+-- Every remediation run, kept as the audit trail of the rule even after it
+-- is deleted. The incident is the open incident of the service when the
+-- rule fired.
+CREATE TABLE IF NOT EXISTS remediation_runs (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	rule_id     TEXT NOT NULL,
+	rule_name   TEXT NOT NULL,
+	host_id     TEXT NOT NULL DEFAULT '',
+	service_id  TEXT NOT NULL DEFAULT '',
+	incident_id INTEGER,
+	type        TEXT NOT NULL,
+	target      TEXT NOT NULL,
+	command     TEXT NOT NULL DEFAULT '',
+	status      TEXT NOT NULL,
+	output      TEXT NOT NULL DEFAULT '',
+	error       TEXT NOT NULL DEFAULT '',
+	started_at  DATETIME NOT NULL,
+	duration_ms INTEGER NOT NULL DEFAULT 0,
+	FOREIGN KEY (incident_id) REFERENCES incidents(id) ON DELETE SET NULL
+);
+CREATE INDEX IF NOT EXISTS idx_remediation_runs_rule ON remediation_runs(rule_id, started_at);
+CREATE INDEX IF NOT EXISTS idx_remediation_runs_incident ON remediation_runs(incident_id);
@@ -0,0 +1,16 @@
+-- Personal notification channels of X-User owners and the alerts they
+-- receive on them. List columns are JSON arrays.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+	owner            TEXT PRIMARY KEY,
+	enabled          INTEGER NOT NULL DEFAULT 1,
+	telegram_chat_id TEXT,
+	email            TEXT,
+	language         TEXT,
+	timezone         TEXT,
+	severities       TEXT DEFAULT '[]',
+	service_ids      TEXT DEFAULT '[]',
+	host_ids         TEXT DEFAULT '[]',
+	tags             TEXT DEFAULT '[]',
+	all_alerts       INTEGER NOT NULL DEFAULT 0,
+	updated_at       DATETIME DEFAULT CURRENT_TIMESTAMP
+);
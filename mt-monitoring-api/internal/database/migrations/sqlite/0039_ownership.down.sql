@@ -0,0 +1,2 @@
+ALTER TABLE hosts DROP COLUMN ownership;
+ALTER TABLE services DROP COLUMN ownership;
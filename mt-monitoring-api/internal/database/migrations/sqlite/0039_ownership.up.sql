@@ -0,0 +1,4 @@
+-- Team and user responsible for a service or host (JSON {team, owner,
+-- contact, escalation}), NULL for none
+ALTER TABLE services ADD COLUMN ownership TEXT;
+ALTER TABLE hosts ADD COLUMN ownership TEXT;
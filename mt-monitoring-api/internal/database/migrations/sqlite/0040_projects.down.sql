@@ -0,0 +1,52 @@
+-- Preferences of users outside the default project are dropped
+CREATE TABLE notification_preferences_old (
+	owner            TEXT PRIMARY KEY,
+	enabled          INTEGER NOT NULL DEFAULT 1,
+	telegram_chat_id TEXT,
+	email            TEXT,
+	language         TEXT,
+	timezone         TEXT,
+	severities       TEXT DEFAULT '[]',
+	service_ids      TEXT DEFAULT '[]',
+	host_ids         TEXT DEFAULT '[]',
+	tags             TEXT DEFAULT '[]',
+	all_alerts       INTEGER NOT NULL DEFAULT 0,
+	updated_at       DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO notification_preferences_old (owner, enabled, telegram_chat_id, email, language, timezone,
+	severities, service_ids, host_ids, tags, all_alerts, updated_at)
+SELECT owner, enabled, telegram_chat_id, email, language, timezone,
+	severities, service_ids, host_ids, tags, all_alerts, updated_at
+FROM notification_preferences WHERE project_id = 'default';
+DROP TABLE notification_preferences;
+ALTER TABLE notification_preferences_old RENAME TO notification_preferences;
+
+CREATE TABLE dashboard_preferences_old (
+	owner           TEXT PRIMARY KEY,
+	pinned_services TEXT DEFAULT '[]',
+	pinned_hosts    TEXT DEFAULT '[]',
+	service_order   TEXT DEFAULT '[]',
+	host_order      TEXT DEFAULT '[]',
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+INSERT INTO dashboard_preferences_old (owner, pinned_services, pinned_hosts, service_order, host_order, updated_at)
+SELECT owner, pinned_services, pinned_hosts, service_order, host_order, updated_at
+FROM dashboard_preferences WHERE project_id = 'default';
+DROP TABLE dashboard_preferences;
+ALTER TABLE dashboard_preferences_old RENAME TO dashboard_preferences;
+
+DELETE FROM dashboard_views WHERE project_id != 'default';
+DROP INDEX IF EXISTS idx_dashboard_views_owner;
+ALTER TABLE dashboard_views DROP COLUMN project_id;
+CREATE INDEX IF NOT EXISTS idx_dashboard_views_owner ON dashboard_views(owner);
+
+DROP INDEX IF EXISTS idx_notification_channels_project;
+DROP INDEX IF EXISTS idx_alert_rules_project;
+DROP INDEX IF EXISTS idx_hosts_project;
+DROP INDEX IF EXISTS idx_services_project;
+ALTER TABLE notification_channels DROP COLUMN project_id;
+ALTER TABLE alert_rules DROP COLUMN project_id;
+ALTER TABLE hosts DROP COLUMN project_id;
+ALTER TABLE services DROP COLUMN project_id;
+
+DROP TABLE IF EXISTS projects;
@@ -0,0 +1,67 @@
+-- Projects isolate the resources of teams sharing the server. Requests
+-- authenticated with the token of a project only see its resources.
+CREATE TABLE IF NOT EXISTS projects (
+	id          TEXT PRIMARY KEY,
+	name        TEXT NOT NULL,
+	description TEXT,
+	token       TEXT UNIQUE,
+	created_at  DATETIME DEFAULT CURRENT_TIMESTAMP,
+	updated_at  DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Existing resources belong to the default project, which has no token
+-- until one is generated
+INSERT OR IGNORE INTO projects (id, name) VALUES ('default', 'Default');
+
+ALTER TABLE services ADD COLUMN project_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE hosts ADD COLUMN project_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE alert_rules ADD COLUMN project_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE notification_channels ADD COLUMN project_id TEXT NOT NULL DEFAULT 'default';
+ALTER TABLE dashboard_views ADD COLUMN project_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_services_project ON services(project_id);
+CREATE INDEX IF NOT EXISTS idx_hosts_project ON hosts(project_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rules_project ON alert_rules(project_id);
+CREATE INDEX IF NOT EXISTS idx_notification_channels_project ON notification_channels(project_id);
+DROP INDEX IF EXISTS idx_dashboard_views_owner;
+CREATE INDEX IF NOT EXISTS idx_dashboard_views_owner ON dashboard_views(project_id, owner);
+
+-- Preferences are kept per user of a project: the same X-User may be a
+-- different person in another project
+CREATE TABLE dashboard_preferences_new (
+	project_id      TEXT NOT NULL DEFAULT 'default',
+	owner           TEXT NOT NULL,
+	pinned_services TEXT DEFAULT '[]',
+	pinned_hosts    TEXT DEFAULT '[]',
+	service_order   TEXT DEFAULT '[]',
+	host_order      TEXT DEFAULT '[]',
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (project_id, owner)
+);
+INSERT INTO dashboard_preferences_new (owner, pinned_services, pinned_hosts, service_order, host_order, updated_at)
+SELECT owner, pinned_services, pinned_hosts, service_order, host_order, updated_at FROM dashboard_preferences;
+DROP TABLE dashboard_preferences;
+ALTER TABLE dashboard_preferences_new RENAME TO dashboard_preferences;
+
+CREATE TABLE notification_preferences_new (
+	project_id       TEXT NOT NULL DEFAULT 'default',
+	owner            TEXT NOT NULL,
+	enabled          INTEGER NOT NULL DEFAULT 1,
+	telegram_chat_id TEXT,
+	email            TEXT,
+	language         TEXT,
+	timezone         TEXT,
+	severities       TEXT DEFAULT '[]',
+	service_ids      TEXT DEFAULT '[]',
+	host_ids         TEXT DEFAULT '[]',
+	tags             TEXT DEFAULT '[]',
+	all_alerts       INTEGER NOT NULL DEFAULT 0,
+	updated_at       DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (project_id, owner)
+);
+INSERT INTO notification_preferences_new (owner, enabled, telegram_chat_id, email, language, timezone,
+	severities, service_ids, host_ids, tags, all_alerts, updated_at)
+SELECT owner, enabled, telegram_chat_id, email, language, timezone,
+	severities, service_ids, host_ids, tags, all_alerts, updated_at FROM notification_preferences;
+DROP TABLE notification_preferences;
+ALTER TABLE notification_preferences_new RENAME TO notification_preferences;
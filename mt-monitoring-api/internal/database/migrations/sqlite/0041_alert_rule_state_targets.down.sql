@@ -0,0 +1,20 @@
+CREATE TABLE alert_rule_state_old (
+	rule_id         TEXT NOT NULL,
+	host_id         TEXT NOT NULL,
+	breach_count    INTEGER DEFAULT 0,
+	last_alerted_at DATETIME,
+	is_alerting     INTEGER DEFAULT 0,
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (rule_id, host_id),
+	FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+);
+
+INSERT OR IGNORE INTO alert_rule_state_old (rule_id, host_id, breach_count, last_alerted_at, is_alerting, updated_at)
+SELECT rule_id, target_id, breach_count, last_alerted_at, is_alerting, updated_at
+FROM alert_rule_state;
+
+DROP INDEX IF EXISTS idx_alert_rule_state_target;
+DROP TABLE alert_rule_state;
+ALTER TABLE alert_rule_state_old RENAME TO alert_rule_state;
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_rule ON alert_rule_state(rule_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_host ON alert_rule_state(host_id);
@@ -0,0 +1,25 @@
+-- Evaluation state of alert rules is kept per host or per service. Service
+-- states used to be stored in host_id; target_type now tells them apart.
+CREATE TABLE alert_rule_state_new (
+	rule_id         TEXT NOT NULL,
+	target_type     TEXT NOT NULL DEFAULT 'host',
+	target_id       TEXT NOT NULL,
+	breach_count    INTEGER DEFAULT 0,
+	last_alerted_at DATETIME,
+	is_alerting     INTEGER DEFAULT 0,
+	updated_at      DATETIME DEFAULT CURRENT_TIMESTAMP,
+	PRIMARY KEY (rule_id, target_type, target_id),
+	FOREIGN KEY (rule_id) REFERENCES alert_rules(id) ON DELETE CASCADE
+);
+
+INSERT INTO alert_rule_state_new (rule_id, target_type, target_id, breach_count, last_alerted_at, is_alerting, updated_at)
+SELECT s.rule_id, CASE WHEN r.type = 'service' THEN 'service' ELSE 'host' END, s.host_id,
+	s.breach_count, s.last_alerted_at, s.is_alerting, s.updated_at
+FROM alert_rule_state s
+JOIN alert_rules r ON r.id = s.rule_id;
+
+DROP INDEX IF EXISTS idx_alert_rule_state_host;
+DROP TABLE alert_rule_state;
+ALTER TABLE alert_rule_state_new RENAME TO alert_rule_state;
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_rule ON alert_rule_state(rule_id);
+CREATE INDEX IF NOT EXISTS idx_alert_rule_state_target ON alert_rule_state(target_type, target_id);
@@ -0,0 +1,2 @@
+ALTER TABLE alert_rule_state DROP COLUMN breach_started_at;
+ALTER TABLE alert_rule_state DROP COLUMN value;
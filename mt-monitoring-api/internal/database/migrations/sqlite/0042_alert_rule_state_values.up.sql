@@ -0,0 +1,4 @@
+-- Last evaluated value of a rule and the start of its current breach,
+-- listed by GET /alerts/active
+ALTER TABLE alert_rule_state ADD COLUMN value REAL NOT NULL DEFAULT 0;
+ALTER TABLE alert_rule_state ADD COLUMN breach_started_at DATETIME;
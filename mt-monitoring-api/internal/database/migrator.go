@@ -0,0 +1,373 @@
+package database
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Migration files live in migrations/<dialect>/ and are named
+// NNNN_description.up.sql / NNNN_description.down.sql.
+//
+//go:embed migrations
+var migrationFiles embed.FS
+
+// DialectSQLite is the migrations subdirectory for the SQLite backend
+const DialectSQLite = "sqlite"
+
+// Migration is a single numbered schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// MigrationStatus reports whether a migration has been applied
+type MigrationStatus struct {
+	Version   int        `json:"version"`
+	Name      string     `json:"name"`
+	Applied   bool       `json:"applied"`
+	AppliedAt *time.Time `json:"appliedAt,omitempty"`
+}
+
+// Migrator applies versioned migrations and records them in schema_migrations
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the SQLite migrations embedded in the binary
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	return NewMigratorForDialect(db, DialectSQLite)
+}
+
+// NewMigratorForDialect creates a migrator using the embedded migrations for the given dialect
+func NewMigratorForDialect(db *sql.DB, dialect string) (*Migrator, error) {
+	sub, err := fs.Sub(migrationFiles, path.Join("migrations", dialect))
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for dialect %s: %w", dialect, err)
+	}
+
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads NNNN_name.up.sql / NNNN_name.down.sql pairs from fsys,
+// sorted by version. Every migration must have an up file; down is optional.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		fileName := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(fileName, ".sql") {
+			continue
+		}
+
+		var direction string
+		switch {
+		case strings.HasSuffix(fileName, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(fileName, ".down.sql"):
+			direction = "down"
+		default:
+			return nil, fmt.Errorf("migration %s must end in .up.sql or .down.sql", fileName)
+		}
+
+		base := strings.TrimSuffix(fileName, "."+direction+".sql")
+		versionStr, name, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("migration %s must be named NNNN_description", fileName)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil || version <= 0 {
+			return nil, fmt.Errorf("migration %s has invalid version %q", fileName, versionStr)
+		}
+
+		content, err := fs.ReadFile(fsys, fileName)
+		if err != nil {
+			return nil, err
+		}
+
+		m, exists := byVersion[version]
+		if !exists {
+			m = &Migration{Version: version, Name: name}
+			byVersion[version] = m
+		} else if m.Name != name {
+			return nil, fmt.Errorf("migration version %d used by both %s and %s", version, m.Name, name)
+		}
+
+		if direction == "up" {
+			m.Up = string(content)
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if strings.TrimSpace(m.Up) == "" {
+			return nil, fmt.Errorf("migration %04d_%s has no up script", m.Version, m.Name)
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Migrations returns the known migrations sorted by version
+func (m *Migrator) Migrations() []Migration {
+	return m.migrations
+}
+
+// ensureTable creates the schema_migrations bookkeeping table
+func (m *Migrator) ensureTable() error {
+	_, err := m.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		name       TEXT NOT NULL,
+		applied_at DATETIME NOT NULL
+	)`)
+	return err
+}
+
+// applied returns version → applied_at for every recorded migration. It
+// doesn't create schema_migrations, so reading the status of a
+// pre-versioning database leaves it recognizable by BaselineLegacy.
+func (m *Migrator) applied() (map[int]time.Time, error) {
+	var hasTable int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).
+		Scan(&hasTable); err != nil {
+		return nil, err
+	}
+	if hasTable == 0 {
+		return map[int]time.Time{}, nil
+	}
+
+	rows, err := m.db.Query(`SELECT version, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]time.Time)
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+// Version returns the highest applied migration version (0 if none)
+func (m *Migrator) Version() (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	version := 0
+	for v := range applied {
+		if v > version {
+			version = v
+		}
+	}
+	return version, nil
+}
+
+// Status lists every known migration with its applied state
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, mig := range m.migrations {
+		status := MigrationStatus{Version: mig.Version, Name: mig.Name}
+		if at, ok := applied[mig.Version]; ok {
+			appliedAt := at
+			status.Applied = true
+			status.AppliedAt = &appliedAt
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// Up applies all pending migrations in order. Returns the number applied.
+func (m *Migrator) Up() (int, error) {
+	return m.UpTo(0)
+}
+
+// UpTo applies pending migrations up to and including target (0 = latest).
+// Returns the number applied.
+func (m *Migrator) UpTo(target int) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for _, mig := range m.migrations {
+		if target > 0 && mig.Version > target {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			continue
+		}
+
+		if err := m.run(mig, mig.Up, true); err != nil {
+			return count, err
+		}
+		log.Printf("Applied migration %04d_%s", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// Down rolls back the most recently applied migrations. Returns the number rolled back.
+func (m *Migrator) Down(steps int) (int, error) {
+	if steps <= 0 {
+		return 0, nil
+	}
+	return m.down(func(version, count int) bool { return count < steps })
+}
+
+// DownTo rolls back applied migrations newer than target (0 = all).
+// Returns the number rolled back.
+func (m *Migrator) DownTo(target int) (int, error) {
+	return m.down(func(version, count int) bool { return version > target })
+}
+
+// Pending returns the migrations not yet applied, in order
+func (m *Migrator) Pending() ([]Migration, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []Migration
+	for _, mig := range m.migrations {
+		if _, ok := applied[mig.Version]; !ok {
+			pending = append(pending, mig)
+		}
+	}
+	return pending, nil
+}
+
+// down rolls back applied migrations newest first while more returns true
+func (m *Migrator) down(more func(version, count int) bool) (int, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		mig := m.migrations[i]
+		if _, ok := applied[mig.Version]; !ok {
+			continue
+		}
+		if !more(mig.Version, count) {
+			break
+		}
+		if strings.TrimSpace(mig.Down) == "" {
+			return count, fmt.Errorf("migration %04d_%s has no down script", mig.Version, mig.Name)
+		}
+
+		if err := m.run(mig, mig.Down, false); err != nil {
+			return count, err
+		}
+		log.Printf("Rolled back migration %04d_%s", mig.Version, mig.Name)
+		count++
+	}
+	return count, nil
+}
+
+// run executes a migration script and updates schema_migrations in one transaction
+func (m *Migrator) run(mig Migration, script string, up bool) error {
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+
+	tx, err := m.db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(script); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("migration %04d_%s failed: %w", mig.Version, mig.Name, err)
+	}
+
+	if up {
+		_, err = tx.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+			mig.Version, mig.Name, time.Now())
+	} else {
+		_, err = tx.Exec(`DELETE FROM schema_migrations WHERE version = ?`, mig.Version)
+	}
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to record migration %04d_%s: %w", mig.Version, mig.Name, err)
+	}
+
+	return tx.Commit()
+}
+
+// BaselineLegacy detects a database created before schema_migrations existed,
+// upgrades it with the legacy migrations and marks version 1 as applied so the
+// initial schema migration is not re-run against it. Run it before Status,
+// Up or Down on a database that may predate versioning.
+func (m *Migrator) BaselineLegacy() error {
+	var hasMigrationsTable, hasServicesTable int
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations'`).
+		Scan(&hasMigrationsTable); err != nil {
+		return err
+	}
+	if hasMigrationsTable > 0 {
+		return nil
+	}
+	if err := m.db.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'services'`).
+		Scan(&hasServicesTable); err != nil {
+		return err
+	}
+	if hasServicesTable == 0 {
+		return nil // Fresh database — 0001 creates everything
+	}
+
+	log.Println("Pre-versioning database detected, upgrading legacy schema")
+	if err := upgradeLegacySchema(m.db); err != nil {
+		return fmt.Errorf("legacy schema upgrade failed: %w", err)
+	}
+
+	if err := m.ensureTable(); err != nil {
+		return err
+	}
+	if len(m.migrations) == 0 || m.migrations[0].Version != 1 {
+		return fmt.Errorf("baseline migration 0001 not found")
+	}
+	_, err := m.db.Exec(`INSERT INTO schema_migrations (version, name, applied_at) VALUES (?, ?, ?)`,
+		1, m.migrations[0].Name, time.Now())
+	return err
+}
@@ -0,0 +1,32 @@
+//go:build linux
+
+package database
+
+import (
+	"path/filepath"
+	"syscall"
+)
+
+// networkFilesystems maps statfs magic numbers to the network filesystems
+// SQLite's locking and WAL shared memory don't work on
+var networkFilesystems = map[uint32]string{
+	0x6969:     "NFS",
+	0x517B:     "SMB",
+	0xFF534D42: "CIFS",
+	0xFE534D42: "SMB2",
+	0x5346414F: "AFS",
+	0x73757245: "Coda",
+	0x00C36400: "CephFS",
+	0x01021997: "9P",
+	0x0BD00BD0: "Lustre",
+}
+
+// networkFilesystem returns the network filesystem the file at path is on,
+// empty when it is local or can't be determined
+func networkFilesystem(path string) string {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(filepath.Dir(path), &st); err != nil {
+		return ""
+	}
+	return networkFilesystems[uint32(st.Type)]
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package database
+
+// networkFilesystem is only detected on Linux
+func networkFilesystem(path string) string {
+	return ""
+}
@@ -0,0 +1,299 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// AlertRuleRepository handles alert rule data operations
+type AlertRuleRepository interface {
+	GetAll(ctx context.Context) ([]models.AlertRule, error)
+	GetByID(ctx context.Context, id string) (*models.AlertRule, error)
+	GetEnabledByHostID(ctx context.Context, hostID string) ([]models.AlertRule, error)
+	GetEnabledByServiceID(ctx context.Context, serviceID string) ([]models.AlertRule, error)
+	GetEnabledLogRules(ctx context.Context) ([]models.AlertRule, error)
+	GetEnabledSLORules(ctx context.Context) ([]models.AlertRule, error)
+	Version() int64
+	Create(ctx context.Context, rule *models.AlertRule) error
+	Update(ctx context.Context, id string, req *models.AlertRuleUpdateRequest) error
+	Delete(ctx context.Context, id string) error
+	SetEnabled(ctx context.Context, id string, isEnabled bool) error
+}
+
+// RemediationRepository records the remediation runs of alert rules
+type RemediationRepository interface {
+	Create(ctx context.Context, run *models.RemediationRun) error
+	CountSince(ctx context.Context, ruleID, hostID, serviceID string, since time.Time) (int, error)
+	GetByRule(ctx context.Context, ruleID string, limit int) ([]models.RemediationRun, error)
+	GetByIncidents(ctx context.Context, incidentIDs []int64) ([]models.RemediationRun, error)
+}
+
+// AlertRuleStateRepository handles alert rule state persistence
+type AlertRuleStateRepository interface {
+	GetState(ctx context.Context, ruleID, targetType, targetID string) (*models.AlertRuleState, error)
+	GetAllByRule(ctx context.Context, ruleID string) ([]models.AlertRuleState, error)
+	GetAllByType(ctx context.Context, targetType string) ([]models.AlertRuleState, error)
+	GetActive(ctx context.Context) ([]models.AlertRuleState, error)
+	SaveState(ctx context.Context, state *models.AlertRuleState) error
+	IncrementBreach(ctx context.Context, ruleID, targetType, targetID string) error
+	ResetBreach(ctx context.Context, ruleID, targetType, targetID string) error
+	SetAlerting(ctx context.Context, ruleID, targetType, targetID string, isAlerting bool) error
+	DeleteByRule(ctx context.Context, ruleID string) error
+	DeleteByTarget(ctx context.Context, targetType, targetID string) error
+	DeleteOrphans(ctx context.Context) (int64, error)
+	Delete(ctx context.Context, ruleID, targetType, targetID string) error
+}
+
+// CheckDetailsRepository handles diagnostics captured for failed checks
+type CheckDetailsRepository interface {
+	Create(ctx context.Context, d *models.CheckDetails) error
+	GetByMetricID(ctx context.Context, metricID int64) (*models.CheckDetails, error)
+}
+
+// DashboardRepository computes dashboard KPIs across services
+type DashboardRepository interface {
+	GetSummary(ctx context.Context, duration time.Duration, slowest int) (*models.DashboardSummary, error)
+	GetVersion(ctx context.Context) (*models.DataVersion, error)
+}
+
+// ExternalAlertRepository tracks alerts received from Prometheus Alertmanager
+type ExternalAlertRepository interface {
+	Get(ctx context.Context, fingerprint string) (*models.ExternalAlert, error)
+	GetByStatus(ctx context.Context, status string) ([]models.ExternalAlert, error)
+	Save(ctx context.Context, a *models.ExternalAlert) error
+}
+
+// HostRepository handles host data operations
+type HostRepository interface {
+	GetAll(ctx context.Context) ([]models.Host, error)
+	GetByID(ctx context.Context, id string) (*models.Host, error)
+	GetByType(ctx context.Context, hostType models.HostType) ([]models.Host, error)
+	GetActive(ctx context.Context) ([]models.Host, error)
+	Create(ctx context.Context, h *models.Host) error
+	Update(ctx context.Context, h *models.Host) error
+	SetLastError(ctx context.Context, id string, lastError string) error
+	Delete(ctx context.Context, id string) error
+	SetActive(ctx context.Context, id string, isActive bool) error
+}
+
+// HostFactsRepository handles host inventory facts
+type HostFactsRepository interface {
+	Get(ctx context.Context, hostID string) (*models.HostFacts, error)
+	Save(ctx context.Context, f *models.HostFacts) error
+}
+
+// HostUpdatesRepository handles the pending updates of hosts
+type HostUpdatesRepository interface {
+	Get(ctx context.Context, hostID string) (*models.SecurityUpdates, error)
+	Save(ctx context.Context, u *models.SecurityUpdates) error
+}
+
+// HostPortsRepository handles the listening ports of hosts
+type HostPortsRepository interface {
+	Get(ctx context.Context, hostID string) (*models.HostPorts, error)
+	Save(ctx context.Context, p *models.HostPorts, changed bool) error
+	SetExpected(ctx context.Context, hostID string, expected []string) error
+	GetSnapshots(ctx context.Context, hostID string, since time.Time) ([]models.PortSnapshot, error)
+	DeleteOldSnapshots(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// ProcessMetricRepository handles the recorded top processes of hosts
+type ProcessMetricRepository interface {
+	CreateSample(ctx context.Context, hostID string, at time.Time, processes []models.ProcessInfo) error
+	GetHistory(ctx context.Context, hostID string, since time.Time) ([]models.ProcessSample, error)
+	GetSampleAt(ctx context.Context, hostID string, at time.Time) (*models.ProcessSample, error)
+	DeleteOld(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// IncidentRepository handles incident data operations
+type IncidentRepository interface {
+	Create(ctx context.Context, i *models.Incident) error
+	GetActive(ctx context.Context) ([]models.Incident, error)
+	GetByID(ctx context.Context, id int64) (*models.Incident, error)
+	GetChildren(ctx context.Context, parentID int64) ([]models.Incident, error)
+	GetResolvedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Incident, error)
+	DeleteByIDs(ctx context.Context, ids []int64) (int64, error)
+	DeleteResolvedBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	Resolve(ctx context.Context, serviceID string) error
+	ResolveByID(ctx context.Context, id int64) error
+	GetTimeline(ctx context.Context, limit int) ([]models.TimelineEvent, error)
+	GetRange(ctx context.Context, from, to time.Time) ([]models.Incident, error)
+	GetPostmortems(ctx context.Context, incidentIDs []int64) (map[int64]*models.Postmortem, error)
+	SavePostmortem(ctx context.Context, p *models.Postmortem) error
+	DeletePostmortem(ctx context.Context, incidentID int64) (bool, error)
+	GetTraces(ctx context.Context, incidentIDs []int64) (map[int64]*models.Trace, error)
+	SaveTrace(ctx context.Context, t *models.Trace) error
+}
+
+// LogRepository handles log data operations
+type LogRepository interface {
+	Create(ctx context.Context, l *models.Log) error
+	GetAll(ctx context.Context, filter models.LogFilter) ([]models.Log, int, error)
+	DeleteOld(ctx context.Context, retention time.Duration) (int64, error)
+	DeleteByPolicy(ctx context.Context, policy models.LogRetentionPolicy) (int64, error)
+}
+
+// MaintenanceRepository records database maintenance runs
+type MaintenanceRepository interface {
+	Record(ctx context.Context, run *models.MaintenanceRun) error
+	GetAll(ctx context.Context) ([]models.MaintenanceRun, error)
+}
+
+// MetricRepository handles metric data operations
+type MetricRepository interface {
+	Create(ctx context.Context, m *models.Metric) error
+	GetByServiceID(ctx context.Context, serviceID string, limit int) ([]models.Metric, error)
+	GetBefore(ctx context.Context, serviceID string, before int64, limit int) ([]models.Metric, error)
+	GetByID(ctx context.Context, serviceID string, id int64) (*models.Metric, error)
+	GetSummary(ctx context.Context, serviceID string, duration time.Duration, apdexThreshold int) (*models.MetricSummary, error)
+	GetPerformanceStats(ctx context.Context, duration time.Duration, apdexThreshold int) (map[string]models.PerformanceStats, error)
+	GetStatusSnapshots(ctx context.Context, duration time.Duration) (map[string]models.ServiceStatusSnapshot, error)
+	GetUptimeData(ctx context.Context, serviceID string, days int, loc *time.Location) ([]models.UptimeData, error)
+	GetStatusIntervals(ctx context.Context, serviceID string, since time.Time) ([]models.StatusInterval, error)
+	GetRange(ctx context.Context, serviceID string, from, to time.Time) ([]models.Metric, error)
+	DeleteOld(ctx context.Context, retention time.Duration) (int64, error)
+	SampleOld(ctx context.Context, serviceID string, age time.Duration, every int, interval time.Duration) (int64, error)
+	GetRollups(ctx context.Context, serviceID string, from, to time.Time) ([]models.MetricRollup, error)
+	DeleteOldRollups(ctx context.Context, retention time.Duration) (int64, error)
+}
+
+// NotificationRepository handles notification channel data operations
+type NotificationRepository interface {
+	GetAll(ctx context.Context) ([]models.NotificationChannel, error)
+	GetByID(ctx context.Context, id string) (*models.NotificationChannel, error)
+	Create(ctx context.Context, ch *models.NotificationChannel) error
+	Delete(ctx context.Context, id string) error
+	Update(ctx context.Context, ch *models.NotificationChannel) error
+	SetEnabled(ctx context.Context, id string, isEnabled bool) error
+	GetEnabled(ctx context.Context, projectID string) ([]models.NotificationChannel, error)
+}
+
+// NotificationHistoryRepository handles notification history data operations
+type NotificationHistoryRepository interface {
+	Create(ctx context.Context, history *models.NotificationHistory) error
+	UpdateStatus(ctx context.Context, id int, status string, errorMessage string) error
+	IncrementRetry(ctx context.Context, id int) error
+	GetByID(ctx context.Context, id int) (*models.NotificationHistory, error)
+	GetAll(ctx context.Context, filter *models.NotificationHistoryFilter) ([]models.NotificationHistory, error)
+	GetCount(ctx context.Context, filter *models.NotificationHistoryFilter) (int, error)
+	GetStats(ctx context.Context, days int) (map[string]interface{}, error)
+	GetFailures(ctx context.Context, since time.Time) ([]models.DeliveryFailure, error)
+	DeleteOlderThan(ctx context.Context, days int) (int64, error)
+	DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error)
+	GetOlderThan(ctx context.Context, cutoff time.Time, limit int) ([]models.NotificationHistory, error)
+	DeleteByIDs(ctx context.Context, ids []int) (int64, error)
+}
+
+// ServiceRepository handles service data operations
+type ServiceRepository interface {
+	GetAll(ctx context.Context) ([]models.Service, error)
+	GetByID(ctx context.Context, id string) (*models.Service, error)
+	Create(ctx context.Context, s *models.Service) error
+	UpdateApiKey(ctx context.Context, id, apiKey string) error
+	Update(ctx context.Context, s *models.Service) error
+	GetActive(ctx context.Context) ([]models.Service, error)
+	SetActive(ctx context.Context, id string, isActive bool) error
+	GetByApiKey(ctx context.Context, apiKey string) (*models.Service, error)
+	GetByPingKey(ctx context.Context, pingKey string) (*models.Service, error)
+	GetLogRetentionOverrides(ctx context.Context) (map[string]string, error)
+	AddDroppedLogs(ctx context.Context, id string, n int64) error
+	MarkViewed(ctx context.Context, id string) error
+	GetUsage(ctx context.Context, since time.Time) (map[string]models.ServiceUsage, error)
+	Delete(ctx context.Context, id string) error
+}
+
+// TagRepository handles service tags
+type TagRepository interface {
+	GetAll(ctx context.Context) ([]models.Tag, error)
+	Exists(ctx context.Context, tag string) (bool, error)
+	ServiceIDs(ctx context.Context, tags []string) (map[string]bool, error)
+	Merge(ctx context.Context, tags []string, into string) (int, error)
+}
+
+// ServiceWindowRepository handles pause, maintenance and exclusion windows
+// excluded from uptime
+type ServiceWindowRepository interface {
+	Create(ctx context.Context, w *models.ServiceWindow) error
+	CloseOpen(ctx context.Context, serviceID string, kind models.WindowKind, at time.Time) error
+	GetByServiceID(ctx context.Context, serviceID string, since time.Time) ([]models.ServiceWindow, error)
+	Delete(ctx context.Context, serviceID string, kind models.WindowKind, id int64) (bool, error)
+}
+
+// StatusPageRepository handles status page components, subscribers,
+// incident updates and maintenance announcements
+type StatusPageRepository interface {
+	GetComponents(ctx context.Context) ([]models.StatusComponent, error)
+	GetComponent(ctx context.Context, id string) (*models.StatusComponent, error)
+	CreateComponent(ctx context.Context, c *models.StatusComponent) error
+	UpdateComponent(ctx context.Context, c *models.StatusComponent) error
+	DeleteComponent(ctx context.Context, id string) (bool, error)
+	SetOverride(ctx context.Context, id string, status models.ComponentStatus, message string) (bool, error)
+	GetSubscribers(ctx context.Context) ([]models.StatusSubscriber, error)
+	CreateSubscriber(ctx context.Context, s *models.StatusSubscriber) error
+	DeleteSubscriber(ctx context.Context, id int64) (bool, error)
+	DeleteSubscriberByToken(ctx context.Context, token string) (bool, error)
+	CreateIncidentUpdate(ctx context.Context, u *models.IncidentUpdate) error
+	GetIncidentUpdates(ctx context.Context, incidentIDs []int64) ([]models.IncidentUpdate, error)
+	GetMaintenances(ctx context.Context, endedSince time.Time) ([]models.StatusMaintenance, error)
+	GetMaintenance(ctx context.Context, id int64) (*models.StatusMaintenance, error)
+	CreateMaintenance(ctx context.Context, m *models.StatusMaintenance) error
+	UpdateMaintenance(ctx context.Context, m *models.StatusMaintenance) error
+	DeleteMaintenance(ctx context.Context, id int64) (bool, error)
+	InMaintenance(ctx context.Context, serviceID string, at time.Time) (bool, error)
+}
+
+// SettingRepository stores runtime settings that override the config file
+type SettingRepository interface {
+	GetAll(ctx context.Context) (map[string]string, error)
+	Set(ctx context.Context, values map[string]string) error
+}
+
+// ClusterRepository stores the leases of the scheduler nodes sharing the
+// database
+type ClusterRepository interface {
+	Renew(ctx context.Context, node *models.ClusterNode) error
+	GetAll(ctx context.Context) ([]models.ClusterNode, error)
+	Delete(ctx context.Context, nodeID string) error
+	DeleteExpired(ctx context.Context, before time.Time) (int64, error)
+}
+
+// PreferenceRepository handles dashboard preferences and saved views of the
+// users of a project
+type PreferenceRepository interface {
+	Get(ctx context.Context, projectID, owner string) (*models.DashboardPreferences, error)
+	Save(ctx context.Context, p *models.DashboardPreferences) error
+	GetViews(ctx context.Context, projectID, owner string) ([]models.DashboardView, error)
+	GetView(ctx context.Context, projectID, owner string, id int64) (*models.DashboardView, error)
+	CreateView(ctx context.Context, v *models.DashboardView) error
+	UpdateView(ctx context.Context, v *models.DashboardView) error
+	DeleteView(ctx context.Context, projectID, owner string, id int64) (bool, error)
+	GetNotifications(ctx context.Context, projectID, owner string) (*models.NotificationPreferences, error)
+	GetEnabledNotifications(ctx context.Context) ([]models.NotificationPreferences, error)
+	SaveNotifications(ctx context.Context, p *models.NotificationPreferences) error
+	DeleteNotifications(ctx context.Context, projectID, owner string) (bool, error)
+}
+
+// ProjectRepository handles projects and the project of their resources
+type ProjectRepository interface {
+	GetAll(ctx context.Context) ([]models.Project, error)
+	GetByID(ctx context.Context, id string) (*models.Project, error)
+	GetByToken(ctx context.Context, token string) (*models.Project, error)
+	Create(ctx context.Context, p *models.Project) error
+	Update(ctx context.Context, p *models.Project) error
+	UpdateToken(ctx context.Context, id, token string) error
+	CountResources(ctx context.Context, id string) (int, error)
+	Delete(ctx context.Context, id string) error
+	GetResourceProject(ctx context.Context, kind, id string) (string, error)
+}
+
+// SystemMetricRepository handles system metric data operations
+type SystemMetricRepository interface {
+	Create(ctx context.Context, m *models.SystemMetric) error
+	GetHistory(ctx context.Context, hostID string, since time.Time) ([]models.SystemMetricPoint, error)
+	GetReachability(ctx context.Context, hostID string, since time.Time) ([]models.ReachabilityPoint, error)
+	GetLatestByHost(ctx context.Context, hostID string) (*models.SystemMetric, error)
+	GetRange(ctx context.Context, hostID string, from, to time.Time) ([]models.SystemMetric, error)
+	DeleteOld(ctx context.Context, retention time.Duration) (int64, error)
+}
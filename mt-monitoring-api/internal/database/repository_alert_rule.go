@@ -1,34 +1,48 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
+	"sync/atomic"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// AlertRuleRepository handles alert rule data operations
-type AlertRuleRepository struct{}
+// alertRuleRepository implements AlertRuleRepository on SQLite
+type alertRuleRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+
+	// version counts rule writes so evaluators caching rules know when to
+	// reload them
+	version atomic.Int64
+}
 
 // NewAlertRuleRepository creates a new alert rule repository
-func NewAlertRuleRepository() *AlertRuleRepository {
-	return &AlertRuleRepository{}
+func NewAlertRuleRepository(db *sql.DB, timeout time.Duration) AlertRuleRepository {
+	return &alertRuleRepository{db: db, timeout: timeout}
 }
 
 // alertRuleSelectColumns is the column list for alert rule queries.
 const alertRuleSelectColumns = `id, name, type, host_id, service_id, metric, operator,
-	threshold, duration, severity, is_enabled, cooldown, created_at, updated_at`
+	threshold, duration, severity, is_enabled, cooldown, created_at, updated_at, pattern, log_level,
+	resource_category, slo_objective, slo_latency_target, slo_short_window, slo_long_window, remediation, project_id`
 
 // scanAlertRuleFields scans alert rule columns into an AlertRule struct from a generic scanner.
 func scanAlertRuleFields(scan func(dest ...interface{}) error) (models.AlertRule, error) {
 	var r models.AlertRule
 	var isEnabled int
-	var hostID, serviceID sql.NullString
+	var hostID, serviceID, pattern, logLevel, resourceCategory, remediation sql.NullString
+	var objective sql.NullFloat64
+	var latencyTarget, shortWindow, longWindow sql.NullInt64
 
 	err := scan(
 		&r.ID, &r.Name, &r.Type, &hostID, &serviceID, &r.Metric, &r.Operator,
 		&r.Threshold, &r.Duration, &r.Severity, &isEnabled, &r.Cooldown,
-		&r.CreatedAt, &r.UpdatedAt,
+		&r.CreatedAt, &r.UpdatedAt, &pattern, &logLevel, &resourceCategory,
+		&objective, &latencyTarget, &shortWindow, &longWindow, &remediation, &r.ProjectID,
 	)
 	if err != nil {
 		return r, err
@@ -43,12 +57,37 @@ func scanAlertRuleFields(scan func(dest ...interface{}) error) (models.AlertRule
 		s := serviceID.String
 		r.ServiceID = &s
 	}
+	r.Pattern = pattern.String
+	r.LogLevel = models.LogLevel(logLevel.String)
+	r.ResourceCategory = models.HostResourceCategory(resourceCategory.String)
+	r.Objective = objective.Float64
+	r.LatencyTarget = int(latencyTarget.Int64)
+	r.ShortWindow = int(shortWindow.Int64)
+	r.LongWindow = int(longWindow.Int64)
+	if remediation.String != "" {
+		var action models.Remediation
+		if err := json.Unmarshal([]byte(remediation.String), &action); err == nil {
+			r.Remediation = &action
+		}
+	}
 	return r, nil
 }
 
+// encodeRemediation encodes the remediation column, NULL for none
+func encodeRemediation(action *models.Remediation) (interface{}, error) {
+	if action == nil || action.Type == "" {
+		return nil, nil
+	}
+	data, err := json.Marshal(action)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 // loadChannelIDs loads channel IDs for a given rule.
-func loadChannelIDs(ruleID string) ([]string, error) {
-	rows, err := DB.Query(`SELECT channel_id FROM alert_rule_channels WHERE rule_id = ?`, ruleID)
+func (r *alertRuleRepository) loadChannelIDs(ctx context.Context, ruleID string) ([]string, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT channel_id FROM alert_rule_channels WHERE rule_id = ?`, ruleID)
 	if err != nil {
 		return nil, err
 	}
@@ -66,9 +105,12 @@ func loadChannelIDs(ruleID string) ([]string, error) {
 }
 
 // GetAll returns all alert rules with their channel IDs
-func (r *AlertRuleRepository) GetAll() ([]models.AlertRule, error) {
-	rows, err := DB.Query(`
-		SELECT ` + alertRuleSelectColumns + `
+func (r *alertRuleRepository) GetAll(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+alertRuleSelectColumns+`
 		FROM alert_rules
 		ORDER BY created_at DESC
 	`)
@@ -88,15 +130,18 @@ func (r *AlertRuleRepository) GetAll() ([]models.AlertRule, error) {
 
 	// Load channel IDs after closing the rows iterator to avoid SQLite deadlock
 	for i := range rules {
-		chIDs, _ := loadChannelIDs(rules[i].ID)
+		chIDs, _ := r.loadChannelIDs(ctx, rules[i].ID)
 		rules[i].ChannelIDs = chIDs
 	}
 	return rules, nil
 }
 
 // GetByID returns an alert rule by ID with channel IDs
-func (r *AlertRuleRepository) GetByID(id string) (*models.AlertRule, error) {
-	row := DB.QueryRow(`
+func (r *alertRuleRepository) GetByID(ctx context.Context, id string) (*models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, `
 		SELECT `+alertRuleSelectColumns+`
 		FROM alert_rules WHERE id = ?
 	`, id)
@@ -109,21 +154,28 @@ func (r *AlertRuleRepository) GetByID(id string) (*models.AlertRule, error) {
 		return nil, err
 	}
 
-	chIDs, _ := loadChannelIDs(rule.ID)
+	chIDs, _ := r.loadChannelIDs(ctx, rule.ID)
 	rule.ChannelIDs = chIDs
 	return &rule, nil
 }
 
-// GetEnabledByHostID returns enabled resource rules for a given host (or global rules).
+// GetEnabledByHostID returns enabled resource rules for a given host (or global rules
+// of its project), leaving out rules for another resource category than the host's.
 // This is the hot path used by the RuleEvaluator on every metric collection.
-func (r *AlertRuleRepository) GetEnabledByHostID(hostID string) ([]models.AlertRule, error) {
-	rows, err := DB.Query(`
+func (r *alertRuleRepository) GetEnabledByHostID(ctx context.Context, hostID string) ([]models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT `+alertRuleSelectColumns+`
 		FROM alert_rules
 		WHERE is_enabled = 1 AND type = 'resource'
 		  AND (host_id = ? OR host_id IS NULL OR host_id = '')
+		  AND project_id = (SELECT project_id FROM hosts WHERE id = ?)
+		  AND (resource_category IS NULL OR resource_category = ''
+		       OR resource_category = (SELECT COALESCE(NULLIF(resource_category, ''), 'server') FROM hosts WHERE id = ?))
 		ORDER BY severity DESC
-	`, hostID)
+	`, hostID, hostID, hostID)
 	if err != nil {
 		return nil, err
 	}
@@ -140,22 +192,95 @@ func (r *AlertRuleRepository) GetEnabledByHostID(hostID string) ([]models.AlertR
 
 	// Load channel IDs after closing the rows iterator to avoid SQLite deadlock
 	for i := range rules {
-		chIDs, _ := loadChannelIDs(rules[i].ID)
+		chIDs, _ := r.loadChannelIDs(ctx, rules[i].ID)
 		rules[i].ChannelIDs = chIDs
 	}
 	return rules, nil
 }
 
-// GetEnabledByServiceID returns enabled service rules for a given service (or global rules).
+// GetEnabledByServiceID returns enabled service rules for a given service (or global
+// rules of its project).
 // This is the hot path used by the ServiceRuleEvaluator on every service check.
-func (r *AlertRuleRepository) GetEnabledByServiceID(serviceID string) ([]models.AlertRule, error) {
-	rows, err := DB.Query(`
+func (r *alertRuleRepository) GetEnabledByServiceID(ctx context.Context, serviceID string) ([]models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT `+alertRuleSelectColumns+`
 		FROM alert_rules
 		WHERE is_enabled = 1 AND type = 'service'
 		  AND (service_id = ? OR service_id IS NULL OR service_id = '')
+		  AND project_id = (SELECT project_id FROM services WHERE id = ?)
+		ORDER BY severity DESC
+	`, serviceID, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRuleFields(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	// Load channel IDs after closing the rows iterator to avoid SQLite deadlock
+	for i := range rules {
+		chIDs, _ := r.loadChannelIDs(ctx, rules[i].ID)
+		rules[i].ChannelIDs = chIDs
+	}
+	return rules, nil
+}
+
+// GetEnabledLogRules returns the enabled log rules of all services.
+// The LogRuleEvaluator caches them until Version changes.
+func (r *alertRuleRepository) GetEnabledLogRules(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+alertRuleSelectColumns+`
+		FROM alert_rules
+		WHERE is_enabled = 1 AND type = 'log'
+		ORDER BY severity DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []models.AlertRule
+	for rows.Next() {
+		rule, err := scanAlertRuleFields(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	// Load channel IDs after closing the rows iterator to avoid SQLite deadlock
+	for i := range rules {
+		chIDs, _ := r.loadChannelIDs(ctx, rules[i].ID)
+		rules[i].ChannelIDs = chIDs
+	}
+	return rules, nil
+}
+
+// GetEnabledSLORules returns the enabled SLO rules of all services, for
+// the SLOEvaluator's periodic run.
+func (r *alertRuleRepository) GetEnabledSLORules(ctx context.Context) ([]models.AlertRule, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+alertRuleSelectColumns+`
+		FROM alert_rules
+		WHERE is_enabled = 1 AND type = 'slo'
 		ORDER BY severity DESC
-	`, serviceID)
+	`)
 	if err != nil {
 		return nil, err
 	}
@@ -172,34 +297,52 @@ func (r *AlertRuleRepository) GetEnabledByServiceID(serviceID string) ([]models.
 
 	// Load channel IDs after closing the rows iterator to avoid SQLite deadlock
 	for i := range rules {
-		chIDs, _ := loadChannelIDs(rules[i].ID)
+		chIDs, _ := r.loadChannelIDs(ctx, rules[i].ID)
 		rules[i].ChannelIDs = chIDs
 	}
 	return rules, nil
 }
 
+// Version returns a counter that changes whenever rules are created,
+// updated, deleted, enabled or disabled through this repository
+func (r *alertRuleRepository) Version() int64 {
+	return r.version.Load()
+}
+
 // Create creates a new alert rule with channel mappings in a transaction.
-func (r *AlertRuleRepository) Create(rule *models.AlertRule) error {
-	return Transaction(func(tx *sql.Tx) error {
+func (r *alertRuleRepository) Create(ctx context.Context, rule *models.AlertRule) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	defer r.version.Add(1)
+
+	remediation, err := encodeRemediation(rule.Remediation)
+	if err != nil {
+		return err
+	}
+	rule.ProjectID = projectOrDefault(rule.ProjectID)
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
 		isEnabled := 0
 		if rule.IsEnabled {
 			isEnabled = 1
 		}
 
-		_, err := tx.Exec(`
+		_, err := tx.ExecContext(ctx, `
 			INSERT INTO alert_rules (id, name, type, host_id, service_id, metric, operator,
 			                         threshold, duration, severity, is_enabled, cooldown,
-			                         created_at, updated_at)
-			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			                         created_at, updated_at, pattern, log_level, resource_category,
+			                         slo_objective, slo_latency_target, slo_short_window, slo_long_window, remediation, project_id)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`, rule.ID, rule.Name, rule.Type, rule.HostID, rule.ServiceID,
 			rule.Metric, rule.Operator, rule.Threshold, rule.Duration,
-			rule.Severity, isEnabled, rule.Cooldown, rule.CreatedAt, rule.UpdatedAt)
+			rule.Severity, isEnabled, rule.Cooldown, rule.CreatedAt, rule.UpdatedAt,
+			rule.Pattern, string(rule.LogLevel), string(rule.ResourceCategory),
+			rule.Objective, rule.LatencyTarget, rule.ShortWindow, rule.LongWindow, remediation, rule.ProjectID)
 		if err != nil {
 			return err
 		}
 
 		for _, chID := range rule.ChannelIDs {
-			if _, err := tx.Exec(`INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`,
+			if _, err := tx.ExecContext(ctx, `INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`,
 				rule.ID, chID); err != nil {
 				return err
 			}
@@ -209,8 +352,12 @@ func (r *AlertRuleRepository) Create(rule *models.AlertRule) error {
 }
 
 // Update applies partial updates to an alert rule and replaces channel mappings.
-func (r *AlertRuleRepository) Update(id string, req *models.AlertRuleUpdateRequest) error {
-	return Transaction(func(tx *sql.Tx) error {
+func (r *alertRuleRepository) Update(ctx context.Context, id string, req *models.AlertRuleUpdateRequest) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	defer r.version.Add(1)
+
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
 		// Build dynamic SET clause
 		setClauses := []string{}
 		args := []interface{}{}
@@ -259,6 +406,42 @@ func (r *AlertRuleRepository) Update(id string, req *models.AlertRuleUpdateReque
 			setClauses = append(setClauses, "cooldown = ?")
 			args = append(args, *req.Cooldown)
 		}
+		if req.Pattern != nil {
+			setClauses = append(setClauses, "pattern = ?")
+			args = append(args, *req.Pattern)
+		}
+		if req.LogLevel != nil {
+			setClauses = append(setClauses, "log_level = ?")
+			args = append(args, string(*req.LogLevel))
+		}
+		if req.ResourceCategory != nil {
+			setClauses = append(setClauses, "resource_category = ?")
+			args = append(args, string(*req.ResourceCategory))
+		}
+		if req.Objective != nil {
+			setClauses = append(setClauses, "slo_objective = ?")
+			args = append(args, *req.Objective)
+		}
+		if req.LatencyTarget != nil {
+			setClauses = append(setClauses, "slo_latency_target = ?")
+			args = append(args, *req.LatencyTarget)
+		}
+		if req.ShortWindow != nil {
+			setClauses = append(setClauses, "slo_short_window = ?")
+			args = append(args, *req.ShortWindow)
+		}
+		if req.LongWindow != nil {
+			setClauses = append(setClauses, "slo_long_window = ?")
+			args = append(args, *req.LongWindow)
+		}
+		if req.Remediation != nil {
+			remediation, err := encodeRemediation(req.Remediation)
+			if err != nil {
+				return err
+			}
+			setClauses = append(setClauses, "remediation = ?")
+			args = append(args, remediation)
+		}
 
 		// Always update updated_at
 		setClauses = append(setClauses, "updated_at = ?")
@@ -267,18 +450,18 @@ func (r *AlertRuleRepository) Update(id string, req *models.AlertRuleUpdateReque
 
 		if len(setClauses) > 1 { // at least updated_at + one field
 			query := "UPDATE alert_rules SET " + joinStrings(setClauses, ", ") + " WHERE id = ?"
-			if _, err := tx.Exec(query, args...); err != nil {
+			if _, err := tx.ExecContext(ctx, query, args...); err != nil {
 				return err
 			}
 		}
 
 		// Replace channel mappings if provided
 		if req.ChannelIDs != nil {
-			if _, err := tx.Exec(`DELETE FROM alert_rule_channels WHERE rule_id = ?`, id); err != nil {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM alert_rule_channels WHERE rule_id = ?`, id); err != nil {
 				return err
 			}
 			for _, chID := range *req.ChannelIDs {
-				if _, err := tx.Exec(`INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`,
+				if _, err := tx.ExecContext(ctx, `INSERT INTO alert_rule_channels (rule_id, channel_id) VALUES (?, ?)`,
 					id, chID); err != nil {
 					return err
 				}
@@ -290,18 +473,26 @@ func (r *AlertRuleRepository) Update(id string, req *models.AlertRuleUpdateReque
 }
 
 // Delete deletes an alert rule (CASCADE removes channel mappings).
-func (r *AlertRuleRepository) Delete(id string) error {
-	_, err := DB.Exec("DELETE FROM alert_rules WHERE id = ?", id)
+func (r *alertRuleRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	defer r.version.Add(1)
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM alert_rules WHERE id = ?", id)
 	return err
 }
 
 // SetEnabled updates the is_enabled flag for an alert rule.
-func (r *AlertRuleRepository) SetEnabled(id string, isEnabled bool) error {
+func (r *alertRuleRepository) SetEnabled(ctx context.Context, id string, isEnabled bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+	defer r.version.Add(1)
+
 	enabled := 0
 	if isEnabled {
 		enabled = 1
 	}
-	_, err := DB.Exec(`UPDATE alert_rules SET is_enabled = ?, updated_at = ? WHERE id = ?`,
+	_, err := r.db.ExecContext(ctx, `UPDATE alert_rules SET is_enabled = ?, updated_at = ? WHERE id = ?`,
 		enabled, time.Now(), id)
 	return err
 }
@@ -1,108 +1,107 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// AlertRuleStateRepository handles alert rule state persistence
-type AlertRuleStateRepository struct{}
+// alertRuleStateColumns are the columns read by scanAlertRuleState
+const alertRuleStateColumns = `rule_id, target_type, target_id, breach_count, last_alerted_at, is_alerting, updated_at,
+	value, breach_started_at`
+
+// alertRuleStateRepository implements AlertRuleStateRepository on SQLite
+type alertRuleStateRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewAlertRuleStateRepository creates a new repository
-func NewAlertRuleStateRepository() *AlertRuleStateRepository {
-	return &AlertRuleStateRepository{}
+func NewAlertRuleStateRepository(db *sql.DB, timeout time.Duration) AlertRuleStateRepository {
+	return &alertRuleStateRepository{db: db, timeout: timeout}
 }
 
-// GetState retrieves the state for a specific rule and host
-func (r *AlertRuleStateRepository) GetState(ruleID, hostID string) (*models.AlertRuleState, error) {
+// GetState retrieves the state for a specific rule and host or service
+func (r *alertRuleStateRepository) GetState(ctx context.Context, ruleID, targetType, targetID string) (*models.AlertRuleState, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT rule_id, host_id, breach_count, last_alerted_at, is_alerting, updated_at
+		SELECT ` + alertRuleStateColumns + `
 		FROM alert_rule_state
-		WHERE rule_id = ? AND host_id = ?
+		WHERE rule_id = ? AND target_type = ? AND target_id = ?
 	`
 
-	var state models.AlertRuleState
-	var isAlerting int
-	var lastAlertedAt sql.NullTime
-
-	err := DB.QueryRow(query, ruleID, hostID).Scan(
-		&state.RuleID,
-		&state.HostID,
-		&state.BreachCount,
-		&lastAlertedAt,
-		&isAlerting,
-		&state.UpdatedAt,
-	)
-
-	if err != nil {
-		if err == sql.ErrNoRows {
-			return nil, nil
-		}
-		return nil, err
+	state, err := scanAlertRuleState(r.db.QueryRowContext(ctx, query, ruleID, targetType, targetID).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
 	}
-
-	state.IsAlerting = isAlerting == 1
-	if lastAlertedAt.Valid {
-		state.LastAlertedAt = &lastAlertedAt.Time
-	}
-
-	return &state, nil
+	return state, err
 }
 
-// GetAllByRule retrieves all states for a specific rule (across all hosts)
-func (r *AlertRuleStateRepository) GetAllByRule(ruleID string) ([]models.AlertRuleState, error) {
+// GetAllByRule retrieves all states for a specific rule (across all targets)
+func (r *alertRuleStateRepository) GetAllByRule(ctx context.Context, ruleID string) ([]models.AlertRuleState, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT rule_id, host_id, breach_count, last_alerted_at, is_alerting, updated_at
+		SELECT ` + alertRuleStateColumns + `
 		FROM alert_rule_state
 		WHERE rule_id = ?
 	`
 
-	rows, err := DB.Query(query, ruleID)
+	rows, err := r.db.QueryContext(ctx, query, ruleID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var states []models.AlertRuleState
-	for rows.Next() {
-		var state models.AlertRuleState
-		var isAlerting int
-		var lastAlertedAt sql.NullTime
-
-		err := rows.Scan(
-			&state.RuleID,
-			&state.HostID,
-			&state.BreachCount,
-			&lastAlertedAt,
-			&isAlerting,
-			&state.UpdatedAt,
-		)
-		if err != nil {
-			return nil, err
-		}
+	return scanAlertRuleStates(rows)
+}
 
-		state.IsAlerting = isAlerting == 1
-		if lastAlertedAt.Valid {
-			state.LastAlertedAt = &lastAlertedAt.Time
-		}
+// GetAllByType retrieves the states of all hosts or all services
+func (r *alertRuleStateRepository) GetAllByType(ctx context.Context, targetType string) ([]models.AlertRuleState, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
 
-		states = append(states, state)
+	query := `
+		SELECT ` + alertRuleStateColumns + `
+		FROM alert_rule_state
+		WHERE target_type = ?
+		ORDER BY updated_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, targetType)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return states, nil
+	return scanAlertRuleStates(rows)
 }
 
-// GetAll retrieves all alert rule states
-func (r *AlertRuleStateRepository) GetAll() ([]models.AlertRuleState, error) {
+// GetActive retrieves the states in breach or alerting of enabled rules on
+// existing, active hosts and services, with the name, severity and project of their rule and the
+// name of their target, the ones alerting first
+func (r *alertRuleStateRepository) GetActive(ctx context.Context) ([]models.AlertRuleState, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		SELECT rule_id, host_id, breach_count, last_alerted_at, is_alerting, updated_at
-		FROM alert_rule_state
-		ORDER BY updated_at DESC
+		SELECT s.rule_id, s.target_type, s.target_id, s.breach_count, s.last_alerted_at, s.is_alerting, s.updated_at,
+			s.value, s.breach_started_at, r.name, r.severity, r.project_id, COALESCE(h.name, sv.name, '')
+		FROM alert_rule_state s
+		JOIN alert_rules r ON r.id = s.rule_id
+		LEFT JOIN hosts h ON s.target_type = 'host' AND h.id = s.target_id
+		LEFT JOIN services sv ON s.target_type = 'service' AND sv.id = s.target_id
+		WHERE (s.breach_count > 0 OR s.is_alerting = 1) AND r.is_enabled = 1
+			AND (h.is_active = 1 OR sv.is_active = 1 OR (s.target_type = 'host' AND s.target_id = 'local'))
+		ORDER BY s.is_alerting DESC, s.updated_at DESC
 	`
 
-	rows, err := DB.Query(query)
+	rows, err := r.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -110,43 +109,37 @@ func (r *AlertRuleStateRepository) GetAll() ([]models.AlertRuleState, error) {
 
 	var states []models.AlertRuleState
 	for rows.Next() {
-		var state models.AlertRuleState
-		var isAlerting int
-		var lastAlertedAt sql.NullTime
-
-		err := rows.Scan(
-			&state.RuleID,
-			&state.HostID,
-			&state.BreachCount,
-			&lastAlertedAt,
-			&isAlerting,
-			&state.UpdatedAt,
-		)
+		var name string
+		var severity models.AlertSeverity
+		var projectID, targetName string
+		state, err := scanAlertRuleState(func(dest ...interface{}) error {
+			return rows.Scan(append(dest, &name, &severity, &projectID, &targetName)...)
+		})
 		if err != nil {
 			return nil, err
 		}
-
-		state.IsAlerting = isAlerting == 1
-		if lastAlertedAt.Valid {
-			state.LastAlertedAt = &lastAlertedAt.Time
-		}
-
-		states = append(states, state)
+		state.RuleName, state.Severity, state.ProjectID, state.TargetName = name, severity, projectID, targetName
+		states = append(states, *state)
 	}
-
-	return states, nil
+	return states, rows.Err()
 }
 
 // SaveState creates or updates the state
-func (r *AlertRuleStateRepository) SaveState(state *models.AlertRuleState) error {
+func (r *alertRuleStateRepository) SaveState(ctx context.Context, state *models.AlertRuleState) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO alert_rule_state (rule_id, host_id, breach_count, last_alerted_at, is_alerting, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-		ON CONFLICT(rule_id, host_id) DO UPDATE SET
+		INSERT INTO alert_rule_state (rule_id, target_type, target_id, breach_count, last_alerted_at, is_alerting, updated_at,
+			value, breach_started_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(rule_id, target_type, target_id) DO UPDATE SET
 			breach_count = excluded.breach_count,
 			last_alerted_at = excluded.last_alerted_at,
 			is_alerting = excluded.is_alerting,
-			updated_at = excluded.updated_at
+			updated_at = excluded.updated_at,
+			value = excluded.value,
+			breach_started_at = excluded.breach_started_at
 	`
 
 	isAlerting := 0
@@ -156,48 +149,62 @@ func (r *AlertRuleStateRepository) SaveState(state *models.AlertRuleState) error
 
 	state.UpdatedAt = time.Now()
 
-	_, err := DB.Exec(query,
+	_, err := r.db.ExecContext(ctx, query,
 		state.RuleID,
-		state.HostID,
+		state.TargetType,
+		state.TargetID,
 		state.BreachCount,
 		state.LastAlertedAt,
 		isAlerting,
 		state.UpdatedAt,
+		state.Value,
+		state.BreachStartedAt,
 	)
 	return err
 }
 
-// IncrementBreach increments the breach count for a rule+host
-func (r *AlertRuleStateRepository) IncrementBreach(ruleID, hostID string) error {
+// IncrementBreach increments the breach count for a rule and target
+func (r *alertRuleStateRepository) IncrementBreach(ctx context.Context, ruleID, targetType, targetID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO alert_rule_state (rule_id, host_id, breach_count, updated_at)
-		VALUES (?, ?, 1, ?)
-		ON CONFLICT(rule_id, host_id) DO UPDATE SET
+		INSERT INTO alert_rule_state (rule_id, target_type, target_id, breach_count, breach_started_at, updated_at)
+		VALUES (?, ?, ?, 1, ?, ?)
+		ON CONFLICT(rule_id, target_type, target_id) DO UPDATE SET
 			breach_count = breach_count + 1,
-			updated_at = ?
+			breach_started_at = COALESCE(breach_started_at, excluded.breach_started_at),
+			updated_at = excluded.updated_at
 	`
 	now := time.Now()
-	_, err := DB.Exec(query, ruleID, hostID, now, now)
+	_, err := r.db.ExecContext(ctx, query, ruleID, targetType, targetID, now, now)
 	return err
 }
 
 // ResetBreach resets the breach count to 0
-func (r *AlertRuleStateRepository) ResetBreach(ruleID, hostID string) error {
+func (r *alertRuleStateRepository) ResetBreach(ctx context.Context, ruleID, targetType, targetID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
-		INSERT INTO alert_rule_state (rule_id, host_id, breach_count, is_alerting, updated_at)
-		VALUES (?, ?, 0, 0, ?)
-		ON CONFLICT(rule_id, host_id) DO UPDATE SET
+		INSERT INTO alert_rule_state (rule_id, target_type, target_id, breach_count, is_alerting, updated_at)
+		VALUES (?, ?, ?, 0, 0, ?)
+		ON CONFLICT(rule_id, target_type, target_id) DO UPDATE SET
 			breach_count = 0,
 			is_alerting = 0,
+			breach_started_at = NULL,
 			updated_at = ?
 	`
 	now := time.Now()
-	_, err := DB.Exec(query, ruleID, hostID, now, now)
+	_, err := r.db.ExecContext(ctx, query, ruleID, targetType, targetID, now, now)
 	return err
 }
 
 // SetAlerting sets the alerting state and last alerted time
-func (r *AlertRuleStateRepository) SetAlerting(ruleID, hostID string, isAlerting bool) error {
+func (r *alertRuleStateRepository) SetAlerting(ctx context.Context, ruleID, targetType, targetID string, isAlerting bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var lastAlerted *time.Time
 	if isAlerting {
 		now := time.Now()
@@ -205,9 +212,9 @@ func (r *AlertRuleStateRepository) SetAlerting(ruleID, hostID string, isAlerting
 	}
 
 	query := `
-		INSERT INTO alert_rule_state (rule_id, host_id, is_alerting, last_alerted_at, updated_at)
-		VALUES (?, ?, ?, ?, ?)
-		ON CONFLICT(rule_id, host_id) DO UPDATE SET
+		INSERT INTO alert_rule_state (rule_id, target_type, target_id, is_alerting, last_alerted_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(rule_id, target_type, target_id) DO UPDATE SET
 			is_alerting = excluded.is_alerting,
 			last_alerted_at = excluded.last_alerted_at,
 			updated_at = excluded.updated_at
@@ -219,27 +226,97 @@ func (r *AlertRuleStateRepository) SetAlerting(ruleID, hostID string, isAlerting
 	}
 
 	now := time.Now()
-	_, err := DB.Exec(query, ruleID, hostID, alertingInt, lastAlerted, now)
+	_, err := r.db.ExecContext(ctx, query, ruleID, targetType, targetID, alertingInt, lastAlerted, now)
 	return err
 }
 
 // DeleteByRule deletes all states for a specific rule
-func (r *AlertRuleStateRepository) DeleteByRule(ruleID string) error {
+func (r *alertRuleStateRepository) DeleteByRule(ctx context.Context, ruleID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `DELETE FROM alert_rule_state WHERE rule_id = ?`
-	_, err := DB.Exec(query, ruleID)
+	_, err := r.db.ExecContext(ctx, query, ruleID)
 	return err
 }
 
-// DeleteByHost deletes all states for a specific host
-func (r *AlertRuleStateRepository) DeleteByHost(hostID string) error {
-	query := `DELETE FROM alert_rule_state WHERE host_id = ?`
-	_, err := DB.Exec(query, hostID)
+// DeleteByTarget deletes all states for a specific host or service
+func (r *alertRuleStateRepository) DeleteByTarget(ctx context.Context, targetType, targetID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM alert_rule_state WHERE target_type = ? AND target_id = ?`
+	_, err := r.db.ExecContext(ctx, query, targetType, targetID)
 	return err
 }
 
+// DeleteOrphans deletes the states of hosts and services that no longer
+// exist. States of the local host are kept, as it may have no row in hosts.
+func (r *alertRuleStateRepository) DeleteOrphans(ctx context.Context) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM alert_rule_state
+		WHERE (target_type = 'host' AND target_id != 'local' AND target_id NOT IN (SELECT id FROM hosts))
+			OR (target_type = 'service' AND target_id NOT IN (SELECT id FROM services))
+	`)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // Delete deletes a specific state
-func (r *AlertRuleStateRepository) Delete(ruleID, hostID string) error {
-	query := `DELETE FROM alert_rule_state WHERE rule_id = ? AND host_id = ?`
-	_, err := DB.Exec(query, ruleID, hostID)
+func (r *alertRuleStateRepository) Delete(ctx context.Context, ruleID, targetType, targetID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	query := `DELETE FROM alert_rule_state WHERE rule_id = ? AND target_type = ? AND target_id = ?`
+	_, err := r.db.ExecContext(ctx, query, ruleID, targetType, targetID)
 	return err
 }
+
+// scanAlertRuleStates reads every row of alertRuleStateColumns
+func scanAlertRuleStates(rows *sql.Rows) ([]models.AlertRuleState, error) {
+	var states []models.AlertRuleState
+	for rows.Next() {
+		state, err := scanAlertRuleState(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		states = append(states, *state)
+	}
+	return states, rows.Err()
+}
+
+// scanAlertRuleState reads a row of alertRuleStateColumns
+func scanAlertRuleState(scan func(dest ...interface{}) error) (*models.AlertRuleState, error) {
+	var state models.AlertRuleState
+	var isAlerting int
+	var lastAlertedAt, breachStartedAt sql.NullTime
+
+	err := scan(
+		&state.RuleID,
+		&state.TargetType,
+		&state.TargetID,
+		&state.BreachCount,
+		&lastAlertedAt,
+		&isAlerting,
+		&state.UpdatedAt,
+		&state.Value,
+		&breachStartedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	state.IsAlerting = isAlerting == 1
+	if lastAlertedAt.Valid {
+		state.LastAlertedAt = &lastAlertedAt.Time
+	}
+	if breachStartedAt.Valid {
+		state.BreachStartedAt = &breachStartedAt.Time
+	}
+	return &state, nil
+}
@@ -0,0 +1,89 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// checkDetailsRepository implements CheckDetailsRepository on SQLite
+type checkDetailsRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewCheckDetailsRepository creates a new check details repository
+func NewCheckDetailsRepository(db *sql.DB, timeout time.Duration) CheckDetailsRepository {
+	return &checkDetailsRepository{db: db, timeout: timeout}
+}
+
+// Create stores diagnostics for a metric
+func (r *checkDetailsRepository) Create(ctx context.Context, d *models.CheckDetails) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var tlsJSON sql.NullString
+	if d.TLS != nil {
+		data, err := json.Marshal(d.TLS)
+		if err != nil {
+			return err
+		}
+		tlsJSON = sql.NullString{String: string(data), Valid: true}
+	}
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO check_details (metric_id, status_line, body_snippet, remote_addr, tls,
+		                           dns_ms, connect_ms, tls_ms, ttfb_ms, protocol)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, d.MetricID, d.StatusLine, d.BodySnippet, d.RemoteAddr, tlsJSON,
+		d.DNSMs, d.ConnectMs, d.TLSMs, d.TTFBMs, d.Protocol)
+	return err
+}
+
+// GetByMetricID returns diagnostics for a metric, or nil if none were captured
+func (r *checkDetailsRepository) GetByMetricID(ctx context.Context, metricID int64) (*models.CheckDetails, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var d models.CheckDetails
+	var statusLine, body, remoteAddr, tlsJSON, protocol sql.NullString
+	var dns, connect, tlsMs, ttfb sql.NullInt64
+	err := r.db.QueryRowContext(ctx, `
+		SELECT metric_id, status_line, body_snippet, remote_addr, tls, dns_ms, connect_ms, tls_ms, ttfb_ms, protocol
+		FROM check_details WHERE metric_id = ?
+	`, metricID).Scan(&d.MetricID, &statusLine, &body, &remoteAddr, &tlsJSON, &dns, &connect, &tlsMs, &ttfb, &protocol)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	d.StatusLine = statusLine.String
+	d.BodySnippet = body.String
+	d.RemoteAddr = remoteAddr.String
+	d.Protocol = protocol.String
+	if tlsJSON.Valid && tlsJSON.String != "" {
+		var t models.TLSDetails
+		if err := json.Unmarshal([]byte(tlsJSON.String), &t); err == nil {
+			d.TLS = &t
+		}
+	}
+	d.DNSMs = nullIntPtr(dns)
+	d.ConnectMs = nullIntPtr(connect)
+	d.TLSMs = nullIntPtr(tlsMs)
+	d.TTFBMs = nullIntPtr(ttfb)
+
+	return &d, nil
+}
+
+func nullIntPtr(n sql.NullInt64) *int {
+	if !n.Valid {
+		return nil
+	}
+	v := int(n.Int64)
+	return &v
+}
@@ -0,0 +1,82 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// clusterRepository implements ClusterRepository on SQLite
+type clusterRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewClusterRepository creates a new cluster repository
+func NewClusterRepository(db *sql.DB, timeout time.Duration) ClusterRepository {
+	return &clusterRepository{db: db, timeout: timeout}
+}
+
+// Renew stores the lease of a node, registering the node on its first
+// renewal
+func (r *clusterRepository) Renew(ctx context.Context, node *models.ClusterNode) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO cluster_nodes (node_id, address, started_at, lease_expires_at) VALUES (?, ?, ?, ?)
+		ON CONFLICT(node_id) DO UPDATE SET
+			address = excluded.address,
+			started_at = excluded.started_at,
+			lease_expires_at = excluded.lease_expires_at
+	`, node.ID, node.Address, node.StartedAt.UTC(), node.LeaseExpiresAt.UTC())
+	return err
+}
+
+// GetAll returns every registered node, expired or not, oldest first
+func (r *clusterRepository) GetAll(ctx context.Context) ([]models.ClusterNode, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT node_id, address, started_at, lease_expires_at
+		FROM cluster_nodes ORDER BY started_at, node_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var nodes []models.ClusterNode
+	for rows.Next() {
+		var n models.ClusterNode
+		if err := rows.Scan(&n.ID, &n.Address, &n.StartedAt, &n.LeaseExpiresAt); err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
+// Delete removes a node, releasing its services and hosts at once
+func (r *clusterRepository) Delete(ctx context.Context, nodeID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `DELETE FROM cluster_nodes WHERE node_id = ?`, nodeID)
+	return err
+}
+
+// DeleteExpired removes the nodes whose lease expired before the given time
+func (r *clusterRepository) DeleteExpired(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM cluster_nodes WHERE lease_expires_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
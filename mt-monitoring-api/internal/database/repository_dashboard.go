@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// dashboardRepository implements DashboardRepository on SQLite
+type dashboardRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewDashboardRepository creates a new dashboard repository
+func NewDashboardRepository(db *sql.DB, timeout time.Duration) DashboardRepository {
+	return &dashboardRepository{db: db, timeout: timeout}
+}
+
+// GetSummary computes the dashboard KPIs with grouped aggregate queries:
+// service counts by latest status, mean uptime and response time since
+// now-duration, active incidents, and the slowest services.
+func (r *dashboardRepository) GetSummary(ctx context.Context, duration time.Duration, slowest int) (*models.DashboardSummary, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	since := time.Now().Add(-duration)
+	summary := &models.DashboardSummary{SlowestServices: []models.ServiceLatency{}}
+
+	// The latest status comes from one indexed lookup per service instead of
+	// ranking every metric row
+	var criticalServiceID sql.NullString
+	err := r.db.QueryRowContext(ctx, `
+		WITH svc AS (
+			SELECT s.is_active,
+			       (SELECT status FROM metrics
+			        WHERE metrics.service_id = s.id
+			        ORDER BY checked_at DESC LIMIT 1) AS last_status
+			FROM services s
+		),
+		recent AS (
+			SELECT service_id,
+			       COUNT(*) AS total,
+			       SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) AS success,
+			       AVG(CASE WHEN response_time > 0 THEN response_time END) AS avg_rt
+			FROM metrics
+			WHERE checked_at >= ? AND `+notExcluded+`
+			GROUP BY service_id
+		)
+		SELECT
+			(SELECT COUNT(*) FROM svc),
+			(SELECT COUNT(*) FROM svc WHERE is_active = 1 AND last_status = 'success'),
+			(SELECT COUNT(*) FROM svc WHERE is_active = 1 AND last_status = 'failure'),
+			(SELECT COUNT(*) FROM svc WHERE is_active = 0),
+			(SELECT COALESCE(AVG(avg_rt), 0) FROM recent),
+			(SELECT COALESCE(AVG(100.0 * success / total), 0) FROM recent),
+			(SELECT COUNT(*) FROM incidents WHERE resolved_at IS NULL),
+			(SELECT MIN(service_id) FROM incidents WHERE resolved_at IS NULL)
+	`, since).Scan(
+		&summary.TotalServices,
+		&summary.HealthyServices,
+		&summary.UnhealthyServices,
+		&summary.PausedServices,
+		&summary.AvgResponseTime,
+		&summary.OverallUptime,
+		&summary.CriticalAlerts,
+		&criticalServiceID,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// When exactly one incident is active, surface its service ID so the
+	// frontend can navigate directly to that service's detail page.
+	if summary.CriticalAlerts == 1 {
+		summary.CriticalServiceID = criticalServiceID.String
+	}
+
+	if slowest <= 0 {
+		return summary, nil
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT metrics.service_id, services.name, AVG(metrics.response_time) AS avg_rt, COUNT(*)
+		FROM metrics
+		JOIN services ON services.id = metrics.service_id
+		WHERE metrics.checked_at >= ? AND metrics.response_time > 0 AND `+notExcluded+`
+		GROUP BY metrics.service_id
+		ORDER BY avg_rt DESC
+		LIMIT ?
+	`, since, slowest)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var l models.ServiceLatency
+		if err := rows.Scan(&l.ServiceID, &l.Name, &l.AvgResponseTime, &l.Checks); err != nil {
+			return nil, err
+		}
+		summary.SlowestServices = append(summary.SlowestServices, l)
+	}
+	return summary, rows.Err()
+}
+
+// GetVersion returns the version of the data behind the service, host and
+// dashboard responses, from row counts, the newest IDs and update times,
+// which the indexes answer without scanning metrics
+func (r *dashboardRepository) GetVersion(ctx context.Context) (*models.DataVersion, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var v models.DataVersion
+	err := r.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at), '') || ':' || COALESCE(SUM(ingest_dropped), 0) FROM services)
+				|| ':' || (SELECT COUNT(*) FROM service_channels),
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at), '') FROM hosts),
+			(SELECT COALESCE(MAX(id), 0) FROM metrics),
+			(SELECT COALESCE(MAX(id), 0) FROM system_metrics),
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(id), 0) || ':' || COUNT(resolved_at) FROM incidents),
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(id), 0) || ':' || COUNT(ends_at) FROM service_windows)
+	`).Scan(&v.Services, &v.Hosts, &v.Checks, &v.HostMetrics, &v.Incidents, &v.Windows)
+	if err != nil {
+		return nil, err
+	}
+	return &v, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// externalAlertRepository implements ExternalAlertRepository on SQLite
+type externalAlertRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewExternalAlertRepository creates a new external alert repository
+func NewExternalAlertRepository(db *sql.DB, timeout time.Duration) ExternalAlertRepository {
+	return &externalAlertRepository{db: db, timeout: timeout}
+}
+
+const externalAlertSelectColumns = `fingerprint, alert_name, status, severity, service_id, incident_id,
+	labels, annotations, starts_at, ends_at, updated_at`
+
+func scanExternalAlert(scan func(dest ...interface{}) error) (models.ExternalAlert, error) {
+	var a models.ExternalAlert
+	var severity, serviceID sql.NullString
+	var incidentID sql.NullInt64
+	var labels, annotations string
+	var endsAt sql.NullTime
+
+	err := scan(&a.Fingerprint, &a.AlertName, &a.Status, &severity, &serviceID, &incidentID,
+		&labels, &annotations, &a.StartsAt, &endsAt, &a.UpdatedAt)
+	if err != nil {
+		return a, err
+	}
+
+	a.Severity = severity.String
+	if serviceID.Valid {
+		a.ServiceID = &serviceID.String
+	}
+	if incidentID.Valid {
+		a.IncidentID = &incidentID.Int64
+	}
+	if endsAt.Valid {
+		a.EndsAt = &endsAt.Time
+	}
+	json.Unmarshal([]byte(labels), &a.Labels)
+	json.Unmarshal([]byte(annotations), &a.Annotations)
+	return a, nil
+}
+
+// Get returns the alert with the given fingerprint, nil if it is unknown
+func (r *externalAlertRepository) Get(ctx context.Context, fingerprint string) (*models.ExternalAlert, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, `
+		SELECT `+externalAlertSelectColumns+`
+		FROM external_alerts
+		WHERE fingerprint = ?
+	`, fingerprint)
+	a, err := scanExternalAlert(row.Scan)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &a, nil
+}
+
+// GetByStatus returns the alerts with the given status, newest first.
+// An empty status returns every alert.
+func (r *externalAlertRepository) GetByStatus(ctx context.Context, status string) ([]models.ExternalAlert, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+externalAlertSelectColumns+`
+		FROM external_alerts
+		WHERE ? = '' OR status = ?
+		ORDER BY starts_at DESC
+	`, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	alerts := []models.ExternalAlert{}
+	for rows.Next() {
+		a, err := scanExternalAlert(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		alerts = append(alerts, a)
+	}
+	return alerts, rows.Err()
+}
+
+// Save inserts or replaces the alert with a's fingerprint
+func (r *externalAlertRepository) Save(ctx context.Context, a *models.ExternalAlert) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	labels, err := json.Marshal(a.Labels)
+	if err != nil {
+		return err
+	}
+	annotations, err := json.Marshal(a.Annotations)
+	if err != nil {
+		return err
+	}
+
+	a.UpdatedAt = time.Now()
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO external_alerts (`+externalAlertSelectColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(fingerprint) DO UPDATE SET
+			alert_name = excluded.alert_name,
+			status = excluded.status,
+			severity = excluded.severity,
+			service_id = excluded.service_id,
+			incident_id = excluded.incident_id,
+			labels = excluded.labels,
+			annotations = excluded.annotations,
+			starts_at = excluded.starts_at,
+			ends_at = excluded.ends_at,
+			updated_at = excluded.updated_at
+	`, a.Fingerprint, a.AlertName, a.Status, a.Severity, a.ServiceID, a.IncidentID,
+		string(labels), string(annotations), a.StartsAt, a.EndsAt, a.UpdatedAt)
+	return err
+}
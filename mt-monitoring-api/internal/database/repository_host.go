@@ -1,6 +1,7 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
@@ -8,23 +9,29 @@ import (
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// HostRepository handles host data operations
-type HostRepository struct{}
+// hostRepository implements HostRepository on SQLite
+type hostRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewHostRepository creates a new host repository
-func NewHostRepository() *HostRepository {
-	return &HostRepository{}
+func NewHostRepository(db *sql.DB, timeout time.Duration) HostRepository {
+	return &hostRepository{db: db, timeout: timeout}
 }
 
 // hostSelectColumns is the column list for host queries.
 const hostSelectColumns = `id, name, type, resource_category, ip, port, "group", is_active, description,
-	ssh_user, ssh_port, ssh_auth_type, ssh_key_path, ssh_key, ssh_password, last_error,
-	created_at, updated_at`
+	ssh_user, ssh_port, ssh_auth_type, ssh_key_path, ssh_key, ssh_password, ssh_secret_ref, last_error,
+	collect_interval, store_interval, collector, exporter_url, ownership, project_id, created_at, updated_at`
 
 // GetAll returns all hosts
-func (r *HostRepository) GetAll() ([]models.Host, error) {
-	rows, err := DB.Query(`
-		SELECT ` + hostSelectColumns + `
+func (r *hostRepository) GetAll(ctx context.Context) ([]models.Host, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+hostSelectColumns+`
 		FROM hosts
 		ORDER BY name
 	`)
@@ -45,8 +52,11 @@ func (r *HostRepository) GetAll() ([]models.Host, error) {
 }
 
 // GetByID returns a host by ID
-func (r *HostRepository) GetByID(id string) (*models.Host, error) {
-	row := DB.QueryRow(`
+func (r *hostRepository) GetByID(ctx context.Context, id string) (*models.Host, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx, `
 		SELECT `+hostSelectColumns+`
 		FROM hosts WHERE id = ?
 	`, id)
@@ -62,8 +72,11 @@ func (r *HostRepository) GetByID(id string) (*models.Host, error) {
 }
 
 // GetByType returns hosts by type (local/remote)
-func (r *HostRepository) GetByType(hostType models.HostType) ([]models.Host, error) {
-	rows, err := DB.Query(`
+func (r *hostRepository) GetByType(ctx context.Context, hostType models.HostType) ([]models.Host, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT `+hostSelectColumns+`
 		FROM hosts WHERE type = ?
 		ORDER BY name
@@ -85,9 +98,12 @@ func (r *HostRepository) GetByType(hostType models.HostType) ([]models.Host, err
 }
 
 // GetActive returns all active hosts
-func (r *HostRepository) GetActive() ([]models.Host, error) {
-	rows, err := DB.Query(`
-		SELECT ` + hostSelectColumns + `
+func (r *hostRepository) GetActive(ctx context.Context) ([]models.Host, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+hostSelectColumns+`
 		FROM hosts WHERE is_active = 1
 		ORDER BY name
 	`)
@@ -108,7 +124,10 @@ func (r *HostRepository) GetActive() ([]models.Host, error) {
 }
 
 // Create creates a new host
-func (r *HostRepository) Create(h *models.Host) error {
+func (r *hostRepository) Create(ctx context.Context, h *models.Host) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	isActive := 0
 	if h.IsActive {
 		isActive = 1
@@ -122,20 +141,28 @@ func (r *HostRepository) Create(h *models.Host) error {
 	if err != nil {
 		return err
 	}
+	ownership, err := encodeOwnership(h.Ownership)
+	if err != nil {
+		return err
+	}
+	h.ProjectID = projectOrDefault(h.ProjectID)
 
-	_, err = DB.Exec(`
+	_, err = r.db.ExecContext(ctx, `
 		INSERT INTO hosts (id, name, type, resource_category, ip, port, "group", is_active, description,
-		                    ssh_user, ssh_port, ssh_auth_type, ssh_key_path, ssh_key, ssh_password, last_error,
-		                    created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                    ssh_user, ssh_port, ssh_auth_type, ssh_key_path, ssh_key, ssh_password, ssh_secret_ref, last_error,
+		                    collect_interval, store_interval, collector, exporter_url, ownership, project_id, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, h.ID, h.Name, h.Type, h.ResourceCategory, h.IP, h.Port, h.Group, isActive, h.Description,
-		h.SSHUser, h.SSHPort, h.SSHAuthType, h.SSHKeyPath, encKey, encPassword, h.LastError,
-		h.CreatedAt, h.UpdatedAt)
+		h.SSHUser, h.SSHPort, h.SSHAuthType, h.SSHKeyPath, encKey, encPassword, h.SSHSecretRef, h.LastError,
+		h.CollectInterval, h.StoreInterval, h.Collector, h.ExporterURL, ownership, h.ProjectID, h.CreatedAt, h.UpdatedAt)
 	return err
 }
 
 // Update updates a host
-func (r *HostRepository) Update(h *models.Host) error {
+func (r *hostRepository) Update(ctx context.Context, h *models.Host) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	isActive := 0
 	if h.IsActive {
 		isActive = 1
@@ -149,46 +176,77 @@ func (r *HostRepository) Update(h *models.Host) error {
 	if err != nil {
 		return err
 	}
+	ownership, err := encodeOwnership(h.Ownership)
+	if err != nil {
+		return err
+	}
 
 	h.UpdatedAt = time.Now()
-	_, err = DB.Exec(`
+	_, err = r.db.ExecContext(ctx, `
 		UPDATE hosts SET name = ?, type = ?, resource_category = ?, ip = ?, port = ?, "group" = ?,
 		                 is_active = ?, description = ?,
 		                 ssh_user = ?, ssh_port = ?, ssh_auth_type = ?,
-		                 ssh_key_path = ?, ssh_key = ?, ssh_password = ?,
-		                 last_error = ?, updated_at = ?
+		                 ssh_key_path = ?, ssh_key = ?, ssh_password = ?, ssh_secret_ref = ?,
+		                 last_error = ?, collect_interval = ?, store_interval = ?,
+		                 collector = ?, exporter_url = ?, ownership = ?, updated_at = ?
 		WHERE id = ?
 	`, h.Name, h.Type, h.ResourceCategory, h.IP, h.Port, h.Group, isActive, h.Description,
 		h.SSHUser, h.SSHPort, h.SSHAuthType,
-		h.SSHKeyPath, encKey, encPassword,
-		h.LastError, h.UpdatedAt, h.ID)
+		h.SSHKeyPath, encKey, encPassword, h.SSHSecretRef,
+		h.LastError, h.CollectInterval, h.StoreInterval,
+		h.Collector, h.ExporterURL, ownership, h.UpdatedAt, h.ID)
 	return err
 }
 
 // SetLastError updates the last_error field for a host
-func (r *HostRepository) SetLastError(id string, lastError string) error {
-	_, err := DB.Exec(`UPDATE hosts SET last_error = ?, updated_at = ? WHERE id = ?`,
+func (r *hostRepository) SetLastError(ctx context.Context, id string, lastError string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE hosts SET last_error = ?, updated_at = ? WHERE id = ?`,
 		lastError, time.Now(), id)
 	return err
 }
 
-// Delete deletes a host and its associated metrics
-func (r *HostRepository) Delete(id string) error {
-	// Delete associated system metrics first
-	if _, err := DB.Exec("DELETE FROM system_metrics WHERE host_id = ?", id); err != nil {
+// Delete deletes a host and the metrics, processes, facts, updates and ports
+// collected from it
+func (r *hostRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	// Delete the collected data first
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM system_metrics WHERE host_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM host_facts WHERE host_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM host_updates WHERE host_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM host_ports WHERE host_id = ?", id); err != nil {
+		return err
+	}
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM port_snapshots WHERE host_id = ?", id); err != nil {
 		return err
 	}
-	_, err := DB.Exec("DELETE FROM hosts WHERE id = ?", id)
+	if _, err := r.db.ExecContext(ctx, "DELETE FROM process_metrics WHERE host_id = ?", id); err != nil {
+		return err
+	}
+	_, err := r.db.ExecContext(ctx, "DELETE FROM hosts WHERE id = ?", id)
 	return err
 }
 
 // SetActive sets the is_active flag for a host
-func (r *HostRepository) SetActive(id string, isActive bool) error {
+func (r *hostRepository) SetActive(ctx context.Context, id string, isActive bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	active := 0
 	if isActive {
 		active = 1
 	}
-	_, err := DB.Exec(`UPDATE hosts SET is_active = ?, updated_at = ? WHERE id = ?`,
+	_, err := r.db.ExecContext(ctx, `UPDATE hosts SET is_active = ?, updated_at = ? WHERE id = ?`,
 		active, time.Now(), id)
 	return err
 }
@@ -198,13 +256,13 @@ func scanHostFields(scan func(dest ...interface{}) error) (models.Host, error) {
 	var h models.Host
 	var isActive int
 	var port, sshPort sql.NullInt64
-	var resourceCategory sql.NullString
-	var description, sshUser, sshAuthType, sshKeyPath, sshKey, sshPassword, lastError sql.NullString
+	var resourceCategory, ownership sql.NullString
+	var description, sshUser, sshAuthType, sshKeyPath, sshKey, sshPassword, sshSecretRef, lastError sql.NullString
 
 	err := scan(
 		&h.ID, &h.Name, &h.Type, &resourceCategory, &h.IP, &port, &h.Group, &isActive, &description,
-		&sshUser, &sshPort, &sshAuthType, &sshKeyPath, &sshKey, &sshPassword, &lastError,
-		&h.CreatedAt, &h.UpdatedAt,
+		&sshUser, &sshPort, &sshAuthType, &sshKeyPath, &sshKey, &sshPassword, &sshSecretRef, &lastError,
+		&h.CollectInterval, &h.StoreInterval, &h.Collector, &h.ExporterURL, &ownership, &h.ProjectID, &h.CreatedAt, &h.UpdatedAt,
 	)
 	if err != nil {
 		return h, err
@@ -250,9 +308,13 @@ func scanHostFields(scan func(dest ...interface{}) error) (models.Host, error) {
 			h.SSHPassword = sshPassword.String
 		}
 	}
+	if sshSecretRef.Valid {
+		h.SSHSecretRef = sshSecretRef.String
+	}
 	if lastError.Valid {
 		h.LastError = lastError.String
 	}
+	h.Ownership = decodeOwnership(ownership)
 	h.Status = models.HostStatusUnknown
 	return h, nil
 }
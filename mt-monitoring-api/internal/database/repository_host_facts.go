@@ -0,0 +1,81 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// hostFactsRepository implements HostFactsRepository on SQLite
+type hostFactsRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewHostFactsRepository creates a new host facts repository
+func NewHostFactsRepository(db *sql.DB, timeout time.Duration) HostFactsRepository {
+	return &hostFactsRepository{db: db, timeout: timeout}
+}
+
+// Get returns the facts of a host, nil when none were collected yet
+func (r *hostFactsRepository) Get(ctx context.Context, hostID string) (*models.HostFacts, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	f := models.HostFacts{HostID: hostID}
+	var packages, ports string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT kernel, cpu_model, cpu_cores, virtualization, packages, listening_ports, collected_at
+		FROM host_facts WHERE host_id = ?
+	`, hostID).Scan(&f.Kernel, &f.CPUModel, &f.CPUCores, &f.Virtualization, &packages, &ports, &f.CollectedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	f.Packages = map[string]int{}
+	f.ListeningPorts = []models.ListeningPort{}
+	json.Unmarshal([]byte(packages), &f.Packages)
+	json.Unmarshal([]byte(ports), &f.ListeningPorts)
+	return &f, nil
+}
+
+// Save replaces the facts of a host
+func (r *hostFactsRepository) Save(ctx context.Context, f *models.HostFacts) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	packages, err := json.Marshal(f.Packages)
+	if err != nil {
+		return err
+	}
+	if f.Packages == nil {
+		packages = []byte("{}")
+	}
+	ports, err := json.Marshal(f.ListeningPorts)
+	if err != nil {
+		return err
+	}
+	if f.ListeningPorts == nil {
+		ports = []byte("[]")
+	}
+
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO host_facts (host_id, kernel, cpu_model, cpu_cores, virtualization, packages, listening_ports, collected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(host_id) DO UPDATE SET
+			kernel = excluded.kernel,
+			cpu_model = excluded.cpu_model,
+			cpu_cores = excluded.cpu_cores,
+			virtualization = excluded.virtualization,
+			packages = excluded.packages,
+			listening_ports = excluded.listening_ports,
+			collected_at = excluded.collected_at
+	`, f.HostID, f.Kernel, f.CPUModel, f.CPUCores, f.Virtualization, string(packages), string(ports), f.CollectedAt)
+	return err
+}
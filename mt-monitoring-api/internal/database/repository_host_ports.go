@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// hostPortsRepository implements HostPortsRepository on SQLite
+type hostPortsRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewHostPortsRepository creates a new host ports repository
+func NewHostPortsRepository(db *sql.DB, timeout time.Duration) HostPortsRepository {
+	return &hostPortsRepository{db: db, timeout: timeout}
+}
+
+// Get returns the listening ports of a host compared with its expected
+// ports, nil when it was not checked yet
+func (r *hostPortsRepository) Get(ctx context.Context, hostID string) (*models.HostPorts, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p := models.HostPorts{HostID: hostID}
+	var expected, current string
+	err := r.db.QueryRowContext(ctx, `
+		SELECT expected, current, checked_at FROM host_ports WHERE host_id = ?
+	`, hostID).Scan(&expected, &current, &p.CheckedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	p.Expected = []string{}
+	p.Current = []models.ListeningPort{}
+	json.Unmarshal([]byte(expected), &p.Expected)
+	json.Unmarshal([]byte(current), &p.Current)
+	p.Compare()
+	return &p, nil
+}
+
+// Save stores the current ports of a host, and a snapshot of them when they
+// changed. The expected ports are only written when the host has no row
+// yet; afterwards they change through SetExpected alone.
+func (r *hostPortsRepository) Save(ctx context.Context, p *models.HostPorts, changed bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	expected, err := marshalList(p.Expected)
+	if err != nil {
+		return err
+	}
+	current, err := marshalList(p.Current)
+	if err != nil {
+		return err
+	}
+
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO host_ports (host_id, expected, current, checked_at)
+			VALUES (?, ?, ?, ?)
+			ON CONFLICT(host_id) DO UPDATE SET
+				current = excluded.current,
+				checked_at = excluded.checked_at
+		`, p.HostID, expected, current, p.CheckedAt); err != nil {
+			return err
+		}
+		if !changed {
+			return nil
+		}
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO port_snapshots (host_id, ports, created_at) VALUES (?, ?, ?)
+		`, p.HostID, current, p.CheckedAt)
+		return err
+	})
+}
+
+// SetExpected replaces the expected ports of a checked host
+func (r *hostPortsRepository) SetExpected(ctx context.Context, hostID string, expected []string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	data, err := marshalList(expected)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.ExecContext(ctx, "UPDATE host_ports SET expected = ? WHERE host_id = ?", data, hostID)
+	return err
+}
+
+// GetSnapshots returns the port changes of a host since a time, newest first
+func (r *hostPortsRepository) GetSnapshots(ctx context.Context, hostID string, since time.Time) ([]models.PortSnapshot, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT ports, created_at FROM port_snapshots
+		WHERE host_id = ? AND created_at >= ?
+		ORDER BY created_at DESC
+	`, hostID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []models.PortSnapshot{}
+	for rows.Next() {
+		s := models.PortSnapshot{HostID: hostID, Ports: []models.ListeningPort{}}
+		var ports string
+		if err := rows.Scan(&ports, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		json.Unmarshal([]byte(ports), &s.Ports)
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
+
+// DeleteOldSnapshots deletes port snapshots older than the retention
+func (r *hostPortsRepository) DeleteOldSnapshots(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM port_snapshots WHERE created_at < ?
+	`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// marshalList encodes a slice as a JSON array, "[]" when it is nil
+func marshalList[T any](list []T) (string, error) {
+	if list == nil {
+		return "[]", nil
+	}
+	data, err := json.Marshal(list)
+	return string(data), err
+}
@@ -0,0 +1,55 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// hostUpdatesRepository implements HostUpdatesRepository on SQLite
+type hostUpdatesRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewHostUpdatesRepository creates a new host updates repository
+func NewHostUpdatesRepository(db *sql.DB, timeout time.Duration) HostUpdatesRepository {
+	return &hostUpdatesRepository{db: db, timeout: timeout}
+}
+
+// Get returns the pending updates of a host, nil when it was not checked yet
+func (r *hostUpdatesRepository) Get(ctx context.Context, hostID string) (*models.SecurityUpdates, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	u := models.SecurityUpdates{HostID: hostID}
+	err := r.db.QueryRowContext(ctx, `
+		SELECT manager, security, total, checked_at FROM host_updates WHERE host_id = ?
+	`, hostID).Scan(&u.Manager, &u.Security, &u.Total, &u.CheckedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// Save replaces the pending updates of a host
+func (r *hostUpdatesRepository) Save(ctx context.Context, u *models.SecurityUpdates) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO host_updates (host_id, manager, security, total, checked_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(host_id) DO UPDATE SET
+			manager = excluded.manager,
+			security = excluded.security,
+			total = excluded.total,
+			checked_at = excluded.checked_at
+	`, u.HostID, u.Manager, u.Security, u.Total, u.CheckedAt)
+	return err
+}
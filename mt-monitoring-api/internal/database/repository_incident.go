@@ -1,26 +1,69 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// IncidentRepository handles incident data operations
-type IncidentRepository struct{}
+// incidentColumns are the columns scanned by scanIncident
+const incidentColumns = `id, service_id, type, message, started_at, resolved_at, parent_id`
+
+// scanIncident scans a row of incidentColumns
+func scanIncident(row interface{ Scan(...interface{}) error }) (*models.Incident, error) {
+	var i models.Incident
+	var resolvedAt sql.NullTime
+	var message sql.NullString
+	var parentID sql.NullInt64
+	if err := row.Scan(&i.ID, &i.ServiceID, &i.Type, &message, &i.StartedAt, &resolvedAt, &parentID); err != nil {
+		return nil, err
+	}
+	i.Message = message.String
+	if resolvedAt.Valid {
+		i.ResolvedAt = &resolvedAt.Time
+	}
+	if parentID.Valid {
+		i.ParentID = &parentID.Int64
+	}
+	return &i, nil
+}
+
+// scanIncidents scans rows of incidentColumns
+func scanIncidents(rows *sql.Rows) ([]models.Incident, error) {
+	var incidents []models.Incident
+	for rows.Next() {
+		i, err := scanIncident(rows)
+		if err != nil {
+			return nil, err
+		}
+		incidents = append(incidents, *i)
+	}
+	return incidents, rows.Err()
+}
+
+// incidentRepository implements IncidentRepository on SQLite
+type incidentRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewIncidentRepository creates a new incident repository
-func NewIncidentRepository() *IncidentRepository {
-	return &IncidentRepository{}
+func NewIncidentRepository(db *sql.DB, timeout time.Duration) IncidentRepository {
+	return &incidentRepository{db: db, timeout: timeout}
 }
 
 // Create creates a new incident
-func (r *IncidentRepository) Create(i *models.Incident) error {
-	result, err := DB.Exec(`
-		INSERT INTO incidents (service_id, type, message, started_at)
-		VALUES (?, ?, ?, ?)
-	`, i.ServiceID, i.Type, i.Message, i.StartedAt)
+func (r *incidentRepository) Create(ctx context.Context, i *models.Incident) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO incidents (service_id, type, message, started_at, parent_id)
+		VALUES (?, ?, ?, ?, ?)
+	`, i.ServiceID, i.Type, i.Message, i.StartedAt, i.ParentID)
 	if err != nil {
 		return err
 	}
@@ -31,9 +74,12 @@ func (r *IncidentRepository) Create(i *models.Incident) error {
 }
 
 // GetActive returns all active (unresolved) incidents
-func (r *IncidentRepository) GetActive() ([]models.Incident, error) {
-	rows, err := DB.Query(`
-		SELECT id, service_id, type, message, started_at, resolved_at
+func (r *incidentRepository) GetActive(ctx context.Context) ([]models.Incident, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
 		FROM incidents
 		WHERE resolved_at IS NULL
 		ORDER BY started_at DESC
@@ -43,41 +89,297 @@ func (r *IncidentRepository) GetActive() ([]models.Incident, error) {
 	}
 	defer rows.Close()
 
-	var incidents []models.Incident
+	return scanIncidents(rows)
+}
+
+// GetByID returns an incident by ID, nil if it does not exist
+func (r *incidentRepository) GetByID(ctx context.Context, id int64) (*models.Incident, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	i, err := scanIncident(r.db.QueryRowContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE id = ?
+	`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return i, nil
+}
+
+// GetChildren returns the incidents grouped under a parent incident,
+// oldest first
+func (r *incidentRepository) GetChildren(ctx context.Context, parentID int64) ([]models.Incident, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE parent_id = ?
+		ORDER BY started_at
+	`, parentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	incidents, err := scanIncidents(rows)
+	if incidents == nil && err == nil {
+		incidents = []models.Incident{}
+	}
+	return incidents, err
+}
+
+// GetResolvedBefore returns up to limit incidents resolved before cutoff, oldest first
+func (r *incidentRepository) GetResolvedBefore(ctx context.Context, cutoff time.Time, limit int) ([]models.Incident, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE resolved_at IS NOT NULL AND resolved_at < ?
+		ORDER BY id
+		LIMIT ?
+	`, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+// GetRange returns the incidents that were open at any time between from
+// and to, oldest first
+func (r *incidentRepository) GetRange(ctx context.Context, from, to time.Time) ([]models.Incident, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+incidentColumns+`
+		FROM incidents
+		WHERE started_at <= ? AND (resolved_at IS NULL OR resolved_at >= ?)
+		ORDER BY started_at
+	`, to, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanIncidents(rows)
+}
+
+// DeleteByIDs deletes the given incidents
+func (r *incidentRepository) DeleteByIDs(ctx context.Context, ids []int64) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM incidents WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// DeleteResolvedBefore deletes incidents resolved before cutoff, with their
+// postmortems and traces. Open incidents are kept however old.
+func (r *incidentRepository) DeleteResolvedBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM incidents WHERE resolved_at IS NOT NULL AND resolved_at < ?
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Resolve resolves an incident
+func (r *incidentRepository) Resolve(ctx context.Context, serviceID string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE incidents SET resolved_at = ?
+		WHERE service_id = ? AND resolved_at IS NULL
+	`, time.Now(), serviceID)
+	return err
+}
+
+// ResolveByID resolves a single incident if it is still open
+func (r *incidentRepository) ResolveByID(ctx context.Context, id int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE incidents SET resolved_at = ?
+		WHERE id = ? AND resolved_at IS NULL
+	`, time.Now(), id)
+	return err
+}
+
+// GetPostmortems returns the postmortems of the given incidents by
+// incident ID; incidents without one are absent
+func (r *incidentRepository) GetPostmortems(ctx context.Context, incidentIDs []int64) (map[int64]*models.Postmortem, error) {
+	postmortems := make(map[int64]*models.Postmortem)
+	if len(incidentIDs) == 0 {
+		return postmortems, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(incidentIDs))
+	for i, id := range incidentIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT incident_id, root_cause, impact, action_items, body, created_at, updated_at
+		FROM incident_postmortems
+		WHERE incident_id IN (`+placeholders(len(incidentIDs))+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
 	for rows.Next() {
-		var i models.Incident
-		var resolvedAt sql.NullTime
-		var message sql.NullString
-		if err := rows.Scan(&i.ID, &i.ServiceID, &i.Type, &message, &i.StartedAt, &resolvedAt); err != nil {
+		var p models.Postmortem
+		var impact, actionItems, body sql.NullString
+		if err := rows.Scan(&p.IncidentID, &p.RootCause, &impact, &actionItems, &body, &p.CreatedAt, &p.UpdatedAt); err != nil {
 			return nil, err
 		}
-		if message.Valid {
-			i.Message = message.String
+		p.Impact = impact.String
+		p.Body = body.String
+		p.ActionItems = []models.ActionItem{}
+		if actionItems.Valid {
+			json.Unmarshal([]byte(actionItems.String), &p.ActionItems)
 		}
-		if resolvedAt.Valid {
-			i.ResolvedAt = &resolvedAt.Time
+		postmortems[p.IncidentID] = &p
+	}
+	return postmortems, rows.Err()
+}
+
+// SavePostmortem creates or replaces the postmortem of an incident,
+// keeping the time it was first written
+func (r *incidentRepository) SavePostmortem(ctx context.Context, p *models.Postmortem) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if p.ActionItems == nil {
+		p.ActionItems = []models.ActionItem{}
+	}
+	actionItems, err := json.Marshal(p.ActionItems)
+	if err != nil {
+		return err
+	}
+
+	p.UpdatedAt = time.Now()
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO incident_postmortems (incident_id, root_cause, impact, action_items, body, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(incident_id) DO UPDATE SET
+			root_cause = excluded.root_cause,
+			impact = excluded.impact,
+			action_items = excluded.action_items,
+			body = excluded.body,
+			updated_at = excluded.updated_at
+	`, p.IncidentID, p.RootCause, p.Impact, string(actionItems), p.Body, p.UpdatedAt, p.UpdatedAt); err != nil {
+		return err
+	}
+	return r.db.QueryRowContext(ctx, `
+		SELECT created_at FROM incident_postmortems WHERE incident_id = ?
+	`, p.IncidentID).Scan(&p.CreatedAt)
+}
+
+// DeletePostmortem deletes the postmortem of an incident, reporting whether
+// it existed
+func (r *incidentRepository) DeletePostmortem(ctx context.Context, incidentID int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM incident_postmortems WHERE incident_id = ?`, incidentID)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetTraces returns the traces of the given incidents by incident ID;
+// incidents without one are absent
+func (r *incidentRepository) GetTraces(ctx context.Context, incidentIDs []int64) (map[int64]*models.Trace, error) {
+	traces := make(map[int64]*models.Trace)
+	if len(incidentIDs) == 0 {
+		return traces, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(incidentIDs))
+	for i, id := range incidentIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT incident_id, target, command, output, error, created_at
+		FROM incident_traces
+		WHERE incident_id IN (`+placeholders(len(incidentIDs))+`)
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t models.Trace
+		if err := rows.Scan(&t.IncidentID, &t.Target, &t.Command, &t.Output, &t.Error, &t.CreatedAt); err != nil {
+			return nil, err
 		}
-		incidents = append(incidents, i)
+		traces[t.IncidentID] = &t
 	}
-	return incidents, nil
+	return traces, rows.Err()
 }
 
-// Resolve resolves an incident
-func (r *IncidentRepository) Resolve(serviceID string) error {
-	_, err := DB.Exec(`
-		UPDATE incidents SET resolved_at = ?
-		WHERE service_id = ? AND resolved_at IS NULL
-	`, time.Now(), serviceID)
+// SaveTrace stores the trace of an incident, replacing an earlier one
+func (r *incidentRepository) SaveTrace(ctx context.Context, t *models.Trace) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT OR REPLACE INTO incident_traces (incident_id, target, command, output, error, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, t.IncidentID, t.Target, t.Command, t.Output, t.Error, t.CreatedAt)
 	return err
 }
 
 // GetTimeline returns recent events as a timeline
-func (r *IncidentRepository) GetTimeline(limit int) ([]models.TimelineEvent, error) {
+func (r *incidentRepository) GetTimeline(ctx context.Context, limit int) ([]models.TimelineEvent, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	if limit <= 0 {
 		limit = 20
 	}
 
-	rows, err := DB.Query(`
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT i.id, i.started_at, i.type, s.name, i.message, i.service_id
 		FROM incidents i
 		JOIN services s ON i.service_id = s.id
@@ -1,29 +1,37 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// LogRepository handles log data operations
-type LogRepository struct{}
+// logRepository implements LogRepository on SQLite
+type logRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewLogRepository creates a new log repository
-func NewLogRepository() *LogRepository {
-	return &LogRepository{}
+func NewLogRepository(db *sql.DB, timeout time.Duration) LogRepository {
+	return &logRepository{db: db, timeout: timeout}
 }
 
 // Create creates a new log entry
-func (r *LogRepository) Create(l *models.Log) error {
+func (r *logRepository) Create(ctx context.Context, l *models.Log) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	if l.Source == "" {
 		l.Source = models.LogSourceInternal
 	}
 
-	result, err := DB.Exec(`
+	result, err := r.db.ExecContext(ctx, `
 		INSERT INTO logs (service_id, level, message, metadata, source, fingerprint, created_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`, l.ServiceID, l.Level, l.Message, l.Metadata, l.Source, l.Fingerprint, l.CreatedAt)
@@ -37,7 +45,10 @@ func (r *LogRepository) Create(l *models.Log) error {
 }
 
 // GetAll returns logs with optional filters
-func (r *LogRepository) GetAll(filter models.LogFilter) ([]models.Log, int, error) {
+func (r *logRepository) GetAll(ctx context.Context, filter models.LogFilter) ([]models.Log, int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	// Build query
 	query := "SELECT id, service_id, level, message, metadata, created_at FROM logs WHERE 1=1"
 	countQuery := "SELECT COUNT(*) FROM logs WHERE 1=1"
@@ -48,6 +59,11 @@ func (r *LogRepository) GetAll(filter models.LogFilter) ([]models.Log, int, erro
 		countQuery += " AND service_id = ?"
 		args = append(args, filter.ServiceID)
 	}
+	if condition, tagArgs := tagCondition("service_id", filter.Tags); condition != "" {
+		query += condition
+		countQuery += condition
+		args = append(args, tagArgs...)
+	}
 	if filter.Level != "" {
 		query += " AND level = ?"
 		countQuery += " AND level = ?"
@@ -71,7 +87,7 @@ func (r *LogRepository) GetAll(filter models.LogFilter) ([]models.Log, int, erro
 
 	// Get total count
 	var total int
-	if err := DB.QueryRow(countQuery, args...).Scan(&total); err != nil {
+	if err := r.db.QueryRowContext(ctx, countQuery, args...).Scan(&total); err != nil {
 		return nil, 0, err
 	}
 
@@ -84,7 +100,7 @@ func (r *LogRepository) GetAll(filter models.LogFilter) ([]models.Log, int, erro
 		query += fmt.Sprintf(" OFFSET %d", filter.Offset)
 	}
 
-	rows, err := DB.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -109,8 +125,11 @@ func (r *LogRepository) GetAll(filter models.LogFilter) ([]models.Log, int, erro
 }
 
 // DeleteOld deletes logs older than the specified duration
-func (r *LogRepository) DeleteOld(retention time.Duration) (int64, error) {
-	result, err := DB.Exec(`
+func (r *logRepository) DeleteOld(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
 		DELETE FROM logs WHERE created_at < ?
 	`, time.Now().Add(-retention))
 	if err != nil {
@@ -118,3 +137,67 @@ func (r *LogRepository) DeleteOld(retention time.Duration) (int64, error) {
 	}
 	return result.RowsAffected()
 }
+
+// DeleteByPolicy deletes logs according to per-service and per-level retention.
+// Returns the total number of deleted rows.
+func (r *logRepository) DeleteByPolicy(ctx context.Context, policy models.LogRetentionPolicy) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	var total int64
+
+	// Services with an override are handled first and excluded from the level/default passes
+	overridden := make([]interface{}, 0, len(policy.Services))
+	for serviceID, retention := range policy.Services {
+		result, err := r.db.ExecContext(ctx, `DELETE FROM logs WHERE service_id = ? AND created_at < ?`,
+			serviceID, now.Add(-retention))
+		if err != nil {
+			return total, err
+		}
+		n, _ := result.RowsAffected()
+		total += n
+		overridden = append(overridden, serviceID)
+	}
+
+	excludeClause := ""
+	if len(overridden) > 0 {
+		excludeClause = " AND (service_id IS NULL OR service_id NOT IN (" + placeholders(len(overridden)) + "))"
+	}
+
+	levels := make([]interface{}, 0, len(policy.Levels))
+	for level, retention := range policy.Levels {
+		args := append([]interface{}{string(level), now.Add(-retention)}, overridden...)
+		result, err := r.db.ExecContext(ctx, `DELETE FROM logs WHERE level = ? AND created_at < ?`+excludeClause, args...)
+		if err != nil {
+			return total, err
+		}
+		n, _ := result.RowsAffected()
+		total += n
+		levels = append(levels, string(level))
+	}
+
+	// Remaining levels fall back to the default retention
+	query := `DELETE FROM logs WHERE created_at < ?` + excludeClause
+	args := append([]interface{}{now.Add(-policy.Default)}, overridden...)
+	if len(levels) > 0 {
+		query += " AND level NOT IN (" + placeholders(len(levels)) + ")"
+		args = append(args, levels...)
+	}
+	result, err := r.db.ExecContext(ctx, query, args...)
+	if err != nil {
+		return total, err
+	}
+	n, _ := result.RowsAffected()
+	total += n
+
+	return total, nil
+}
+
+// placeholders returns n comma-separated SQL placeholders
+func placeholders(n int) string {
+	if n <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", n), ",")
+}
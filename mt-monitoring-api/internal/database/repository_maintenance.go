@@ -0,0 +1,64 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// maintenanceRepository implements MaintenanceRepository on SQLite
+type maintenanceRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewMaintenanceRepository creates a new maintenance repository
+func NewMaintenanceRepository(db *sql.DB, timeout time.Duration) MaintenanceRepository {
+	return &maintenanceRepository{db: db, timeout: timeout}
+}
+
+// Record stores the latest run of a maintenance task
+func (r *maintenanceRepository) Record(ctx context.Context, run *models.MaintenanceRun) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO maintenance_runs (task, last_run_at, duration_ms, detail)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(task) DO UPDATE SET
+			last_run_at = excluded.last_run_at,
+			duration_ms = excluded.duration_ms,
+			detail = excluded.detail
+	`, run.Task, run.LastRunAt, run.DurationMs, run.Detail)
+	return err
+}
+
+// GetAll returns the latest run of every recorded task
+func (r *maintenanceRepository) GetAll(ctx context.Context) ([]models.MaintenanceRun, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT task, last_run_at, duration_ms, detail
+		FROM maintenance_runs
+		ORDER BY task
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.MaintenanceRun
+	for rows.Next() {
+		var run models.MaintenanceRun
+		var detail sql.NullString
+		if err := rows.Scan(&run.Task, &run.LastRunAt, &run.DurationMs, &detail); err != nil {
+			return nil, err
+		}
+		run.Detail = detail.String
+		runs = append(runs, run)
+	}
+	return runs, nil
+}
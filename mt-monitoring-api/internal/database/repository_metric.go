@@ -1,49 +1,157 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// MetricRepository handles metric data operations
-type MetricRepository struct{}
+// metricRepository implements MetricRepository on SQLite
+type metricRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewMetricRepository creates a new metric repository
-func NewMetricRepository() *MetricRepository {
-	return &MetricRepository{}
+func NewMetricRepository(db *sql.DB, timeout time.Duration) MetricRepository {
+	return &metricRepository{db: db, timeout: timeout}
 }
 
+// notExcluded is a predicate on the metrics table that drops checks recorded
+// inside a pause, maintenance or exclusion window of their service, so
+// uptime and Apdex only cover monitored time
+const notExcluded = `NOT EXISTS (
+	SELECT 1 FROM service_windows w
+	WHERE w.service_id = metrics.service_id
+	  AND metrics.checked_at >= w.starts_at
+	  AND (w.ends_at IS NULL OR metrics.checked_at < w.ends_at))`
+
 // Create creates a new metric
-func (r *MetricRepository) Create(m *models.Metric) error {
-	result, err := DB.Exec(`
-		INSERT INTO metrics (service_id, status, response_time, status_code, error_message, checked_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, m.ServiceID, m.Status, m.ResponseTime, m.StatusCode, m.ErrorMessage, m.CheckedAt)
+func (r *metricRepository) Create(ctx context.Context, m *models.Metric) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var t models.CheckTimings
+	if m.Timings != nil {
+		t = *m.Timings
+	}
+
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO metrics (service_id, status, response_time, status_code, error_message, checked_at,
+			                     dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, dom_content_loaded_ms, load_ms, protocol)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, m.ServiceID, m.Status, m.ResponseTime, m.StatusCode, m.ErrorMessage, m.CheckedAt,
+			t.DNS, t.Connect, t.TLS, t.TTFB, t.Transfer, t.DOMContentLoaded, t.Load, m.Protocol)
+		if err != nil {
+			return err
+		}
+
+		id, _ := result.LastInsertId()
+		m.ID = id
+		return addToRollup(ctx, tx, m)
+	})
+}
+
+// timingsColumns are the phase columns of a check, in the order phaseScanner
+// expects them
+const timingsColumns = `dns_ms, connect_ms, tls_ms, ttfb_ms, transfer_ms, dom_content_loaded_ms, load_ms`
+
+// phaseScanner scans the timingsColumns of a row
+type phaseScanner [7]sql.NullInt64
+
+// dest returns the scan destinations of the phases
+func (p *phaseScanner) dest() []any {
+	dest := make([]any, len(p))
+	for i := range p {
+		dest[i] = &p[i]
+	}
+	return dest
+}
+
+// timings returns the scanned phases, nil when none was recorded
+func (p *phaseScanner) timings() *models.CheckTimings {
+	if !slices.ContainsFunc(p[:], func(n sql.NullInt64) bool { return n.Valid }) {
+		return nil
+	}
+	return &models.CheckTimings{
+		DNS:              nullIntPtr(p[0]),
+		Connect:          nullIntPtr(p[1]),
+		TLS:              nullIntPtr(p[2]),
+		TTFB:             nullIntPtr(p[3]),
+		Transfer:         nullIntPtr(p[4]),
+		DOMContentLoaded: nullIntPtr(p[5]),
+		Load:             nullIntPtr(p[6]),
+	}
+}
+
+// addToRollup counts a check in the hourly rollup of its service. Checks
+// without a response time are counted but left out of the histogram.
+func addToRollup(ctx context.Context, tx *sql.Tx, m *models.Metric) error {
+	checkedAt := m.CheckedAt
+	if checkedAt.IsZero() {
+		checkedAt = time.Now()
+	}
+	hour := checkedAt.UTC().Truncate(time.Hour)
+
+	failures := 0
+	if m.Status != models.CheckStatusSuccess {
+		failures = 1
+	}
+	counts := make([]int, len(models.LatencyBuckets)+1)
+	bucket := "" // JSON path of the bucket to increment, none without a response
+	if m.ResponseTime > 0 {
+		i := models.LatencyBucket(m.ResponseTime)
+		counts[i] = 1
+		bucket = fmt.Sprintf("$[%d]", i)
+	}
+	data, err := json.Marshal(counts)
 	if err != nil {
 		return err
 	}
 
-	id, _ := result.LastInsertId()
-	m.ID = id
-	return nil
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO metric_rollups (service_id, hour, checks, failures, response_sum, response_max, buckets)
+		VALUES (?1, ?2, 1, ?3, ?4, ?4, ?5)
+		ON CONFLICT(service_id, hour) DO UPDATE SET
+			checks       = checks + 1,
+			failures     = failures + excluded.failures,
+			response_sum = response_sum + excluded.response_sum,
+			response_max = MAX(response_max, excluded.response_max),
+			buckets      = CASE WHEN ?6 = '' THEN buckets
+			               ELSE json_set(buckets, ?6, COALESCE(json_extract(buckets, ?6), 0) + 1) END
+	`, m.ServiceID, hour, failures, max(m.ResponseTime, 0), string(data), bucket)
+	return err
+}
+
+// GetByServiceID returns the latest metrics of a service
+func (r *metricRepository) GetByServiceID(ctx context.Context, serviceID string, limit int) ([]models.Metric, error) {
+	return r.GetBefore(ctx, serviceID, 0, limit)
 }
 
-// GetByServiceID returns metrics for a service
-func (r *MetricRepository) GetByServiceID(serviceID string, limit int) ([]models.Metric, error) {
+// GetBefore returns the metrics of a service older than the metric with ID
+// before, newest first. A before of 0 starts at the latest one.
+func (r *metricRepository) GetBefore(ctx context.Context, serviceID string, before int64, limit int) ([]models.Metric, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	if limit <= 0 {
 		limit = 100
 	}
 
-	rows, err := DB.Query(`
-		SELECT id, service_id, status, response_time, status_code, error_message, checked_at
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, service_id, status, response_time, status_code, error_message, checked_at, protocol, `+timingsColumns+`
 		FROM metrics
-		WHERE service_id = ?
-		ORDER BY checked_at DESC
-		LIMIT ?
-	`, serviceID, limit)
+		WHERE service_id = ?1
+		  AND (?2 = 0 OR (checked_at, id) < ((SELECT checked_at FROM metrics WHERE id = ?2), ?2))
+		ORDER BY checked_at DESC, id DESC
+		LIMIT ?3
+	`, serviceID, before, limit)
 	if err != nil {
 		return nil, err
 	}
@@ -53,10 +161,14 @@ func (r *MetricRepository) GetByServiceID(serviceID string, limit int) ([]models
 	for rows.Next() {
 		var m models.Metric
 		var statusCode, responseTime sql.NullInt64
-		var errorMsg sql.NullString
-		if err := rows.Scan(&m.ID, &m.ServiceID, &m.Status, &responseTime, &statusCode, &errorMsg, &m.CheckedAt); err != nil {
+		var errorMsg, protocol sql.NullString
+		var phases phaseScanner
+		dest := append([]any{&m.ID, &m.ServiceID, &m.Status, &responseTime, &statusCode, &errorMsg, &m.CheckedAt, &protocol}, phases.dest()...)
+		if err := rows.Scan(dest...); err != nil {
 			return nil, err
 		}
+		m.Timings = phases.timings()
+		m.Protocol = protocol.String
 		if statusCode.Valid {
 			m.StatusCode = int(statusCode.Int64)
 		}
@@ -71,81 +183,379 @@ func (r *MetricRepository) GetByServiceID(serviceID string, limit int) ([]models
 	return metrics, nil
 }
 
-// GetSummary returns metric summary for a service
-func (r *MetricRepository) GetSummary(serviceID string, duration time.Duration) (*models.MetricSummary, error) {
+// GetRange returns the checks of a service between from and to, oldest
+// first. Only status, response time and check time are loaded.
+func (r *metricRepository) GetRange(ctx context.Context, serviceID string, from, to time.Time) ([]models.Metric, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT status, response_time, checked_at
+		FROM metrics
+		WHERE service_id = ? AND checked_at >= ? AND checked_at <= ?
+		ORDER BY checked_at ASC
+	`, serviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []models.Metric
+	for rows.Next() {
+		m := models.Metric{ServiceID: serviceID}
+		var responseTime sql.NullInt64
+		if err := rows.Scan(&m.Status, &responseTime, &m.CheckedAt); err != nil {
+			return nil, err
+		}
+		if responseTime.Valid {
+			m.ResponseTime = int(responseTime.Int64)
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
+// GetByID returns a single metric of a service, or nil if not found
+func (r *metricRepository) GetByID(ctx context.Context, serviceID string, id int64) (*models.Metric, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var m models.Metric
+	var statusCode, responseTime sql.NullInt64
+	var errorMsg, protocol sql.NullString
+	var phases phaseScanner
+	dest := append([]any{&m.ID, &m.ServiceID, &m.Status, &responseTime, &statusCode, &errorMsg, &m.CheckedAt, &protocol}, phases.dest()...)
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, service_id, status, response_time, status_code, error_message, checked_at, protocol, `+timingsColumns+`
+		FROM metrics
+		WHERE id = ? AND service_id = ?
+	`, id, serviceID).Scan(dest...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.StatusCode = int(statusCode.Int64)
+	m.ResponseTime = int(responseTime.Int64)
+	m.ErrorMessage = errorMsg.String
+	m.Timings = phases.timings()
+	m.Protocol = protocol.String
+	return &m, nil
+}
+
+// GetSummary returns metric summary for a service. apdexThreshold is the
+// Apdex T in milliseconds.
+func (r *metricRepository) GetSummary(ctx context.Context, serviceID string, duration time.Duration, apdexThreshold int) (*models.MetricSummary, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	since := time.Now().Add(-duration)
 
 	var summary models.MetricSummary
 	summary.ServiceID = serviceID
 
-	err := DB.QueryRow(`
+	var allChecks int
+	var avgPhases phaseScanner
+	err := r.db.QueryRowContext(ctx, `
 		SELECT
 			COUNT(*) as total,
-			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success,
-			AVG(CASE WHEN response_time > 0 THEN response_time END) as avg_rt,
-			MIN(CASE WHEN response_time > 0 THEN response_time END) as min_rt,
-			MAX(response_time) as max_rt
+			COALESCE(SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END), 0) as success,
+			COALESCE(AVG(CASE WHEN response_time > 0 THEN response_time END), 0) as avg_rt,
+			COALESCE(MIN(CASE WHEN response_time > 0 THEN response_time END), 0) as min_rt,
+			COALESCE(MAX(response_time), 0) as max_rt,
+			(SELECT COUNT(*) FROM metrics WHERE service_id = ? AND checked_at >= ?) as all_checks,
+			CAST(ROUND(AVG(dns_ms)) AS INTEGER), CAST(ROUND(AVG(connect_ms)) AS INTEGER),
+			CAST(ROUND(AVG(tls_ms)) AS INTEGER), CAST(ROUND(AVG(ttfb_ms)) AS INTEGER),
+			CAST(ROUND(AVG(transfer_ms)) AS INTEGER), CAST(ROUND(AVG(dom_content_loaded_ms)) AS INTEGER),
+			CAST(ROUND(AVG(load_ms)) AS INTEGER)
 		FROM metrics
-		WHERE service_id = ? AND checked_at >= ?
-	`, serviceID, since).Scan(
+		WHERE service_id = ? AND checked_at >= ? AND `+notExcluded+`
+	`, serviceID, since, serviceID, since).Scan(append([]any{
 		&summary.TotalChecks,
 		&summary.SuccessfulChecks,
 		&summary.AvgResponseTime,
 		&summary.MinResponseTime,
 		&summary.MaxResponseTime,
-	)
+		&allChecks,
+	}, avgPhases.dest()...)...)
 	if err != nil {
 		return nil, err
 	}
+	summary.AvgTimings = avgPhases.timings()
 
 	summary.FailedChecks = summary.TotalChecks - summary.SuccessfulChecks
+	summary.ExcludedChecks = allChecks - summary.TotalChecks
 	if summary.TotalChecks > 0 {
 		summary.Uptime = float64(summary.SuccessfulChecks) / float64(summary.TotalChecks) * 100
 	}
 
+	stats, err := r.performanceStats(ctx, serviceID, since, apdexThreshold)
+	if err != nil {
+		return nil, err
+	}
+	if p, ok := stats[serviceID]; ok {
+		summary.P50ResponseTime = p.P50ResponseTime
+		summary.P95ResponseTime = p.P95ResponseTime
+		summary.P99ResponseTime = p.P99ResponseTime
+		summary.Apdex = p.Apdex
+	}
+	summary.ApdexThreshold = apdexThreshold
+
 	return &summary, nil
 }
 
-// GetUptimeData returns daily uptime data for calendar view
-func (r *MetricRepository) GetUptimeData(serviceID string, days int) ([]models.UptimeData, error) {
-	rows, err := DB.Query(`
-		SELECT
-			COALESCE(DATE(checked_at), '') as date,
-			COUNT(*) as total,
-			SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) as success
+// GetPerformanceStats returns response-time percentiles and Apdex since
+// now-duration for every service with checks in the window
+func (r *metricRepository) GetPerformanceStats(ctx context.Context, duration time.Duration, apdexThreshold int) (map[string]models.PerformanceStats, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.performanceStats(ctx, "", time.Now().Add(-duration), apdexThreshold)
+}
+
+// performanceStats computes percentiles and Apdex per service, optionally
+// restricted to a single service. SQLite has no percentile function, so
+// values are picked by rank: the p-th percentile is the smallest value whose
+// rank satisfies rank*100 >= count*p.
+func (r *metricRepository) performanceStats(ctx context.Context, serviceID string, since time.Time, apdexThreshold int) (map[string]models.PerformanceStats, error) {
+	filter := " AND " + notExcluded
+	if serviceID != "" {
+		filter += " AND service_id = ?"
+	}
+
+	args := []interface{}{since}
+	if serviceID != "" {
+		args = append(args, serviceID)
+	}
+	args = append(args, apdexThreshold, apdexThreshold, 4*apdexThreshold, since)
+	if serviceID != "" {
+		args = append(args, serviceID)
+	}
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH ranked AS (
+			SELECT service_id, response_time,
+			       ROW_NUMBER() OVER (PARTITION BY service_id ORDER BY response_time) AS rn,
+			       COUNT(*) OVER (PARTITION BY service_id) AS cnt
+			FROM metrics
+			WHERE checked_at >= ? AND response_time > 0`+filter+`
+		),
+		pct AS (
+			SELECT service_id,
+			       MIN(CASE WHEN rn * 100 >= cnt * 50 THEN response_time END) AS p50,
+			       MIN(CASE WHEN rn * 100 >= cnt * 95 THEN response_time END) AS p95,
+			       MIN(CASE WHEN rn * 100 >= cnt * 99 THEN response_time END) AS p99
+			FROM ranked
+			GROUP BY service_id
+		),
+		apdex AS (
+			SELECT service_id,
+			       COUNT(*) AS total,
+			       SUM(CASE WHEN status = 'success' AND response_time <= ? THEN 1 ELSE 0 END) AS satisfied,
+			       SUM(CASE WHEN status = 'success' AND response_time > ? AND response_time <= ? THEN 1 ELSE 0 END) AS tolerating
+			FROM metrics
+			WHERE checked_at >= ?`+filter+`
+			GROUP BY service_id
+		)
+		SELECT a.service_id, COALESCE(p.p50, 0), COALESCE(p.p95, 0), COALESCE(p.p99, 0),
+		       a.total, a.satisfied, a.tolerating
+		FROM apdex a
+		LEFT JOIN pct p ON p.service_id = a.service_id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stats := make(map[string]models.PerformanceStats)
+	for rows.Next() {
+		var p models.PerformanceStats
+		var total int
+		if err := rows.Scan(&p.ServiceID, &p.P50ResponseTime, &p.P95ResponseTime, &p.P99ResponseTime,
+			&total, &p.Satisfied, &p.Tolerating); err != nil {
+			return nil, err
+		}
+		p.Frustrated = total - p.Satisfied - p.Tolerating
+		p.Apdex = models.ApdexScore(p.Satisfied, p.Tolerating, total)
+		stats[p.ServiceID] = p
+	}
+	return stats, rows.Err()
+}
+
+// GetStatusSnapshots returns the latest check and the summary since now-duration
+// for every service that has metrics, in a single query. The latest check is
+// looked up per service so it stays an index seek on idx_metrics_service_time
+// instead of ranking the whole metrics table.
+func (r *metricRepository) GetStatusSnapshots(ctx context.Context, duration time.Duration) (map[string]models.ServiceStatusSnapshot, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	since := time.Now().Add(-duration)
+
+	rows, err := r.db.QueryContext(ctx, `
+		WITH latest AS (
+			SELECT (SELECT id FROM metrics
+			        WHERE service_id = s.id
+			        ORDER BY checked_at DESC, id DESC LIMIT 1) AS metric_id
+			FROM services s
+		),
+		recent AS (
+			SELECT service_id,
+			       COUNT(*) AS total,
+			       SUM(CASE WHEN status = 'success' THEN 1 ELSE 0 END) AS success,
+			       AVG(CASE WHEN response_time > 0 THEN response_time END) AS avg_rt
+			FROM metrics
+			WHERE checked_at >= ? AND `+notExcluded+`
+			GROUP BY service_id
+		)
+		SELECT m.service_id, m.status, m.checked_at,
+		       COALESCE(w.total, 0), COALESCE(w.success, 0), COALESCE(w.avg_rt, 0)
+		FROM latest l
+		JOIN metrics m ON m.id = l.metric_id
+		LEFT JOIN recent w ON w.service_id = m.service_id
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := make(map[string]models.ServiceStatusSnapshot)
+	for rows.Next() {
+		var s models.ServiceStatusSnapshot
+		if err := rows.Scan(&s.ServiceID, &s.LastStatus, &s.LastCheckAt,
+			&s.TotalChecks, &s.SuccessfulChecks, &s.AvgResponseTime); err != nil {
+			return nil, err
+		}
+		snapshots[s.ServiceID] = s
+	}
+	return snapshots, rows.Err()
+}
+
+// GetUptimeData returns daily uptime data for calendar view, newest day
+// first. Days run midnight to midnight in loc.
+func (r *metricRepository) GetUptimeData(ctx context.Context, serviceID string, days int, loc *time.Location) ([]models.UptimeData, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now().In(loc)
+	since := time.Date(now.Year(), now.Month(), now.Day()-days, 0, 0, 0, 0, loc)
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT checked_at, status
 		FROM metrics
-		WHERE service_id = ? AND checked_at >= DATE('now', ?)
-		GROUP BY DATE(checked_at)
-		HAVING date != ''
-		ORDER BY date DESC
-	`, serviceID, fmt.Sprintf("-%d days", days))
+		WHERE service_id = ? AND checked_at >= ? AND `+notExcluded+`
+		ORDER BY checked_at DESC
+	`, serviceID, since.Local())
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
 	var data []models.UptimeData
+	index := make(map[string]int)
 	for rows.Next() {
-		var d models.UptimeData
-		var date sql.NullString
-		if err := rows.Scan(&date, &d.Checks, &d.Success); err != nil {
+		var checkedAt time.Time
+		var status string
+		if err := rows.Scan(&checkedAt, &status); err != nil {
 			return nil, err
 		}
-		if date.Valid {
-			d.Date = date.String
+		date := checkedAt.In(loc).Format("2006-01-02")
+		i, ok := index[date]
+		if !ok {
+			i = len(data)
+			index[date] = i
+			data = append(data, models.UptimeData{Date: date})
 		}
-		d.Failure = d.Checks - d.Success
-		if d.Checks > 0 {
-			d.Uptime = float64(d.Success) / float64(d.Checks) * 100
+		d := &data[i]
+		d.Checks++
+		if status == string(models.CheckStatusSuccess) {
+			d.Success++
 		}
-		data = append(data, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range data {
+		d := &data[i]
+		d.Failure = d.Checks - d.Success
+		d.Uptime = float64(d.Success) / float64(d.Checks) * 100
 	}
 	return data, nil
 }
 
+// GetStatusIntervals compresses the checks since the given time into
+// state-change intervals. Checks inside a pause, maintenance or exclusion
+// window take the window's kind as their state and its note, maintenance
+// winning over exclusions where they overlap.
+func (r *metricRepository) GetStatusIntervals(ctx context.Context, serviceID string, since time.Time) ([]models.StatusInterval, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT metrics.checked_at, metrics.status, sw.kind, sw.note
+		FROM metrics
+		LEFT JOIN service_windows sw ON sw.id = (
+			SELECT w.id FROM service_windows w
+			WHERE w.service_id = metrics.service_id
+			  AND metrics.checked_at >= w.starts_at
+			  AND (w.ends_at IS NULL OR metrics.checked_at < w.ends_at)
+			ORDER BY w.kind = 'maintenance' DESC, w.starts_at DESC
+			LIMIT 1)
+		WHERE metrics.service_id = ? AND metrics.checked_at >= ?
+		ORDER BY metrics.checked_at ASC
+	`, serviceID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	intervals := []models.StatusInterval{}
+	for rows.Next() {
+		var checkedAt time.Time
+		var status string
+		var windowKind, windowNote sql.NullString
+		if err := rows.Scan(&checkedAt, &status, &windowKind, &windowNote); err != nil {
+			return nil, err
+		}
+
+		state := models.TimelineDown
+		switch {
+		case windowKind.Valid:
+			state = windowKind.String
+		case status == string(models.CheckStatusSuccess):
+			state = models.TimelineUp
+		}
+
+		if n := len(intervals); n > 0 && intervals[n-1].Status == state && intervals[n-1].Note == windowNote.String {
+			intervals[n-1].Checks++
+			continue
+		}
+		if n := len(intervals); n > 0 {
+			intervals[n-1].EndedAt = checkedAt
+		}
+		intervals = append(intervals, models.StatusInterval{Status: state, StartedAt: checkedAt, Checks: 1, Note: windowNote.String})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if n := len(intervals); n > 0 {
+		intervals[n-1].EndedAt = time.Now()
+	}
+	for i := range intervals {
+		intervals[i].Duration = int64(intervals[i].EndedAt.Sub(intervals[i].StartedAt).Seconds())
+	}
+	return intervals, nil
+}
+
 // DeleteOld deletes metrics older than the specified duration
-func (r *MetricRepository) DeleteOld(retention time.Duration) (int64, error) {
-	result, err := DB.Exec(`
+func (r *metricRepository) DeleteOld(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
 		DELETE FROM metrics WHERE checked_at < ?
 	`, time.Now().Add(-retention))
 	if err != nil {
@@ -153,3 +563,114 @@ func (r *MetricRepository) DeleteOld(retention time.Duration) (int64, error) {
 	}
 	return result.RowsAffected()
 }
+
+// SampleOld thins out the raw checks of a service older than age to 1 in
+// every, keeping the first check in each span of every × interval. Spans
+// are aligned to the Unix epoch, so running it again deletes nothing more.
+func (r *metricRepository) SampleOld(ctx context.Context, serviceID string, age time.Duration, every int, interval time.Duration) (int64, error) {
+	if every <= 1 || interval <= 0 {
+		return 0, nil
+	}
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, checked_at FROM metrics
+		WHERE service_id = ? AND checked_at < ?
+		ORDER BY checked_at ASC
+	`, serviceID, time.Now().Add(-age))
+	if err != nil {
+		return 0, err
+	}
+	span := int64(every) * int64(interval/time.Second)
+	var drop []int64
+	last := int64(-1)
+	for rows.Next() {
+		var id int64
+		var checkedAt time.Time
+		if err := rows.Scan(&id, &checkedAt); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if slot := checkedAt.Unix() / span; slot != last {
+			last = slot
+			continue
+		}
+		drop = append(drop, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil || len(drop) == 0 {
+		return 0, err
+	}
+
+	err = transaction(ctx, r.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `DELETE FROM metrics WHERE id = ?`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+		for _, id := range drop {
+			if _, err := stmt.ExecContext(ctx, id); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(drop)), nil
+}
+
+// GetRollups returns the hourly rollups of a service from the hour of from
+// up to to, oldest first
+func (r *metricRepository) GetRollups(ctx context.Context, serviceID string, from, to time.Time) ([]models.MetricRollup, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT hour, checks, failures, response_sum, response_max, buckets
+		FROM metric_rollups
+		WHERE service_id = ? AND hour >= ? AND hour < ?
+		ORDER BY hour ASC
+	`, serviceID, from.UTC().Truncate(time.Hour), to.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	rollups := []models.MetricRollup{}
+	for rows.Next() {
+		var ru models.MetricRollup
+		var responseSum int64
+		var buckets string
+		if err := rows.Scan(&ru.Hour, &ru.Checks, &ru.Failures, &responseSum, &ru.MaxResponseTime, &buckets); err != nil {
+			return nil, err
+		}
+		ru.Counts = make([]int, len(models.LatencyBuckets)+1)
+		json.Unmarshal([]byte(buckets), &ru.Counts)
+		responded := 0
+		for _, n := range ru.Counts {
+			responded += n
+		}
+		if responded > 0 {
+			ru.AvgResponseTime = float64(responseSum) / float64(responded)
+		}
+		rollups = append(rollups, ru)
+	}
+	return rollups, rows.Err()
+}
+
+// DeleteOldRollups deletes hourly rollups older than retention
+func (r *metricRepository) DeleteOldRollups(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM metric_rollups WHERE hour < ?
+	`, time.Now().Add(-retention).UTC())
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
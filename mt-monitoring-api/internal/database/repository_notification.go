@@ -1,23 +1,31 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// NotificationRepository handles notification channel data operations
-type NotificationRepository struct{}
+// notificationRepository implements NotificationRepository on SQLite
+type notificationRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewNotificationRepository creates a new notification repository
-func NewNotificationRepository() *NotificationRepository {
-	return &NotificationRepository{}
+func NewNotificationRepository(db *sql.DB, timeout time.Duration) NotificationRepository {
+	return &notificationRepository{db: db, timeout: timeout}
 }
 
 // GetAll returns all notification channels
-func (r *NotificationRepository) GetAll() ([]models.NotificationChannel, error) {
-	rows, err := DB.Query(`
-		SELECT id, name, type, config, is_enabled, created_at
+func (r *notificationRepository) GetAll(ctx context.Context) ([]models.NotificationChannel, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, type, config, is_enabled, created_at, project_id
 		FROM notification_channels
 		ORDER BY created_at DESC
 	`)
@@ -30,7 +38,7 @@ func (r *NotificationRepository) GetAll() ([]models.NotificationChannel, error)
 	for rows.Next() {
 		var ch models.NotificationChannel
 		var isEnabled int
-		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt); err != nil {
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt, &ch.ProjectID); err != nil {
 			return nil, err
 		}
 		ch.IsEnabled = isEnabled == 1
@@ -40,14 +48,17 @@ func (r *NotificationRepository) GetAll() ([]models.NotificationChannel, error)
 }
 
 // GetByID returns a notification channel by ID
-func (r *NotificationRepository) GetByID(id string) (*models.NotificationChannel, error) {
+func (r *notificationRepository) GetByID(ctx context.Context, id string) (*models.NotificationChannel, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var ch models.NotificationChannel
 	var isEnabled int
 
-	err := DB.QueryRow(`
-		SELECT id, name, type, config, is_enabled, created_at
+	err := r.db.QueryRowContext(ctx, `
+		SELECT id, name, type, config, is_enabled, created_at, project_id
 		FROM notification_channels WHERE id = ?
-	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt)
+	`, id).Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt, &ch.ProjectID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -61,33 +72,43 @@ func (r *NotificationRepository) GetByID(id string) (*models.NotificationChannel
 }
 
 // Create creates a new notification channel
-func (r *NotificationRepository) Create(ch *models.NotificationChannel) error {
+func (r *notificationRepository) Create(ctx context.Context, ch *models.NotificationChannel) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	isEnabled := 0
 	if ch.IsEnabled {
 		isEnabled = 1
 	}
+	ch.ProjectID = projectOrDefault(ch.ProjectID)
 
-	_, err := DB.Exec(`
-		INSERT INTO notification_channels (id, name, type, config, is_enabled, created_at)
-		VALUES (?, ?, ?, ?, ?, ?)
-	`, ch.ID, ch.Name, ch.Type, ch.Config, isEnabled, ch.CreatedAt)
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO notification_channels (id, name, type, config, is_enabled, created_at, project_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`, ch.ID, ch.Name, ch.Type, ch.Config, isEnabled, ch.CreatedAt, ch.ProjectID)
 	return err
 }
 
 // Delete deletes a notification channel
-func (r *NotificationRepository) Delete(id string) error {
-	_, err := DB.Exec("DELETE FROM notification_channels WHERE id = ?", id)
+func (r *notificationRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM notification_channels WHERE id = ?", id)
 	return err
 }
 
 // Update updates a notification channel
-func (r *NotificationRepository) Update(ch *models.NotificationChannel) error {
+func (r *notificationRepository) Update(ctx context.Context, ch *models.NotificationChannel) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	isEnabled := 0
 	if ch.IsEnabled {
 		isEnabled = 1
 	}
 
-	_, err := DB.Exec(`
+	_, err := r.db.ExecContext(ctx, `
 		UPDATE notification_channels SET name = ?, type = ?, config = ?, is_enabled = ?
 		WHERE id = ?
 	`, ch.Name, ch.Type, ch.Config, isEnabled, ch.ID)
@@ -95,24 +116,30 @@ func (r *NotificationRepository) Update(ch *models.NotificationChannel) error {
 }
 
 // SetEnabled updates the is_enabled flag of a notification channel
-func (r *NotificationRepository) SetEnabled(id string, isEnabled bool) error {
+func (r *notificationRepository) SetEnabled(ctx context.Context, id string, isEnabled bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	enabled := 0
 	if isEnabled {
 		enabled = 1
 	}
 
-	_, err := DB.Exec(`UPDATE notification_channels SET is_enabled = ? WHERE id = ?`, enabled, id)
+	_, err := r.db.ExecContext(ctx, `UPDATE notification_channels SET is_enabled = ? WHERE id = ?`, enabled, id)
 	return err
 }
 
-// GetEnabled returns all enabled notification channels
-func (r *NotificationRepository) GetEnabled() ([]models.NotificationChannel, error) {
-	rows, err := DB.Query(`
-		SELECT id, name, type, config, is_enabled, created_at
+// GetEnabled returns the enabled notification channels of a project
+func (r *notificationRepository) GetEnabled(ctx context.Context, projectID string) ([]models.NotificationChannel, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, type, config, is_enabled, created_at, project_id
 		FROM notification_channels
-		WHERE is_enabled = 1
+		WHERE is_enabled = 1 AND project_id = ?
 		ORDER BY created_at DESC
-	`)
+	`, projectID)
 	if err != nil {
 		return nil, err
 	}
@@ -122,7 +149,7 @@ func (r *NotificationRepository) GetEnabled() ([]models.NotificationChannel, err
 	for rows.Next() {
 		var ch models.NotificationChannel
 		var isEnabled int
-		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt); err != nil {
+		if err := rows.Scan(&ch.ID, &ch.Name, &ch.Type, &ch.Config, &isEnabled, &ch.CreatedAt, &ch.ProjectID); err != nil {
 			return nil, err
 		}
 		ch.IsEnabled = isEnabled == 1
@@ -1,22 +1,29 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// NotificationHistoryRepository handles notification history data operations
-type NotificationHistoryRepository struct{}
+// notificationHistoryRepository implements NotificationHistoryRepository on SQLite
+type notificationHistoryRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewNotificationHistoryRepository creates a new notification history repository
-func NewNotificationHistoryRepository() *NotificationHistoryRepository {
-	return &NotificationHistoryRepository{}
+func NewNotificationHistoryRepository(db *sql.DB, timeout time.Duration) NotificationHistoryRepository {
+	return &notificationHistoryRepository{db: db, timeout: timeout}
 }
 
 // Create adds a new notification history record
-func (r *NotificationHistoryRepository) Create(history *models.NotificationHistory) error {
+func (r *notificationHistoryRepository) Create(ctx context.Context, history *models.NotificationHistory) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		INSERT INTO notification_history (
 			rule_id, channel_id, channel_name, channel_type,
@@ -26,7 +33,7 @@ func (r *NotificationHistoryRepository) Create(history *models.NotificationHisto
 		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := DB.Exec(query,
+	result, err := r.db.ExecContext(ctx, query,
 		history.RuleID,
 		history.ChannelID,
 		history.ChannelName,
@@ -56,7 +63,10 @@ func (r *NotificationHistoryRepository) Create(history *models.NotificationHisto
 }
 
 // UpdateStatus updates the status of a notification
-func (r *NotificationHistoryRepository) UpdateStatus(id int, status string, errorMessage string) error {
+func (r *notificationHistoryRepository) UpdateStatus(ctx context.Context, id int, status string, errorMessage string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var sentAt *time.Time
 	if status == "sent" {
 		now := time.Now()
@@ -68,19 +78,25 @@ func (r *NotificationHistoryRepository) UpdateStatus(id int, status string, erro
 		SET status = ?, error_message = ?, sent_at = ?
 		WHERE id = ?
 	`
-	_, err := DB.Exec(query, status, errorMessage, sentAt, id)
+	_, err := r.db.ExecContext(ctx, query, status, errorMessage, sentAt, id)
 	return err
 }
 
 // IncrementRetry increments the retry count
-func (r *NotificationHistoryRepository) IncrementRetry(id int) error {
+func (r *notificationHistoryRepository) IncrementRetry(ctx context.Context, id int) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `UPDATE notification_history SET retry_count = retry_count + 1 WHERE id = ?`
-	_, err := DB.Exec(query, id)
+	_, err := r.db.ExecContext(ctx, query, id)
 	return err
 }
 
 // GetByID retrieves a notification history by ID
-func (r *NotificationHistoryRepository) GetByID(id int) (*models.NotificationHistory, error) {
+func (r *notificationHistoryRepository) GetByID(ctx context.Context, id int) (*models.NotificationHistory, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, rule_id, channel_id, channel_name, channel_type,
 		       alert_type, severity, host_id, host_name,
@@ -94,7 +110,7 @@ func (r *NotificationHistoryRepository) GetByID(id int) (*models.NotificationHis
 	var ruleID, severity, hostID, hostName, serviceID, serviceName, errorMessage sql.NullString
 	var sentAt sql.NullTime
 
-	err := DB.QueryRow(query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id).Scan(
 		&history.ID,
 		&ruleID,
 		&history.ChannelID,
@@ -151,7 +167,10 @@ func (r *NotificationHistoryRepository) GetByID(id int) (*models.NotificationHis
 }
 
 // GetAll retrieves notification history with optional filters
-func (r *NotificationHistoryRepository) GetAll(filter *models.NotificationHistoryFilter) ([]models.NotificationHistory, error) {
+func (r *notificationHistoryRepository) GetAll(ctx context.Context, filter *models.NotificationHistoryFilter) ([]models.NotificationHistory, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := `
 		SELECT id, rule_id, channel_id, channel_name, channel_type,
 		       alert_type, severity, host_id, host_name,
@@ -176,6 +195,10 @@ func (r *NotificationHistoryRepository) GetAll(filter *models.NotificationHistor
 			query += " AND status = ?"
 			args = append(args, *filter.Status)
 		}
+		if condition, tagArgs := tagCondition("service_id", filter.Tags); condition != "" {
+			query += condition
+			args = append(args, tagArgs...)
+		}
 		if filter.FromDate != nil {
 			query += " AND created_at >= ?"
 			args = append(args, *filter.FromDate)
@@ -200,7 +223,7 @@ func (r *NotificationHistoryRepository) GetAll(filter *models.NotificationHistor
 		}
 	}
 
-	rows, err := DB.Query(query, args...)
+	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -268,7 +291,10 @@ func (r *NotificationHistoryRepository) GetAll(filter *models.NotificationHistor
 }
 
 // GetCount returns total count with filters
-func (r *NotificationHistoryRepository) GetCount(filter *models.NotificationHistoryFilter) (int, error) {
+func (r *notificationHistoryRepository) GetCount(ctx context.Context, filter *models.NotificationHistoryFilter) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	query := "SELECT COUNT(*) FROM notification_history WHERE 1=1"
 	args := []interface{}{}
 
@@ -285,6 +311,10 @@ func (r *NotificationHistoryRepository) GetCount(filter *models.NotificationHist
 			query += " AND status = ?"
 			args = append(args, *filter.Status)
 		}
+		if condition, tagArgs := tagCondition("service_id", filter.Tags); condition != "" {
+			query += condition
+			args = append(args, tagArgs...)
+		}
 		if filter.FromDate != nil {
 			query += " AND created_at >= ?"
 			args = append(args, *filter.FromDate)
@@ -296,17 +326,20 @@ func (r *NotificationHistoryRepository) GetCount(filter *models.NotificationHist
 	}
 
 	var count int
-	err := DB.QueryRow(query, args...).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	return count, err
 }
 
 // GetStats returns aggregated statistics
-func (r *NotificationHistoryRepository) GetStats(days int) (map[string]interface{}, error) {
+func (r *notificationHistoryRepository) GetStats(ctx context.Context, days int) (map[string]interface{}, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	cutoff := time.Now().AddDate(0, 0, -days)
 
 	// Total sent
 	var totalSent int
-	err := DB.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM notification_history
 		WHERE created_at >= ? AND status = 'sent'
 	`, cutoff).Scan(&totalSent)
@@ -316,7 +349,7 @@ func (r *NotificationHistoryRepository) GetStats(days int) (map[string]interface
 
 	// Total failed
 	var totalFailed int
-	err = DB.QueryRow(`
+	err = r.db.QueryRowContext(ctx, `
 		SELECT COUNT(*) FROM notification_history
 		WHERE created_at >= ? AND status = 'failed'
 	`, cutoff).Scan(&totalFailed)
@@ -326,7 +359,7 @@ func (r *NotificationHistoryRepository) GetStats(days int) (map[string]interface
 
 	// By channel
 	byChannel := make(map[string]int)
-	rows, err := DB.Query(`
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT channel_name, COUNT(*) as count
 		FROM notification_history
 		WHERE created_at >= ?
@@ -348,7 +381,7 @@ func (r *NotificationHistoryRepository) GetStats(days int) (map[string]interface
 
 	// By alert type
 	byAlertType := make(map[string]int)
-	rows2, err := DB.Query(`
+	rows2, err := r.db.QueryContext(ctx, `
 		SELECT alert_type, COUNT(*) as count
 		FROM notification_history
 		WHERE created_at >= ?
@@ -377,10 +410,46 @@ func (r *NotificationHistoryRepository) GetStats(days int) (map[string]interface
 	}, nil
 }
 
+// GetFailures counts the deliveries that failed since a time by channel
+// and error
+func (r *notificationHistoryRepository) GetFailures(ctx context.Context, since time.Time) ([]models.DeliveryFailure, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT channel_id, channel_name, channel_type, COALESCE(error_message, ''), COUNT(*)
+		FROM notification_history
+		WHERE created_at >= ? AND status = 'failed'
+		GROUP BY channel_id, channel_name, channel_type, error_message
+		ORDER BY COUNT(*) DESC
+	`, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var failures []models.DeliveryFailure
+	for rows.Next() {
+		var f models.DeliveryFailure
+		if err := rows.Scan(&f.ChannelID, &f.ChannelName, &f.ChannelType, &f.Error, &f.Count); err != nil {
+			return nil, err
+		}
+		failures = append(failures, f)
+	}
+	return failures, rows.Err()
+}
+
 // DeleteOlderThan deletes records older than the specified duration
-func (r *NotificationHistoryRepository) DeleteOlderThan(days int) (int64, error) {
-	cutoff := time.Now().AddDate(0, 0, -days)
-	result, err := DB.Exec(`
+func (r *notificationHistoryRepository) DeleteOlderThan(ctx context.Context, days int) (int64, error) {
+	return r.DeleteBefore(ctx, time.Now().AddDate(0, 0, -days))
+}
+
+// DeleteBefore deletes records created before cutoff
+func (r *notificationHistoryRepository) DeleteBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
 		DELETE FROM notification_history WHERE created_at < ?
 	`, cutoff)
 	if err != nil {
@@ -389,6 +458,58 @@ func (r *NotificationHistoryRepository) DeleteOlderThan(days int) (int64, error)
 	return result.RowsAffected()
 }
 
+// GetOlderThan returns up to limit records created before cutoff, oldest first
+func (r *notificationHistoryRepository) GetOlderThan(ctx context.Context, cutoff time.Time, limit int) ([]models.NotificationHistory, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, rule_id, channel_id, channel_name, channel_type,
+		       alert_type, severity, host_id, host_name,
+		       service_id, service_name, message, status,
+		       error_message, retry_count, created_at, sent_at
+		FROM notification_history
+		WHERE created_at < ?
+		ORDER BY id
+		LIMIT ?
+	`, cutoff, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var histories []models.NotificationHistory
+	for rows.Next() {
+		history, err := scanNotificationHistory(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		histories = append(histories, history)
+	}
+	return histories, nil
+}
+
+// DeleteByIDs deletes the given records
+func (r *notificationHistoryRepository) DeleteByIDs(ctx context.Context, ids []int) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM notification_history WHERE id IN (`+placeholders(len(ids))+`)`, args...)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 // scanNotificationHistory is a helper to scan a single row
 func scanNotificationHistory(scan func(dest ...interface{}) error) (models.NotificationHistory, error) {
 	var history models.NotificationHistory
@@ -0,0 +1,372 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// preferenceRepository implements PreferenceRepository on SQLite
+type preferenceRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewPreferenceRepository creates a new dashboard preference repository
+func NewPreferenceRepository(db *sql.DB, timeout time.Duration) PreferenceRepository {
+	return &preferenceRepository{db: db, timeout: timeout}
+}
+
+// Get returns the preferences of owner in a project, empty ones if never
+// saved
+func (r *preferenceRepository) Get(ctx context.Context, projectID, owner string) (*models.DashboardPreferences, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p := &models.DashboardPreferences{
+		ProjectID:      projectID,
+		Owner:          owner,
+		PinnedServices: []string{},
+		PinnedHosts:    []string{},
+		ServiceOrder:   []string{},
+		HostOrder:      []string{},
+	}
+	var pinnedServices, pinnedHosts, serviceOrder, hostOrder sql.NullString
+	var updatedAt time.Time
+	err := r.db.QueryRowContext(ctx, `
+		SELECT pinned_services, pinned_hosts, service_order, host_order, updated_at
+		FROM dashboard_preferences WHERE project_id = ? AND owner = ?
+	`, projectID, owner).Scan(&pinnedServices, &pinnedHosts, &serviceOrder, &hostOrder, &updatedAt)
+	if err == sql.ErrNoRows {
+		return p, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, f := range []struct {
+		column sql.NullString
+		list   *[]string
+	}{
+		{pinnedServices, &p.PinnedServices},
+		{pinnedHosts, &p.PinnedHosts},
+		{serviceOrder, &p.ServiceOrder},
+		{hostOrder, &p.HostOrder},
+	} {
+		if f.column.Valid && f.column.String != "" {
+			json.Unmarshal([]byte(f.column.String), f.list)
+		}
+	}
+	p.UpdatedAt = &updatedAt
+	return p, nil
+}
+
+// Save stores the preferences of p.Owner in p.ProjectID, replacing saved
+// ones
+func (r *preferenceRepository) Save(ctx context.Context, p *models.DashboardPreferences) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	columns := make([]interface{}, 0, 4)
+	for _, list := range [][]string{p.PinnedServices, p.PinnedHosts, p.ServiceOrder, p.HostOrder} {
+		if list == nil {
+			list = []string{}
+		}
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		columns = append(columns, string(data))
+	}
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO dashboard_preferences (project_id, owner, pinned_services, pinned_hosts, service_order, host_order, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project_id, owner) DO UPDATE SET
+			pinned_services = excluded.pinned_services,
+			pinned_hosts    = excluded.pinned_hosts,
+			service_order   = excluded.service_order,
+			host_order      = excluded.host_order,
+			updated_at      = excluded.updated_at
+	`, append(append([]interface{}{p.ProjectID, p.Owner}, columns...), now)...)
+	if err != nil {
+		return err
+	}
+	p.UpdatedAt = &now
+	return nil
+}
+
+// GetViews returns the saved views of owner in a project by name
+func (r *preferenceRepository) GetViews(ctx context.Context, projectID, owner string) ([]models.DashboardView, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, project_id, owner, name, filters, created_at, updated_at
+		FROM dashboard_views WHERE project_id = ? AND owner = ?
+		ORDER BY name, id
+	`, projectID, owner)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	views := []models.DashboardView{}
+	for rows.Next() {
+		v, err := scanDashboardView(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		views = append(views, *v)
+	}
+	return views, rows.Err()
+}
+
+// GetView returns a saved view of owner in a project, nil if it does not
+// exist
+func (r *preferenceRepository) GetView(ctx context.Context, projectID, owner string, id int64) (*models.DashboardView, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	v, err := scanDashboardView(r.db.QueryRowContext(ctx, `
+		SELECT id, project_id, owner, name, filters, created_at, updated_at
+		FROM dashboard_views WHERE project_id = ? AND owner = ? AND id = ?
+	`, projectID, owner, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return v, err
+}
+
+// CreateView inserts a view
+func (r *preferenceRepository) CreateView(ctx context.Context, v *models.DashboardView) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filters, err := marshalViewFilters(v.Filters)
+	if err != nil {
+		return err
+	}
+	v.CreatedAt = time.Now()
+	v.UpdatedAt = v.CreatedAt
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO dashboard_views (project_id, owner, name, filters, created_at, updated_at) VALUES (?, ?, ?, ?, ?, ?)
+	`, v.ProjectID, v.Owner, v.Name, filters, v.CreatedAt, v.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	v.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// UpdateView updates the name and filters of a view
+func (r *preferenceRepository) UpdateView(ctx context.Context, v *models.DashboardView) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	filters, err := marshalViewFilters(v.Filters)
+	if err != nil {
+		return err
+	}
+	v.UpdatedAt = time.Now()
+	_, err = r.db.ExecContext(ctx, `
+		UPDATE dashboard_views SET name = ?, filters = ?, updated_at = ? WHERE project_id = ? AND owner = ? AND id = ?
+	`, v.Name, filters, v.UpdatedAt, v.ProjectID, v.Owner, v.ID)
+	return err
+}
+
+// DeleteView deletes a view of owner in a project, reporting whether it
+// existed
+func (r *preferenceRepository) DeleteView(ctx context.Context, projectID, owner string, id int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM dashboard_views WHERE project_id = ? AND owner = ? AND id = ?`, projectID, owner, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// notificationPreferenceColumns are the columns read by
+// scanNotificationPreferences
+const notificationPreferenceColumns = `project_id, owner, enabled, telegram_chat_id, email, language, timezone,
+	severities, service_ids, host_ids, tags, all_alerts, updated_at`
+
+// GetNotifications returns the notification preferences of owner in a
+// project, disabled ones without channels if never saved
+func (r *preferenceRepository) GetNotifications(ctx context.Context, projectID, owner string) (*models.NotificationPreferences, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p, err := scanNotificationPreferences(r.db.QueryRowContext(ctx, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM notification_preferences WHERE project_id = ? AND owner = ?
+	`, projectID, owner).Scan)
+	if err == sql.ErrNoRows {
+		return &models.NotificationPreferences{
+			ProjectID:  projectID,
+			Owner:      owner,
+			Severities: []models.AlertSeverity{},
+			ServiceIDs: []string{},
+			HostIDs:    []string{},
+			Tags:       []string{},
+		}, nil
+	}
+	return p, err
+}
+
+// GetEnabledNotifications returns the enabled notification preferences of
+// every owner of every project
+func (r *preferenceRepository) GetEnabledNotifications(ctx context.Context) ([]models.NotificationPreferences, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+notificationPreferenceColumns+`
+		FROM notification_preferences WHERE enabled = 1
+		ORDER BY project_id, owner
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var prefs []models.NotificationPreferences
+	for rows.Next() {
+		p, err := scanNotificationPreferences(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		prefs = append(prefs, *p)
+	}
+	return prefs, rows.Err()
+}
+
+// SaveNotifications stores the notification preferences of p.Owner in
+// p.ProjectID, replacing saved ones
+func (r *preferenceRepository) SaveNotifications(ctx context.Context, p *models.NotificationPreferences) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if p.Severities == nil {
+		p.Severities = []models.AlertSeverity{}
+	}
+	severities, err := json.Marshal(p.Severities)
+	if err != nil {
+		return err
+	}
+	lists := make([]interface{}, 0, 3)
+	for _, list := range [][]string{p.ServiceIDs, p.HostIDs, p.Tags} {
+		if list == nil {
+			list = []string{}
+		}
+		data, err := json.Marshal(list)
+		if err != nil {
+			return err
+		}
+		lists = append(lists, string(data))
+	}
+
+	now := time.Now()
+	args := []interface{}{p.ProjectID, p.Owner, p.Enabled, p.TelegramChatID, p.Email, p.Language, p.Timezone, string(severities)}
+	args = append(append(args, lists...), p.AllAlerts, now)
+	_, err = r.db.ExecContext(ctx, `
+		INSERT INTO notification_preferences (`+notificationPreferenceColumns+`)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project_id, owner) DO UPDATE SET
+			enabled          = excluded.enabled,
+			telegram_chat_id = excluded.telegram_chat_id,
+			email            = excluded.email,
+			language         = excluded.language,
+			timezone         = excluded.timezone,
+			severities       = excluded.severities,
+			service_ids      = excluded.service_ids,
+			host_ids         = excluded.host_ids,
+			tags             = excluded.tags,
+			all_alerts       = excluded.all_alerts,
+			updated_at       = excluded.updated_at
+	`, args...)
+	if err != nil {
+		return err
+	}
+	p.UpdatedAt = &now
+	return nil
+}
+
+// DeleteNotifications deletes the notification preferences of owner in a
+// project, reporting whether they existed
+func (r *preferenceRepository) DeleteNotifications(ctx context.Context, projectID, owner string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM notification_preferences WHERE project_id = ? AND owner = ?`, projectID, owner)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+func scanNotificationPreferences(scan func(dest ...interface{}) error) (*models.NotificationPreferences, error) {
+	p := &models.NotificationPreferences{
+		Severities: []models.AlertSeverity{},
+		ServiceIDs: []string{},
+		HostIDs:    []string{},
+		Tags:       []string{},
+	}
+	var telegramChatID, email, language, timezone sql.NullString
+	var severities, serviceIDs, hostIDs, tags sql.NullString
+	var updatedAt time.Time
+	if err := scan(&p.ProjectID, &p.Owner, &p.Enabled, &telegramChatID, &email, &language, &timezone,
+		&severities, &serviceIDs, &hostIDs, &tags, &p.AllAlerts, &updatedAt); err != nil {
+		return nil, err
+	}
+	p.TelegramChatID = telegramChatID.String
+	p.Email = email.String
+	p.Language = language.String
+	p.Timezone = timezone.String
+	if severities.Valid && severities.String != "" {
+		json.Unmarshal([]byte(severities.String), &p.Severities)
+	}
+	for _, f := range []struct {
+		column sql.NullString
+		list   *[]string
+	}{
+		{serviceIDs, &p.ServiceIDs},
+		{hostIDs, &p.HostIDs},
+		{tags, &p.Tags},
+	} {
+		if f.column.Valid && f.column.String != "" {
+			json.Unmarshal([]byte(f.column.String), f.list)
+		}
+	}
+	p.UpdatedAt = &updatedAt
+	return p, nil
+}
+
+func scanDashboardView(scan func(dest ...interface{}) error) (*models.DashboardView, error) {
+	var v models.DashboardView
+	var filters sql.NullString
+	if err := scan(&v.ID, &v.ProjectID, &v.Owner, &v.Name, &filters, &v.CreatedAt, &v.UpdatedAt); err != nil {
+		return nil, err
+	}
+	v.Filters = map[string]interface{}{}
+	if filters.Valid && filters.String != "" {
+		json.Unmarshal([]byte(filters.String), &v.Filters)
+	}
+	return &v, nil
+}
+
+func marshalViewFilters(filters map[string]interface{}) (string, error) {
+	if filters == nil {
+		filters = map[string]interface{}{}
+	}
+	data, err := json.Marshal(filters)
+	return string(data), err
+}
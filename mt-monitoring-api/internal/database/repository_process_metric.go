@@ -0,0 +1,117 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// processMetricRepository implements ProcessMetricRepository on SQLite
+type processMetricRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewProcessMetricRepository creates a new process metric repository
+func NewProcessMetricRepository(db *sql.DB, timeout time.Duration) ProcessMetricRepository {
+	return &processMetricRepository{db: db, timeout: timeout}
+}
+
+// CreateSample stores the top processes of a host at a time
+func (r *processMetricRepository) CreateSample(ctx context.Context, hostID string, at time.Time, processes []models.ProcessInfo) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		stmt, err := tx.PrepareContext(ctx, `
+			INSERT INTO process_metrics (host_id, pid, name, cpu, memory, memory_bytes, status, created_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		`)
+		if err != nil {
+			return err
+		}
+		defer stmt.Close()
+
+		for _, p := range processes {
+			if _, err := stmt.ExecContext(ctx, hostID, p.PID, p.Name, p.CPU, p.Memory, p.MemoryBytes, p.Status, at); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// GetHistory returns the process samples of a host since a time, oldest
+// first
+func (r *processMetricRepository) GetHistory(ctx context.Context, hostID string, since time.Time) ([]models.ProcessSample, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	return r.samples(ctx, `
+		SELECT created_at, pid, name, cpu, memory, memory_bytes, status
+		FROM process_metrics
+		WHERE host_id = ? AND created_at >= ?
+		ORDER BY created_at ASC, cpu DESC
+	`, hostID, since)
+}
+
+// GetSampleAt returns the last process sample of a host at or before a
+// time, nil when there is none
+func (r *processMetricRepository) GetSampleAt(ctx context.Context, hostID string, at time.Time) (*models.ProcessSample, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	samples, err := r.samples(ctx, `
+		SELECT created_at, pid, name, cpu, memory, memory_bytes, status
+		FROM process_metrics
+		WHERE host_id = ? AND created_at = (
+			SELECT MAX(created_at) FROM process_metrics WHERE host_id = ? AND created_at <= ?
+		)
+		ORDER BY cpu DESC
+	`, hostID, hostID, at)
+	if err != nil || len(samples) == 0 {
+		return nil, err
+	}
+	return &samples[0], nil
+}
+
+// samples groups the process rows of a query by their created_at
+func (r *processMetricRepository) samples(ctx context.Context, query string, args ...any) ([]models.ProcessSample, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	samples := []models.ProcessSample{}
+	for rows.Next() {
+		var ts time.Time
+		var p models.ProcessInfo
+		if err := rows.Scan(&ts, &p.PID, &p.Name, &p.CPU, &p.Memory, &p.MemoryBytes, &p.Status); err != nil {
+			return nil, err
+		}
+		timestamp := ts.Format(time.RFC3339)
+		if n := len(samples); n == 0 || samples[n-1].Timestamp != timestamp {
+			samples = append(samples, models.ProcessSample{Timestamp: timestamp})
+		}
+		last := &samples[len(samples)-1]
+		last.Processes = append(last.Processes, p)
+	}
+	return samples, rows.Err()
+}
+
+// DeleteOld deletes process samples older than the retention
+func (r *processMetricRepository) DeleteOld(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM process_metrics WHERE created_at < ?
+	`, time.Now().Add(-retention))
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,212 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// projectResourceTables maps the resource kinds of a project to their tables
+var projectResourceTables = map[string]string{
+	models.ProjectService:   "services",
+	models.ProjectHost:      "hosts",
+	models.ProjectAlertRule: "alert_rules",
+	models.ProjectChannel:   "notification_channels",
+}
+
+// projectRepository implements ProjectRepository on SQLite
+type projectRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewProjectRepository creates a new project repository
+func NewProjectRepository(db *sql.DB, timeout time.Duration) ProjectRepository {
+	return &projectRepository{db: db, timeout: timeout}
+}
+
+// projectSelectColumns is the column list for project queries
+const projectSelectColumns = `id, name, description, token, created_at, updated_at`
+
+// GetAll returns all projects, the default one first
+func (r *projectRepository) GetAll(ctx context.Context) ([]models.Project, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+projectSelectColumns+`
+		FROM projects
+		ORDER BY id != ?, name, id
+	`, models.DefaultProject)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	projects := []models.Project{}
+	for rows.Next() {
+		p, err := scanProject(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		projects = append(projects, *p)
+	}
+	return projects, rows.Err()
+}
+
+// GetByID returns a project, nil if it does not exist
+func (r *projectRepository) GetByID(ctx context.Context, id string) (*models.Project, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p, err := scanProject(r.db.QueryRowContext(ctx, `
+		SELECT `+projectSelectColumns+` FROM projects WHERE id = ?
+	`, id).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetByToken returns the project authenticated by token, nil if none is
+func (r *projectRepository) GetByToken(ctx context.Context, token string) (*models.Project, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p, err := scanProject(r.db.QueryRowContext(ctx, `
+		SELECT `+projectSelectColumns+` FROM projects WHERE token = ?
+	`, token).Scan)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+// Create inserts a project
+func (r *projectRepository) Create(ctx context.Context, p *models.Project) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	p.CreatedAt = now
+	p.UpdatedAt = now
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO projects (id, name, description, token, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, p.ID, p.Name, p.Description, nullableToken(p.Token), p.CreatedAt, p.UpdatedAt)
+	return err
+}
+
+// Update replaces the name and description of a project
+func (r *projectRepository) Update(ctx context.Context, p *models.Project) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	p.UpdatedAt = time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE projects SET name = ?, description = ?, updated_at = ? WHERE id = ?
+	`, p.Name, p.Description, p.UpdatedAt, p.ID)
+	return err
+}
+
+// UpdateToken replaces the token of a project, revoking the previous one
+func (r *projectRepository) UpdateToken(ctx context.Context, id, token string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE projects SET token = ?, updated_at = ? WHERE id = ?
+	`, nullableToken(token), time.Now(), id)
+	return err
+}
+
+// CountResources returns the number of services, hosts, alert rules and
+// notification channels of a project
+func (r *projectRepository) CountResources(ctx context.Context, id string) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT (SELECT COUNT(*) FROM services WHERE project_id = ?)
+		     + (SELECT COUNT(*) FROM hosts WHERE project_id = ?)
+		     + (SELECT COUNT(*) FROM alert_rules WHERE project_id = ?)
+		     + (SELECT COUNT(*) FROM notification_channels WHERE project_id = ?)
+	`, id, id, id, id).Scan(&count)
+	return count, err
+}
+
+// Delete deletes a project with the preferences and views of its users
+func (r *projectRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM dashboard_preferences WHERE project_id = ?`,
+		`DELETE FROM dashboard_views WHERE project_id = ?`,
+		`DELETE FROM notification_preferences WHERE project_id = ?`,
+		`DELETE FROM projects WHERE id = ?`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, id); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// GetResourceProject returns the project of a service, host, alert rule or
+// notification channel, "" when it does not exist
+func (r *projectRepository) GetResourceProject(ctx context.Context, kind, id string) (string, error) {
+	table, ok := projectResourceTables[kind]
+	if !ok {
+		return "", fmt.Errorf("unknown project resource kind %q", kind)
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var projectID string
+	err := r.db.QueryRowContext(ctx, `SELECT project_id FROM `+table+` WHERE id = ?`, id).Scan(&projectID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	return projectID, err
+}
+
+// nullableToken stores a missing token as NULL, which the unique index
+// allows any number of times
+func nullableToken(token string) interface{} {
+	if token == "" {
+		return nil
+	}
+	return token
+}
+
+func scanProject(scan func(dest ...interface{}) error) (*models.Project, error) {
+	var p models.Project
+	var description, token sql.NullString
+	if err := scan(&p.ID, &p.Name, &description, &token, &p.CreatedAt, &p.UpdatedAt); err != nil {
+		return nil, err
+	}
+	p.Description = description.String
+	p.Token = token.String
+	return &p, nil
+}
+
+// projectOrDefault returns the project of a new resource, the default one
+// when it names none
+func projectOrDefault(projectID string) string {
+	if projectID == "" {
+		return models.DefaultProject
+	}
+	return projectID
+}
@@ -0,0 +1,120 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// remediationRepository implements RemediationRepository on SQLite
+type remediationRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewRemediationRepository creates a new remediation run repository
+func NewRemediationRepository(db *sql.DB, timeout time.Duration) RemediationRepository {
+	return &remediationRepository{db: db, timeout: timeout}
+}
+
+// remediationRunColumns is the column list of remediation run queries
+const remediationRunColumns = `id, rule_id, rule_name, host_id, service_id, incident_id, type, target,
+	command, status, output, error, started_at, duration_ms`
+
+// Create records a remediation run
+func (r *remediationRepository) Create(ctx context.Context, run *models.RemediationRun) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO remediation_runs (rule_id, rule_name, host_id, service_id, incident_id, type, target,
+		                              command, status, output, error, started_at, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, run.RuleID, run.RuleName, run.HostID, run.ServiceID, run.IncidentID, run.Type, run.Target,
+		run.Command, run.Status, run.Output, run.Error, run.StartedAt, run.Duration)
+	if err != nil {
+		return err
+	}
+	run.ID, err = result.LastInsertId()
+	return err
+}
+
+// CountSince returns how many times the remediation of a rule ran for a
+// host or service since the given time. Skipped runs do not count.
+func (r *remediationRepository) CountSince(ctx context.Context, ruleID, hostID, serviceID string, since time.Time) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var count int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM remediation_runs
+		WHERE rule_id = ? AND host_id = ? AND service_id = ? AND started_at >= ? AND status != ?
+	`, ruleID, hostID, serviceID, since, models.RemediationSkipped).Scan(&count)
+	return count, err
+}
+
+// GetByRule returns the latest runs of a rule, newest first
+func (r *remediationRepository) GetByRule(ctx context.Context, ruleID string, limit int) ([]models.RemediationRun, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+remediationRunColumns+`
+		FROM remediation_runs
+		WHERE rule_id = ?
+		ORDER BY started_at DESC, id DESC
+		LIMIT ?
+	`, ruleID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRemediationRuns(rows)
+}
+
+// GetByIncidents returns the runs attached to the given incidents, oldest
+// first
+func (r *remediationRepository) GetByIncidents(ctx context.Context, incidentIDs []int64) ([]models.RemediationRun, error) {
+	if len(incidentIDs) == 0 {
+		return nil, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(incidentIDs))
+	for i, id := range incidentIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+remediationRunColumns+`
+		FROM remediation_runs
+		WHERE incident_id IN (`+placeholders(len(incidentIDs))+`)
+		ORDER BY started_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanRemediationRuns(rows)
+}
+
+func scanRemediationRuns(rows *sql.Rows) ([]models.RemediationRun, error) {
+	runs := []models.RemediationRun{}
+	for rows.Next() {
+		var run models.RemediationRun
+		var incidentID sql.NullInt64
+		if err := rows.Scan(&run.ID, &run.RuleID, &run.RuleName, &run.HostID, &run.ServiceID, &incidentID,
+			&run.Type, &run.Target, &run.Command, &run.Status, &run.Output, &run.Error,
+			&run.StartedAt, &run.Duration); err != nil {
+			return nil, err
+		}
+		if incidentID.Valid {
+			run.IncidentID = &incidentID.Int64
+		}
+		runs = append(runs, run)
+	}
+	return runs, rows.Err()
+}
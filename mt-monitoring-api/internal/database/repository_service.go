@@ -1,27 +1,36 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"encoding/json"
 	"time"
 
+	"github.com/mt-monitoring/api/internal/crypto"
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// ServiceRepository handles service data operations
-type ServiceRepository struct{}
+// serviceRepository implements ServiceRepository on SQLite
+type serviceRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewServiceRepository creates a new service repository
-func NewServiceRepository() *ServiceRepository {
-	return &ServiceRepository{}
+func NewServiceRepository(db *sql.DB, timeout time.Duration) ServiceRepository {
+	return &serviceRepository{db: db, timeout: timeout}
 }
 
 // GetAll returns all services
-func (r *ServiceRepository) GetAll() ([]models.Service, error) {
-	rows, err := DB.Query(`
+func (r *serviceRepository) GetAll(ctx context.Context) ([]models.Service, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, name, type, is_active, url, port, method, headers, body,
 		       expected_status, interval, timeout, tags, schedule_type, cron_expression,
-		       created_at, updated_at
+		       log_retention, ingest_rate_limit, ingest_max_payload, ingest_dropped,
+		       ping_key, grace, metric_sampling, ca_cert, insecure_skip_verify, protocol, selector, callback_url, callback_on, auth, ownership, project_id, created_at, updated_at, `+serviceChannelsColumn+`
 		FROM services
 		ORDER BY name
 	`)
@@ -34,11 +43,12 @@ func (r *ServiceRepository) GetAll() ([]models.Service, error) {
 	for rows.Next() {
 		var s models.Service
 		var isActive int
-		var url, method, headers, body, tags, scheduleType, cronExpression sql.NullString
-		var port, expectedStatus, interval, timeout sql.NullInt64
+		var url, method, headers, body, tags, scheduleType, cronExpression, logRetention, pingKey, caCert, protocol, selector, callbackURL, callbackOn, auth, ownership, channels sql.NullString
+		var port, expectedStatus, interval, timeout, ingestRateLimit, ingestMaxPayload, ingestDropped, grace, metricSampling, insecure sql.NullInt64
 		if err := rows.Scan(&s.ID, &s.Name, &s.Type, &isActive, &url, &port, &method, &headers, &body,
 			&expectedStatus, &interval, &timeout, &tags, &scheduleType, &cronExpression,
-			&s.CreatedAt, &s.UpdatedAt); err != nil {
+			&logRetention, &ingestRateLimit, &ingestMaxPayload, &ingestDropped,
+			&pingKey, &grace, &metricSampling, &caCert, &insecure, &protocol, &selector, &callbackURL, &callbackOn, &auth, &ownership, &s.ProjectID, &s.CreatedAt, &s.UpdatedAt, &channels); err != nil {
 			return nil, err
 		}
 		s.IsActive = isActive == 1
@@ -77,27 +87,135 @@ func (r *ServiceRepository) GetAll() ([]models.Service, error) {
 		if cronExpression.Valid {
 			s.CronExpression = cronExpression.String
 		}
+		if logRetention.Valid {
+			s.LogRetention = logRetention.String
+		}
+		s.IngestRateLimit = int(ingestRateLimit.Int64)
+		s.IngestMaxPayload = int(ingestMaxPayload.Int64)
+		s.DroppedLogs = ingestDropped.Int64
+		s.PingKey = pingKey.String
+		s.Grace = int(grace.Int64)
+		s.MetricSampling = int(metricSampling.Int64)
+		s.CACert = caCert.String
+		s.InsecureSkipVerify = insecure.Int64 == 1
+		s.Protocol = models.HTTPProtocol(protocol.String)
+		s.Selector = selector.String
+		s.CallbackURL = callbackURL.String
+		s.CallbackOn = models.CallbackMode(callbackOn.String)
+		s.Auth = decodeServiceAuth(auth)
+		s.Ownership = decodeOwnership(ownership)
+		s.ChannelIDs = parseChannelIDs(channels)
 		s.Status = models.StatusUnknown
 		services = append(services, s)
 	}
 	return services, nil
 }
 
+// serviceChannelsColumn selects the channel IDs of a service as a JSON array
+const serviceChannelsColumn = `(SELECT json_group_array(channel_id) FROM service_channels WHERE service_id = services.id)`
+
+// parseChannelIDs decodes the serviceChannelsColumn of a row, sorted
+func parseChannelIDs(channels sql.NullString) []string {
+	ids := []string{}
+	if channels.Valid {
+		json.Unmarshal([]byte(channels.String), &ids)
+	}
+	return models.NormalizeChannelIDs(ids)
+}
+
+// encodeServiceAuth returns the auth column of a service: its credentials
+// as encrypted JSON, empty without credentials
+func encodeServiceAuth(auth *models.ServiceAuth) (string, error) {
+	if auth == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(auth)
+	if err != nil {
+		return "", err
+	}
+	return crypto.Encrypt(string(data))
+}
+
+// decodeServiceAuth decodes the auth column of a service, nil when it is
+// empty or does not decrypt with the master key
+func decodeServiceAuth(column sql.NullString) *models.ServiceAuth {
+	if column.String == "" {
+		return nil
+	}
+	data, err := crypto.Decrypt(column.String)
+	if err != nil {
+		return nil
+	}
+	var auth models.ServiceAuth
+	if err := json.Unmarshal([]byte(data), &auth); err != nil {
+		return nil
+	}
+	return &auth
+}
+
+// encodeOwnership encodes the ownership column of a service or host, NULL
+// for none
+func encodeOwnership(o *models.Ownership) (interface{}, error) {
+	if o.IsZero() {
+		return nil, nil
+	}
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// decodeOwnership decodes the ownership column of a service or host
+func decodeOwnership(column sql.NullString) *models.Ownership {
+	if column.String == "" {
+		return nil
+	}
+	var o models.Ownership
+	if err := json.Unmarshal([]byte(column.String), &o); err != nil {
+		return nil
+	}
+	return o.Normalized()
+}
+
+// setServiceChannels replaces the channel bindings of a service; nil keeps
+// them
+func setServiceChannels(ctx context.Context, tx *sql.Tx, serviceID string, channelIDs []string) error {
+	if channelIDs == nil {
+		return nil
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM service_channels WHERE service_id = ?`, serviceID); err != nil {
+		return err
+	}
+	for _, id := range channelIDs {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO service_channels (service_id, channel_id) VALUES (?, ?)`,
+			serviceID, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetByID returns a service by ID
-func (r *ServiceRepository) GetByID(id string) (*models.Service, error) {
+func (r *serviceRepository) GetByID(ctx context.Context, id string) (*models.Service, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var s models.Service
 	var isActive int
-	var url, method, headers, body, tags, scheduleType, cronExpression sql.NullString
-	var port, expectedStatus, interval, timeout sql.NullInt64
+	var url, method, headers, body, tags, scheduleType, cronExpression, logRetention, pingKey, caCert, protocol, selector, callbackURL, callbackOn, auth, ownership, channels sql.NullString
+	var port, expectedStatus, interval, timeout, ingestRateLimit, ingestMaxPayload, ingestDropped, grace, metricSampling, insecure sql.NullInt64
 
-	err := DB.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT id, name, type, is_active, url, port, method, headers, body,
 		       expected_status, interval, timeout, tags, schedule_type, cron_expression,
-		       created_at, updated_at
+		       log_retention, ingest_rate_limit, ingest_max_payload, ingest_dropped,
+		       ping_key, grace, metric_sampling, ca_cert, insecure_skip_verify, protocol, selector, callback_url, callback_on, auth, ownership, project_id, created_at, updated_at, `+serviceChannelsColumn+`
 		FROM services WHERE id = ?
 	`, id).Scan(&s.ID, &s.Name, &s.Type, &isActive, &url, &port, &method, &headers, &body,
 		&expectedStatus, &interval, &timeout, &tags, &scheduleType, &cronExpression,
-		&s.CreatedAt, &s.UpdatedAt)
+		&logRetention, &ingestRateLimit, &ingestMaxPayload, &ingestDropped,
+		&pingKey, &grace, &metricSampling, &caCert, &insecure, &protocol, &selector, &callbackURL, &callbackOn, &auth, &ownership, &s.ProjectID, &s.CreatedAt, &s.UpdatedAt, &channels)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -142,13 +260,34 @@ func (r *ServiceRepository) GetByID(id string) (*models.Service, error) {
 	if cronExpression.Valid {
 		s.CronExpression = cronExpression.String
 	}
+	if logRetention.Valid {
+		s.LogRetention = logRetention.String
+	}
+	s.IngestRateLimit = int(ingestRateLimit.Int64)
+	s.IngestMaxPayload = int(ingestMaxPayload.Int64)
+	s.DroppedLogs = ingestDropped.Int64
+	s.PingKey = pingKey.String
+	s.Grace = int(grace.Int64)
+	s.MetricSampling = int(metricSampling.Int64)
+	s.CACert = caCert.String
+	s.InsecureSkipVerify = insecure.Int64 == 1
+	s.Protocol = models.HTTPProtocol(protocol.String)
+	s.Selector = selector.String
+	s.CallbackURL = callbackURL.String
+	s.CallbackOn = models.CallbackMode(callbackOn.String)
+	s.Auth = decodeServiceAuth(auth)
+	s.Ownership = decodeOwnership(ownership)
+	s.ChannelIDs = parseChannelIDs(channels)
 	s.Status = models.StatusUnknown
 
 	return &s, nil
 }
 
 // Create creates a new service
-func (r *ServiceRepository) Create(s *models.Service) error {
+func (r *serviceRepository) Create(ctx context.Context, s *models.Service) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var headersJSON, tagsJSON []byte
 	var err error
 
@@ -158,6 +297,7 @@ func (r *ServiceRepository) Create(s *models.Service) error {
 			return err
 		}
 	}
+	s.Tags = models.NormalizeTags(s.Tags)
 	if s.Tags != nil {
 		tagsJSON, err = json.Marshal(s.Tags)
 		if err != nil {
@@ -165,10 +305,13 @@ func (r *ServiceRepository) Create(s *models.Service) error {
 		}
 	}
 
-	isActive := 0
+	isActive, insecure := 0, 0
 	if s.IsActive {
 		isActive = 1
 	}
+	if s.InsecureSkipVerify {
+		insecure = 1
+	}
 
 	// Default to "interval" if not set
 	scheduleType := string(s.ScheduleType)
@@ -176,25 +319,51 @@ func (r *ServiceRepository) Create(s *models.Service) error {
 		scheduleType = string(models.ScheduleTypeInterval)
 	}
 
-	_, err = DB.Exec(`
-		INSERT INTO services (id, name, type, is_active, url, port, method, headers, body,
-		                      expected_status, interval, timeout, tags, schedule_type, cron_expression,
-		                      api_key, created_at, updated_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
-	`, s.ID, s.Name, s.Type, isActive, s.URL, s.Port, s.Method, string(headersJSON), s.Body,
-		s.ExpectedStatus, s.Interval, s.Timeout, string(tagsJSON), scheduleType, s.CronExpression,
-		s.ApiKey, s.CreatedAt, s.UpdatedAt)
-	return err
+	auth, err := encodeServiceAuth(s.Auth)
+	if err != nil {
+		return err
+	}
+	ownership, err := encodeOwnership(s.Ownership)
+	if err != nil {
+		return err
+	}
+	s.ProjectID = projectOrDefault(s.ProjectID)
+
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO services (id, name, type, is_active, url, port, method, headers, body,
+			                      expected_status, interval, timeout, tags, schedule_type, cron_expression,
+			                      log_retention, ingest_rate_limit, ingest_max_payload, api_key, ping_key, grace,
+			                      metric_sampling, ca_cert, insecure_skip_verify, protocol, selector, callback_url, callback_on, auth, ownership, project_id, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		`, s.ID, s.Name, s.Type, isActive, s.URL, s.Port, s.Method, string(headersJSON), s.Body,
+			s.ExpectedStatus, s.Interval, s.Timeout, string(tagsJSON), scheduleType, s.CronExpression,
+			s.LogRetention, s.IngestRateLimit, s.IngestMaxPayload, s.ApiKey, s.PingKey, s.Grace,
+			s.MetricSampling, s.CACert, insecure, s.Protocol, s.Selector, s.CallbackURL, s.CallbackOn, auth, ownership, s.ProjectID, s.CreatedAt, s.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if err := setServiceChannels(ctx, tx, s.ID, s.ChannelIDs); err != nil {
+			return err
+		}
+		return setServiceTags(ctx, tx, s.ID, s.Tags)
+	})
 }
 
 // UpdateApiKey updates only the api_key field of a service
-func (r *ServiceRepository) UpdateApiKey(id, apiKey string) error {
-	_, err := DB.Exec(`UPDATE services SET api_key = ?, updated_at = ? WHERE id = ?`, apiKey, time.Now(), id)
+func (r *serviceRepository) UpdateApiKey(ctx context.Context, id, apiKey string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE services SET api_key = ?, updated_at = ? WHERE id = ?`, apiKey, time.Now(), id)
 	return err
 }
 
 // Update updates a service
-func (r *ServiceRepository) Update(s *models.Service) error {
+func (r *serviceRepository) Update(ctx context.Context, s *models.Service) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var headersJSON, tagsJSON []byte
 	var err error
 
@@ -204,6 +373,7 @@ func (r *ServiceRepository) Update(s *models.Service) error {
 			return err
 		}
 	}
+	s.Tags = models.NormalizeTags(s.Tags)
 	if s.Tags != nil {
 		tagsJSON, err = json.Marshal(s.Tags)
 		if err != nil {
@@ -211,10 +381,13 @@ func (r *ServiceRepository) Update(s *models.Service) error {
 		}
 	}
 
-	isActive := 0
+	isActive, insecure := 0, 0
 	if s.IsActive {
 		isActive = 1
 	}
+	if s.InsecureSkipVerify {
+		insecure = 1
+	}
 
 	// Default to "interval" if not set
 	scheduleType := string(s.ScheduleType)
@@ -222,24 +395,49 @@ func (r *ServiceRepository) Update(s *models.Service) error {
 		scheduleType = string(models.ScheduleTypeInterval)
 	}
 
+	auth, err := encodeServiceAuth(s.Auth)
+	if err != nil {
+		return err
+	}
+	ownership, err := encodeOwnership(s.Ownership)
+	if err != nil {
+		return err
+	}
+
 	s.UpdatedAt = time.Now()
-	_, err = DB.Exec(`
-		UPDATE services SET name = ?, type = ?, is_active = ?, url = ?, port = ?, method = ?,
-		                    headers = ?, body = ?, expected_status = ?, interval = ?, timeout = ?,
-		                    tags = ?, schedule_type = ?, cron_expression = ?, updated_at = ?
-		WHERE id = ?
-	`, s.Name, s.Type, isActive, s.URL, s.Port, s.Method, string(headersJSON), s.Body,
-		s.ExpectedStatus, s.Interval, s.Timeout, string(tagsJSON), scheduleType, s.CronExpression,
-		s.UpdatedAt, s.ID)
-	return err
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE services SET name = ?, type = ?, is_active = ?, url = ?, port = ?, method = ?,
+			                    headers = ?, body = ?, expected_status = ?, interval = ?, timeout = ?,
+			                    tags = ?, schedule_type = ?, cron_expression = ?, log_retention = ?,
+			                    ingest_rate_limit = ?, ingest_max_payload = ?, ping_key = ?, grace = ?,
+			                    metric_sampling = ?, ca_cert = ?, insecure_skip_verify = ?, protocol = ?, selector = ?, callback_url = ?, callback_on = ?, auth = ?, ownership = ?,
+			                    updated_at = ?
+			WHERE id = ?
+		`, s.Name, s.Type, isActive, s.URL, s.Port, s.Method, string(headersJSON), s.Body,
+			s.ExpectedStatus, s.Interval, s.Timeout, string(tagsJSON), scheduleType, s.CronExpression,
+			s.LogRetention, s.IngestRateLimit, s.IngestMaxPayload, s.PingKey, s.Grace,
+			s.MetricSampling, s.CACert, insecure, s.Protocol, s.Selector, s.CallbackURL, s.CallbackOn, auth, ownership, s.UpdatedAt, s.ID)
+		if err != nil {
+			return err
+		}
+		if err := setServiceChannels(ctx, tx, s.ID, s.ChannelIDs); err != nil {
+			return err
+		}
+		return setServiceTags(ctx, tx, s.ID, s.Tags)
+	})
 }
 
 // GetActive returns all active services (is_active = 1)
-func (r *ServiceRepository) GetActive() ([]models.Service, error) {
-	rows, err := DB.Query(`
+func (r *serviceRepository) GetActive(ctx context.Context) ([]models.Service, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT id, name, type, is_active, url, port, method, headers, body,
 		       expected_status, interval, timeout, tags, schedule_type, cron_expression,
-		       created_at, updated_at
+		       log_retention, ingest_rate_limit, ingest_max_payload, ingest_dropped,
+		       ping_key, grace, metric_sampling, ca_cert, insecure_skip_verify, protocol, selector, callback_url, callback_on, auth, ownership, project_id, created_at, updated_at, `+serviceChannelsColumn+`
 		FROM services
 		WHERE is_active = 1
 		ORDER BY name
@@ -253,11 +451,12 @@ func (r *ServiceRepository) GetActive() ([]models.Service, error) {
 	for rows.Next() {
 		var s models.Service
 		var isActive int
-		var url, method, headers, body, tags, scheduleType, cronExpression sql.NullString
-		var port, expectedStatus, interval, timeout sql.NullInt64
+		var url, method, headers, body, tags, scheduleType, cronExpression, logRetention, pingKey, caCert, protocol, selector, callbackURL, callbackOn, auth, ownership, channels sql.NullString
+		var port, expectedStatus, interval, timeout, ingestRateLimit, ingestMaxPayload, ingestDropped, grace, metricSampling, insecure sql.NullInt64
 		if err := rows.Scan(&s.ID, &s.Name, &s.Type, &isActive, &url, &port, &method, &headers, &body,
 			&expectedStatus, &interval, &timeout, &tags, &scheduleType, &cronExpression,
-			&s.CreatedAt, &s.UpdatedAt); err != nil {
+			&logRetention, &ingestRateLimit, &ingestMaxPayload, &ingestDropped,
+			&pingKey, &grace, &metricSampling, &caCert, &insecure, &protocol, &selector, &callbackURL, &callbackOn, &auth, &ownership, &s.ProjectID, &s.CreatedAt, &s.UpdatedAt, &channels); err != nil {
 			return nil, err
 		}
 		s.IsActive = isActive == 1
@@ -296,6 +495,24 @@ func (r *ServiceRepository) GetActive() ([]models.Service, error) {
 		if cronExpression.Valid {
 			s.CronExpression = cronExpression.String
 		}
+		if logRetention.Valid {
+			s.LogRetention = logRetention.String
+		}
+		s.IngestRateLimit = int(ingestRateLimit.Int64)
+		s.IngestMaxPayload = int(ingestMaxPayload.Int64)
+		s.DroppedLogs = ingestDropped.Int64
+		s.PingKey = pingKey.String
+		s.Grace = int(grace.Int64)
+		s.MetricSampling = int(metricSampling.Int64)
+		s.CACert = caCert.String
+		s.InsecureSkipVerify = insecure.Int64 == 1
+		s.Protocol = models.HTTPProtocol(protocol.String)
+		s.Selector = selector.String
+		s.CallbackURL = callbackURL.String
+		s.CallbackOn = models.CallbackMode(callbackOn.String)
+		s.Auth = decodeServiceAuth(auth)
+		s.Ownership = decodeOwnership(ownership)
+		s.ChannelIDs = parseChannelIDs(channels)
 		s.Status = models.StatusUnknown
 		services = append(services, s)
 	}
@@ -303,32 +520,41 @@ func (r *ServiceRepository) GetActive() ([]models.Service, error) {
 }
 
 // SetActive sets the is_active flag for a service
-func (r *ServiceRepository) SetActive(id string, isActive bool) error {
+func (r *serviceRepository) SetActive(ctx context.Context, id string, isActive bool) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	active := 0
 	if isActive {
 		active = 1
 	}
-	_, err := DB.Exec(`UPDATE services SET is_active = ?, updated_at = ? WHERE id = ?`,
+	_, err := r.db.ExecContext(ctx, `UPDATE services SET is_active = ?, updated_at = ? WHERE id = ?`,
 		active, time.Now(), id)
 	return err
 }
 
 // GetByApiKey returns a service by its API key
-func (r *ServiceRepository) GetByApiKey(apiKey string) (*models.Service, error) {
+func (r *serviceRepository) GetByApiKey(ctx context.Context, apiKey string) (*models.Service, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	if apiKey == "" {
 		return nil, nil
 	}
 	var s models.Service
 	var isActive int
 	var headersJSON, tagsJSON, apiKeyVal sql.NullString
+	var ingestRateLimit, ingestMaxPayload, ingestDropped sql.NullInt64
 
-	err := DB.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT id, name, type, is_active, url, port, method, headers, body,
-		       expected_status, interval, timeout, tags, created_at, updated_at, api_key
+		       expected_status, interval, timeout, tags, created_at, updated_at, api_key,
+		       ingest_rate_limit, ingest_max_payload, ingest_dropped, project_id
 		FROM services WHERE api_key = ?
 	`, apiKey).Scan(&s.ID, &s.Name, &s.Type, &isActive, &s.URL, &s.Port, &s.Method,
 		&headersJSON, &s.Body, &s.ExpectedStatus, &s.Interval, &s.Timeout,
-		&tagsJSON, &s.CreatedAt, &s.UpdatedAt, &apiKeyVal)
+		&tagsJSON, &s.CreatedAt, &s.UpdatedAt, &apiKeyVal,
+		&ingestRateLimit, &ingestMaxPayload, &ingestDropped, &s.ProjectID)
 
 	if err == sql.ErrNoRows {
 		return nil, nil
@@ -347,12 +573,181 @@ func (r *ServiceRepository) GetByApiKey(apiKey string) (*models.Service, error)
 	if apiKeyVal.Valid {
 		s.ApiKey = apiKeyVal.String
 	}
+	s.IngestRateLimit = int(ingestRateLimit.Int64)
+	s.IngestMaxPayload = int(ingestMaxPayload.Int64)
+	s.DroppedLogs = ingestDropped.Int64
 
 	return &s, nil
 }
 
+// GetByPingKey returns the service with the given heartbeat ping key
+func (r *serviceRepository) GetByPingKey(ctx context.Context, pingKey string) (*models.Service, error) {
+	if pingKey == "" {
+		return nil, nil
+	}
+
+	var id string
+	queryCtx, cancel := withTimeout(ctx, r.timeout)
+	err := r.db.QueryRowContext(queryCtx, `SELECT id FROM services WHERE ping_key = ?`, pingKey).Scan(&id)
+	cancel()
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return r.GetByID(ctx, id)
+}
+
+// GetLogRetentionOverrides returns service ID → log retention for services that override the global policy
+func (r *serviceRepository) GetLogRetentionOverrides(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT id, log_retention FROM services WHERE log_retention IS NOT NULL AND log_retention != ''`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	overrides := make(map[string]string)
+	for rows.Next() {
+		var id, retention string
+		if err := rows.Scan(&id, &retention); err != nil {
+			return nil, err
+		}
+		overrides[id] = retention
+	}
+	return overrides, rows.Err()
+}
+
+// AddDroppedLogs adds n to the counter of log events rejected by ingestion quotas
+func (r *serviceRepository) AddDroppedLogs(ctx context.Context, id string, n int64) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `UPDATE services SET ingest_dropped = ingest_dropped + ? WHERE id = ?`, n, id)
+	return err
+}
+
+// viewedResolution is how old last_viewed_at gets before a view rewrites
+// it, so browsing the dashboard does not write on every request
+const viewedResolution = time.Hour
+
+// MarkViewed records that a service was opened in the dashboard
+func (r *serviceRepository) MarkViewed(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE services SET last_viewed_at = ?
+		WHERE id = ? AND (last_viewed_at IS NULL OR last_viewed_at < ?)
+	`, now, id, now.Add(-viewedResolution))
+	return err
+}
+
+// GetUsage returns the usage of every service by ID: its open pause
+// window, last view, incidents started since the given time and whether
+// a dashboard pins it
+func (r *serviceRepository) GetUsage(ctx context.Context, since time.Time) (map[string]models.ServiceUsage, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	usage := make(map[string]models.ServiceUsage)
+	query := func(q string, scan func(rows *sql.Rows) error, args ...interface{}) error {
+		rows, err := r.db.QueryContext(ctx, q, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+		for rows.Next() {
+			if err := scan(rows); err != nil {
+				return err
+			}
+		}
+		return rows.Err()
+	}
+
+	err := query(`SELECT id, last_viewed_at FROM services`, func(rows *sql.Rows) error {
+		var id string
+		var viewed sql.NullTime
+		if err := rows.Scan(&id, &viewed); err != nil {
+			return err
+		}
+		u := models.ServiceUsage{}
+		if viewed.Valid {
+			u.LastViewedAt = &viewed.Time
+		}
+		usage[id] = u
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(`
+		SELECT service_id, starts_at FROM service_windows
+		WHERE kind = ? AND ends_at IS NULL
+		ORDER BY starts_at
+	`, func(rows *sql.Rows) error {
+		var id string
+		var startsAt time.Time
+		if err := rows.Scan(&id, &startsAt); err != nil {
+			return err
+		}
+		if u, ok := usage[id]; ok && u.PausedSince == nil {
+			u.PausedSince = &startsAt
+			usage[id] = u
+		}
+		return nil
+	}, models.WindowPaused)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(`
+		SELECT service_id, COUNT(*) FROM incidents WHERE started_at >= ? GROUP BY service_id
+	`, func(rows *sql.Rows) error {
+		var id string
+		var n int
+		if err := rows.Scan(&id, &n); err != nil {
+			return err
+		}
+		if u, ok := usage[id]; ok {
+			u.Incidents = n
+			usage[id] = u
+		}
+		return nil
+	}, since)
+	if err != nil {
+		return nil, err
+	}
+
+	err = query(`
+		SELECT DISTINCT pinned.value FROM dashboard_preferences, json_each(dashboard_preferences.pinned_services) AS pinned
+	`, func(rows *sql.Rows) error {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return err
+		}
+		if u, ok := usage[id]; ok {
+			u.Pinned = true
+			usage[id] = u
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return usage, nil
+}
+
 // Delete deletes a service
-func (r *ServiceRepository) Delete(id string) error {
-	_, err := DB.Exec("DELETE FROM services WHERE id = ?", id)
+func (r *serviceRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, "DELETE FROM services WHERE id = ?", id)
 	return err
 }
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// serviceWindowRepository implements ServiceWindowRepository on SQLite
+type serviceWindowRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewServiceWindowRepository creates a new service window repository
+func NewServiceWindowRepository(db *sql.DB, timeout time.Duration) ServiceWindowRepository {
+	return &serviceWindowRepository{db: db, timeout: timeout}
+}
+
+// Create stores a new window
+func (r *serviceWindowRepository) Create(ctx context.Context, w *models.ServiceWindow) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if w.CreatedAt.IsZero() {
+		w.CreatedAt = time.Now()
+	}
+
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO service_windows (service_id, kind, starts_at, ends_at, note, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, w.ServiceID, w.Kind, w.StartsAt, w.EndsAt, w.Note, w.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+	w.ID = id
+	return nil
+}
+
+// CloseOpen ends every open window of the given kind for a service
+func (r *serviceWindowRepository) CloseOpen(ctx context.Context, serviceID string, kind models.WindowKind, at time.Time) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE service_windows SET ends_at = ?
+		WHERE service_id = ? AND kind = ? AND ends_at IS NULL
+	`, at, serviceID, kind)
+	return err
+}
+
+// GetByServiceID returns windows that are open or ended after since, newest first
+func (r *serviceWindowRepository) GetByServiceID(ctx context.Context, serviceID string, since time.Time) ([]models.ServiceWindow, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, service_id, kind, starts_at, ends_at, note, created_at
+		FROM service_windows
+		WHERE service_id = ? AND (ends_at IS NULL OR ends_at >= ?)
+		ORDER BY starts_at DESC
+	`, serviceID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	windows := []models.ServiceWindow{}
+	for rows.Next() {
+		var w models.ServiceWindow
+		var endsAt sql.NullTime
+		var note sql.NullString
+		if err := rows.Scan(&w.ID, &w.ServiceID, &w.Kind, &w.StartsAt, &endsAt, &note, &w.CreatedAt); err != nil {
+			return nil, err
+		}
+		if endsAt.Valid {
+			w.EndsAt = &endsAt.Time
+		}
+		w.Note = note.String
+		windows = append(windows, w)
+	}
+	return windows, rows.Err()
+}
+
+// Delete removes a window of the given kind, reporting whether it existed
+func (r *serviceWindowRepository) Delete(ctx context.Context, serviceID string, kind models.WindowKind, id int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM service_windows WHERE id = ? AND service_id = ? AND kind = ?
+	`, id, serviceID, kind)
+	if err != nil {
+		return false, err
+	}
+
+	affected, err := result.RowsAffected()
+	return affected > 0, err
+}
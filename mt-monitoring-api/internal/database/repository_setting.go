@@ -0,0 +1,66 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+)
+
+// settingRepository implements SettingRepository on SQLite
+type settingRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewSettingRepository creates a new setting repository
+func NewSettingRepository(db *sql.DB, timeout time.Duration) SettingRepository {
+	return &settingRepository{db: db, timeout: timeout}
+}
+
+// GetAll returns every stored setting keyed by name
+func (r *settingRepository) GetAll(ctx context.Context) (map[string]string, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT key, value FROM settings`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		settings[key] = value
+	}
+	return settings, rows.Err()
+}
+
+// Set stores the given settings in one transaction. A key mapped to an
+// empty string is deleted.
+func (r *settingRepository) Set(ctx context.Context, values map[string]string) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		for key, value := range values {
+			var err error
+			if value == "" {
+				_, err = tx.ExecContext(ctx, `DELETE FROM settings WHERE key = ?`, key)
+			} else {
+				_, err = tx.ExecContext(ctx, `
+					INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+					ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+				`, key, value, now)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
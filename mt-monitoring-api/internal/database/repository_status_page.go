@@ -0,0 +1,464 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// statusPageRepository implements StatusPageRepository on SQLite
+type statusPageRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewStatusPageRepository creates a new status page repository
+func NewStatusPageRepository(db *sql.DB, timeout time.Duration) StatusPageRepository {
+	return &statusPageRepository{db: db, timeout: timeout}
+}
+
+// GetComponents returns all components with their services, in page order
+func (r *statusPageRepository) GetComponents(ctx context.Context) ([]models.StatusComponent, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, name, description, position, status_override, override_message, created_at, updated_at
+		FROM status_components
+		ORDER BY position, name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := []models.StatusComponent{}
+	byID := make(map[string]int)
+	for rows.Next() {
+		var c models.StatusComponent
+		var description, override, message sql.NullString
+		if err := rows.Scan(&c.ID, &c.Name, &description, &c.Position, &override, &message, &c.CreatedAt, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		c.Description = description.String
+		c.StatusOverride = models.ComponentStatus(override.String)
+		c.OverrideMessage = message.String
+		c.ServiceIDs = []string{}
+		byID[c.ID] = len(components)
+		components = append(components, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	links, err := r.db.QueryContext(ctx, `
+		SELECT component_id, service_id FROM status_component_services ORDER BY service_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer links.Close()
+	for links.Next() {
+		var componentID, serviceID string
+		if err := links.Scan(&componentID, &serviceID); err != nil {
+			return nil, err
+		}
+		if i, ok := byID[componentID]; ok {
+			components[i].ServiceIDs = append(components[i].ServiceIDs, serviceID)
+		}
+	}
+	return components, links.Err()
+}
+
+// GetComponent returns a component by ID, nil if it does not exist
+func (r *statusPageRepository) GetComponent(ctx context.Context, id string) (*models.StatusComponent, error) {
+	components, err := r.GetComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for i := range components {
+		if components[i].ID == id {
+			return &components[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// CreateComponent inserts a component and its services
+func (r *statusPageRepository) CreateComponent(ctx context.Context, c *models.StatusComponent) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	c.CreatedAt, c.UpdatedAt = now, now
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO status_components (id, name, description, position, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, c.ID, c.Name, c.Description, c.Position, c.CreatedAt, c.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		return setComponentServices(ctx, tx, c.ID, c.ServiceIDs)
+	})
+}
+
+// UpdateComponent updates a component's name, description, position and
+// services. The status override is changed with SetOverride.
+func (r *statusPageRepository) UpdateComponent(ctx context.Context, c *models.StatusComponent) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	c.UpdatedAt = time.Now()
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE status_components SET name = ?, description = ?, position = ?, updated_at = ?
+			WHERE id = ?
+		`, c.Name, c.Description, c.Position, c.UpdatedAt, c.ID)
+		if err != nil {
+			return err
+		}
+		return setComponentServices(ctx, tx, c.ID, c.ServiceIDs)
+	})
+}
+
+// setComponentServices replaces the services of a component
+func setComponentServices(ctx context.Context, tx *sql.Tx, componentID string, serviceIDs []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM status_component_services WHERE component_id = ?`, componentID); err != nil {
+		return err
+	}
+	for _, serviceID := range serviceIDs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO status_component_services (component_id, service_id) VALUES (?, ?)
+		`, componentID, serviceID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteComponent deletes a component, reporting whether it existed
+func (r *statusPageRepository) DeleteComponent(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_components WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// SetOverride sets a component's manual status; an empty status clears it
+func (r *statusPageRepository) SetOverride(ctx context.Context, id string, status models.ComponentStatus, message string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE status_components SET status_override = ?, override_message = ?, updated_at = ?
+		WHERE id = ?
+	`, status, message, time.Now(), id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// GetSubscribers returns all subscribers, oldest first
+func (r *statusPageRepository) GetSubscribers(ctx context.Context) ([]models.StatusSubscriber, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, type, target, component_ids, token, created_at
+		FROM status_subscribers
+		ORDER BY id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	subscribers := []models.StatusSubscriber{}
+	for rows.Next() {
+		var s models.StatusSubscriber
+		var componentIDs sql.NullString
+		if err := rows.Scan(&s.ID, &s.Type, &s.Target, &componentIDs, &s.Token, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		s.ComponentIDs = []string{}
+		if componentIDs.Valid {
+			json.Unmarshal([]byte(componentIDs.String), &s.ComponentIDs)
+		}
+		subscribers = append(subscribers, s)
+	}
+	return subscribers, rows.Err()
+}
+
+// CreateSubscriber inserts a subscriber
+func (r *statusPageRepository) CreateSubscriber(ctx context.Context, s *models.StatusSubscriber) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	if s.ComponentIDs == nil {
+		s.ComponentIDs = []string{}
+	}
+	componentIDs, err := json.Marshal(s.ComponentIDs)
+	if err != nil {
+		return err
+	}
+
+	s.CreatedAt = time.Now()
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO status_subscribers (type, target, component_ids, token, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`, s.Type, s.Target, string(componentIDs), s.Token, s.CreatedAt)
+	if err != nil {
+		return err
+	}
+	s.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// DeleteSubscriber deletes a subscriber, reporting whether it existed
+func (r *statusPageRepository) DeleteSubscriber(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_subscribers WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// DeleteSubscriberByToken deletes the subscriber with the given
+// unsubscribe token, reporting whether it existed
+func (r *statusPageRepository) DeleteSubscriberByToken(ctx context.Context, token string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_subscribers WHERE token = ?`, token)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// CreateIncidentUpdate inserts an incident update
+func (r *statusPageRepository) CreateIncidentUpdate(ctx context.Context, u *models.IncidentUpdate) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	u.CreatedAt = time.Now()
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO incident_updates (incident_id, message, created_at) VALUES (?, ?, ?)
+	`, u.IncidentID, u.Message, u.CreatedAt)
+	if err != nil {
+		return err
+	}
+	u.ID, _ = result.LastInsertId()
+	return nil
+}
+
+// GetIncidentUpdates returns the updates of the given incidents, oldest first
+func (r *statusPageRepository) GetIncidentUpdates(ctx context.Context, incidentIDs []int64) ([]models.IncidentUpdate, error) {
+	updates := []models.IncidentUpdate{}
+	if len(incidentIDs) == 0 {
+		return updates, nil
+	}
+
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(incidentIDs))
+	for i, id := range incidentIDs {
+		args[i] = id
+	}
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, incident_id, message, created_at
+		FROM incident_updates
+		WHERE incident_id IN (?`+strings.Repeat(", ?", len(incidentIDs)-1)+`)
+		ORDER BY created_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var u models.IncidentUpdate
+		if err := rows.Scan(&u.ID, &u.IncidentID, &u.Message, &u.CreatedAt); err != nil {
+			return nil, err
+		}
+		updates = append(updates, u)
+	}
+	return updates, rows.Err()
+}
+
+// GetMaintenances returns the maintenances ending at or after endedSince
+// with their components, by start
+func (r *statusPageRepository) GetMaintenances(ctx context.Context, endedSince time.Time) ([]models.StatusMaintenance, error) {
+	return r.queryMaintenances(ctx, `WHERE ends_at >= ?`, endedSince)
+}
+
+// GetMaintenance returns a maintenance by ID, nil if it does not exist
+func (r *statusPageRepository) GetMaintenance(ctx context.Context, id int64) (*models.StatusMaintenance, error) {
+	maintenances, err := r.queryMaintenances(ctx, `WHERE id = ?`, id)
+	if err != nil || len(maintenances) == 0 {
+		return nil, err
+	}
+	return &maintenances[0], nil
+}
+
+func (r *statusPageRepository) queryMaintenances(ctx context.Context, where string, args ...interface{}) ([]models.StatusMaintenance, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, title, message, starts_at, ends_at, created_at, updated_at
+		FROM status_maintenances
+		`+where+`
+		ORDER BY starts_at, id
+	`, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	maintenances := []models.StatusMaintenance{}
+	byID := make(map[int64]int)
+	for rows.Next() {
+		var m models.StatusMaintenance
+		var message sql.NullString
+		if err := rows.Scan(&m.ID, &m.Title, &message, &m.StartsAt, &m.EndsAt, &m.CreatedAt, &m.UpdatedAt); err != nil {
+			return nil, err
+		}
+		m.Message = message.String
+		m.ComponentIDs = []string{}
+		byID[m.ID] = len(maintenances)
+		maintenances = append(maintenances, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if len(maintenances) == 0 {
+		return maintenances, nil
+	}
+
+	links, err := r.db.QueryContext(ctx, `
+		SELECT maintenance_id, component_id FROM status_maintenance_components ORDER BY component_id
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer links.Close()
+	for links.Next() {
+		var maintenanceID int64
+		var componentID string
+		if err := links.Scan(&maintenanceID, &componentID); err != nil {
+			return nil, err
+		}
+		if i, ok := byID[maintenanceID]; ok {
+			maintenances[i].ComponentIDs = append(maintenances[i].ComponentIDs, componentID)
+		}
+	}
+	return maintenances, links.Err()
+}
+
+// CreateMaintenance inserts a maintenance and its components
+func (r *statusPageRepository) CreateMaintenance(ctx context.Context, m *models.StatusMaintenance) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	now := time.Now()
+	m.CreatedAt, m.UpdatedAt = now, now
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		result, err := tx.ExecContext(ctx, `
+			INSERT INTO status_maintenances (title, message, starts_at, ends_at, created_at, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?)
+		`, m.Title, m.Message, m.StartsAt, m.EndsAt, m.CreatedAt, m.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		m.ID, _ = result.LastInsertId()
+		return setMaintenanceComponents(ctx, tx, m.ID, m.ComponentIDs)
+	})
+}
+
+// UpdateMaintenance updates a maintenance and replaces its components
+func (r *statusPageRepository) UpdateMaintenance(ctx context.Context, m *models.StatusMaintenance) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	m.UpdatedAt = time.Now()
+	return transaction(ctx, r.db, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			UPDATE status_maintenances SET title = ?, message = ?, starts_at = ?, ends_at = ?, updated_at = ?
+			WHERE id = ?
+		`, m.Title, m.Message, m.StartsAt, m.EndsAt, m.UpdatedAt, m.ID)
+		if err != nil {
+			return err
+		}
+		return setMaintenanceComponents(ctx, tx, m.ID, m.ComponentIDs)
+	})
+}
+
+// setMaintenanceComponents replaces the components of a maintenance
+func setMaintenanceComponents(ctx context.Context, tx *sql.Tx, maintenanceID int64, componentIDs []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM status_maintenance_components WHERE maintenance_id = ?`, maintenanceID); err != nil {
+		return err
+	}
+	for _, componentID := range componentIDs {
+		_, err := tx.ExecContext(ctx, `
+			INSERT OR IGNORE INTO status_maintenance_components (maintenance_id, component_id) VALUES (?, ?)
+		`, maintenanceID, componentID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteMaintenance deletes a maintenance, reporting whether it existed
+func (r *statusPageRepository) DeleteMaintenance(ctx context.Context, id int64) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `DELETE FROM status_maintenances WHERE id = ?`, id)
+	if err != nil {
+		return false, err
+	}
+	n, _ := result.RowsAffected()
+	return n > 0, nil
+}
+
+// InMaintenance reports whether a service belongs to a component with
+// maintenance in progress at the given time
+func (r *statusPageRepository) InMaintenance(ctx context.Context, serviceID string, at time.Time) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (
+			SELECT 1
+			FROM status_maintenances m
+			JOIN status_maintenance_components mc ON mc.maintenance_id = m.id
+			JOIN status_component_services cs ON cs.component_id = mc.component_id
+			WHERE cs.service_id = ? AND m.starts_at <= ? AND m.ends_at > ?
+		)
+	`, serviceID, at, at).Scan(&exists)
+	return exists, err
+}
@@ -1,30 +1,43 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"time"
 
 	"github.com/mt-monitoring/api/internal/models"
 )
 
-// SystemMetricRepository handles system metric data operations
-type SystemMetricRepository struct{}
+// systemMetricRepository implements SystemMetricRepository on SQLite
+type systemMetricRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
 
 // NewSystemMetricRepository creates a new system metric repository
-func NewSystemMetricRepository() *SystemMetricRepository {
-	return &SystemMetricRepository{}
+func NewSystemMetricRepository(db *sql.DB, timeout time.Duration) SystemMetricRepository {
+	return &systemMetricRepository{db: db, timeout: timeout}
 }
 
 // Create stores a 1-minute aggregate system metric
-func (r *SystemMetricRepository) Create(m *models.SystemMetric) error {
-	result, err := DB.Exec(`
+func (r *systemMetricRepository) Create(ctx context.Context, m *models.SystemMetric) error {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	pingOnly := 0
+	if m.PingOnly {
+		pingOnly = 1
+	}
+	result, err := r.db.ExecContext(ctx, `
 		INSERT INTO system_metrics (host_id, cpu_usage, mem_total, mem_used, mem_usage,
 		                            disk_total, disk_used, disk_usage,
-		                            disk_read, disk_write, net_in, net_out, created_at)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		                            disk_read, disk_write, net_in, net_out, created_at,
+		                            ping_rtt, packet_loss, ping_only)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`, m.HostID, m.CPUUsage, m.MemTotal, m.MemUsed, m.MemUsage,
 		m.DiskTotal, m.DiskUsed, m.DiskUsage,
-		m.DiskRead, m.DiskWrite, m.NetIn, m.NetOut, m.CreatedAt)
+		m.DiskRead, m.DiskWrite, m.NetIn, m.NetOut, m.CreatedAt,
+		m.PingRTT, m.PacketLoss, pingOnly)
 	if err != nil {
 		return err
 	}
@@ -35,11 +48,14 @@ func (r *SystemMetricRepository) Create(m *models.SystemMetric) error {
 }
 
 // GetHistory returns system metrics for a given host and time range
-func (r *SystemMetricRepository) GetHistory(hostID string, since time.Time) ([]models.SystemMetricPoint, error) {
-	rows, err := DB.Query(`
+func (r *systemMetricRepository) GetHistory(ctx context.Context, hostID string, since time.Time) ([]models.SystemMetricPoint, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
 		SELECT created_at, cpu_usage, mem_used, disk_read, disk_write
 		FROM system_metrics
-		WHERE host_id = ? AND created_at >= ?
+		WHERE host_id = ? AND created_at >= ? AND ping_only = 0
 		ORDER BY created_at ASC
 	`, hostID, since)
 	if err != nil {
@@ -60,16 +76,85 @@ func (r *SystemMetricRepository) GetHistory(hostID string, since time.Time) ([]m
 	return points, nil
 }
 
+// GetReachability returns the ping round-trip time and packet loss of a
+// host since a time, oldest first
+func (r *systemMetricRepository) GetReachability(ctx context.Context, hostID string, since time.Time) ([]models.ReachabilityPoint, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT created_at, ping_rtt, packet_loss
+		FROM system_metrics
+		WHERE host_id = ? AND created_at >= ? AND packet_loss IS NOT NULL
+		ORDER BY created_at ASC
+	`, hostID, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	points := []models.ReachabilityPoint{}
+	for rows.Next() {
+		var p models.ReachabilityPoint
+		var ts time.Time
+		var rtt sql.NullFloat64
+		if err := rows.Scan(&ts, &rtt, &p.Loss); err != nil {
+			return nil, err
+		}
+		p.Timestamp = ts.Format(time.RFC3339)
+		if rtt.Valid {
+			p.RTT = &rtt.Float64
+		}
+		points = append(points, p)
+	}
+	return points, rows.Err()
+}
+
+// GetRange returns the 1-minute aggregates of a host between from and to,
+// oldest first
+func (r *systemMetricRepository) GetRange(ctx context.Context, hostID string, from, to time.Time) ([]models.SystemMetric, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT id, host_id, cpu_usage, mem_total, mem_used, mem_usage,
+		       disk_total, disk_used, disk_usage, disk_read, disk_write,
+		       net_in, net_out, created_at
+		FROM system_metrics
+		WHERE host_id = ? AND created_at >= ? AND created_at <= ? AND ping_only = 0
+		ORDER BY created_at ASC
+	`, hostID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var metrics []models.SystemMetric
+	for rows.Next() {
+		var m models.SystemMetric
+		if err := rows.Scan(&m.ID, &m.HostID, &m.CPUUsage, &m.MemTotal, &m.MemUsed, &m.MemUsage,
+			&m.DiskTotal, &m.DiskUsed, &m.DiskUsage, &m.DiskRead, &m.DiskWrite,
+			&m.NetIn, &m.NetOut, &m.CreatedAt); err != nil {
+			return nil, err
+		}
+		metrics = append(metrics, m)
+	}
+	return metrics, nil
+}
+
 // GetLatestByHost returns the most recent metric for a host
-func (r *SystemMetricRepository) GetLatestByHost(hostID string) (*models.SystemMetric, error) {
+func (r *systemMetricRepository) GetLatestByHost(ctx context.Context, hostID string) (*models.SystemMetric, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
 	var m models.SystemMetric
 	var ts time.Time
-	err := DB.QueryRow(`
+	err := r.db.QueryRowContext(ctx, `
 		SELECT id, host_id, cpu_usage, mem_total, mem_used, mem_usage,
 		       disk_total, disk_used, disk_usage, disk_read, disk_write,
 		       net_in, net_out, created_at
 		FROM system_metrics
-		WHERE host_id = ?
+		WHERE host_id = ? AND ping_only = 0
 		ORDER BY created_at DESC
 		LIMIT 1
 	`, hostID).Scan(&m.ID, &m.HostID, &m.CPUUsage, &m.MemTotal, &m.MemUsed, &m.MemUsage,
@@ -86,8 +171,11 @@ func (r *SystemMetricRepository) GetLatestByHost(hostID string) (*models.SystemM
 }
 
 // DeleteOld deletes system metrics older than the specified duration
-func (r *SystemMetricRepository) DeleteOld(retention time.Duration) (int64, error) {
-	result, err := DB.Exec(`
+func (r *systemMetricRepository) DeleteOld(ctx context.Context, retention time.Duration) (int64, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, `
 		DELETE FROM system_metrics WHERE created_at < ?
 	`, time.Now().Add(-retention))
 	if err != nil {
@@ -0,0 +1,176 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// tagRepository implements TagRepository on SQLite
+type tagRepository struct {
+	db      *sql.DB
+	timeout time.Duration
+}
+
+// NewTagRepository creates a new tag repository
+func NewTagRepository(db *sql.DB, timeout time.Duration) TagRepository {
+	return &tagRepository{db: db, timeout: timeout}
+}
+
+// GetAll returns every tag in use with its service count, by name
+func (r *tagRepository) GetAll(ctx context.Context) ([]models.Tag, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT tag, COUNT(*) FROM service_tags GROUP BY tag ORDER BY tag
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []models.Tag{}
+	for rows.Next() {
+		var t models.Tag
+		if err := rows.Scan(&t.Name, &t.Services); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// Exists reports whether any service carries the tag
+func (r *tagRepository) Exists(ctx context.Context, tag string) (bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	var n int
+	err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM service_tags WHERE tag = ?`, tag).Scan(&n)
+	return n > 0, err
+}
+
+// ServiceIDs returns the IDs of the services carrying all of the tags
+func (r *tagRepository) ServiceIDs(ctx context.Context, tags []string) (map[string]bool, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	condition, args := tagCondition("id", tags)
+	rows, err := r.db.QueryContext(ctx, `SELECT id FROM services WHERE 1=1`+condition, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
+// Merge replaces the tags with into on every service carrying any of them,
+// returning the number of services changed. Renaming is merging one tag.
+func (r *tagRepository) Merge(ctx context.Context, tags []string, into string) (int, error) {
+	ctx, cancel := withTimeout(ctx, r.timeout)
+	defer cancel()
+
+	args := make([]interface{}, len(tags))
+	replaced := make(map[string]bool, len(tags))
+	for i, tag := range tags {
+		args[i] = tag
+		replaced[tag] = true
+	}
+
+	changed := 0
+	err := transaction(ctx, r.db, func(tx *sql.Tx) error {
+		rows, err := tx.QueryContext(ctx, `
+			SELECT id, tags FROM services
+			WHERE id IN (SELECT service_id FROM service_tags WHERE tag IN (`+placeholders(len(tags))+`))
+		`, args...)
+		if err != nil {
+			return err
+		}
+		lists := make(map[string][]string)
+		for rows.Next() {
+			var id string
+			var tagsJSON sql.NullString
+			if err := rows.Scan(&id, &tagsJSON); err != nil {
+				rows.Close()
+				return err
+			}
+			var list []string
+			if tagsJSON.String != "" {
+				json.Unmarshal([]byte(tagsJSON.String), &list)
+			}
+			lists[id] = list
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for id, list := range lists {
+			for i, tag := range list {
+				if replaced[tag] {
+					list[i] = into
+				}
+			}
+			list = models.NormalizeTags(list)
+			tagsJSON, err := json.Marshal(list)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx, `UPDATE services SET tags = ?, updated_at = ? WHERE id = ?`,
+				string(tagsJSON), now, id); err != nil {
+				return err
+			}
+			if err := setServiceTags(ctx, tx, id, list); err != nil {
+				return err
+			}
+			changed++
+		}
+		return nil
+	})
+	return changed, err
+}
+
+// setServiceTags rewrites the service_tags rows of a service
+func setServiceTags(ctx context.Context, tx *sql.Tx, serviceID string, tags []string) error {
+	if _, err := tx.ExecContext(ctx, `DELETE FROM service_tags WHERE service_id = ?`, serviceID); err != nil {
+		return err
+	}
+	for _, tag := range tags {
+		if _, err := tx.ExecContext(ctx, `INSERT OR IGNORE INTO service_tags (service_id, tag) VALUES (?, ?)`,
+			serviceID, tag); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tagCondition returns an " AND column IN (...)" clause matching service
+// IDs that carry all of the tags, empty when there are none
+func tagCondition(column string, tags []string) (string, []interface{}) {
+	tags = models.NormalizeTags(tags)
+	if len(tags) == 0 {
+		return "", nil
+	}
+	args := make([]interface{}, 0, len(tags)+1)
+	for _, tag := range tags {
+		args = append(args, tag)
+	}
+	args = append(args, len(tags))
+	return ` AND ` + column + ` IN (
+		SELECT service_id FROM service_tags WHERE tag IN (` + placeholders(len(tags)) + `)
+		GROUP BY service_id HAVING COUNT(*) = ?)`, args
+}
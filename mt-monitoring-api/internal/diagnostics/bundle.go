@@ -0,0 +1,189 @@
+package diagnostics
+
+import (
+	"archive/zip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+// Collector gathers a support bundle from the running components. Sections
+// of nil components are left out.
+type Collector struct {
+	store        *database.Store
+	scheduler    *checker.Scheduler
+	collectorMgr *collector.CollectorManager
+	offline      bool
+}
+
+// NewCollector creates a new diagnostics collector for the running server.
+// collectorMgr may be nil when system metric collection is disabled.
+func NewCollector(store *database.Store, scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager) *Collector {
+	return &Collector{
+		store:        store,
+		scheduler:    scheduler,
+		collectorMgr: collectorMgr,
+	}
+}
+
+// NewOfflineCollector creates a collector for use without a running server.
+// Its bundles hold the config and database statistics only, since logs,
+// profiles and runtime state of this process say nothing about the server.
+func NewOfflineCollector(store *database.Store) *Collector {
+	return &Collector{
+		store:   store,
+		offline: true,
+	}
+}
+
+// RuntimeInfo describes the process that produced a bundle
+type RuntimeInfo struct {
+	GeneratedAt time.Time `json:"generatedAt"`
+	Hostname    string    `json:"hostname,omitempty"`
+	GoVersion   string    `json:"goVersion"`
+	OS          string    `json:"os"`
+	Arch        string    `json:"arch"`
+	NumCPU      int       `json:"numCpu"`
+	Goroutines  int       `json:"goroutines"`
+	HeapAlloc   uint64    `json:"heapAlloc"` // bytes
+	Sys         uint64    `json:"sys"`       // bytes
+	NumGC       uint32    `json:"numGc"`
+	ConfigPath  string    `json:"configPath,omitempty"`
+	Offline     bool      `json:"offline"` // built by the CLI without a running server
+}
+
+// section is one file of the bundle
+type section struct {
+	name  string
+	write func(ctx context.Context, w io.Writer) error
+}
+
+// WriteBundle writes the bundle as a zip archive to w. Sections that fail
+// are listed in errors.txt instead of failing the whole bundle, so a
+// partially broken server still produces something to look at.
+func (c *Collector) WriteBundle(ctx context.Context, w io.Writer) error {
+	sections := []section{
+		{"runtime.json", func(ctx context.Context, w io.Writer) error { return writeJSON(w, runtimeInfo(c.offline)) }},
+		{"config.json", c.writeConfig},
+	}
+	if c.store != nil {
+		sections = append(sections, section{"database.json", c.writeDatabase})
+	}
+	if c.scheduler != nil {
+		sections = append(sections, section{"scheduler.json", func(ctx context.Context, w io.Writer) error {
+			return writeJSON(w, c.scheduler.State())
+		}})
+	}
+	if c.collectorMgr != nil {
+		sections = append(sections, section{"collectors.json", func(ctx context.Context, w io.Writer) error {
+			return writeJSON(w, map[string]interface{}{
+				"collectors": c.collectorMgr.Statuses(),
+				"storage":    c.collectorMgr.StorageStats(),
+			})
+		}})
+	}
+	if !c.offline {
+		sections = append(sections,
+			section{"logs.txt", writeLogs},
+			section{"goroutines.txt", func(ctx context.Context, w io.Writer) error {
+				return pprof.Lookup("goroutine").WriteTo(w, 1)
+			}},
+			section{"heap.pb.gz", func(ctx context.Context, w io.Writer) error {
+				return pprof.Lookup("heap").WriteTo(w, 0)
+			}},
+		)
+	}
+
+	zw := zip.NewWriter(w)
+	var failures []string
+	for _, s := range sections {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: s.name, Method: zip.Deflate, Modified: time.Now()})
+		if err != nil {
+			return err
+		}
+		if err := s.write(ctx, f); err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", s.name, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		f, err := zw.Create("errors.txt")
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, strings.Join(failures, "\n")+"\n"); err != nil {
+			return err
+		}
+	}
+	return zw.Close()
+}
+
+func runtimeInfo(offline bool) RuntimeInfo {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	hostname, _ := os.Hostname()
+
+	return RuntimeInfo{
+		GeneratedAt: time.Now().UTC(),
+		Hostname:    hostname,
+		GoVersion:   runtime.Version(),
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		NumCPU:      runtime.NumCPU(),
+		Goroutines:  runtime.NumGoroutine(),
+		HeapAlloc:   mem.HeapAlloc,
+		Sys:         mem.Sys,
+		NumGC:       mem.NumGC,
+		ConfigPath:  config.Path(),
+		Offline:     offline,
+	}
+}
+
+func (c *Collector) writeConfig(ctx context.Context, w io.Writer) error {
+	cfg := config.Get()
+	if cfg == nil {
+		return fmt.Errorf("config not initialized")
+	}
+	tree, err := RedactConfig(cfg)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, tree)
+}
+
+func (c *Collector) writeDatabase(ctx context.Context, w io.Writer) error {
+	stats, err := c.store.Stats(ctx)
+	if err != nil {
+		return err
+	}
+	return writeJSON(w, stats)
+}
+
+func writeLogs(ctx context.Context, w io.Writer) error {
+	for _, line := range RecentLogs.Lines() {
+		if _, err := io.WriteString(w, RedactString(line)+"\n"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,72 @@
+package diagnostics
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"os"
+	"sync"
+)
+
+// defaultLogLines is the number of log lines kept for support bundles
+const defaultLogLines = 2000
+
+// RecentLogs keeps the last log lines of this process once CaptureLogs is
+// called
+var RecentLogs = NewLogBuffer(defaultLogLines)
+
+// CaptureLogs tees the standard logger into RecentLogs so support bundles
+// include recent logs. The server entry point calls it at startup.
+func CaptureLogs() {
+	log.SetOutput(io.MultiWriter(os.Stderr, RecentLogs))
+}
+
+// LogBuffer is an io.Writer that keeps the last size lines written to it
+type LogBuffer struct {
+	mu      sync.Mutex
+	lines   []string
+	next    int // ring position of the oldest line once full
+	partial []byte
+}
+
+// NewLogBuffer creates a buffer holding at most size lines
+func NewLogBuffer(size int) *LogBuffer {
+	return &LogBuffer{lines: make([]string, 0, size)}
+}
+
+// Write stores complete lines; a trailing partial line waits for the rest
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	data := append(b.partial, p...)
+	for {
+		i := bytes.IndexByte(data, '\n')
+		if i < 0 {
+			break
+		}
+		b.add(string(data[:i]))
+		data = data[i+1:]
+	}
+	b.partial = append([]byte(nil), data...)
+	return len(p), nil
+}
+
+func (b *LogBuffer) add(line string) {
+	if len(b.lines) < cap(b.lines) {
+		b.lines = append(b.lines, line)
+		return
+	}
+	b.lines[b.next] = line
+	b.next = (b.next + 1) % len(b.lines)
+}
+
+// Lines returns the buffered lines, oldest first
+func (b *LogBuffer) Lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	out := make([]string, 0, len(b.lines))
+	out = append(out, b.lines[b.next:]...)
+	return append(out, b.lines[:b.next]...)
+}
@@ -0,0 +1,84 @@
+package diagnostics
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// urlCredentials matches the user[:password]@ part of URLs and DSNs
+var urlCredentials = regexp.MustCompile(`(://)[^/@\s]+@`)
+
+// isSecretField reports whether a config field holds credentials. Field
+// names are the Go names of the config structs.
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	for _, s := range []string{"password", "token", "secret", "dsn", "webhook", "callbackurl"} {
+		if strings.Contains(name, s) {
+			return true
+		}
+	}
+	// AccessKey, SSHKey, EncryptionKey, ... but not SSHKeyPath
+	return strings.HasSuffix(name, "key")
+}
+
+// RedactConfig returns v (a config struct) as a JSON tree with credentials
+// replaced: secret fields, header values and passwords in URLs
+func RedactConfig(v interface{}) (interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var tree interface{}
+	if err := json.Unmarshal(data, &tree); err != nil {
+		return nil, err
+	}
+	return redactValue("", tree), nil
+}
+
+func redactValue(field string, v interface{}) interface{} {
+	switch val := v.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			switch {
+			case strings.EqualFold(field, "headers"):
+				// Authorization, cookies, API keys
+				val[k] = redacted
+			case isSecretField(k):
+				switch c := child.(type) {
+				case map[string]interface{}, []interface{}:
+					// e.g. the Secrets provider block: only its credentials are secret
+					val[k] = redactValue(k, c)
+				case string:
+					if c != "" {
+						val[k] = redacted
+					}
+				default:
+					val[k] = redacted
+				}
+			default:
+				val[k] = redactValue(k, child)
+			}
+		}
+		return val
+	case []interface{}:
+		for i, child := range val {
+			val[i] = redactValue(field, child)
+		}
+		return val
+	case string:
+		return RedactString(val)
+	}
+	return v
+}
+
+// RedactString removes credentials embedded in URLs from s, which may be a
+// single URL or a log line
+func RedactString(s string) string {
+	if !strings.Contains(s, "://") {
+		return s
+	}
+	return urlCredentials.ReplaceAllString(s, "${1}"+redacted+"@")
+}
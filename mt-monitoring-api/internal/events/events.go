@@ -0,0 +1,139 @@
+// Package events is the in-process event bus. Subsystems publish what
+// happened (a check completed, a host metric was collected or stored, an
+// incident opened or resolved, a log entry was written) and the WebSocket
+// hub, alert evaluators, exporters and streams subscribe to it, instead of
+// being wired into each other with callbacks.
+package events
+
+import (
+	"log"
+	"runtime/debug"
+	"slices"
+	"sync"
+
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Check is published after every service check, once its metric is stored
+type Check struct {
+	Service    *models.Service
+	Metric     *models.Metric
+	Status     models.ServiceStatus
+	PrevStatus models.ServiceStatus // StatusUnknown on the first check since startup
+	Held       bool                 // alerts are held by status page maintenance
+}
+
+// SystemMetric is published after every collection of a host
+type SystemMetric struct {
+	HostID   string
+	HostName string
+	Metric   *models.SystemMetric
+	Interval int // collection interval of the host, seconds
+}
+
+// Ping is published after every ping round of a host
+type Ping struct {
+	HostID   string
+	HostName string
+	Result   *models.PingResult
+}
+
+// Updates is published after every pending updates check of a host
+type Updates struct {
+	HostID   string
+	HostName string
+	Updates  *models.SecurityUpdates
+}
+
+// Ports is published after every listening ports check of a host
+type Ports struct {
+	HostID   string
+	HostName string
+	Ports    *models.HostPorts
+}
+
+// Topics
+var (
+	CheckCompleted   = newTopic[Check]("check.completed")
+	MetricCollected  = newTopic[SystemMetric]("metric.collected")
+	MetricStored     = newTopic[*models.SystemMetric]("metric.stored") // per-minute host averages
+	IncidentCreated  = newTopic[*models.Incident]("incident.created")
+	IncidentResolved = newTopic[*models.Incident]("incident.resolved")
+	LogWritten       = newTopic[*models.Log]("log.written")
+	HostPinged       = newTopic[Ping]("host.pinged")
+	UpdatesChecked   = newTopic[Updates]("host.updates_checked")
+	PortsChecked     = newTopic[Ports]("host.ports_checked")
+)
+
+// Topic delivers events of one kind to its subscribers. Publish calls them
+// in the order they subscribed, on the publisher's goroutine, so they must
+// not block: slow work belongs on the subscriber's own queue or goroutine.
+type Topic[T any] struct {
+	name string
+
+	mu     sync.RWMutex
+	nextID int
+	subs   []subscriber[T]
+}
+
+type subscriber[T any] struct {
+	id int
+	fn func(T)
+}
+
+func newTopic[T any](name string) *Topic[T] {
+	return &Topic[T]{name: name}
+}
+
+// Name returns the name of the topic, e.g. "check.completed"
+func (t *Topic[T]) Name() string {
+	return t.name
+}
+
+// Subscribe calls fn for every event published from now on until the
+// returned unsubscribe is called
+func (t *Topic[T]) Subscribe(fn func(T)) (unsubscribe func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.nextID++
+	id := t.nextID
+	t.subs = append(t.subs, subscriber[T]{id: id, fn: fn})
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			// Copy so Publish calls in progress keep their snapshot
+			t.subs = slices.DeleteFunc(slices.Clone(t.subs), func(s subscriber[T]) bool { return s.id == id })
+		})
+	}
+}
+
+// Publish delivers an event to every subscriber. A subscriber that panics
+// is logged and skipped so the others and the publisher carry on.
+func (t *Topic[T]) Publish(event T) {
+	t.mu.RLock()
+	subs := t.subs
+	t.mu.RUnlock()
+
+	for _, s := range subs {
+		t.deliver(s.fn, event)
+	}
+}
+
+// Subscribers returns the number of subscribers
+func (t *Topic[T]) Subscribers() int {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return len(t.subs)
+}
+
+func (t *Topic[T]) deliver(fn func(T), event T) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[Events] Subscriber of %s panicked: %v\n%s", t.name, r, debug.Stack())
+		}
+	}()
+	fn(event)
+}
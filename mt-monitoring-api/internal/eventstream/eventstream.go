@@ -0,0 +1,334 @@
+// Package eventstream streams check results, incidents and alerts as JSON
+// events to NATS subjects and Kafka topics for downstream stream
+// processing. Every event has the same envelope (Event) and a data object
+// whose schema depends on its type; fields are only ever added.
+package eventstream
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// SchemaVersion is the version of the event envelope and data schemas
+const SchemaVersion = 1
+
+// Event types
+const (
+	TypeMetric   = "metric"
+	TypeIncident = "incident"
+	TypeAlert    = "alert"
+)
+
+// Event is the envelope of every streamed event
+type Event struct {
+	Schema int             `json:"schema"`
+	ID     string          `json:"id"` // UUID, for deduplication downstream
+	Type   string          `json:"type"`
+	Source string          `json:"source"`
+	Key    string          `json:"key"` // service or host ID; Kafka message key
+	Time   time.Time       `json:"time"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// MetricData is the data of a "metric" event, one per service check
+type MetricData struct {
+	ServiceID    string             `json:"serviceId"`
+	ServiceName  string             `json:"serviceName"`
+	ServiceType  models.ServiceType `json:"serviceType"`
+	Status       models.CheckStatus `json:"status"`
+	ResponseTime int                `json:"responseTime"` // milliseconds
+	StatusCode   int                `json:"statusCode,omitempty"`
+	ErrorMessage string             `json:"errorMessage,omitempty"`
+	CheckedAt    time.Time          `json:"checkedAt"`
+}
+
+// IncidentData is the data of an "incident" event
+type IncidentData struct {
+	Event      string              `json:"event"` // "created" | "resolved"
+	ID         int64               `json:"id"`
+	ServiceID  string              `json:"serviceId"`
+	Type       models.IncidentType `json:"type"`
+	Message    string              `json:"message,omitempty"`
+	StartedAt  time.Time           `json:"startedAt"`
+	ResolvedAt *time.Time          `json:"resolvedAt,omitempty"`
+}
+
+// AlertData is the data of an "alert" event
+type AlertData struct {
+	Type        string    `json:"type"` // "healthcheck" | "log" | "resource" | "endpoint" | "system" | "alertmanager"
+	Severity    string    `json:"severity,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	HostID      string    `json:"hostId,omitempty"`
+	HostName    string    `json:"hostName,omitempty"`
+	ServiceID   string    `json:"serviceId,omitempty"`
+	ServiceName string    `json:"serviceName,omitempty"`
+	Metric      string    `json:"metric,omitempty"`
+	Value       float64   `json:"value,omitempty"`
+	Threshold   float64   `json:"threshold,omitempty"`
+	Message     string    `json:"message"`
+	Time        time.Time `json:"time"`
+}
+
+// Incident events
+const (
+	IncidentCreated  = "created"
+	IncidentResolved = "resolved"
+)
+
+// Sink writes batches of events to a broker
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// Streamer buffers events and forwards them to every configured sink in
+// batches. When the queue is full new events are dropped so checks never
+// block on a slow broker.
+type Streamer struct {
+	source        string
+	sinks         []Sink
+	queue         chan Event
+	batchSize     int
+	flushInterval time.Duration
+
+	stopCh chan struct{}
+	done   chan struct{}
+
+	dropped atomic.Int64
+}
+
+// current is the streamer installed by Setup, nil while disabled
+var current atomic.Pointer[Streamer]
+
+// Enabled reports whether events are being streamed
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Setup starts streaming when eventStream.enabled is set. The server entry
+// point calls it at startup and the returned shutdown on exit, which
+// flushes queued events. Without streaming shutdown does nothing.
+func Setup() (shutdown func(context.Context) error) {
+	noop := func(context.Context) error { return nil }
+	cfg := config.Get()
+	if cfg == nil || !cfg.EventStream.Enabled {
+		return noop
+	}
+	c := cfg.EventStream
+
+	var sinks []Sink
+	if c.NATS.Enabled {
+		sinks = append(sinks, NewNATSSink(c.NATS))
+	}
+	if c.Kafka.Enabled {
+		sinks = append(sinks, NewKafkaSink(c.Kafka))
+	}
+	if len(sinks) == 0 {
+		return noop
+	}
+
+	batchSize := c.BatchSize
+	if batchSize <= 0 {
+		batchSize = 100
+	}
+	queueSize := c.QueueSize
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+	flushInterval := time.Duration(c.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	s := &Streamer{
+		source:        c.Source,
+		sinks:         sinks,
+		queue:         make(chan Event, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		stopCh:        make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	go s.run()
+	current.Store(s)
+	unsubscribe := subscribe()
+
+	names := make([]string, 0, len(sinks))
+	for _, sink := range sinks {
+		names = append(names, sink.Name())
+	}
+	log.Printf("Event streaming enabled (sinks: %v)", names)
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		once.Do(func() {
+			unsubscribe()
+			current.CompareAndSwap(s, nil)
+			close(s.stopCh)
+		})
+		select {
+		case <-s.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// subscribe streams check results and incidents from the event bus
+func subscribe() (unsubscribe func()) {
+	unsubscribes := []func(){
+		events.CheckCompleted.Subscribe(func(c events.Check) {
+			PublishMetric(c.Service, c.Metric)
+		}),
+		events.IncidentCreated.Subscribe(func(incident *models.Incident) {
+			PublishIncident(IncidentCreated, incident)
+		}),
+		events.IncidentResolved.Subscribe(func(incident *models.Incident) {
+			PublishIncident(IncidentResolved, incident)
+		}),
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// PublishMetric streams the result of a service check
+func PublishMetric(service *models.Service, m *models.Metric) {
+	publish(TypeMetric, service.ID, MetricData{
+		ServiceID:    service.ID,
+		ServiceName:  service.Name,
+		ServiceType:  service.Type,
+		Status:       m.Status,
+		ResponseTime: m.ResponseTime,
+		StatusCode:   m.StatusCode,
+		ErrorMessage: m.ErrorMessage,
+		CheckedAt:    m.CheckedAt,
+	})
+}
+
+// PublishIncident streams an incident event
+func PublishIncident(event string, incident *models.Incident) {
+	publish(TypeIncident, incident.ServiceID, IncidentData{
+		Event:      event,
+		ID:         incident.ID,
+		ServiceID:  incident.ServiceID,
+		Type:       incident.Type,
+		Message:    incident.Message,
+		StartedAt:  incident.StartedAt,
+		ResolvedAt: incident.ResolvedAt,
+	})
+}
+
+// PublishAlert streams an alert. Its key is the service ID, or the host ID
+// for host alerts.
+func PublishAlert(alert AlertData) {
+	key := alert.ServiceID
+	if key == "" {
+		key = alert.HostID
+	}
+	publish(TypeAlert, key, alert)
+}
+
+// publish wraps data in an event and queues it without blocking
+func publish(eventType, key string, data interface{}) {
+	s := current.Load()
+	if s == nil {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("[EventStream] Failed to encode %s event: %v", eventType, err)
+		return
+	}
+	event := Event{
+		Schema: SchemaVersion,
+		ID:     uuid.NewString(),
+		Type:   eventType,
+		Source: s.source,
+		Key:    key,
+		Time:   time.Now(),
+		Data:   raw,
+	}
+
+	select {
+	case s.queue <- event:
+	default:
+		s.dropped.Add(1)
+	}
+}
+
+// run collects events into batches and flushes on size or interval
+func (s *Streamer) run() {
+	defer close(s.done)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, s.batchSize)
+	for {
+		select {
+		case e := <-s.queue:
+			batch = append(batch, e)
+			if len(batch) >= s.batchSize {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				s.flush(batch)
+				batch = batch[:0]
+			}
+			if n := s.dropped.Swap(0); n > 0 {
+				log.Printf("[EventStream] Queue full, dropped %d events", n)
+			}
+		case <-s.stopCh:
+			// Drain what is already queued
+			for {
+				select {
+				case e := <-s.queue:
+					batch = append(batch, e)
+					if len(batch) >= s.batchSize {
+						s.flush(batch)
+						batch = batch[:0]
+					}
+				default:
+					if len(batch) > 0 {
+						s.flush(batch)
+					}
+					for _, sink := range s.sinks {
+						if err := sink.Close(); err != nil {
+							log.Printf("[EventStream] Failed to close %s sink: %v", sink.Name(), err)
+						}
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch to every sink. Failed batches are logged and
+// dropped; the sink reconnects on its next write.
+func (s *Streamer) flush(batch []Event) {
+	for _, sink := range s.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := sink.Write(ctx, batch); err != nil {
+			log.Printf("[EventStream] Failed to write %d events to %s: %v", len(batch), sink.Name(), err)
+		}
+		cancel()
+	}
+}
@@ -0,0 +1,468 @@
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// Kafka API keys and the versions used. Metadata v4 and Produce v3 with
+// record batches are accepted by brokers from 1.0 through 4.x.
+const (
+	kafkaProduce         = 0
+	kafkaMetadata        = 3
+	kafkaProduceVersion  = 3
+	kafkaMetadataVersion = 4
+)
+
+var castagnoli = crc32.MakeTable(crc32.Castagnoli)
+
+// KafkaSink produces events to a Kafka topic with the Kafka wire protocol.
+// Events are spread over partitions by key hash, keeping the events of a
+// service in order, and written with acks=all. Each event carries its
+// type in a "type" record header. SASL authentication is not supported.
+type KafkaSink struct {
+	cfg     config.KafkaStreamConfig
+	brokers map[int32]string // node ID -> host:port
+	leaders []int32          // partition -> leader node ID
+	conns   map[int32]*kafkaConn
+	next    uint32 // partition for events without a key
+}
+
+// NewKafkaSink creates a new Kafka sink. It loads the topic metadata on
+// the first write.
+func NewKafkaSink(cfg config.KafkaStreamConfig) *KafkaSink {
+	return &KafkaSink{cfg: cfg, conns: make(map[int32]*kafkaConn)}
+}
+
+// Name returns the sink name
+func (s *KafkaSink) Name() string {
+	return "kafka"
+}
+
+// Write produces a batch of events. After an error metadata is reloaded
+// and connections are reopened on the next write, following leader
+// changes.
+func (s *KafkaSink) Write(ctx context.Context, events []Event) error {
+	if s.leaders == nil {
+		if err := s.loadMetadata(ctx); err != nil {
+			return err
+		}
+	}
+
+	byPartition := make(map[int32][]Event)
+	var order []int32
+	for _, e := range events {
+		p := s.partition(e.Key)
+		if _, ok := byPartition[p]; !ok {
+			order = append(order, p)
+		}
+		byPartition[p] = append(byPartition[p], e)
+	}
+
+	for _, p := range order {
+		if err := s.produce(ctx, p, byPartition[p]); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	return nil
+}
+
+// Close closes all broker connections and forgets the metadata
+func (s *KafkaSink) Close() error {
+	for id, c := range s.conns {
+		c.nc.Close()
+		delete(s.conns, id)
+	}
+	s.leaders = nil
+	return nil
+}
+
+func (s *KafkaSink) partition(key string) int32 {
+	n := uint32(len(s.leaders))
+	if key == "" {
+		s.next++
+		return int32(s.next % n)
+	}
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int32(h.Sum32() % n)
+}
+
+// loadMetadata asks the bootstrap brokers in turn for the brokers and the
+// partition leaders of the topic
+func (s *KafkaSink) loadMetadata(ctx context.Context) error {
+	body := appendInt32(nil, 1)
+	body = appendString(body, s.cfg.Topic)
+	body = append(body, 1) // allow auto topic creation
+
+	var lastErr error
+	for _, addr := range s.cfg.Brokers {
+		c, err := dialKafka(ctx, addr, s.cfg)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := c.roundTrip(ctx, kafkaMetadata, kafkaMetadataVersion, body)
+		c.nc.Close()
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		return s.parseMetadata(resp)
+	}
+	return fmt.Errorf("metadata: %w", lastErr)
+}
+
+func (s *KafkaSink) parseMetadata(resp []byte) error {
+	d := &kafkaDecoder{b: resp}
+	d.int32() // throttle time
+
+	brokers := make(map[int32]string)
+	for n := d.int32(); n > 0 && d.err == nil; n-- {
+		id := d.int32()
+		host := d.string()
+		port := d.int32()
+		d.string() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	d.string() // cluster ID
+	d.int32()  // controller ID
+
+	var leaders []int32
+	for n := d.int32(); n > 0 && d.err == nil; n-- {
+		code := d.int16()
+		name := d.string()
+		d.int8() // is internal
+		partitions := make(map[int32]int32)
+		for p := d.int32(); p > 0 && d.err == nil; p-- {
+			d.int16() // partition error, e.g. an offline replica
+			index := d.int32()
+			partitions[index] = d.int32()
+			d.int32Array() // replicas
+			d.int32Array() // in-sync replicas
+		}
+		if d.err != nil || name != s.cfg.Topic {
+			continue
+		}
+		if code != 0 {
+			return fmt.Errorf("topic %s: %s", name, kafkaError(code))
+		}
+		leaders = make([]int32, len(partitions))
+		for index, leader := range partitions {
+			if index < 0 || int(index) >= len(leaders) {
+				return fmt.Errorf("topic %s: unexpected partition %d", name, index)
+			}
+			leaders[index] = leader
+		}
+	}
+	if d.err != nil {
+		return fmt.Errorf("metadata: %w", d.err)
+	}
+	if len(leaders) == 0 {
+		return fmt.Errorf("topic %s not found", s.cfg.Topic)
+	}
+
+	s.brokers = brokers
+	s.leaders = leaders
+	return nil
+}
+
+// produce writes events to one partition and checks the broker's answer
+func (s *KafkaSink) produce(ctx context.Context, partition int32, events []Event) error {
+	leader := s.leaders[partition]
+	c, err := s.conn(ctx, leader)
+	if err != nil {
+		return err
+	}
+
+	batch, err := encodeRecordBatch(events)
+	if err != nil {
+		return err
+	}
+	timeout := 10 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+
+	body := appendInt16(nil, -1) // transactional ID: none
+	body = appendInt16(body, -1) // acks: all in-sync replicas
+	body = appendInt32(body, int32(timeout.Milliseconds()))
+	body = appendInt32(body, 1)
+	body = appendString(body, s.cfg.Topic)
+	body = appendInt32(body, 1)
+	body = appendInt32(body, partition)
+	body = appendInt32(body, int32(len(batch)))
+	body = append(body, batch...)
+
+	resp, err := c.roundTrip(ctx, kafkaProduce, kafkaProduceVersion, body)
+	if err != nil {
+		return err
+	}
+
+	d := &kafkaDecoder{b: resp}
+	for n := d.int32(); n > 0 && d.err == nil; n-- {
+		d.string() // topic
+		for p := d.int32(); p > 0 && d.err == nil; p-- {
+			index := d.int32()
+			code := d.int16()
+			d.int64() // base offset
+			d.int64() // log append time
+			if d.err == nil && code != 0 {
+				return fmt.Errorf("partition %d: %s", index, kafkaError(code))
+			}
+		}
+	}
+	return d.err
+}
+
+// conn returns the connection to a broker, opening it if needed
+func (s *KafkaSink) conn(ctx context.Context, node int32) (*kafkaConn, error) {
+	if c, ok := s.conns[node]; ok {
+		return c, nil
+	}
+	addr, ok := s.brokers[node]
+	if !ok {
+		return nil, fmt.Errorf("no leader for partition (broker %d)", node)
+	}
+	c, err := dialKafka(ctx, addr, s.cfg)
+	if err != nil {
+		return nil, err
+	}
+	s.conns[node] = c
+	return c, nil
+}
+
+// encodeRecordBatch encodes events as an uncompressed record batch (magic
+// 2). The CRC-32C covers everything from the attributes on.
+func encodeRecordBatch(events []Event) ([]byte, error) {
+	base := events[0].Time.UnixMilli()
+	maxTimestamp := base
+
+	var records []byte
+	for i, e := range events {
+		value, err := json.Marshal(e)
+		if err != nil {
+			return nil, err
+		}
+		ts := e.Time.UnixMilli()
+		if ts > maxTimestamp {
+			maxTimestamp = ts
+		}
+
+		rec := []byte{0} // attributes
+		rec = binary.AppendVarint(rec, ts-base)
+		rec = binary.AppendVarint(rec, int64(i))
+		if e.Key == "" {
+			rec = binary.AppendVarint(rec, -1)
+		} else {
+			rec = appendVarBytes(rec, []byte(e.Key))
+		}
+		rec = appendVarBytes(rec, value)
+		rec = binary.AppendVarint(rec, 1) // headers
+		rec = appendVarBytes(rec, []byte("type"))
+		rec = appendVarBytes(rec, []byte(e.Type))
+
+		records = binary.AppendVarint(records, int64(len(rec)))
+		records = append(records, rec...)
+	}
+
+	body := appendInt16(nil, 0) // attributes: no compression
+	body = appendInt32(body, int32(len(events)-1))
+	body = appendInt64(body, base)
+	body = appendInt64(body, maxTimestamp)
+	body = appendInt64(body, -1) // producer ID
+	body = appendInt16(body, -1) // producer epoch
+	body = appendInt32(body, -1) // base sequence
+	body = appendInt32(body, int32(len(events)))
+	body = append(body, records...)
+
+	batch := appendInt64(nil, 0)                       // base offset
+	batch = appendInt32(batch, int32(4+1+4+len(body))) // length after this field
+	batch = appendInt32(batch, -1)                     // partition leader epoch
+	batch = append(batch, 2)                           // magic
+	batch = binary.BigEndian.AppendUint32(batch, crc32.Checksum(body, castagnoli))
+	return append(batch, body...), nil
+}
+
+// kafkaConn is a connection to one broker. Requests are sent one at a
+// time, so each response belongs to the last request.
+type kafkaConn struct {
+	nc            net.Conn
+	r             *bufio.Reader
+	clientID      string
+	correlationID int32
+}
+
+func dialKafka(ctx context.Context, addr string, cfg config.KafkaStreamConfig) (*kafkaConn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var nc net.Conn
+	var err error
+	if cfg.TLS {
+		host, _, _ := net.SplitHostPort(addr)
+		td := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+		nc, err = td.DialContext(ctx, "tcp", addr)
+	} else {
+		nc, err = dialer.DialContext(ctx, "tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &kafkaConn{nc: nc, r: bufio.NewReader(nc), clientID: cfg.ClientID}, nil
+}
+
+// roundTrip sends a request and returns the response body after the
+// correlation ID
+func (c *kafkaConn) roundTrip(ctx context.Context, apiKey, version int16, body []byte) ([]byte, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		c.nc.SetDeadline(deadline)
+	}
+
+	c.correlationID++
+	req := appendInt32(nil, 0) // size, filled in below
+	req = appendInt16(req, apiKey)
+	req = appendInt16(req, version)
+	req = appendInt32(req, c.correlationID)
+	req = appendString(req, c.clientID)
+	req = append(req, body...)
+	binary.BigEndian.PutUint32(req, uint32(len(req)-4))
+
+	if _, err := c.nc.Write(req); err != nil {
+		return nil, err
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(c.r, size[:]); err != nil {
+		return nil, err
+	}
+	resp := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(c.r, resp); err != nil {
+		return nil, err
+	}
+	if len(resp) < 4 || int32(binary.BigEndian.Uint32(resp)) != c.correlationID {
+		return nil, errors.New("response does not match the request")
+	}
+	return resp[4:], nil
+}
+
+func appendInt16(b []byte, v int16) []byte {
+	return binary.BigEndian.AppendUint16(b, uint16(v))
+}
+
+func appendInt32(b []byte, v int32) []byte {
+	return binary.BigEndian.AppendUint32(b, uint32(v))
+}
+
+func appendInt64(b []byte, v int64) []byte {
+	return binary.BigEndian.AppendUint64(b, uint64(v))
+}
+
+func appendString(b []byte, s string) []byte {
+	b = appendInt16(b, int16(len(s)))
+	return append(b, s...)
+}
+
+// appendVarBytes appends bytes with a zigzag varint length, as in records
+func appendVarBytes(b, v []byte) []byte {
+	b = binary.AppendVarint(b, int64(len(v)))
+	return append(b, v...)
+}
+
+// kafkaDecoder reads big-endian fields. The first short read sets err and
+// makes every later read return zero.
+type kafkaDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *kafkaDecoder) take(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n < 0 || len(d.b) < n {
+		d.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	v := d.b[:n]
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *kafkaDecoder) int8() int8 {
+	if v := d.take(1); v != nil {
+		return int8(v[0])
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int16() int16 {
+	if v := d.take(2); v != nil {
+		return int16(binary.BigEndian.Uint16(v))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int32() int32 {
+	if v := d.take(4); v != nil {
+		return int32(binary.BigEndian.Uint32(v))
+	}
+	return 0
+}
+
+func (d *kafkaDecoder) int64() int64 {
+	if v := d.take(8); v != nil {
+		return int64(binary.BigEndian.Uint64(v))
+	}
+	return 0
+}
+
+// string reads a nullable string; null reads as ""
+func (d *kafkaDecoder) string() string {
+	n := d.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(d.take(int(n)))
+}
+
+func (d *kafkaDecoder) int32Array() {
+	for n := d.int32(); n > 0 && d.err == nil; n-- {
+		d.int32()
+	}
+}
+
+// kafkaError names the broker error codes a producer is likely to see
+func kafkaError(code int16) string {
+	names := map[int16]string{
+		2:  "CORRUPT_MESSAGE",
+		3:  "UNKNOWN_TOPIC_OR_PARTITION",
+		5:  "LEADER_NOT_AVAILABLE",
+		6:  "NOT_LEADER_OR_FOLLOWER",
+		7:  "REQUEST_TIMED_OUT",
+		10: "MESSAGE_TOO_LARGE",
+		17: "INVALID_TOPIC_EXCEPTION",
+		19: "NOT_ENOUGH_REPLICAS",
+		20: "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+		29: "TOPIC_AUTHORIZATION_FAILED",
+		31: "CLUSTER_AUTHORIZATION_FAILED",
+		35: "UNSUPPORTED_VERSION",
+		87: "INVALID_RECORD",
+	}
+	if name, ok := names[code]; ok {
+		return fmt.Sprintf("%s (%d)", name, code)
+	}
+	return fmt.Sprintf("error code %d", code)
+}
@@ -0,0 +1,291 @@
+package eventstream
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// NATSSink publishes events with the core NATS protocol. Core NATS is
+// at-most-once: a PING/PONG round trip after each batch confirms the
+// server received it, not that a subscriber did.
+type NATSSink struct {
+	cfg  config.NATSStreamConfig
+	conn *natsConn
+}
+
+// NewNATSSink creates a new NATS sink. It connects on the first write.
+func NewNATSSink(cfg config.NATSStreamConfig) *NATSSink {
+	return &NATSSink{cfg: cfg}
+}
+
+// Name returns the sink name
+func (s *NATSSink) Name() string {
+	return "nats"
+}
+
+// Write publishes a batch of events, reconnecting first if needed
+func (s *NATSSink) Write(ctx context.Context, events []Event) error {
+	if s.conn == nil {
+		conn, err := dialNATS(ctx, s.cfg)
+		if err != nil {
+			return err
+		}
+		s.conn = conn
+	}
+
+	err := s.publish(ctx, events)
+	if err != nil {
+		s.conn.close()
+		s.conn = nil
+	}
+	return err
+}
+
+func (s *NATSSink) publish(ctx context.Context, events []Event) error {
+	if deadline, ok := ctx.Deadline(); ok {
+		s.conn.setWriteDeadline(deadline)
+		defer s.conn.setWriteDeadline(time.Time{})
+	}
+	for _, e := range events {
+		payload, err := json.Marshal(e)
+		if err != nil {
+			return err
+		}
+		if err := s.conn.pub(s.subject(e), payload); err != nil {
+			return err
+		}
+	}
+	return s.conn.flush(ctx)
+}
+
+// subject returns <prefix>.<type>.<key>. Subject tokens cannot contain
+// dots, spaces or wildcards, so those are replaced in the key.
+func (s *NATSSink) subject(e Event) string {
+	key := strings.Map(func(r rune) rune {
+		switch r {
+		case '.', ' ', '\t', '\r', '\n', '*', '>':
+			return '_'
+		}
+		return r
+	}, e.Key)
+	if key == "" {
+		key = "_"
+	}
+	return s.cfg.SubjectPrefix + "." + e.Type + "." + key
+}
+
+// Close closes the connection
+func (s *NATSSink) Close() error {
+	if s.conn != nil {
+		s.conn.close()
+		s.conn = nil
+	}
+	return nil
+}
+
+// natsConn is a publishing connection to a NATS server. A reader
+// goroutine answers the server's PINGs and collects PONGs and errors.
+type natsConn struct {
+	nc net.Conn
+
+	mu sync.Mutex // guards w
+	w  *bufio.Writer
+
+	pongs chan struct{}
+	errs  chan error // -ERR from the server or the read error ending the reader
+}
+
+// natsInfo is the part of the server's INFO message used here
+type natsInfo struct {
+	TLSRequired bool `json:"tls_required"`
+}
+
+func dialNATS(ctx context.Context, cfg config.NATSStreamConfig) (*natsConn, error) {
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, err
+	}
+	addr := u.Host
+	if u.Port() == "" {
+		addr = net.JoinHostPort(u.Hostname(), "4222")
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	nc, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		nc.SetDeadline(deadline)
+	}
+
+	// The server greets with INFO; TLS is negotiated after it
+	r := bufio.NewReader(nc)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		nc.Close()
+		return nil, err
+	}
+	if !strings.HasPrefix(line, "INFO ") {
+		nc.Close()
+		return nil, fmt.Errorf("expected INFO, got %q", strings.TrimSpace(line))
+	}
+	var info natsInfo
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "INFO ")), &info); err != nil {
+		nc.Close()
+		return nil, fmt.Errorf("invalid INFO: %w", err)
+	}
+	if u.Scheme == "tls" || info.TLSRequired {
+		tlsConn := tls.Client(nc, &tls.Config{ServerName: u.Hostname()})
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			nc.Close()
+			return nil, err
+		}
+		nc = tlsConn
+		r = bufio.NewReader(nc)
+	}
+
+	connect := map[string]interface{}{
+		"verbose":  false,
+		"pedantic": false,
+		"name":     "mt-monitoring-api",
+		"lang":     "go",
+		"version":  "1.0.0",
+		"protocol": 1,
+	}
+	user, pass := cfg.Username, cfg.Password
+	if u.User != nil {
+		user = u.User.Username()
+		pass, _ = u.User.Password()
+	}
+	if user != "" {
+		connect["user"] = user
+		connect["pass"] = pass
+	}
+	if cfg.Token != "" {
+		connect["auth_token"] = cfg.Token
+	}
+	connectJSON, _ := json.Marshal(connect)
+
+	// PING right after CONNECT: the PONG confirms the login, an -ERR
+	// reports why it failed
+	if _, err := fmt.Fprintf(nc, "CONNECT %s\r\nPING\r\n", connectJSON); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			nc.Close()
+			return nil, err
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			nc.Close()
+			return nil, fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+	nc.SetDeadline(time.Time{})
+
+	c := &natsConn{
+		nc:    nc,
+		w:     bufio.NewWriter(nc),
+		pongs: make(chan struct{}, 1),
+		errs:  make(chan error, 1),
+	}
+	go c.readLoop(r)
+	return c, nil
+}
+
+// readLoop handles what the server sends until the connection closes
+func (c *natsConn) readLoop(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			c.fail(err)
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			c.mu.Lock()
+			c.w.WriteString("PONG\r\n")
+			c.w.Flush()
+			c.mu.Unlock()
+		case line == "PONG":
+			select {
+			case c.pongs <- struct{}{}:
+			default:
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			c.fail(fmt.Errorf("server error: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))))
+		}
+	}
+}
+
+func (c *natsConn) fail(err error) {
+	select {
+	case c.errs <- err:
+	default:
+	}
+}
+
+// pub buffers a PUB message
+func (c *natsConn) pub(subject string, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, err := fmt.Fprintf(c.w, "PUB %s %d\r\n", subject, len(payload)); err != nil {
+		return err
+	}
+	if _, err := c.w.Write(payload); err != nil {
+		return err
+	}
+	_, err := c.w.WriteString("\r\n")
+	return err
+}
+
+// flush sends buffered messages and waits for the server to answer a PING
+func (c *natsConn) flush(ctx context.Context) error {
+	c.mu.Lock()
+	c.w.WriteString("PING\r\n")
+	err := c.w.Flush()
+	c.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	select {
+	case <-c.pongs:
+		return nil
+	case err := <-c.errs:
+		return err
+	case <-ctx.Done():
+		return errors.New("timed out waiting for PONG")
+	}
+}
+
+func (c *natsConn) setWriteDeadline(t time.Time) {
+	c.mu.Lock()
+	c.nc.SetWriteDeadline(t)
+	c.mu.Unlock()
+}
+
+func (c *natsConn) close() {
+	c.mu.Lock()
+	c.w.Flush()
+	c.mu.Unlock()
+	c.nc.Close()
+}
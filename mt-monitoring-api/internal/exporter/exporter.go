@@ -0,0 +1,247 @@
+package exporter
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Sample is a single labelled data point
+type Sample struct {
+	Name      string
+	Labels    map[string]string
+	Value     float64
+	Timestamp time.Time
+}
+
+// Sink writes batches of samples to an external time-series database
+type Sink interface {
+	Name() string
+	Write(ctx context.Context, samples []Sample) error
+	Close() error
+}
+
+// Exporter buffers metric samples and forwards them to every configured sink
+// in batches. When the queue is full new samples are dropped so collection
+// and health checks never block on a slow TSDB.
+type Exporter struct {
+	sinks         []Sink
+	queue         chan Sample
+	batchSize     int
+	flushInterval time.Duration
+
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+	unsubscribe []func()
+
+	mu      sync.Mutex
+	dropped int64
+}
+
+// NewExporter builds an exporter from config.
+// Returns nil when no sink is enabled.
+func NewExporter(cfg config.ExportConfig) (*Exporter, error) {
+	var sinks []Sink
+
+	if cfg.Prometheus.Enabled {
+		sinks = append(sinks, NewPrometheusSink(cfg.Prometheus))
+	}
+	if cfg.Influx.Enabled {
+		sinks = append(sinks, NewInfluxSink(cfg.Influx))
+	}
+	if cfg.Timescale.Enabled {
+		sink, err := NewTimescaleSink(cfg.Timescale)
+		if err != nil {
+			for _, s := range sinks {
+				s.Close()
+			}
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+
+	if len(sinks) == 0 {
+		return nil, nil
+	}
+
+	batchSize := cfg.BatchSize
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+	queueSize := cfg.QueueSize
+	if queueSize <= 0 {
+		queueSize = 10000
+	}
+	flushInterval := time.Duration(cfg.FlushInterval) * time.Second
+	if flushInterval <= 0 {
+		flushInterval = 10 * time.Second
+	}
+
+	return &Exporter{
+		sinks:         sinks,
+		queue:         make(chan Sample, queueSize),
+		batchSize:     batchSize,
+		flushInterval: flushInterval,
+		stopCh:        make(chan struct{}),
+	}, nil
+}
+
+// Start begins the background flush loop and exports every check result
+// and stored host metric published from then on
+func (e *Exporter) Start() {
+	e.wg.Add(1)
+	go e.run()
+
+	e.unsubscribe = []func(){
+		events.CheckCompleted.Subscribe(func(c events.Check) {
+			e.ExportServiceMetric(c.Service, c.Metric)
+		}),
+		events.MetricStored.Subscribe(e.ExportSystemMetric),
+	}
+
+	names := make([]string, 0, len(e.sinks))
+	for _, s := range e.sinks {
+		names = append(names, s.Name())
+	}
+	log.Printf("Metric exporter started (sinks: %v)", names)
+}
+
+// Stop flushes buffered samples and closes all sinks
+func (e *Exporter) Stop() {
+	for _, unsubscribe := range e.unsubscribe {
+		unsubscribe()
+	}
+	close(e.stopCh)
+	e.wg.Wait()
+
+	for _, s := range e.sinks {
+		if err := s.Close(); err != nil {
+			log.Printf("[Exporter] Failed to close %s sink: %v", s.Name(), err)
+		}
+	}
+}
+
+// Enqueue adds samples to the export queue without blocking
+func (e *Exporter) Enqueue(samples ...Sample) {
+	for _, s := range samples {
+		select {
+		case e.queue <- s:
+		default:
+			e.mu.Lock()
+			e.dropped++
+			e.mu.Unlock()
+		}
+	}
+}
+
+// ExportServiceMetric converts a health check result into samples
+func (e *Exporter) ExportServiceMetric(service *models.Service, m *models.Metric) {
+	labels := map[string]string{
+		"service_id":   service.ID,
+		"service_name": service.Name,
+		"type":         string(service.Type),
+	}
+
+	up := 0.0
+	if m.Status == models.CheckStatusSuccess {
+		up = 1
+	}
+
+	samples := []Sample{
+		{Name: "mt_service_up", Labels: labels, Value: up, Timestamp: m.CheckedAt},
+		{Name: "mt_service_response_time_ms", Labels: labels, Value: float64(m.ResponseTime), Timestamp: m.CheckedAt},
+	}
+	if m.StatusCode > 0 {
+		samples = append(samples, Sample{Name: "mt_service_status_code", Labels: labels, Value: float64(m.StatusCode), Timestamp: m.CheckedAt})
+	}
+
+	e.Enqueue(samples...)
+}
+
+// ExportSystemMetric converts a stored host metric aggregate into samples
+func (e *Exporter) ExportSystemMetric(m *models.SystemMetric) {
+	labels := map[string]string{"host_id": m.HostID}
+	ts := m.CreatedAt
+
+	e.Enqueue(
+		Sample{Name: "mt_host_cpu_usage_percent", Labels: labels, Value: m.CPUUsage, Timestamp: ts},
+		Sample{Name: "mt_host_memory_usage_percent", Labels: labels, Value: m.MemUsage, Timestamp: ts},
+		Sample{Name: "mt_host_memory_used_gigabytes", Labels: labels, Value: m.MemUsed, Timestamp: ts},
+		Sample{Name: "mt_host_memory_total_gigabytes", Labels: labels, Value: m.MemTotal, Timestamp: ts},
+		Sample{Name: "mt_host_disk_usage_percent", Labels: labels, Value: m.DiskUsage, Timestamp: ts},
+		Sample{Name: "mt_host_disk_used_gigabytes", Labels: labels, Value: m.DiskUsed, Timestamp: ts},
+		Sample{Name: "mt_host_disk_total_gigabytes", Labels: labels, Value: m.DiskTotal, Timestamp: ts},
+		Sample{Name: "mt_host_disk_read_mbps", Labels: labels, Value: m.DiskRead, Timestamp: ts},
+		Sample{Name: "mt_host_disk_write_mbps", Labels: labels, Value: m.DiskWrite, Timestamp: ts},
+		Sample{Name: "mt_host_network_in_mbps", Labels: labels, Value: m.NetIn, Timestamp: ts},
+		Sample{Name: "mt_host_network_out_mbps", Labels: labels, Value: m.NetOut, Timestamp: ts},
+	)
+}
+
+// run collects samples into batches and flushes on size or interval
+func (e *Exporter) run() {
+	defer e.wg.Done()
+
+	ticker := time.NewTicker(e.flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Sample, 0, e.batchSize)
+	for {
+		select {
+		case s := <-e.queue:
+			batch = append(batch, s)
+			if len(batch) >= e.batchSize {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+		case <-ticker.C:
+			if len(batch) > 0 {
+				e.flush(batch)
+				batch = batch[:0]
+			}
+			e.reportDropped()
+		case <-e.stopCh:
+			// Drain what is already queued
+			for {
+				select {
+				case s := <-e.queue:
+					batch = append(batch, s)
+				default:
+					if len(batch) > 0 {
+						e.flush(batch)
+					}
+					return
+				}
+			}
+		}
+	}
+}
+
+// flush writes a batch to every sink. Failed batches are logged and dropped;
+// SQLite still holds the recent data.
+func (e *Exporter) flush(batch []Sample) {
+	for _, sink := range e.sinks {
+		ctx, cancel := context.WithTimeout(context.Background(), e.flushInterval)
+		if err := sink.Write(ctx, batch); err != nil {
+			log.Printf("[Exporter] Failed to write %d samples to %s: %v", len(batch), sink.Name(), err)
+		}
+		cancel()
+	}
+}
+
+// reportDropped logs samples dropped since the last report
+func (e *Exporter) reportDropped() {
+	e.mu.Lock()
+	dropped := e.dropped
+	e.dropped = 0
+	e.mu.Unlock()
+
+	if dropped > 0 {
+		log.Printf("[Exporter] Queue full, dropped %d samples", dropped)
+	}
+}
@@ -0,0 +1,131 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// InfluxSink sends samples to InfluxDB using the line protocol
+type InfluxSink struct {
+	cfg    config.InfluxExportConfig
+	client *http.Client
+}
+
+// NewInfluxSink creates a new InfluxDB sink
+func NewInfluxSink(cfg config.InfluxExportConfig) *InfluxSink {
+	return &InfluxSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the sink name
+func (i *InfluxSink) Name() string {
+	return "influx"
+}
+
+// Write sends a batch of samples
+func (i *InfluxSink) Write(ctx context.Context, samples []Sample) error {
+	target, err := i.writeURL()
+	if err != nil {
+		return err
+	}
+
+	var body bytes.Buffer
+	for _, s := range samples {
+		writeLine(&body, s)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, &body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	if i.cfg.Token != "" {
+		req.Header.Set("Authorization", "Token "+i.cfg.Token)
+	}
+
+	resp, err := i.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("InfluxDB returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close releases resources
+func (i *InfluxSink) Close() error {
+	return nil
+}
+
+// writeURL returns the 2.x write endpoint, or the 1.x endpoint when Database is set
+func (i *InfluxSink) writeURL() (string, error) {
+	base, err := url.Parse(strings.TrimSuffix(i.cfg.URL, "/"))
+	if err != nil || base.Host == "" {
+		return "", fmt.Errorf("invalid InfluxDB URL %q", i.cfg.URL)
+	}
+
+	q := url.Values{}
+	q.Set("precision", "ms")
+	if i.cfg.Database != "" {
+		base.Path += "/write"
+		q.Set("db", i.cfg.Database)
+	} else {
+		base.Path += "/api/v2/write"
+		q.Set("org", i.cfg.Org)
+		q.Set("bucket", i.cfg.Bucket)
+	}
+	base.RawQuery = q.Encode()
+	return base.String(), nil
+}
+
+// writeLine appends one sample as "measurement,tag=v value=<f> <ms>\n"
+func writeLine(b *bytes.Buffer, s Sample) {
+	b.WriteString(influxEscape(s.Name, false))
+
+	keys := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		v := s.Labels[k]
+		if v == "" {
+			continue // empty tag values are not allowed
+		}
+		b.WriteByte(',')
+		b.WriteString(influxEscape(k, true))
+		b.WriteByte('=')
+		b.WriteString(influxEscape(v, true))
+	}
+
+	b.WriteString(" value=")
+	b.WriteString(strconv.FormatFloat(s.Value, 'f', -1, 64))
+	b.WriteByte(' ')
+	b.WriteString(strconv.FormatInt(s.Timestamp.UnixMilli(), 10))
+	b.WriteByte('\n')
+}
+
+// influxEscape escapes commas and spaces (and equals signs in tags)
+func influxEscape(s string, tag bool) string {
+	r := strings.NewReplacer(",", `\,`, " ", `\ `)
+	if tag {
+		r = strings.NewReplacer(",", `\,`, " ", `\ `, "=", `\=`)
+	}
+	return r.Replace(s)
+}
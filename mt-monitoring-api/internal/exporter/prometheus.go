@@ -0,0 +1,125 @@
+package exporter
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/klauspost/compress/s2"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// PrometheusSink sends samples using the Prometheus remote_write protocol
+// (snappy-compressed protobuf WriteRequest)
+type PrometheusSink struct {
+	cfg    config.PrometheusExportConfig
+	client *http.Client
+}
+
+// NewPrometheusSink creates a new remote_write sink
+func NewPrometheusSink(cfg config.PrometheusExportConfig) *PrometheusSink {
+	return &PrometheusSink{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+// Name returns the sink name
+func (p *PrometheusSink) Name() string {
+	return "prometheus"
+}
+
+// Write sends a batch of samples
+func (p *PrometheusSink) Write(ctx context.Context, samples []Sample) error {
+	body := s2.EncodeSnappy(nil, encodeWriteRequest(samples))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req.Header.Set("Content-Encoding", "snappy")
+	req.Header.Set("X-Prometheus-Remote-Write-Version", "0.1.0")
+	if p.cfg.BearerToken != "" {
+		req.Header.Set("Authorization", "Bearer "+p.cfg.BearerToken)
+	} else if p.cfg.Username != "" {
+		req.SetBasicAuth(p.cfg.Username, p.cfg.Password)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("remote_write returned status %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Close releases resources
+func (p *PrometheusSink) Close() error {
+	return nil
+}
+
+// encodeWriteRequest encodes samples as a prometheus.WriteRequest protobuf.
+// Each sample becomes its own TimeSeries; receivers merge series by labels.
+//
+//	WriteRequest { repeated TimeSeries timeseries = 1; }
+//	TimeSeries   { repeated Label labels = 1; repeated Sample samples = 2; }
+//	Label        { string name = 1; string value = 2; }
+//	Sample       { double value = 1; int64 timestamp = 2; }
+func encodeWriteRequest(samples []Sample) []byte {
+	var out []byte
+	for _, s := range samples {
+		out = appendBytesField(out, 1, encodeTimeSeries(s))
+	}
+	return out
+}
+
+func encodeTimeSeries(s Sample) []byte {
+	// Labels must be sorted by name, with __name__ first
+	names := make([]string, 0, len(s.Labels))
+	for k := range s.Labels {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var ts []byte
+	ts = appendBytesField(ts, 1, encodeLabel("__name__", s.Name))
+	for _, k := range names {
+		ts = appendBytesField(ts, 1, encodeLabel(k, s.Labels[k]))
+	}
+
+	var sample []byte
+	sample = append(sample, 0x09) // field 1, fixed64
+	sample = binary.LittleEndian.AppendUint64(sample, math.Float64bits(s.Value))
+	sample = append(sample, 0x10) // field 2, varint
+	sample = binary.AppendUvarint(sample, uint64(s.Timestamp.UnixMilli()))
+	ts = appendBytesField(ts, 2, sample)
+
+	return ts
+}
+
+func encodeLabel(name, value string) []byte {
+	var l []byte
+	l = appendBytesField(l, 1, []byte(name))
+	l = appendBytesField(l, 2, []byte(value))
+	return l
+}
+
+// appendBytesField appends a length-delimited protobuf field
+func appendBytesField(b []byte, field int, data []byte) []byte {
+	b = binary.AppendUvarint(b, uint64(field<<3|2))
+	b = binary.AppendUvarint(b, uint64(len(data)))
+	return append(b, data...)
+}
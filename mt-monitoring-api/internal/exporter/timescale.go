@@ -0,0 +1,90 @@
+package exporter
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+
+	_ "github.com/lib/pq" // PostgreSQL driver for TimescaleDB
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+var validTableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// TimescaleSink writes samples into a TimescaleDB hypertable
+type TimescaleSink struct {
+	db    *sql.DB
+	table string
+}
+
+// NewTimescaleSink connects to TimescaleDB and creates the target table if missing
+func NewTimescaleSink(cfg config.TimescaleExportConfig) (*TimescaleSink, error) {
+	table := cfg.Table
+	if table == "" {
+		table = "mt_metrics"
+	}
+	if !validTableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid TimescaleDB table name %q", table)
+	}
+
+	db, err := sql.Open("postgres", cfg.DSN)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TimescaleDB: %w", err)
+	}
+	db.SetMaxOpenConns(2)
+
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS ` + table + ` (
+		time   TIMESTAMPTZ      NOT NULL,
+		name   TEXT             NOT NULL,
+		labels JSONB            NOT NULL DEFAULT '{}',
+		value  DOUBLE PRECISION NOT NULL
+	)`); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to create %s: %w", table, err)
+	}
+
+	// Plain PostgreSQL works too; only TimescaleDB turns it into a hypertable
+	if _, err := db.Exec(`SELECT create_hypertable($1, 'time', if_not_exists => TRUE)`, table); err != nil {
+		log.Printf("[Exporter] %s is not a hypertable (TimescaleDB extension missing?): %v", table, err)
+	}
+	db.Exec(`CREATE INDEX IF NOT EXISTS ` + table + `_name_time_idx ON ` + table + ` (name, time DESC)`)
+
+	return &TimescaleSink{db: db, table: table}, nil
+}
+
+// Name returns the sink name
+func (t *TimescaleSink) Name() string {
+	return "timescale"
+}
+
+// Write inserts a batch of samples in one transaction
+func (t *TimescaleSink) Write(ctx context.Context, samples []Sample) error {
+	tx, err := t.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO `+t.table+` (time, name, labels, value) VALUES ($1, $2, $3, $4)`)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	defer stmt.Close()
+
+	for _, s := range samples {
+		labels, _ := json.Marshal(s.Labels)
+		if _, err := stmt.ExecContext(ctx, s.Timestamp, s.Name, string(labels), s.Value); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Close closes the database connection
+func (t *TimescaleSink) Close() error {
+	return t.db.Close()
+}
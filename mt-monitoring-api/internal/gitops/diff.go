@@ -0,0 +1,388 @@
+package gitops
+
+import (
+	"context"
+	"reflect"
+	"sort"
+
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// change is a detected drift and the function that corrects it
+type change struct {
+	drift models.Drift
+	apply func(ctx context.Context) error
+}
+
+// field pairs a stored and a declared value for comparison
+type field struct {
+	name       string
+	have, want interface{}
+}
+
+// changedFields returns the names of fields whose values differ. Nil and
+// empty maps/slices are equal.
+func changedFields(fields ...field) []string {
+	var changed []string
+	for _, f := range fields {
+		if isEmpty(f.have) && isEmpty(f.want) {
+			continue
+		}
+		if !reflect.DeepEqual(f.have, f.want) {
+			changed = append(changed, f.name)
+		}
+	}
+	return changed
+}
+
+func isEmpty(v interface{}) bool {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map, reflect.Slice:
+		return rv.Len() == 0
+	case reflect.Invalid:
+		return true
+	}
+	return false
+}
+
+func (r *Reconciler) diffServices(ctx context.Context, declared []config.ServiceConfig, prune bool) ([]change, error) {
+	stored, err := r.store.Services.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Service, len(stored))
+	for i := range stored {
+		byID[stored[i].ID] = &stored[i]
+	}
+
+	var changes []change
+	declaredIDs := make(map[string]bool, len(declared))
+	for _, svc := range declared {
+		svc := svc
+		declaredIDs[svc.ID] = true
+		sync := func(ctx context.Context) error {
+			return r.scheduler.SyncServices(ctx, []config.ServiceConfig{svc})
+		}
+
+		have := byID[svc.ID]
+		if have == nil {
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedService, ID: svc.ID, Action: models.DriftCreate},
+				apply: sync,
+			})
+			continue
+		}
+
+		want := checker.ServiceFromConfig(svc)
+		// An undeclared ping key is generated on sync and not drift
+		if want.PingKey == "" {
+			want.PingKey = have.PingKey
+		}
+		// An undeclared insecureSkipVerify keeps the stored flag
+		if svc.InsecureSkipVerify == nil {
+			want.InsecureSkipVerify = have.InsecureSkipVerify
+		}
+		fields := changedFields(
+			field{"name", have.Name, want.Name},
+			field{"type", have.Type, want.Type},
+			field{"url", have.URL, want.URL},
+			field{"port", have.Port, want.Port},
+			field{"method", have.Method, want.Method},
+			field{"headers", have.Headers, want.Headers},
+			field{"expectedStatus", have.ExpectedStatus, want.ExpectedStatus},
+			field{"interval", have.Interval, want.Interval},
+			field{"timeout", have.Timeout, want.Timeout},
+			field{"tags", have.Tags, want.Tags},
+			field{"pingKey", have.PingKey, want.PingKey},
+			field{"grace", have.Grace, want.Grace},
+			field{"caCert", have.CACert, want.CACert},
+			field{"insecureSkipVerify", have.InsecureSkipVerify, want.InsecureSkipVerify},
+			field{"protocol", have.Protocol, want.Protocol},
+			field{"selector", have.Selector, want.Selector},
+			field{"callbackUrl", have.CallbackURL, want.CallbackURL},
+			field{"callbackOn", have.CallbackOn, want.CallbackOn},
+			field{"ownership", have.Ownership, want.Ownership},
+		)
+		if len(fields) > 0 {
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedService, ID: svc.ID, Action: models.DriftUpdate, Fields: fields},
+				apply: sync,
+			})
+		}
+	}
+
+	if prune {
+		for _, svc := range stored {
+			id := svc.ID
+			if declaredIDs[id] || !svc.IsActive {
+				continue
+			}
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedService, ID: id, Action: models.DriftRemove},
+				apply: func(ctx context.Context) error { return r.scheduler.PauseService(ctx, id) },
+			})
+		}
+	}
+	return changes, nil
+}
+
+// hostFromConfig converts a declared host to a model with defaults applied
+func hostFromConfig(h config.HostConfig) *models.Host {
+	req := &models.HostCreateRequest{
+		ID:               h.ID,
+		Name:             h.Name,
+		Type:             models.HostType(h.Type),
+		ResourceCategory: models.HostResourceCategory(h.ResourceCategory),
+		IP:               h.IP,
+		Port:             h.Port,
+		Group:            h.Group,
+		Description:      h.Description,
+		SSHUser:          h.SSHUser,
+		SSHPort:          h.SSHPort,
+		SSHAuthType:      models.SSHAuthType(h.SSHAuthType),
+		SSHKeyPath:       h.SSHKeyPath,
+		SSHKey:           h.SSHKey,
+		SSHPassword:      h.SSHPassword,
+		SSHSecretRef:     h.SSHSecretRef,
+		CollectInterval:  h.CollectInterval,
+		StoreInterval:    h.StoreInterval,
+		Collector:        models.HostCollector(h.Collector),
+		ExporterURL:      h.ExporterURL,
+	}
+	ownership := models.Ownership(h.Ownership)
+	req.Ownership = &ownership
+	return req.ToHost()
+}
+
+func (r *Reconciler) diffHosts(ctx context.Context, declared []config.HostConfig, prune bool) ([]change, error) {
+	stored, err := r.store.Hosts.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.Host, len(stored))
+	for i := range stored {
+		byID[stored[i].ID] = &stored[i]
+	}
+
+	var changes []change
+	declaredIDs := make(map[string]bool, len(declared))
+	for _, h := range declared {
+		declaredIDs[h.ID] = true
+		want := hostFromConfig(h)
+
+		have := byID[h.ID]
+		if have == nil {
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedHost, ID: h.ID, Action: models.DriftCreate},
+				apply: func(ctx context.Context) error {
+					if err := r.store.Hosts.Create(ctx, want); err != nil {
+						return err
+					}
+					return r.registerCollector(want)
+				},
+			})
+			continue
+		}
+
+		fields := changedFields(
+			field{"name", have.Name, want.Name},
+			field{"type", have.Type, want.Type},
+			field{"resourceCategory", have.ResourceCategory, want.ResourceCategory},
+			field{"ip", have.IP, want.IP},
+			field{"port", have.Port, want.Port},
+			field{"group", have.Group, want.Group},
+			field{"description", have.Description, want.Description},
+			field{"sshUser", have.SSHUser, want.SSHUser},
+			field{"sshPort", have.SSHPort, want.SSHPort},
+			field{"sshAuthType", have.SSHAuthType, want.SSHAuthType},
+			field{"sshKeyPath", have.SSHKeyPath, want.SSHKeyPath},
+			field{"sshKey", have.SSHKey, want.SSHKey},
+			field{"sshPassword", have.SSHPassword, want.SSHPassword},
+			field{"sshSecretRef", have.SSHSecretRef, want.SSHSecretRef},
+			field{"collectInterval", have.CollectInterval, want.CollectInterval},
+			field{"storeInterval", have.StoreInterval, want.StoreInterval},
+			field{"collector", have.Collector, want.Collector},
+			field{"exporterUrl", have.ExporterURL, want.ExporterURL},
+			field{"ownership", have.Ownership, want.Ownership},
+		)
+		if len(fields) == 0 {
+			continue
+		}
+
+		updated := *have
+		changes = append(changes, change{
+			drift: models.Drift{Kind: models.ManagedHost, ID: h.ID, Action: models.DriftUpdate, Fields: fields},
+			apply: func(ctx context.Context) error {
+				// Pause state and status are operational, not declared
+				want.IsActive = updated.IsActive
+				want.CreatedAt = updated.CreatedAt
+				want.LastError = updated.LastError
+				if err := r.store.Hosts.Update(ctx, want); err != nil {
+					return err
+				}
+				return r.registerCollector(want)
+			},
+		})
+	}
+
+	if prune {
+		for _, h := range stored {
+			id := h.ID
+			if declaredIDs[id] || h.Type == models.HostTypeLocal {
+				continue
+			}
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedHost, ID: id, Action: models.DriftRemove},
+				apply: func(ctx context.Context) error {
+					if r.collectorMgr != nil {
+						r.collectorMgr.Unregister(id)
+					}
+					return r.store.Hosts.Delete(ctx, id)
+				},
+			})
+		}
+	}
+	return changes, nil
+}
+
+// registerCollector (re)starts collection for an active remote host; the
+// local host only takes the declared intervals
+func (r *Reconciler) registerCollector(h *models.Host) error {
+	if r.collectorMgr == nil {
+		return nil
+	}
+	if h.Type == models.HostTypeLocal {
+		r.collectorMgr.SetHostIntervals(h.ID, h.CollectInterval, h.StoreInterval)
+		return nil
+	}
+	if h.Type != models.HostTypeRemote || !h.IsActive {
+		return nil
+	}
+	return r.collectorMgr.RegisterRemoteHost(h)
+}
+
+// alertRuleFromConfig converts a declared rule to a model with defaults applied
+func alertRuleFromConfig(rule config.AlertRuleConfig) *models.AlertRule {
+	req := &models.AlertRuleCreateRequest{
+		Name:       rule.Name,
+		Type:       models.AlertRuleType(rule.Type),
+		HostID:     optional(rule.HostID),
+		ServiceID:  optional(rule.ServiceID),
+		Metric:     models.AlertMetric(rule.Metric),
+		Operator:   models.AlertOperator(rule.Operator),
+		Threshold:  rule.Threshold,
+		Duration:   rule.Duration,
+		Severity:   models.AlertSeverity(rule.Severity),
+		IsEnabled:  rule.Enabled,
+		Cooldown:   rule.Cooldown,
+		ChannelIDs: sorted(rule.ChannelIDs),
+
+		ResourceCategory: models.HostResourceCategory(rule.ResourceCategory),
+	}
+	return req.ToAlertRule(rule.ID)
+}
+
+func (r *Reconciler) diffAlertRules(ctx context.Context, declared []config.AlertRuleConfig, prune bool) ([]change, error) {
+	stored, err := r.store.AlertRules.GetAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := make(map[string]*models.AlertRule, len(stored))
+	for i := range stored {
+		byID[stored[i].ID] = &stored[i]
+	}
+
+	var changes []change
+	declaredIDs := make(map[string]bool, len(declared))
+	for _, rule := range declared {
+		declaredIDs[rule.ID] = true
+		want := alertRuleFromConfig(rule)
+
+		have := byID[rule.ID]
+		if have == nil {
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedAlertRule, ID: rule.ID, Action: models.DriftCreate},
+				apply: func(ctx context.Context) error { return r.store.AlertRules.Create(ctx, want) },
+			})
+			continue
+		}
+
+		fields := changedFields(
+			field{"name", have.Name, want.Name},
+			field{"type", have.Type, want.Type},
+			field{"hostId", deref(have.HostID), deref(want.HostID)},
+			field{"serviceId", deref(have.ServiceID), deref(want.ServiceID)},
+			field{"metric", have.Metric, want.Metric},
+			field{"operator", have.Operator, want.Operator},
+			field{"threshold", have.Threshold, want.Threshold},
+			field{"duration", have.Duration, want.Duration},
+			field{"severity", have.Severity, want.Severity},
+			field{"isEnabled", have.IsEnabled, want.IsEnabled},
+			field{"cooldown", have.Cooldown, want.Cooldown},
+			field{"channelIds", sorted(have.ChannelIDs), want.ChannelIDs},
+			field{"resourceCategory", have.ResourceCategory, want.ResourceCategory},
+		)
+		if len(fields) == 0 {
+			continue
+		}
+
+		hostID, serviceID := deref(want.HostID), deref(want.ServiceID)
+		update := &models.AlertRuleUpdateRequest{
+			Name:       &want.Name,
+			HostID:     &hostID,
+			ServiceID:  &serviceID,
+			Metric:     &want.Metric,
+			Operator:   &want.Operator,
+			Threshold:  &want.Threshold,
+			Duration:   &want.Duration,
+			Severity:   &want.Severity,
+			IsEnabled:  &want.IsEnabled,
+			Cooldown:   &want.Cooldown,
+			ChannelIDs: &want.ChannelIDs,
+
+			ResourceCategory: &want.ResourceCategory,
+		}
+		id := rule.ID
+		changes = append(changes, change{
+			drift: models.Drift{Kind: models.ManagedAlertRule, ID: id, Action: models.DriftUpdate, Fields: fields},
+			apply: func(ctx context.Context) error { return r.store.AlertRules.Update(ctx, id, update) },
+		})
+	}
+
+	if prune {
+		for _, rule := range stored {
+			id := rule.ID
+			if declaredIDs[id] {
+				continue
+			}
+			changes = append(changes, change{
+				drift: models.Drift{Kind: models.ManagedAlertRule, ID: id, Action: models.DriftRemove},
+				apply: func(ctx context.Context) error { return r.store.AlertRules.Delete(ctx, id) },
+			})
+		}
+	}
+	return changes, nil
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func sorted(ids []string) []string {
+	out := append([]string(nil), ids...)
+	sort.Strings(out)
+	return out
+}
@@ -0,0 +1,233 @@
+package gitops
+
+import (
+	"context"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Reconciler compares the services, hosts and alert rules declared in the
+// GitOps source with the database. In report mode drift is only recorded
+// and logged; in enforce mode it is corrected.
+type Reconciler struct {
+	store        *database.Store
+	scheduler    *checker.Scheduler
+	collectorMgr *collector.CollectorManager
+
+	mu      sync.Mutex // one reconcile at a time
+	stateMu sync.RWMutex
+	managed map[string]map[string]bool // kind -> declared IDs
+	last    *models.ReconcileResult
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// NewReconciler creates a new reconciler. collectorMgr may be nil when
+// system metric collection is disabled.
+func NewReconciler(store *database.Store, scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager) *Reconciler {
+	return &Reconciler{
+		store:        store,
+		scheduler:    scheduler,
+		collectorMgr: collectorMgr,
+		managed:      make(map[string]map[string]bool),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start reconciles once and then every gitops.interval seconds. It does
+// nothing unless gitops.enabled is set.
+func (r *Reconciler) Start() {
+	cfg := gitopsConfig()
+	if !cfg.Enabled {
+		return
+	}
+
+	r.wg.Add(1)
+	go r.run(time.Duration(cfg.Interval) * time.Second)
+	log.Printf("GitOps reconciler started (mode: %s, every %ds)", cfg.Mode, cfg.Interval)
+}
+
+// Stop stops the reconcile loop
+func (r *Reconciler) Stop() {
+	close(r.stopCh)
+	r.wg.Wait()
+}
+
+func (r *Reconciler) run(interval time.Duration) {
+	defer r.wg.Done()
+
+	r.reconcileAndLog()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.reconcileAndLog()
+		case <-r.stopCh:
+			return
+		}
+	}
+}
+
+func (r *Reconciler) reconcileAndLog() {
+	result, err := r.Reconcile(context.Background(), false)
+	if err != nil {
+		log.Printf("[GitOps] Reconcile failed: %v", err)
+		return
+	}
+	if len(result.Drift) == 0 {
+		return
+	}
+	for _, d := range result.Drift {
+		switch {
+		case d.Error != "":
+			log.Printf("[GitOps] Failed to %s %s %s: %s", d.Action, d.Kind, d.ID, d.Error)
+		case d.Applied:
+			log.Printf("[GitOps] Applied %s of %s %s %v", d.Action, d.Kind, d.ID, d.Fields)
+		default:
+			log.Printf("[GitOps] Drift: %s %s needs %s %v", d.Kind, d.ID, d.Action, d.Fields)
+		}
+	}
+}
+
+// Reconcile loads the source, computes drift and, in enforce mode, corrects
+// it. dryRun reports drift without applying it regardless of the mode. An
+// error means the source could not be loaded or compared; it is recorded in
+// the result as well.
+func (r *Reconciler) Reconcile(ctx context.Context, dryRun bool) (*models.ReconcileResult, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	cfg := gitopsConfig()
+	result := &models.ReconcileResult{
+		Mode:      cfg.Mode,
+		StartedAt: time.Now().UTC(),
+		Drift:     []models.Drift{},
+	}
+
+	err := r.reconcile(ctx, cfg, dryRun, result)
+	result.Duration = time.Since(result.StartedAt).Milliseconds()
+	if err != nil {
+		result.Error = err.Error()
+	}
+	if !dryRun {
+		r.stateMu.Lock()
+		r.last = result
+		r.stateMu.Unlock()
+	}
+	return result, err
+}
+
+func (r *Reconciler) reconcile(ctx context.Context, cfg config.GitOpsConfig, dryRun bool, result *models.ReconcileResult) error {
+	desired, source, revision, err := loadSource(ctx, cfg)
+	result.Source, result.Revision = source, revision
+	if err != nil {
+		return err
+	}
+
+	managed := map[string]map[string]bool{
+		models.ManagedService:   {},
+		models.ManagedHost:      {},
+		models.ManagedAlertRule: {},
+	}
+	for _, svc := range desired.Services {
+		managed[models.ManagedService][svc.ID] = true
+	}
+	for _, h := range desired.Hosts {
+		managed[models.ManagedHost][h.ID] = true
+	}
+	for _, rule := range desired.AlertRules {
+		managed[models.ManagedAlertRule][rule.ID] = true
+	}
+	result.Managed = len(desired.Services) + len(desired.Hosts) + len(desired.AlertRules)
+
+	// Hosts before alert rules that reference them
+	var changes []change
+	services, err := r.diffServices(ctx, desired.Services, cfg.Prune)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, services...)
+	hosts, err := r.diffHosts(ctx, desired.Hosts, cfg.Prune)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, hosts...)
+	rules, err := r.diffAlertRules(ctx, desired.AlertRules, cfg.Prune)
+	if err != nil {
+		return err
+	}
+	changes = append(changes, rules...)
+
+	enforce := cfg.Mode == "enforce" && !dryRun
+	for _, c := range changes {
+		if enforce {
+			if err := c.apply(ctx); err != nil {
+				c.drift.Error = err.Error()
+			} else {
+				c.drift.Applied = true
+			}
+		}
+		result.Drift = append(result.Drift, c.drift)
+	}
+
+	if !dryRun {
+		r.stateMu.Lock()
+		r.managed = managed
+		r.stateMu.Unlock()
+	}
+	return nil
+}
+
+// Last returns the result of the most recent reconcile, nil before the first
+func (r *Reconciler) Last() *models.ReconcileResult {
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+	return r.last
+}
+
+// IsManaged reports whether API changes to a resource must be rejected:
+// GitOps is enabled with blockApiMutations and the resource is declared in
+// the source as of the last reconcile.
+func (r *Reconciler) IsManaged(kind, id string) bool {
+	cfg := gitopsConfig()
+	if !cfg.Enabled || !cfg.BlockAPIMutations {
+		return false
+	}
+	r.stateMu.RLock()
+	defer r.stateMu.RUnlock()
+	return r.managed[kind][id]
+}
+
+// gitopsConfig returns the GitOps configuration with defaults applied
+func gitopsConfig() config.GitOpsConfig {
+	var cfg config.GitOpsConfig
+	if c := config.Get(); c != nil {
+		cfg = c.GitOps
+	}
+	if cfg.Mode == "" {
+		cfg.Mode = "report"
+	}
+	if cfg.Interval < 1 {
+		cfg.Interval = 60
+	}
+	if cfg.Repo.Branch == "" {
+		cfg.Repo.Branch = "main"
+	}
+	if cfg.Repo.Path == "" {
+		cfg.Repo.Path = "config.yaml"
+	}
+	if cfg.Repo.Dir == "" {
+		cfg.Repo.Dir = "./data/gitops"
+	}
+	return cfg
+}
@@ -0,0 +1,93 @@
+package gitops
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// loadSource returns the declared state: the running configuration, or the
+// config file in the Git repository when one is configured. revision is
+// the checked out commit for repositories.
+func loadSource(ctx context.Context, cfg config.GitOpsConfig) (desired *config.Config, source, revision string, err error) {
+	if cfg.Repo.URL == "" {
+		desired = config.Get()
+		if desired == nil {
+			return nil, "", "", fmt.Errorf("config not initialized")
+		}
+		source = config.Path()
+		if source == "" {
+			source = "config file"
+		}
+		return desired, source, "", nil
+	}
+
+	source = redactURL(cfg.Repo.URL)
+	if revision, err = syncRepo(ctx, cfg.Repo); err != nil {
+		return nil, source, "", err
+	}
+	desired, err = config.LoadFile(filepath.Join(cfg.Repo.Dir, cfg.Repo.Path))
+	if err != nil {
+		return nil, source, revision, err
+	}
+	return desired, source, revision, nil
+}
+
+// syncRepo clones the repository on first use and afterwards fetches the
+// branch and resets the checkout to it. It returns the checked out commit.
+func syncRepo(ctx context.Context, repo config.GitRepoConfig) (string, error) {
+	if _, err := os.Stat(filepath.Join(repo.Dir, ".git")); os.IsNotExist(err) {
+		if err := os.MkdirAll(filepath.Dir(repo.Dir), 0755); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, "", repo, "clone", "--depth", "1", "--branch", repo.Branch, repo.URL, repo.Dir); err != nil {
+			return "", err
+		}
+	} else {
+		// The remote URL may have changed (e.g. a rotated token)
+		if _, err := git(ctx, repo.Dir, repo, "remote", "set-url", "origin", repo.URL); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, repo.Dir, repo, "fetch", "--depth", "1", "origin", repo.Branch); err != nil {
+			return "", err
+		}
+		if _, err := git(ctx, repo.Dir, repo, "reset", "--hard", "FETCH_HEAD"); err != nil {
+			return "", err
+		}
+	}
+	return git(ctx, repo.Dir, repo, "rev-parse", "HEAD")
+}
+
+// git runs a git command, keeping credentials in the URL out of errors
+func git(ctx context.Context, dir string, repo config.GitRepoConfig, args ...string) (string, error) {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GIT_TERMINAL_PROMPT=0")
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stderr.String())
+		msg = strings.ReplaceAll(msg, repo.URL, redactURL(repo.URL))
+		return "", fmt.Errorf("git %s failed: %v: %s", args[0], err, msg)
+	}
+	return strings.TrimSpace(stdout.String()), nil
+}
+
+// redactURL removes credentials from a repository URL
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil || u.User == nil {
+		return raw
+	}
+	u.User = nil
+	return u.String()
+}
@@ -8,18 +8,25 @@ type AlertRuleType string
 const (
 	AlertRuleTypeResource AlertRuleType = "resource"
 	AlertRuleTypeService  AlertRuleType = "service"
+	AlertRuleTypeLog      AlertRuleType = "log"
+	AlertRuleTypeSLO      AlertRuleType = "slo"
 )
 
 // AlertMetric is the metric being evaluated
 type AlertMetric string
 
 const (
-	AlertMetricCPU          AlertMetric = "cpu"
-	AlertMetricMemory       AlertMetric = "memory"
-	AlertMetricDisk         AlertMetric = "disk"
-	AlertMetricStatusChange AlertMetric = "status_change"
-	AlertMetricHTTPStatus   AlertMetric = "http_status"   // HTTP status code comparison
-	AlertMetricResponseTime AlertMetric = "response_time" // Response time in ms
+	AlertMetricCPU             AlertMetric = "cpu"
+	AlertMetricMemory          AlertMetric = "memory"
+	AlertMetricDisk            AlertMetric = "disk"
+	AlertMetricPacketLoss      AlertMetric = "packet_loss"      // Host ping loss in percent
+	AlertMetricSecurityUpdates AlertMetric = "security_updates" // Pending security updates of a host
+	AlertMetricPortChange      AlertMetric = "port_change"      // Unexpected plus missing listening ports of a host
+	AlertMetricStatusChange    AlertMetric = "status_change"
+	AlertMetricHTTPStatus      AlertMetric = "http_status"   // HTTP status code comparison
+	AlertMetricResponseTime    AlertMetric = "response_time" // Response time in ms
+	AlertMetricLogMatch        AlertMetric = "log_match"     // Ingested logs matching a pattern
+	AlertMetricBurnRate        AlertMetric = "burn_rate"     // SLO error budget burn rate
 )
 
 // AlertOperator defines comparison operators
@@ -42,9 +49,15 @@ const (
 	AlertSeverityInfo     AlertSeverity = "info"
 )
 
+// IsValid reports whether s is a known severity
+func (s AlertSeverity) IsValid() bool {
+	return s == AlertSeverityCritical || s == AlertSeverityWarning || s == AlertSeverityInfo
+}
+
 // AlertRule represents a threshold-based alerting rule
 type AlertRule struct {
 	ID        string        `json:"id"`
+	ProjectID string        `json:"projectId"`
 	Name      string        `json:"name"`
 	Type      AlertRuleType `json:"type"`
 	HostID    *string       `json:"hostId"`
@@ -59,6 +72,27 @@ type AlertRule struct {
 	CreatedAt time.Time     `json:"createdAt"`
 	UpdatedAt time.Time     `json:"updatedAt"`
 
+	// Resource rules: only hosts of this category, combined with HostID;
+	// all hosts when empty
+	ResourceCategory HostResourceCategory `json:"resourceCategory,omitempty"`
+
+	// Log rules: fire when Threshold (at least 1) ingested messages of the
+	// service, or of any service, match Pattern within Duration minutes
+	Pattern  string   `json:"pattern,omitempty"`  // regular expression
+	LogLevel LogLevel `json:"logLevel,omitempty"` // only logs of this level, any when empty
+
+	// SLO rules: a check is good when it succeeds within LatencyTarget ms
+	// and Objective percent of checks should be good. The rule fires when
+	// the error budget burns at least Threshold times faster than the
+	// objective allows over both ShortWindow and LongWindow minutes.
+	Objective     float64 `json:"objective,omitempty"`     // percent, e.g. 99.9
+	LatencyTarget int     `json:"latencyTarget,omitempty"` // ms, 0 counts only failed checks
+	ShortWindow   int     `json:"shortWindow,omitempty"`   // minutes
+	LongWindow    int     `json:"longWindow,omitempty"`    // minutes
+
+	// Action run when the rule fires, nil for none
+	Remediation *Remediation `json:"remediation,omitempty"`
+
 	// Populated by JOIN queries, not stored in alert_rules table
 	ChannelIDs []string `json:"channelIds,omitempty"`
 }
@@ -77,6 +111,17 @@ type AlertRuleCreateRequest struct {
 	IsEnabled  *bool         `json:"isEnabled"`
 	Cooldown   int           `json:"cooldown"`
 	ChannelIDs []string      `json:"channelIds"`
+	Pattern    string        `json:"pattern"`
+	LogLevel   LogLevel      `json:"logLevel"`
+
+	ResourceCategory HostResourceCategory `json:"resourceCategory"`
+
+	Objective     float64 `json:"objective"`
+	LatencyTarget int     `json:"latencyTarget"`
+	ShortWindow   int     `json:"shortWindow"`
+	LongWindow    int     `json:"longWindow"`
+
+	Remediation *Remediation `json:"remediation"`
 }
 
 // ToAlertRule converts request into model with defaults applied
@@ -85,6 +130,26 @@ func (r *AlertRuleCreateRequest) ToAlertRule(id string) *AlertRule {
 	if r.IsEnabled != nil {
 		isEnabled = *r.IsEnabled
 	}
+	if r.Type == AlertRuleTypeLog {
+		r.Metric = AlertMetricLogMatch
+		r.Operator = AlertOperatorGTE
+		if r.Threshold < 1 {
+			r.Threshold = 1
+		}
+	}
+	if r.Type == AlertRuleTypeSLO {
+		r.Metric = AlertMetricBurnRate
+		r.Operator = AlertOperatorGTE
+		if r.Threshold <= 0 {
+			r.Threshold = 14.4 // 2% of a 30-day budget in an hour
+		}
+		if r.ShortWindow <= 0 {
+			r.ShortWindow = 5
+		}
+		if r.LongWindow <= 0 {
+			r.LongWindow = 60
+		}
+	}
 	if r.Operator == "" {
 		r.Operator = AlertOperatorGT
 	}
@@ -97,6 +162,9 @@ func (r *AlertRuleCreateRequest) ToAlertRule(id string) *AlertRule {
 	if r.Cooldown <= 0 {
 		r.Cooldown = 300
 	}
+	if r.Remediation != nil {
+		r.Remediation.ApplyDefaults()
+	}
 	now := time.Now()
 	return &AlertRule{
 		ID:         id,
@@ -112,8 +180,17 @@ func (r *AlertRuleCreateRequest) ToAlertRule(id string) *AlertRule {
 		IsEnabled:  isEnabled,
 		Cooldown:   r.Cooldown,
 		ChannelIDs: r.ChannelIDs,
+		Pattern:    r.Pattern,
+		LogLevel:   r.LogLevel,
 		CreatedAt:  now,
 		UpdatedAt:  now,
+
+		ResourceCategory: r.ResourceCategory,
+		Objective:        r.Objective,
+		LatencyTarget:    r.LatencyTarget,
+		ShortWindow:      r.ShortWindow,
+		LongWindow:       r.LongWindow,
+		Remediation:      r.Remediation,
 	}
 }
 
@@ -130,4 +207,16 @@ type AlertRuleUpdateRequest struct {
 	IsEnabled  *bool          `json:"isEnabled"`
 	Cooldown   *int           `json:"cooldown"`
 	ChannelIDs *[]string      `json:"channelIds"`
+	Pattern    *string        `json:"pattern"`
+	LogLevel   *LogLevel      `json:"logLevel"`
+
+	ResourceCategory *HostResourceCategory `json:"resourceCategory"`
+
+	Objective     *float64 `json:"objective"`
+	LatencyTarget *int     `json:"latencyTarget"`
+	ShortWindow   *int     `json:"shortWindow"`
+	LongWindow    *int     `json:"longWindow"`
+
+	// {} removes the remediation action
+	Remediation *Remediation `json:"remediation"`
 }
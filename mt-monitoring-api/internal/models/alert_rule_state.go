@@ -2,12 +2,64 @@ package models
 
 import "time"
 
+// Targets of alert rule states
+const (
+	AlertTargetHost    = "host"
+	AlertTargetService = "service"
+)
+
 // AlertRuleState represents the persistent state of an alert rule evaluation
+// for one host or service
 type AlertRuleState struct {
 	RuleID        string     `json:"ruleId"`
-	HostID        string     `json:"hostId"`
+	TargetType    string     `json:"targetType"` // AlertTargetHost or AlertTargetService
+	TargetID      string     `json:"targetId"`
 	BreachCount   int        `json:"breachCount"`
 	LastAlertedAt *time.Time `json:"lastAlertedAt,omitempty"`
 	IsAlerting    bool       `json:"isAlerting"`
 	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	// Value is the last evaluated value of the rule metric; BreachStartedAt
+	// the first evaluation of the current breach, nil while within threshold
+	Value           float64    `json:"value"`
+	BreachStartedAt *time.Time `json:"breachStartedAt,omitempty"`
+
+	// Set by GetActive for listing
+	RuleName   string        `json:"ruleName,omitempty"`
+	Severity   AlertSeverity `json:"severity,omitempty"`
+	ProjectID  string        `json:"projectId,omitempty"`
+	TargetName string        `json:"targetName,omitempty"`
+}
+
+// Statuses of an active alert
+const (
+	ActiveAlertAlerting = "alerting" // the rule fired and has not recovered
+	ActiveAlertPending  = "pending"  // in breach for fewer checks than the rule duration
+)
+
+// ActiveAlert is a rule currently in breach or alerting for a host or
+// service, as listed by GET /alerts/active
+type ActiveAlert struct {
+	RuleID     string        `json:"ruleId"`
+	RuleName   string        `json:"ruleName"`
+	RuleType   AlertRuleType `json:"ruleType"`
+	ProjectID  string        `json:"projectId"`
+	Severity   AlertSeverity `json:"severity"`
+	Status     string        `json:"status"` // ActiveAlertAlerting or ActiveAlertPending
+	TargetType string        `json:"targetType"`
+	TargetID   string        `json:"targetId"`
+	TargetName string        `json:"targetName"`
+
+	Metric      AlertMetric   `json:"metric"`
+	Operator    AlertOperator `json:"operator"`
+	Threshold   float64       `json:"threshold"`
+	Value       float64       `json:"value"`
+	BreachCount int           `json:"breachCount"`
+
+	// Since is the start of the breach, or the last alert for states
+	// persisted before breaches were timed; Duration the seconds since then
+	Since         *time.Time `json:"since,omitempty"`
+	Duration      int64      `json:"duration"`
+	LastAlertedAt *time.Time `json:"lastAlertedAt,omitempty"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
 }
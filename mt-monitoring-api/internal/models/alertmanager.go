@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Alertmanager alert statuses
+const (
+	AlertmanagerFiring   = "firing"
+	AlertmanagerResolved = "resolved"
+)
+
+// AlertmanagerWebhook is the payload Prometheus Alertmanager posts to a
+// webhook receiver (payload version 4)
+type AlertmanagerWebhook struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	TruncatedAlerts   int                 `json:"truncatedAlerts"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []AlertmanagerAlert `json:"alerts"`
+}
+
+// AlertmanagerAlert is one alert of a webhook payload
+type AlertmanagerAlert struct {
+	Status       string            `json:"status"` // "firing" | "resolved"
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// ExternalAlert is the last known state of an alert received from
+// Alertmanager, keyed by its fingerprint
+type ExternalAlert struct {
+	Fingerprint string            `json:"fingerprint"`
+	AlertName   string            `json:"alertName"`
+	Status      string            `json:"status"` // "firing" | "resolved"
+	Severity    string            `json:"severity,omitempty"`
+	ServiceID   *string           `json:"serviceId,omitempty"`  // service named by the service label
+	IncidentID  *int64            `json:"incidentId,omitempty"` // incident opened on that service
+	Labels      map[string]string `json:"labels"`
+	Annotations map[string]string `json:"annotations"`
+	StartsAt    time.Time         `json:"startsAt"`
+	EndsAt      *time.Time        `json:"endsAt,omitempty"`
+	UpdatedAt   time.Time         `json:"updatedAt"`
+}
+
+// AlertmanagerResult summarizes what a webhook delivery changed
+type AlertmanagerResult struct {
+	Received  int `json:"received"`
+	Fired     int `json:"fired"`     // newly firing alerts, notified
+	Resolved  int `json:"resolved"`  // resolved alerts, notified
+	Skipped   int `json:"skipped"`   // redeliveries and resolves of unknown alerts, not notified
+	Incidents int `json:"incidents"` // incidents opened
+}
@@ -0,0 +1,51 @@
+package models
+
+import "time"
+
+// CheckDetails holds diagnostics captured for a failed check.
+// Timing fields are nil when the phase did not happen (e.g. no DNS lookup
+// for an IP address, no TLS for plain HTTP).
+type CheckDetails struct {
+	MetricID    int64       `json:"metricId"`
+	StatusLine  string      `json:"statusLine,omitempty"`  // e.g. "HTTP/1.1 503 Service Unavailable"
+	Protocol    string      `json:"protocol,omitempty"`    // negotiated protocol, e.g. "HTTP/2.0"
+	BodySnippet string      `json:"bodySnippet,omitempty"` // first diagnostics.maxBodyBytes of the body
+	RemoteAddr  string      `json:"remoteAddr,omitempty"`
+	TLS         *TLSDetails `json:"tls,omitempty"`
+	DNSMs       *int        `json:"dnsMs,omitempty"`
+	ConnectMs   *int        `json:"connectMs,omitempty"`
+	TLSMs       *int        `json:"tlsMs,omitempty"`
+	TTFBMs      *int        `json:"ttfbMs,omitempty"` // request sent to first response byte
+}
+
+// TLSDetails describes the TLS handshake of a check
+type TLSDetails struct {
+	Version        string     `json:"version,omitempty"`
+	CipherSuite    string     `json:"cipherSuite,omitempty"`
+	ServerName     string     `json:"serverName,omitempty"`
+	PeerSubject    string     `json:"peerSubject,omitempty"`
+	PeerIssuer     string     `json:"peerIssuer,omitempty"`
+	PeerNotAfter   *time.Time `json:"peerNotAfter,omitempty"`
+	HandshakeError string     `json:"handshakeError,omitempty"`
+}
+
+// MetricDetail is a single check result with its diagnostics, if captured
+type MetricDetail struct {
+	Metric
+	Details *CheckDetails `json:"details,omitempty"`
+}
+
+// CheckTimings are the phases of an HTTP check in milliseconds. Phases that
+// did not happen are nil: no DNS lookup for an IP address, no TLS handshake
+// for plain HTTP, no transfer when the request failed. Browser checks set
+// only DOMContentLoaded and Load.
+type CheckTimings struct {
+	DNS      *int `json:"dns,omitempty"`
+	Connect  *int `json:"connect,omitempty"`
+	TLS      *int `json:"tls,omitempty"`
+	TTFB     *int `json:"ttfb,omitempty"`     // request sent to first response byte
+	Transfer *int `json:"transfer,omitempty"` // first to last body byte
+
+	DOMContentLoaded *int `json:"domContentLoaded,omitempty"` // navigation start to DOMContentLoaded
+	Load             *int `json:"load,omitempty"`             // navigation start to the load event
+}
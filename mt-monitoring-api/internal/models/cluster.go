@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// ClusterNode is a scheduler node sharing the database with others
+type ClusterNode struct {
+	ID             string    `json:"id"`
+	Address        string    `json:"address,omitempty"` // URL heartbeat pings are forwarded to
+	StartedAt      time.Time `json:"startedAt"`
+	LeaseExpiresAt time.Time `json:"leaseExpiresAt"`
+}
+
+// ClusterStatus is the membership as one node sees it
+type ClusterStatus struct {
+	Enabled bool          `json:"enabled"`
+	NodeID  string        `json:"nodeId"`
+	Leader  bool          `json:"leader"` // runs cleanup, maintenance and scheduled jobs
+	Nodes   []ClusterNode `json:"nodes"`  // live nodes, oldest first
+}
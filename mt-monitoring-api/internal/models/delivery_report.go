@@ -0,0 +1,66 @@
+package models
+
+import (
+	"sort"
+	"time"
+)
+
+// DeliveryFailure counts the failed notification deliveries to a channel
+// that ended with the same error
+type DeliveryFailure struct {
+	ChannelID   string `json:"channelId"`
+	ChannelName string `json:"channelName"`
+	ChannelType string `json:"channelType"`
+	Error       string `json:"error"`
+	Count       int    `json:"count"`
+}
+
+// ChannelDeliveryFailures are the failed deliveries to one channel, by error
+// from the most frequent
+type ChannelDeliveryFailures struct {
+	ChannelID   string            `json:"channelId"`
+	ChannelName string            `json:"channelName"`
+	ChannelType string            `json:"channelType"`
+	Failed      int               `json:"failed"`
+	Errors      []DeliveryFailure `json:"errors"`
+}
+
+// DeliveryReport summarizes the failed notification deliveries of a period
+// by channel, from the channel with the most failures
+type DeliveryReport struct {
+	Since    time.Time                 `json:"since"`
+	Until    time.Time                 `json:"until"`
+	Failed   int                       `json:"failed"`
+	Channels []ChannelDeliveryFailures `json:"channels"`
+}
+
+// NewDeliveryReport groups the failures of a period by channel
+func NewDeliveryReport(since, until time.Time, failures []DeliveryFailure) *DeliveryReport {
+	report := &DeliveryReport{Since: since, Until: until, Channels: []ChannelDeliveryFailures{}}
+	index := make(map[string]int)
+	for _, f := range failures {
+		i, ok := index[f.ChannelID]
+		if !ok {
+			i = len(report.Channels)
+			index[f.ChannelID] = i
+			report.Channels = append(report.Channels, ChannelDeliveryFailures{
+				ChannelID:   f.ChannelID,
+				ChannelName: f.ChannelName,
+				ChannelType: f.ChannelType,
+			})
+		}
+		ch := &report.Channels[i]
+		ch.Failed += f.Count
+		ch.Errors = append(ch.Errors, f)
+		report.Failed += f.Count
+	}
+
+	for i := range report.Channels {
+		errs := report.Channels[i].Errors
+		sort.SliceStable(errs, func(a, b int) bool { return errs[a].Count > errs[b].Count })
+	}
+	sort.SliceStable(report.Channels, func(a, b int) bool {
+		return report.Channels[a].Failed > report.Channels[b].Failed
+	})
+	return report
+}
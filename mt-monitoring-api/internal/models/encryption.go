@@ -0,0 +1,34 @@
+package models
+
+// KeyRotationRequest re-encrypts stored secrets with a new encryption key.
+// OldKey defaults to the key in use.
+type KeyRotationRequest struct {
+	OldKey string `json:"oldKey"`
+	NewKey string `json:"newKey"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// KeyRotationFailure is a stored value that could not be decrypted with the
+// old key
+type KeyRotationFailure struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	RowID  string `json:"rowId"`
+}
+
+// KeyRotationColumn counts the values of one encrypted column
+type KeyRotationColumn struct {
+	Table       string `json:"table"`
+	Column      string `json:"column"`
+	Reencrypted int    `json:"reencrypted"`
+	Plaintext   int    `json:"plaintext"` // stored before encryption was enabled, now encrypted
+}
+
+// KeyRotationResult describes a key rotation. Nothing is written when
+// DryRun is set or any value failed to decrypt.
+type KeyRotationResult struct {
+	DryRun   bool                 `json:"dryRun"`
+	Applied  bool                 `json:"applied"`
+	Columns  []KeyRotationColumn  `json:"columns"`
+	Failures []KeyRotationFailure `json:"failures"`
+}
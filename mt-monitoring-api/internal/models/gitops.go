@@ -0,0 +1,42 @@
+package models
+
+import "time"
+
+// Kinds of resources managed by GitOps sync
+const (
+	ManagedService   = "service"
+	ManagedHost      = "host"
+	ManagedAlertRule = "alertRule"
+)
+
+// DriftAction is what reconciling a drifted resource does
+type DriftAction string
+
+const (
+	DriftCreate DriftAction = "create" // declared but missing from the database
+	DriftUpdate DriftAction = "update" // fields differ from the declaration
+	DriftRemove DriftAction = "remove" // no longer declared (only with prune); services are paused
+)
+
+// Drift is a difference between the declared and the stored state of one
+// resource
+type Drift struct {
+	Kind    string      `json:"kind"`
+	ID      string      `json:"id"`
+	Action  DriftAction `json:"action"`
+	Fields  []string    `json:"fields,omitempty"` // changed fields for updates
+	Applied bool        `json:"applied"`
+	Error   string      `json:"error,omitempty"`
+}
+
+// ReconcileResult describes one GitOps reconcile run
+type ReconcileResult struct {
+	Source    string    `json:"source"`             // config file or repository URL
+	Revision  string    `json:"revision,omitempty"` // commit for repository sources
+	Mode      string    `json:"mode"`
+	StartedAt time.Time `json:"startedAt"`
+	Duration  int64     `json:"duration"` // milliseconds
+	Managed   int       `json:"managed"`  // declared resources
+	Drift     []Drift   `json:"drift"`
+	Error     string    `json:"error,omitempty"` // the source could not be loaded
+}
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// GrafanaRange is the dashboard time range of a Grafana request
+type GrafanaRange struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+}
+
+// GrafanaTarget is one query of a panel
+type GrafanaTarget struct {
+	Target string `json:"target"` // "service:<id>:<metric>" or "host:<id>:<metric>", id may be *
+	RefID  string `json:"refId"`
+	Type   string `json:"type"` // "timeserie" (only time series are supported)
+	Hide   bool   `json:"hide"`
+}
+
+// GrafanaQueryRequest is the body of POST /grafana/query
+type GrafanaQueryRequest struct {
+	Range         GrafanaRange    `json:"range"`
+	IntervalMs    int64           `json:"intervalMs"`
+	MaxDataPoints int             `json:"maxDataPoints"`
+	Targets       []GrafanaTarget `json:"targets"`
+}
+
+// GrafanaTimeSeries is a query result. Datapoints are [value, unix ms].
+type GrafanaTimeSeries struct {
+	Target     string       `json:"target"`
+	RefID      string       `json:"refId,omitempty"`
+	Datapoints [][2]float64 `json:"datapoints"`
+}
+
+// GrafanaSearchRequest is the body of POST /grafana/search and /grafana/metrics
+type GrafanaSearchRequest struct {
+	Target string `json:"target"` // substring filter, empty for all
+}
+
+// GrafanaMetricOption is a target offered by POST /grafana/metrics
+type GrafanaMetricOption struct {
+	Label string `json:"label"`
+	Value string `json:"value"`
+}
+
+// GrafanaAnnotationRequest is the body of POST /grafana/annotations
+type GrafanaAnnotationRequest struct {
+	Range      GrafanaRange `json:"range"`
+	Annotation struct {
+		Name  string `json:"name"`
+		Query string `json:"query"` // service ID, empty for all services
+	} `json:"annotation"`
+}
+
+// GrafanaAnnotation marks an incident on Grafana panels. Times are unix ms.
+type GrafanaAnnotation struct {
+	Time    int64    `json:"time"`
+	TimeEnd int64    `json:"timeEnd,omitempty"`
+	Title   string   `json:"title"`
+	Text    string   `json:"text"`
+	Tags    []string `json:"tags"`
+}
@@ -19,6 +19,11 @@ const (
 	HostResourceContainer HostResourceCategory = "container"
 )
 
+// IsValid returns true if the category is one of the known categories
+func (c HostResourceCategory) IsValid() bool {
+	return c == HostResourceServer || c == HostResourceDatabase || c == HostResourceContainer
+}
+
 // HostStatus represents the current operational status of a host
 type HostStatus string
 
@@ -29,6 +34,19 @@ const (
 	HostStatusError   HostStatus = "error"
 )
 
+// HostCollector is how the metrics of a remote host are collected
+type HostCollector string
+
+const (
+	HostCollectorSSH          HostCollector = "ssh"
+	HostCollectorNodeExporter HostCollector = "node_exporter" // scrapes a Prometheus node_exporter over HTTP
+)
+
+// IsValid returns true if the collector is one of the known collectors
+func (c HostCollector) IsValid() bool {
+	return c == HostCollectorSSH || c == HostCollectorNodeExporter
+}
+
 // SSHAuthType represents the SSH authentication method
 type SSHAuthType string
 
@@ -36,11 +54,13 @@ const (
 	SSHAuthPassword SSHAuthType = "password"
 	SSHAuthKey      SSHAuthType = "key"      // PEM key content directly
 	SSHAuthKeyFile  SSHAuthType = "key_file" // Server-side file path
+	SSHAuthSecret   SSHAuthType = "secret"   // Fetched from Vault/AWS Secrets Manager via SSHSecretRef
 )
 
 // Host represents a monitored server/host
 type Host struct {
 	ID               string               `json:"id"`
+	ProjectID        string               `json:"projectId"`
 	Name             string               `json:"name"`
 	Type             HostType             `json:"type"`
 	ResourceCategory HostResourceCategory `json:"resourceCategory,omitempty"`
@@ -53,12 +73,26 @@ type Host struct {
 	UpdatedAt        time.Time            `json:"updatedAt"`
 
 	// SSH Authentication (remote hosts only)
-	SSHUser     string      `json:"sshUser,omitempty"`
-	SSHPort     int         `json:"sshPort,omitempty"`
-	SSHAuthType SSHAuthType `json:"sshAuthType,omitempty"`
-	SSHKeyPath  string      `json:"sshKeyPath,omitempty"`
-	SSHKey      string      `json:"sshKey,omitempty"`      // encrypted at rest, masked in API response
-	SSHPassword string      `json:"sshPassword,omitempty"` // encrypted at rest, masked in API response
+	SSHUser      string      `json:"sshUser,omitempty"`
+	SSHPort      int         `json:"sshPort,omitempty"`
+	SSHAuthType  SSHAuthType `json:"sshAuthType,omitempty"`
+	SSHKeyPath   string      `json:"sshKeyPath,omitempty"`
+	SSHKey       string      `json:"sshKey,omitempty"`       // encrypted at rest, masked in API response
+	SSHPassword  string      `json:"sshPassword,omitempty"`  // encrypted at rest, masked in API response
+	SSHSecretRef string      `json:"sshSecretRef,omitempty"` // "vault:<path>" or "aws:<secret-id>"
+
+	// Collection and storage intervals in seconds, overriding the global
+	// ones when set
+	CollectInterval int `json:"collectInterval,omitempty"`
+	StoreInterval   int `json:"storeInterval,omitempty"`
+
+	// Collector of a remote host; ExporterURL is the node_exporter metrics
+	// URL, http://<ip>:9100/metrics when empty
+	Collector   HostCollector `json:"collector,omitempty"`
+	ExporterURL string        `json:"exporterUrl,omitempty"`
+
+	// Team and user responsible for the host, shown in its alerts
+	Ownership *Ownership `json:"ownership,omitempty"`
 
 	// Computed fields (not stored in DB directly)
 	Status    HostStatus `json:"status,omitempty"`
@@ -82,6 +116,12 @@ type HostCreateRequest struct {
 	SSHKeyPath       string               `json:"sshKeyPath,omitempty"`
 	SSHKey           string               `json:"sshKey,omitempty"`
 	SSHPassword      string               `json:"sshPassword,omitempty"`
+	SSHSecretRef     string               `json:"sshSecretRef,omitempty"`
+	CollectInterval  int                  `json:"collectInterval,omitempty"`
+	StoreInterval    int                  `json:"storeInterval,omitempty"`
+	Collector        HostCollector        `json:"collector,omitempty"`
+	ExporterURL      string               `json:"exporterUrl,omitempty"`
+	Ownership        *Ownership           `json:"ownership,omitempty"`
 }
 
 // ToHost converts request to Host model
@@ -106,6 +146,11 @@ func (r *HostCreateRequest) ToHost() *Host {
 		sshPort = 22
 	}
 
+	collector := r.Collector
+	if collector == "" && hostType == HostTypeRemote {
+		collector = HostCollectorSSH
+	}
+
 	resourceCategory := r.ResourceCategory
 	if resourceCategory == "" {
 		resourceCategory = HostResourceServer
@@ -128,18 +173,101 @@ func (r *HostCreateRequest) ToHost() *Host {
 		SSHKeyPath:       r.SSHKeyPath,
 		SSHKey:           r.SSHKey,
 		SSHPassword:      r.SSHPassword,
+		SSHSecretRef:     r.SSHSecretRef,
+		CollectInterval:  r.CollectInterval,
+		StoreInterval:    r.StoreInterval,
+		Collector:        collector,
+		ExporterURL:      r.ExporterURL,
+		Ownership:        r.Ownership.Normalized(),
 		CreatedAt:        now,
 		UpdatedAt:        now,
 		Status:           HostStatusUnknown,
 	}
 }
 
+// HostUpdateRequest is the API request to update a host (PATCH). Omitted
+// fields keep their stored values. null or an empty value clears a field,
+// and fields with a default (group, sshPort, collector, resourceCategory)
+// go back to it. Masked SSH secrets sent back are kept.
+type HostUpdateRequest struct {
+	Name             *string               `json:"name"`
+	Type             *HostType             `json:"type"`
+	ResourceCategory *HostResourceCategory `json:"resourceCategory"`
+	IP               *string               `json:"ip"`
+	Port             *int                  `json:"port"`
+	Group            *string               `json:"group"`
+	IsActive         *bool                 `json:"isActive"`
+	Description      *string               `json:"description"`
+	SSHUser          *string               `json:"sshUser"`
+	SSHPort          *int                  `json:"sshPort"`
+	SSHAuthType      *SSHAuthType          `json:"sshAuthType"`
+	SSHKeyPath       *string               `json:"sshKeyPath"`
+	SSHKey           *string               `json:"sshKey"`
+	SSHPassword      *string               `json:"sshPassword"`
+	SSHSecretRef     *string               `json:"sshSecretRef"`
+	CollectInterval  *int                  `json:"collectInterval"` // 0 uses the global interval
+	StoreInterval    *int                  `json:"storeInterval"`
+	Collector        *HostCollector        `json:"collector"`
+	ExporterURL      *string               `json:"exporterUrl"`
+	Ownership        *Ownership            `json:"ownership"` // {} removes it
+}
+
+// UnmarshalJSON decodes the request, taking null as the empty value
+func (r *HostUpdateRequest) UnmarshalJSON(data []byte) error {
+	type plain HostUpdateRequest
+	return decodePatch(data, (*plain)(r))
+}
+
+// ApplyTo updates h with the fields set in the request
+func (r *HostUpdateRequest) ApplyTo(h *Host) {
+	set(&h.Name, r.Name)
+	set(&h.Type, r.Type)
+	set(&h.ResourceCategory, r.ResourceCategory)
+	if h.ResourceCategory == "" {
+		h.ResourceCategory = HostResourceServer
+	}
+	set(&h.IP, r.IP)
+	set(&h.Port, r.Port)
+	set(&h.Group, r.Group)
+	if h.Group == "" {
+		h.Group = "Default"
+	}
+	set(&h.IsActive, r.IsActive)
+	set(&h.Description, r.Description)
+
+	set(&h.SSHUser, r.SSHUser)
+	set(&h.SSHPort, r.SSHPort)
+	if h.SSHPort == 0 && h.Type == HostTypeRemote {
+		h.SSHPort = 22
+	}
+	set(&h.SSHAuthType, r.SSHAuthType)
+	set(&h.SSHKeyPath, r.SSHKeyPath)
+	if r.SSHKey != nil && *r.SSHKey != MaskedSecret {
+		h.SSHKey = *r.SSHKey
+	}
+	if r.SSHPassword != nil && *r.SSHPassword != MaskedSecret {
+		h.SSHPassword = *r.SSHPassword
+	}
+	set(&h.SSHSecretRef, r.SSHSecretRef)
+
+	set(&h.CollectInterval, r.CollectInterval)
+	set(&h.StoreInterval, r.StoreInterval)
+	set(&h.Collector, r.Collector)
+	if h.Collector == "" && h.Type == HostTypeRemote {
+		h.Collector = HostCollectorSSH
+	}
+	set(&h.ExporterURL, r.ExporterURL)
+	if r.Ownership != nil {
+		h.Ownership = r.Ownership.Normalized()
+	}
+}
+
 // MaskSecrets replaces sensitive SSH fields with "***" for API responses.
 func (h *Host) MaskSecrets() {
 	if h.SSHPassword != "" {
-		h.SSHPassword = "***"
+		h.SSHPassword = MaskedSecret
 	}
 	if h.SSHKey != "" {
-		h.SSHKey = "***"
+		h.SSHKey = MaskedSecret
 	}
 }
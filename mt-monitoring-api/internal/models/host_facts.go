@@ -0,0 +1,119 @@
+package models
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HostFacts is the inventory of a host: facts that rarely change, collected
+// once a day
+type HostFacts struct {
+	HostID         string          `json:"hostId"`
+	Kernel         string          `json:"kernel"`
+	CPUModel       string          `json:"cpuModel"`
+	CPUCores       int             `json:"cpuCores"`       // logical cores
+	Virtualization string          `json:"virtualization"` // e.g. "kvm", "docker"; empty on bare metal or when unknown
+	Packages       map[string]int  `json:"packages"`       // installed packages per package manager (dpkg, rpm, apk)
+	ListeningPorts []ListeningPort `json:"listeningPorts"`
+	CollectedAt    time.Time       `json:"collectedAt"`
+}
+
+// SecurityUpdates is the number of updates pending on a host, from the
+// package lists the host already has
+type SecurityUpdates struct {
+	HostID    string    `json:"hostId"`
+	Manager   string    `json:"manager"`  // "apt", "dnf" or "yum"
+	Security  int       `json:"security"` // pending updates fixing security issues
+	Total     int       `json:"total"`    // all pending updates
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ListeningPort is a socket a host accepts connections or datagrams on
+type ListeningPort struct {
+	Protocol string `json:"protocol"` // "tcp" or "udp"
+	Address  string `json:"address"`  // local address, "*" for any
+	Port     int    `json:"port"`
+}
+
+// Key identifies the port regardless of its address, e.g. "tcp/22"
+func (p ListeningPort) Key() string {
+	return fmt.Sprintf("%s/%d", p.Protocol, p.Port)
+}
+
+// HostPorts is the listening ports of a host compared with the ports it is
+// expected to listen on. Ports are compared by Key, so a port moving to
+// another address is not a change.
+type HostPorts struct {
+	HostID     string          `json:"hostId"`
+	Expected   []string        `json:"expected"` // port keys, e.g. "tcp/22"
+	Current    []ListeningPort `json:"current"`
+	Unexpected []string        `json:"unexpected"` // listening but not expected
+	Missing    []string        `json:"missing"`    // expected but not listening
+	CheckedAt  time.Time       `json:"checkedAt"`
+}
+
+// Compare sets Unexpected and Missing from Expected and Current
+func (h *HostPorts) Compare() {
+	current := PortKeys(h.Current)
+	h.Unexpected, h.Missing = []string{}, []string{}
+	for _, key := range current {
+		if !slices.Contains(h.Expected, key) {
+			h.Unexpected = append(h.Unexpected, key)
+		}
+	}
+	for _, key := range h.Expected {
+		if !slices.Contains(current, key) {
+			h.Missing = append(h.Missing, key)
+		}
+	}
+}
+
+// PortKeys returns the distinct keys of ports, sorted by SortPortKeys
+func PortKeys(ports []ListeningPort) []string {
+	keys := make([]string, 0, len(ports))
+	for _, p := range ports {
+		keys = append(keys, p.Key())
+	}
+	SortPortKeys(keys)
+	return slices.Compact(keys)
+}
+
+// ParsePortKey parses a port key such as "tcp/22"
+func ParsePortKey(key string) (protocol string, port int, ok bool) {
+	protocol, number, found := strings.Cut(key, "/")
+	if !found || (protocol != "tcp" && protocol != "udp") {
+		return "", 0, false
+	}
+	port, err := strconv.Atoi(number)
+	if err != nil || port < 1 || port > 65535 {
+		return "", 0, false
+	}
+	return protocol, port, true
+}
+
+// SortPortKeys sorts port keys by protocol, then numerically by port
+func SortPortKeys(keys []string) {
+	slices.SortFunc(keys, func(a, b string) int {
+		pa, na, _ := ParsePortKey(a)
+		pb, nb, _ := ParsePortKey(b)
+		if c := strings.Compare(pa, pb); c != 0 {
+			return c
+		}
+		return na - nb
+	})
+}
+
+// ExpectedPortsRequest replaces the expected ports of a host
+type ExpectedPortsRequest struct {
+	Ports []string `json:"ports"` // port keys, e.g. "tcp/22"
+}
+
+// PortSnapshot is the listening ports of a host after they changed
+type PortSnapshot struct {
+	HostID    string          `json:"hostId"`
+	Ports     []ListeningPort `json:"ports"`
+	CreatedAt time.Time       `json:"createdAt"`
+}
@@ -13,12 +13,109 @@ const (
 
 // Incident represents a service incident
 type Incident struct {
-	ID         int64        `json:"id"`
-	ServiceID  string       `json:"serviceId"`
-	Type       IncidentType `json:"type"`
-	Message    string       `json:"message,omitempty"`
-	StartedAt  time.Time    `json:"startedAt"`
-	ResolvedAt *time.Time   `json:"resolvedAt,omitempty"`
+	ID           int64            `json:"id"`
+	ServiceID    string           `json:"serviceId"`
+	Type         IncidentType     `json:"type"`
+	Message      string           `json:"message,omitempty"`
+	StartedAt    time.Time        `json:"startedAt"`
+	ResolvedAt   *time.Time       `json:"resolvedAt,omitempty"`
+	ParentID     *int64           `json:"parentId,omitempty"` // first incident of a correlated outage
+	Postmortem   *Postmortem      `json:"postmortem,omitempty"`
+	Trace        *Trace           `json:"trace,omitempty"`
+	Remediations []RemediationRun `json:"remediations,omitempty"`
+}
+
+// Trace is the network path to the target of a service, traced when its
+// incident opened
+type Trace struct {
+	IncidentID int64     `json:"incidentId"`
+	Target     string    `json:"target"`
+	Command    string    `json:"command"` // e.g. "traceroute -n -m 30 example.com"
+	Output     string    `json:"output"`
+	Error      string    `json:"error,omitempty"` // why the trace failed or stopped early
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// RootCause categorizes the cause of an incident in its postmortem
+type RootCause string
+
+const (
+	RootCauseInfrastructure RootCause = "infrastructure"
+	RootCauseNetwork        RootCause = "network"
+	RootCauseDeployment     RootCause = "deployment"
+	RootCauseConfiguration  RootCause = "configuration"
+	RootCauseDependency     RootCause = "dependency"
+	RootCauseCapacity       RootCause = "capacity"
+	RootCauseSecurity       RootCause = "security"
+	RootCauseHumanError     RootCause = "human_error"
+	RootCauseUnknown        RootCause = "unknown"
+)
+
+// RootCauses lists the valid root-cause categories
+var RootCauses = []RootCause{
+	RootCauseInfrastructure, RootCauseNetwork, RootCauseDeployment, RootCauseConfiguration,
+	RootCauseDependency, RootCauseCapacity, RootCauseSecurity, RootCauseHumanError, RootCauseUnknown,
+}
+
+// IsValid reports whether c is a known root-cause category
+func (c RootCause) IsValid() bool {
+	for _, known := range RootCauses {
+		if c == known {
+			return true
+		}
+	}
+	return false
+}
+
+// ActionItem is a follow-up task from a postmortem
+type ActionItem struct {
+	Description string     `json:"description"`
+	Owner       string     `json:"owner"`
+	DueDate     *time.Time `json:"dueDate,omitempty"`
+	Done        bool       `json:"done"`
+}
+
+// Postmortem is the structured review of an incident
+type Postmortem struct {
+	IncidentID  int64        `json:"incidentId"`
+	RootCause   RootCause    `json:"rootCause"`
+	Impact      string       `json:"impact,omitempty"` // summary of who/what was affected
+	ActionItems []ActionItem `json:"actionItems"`
+	Body        string       `json:"body,omitempty"` // markdown
+	CreatedAt   time.Time    `json:"createdAt"`
+	UpdatedAt   time.Time    `json:"updatedAt"`
+}
+
+// PostmortemRequest represents a request to write an incident's postmortem
+type PostmortemRequest struct {
+	RootCause   RootCause    `json:"rootCause"`
+	Impact      string       `json:"impact"`
+	ActionItems []ActionItem `json:"actionItems"`
+	Body        string       `json:"body"`
+}
+
+// PostmortemReport summarizes the postmortems of incidents started in a
+// period by root cause
+type PostmortemReport struct {
+	From            time.Time              `json:"from"`
+	To              time.Time              `json:"to"`
+	Incidents       int                    `json:"incidents"`
+	WithPostmortem  int                    `json:"withPostmortem"`
+	RootCauses      []RootCauseSummary     `json:"rootCauses"`
+	OpenActionItems []PostmortemActionItem `json:"openActionItems"`
+}
+
+// RootCauseSummary counts the incidents of a root cause and their downtime
+type RootCauseSummary struct {
+	RootCause       RootCause `json:"rootCause"`
+	Incidents       int       `json:"incidents"`
+	DowntimeSeconds int64     `json:"downtimeSeconds"` // resolved incidents only
+}
+
+// PostmortemActionItem is an action item with the incident it came from
+type PostmortemActionItem struct {
+	IncidentID int64 `json:"incidentId"`
+	ActionItem
 }
 
 // TimelineEvent represents an event in the incident timeline
@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// OrphanSummary counts rows whose reference points at a missing parent row
+type OrphanSummary struct {
+	Table  string `json:"table"`
+	Column string `json:"column"`
+	Parent string `json:"parent"`
+	Count  int64  `json:"count"`
+	// Repair action: "delete", "set null", or "none" for references that
+	// are reported but never changed automatically (e.g. alert rules)
+	Action string `json:"action"`
+}
+
+// IntegrityReport is the result of a schema and data integrity check
+type IntegrityReport struct {
+	CheckedAt       time.Time       `json:"checkedAt"`
+	Healthy         bool            `json:"healthy"`
+	Full            bool            `json:"full"`            // integrity_check instead of quick_check
+	IntegrityErrors []string        `json:"integrityErrors"` // SQLite page/index corruption messages
+	Orphans         []OrphanSummary `json:"orphans"`
+	MissingIndexes  []string        `json:"missingIndexes"`
+}
+
+// IntegrityRepairOptions selects which problems a repair fixes
+type IntegrityRepairOptions struct {
+	DeleteOrphans   bool `json:"deleteOrphans"`
+	RecreateIndexes bool `json:"recreateIndexes"`
+}
+
+// IntegrityRepairResult describes what a repair changed and the state afterwards
+type IntegrityRepairResult struct {
+	OrphansDeleted int64            `json:"orphansDeleted"`
+	OrphansNulled  int64            `json:"orphansNulled"`
+	IndexesCreated []string         `json:"indexesCreated"`
+	Report         *IntegrityReport `json:"report"`
+}
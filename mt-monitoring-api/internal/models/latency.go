@@ -0,0 +1,41 @@
+package models
+
+import "time"
+
+// LatencyBuckets are the upper bounds in milliseconds of the response time
+// histogram buckets. A check falls in the first bucket whose bound is at
+// least its response time; slower checks fall in a last, unbounded bucket.
+var LatencyBuckets = []int{10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// LatencyBucket returns the histogram bucket index of a response time
+func LatencyBucket(responseTime int) int {
+	for i, bound := range LatencyBuckets {
+		if responseTime <= bound {
+			return i
+		}
+	}
+	return len(LatencyBuckets)
+}
+
+// MetricRollup aggregates the checks of a service in one hour. Counts has
+// one entry per LatencyBuckets bound plus the unbounded bucket and only
+// counts checks that got a response.
+type MetricRollup struct {
+	Hour            time.Time `json:"hour"`
+	Checks          int       `json:"checks"`
+	Failures        int       `json:"failures"`
+	AvgResponseTime float64   `json:"avgResponseTime"`
+	MaxResponseTime int       `json:"maxResponseTime"`
+	Counts          []int     `json:"counts"`
+}
+
+// LatencyHistogram is the response time distribution of a service over a
+// period, by hour for heatmaps and in total
+type LatencyHistogram struct {
+	ServiceID string         `json:"serviceId"`
+	Buckets   []int          `json:"buckets"` // upper bounds in ms; counts have one more, unbounded entry
+	Hours     []MetricRollup `json:"hours"`
+	Total     []int          `json:"total"`
+	Checks    int            `json:"checks"`
+	Failures  int            `json:"failures"`
+}
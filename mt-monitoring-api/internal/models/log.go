@@ -14,6 +14,25 @@ const (
 	LogLevelInfo  LogLevel = "info"
 )
 
+// IsValid returns true if the level is one of the known levels
+func (l LogLevel) IsValid() bool {
+	return l == LogLevelError || l == LogLevelWarn || l == LogLevelInfo
+}
+
+// Rank orders levels by severity (info < warn < error); unknown levels rank lowest
+func (l LogLevel) Rank() int {
+	switch l {
+	case LogLevelError:
+		return 3
+	case LogLevelWarn:
+		return 2
+	case LogLevelInfo:
+		return 1
+	default:
+		return 0
+	}
+}
+
 // LogSource represents where the log originated from
 const (
 	LogSourceInternal = "internal"
@@ -47,9 +66,18 @@ type LogIngestRequest struct {
 	Metadata map[string]interface{} `json:"metadata,omitempty"`
 }
 
+// LogRetentionPolicy describes how long logs are kept.
+// Per-service overrides take precedence over level policies, which take precedence over Default.
+type LogRetentionPolicy struct {
+	Default  time.Duration
+	Levels   map[LogLevel]time.Duration
+	Services map[string]time.Duration
+}
+
 // LogFilter represents filter options for log queries
 type LogFilter struct {
 	ServiceID string    `json:"serviceId,omitempty"`
+	Tags      []string  `json:"tags,omitempty"` // services carrying all of them
 	Level     LogLevel  `json:"level,omitempty"`
 	Search    string    `json:"search,omitempty"`
 	From      time.Time `json:"from,omitempty"`
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// MaintenanceTask identifies a database maintenance job
+type MaintenanceTask string
+
+const (
+	MaintenanceCleanup    MaintenanceTask = "cleanup"
+	MaintenanceCheckpoint MaintenanceTask = "checkpoint"
+	MaintenanceVacuum     MaintenanceTask = "vacuum"
+)
+
+// MaintenanceRun records the last run of a maintenance task
+type MaintenanceRun struct {
+	Task       MaintenanceTask `json:"task"`
+	LastRunAt  time.Time       `json:"lastRunAt"`
+	DurationMs int64           `json:"durationMs"`
+	Detail     string          `json:"detail,omitempty"`
+}
+
+// DatabaseStats represents database file and table statistics
+type DatabaseStats struct {
+	Path             string           `json:"path"`
+	FileSize         int64            `json:"fileSize"` // bytes
+	WALSize          int64            `json:"walSize"`  // bytes
+	TotalSize        int64            `json:"totalSize"`
+	MaxSize          int64            `json:"maxSize,omitempty"` // alert threshold, bytes
+	PageCount        int64            `json:"pageCount"`
+	PageSize         int64            `json:"pageSize"`
+	FreePages        int64            `json:"freePages"`
+	SchemaVersion    int              `json:"schemaVersion"`
+	Tables           map[string]int64 `json:"tables"` // table → row count
+	LastCleanupAt    *time.Time       `json:"lastCleanupAt,omitempty"`
+	LastCheckpointAt *time.Time       `json:"lastCheckpointAt,omitempty"`
+	LastVacuumAt     *time.Time       `json:"lastVacuumAt,omitempty"`
+}
@@ -19,6 +19,11 @@ type Metric struct {
 	StatusCode   int         `json:"statusCode,omitempty"`
 	ErrorMessage string      `json:"errorMessage,omitempty"`
 	CheckedAt    time.Time   `json:"checkedAt"`
+
+	// Phases and negotiated protocol (e.g. "HTTP/2.0") of HTTP checks,
+	// empty for other check types
+	Timings  *CheckTimings `json:"timings,omitempty"`
+	Protocol string        `json:"protocol,omitempty"`
 }
 
 // MetricSummary represents aggregated metrics for a service
@@ -27,10 +32,81 @@ type MetricSummary struct {
 	TotalChecks      int     `json:"totalChecks"`
 	SuccessfulChecks int     `json:"successfulChecks"`
 	FailedChecks     int     `json:"failedChecks"`
-	Uptime           float64 `json:"uptime"` // percentage
+	ExcludedChecks   int     `json:"excludedChecks"` // inside pause, maintenance or exclusion windows, not counted
+	Uptime           float64 `json:"uptime"`         // percentage
 	AvgResponseTime  float64 `json:"avgResponseTime"`
 	MinResponseTime  int     `json:"minResponseTime"`
 	MaxResponseTime  int     `json:"maxResponseTime"`
+	P50ResponseTime  int     `json:"p50ResponseTime"`
+	P95ResponseTime  int     `json:"p95ResponseTime"`
+	P99ResponseTime  int     `json:"p99ResponseTime"`
+	Apdex            float64 `json:"apdex"`          // 0-1
+	ApdexThreshold   int     `json:"apdexThreshold"` // milliseconds
+
+	// Average phases of the HTTP checks, nil without timed checks
+	AvgTimings *CheckTimings `json:"avgTimings,omitempty"`
+}
+
+// PerformanceStats holds response-time percentiles and the Apdex score for a
+// service. Percentiles use the nearest-rank method over checks that got a
+// response; Apdex counts failed checks as frustrated.
+type PerformanceStats struct {
+	ServiceID       string  `json:"serviceId"`
+	P50ResponseTime int     `json:"p50ResponseTime"`
+	P95ResponseTime int     `json:"p95ResponseTime"`
+	P99ResponseTime int     `json:"p99ResponseTime"`
+	Apdex           float64 `json:"apdex"`
+	Satisfied       int     `json:"satisfied"`
+	Tolerating      int     `json:"tolerating"`
+	Frustrated      int     `json:"frustrated"`
+}
+
+// ApdexScore returns (satisfied + tolerating/2) / total, or 0 without samples
+func ApdexScore(satisfied, tolerating, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return (float64(satisfied) + float64(tolerating)/2) / float64(total)
+}
+
+// ServiceStatusSnapshot is the latest check result and windowed summary for
+// one service, used to enrich service lists without per-service queries
+type ServiceStatusSnapshot struct {
+	ServiceID        string
+	LastStatus       CheckStatus
+	LastCheckAt      time.Time
+	TotalChecks      int
+	SuccessfulChecks int
+	AvgResponseTime  float64
+}
+
+// Uptime returns the success percentage within the summary window
+func (s ServiceStatusSnapshot) Uptime() float64 {
+	if s.TotalChecks == 0 {
+		return 0
+	}
+	return float64(s.SuccessfulChecks) / float64(s.TotalChecks) * 100
+}
+
+// Timeline interval states; paused, maintenance and excluded come from
+// service windows
+const (
+	TimelineUp          = "up"
+	TimelineDown        = "down"
+	TimelinePaused      = "paused"
+	TimelineMaintenance = "maintenance"
+	TimelineExcluded    = "excluded"
+)
+
+// StatusInterval is a run of consecutive checks with the same state. An
+// interval ends where the next one starts; the last one ends now.
+type StatusInterval struct {
+	Status    string    `json:"status"`
+	StartedAt time.Time `json:"startedAt"`
+	EndedAt   time.Time `json:"endedAt"`
+	Duration  int64     `json:"duration"` // seconds
+	Checks    int       `json:"checks"`
+	Note      string    `json:"note,omitempty"` // note of the window, for window states
 }
 
 // UptimeData represents uptime data for calendar view
@@ -54,10 +130,26 @@ type DashboardSummary struct {
 	TotalServices     int     `json:"totalServices"`
 	HealthyServices   int     `json:"healthyServices"`
 	UnhealthyServices int     `json:"unhealthyServices"`
+	PausedServices    int     `json:"pausedServices"`
 	AvgResponseTime   float64 `json:"avgResponseTime"`
 	OverallUptime     float64 `json:"overallUptime"`
 	CriticalAlerts    int     `json:"criticalAlerts"`
 	// CriticalServiceID is set when there is exactly one active incident,
 	// so the frontend can navigate directly to that service's detail page.
 	CriticalServiceID string `json:"criticalServiceId,omitempty"`
+	// Apdex averaged over services with checks in the window, plus the
+	// per-service breakdown
+	OverallApdex   float64            `json:"overallApdex"`
+	ApdexThreshold int                `json:"apdexThreshold"` // milliseconds
+	Performance    []PerformanceStats `json:"performance"`
+	// Services with the highest average response time in the window
+	SlowestServices []ServiceLatency `json:"slowestServices"`
+}
+
+// ServiceLatency is a service's average response time within a window
+type ServiceLatency struct {
+	ServiceID       string  `json:"serviceId"`
+	Name            string  `json:"name"`
+	AvgResponseTime float64 `json:"avgResponseTime"`
+	Checks          int     `json:"checks"`
 }
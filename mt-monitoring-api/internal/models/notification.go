@@ -5,6 +5,7 @@ import "time"
 // NotificationChannel represents a configured alert channel
 type NotificationChannel struct {
 	ID        string    `json:"id"`
+	ProjectID string    `json:"projectId"`
 	Name      string    `json:"name"`
 	Type      string    `json:"type"`   // "telegram" | "discord"
 	Config    string    `json:"config"` // JSON string
@@ -16,11 +17,23 @@ type NotificationChannel struct {
 type TelegramConfig struct {
 	BotToken string `json:"botToken"`
 	ChatID   string `json:"chatId"`
+	Language string `json:"language,omitempty"` // notification language, alerts.language when empty
+	Timezone string `json:"timezone,omitempty"` // IANA timezone of message times, server.timezone when empty
 }
 
 // DiscordConfig holds Discord webhook configuration
 type DiscordConfig struct {
 	WebhookURL string `json:"webhookUrl"`
+	Language   string `json:"language,omitempty"` // notification language, alerts.language when empty
+	Timezone   string `json:"timezone,omitempty"` // IANA timezone of message times, server.timezone when empty
+}
+
+// EmailConfig is the recipient of an email channel, sent through the
+// alerts.channels.email SMTP server
+type EmailConfig struct {
+	To       string `json:"to"`
+	Language string `json:"language,omitempty"` // notification language, alerts.language when empty
+	Timezone string `json:"timezone,omitempty"` // IANA timezone of message times, server.timezone when empty
 }
 
 // NotificationChannelCreateRequest represents the request to create a channel
@@ -28,6 +28,7 @@ type NotificationHistoryFilter struct {
 	ChannelID *string
 	AlertType *string
 	Status    *string
+	Tags      []string // services carrying all of them
 	FromDate  *time.Time
 	ToDate    *time.Time
 	Limit     int
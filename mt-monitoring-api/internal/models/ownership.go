@@ -0,0 +1,48 @@
+package models
+
+import "strings"
+
+// Ownership tells who is responsible for a service or host. It is shown in
+// its alerts, and Owner receives them through the notification preferences
+// of that user.
+type Ownership struct {
+	Team       string `json:"team,omitempty"`
+	Owner      string `json:"owner,omitempty"`      // user ID, as sent in X-User
+	Contact    string `json:"contact,omitempty"`    // e.g. phone number or email address
+	Escalation string `json:"escalation,omitempty"` // e.g. "#payments-oncall"
+}
+
+// IsZero reports whether no ownership field is set
+func (o *Ownership) IsZero() bool {
+	return o == nil || *o == Ownership{}
+}
+
+// Normalized returns o with its fields trimmed, nil when none is set
+func (o *Ownership) Normalized() *Ownership {
+	if o == nil {
+		return nil
+	}
+	n := &Ownership{
+		Team:       strings.TrimSpace(o.Team),
+		Owner:      strings.TrimSpace(o.Owner),
+		Contact:    strings.TrimSpace(o.Contact),
+		Escalation: strings.TrimSpace(o.Escalation),
+	}
+	if n.IsZero() {
+		return nil
+	}
+	return n
+}
+
+// Matches reports whether o belongs to team and owner, compared case
+// insensitively. Empty filters match anything.
+func (o *Ownership) Matches(team, owner string) bool {
+	if team == "" && owner == "" {
+		return true
+	}
+	if o == nil {
+		return false
+	}
+	return (team == "" || strings.EqualFold(o.Team, team)) &&
+		(owner == "" || strings.EqualFold(o.Owner, owner))
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// decodePatch decodes the body of a partial update into v, a pointer to a
+// struct of pointer fields. Omitted fields stay nil and keep the stored
+// value. Fields sent as null point to their zero value, the same as
+// sending "", 0, [] or {}, so a client can clear a field either way.
+func decodePatch(data []byte, v interface{}) error {
+	if err := json.Unmarshal(data, v); err != nil {
+		return err
+	}
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	for i := 0; i < value.NumField(); i++ {
+		name, _, _ := strings.Cut(value.Type().Field(i).Tag.Get("json"), ",")
+		field := value.Field(i)
+		if !bytes.Equal(raw[name], []byte("null")) || field.Kind() != reflect.Pointer || !field.IsNil() {
+			continue
+		}
+		field.Set(reflect.New(field.Type().Elem()))
+	}
+	return nil
+}
+
+// set stores the value of an update field in dst when it was sent
+func set[T any](dst *T, value *T) {
+	if value != nil {
+		*dst = *value
+	}
+}
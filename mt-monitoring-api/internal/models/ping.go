@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// PingResult is the outcome of one round of echo requests to a host
+type PingResult struct {
+	HostID    string    `json:"hostId"`
+	Sent      int       `json:"sent"`
+	Received  int       `json:"received"`
+	RTT       float64   `json:"rtt"`  // average ms of the replies
+	Loss      float64   `json:"loss"` // percent of requests without reply
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checkedAt"`
+}
+
+// ReachabilityPoint is the ping round-trip time and packet loss of a host
+// at one stored system metric point
+type ReachabilityPoint struct {
+	Timestamp string   `json:"timestamp"`
+	RTT       *float64 `json:"rtt"` // null when every ping was lost
+	Loss      float64  `json:"loss"`
+}
+
+// ReachabilityHistory is the reachability chart of a host
+type ReachabilityHistory struct {
+	Range  string              `json:"range"`
+	Points []ReachabilityPoint `json:"points"`
+}
@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+// GlobalPreferenceOwner owns the preferences of requests that name no owner
+const GlobalPreferenceOwner = "global"
+
+// DashboardPreferences are the pinned services and hosts and the custom
+// ordering of a dashboard
+type DashboardPreferences struct {
+	ProjectID      string     `json:"projectId"`
+	Owner          string     `json:"owner"`
+	PinnedServices []string   `json:"pinnedServices"`
+	PinnedHosts    []string   `json:"pinnedHosts"`
+	ServiceOrder   []string   `json:"serviceOrder"` // IDs first in this order, the rest after
+	HostOrder      []string   `json:"hostOrder"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"` // nil until first saved
+}
+
+// DashboardPreferencesRequest replaces the dashboard preferences
+type DashboardPreferencesRequest struct {
+	PinnedServices []string `json:"pinnedServices"`
+	PinnedHosts    []string `json:"pinnedHosts"`
+	ServiceOrder   []string `json:"serviceOrder"`
+	HostOrder      []string `json:"hostOrder"`
+}
+
+// DashboardView is a saved set of dashboard filters. Filters are stored as
+// sent; their keys are up to the dashboard (e.g. tags, type, status).
+type DashboardView struct {
+	ID        int64                  `json:"id"`
+	ProjectID string                 `json:"projectId"`
+	Owner     string                 `json:"owner"`
+	Name      string                 `json:"name"`
+	Filters   map[string]interface{} `json:"filters"`
+	CreatedAt time.Time              `json:"createdAt"`
+	UpdatedAt time.Time              `json:"updatedAt"`
+}
+
+// DashboardViewRequest represents a request to save a view
+type DashboardViewRequest struct {
+	Name    string                 `json:"name"`
+	Filters map[string]interface{} `json:"filters"`
+}
+
+// NotificationPreferences are the personal channels of a user and the
+// alerts they receive on them, on top of the channels the alerts are sent
+// to. A user receives the alerts of the services and hosts in their scopes
+// (services carrying any of Tags included), or every alert with AllAlerts.
+type NotificationPreferences struct {
+	ProjectID      string          `json:"projectId"`
+	Owner          string          `json:"owner"`
+	Enabled        bool            `json:"enabled"`
+	TelegramChatID string          `json:"telegramChatId,omitempty"` // sent to by the alerts.channels.telegram bot
+	Email          string          `json:"email,omitempty"`          // sent to through alerts.channels.email.smtp
+	Language       string          `json:"language,omitempty"`       // alerts.language when empty
+	Timezone       string          `json:"timezone,omitempty"`       // server.timezone when empty
+	Severities     []AlertSeverity `json:"severities"`               // every severity when empty
+	ServiceIDs     []string        `json:"serviceIds"`
+	HostIDs        []string        `json:"hostIds"`
+	Tags           []string        `json:"tags"`
+	AllAlerts      bool            `json:"allAlerts"`
+	UpdatedAt      *time.Time      `json:"updatedAt,omitempty"` // nil until first saved
+}
+
+// Receives reports whether the preferences take alerts of severity
+func (p *NotificationPreferences) Receives(severity AlertSeverity) bool {
+	if len(p.Severities) == 0 {
+		return true
+	}
+	for _, s := range p.Severities {
+		if s == severity {
+			return true
+		}
+	}
+	return false
+}
+
+// NotificationPreferencesRequest replaces the notification preferences
+type NotificationPreferencesRequest struct {
+	Enabled        *bool           `json:"enabled"` // default true
+	TelegramChatID string          `json:"telegramChatId"`
+	Email          string          `json:"email"`
+	Language       string          `json:"language"`
+	Timezone       string          `json:"timezone"`
+	Severities     []AlertSeverity `json:"severities"`
+	ServiceIDs     []string        `json:"serviceIds"`
+	HostIDs        []string        `json:"hostIds"`
+	Tags           []string        `json:"tags"`
+	AllAlerts      bool            `json:"allAlerts"`
+}
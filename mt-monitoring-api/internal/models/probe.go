@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// ProbeModule selects the kind of check an ad-hoc probe runs
+type ProbeModule string
+
+const (
+	ProbeModuleHTTP ProbeModule = "http"
+	ProbeModuleTCP  ProbeModule = "tcp"
+	ProbeModuleICMP ProbeModule = "icmp"
+	ProbeModuleDNS  ProbeModule = "dns"
+)
+
+// ProbeResult is the outcome of an ad-hoc probe. Nothing is stored.
+type ProbeResult struct {
+	Module       ProbeModule   `json:"module"`
+	Target       string        `json:"target"`
+	Success      bool          `json:"success"`
+	Status       CheckStatus   `json:"status"`
+	ResponseTime int           `json:"responseTime"` // milliseconds
+	StatusCode   int           `json:"statusCode,omitempty"`
+	ErrorMessage string        `json:"errorMessage,omitempty"`
+	Addresses    []string      `json:"addresses,omitempty"` // resolved addresses, dns module
+	CheckedAt    time.Time     `json:"checkedAt"`
+	Details      *CheckDetails `json:"details,omitempty"` // failure diagnostics, when enabled
+}
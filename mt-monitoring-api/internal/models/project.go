@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// DefaultProject holds the resources created before projects existed, those
+// declared in the GitOps source and those of requests naming no project
+const DefaultProject = "default"
+
+// ProjectTokenPrefix starts every project token, telling them apart from
+// service API keys and the admin token
+const ProjectTokenPrefix = "mtp_"
+
+// Project isolates the services, hosts, alert rules, notification channels
+// and user preferences of a team. Requests authenticated with its token
+// only see and change the resources of the project.
+type Project struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Token       string    `json:"token,omitempty"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ProjectRequest creates a project or replaces its name and description
+type ProjectRequest struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// Kinds of resources belonging to a project
+const (
+	ProjectService   = "service"
+	ProjectHost      = "host"
+	ProjectAlertRule = "alertRule"
+	ProjectChannel   = "channel"
+)
@@ -0,0 +1,61 @@
+package models
+
+import "time"
+
+// RemediationType is how the remediation action of an alert rule runs
+type RemediationType string
+
+const (
+	RemediationSSH     RemediationType = "ssh"     // shell command on a host
+	RemediationWebhook RemediationType = "webhook" // POST of the alert to a URL
+)
+
+// Remediation is the action an alert rule runs when it fires, e.g.
+// `systemctl restart app`. It runs at most MaxRuns times per Window minutes
+// for each host or service of the rule.
+type Remediation struct {
+	Type    RemediationType `json:"type"`
+	Command string          `json:"command,omitempty"` // ssh
+	HostID  string          `json:"hostId,omitempty"`  // ssh: host to run on, the alerting host of resource rules when empty
+	URL     string          `json:"url,omitempty"`     // webhook
+	MaxRuns int             `json:"maxRuns"`
+	Window  int             `json:"window"` // minutes
+}
+
+// ApplyDefaults fills in the run limit: once per hour
+func (r *Remediation) ApplyDefaults() {
+	if r.MaxRuns <= 0 {
+		r.MaxRuns = 1
+	}
+	if r.Window <= 0 {
+		r.Window = 60
+	}
+}
+
+// RemediationStatus is the outcome of a remediation run
+type RemediationStatus string
+
+const (
+	RemediationSucceeded RemediationStatus = "succeeded"
+	RemediationFailed    RemediationStatus = "failed"
+	RemediationSkipped   RemediationStatus = "skipped" // run limit reached
+)
+
+// RemediationRun records a remediation action run by an alert rule. Runs
+// are kept when the rule is deleted, as its audit trail.
+type RemediationRun struct {
+	ID         int64             `json:"id"`
+	RuleID     string            `json:"ruleId"`
+	RuleName   string            `json:"ruleName"`
+	HostID     string            `json:"hostId,omitempty"`
+	ServiceID  string            `json:"serviceId,omitempty"`
+	IncidentID *int64            `json:"incidentId,omitempty"` // open incident of the service when the rule fired
+	Type       RemediationType   `json:"type"`
+	Target     string            `json:"target"`            // host the command ran on, or webhook URL
+	Command    string            `json:"command,omitempty"` // ssh
+	Status     RemediationStatus `json:"status"`
+	Output     string            `json:"output,omitempty"` // command output or webhook response, truncated
+	Error      string            `json:"error,omitempty"`
+	StartedAt  time.Time         `json:"startedAt"`
+	Duration   int               `json:"duration"` // ms
+}
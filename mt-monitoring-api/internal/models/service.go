@@ -1,6 +1,9 @@
 package models
 
 import (
+	"regexp"
+	"slices"
+	"strings"
 	"time"
 )
 
@@ -11,8 +14,24 @@ const (
 	ServiceTypeHTTP ServiceType = "http"
 	ServiceTypeTCP  ServiceType = "tcp"
 	ServiceTypeICMP ServiceType = "icmp"
+
+	// ServiceTypeHeartbeat is not checked; the monitored job pings
+	// /ping/<pingKey> at least every interval seconds
+	ServiceTypeHeartbeat ServiceType = "heartbeat"
+
+	// ServiceTypeSelf checks the health of the monitoring server itself.
+	// Only the service registered by selfMonitor has it.
+	ServiceTypeSelf ServiceType = "self"
+
+	// ServiceTypeBrowser loads the page in headless Chrome and waits for a
+	// selector, for single-page apps an HTTP check cannot validate. It
+	// needs browser.enabled and a build with -tags browser.
+	ServiceTypeBrowser ServiceType = "browser"
 )
 
+// SelfServiceID is the ID of the self-monitoring service
+const SelfServiceID = "self"
+
 // ServiceStatus represents the current status of a service
 type ServiceStatus string
 
@@ -31,9 +50,108 @@ const (
 	ScheduleTypeCron     ScheduleType = "cron"
 )
 
+// HTTPProtocol is the HTTP version an HTTP service is checked over. Some
+// outages only affect one protocol path, e.g. a broken QUIC listener.
+type HTTPProtocol string
+
+const (
+	HTTPProtocolAuto HTTPProtocol = "auto" // HTTP/2 when the server offers it, else HTTP/1.1
+	HTTPProtocolH1   HTTPProtocol = "h1"
+	HTTPProtocolH2   HTTPProtocol = "h2" // prior knowledge (h2c) on plain HTTP
+	HTTPProtocolH3   HTTPProtocol = "h3" // HTTPS only
+)
+
+// IsValid reports whether p is a known protocol
+func (p HTTPProtocol) IsValid() bool {
+	switch p {
+	case HTTPProtocolAuto, HTTPProtocolH1, HTTPProtocolH2, HTTPProtocolH3:
+		return true
+	}
+	return false
+}
+
+// CallbackMode is which check results are posted to the callback URL of a
+// service
+type CallbackMode string
+
+const (
+	CallbackAll    CallbackMode = "all"    // every check result
+	CallbackChange CallbackMode = "change" // only results changing the service status
+)
+
+// IsValid reports whether m is a known callback mode
+func (m CallbackMode) IsValid() bool {
+	return m == CallbackAll || m == CallbackChange
+}
+
+// AuthType is how the checks of an HTTP service authenticate
+type AuthType string
+
+const (
+	AuthTypeBasic  AuthType = "basic"
+	AuthTypeBearer AuthType = "bearer"
+	AuthTypeOAuth2 AuthType = "oauth2" // client credentials grant
+)
+
+// MaskedSecret replaces secrets in API responses. Sent back unchanged, it
+// keeps the stored secret.
+const MaskedSecret = "***"
+
+// ServiceAuth holds the credentials of an HTTP service. The whole value is
+// stored encrypted; secrets are masked in API responses.
+type ServiceAuth struct {
+	Type AuthType `json:"type"`
+
+	// basic
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// bearer
+	Token string `json:"token,omitempty"`
+
+	// oauth2: the token is requested from TokenURL with the client
+	// credentials and cached until it expires
+	TokenURL     string   `json:"tokenUrl,omitempty"`
+	ClientID     string   `json:"clientId,omitempty"`
+	ClientSecret string   `json:"clientSecret,omitempty"`
+	Scopes       []string `json:"scopes,omitempty"`
+}
+
+// Masked returns a copy of the credentials with the secrets masked
+func (a *ServiceAuth) Masked() *ServiceAuth {
+	if a == nil {
+		return nil
+	}
+	m := *a
+	for _, secret := range []*string{&m.Password, &m.Token, &m.ClientSecret} {
+		if *secret != "" {
+			*secret = MaskedSecret
+		}
+	}
+	return &m
+}
+
+// KeepSecrets replaces the masked secrets of a by those of stored, so a
+// client can send back the credentials it read
+func (a *ServiceAuth) KeepSecrets(stored *ServiceAuth) {
+	if a == nil || stored == nil {
+		return
+	}
+	if a.Password == MaskedSecret {
+		a.Password = stored.Password
+	}
+	if a.Token == MaskedSecret {
+		a.Token = stored.Token
+	}
+	if a.ClientSecret == MaskedSecret {
+		a.ClientSecret = stored.ClientSecret
+	}
+}
+
 // Service represents a monitored service
 type Service struct {
 	ID             string            `json:"id"`
+	ProjectID      string            `json:"projectId"`
 	Name           string            `json:"name"`
 	Type           ServiceType       `json:"type"`
 	IsActive       bool              `json:"isActive"`
@@ -50,12 +168,57 @@ type Service struct {
 	UpdatedAt      time.Time         `json:"updatedAt"`
 
 	// Schedule configuration
-	ScheduleType   ScheduleType `json:"scheduleType"`           // "interval" or "cron"
+	ScheduleType   ScheduleType `json:"scheduleType"`             // "interval" or "cron"
 	CronExpression string       `json:"cronExpression,omitempty"` // For cron type
 
 	// API Key for log ingestion
 	ApiKey string `json:"apiKey,omitempty"`
 
+	// Heartbeat services: key of the ping URL and the seconds a ping may
+	// be late before the service is down
+	PingKey string `json:"pingKey,omitempty"`
+	Grace   int    `json:"grace,omitempty"`
+
+	// Log retention override (e.g. "30d"); empty uses the global/level policy
+	LogRetention string `json:"logRetention,omitempty"`
+
+	// HTTP services: PEM CA certificates trusted in addition to the system
+	// roots, and whether certificates are not verified at all
+	CACert             string `json:"caCert,omitempty"`
+	InsecureSkipVerify bool   `json:"insecureSkipVerify"`
+
+	// HTTP services: protocol the checks use
+	Protocol HTTPProtocol `json:"protocol,omitempty"`
+
+	// HTTP services: credentials the checks authenticate with
+	Auth *ServiceAuth `json:"auth,omitempty"`
+
+	// Browser services: CSS selector that must become visible for the page
+	// to count as loaded; empty waits for the load event only
+	Selector string `json:"selector,omitempty"`
+
+	// URL the check results are posted to, for external automation such as
+	// restart runbooks, and whether every result or only status changes
+	CallbackURL string       `json:"callbackUrl,omitempty"`
+	CallbackOn  CallbackMode `json:"callbackOn,omitempty"`
+
+	// Keep 1 in N raw checks older than retention.sampleAfter; 0 uses
+	// retention.metricSampling, 1 keeps every check
+	MetricSampling int `json:"metricSampling,omitempty"`
+
+	// Notification channels of down and recovery alerts, sorted; empty
+	// uses alerts.defaultChannelIds. nil leaves stored ones unchanged on
+	// update.
+	ChannelIDs []string `json:"channelIds,omitempty"`
+
+	// Team and user responsible for the service, shown in its alerts
+	Ownership *Ownership `json:"ownership,omitempty"`
+
+	// Log ingestion quotas (0 = use server default)
+	IngestRateLimit  int   `json:"ingestRateLimit"`  // events per minute
+	IngestMaxPayload int   `json:"ingestMaxPayload"` // bytes per request
+	DroppedLogs      int64 `json:"droppedLogs"`      // events rejected by quotas
+
 	// Computed fields (not stored in DB, populated from metrics)
 	Status       ServiceStatus `json:"status,omitempty"`
 	LastCheckAt  *time.Time    `json:"lastCheckAt,omitempty"`
@@ -63,6 +226,14 @@ type Service struct {
 	ResponseTime int           `json:"responseTime,omitempty"`
 }
 
+var pingKeyPattern = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
+
+// IsValidPingKey reports whether key is a UUID, the ping key format of
+// healthchecks.io clients
+func IsValidPingKey(key string) bool {
+	return pingKeyPattern.MatchString(key)
+}
+
 // MaskApiKey returns a masked version of the API key (first 8 chars + ***)
 func (s *Service) MaskApiKey() string {
 	if len(s.ApiKey) <= 8 {
@@ -71,6 +242,12 @@ func (s *Service) MaskApiKey() string {
 	return s.ApiKey[:8] + "***"
 }
 
+// MaskSecrets masks the credentials of the service. The stored ones are
+// not modified.
+func (s *Service) MaskSecrets() {
+	s.Auth = s.Auth.Masked()
+}
+
 // HTTPConfig holds HTTP check configuration
 type HTTPConfig struct {
 	URL            string            `json:"url"`
@@ -79,6 +256,21 @@ type HTTPConfig struct {
 	ExpectedStatus int               `json:"expectedStatus"`
 	Timeout        int               `json:"timeout"`
 	Interval       int               `json:"interval"`
+
+	CACert             string       `json:"caCert,omitempty"`
+	InsecureSkipVerify bool         `json:"insecureSkipVerify"`
+	Protocol           HTTPProtocol `json:"protocol,omitempty"`
+	Auth               *ServiceAuth `json:"auth,omitempty"`
+}
+
+// BrowserConfig holds browser check configuration
+type BrowserConfig struct {
+	URL                string            `json:"url"`
+	Headers            map[string]string `json:"headers,omitempty"`
+	Selector           string            `json:"selector,omitempty"`
+	ExpectedStatus     int               `json:"expectedStatus"`
+	Timeout            int               `json:"timeout"`
+	InsecureSkipVerify bool              `json:"insecureSkipVerify"`
 }
 
 // TCPConfig holds TCP check configuration
@@ -91,22 +283,39 @@ type TCPConfig struct {
 
 // ServiceCreateRequest represents a request to create a service
 type ServiceCreateRequest struct {
-	ID             string            `json:"id"`
-	Name           string            `json:"name"`
-	Type           ServiceType       `json:"type"`
-	IsActive       *bool             `json:"isActive,omitempty"`
-	URL            string            `json:"url,omitempty"`
-	Method         string            `json:"method,omitempty"`
-	Host           string            `json:"host,omitempty"`
-	Port           int               `json:"port,omitempty"`
-	Headers        map[string]string `json:"headers,omitempty"`
-	Body           string            `json:"body,omitempty"`
-	ExpectedStatus int               `json:"expectedStatus,omitempty"`
-	Timeout        int               `json:"timeout,omitempty"`
-	Interval       int               `json:"interval,omitempty"`
-	Tags           []string          `json:"tags,omitempty"`
-	ScheduleType   string            `json:"scheduleType,omitempty"`
-	CronExpression string            `json:"cronExpression,omitempty"`
+	ID               string            `json:"id"`
+	Name             string            `json:"name"`
+	Type             ServiceType       `json:"type"`
+	IsActive         *bool             `json:"isActive,omitempty"`
+	URL              string            `json:"url,omitempty"`
+	Method           string            `json:"method,omitempty"`
+	Host             string            `json:"host,omitempty"`
+	Port             int               `json:"port,omitempty"`
+	Headers          map[string]string `json:"headers,omitempty"`
+	Body             string            `json:"body,omitempty"`
+	ExpectedStatus   int               `json:"expectedStatus,omitempty"`
+	Timeout          int               `json:"timeout,omitempty"`
+	Interval         int               `json:"interval,omitempty"`
+	Tags             []string          `json:"tags,omitempty"`
+	ScheduleType     string            `json:"scheduleType,omitempty"`
+	CronExpression   string            `json:"cronExpression,omitempty"`
+	LogRetention     string            `json:"logRetention,omitempty"`
+	IngestRateLimit  int               `json:"ingestRateLimit,omitempty"`
+	IngestMaxPayload int               `json:"ingestMaxPayload,omitempty"`
+	Profile          string            `json:"profile,omitempty"` // named serviceDefaults profile for omitted fields
+	PingKey          string            `json:"pingKey,omitempty"` // heartbeat only, generated when empty
+	Grace            int               `json:"grace,omitempty"`   // heartbeat only, seconds
+	ChannelIDs       []string          `json:"channelIds,omitempty"`
+	MetricSampling   int               `json:"metricSampling,omitempty"`
+
+	CACert             string       `json:"caCert,omitempty"`
+	InsecureSkipVerify *bool        `json:"insecureSkipVerify,omitempty"`
+	Protocol           HTTPProtocol `json:"protocol,omitempty"` // auto when empty
+	Auth               *ServiceAuth `json:"auth,omitempty"`     // none without a type
+	Selector           string       `json:"selector,omitempty"` // browser only
+	CallbackURL        string       `json:"callbackUrl,omitempty"`
+	CallbackOn         CallbackMode `json:"callbackOn,omitempty"` // all when empty
+	Ownership          *Ownership   `json:"ownership,omitempty"`
 }
 
 // ToService converts request to Service model
@@ -129,20 +338,17 @@ func (r *ServiceCreateRequest) ToService() *Service {
 
 	timeout := r.Timeout
 	if timeout == 0 {
-		if r.Type == ServiceTypeTCP {
-			timeout = 3000
-		} else {
-			timeout = 5000
-		}
+		timeout = DefaultTimeout(r.Type)
 	}
 
 	interval := r.Interval
 	if interval == 0 {
-		if r.Type == ServiceTypeTCP {
-			interval = 60
-		} else {
-			interval = 30
-		}
+		interval = DefaultInterval(r.Type)
+	}
+
+	grace := r.Grace
+	if grace == 0 && r.Type == ServiceTypeHeartbeat {
+		grace = DefaultGrace
 	}
 
 	// Schedule type defaults to "interval"
@@ -157,27 +363,237 @@ func (r *ServiceCreateRequest) ToService() *Service {
 		url = r.Host
 	}
 
+	protocol := r.Protocol
+	if protocol == "" {
+		protocol = HTTPProtocolAuto
+	}
+
+	callbackOn := r.CallbackOn
+	if r.CallbackURL == "" {
+		callbackOn = ""
+	} else if callbackOn == "" {
+		callbackOn = CallbackAll
+	}
+
 	now := time.Now()
-	return &Service{
-		ID:             r.ID,
-		Name:           r.Name,
-		Type:           r.Type,
-		IsActive:       isActive,
-		URL:            url,
-		Port:           r.Port,
-		Method:         method,
-		Headers:        r.Headers,
-		Body:           r.Body,
-		ExpectedStatus: expectedStatus,
-		Timeout:        timeout,
-		Interval:       interval,
-		Tags:           r.Tags,
-		ScheduleType:   scheduleType,
-		CronExpression: r.CronExpression,
-		CreatedAt:      now,
-		UpdatedAt:      now,
-		Status:         StatusUnknown,
+	service := &Service{
+		ID:               r.ID,
+		Name:             r.Name,
+		Type:             r.Type,
+		IsActive:         isActive,
+		URL:              url,
+		Port:             r.Port,
+		Method:           method,
+		Headers:          r.Headers,
+		Body:             r.Body,
+		ExpectedStatus:   expectedStatus,
+		Timeout:          timeout,
+		Interval:         interval,
+		Tags:             r.Tags,
+		ScheduleType:     scheduleType,
+		CronExpression:   r.CronExpression,
+		LogRetention:     r.LogRetention,
+		IngestRateLimit:  r.IngestRateLimit,
+		IngestMaxPayload: r.IngestMaxPayload,
+		PingKey:          r.PingKey,
+		Grace:            grace,
+		MetricSampling:   r.MetricSampling,
+		CACert:           r.CACert,
+		Protocol:         protocol,
+		Selector:         r.Selector,
+		CallbackURL:      r.CallbackURL,
+		CallbackOn:       callbackOn,
+		ChannelIDs:       NormalizeChannelIDs(r.ChannelIDs),
+		Ownership:        r.Ownership.Normalized(),
+		CreatedAt:        now,
+		UpdatedAt:        now,
+		Status:           StatusUnknown,
 	}
+	if r.InsecureSkipVerify != nil {
+		service.InsecureSkipVerify = *r.InsecureSkipVerify
+	}
+	// Credentials without a type are none
+	if r.Auth != nil && r.Auth.Type != "" {
+		service.Auth = r.Auth
+	}
+	return service
+}
+
+// DefaultGrace is the grace period of heartbeat services in seconds
+const DefaultGrace = 3600
+
+// DefaultTimeout returns the check timeout of a service type in
+// milliseconds
+func DefaultTimeout(t ServiceType) int {
+	switch t {
+	case ServiceTypeTCP:
+		return 3000
+	case ServiceTypeBrowser:
+		return 30000
+	default:
+		return 5000
+	}
+}
+
+// DefaultInterval returns the check interval of a service type in seconds
+func DefaultInterval(t ServiceType) int {
+	switch t {
+	case ServiceTypeTCP:
+		return 60
+	case ServiceTypeHeartbeat:
+		return 86400
+	case ServiceTypeBrowser:
+		return 300
+	default:
+		return 30
+	}
+}
+
+// ServiceUpdateRequest is the API request to update a service (PATCH).
+// Omitted fields keep their stored values. null or an empty value clears
+// a field, and fields with a default (method, expectedStatus, timeout,
+// interval, scheduleType, grace, protocol) go back to it.
+type ServiceUpdateRequest struct {
+	Name             *string            `json:"name"`
+	Type             *ServiceType       `json:"type"`
+	IsActive         *bool              `json:"isActive"`
+	URL              *string            `json:"url"`
+	Method           *string            `json:"method"`
+	Host             *string            `json:"host"` // TCP and ICMP: used as url while that is empty
+	Port             *int               `json:"port"`
+	Headers          *map[string]string `json:"headers"`
+	Body             *string            `json:"body"`
+	ExpectedStatus   *int               `json:"expectedStatus"`
+	Timeout          *int               `json:"timeout"`
+	Interval         *int               `json:"interval"`
+	Tags             *[]string          `json:"tags"`
+	ScheduleType     *ScheduleType      `json:"scheduleType"`
+	CronExpression   *string            `json:"cronExpression"`
+	LogRetention     *string            `json:"logRetention"`
+	IngestRateLimit  *int               `json:"ingestRateLimit"`
+	IngestMaxPayload *int               `json:"ingestMaxPayload"`
+	PingKey          *string            `json:"pingKey"` // heartbeat only, generated when cleared
+	Grace            *int               `json:"grace"`
+	ChannelIDs       *[]string          `json:"channelIds"`
+	MetricSampling   *int               `json:"metricSampling"`
+
+	CACert             *string       `json:"caCert"`
+	InsecureSkipVerify *bool         `json:"insecureSkipVerify"`
+	Protocol           *HTTPProtocol `json:"protocol"`
+	Auth               *ServiceAuth  `json:"auth"` // {} removes the credentials, masked secrets are kept
+	Selector           *string       `json:"selector"`
+	CallbackURL        *string       `json:"callbackUrl"`
+	CallbackOn         *CallbackMode `json:"callbackOn"`
+	Ownership          *Ownership    `json:"ownership"` // {} removes it
+}
+
+// UnmarshalJSON decodes the request, taking null as the empty value
+func (r *ServiceUpdateRequest) UnmarshalJSON(data []byte) error {
+	type plain ServiceUpdateRequest
+	return decodePatch(data, (*plain)(r))
+}
+
+// ApplyTo updates s with the fields set in the request
+func (r *ServiceUpdateRequest) ApplyTo(s *Service) {
+	set(&s.Name, r.Name)
+	set(&s.Type, r.Type)
+	set(&s.IsActive, r.IsActive)
+	set(&s.URL, r.URL)
+	if r.Host != nil && s.URL == "" {
+		s.URL = *r.Host
+	}
+	set(&s.Port, r.Port)
+	set(&s.Method, r.Method)
+	if s.Method == "" {
+		s.Method = "GET"
+	}
+	if r.Headers != nil {
+		s.Headers = *r.Headers
+		if len(s.Headers) == 0 {
+			s.Headers = nil
+		}
+	}
+	set(&s.Body, r.Body)
+	set(&s.ExpectedStatus, r.ExpectedStatus)
+	if s.ExpectedStatus == 0 {
+		s.ExpectedStatus = 200
+	}
+	set(&s.Timeout, r.Timeout)
+	if s.Timeout == 0 {
+		s.Timeout = DefaultTimeout(s.Type)
+	}
+	set(&s.Interval, r.Interval)
+	if s.Interval == 0 {
+		s.Interval = DefaultInterval(s.Type)
+	}
+	if r.Tags != nil {
+		s.Tags = *r.Tags
+		if len(s.Tags) == 0 {
+			s.Tags = nil
+		}
+	}
+	set(&s.ScheduleType, r.ScheduleType)
+	if s.ScheduleType == "" {
+		s.ScheduleType = ScheduleTypeInterval
+	}
+	set(&s.CronExpression, r.CronExpression)
+	set(&s.LogRetention, r.LogRetention)
+	set(&s.IngestRateLimit, r.IngestRateLimit)
+	set(&s.IngestMaxPayload, r.IngestMaxPayload)
+	set(&s.PingKey, r.PingKey)
+	set(&s.Grace, r.Grace)
+	if s.Grace == 0 && s.Type == ServiceTypeHeartbeat {
+		s.Grace = DefaultGrace
+	}
+	if r.ChannelIDs != nil {
+		s.ChannelIDs = NormalizeChannelIDs(*r.ChannelIDs)
+		if len(s.ChannelIDs) == 0 {
+			s.ChannelIDs = nil
+		}
+	}
+	set(&s.MetricSampling, r.MetricSampling)
+
+	set(&s.CACert, r.CACert)
+	set(&s.InsecureSkipVerify, r.InsecureSkipVerify)
+	set(&s.Protocol, r.Protocol)
+	if s.Protocol == "" {
+		s.Protocol = HTTPProtocolAuto
+	}
+	if r.Auth != nil {
+		if r.Auth.Type == "" {
+			s.Auth = nil
+		} else {
+			r.Auth.KeepSecrets(s.Auth)
+			s.Auth = r.Auth
+		}
+	}
+	set(&s.Selector, r.Selector)
+	set(&s.CallbackURL, r.CallbackURL)
+	set(&s.CallbackOn, r.CallbackOn)
+	if s.CallbackURL == "" {
+		s.CallbackOn = ""
+	} else if s.CallbackOn == "" {
+		s.CallbackOn = CallbackAll
+	}
+	if r.Ownership != nil {
+		s.Ownership = r.Ownership.Normalized()
+	}
+}
+
+// NormalizeChannelIDs trims channel IDs, drops empty and repeated ones and
+// sorts them. nil stays nil.
+func NormalizeChannelIDs(ids []string) []string {
+	if ids == nil {
+		return nil
+	}
+	list := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			list = append(list, id)
+		}
+	}
+	slices.Sort(list)
+	return slices.Compact(list)
 }
 
 // GetHTTPConfig returns HTTP configuration from Service fields
@@ -189,6 +605,23 @@ func (s *Service) GetHTTPConfig() *HTTPConfig {
 		ExpectedStatus: s.ExpectedStatus,
 		Timeout:        s.Timeout,
 		Interval:       s.Interval,
+
+		CACert:             s.CACert,
+		InsecureSkipVerify: s.InsecureSkipVerify,
+		Protocol:           s.Protocol,
+		Auth:               s.Auth,
+	}
+}
+
+// GetBrowserConfig returns browser configuration from Service fields
+func (s *Service) GetBrowserConfig() *BrowserConfig {
+	return &BrowserConfig{
+		URL:                s.URL,
+		Headers:            s.Headers,
+		Selector:           s.Selector,
+		ExpectedStatus:     s.ExpectedStatus,
+		Timeout:            s.Timeout,
+		InsecureSkipVerify: s.InsecureSkipVerify,
 	}
 }
 
@@ -201,3 +634,88 @@ func (s *Service) GetTCPConfig() *TCPConfig {
 		Interval: s.Interval,
 	}
 }
+
+// ServiceBulkFilter selects the services of a bulk update. Criteria combine
+// with AND; All must be set to select every service.
+type ServiceBulkFilter struct {
+	All    bool        `json:"all"`
+	IDs    []string    `json:"ids"`
+	Tags   []string    `json:"tags"`
+	Type   ServiceType `json:"type"`
+	Active *bool       `json:"active"`
+}
+
+// IsEmpty reports whether the filter has no criteria
+func (f *ServiceBulkFilter) IsEmpty() bool {
+	return !f.All && len(f.IDs) == 0 && len(f.Tags) == 0 && f.Type == "" && f.Active == nil
+}
+
+// ServiceBulkPatch holds the fields a bulk update sets; nil fields are kept
+type ServiceBulkPatch struct {
+	Interval       *int      `json:"interval"`
+	Timeout        *int      `json:"timeout"`
+	ExpectedStatus *int      `json:"expectedStatus"`
+	ChannelIDs     *[]string `json:"channelIds"` // channels of the services' alert rules
+}
+
+// IsEmpty reports whether the patch sets nothing
+func (p *ServiceBulkPatch) IsEmpty() bool {
+	return p.Interval == nil && p.Timeout == nil && p.ExpectedStatus == nil && p.ChannelIDs == nil
+}
+
+// ServiceBulkRequest is the body of PATCH /services
+type ServiceBulkRequest struct {
+	Filter ServiceBulkFilter `json:"filter"`
+	Patch  ServiceBulkPatch  `json:"patch"`
+	DryRun bool              `json:"dryRun"`
+}
+
+// FieldChange is a field a bulk update changes
+type FieldChange struct {
+	Field string      `json:"field"`
+	From  interface{} `json:"from"`
+	To    interface{} `json:"to"`
+}
+
+// Reasons a service is reported as stale
+const (
+	StaleReasonPaused = "paused"         // paused for longer than the report window
+	StaleReasonUnused = "unused"         // no incidents, views or pins in the window
+	StaleReasonDNS    = "dns_unresolved" // the target host name does not resolve
+)
+
+// ServiceUsage tells how a service has been used, for the stale report
+type ServiceUsage struct {
+	PausedSince  *time.Time // start of the open pause window
+	LastViewedAt *time.Time
+	Incidents    int  // incidents started since the report window began
+	Pinned       bool // pinned on a dashboard
+}
+
+// StaleReason is one reason a service is reported as stale
+type StaleReason struct {
+	Reason string `json:"reason"`
+	Detail string `json:"detail"`
+}
+
+// StaleService is a service reported as a candidate for removal
+type StaleService struct {
+	ID           string        `json:"id"`
+	Name         string        `json:"name"`
+	Type         ServiceType   `json:"type"`
+	IsActive     bool          `json:"isActive"`
+	PausedSince  *time.Time    `json:"pausedSince,omitempty"`
+	LastViewedAt *time.Time    `json:"lastViewedAt,omitempty"`
+	Incidents    int           `json:"incidents"`
+	Reasons      []StaleReason `json:"reasons"`
+}
+
+// ServiceBulkResult is what a bulk update changes, or would change, on a
+// matched service
+type ServiceBulkResult struct {
+	ID      string        `json:"id"`
+	Name    string        `json:"name"`
+	Changes []FieldChange `json:"changes"`
+	Rules   []string      `json:"rules,omitempty"`   // alert rules whose channels change
+	Skipped string        `json:"skipped,omitempty"` // why the service is left unchanged
+}
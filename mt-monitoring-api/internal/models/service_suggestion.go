@@ -0,0 +1,167 @@
+package models
+
+import (
+	"maps"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// knownPort is a well-known TCP port a service monitor is suggested for
+type knownPort struct {
+	Slug   string // service ID suffix, unique per port
+	Name   string
+	Scheme string // "http" or "https" for an HTTP monitor, "" for a TCP one
+}
+
+// knownPorts are the ports suggestions are made for. Other ports are too
+// often ephemeral or internal to guess a monitor for.
+var knownPorts = map[int]knownPort{
+	21:    {"ftp", "FTP", ""},
+	22:    {"ssh", "SSH", ""},
+	25:    {"smtp", "SMTP", ""},
+	80:    {"http", "HTTP", "http"},
+	389:   {"ldap", "LDAP", ""},
+	443:   {"https", "HTTPS", "https"},
+	465:   {"smtps", "SMTPS", ""},
+	587:   {"submission", "SMTP Submission", ""},
+	636:   {"ldaps", "LDAPS", ""},
+	993:   {"imaps", "IMAPS", ""},
+	1433:  {"mssql", "SQL Server", ""},
+	1883:  {"mqtt", "MQTT", ""},
+	2379:  {"etcd", "etcd", ""},
+	3000:  {"http-3000", "HTTP 3000", "http"},
+	3306:  {"mysql", "MySQL", ""},
+	5432:  {"postgresql", "PostgreSQL", ""},
+	5672:  {"amqp", "RabbitMQ", ""},
+	6379:  {"redis", "Redis", ""},
+	8080:  {"http-8080", "HTTP 8080", "http"},
+	8443:  {"https-8443", "HTTPS 8443", "https"},
+	9090:  {"prometheus", "Prometheus", "http"},
+	9092:  {"kafka", "Kafka", ""},
+	9200:  {"elasticsearch", "Elasticsearch", "http"},
+	11211: {"memcached", "Memcached", ""},
+	15672: {"rabbitmq-management", "RabbitMQ Management", "http"},
+	27017: {"mongodb", "MongoDB", ""},
+}
+
+// ServiceSuggestion is a service monitor proposed for a port a host listens
+// on. Approving it creates Service as is.
+type ServiceSuggestion struct {
+	Port        string               `json:"port"` // port key, e.g. "tcp/5432"
+	Name        string               `json:"name"`
+	Service     ServiceCreateRequest `json:"service"`
+	MonitoredBy string               `json:"monitoredBy,omitempty"` // existing service checking this port
+}
+
+// ServiceSuggestionRequest approves suggestions of a host by port key
+type ServiceSuggestionRequest struct {
+	Ports   []string `json:"ports"`             // e.g. ["tcp/443", "tcp/5432"]
+	Profile string   `json:"profile,omitempty"` // serviceDefaults profile for the created services
+}
+
+// ServiceSuggestionResult is the outcome of approving one suggestion
+type ServiceSuggestionResult struct {
+	Port    string   `json:"port"`
+	Service *Service `json:"service,omitempty"`
+	Skipped string   `json:"skipped,omitempty"` // why no service was created
+}
+
+// SuggestServices proposes HTTP and TCP monitors for the well-known TCP
+// ports a host listens on, checked at address. Ports bound only to loopback
+// are left out unless address is a loopback address itself. Suggestions
+// for ports an existing service already checks carry its ID in
+// MonitoredBy.
+func SuggestServices(host *Host, address string, ports []ListeningPort, services []Service) []ServiceSuggestion {
+	loopback := isLoopback(address)
+	reachable := make(map[int]bool)
+	for _, p := range ports {
+		if p.Protocol != "tcp" {
+			continue
+		}
+		if _, ok := knownPorts[p.Port]; ok && (loopback || !isLoopback(p.Address)) {
+			reachable[p.Port] = true
+		}
+	}
+
+	suggestions := make([]ServiceSuggestion, 0, len(reachable))
+	for _, port := range slices.Sorted(maps.Keys(reachable)) {
+		known := knownPorts[port]
+		req := ServiceCreateRequest{
+			ID:   host.ID + "-" + known.Slug,
+			Name: host.Name + " " + known.Name,
+			Type: ServiceTypeTCP,
+			Host: address,
+			Port: port,
+		}
+		if known.Scheme != "" {
+			req.Type = ServiceTypeHTTP
+			req.Host, req.Port = "", 0
+			req.URL = suggestionURL(known.Scheme, address, port)
+		}
+		s := ServiceSuggestion{
+			Port:    ListeningPort{Protocol: "tcp", Port: port}.Key(),
+			Name:    known.Name,
+			Service: req,
+		}
+		for i := range services {
+			if checksPort(&services[i], address, port) {
+				s.MonitoredBy = services[i].ID
+				break
+			}
+		}
+		suggestions = append(suggestions, s)
+	}
+	return suggestions
+}
+
+// suggestionURL returns the URL of an HTTP monitor, leaving out the default
+// port of the scheme
+func suggestionURL(scheme, address string, port int) string {
+	hostPort := net.JoinHostPort(address, strconv.Itoa(port))
+	if (scheme == "http" && port == 80) || (scheme == "https" && port == 443) {
+		hostPort = address
+		if strings.Contains(address, ":") {
+			hostPort = "[" + address + "]"
+		}
+	}
+	return scheme + "://" + hostPort + "/"
+}
+
+// checksPort reports whether an HTTP or TCP service checks the given port
+// at address
+func checksPort(s *Service, address string, port int) bool {
+	switch s.Type {
+	case ServiceTypeTCP:
+		return s.Port == port && strings.EqualFold(s.URL, address)
+	case ServiceTypeHTTP:
+		u, err := url.Parse(s.URL)
+		if err != nil || !strings.EqualFold(u.Hostname(), address) {
+			return false
+		}
+		p := u.Port()
+		if p == "" {
+			switch u.Scheme {
+			case "http":
+				p = "80"
+			case "https":
+				p = "443"
+			}
+		}
+		return p == strconv.Itoa(port)
+	}
+	return false
+}
+
+// isLoopback reports whether a listening or target address is loopback,
+// e.g. "127.0.0.53%lo" of systemd-resolved
+func isLoopback(address string) bool {
+	if address == "localhost" {
+		return true
+	}
+	address, _, _ = strings.Cut(strings.Trim(address, "[]"), "%")
+	ip := net.ParseIP(address)
+	return ip != nil && ip.IsLoopback()
+}
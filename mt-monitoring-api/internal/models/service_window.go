@@ -0,0 +1,45 @@
+package models
+
+import "time"
+
+// WindowKind identifies why a service was excluded from uptime math
+type WindowKind string
+
+const (
+	WindowPaused      WindowKind = "paused"
+	WindowMaintenance WindowKind = "maintenance"
+	// WindowExcluded annotates a range whose checks don't reflect the
+	// service, such as failures caused by the monitor's own network
+	WindowExcluded WindowKind = "excluded"
+)
+
+// ServiceWindow is a period during which a service's checks do not count
+// towards uptime. Pause windows stay open (EndsAt nil) until resumed.
+type ServiceWindow struct {
+	ID        int64      `json:"id"`
+	ServiceID string     `json:"serviceId"`
+	Kind      WindowKind `json:"kind"`
+	StartsAt  time.Time  `json:"startsAt"`
+	EndsAt    *time.Time `json:"endsAt,omitempty"`
+	Note      string     `json:"note,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// MaxWindowNoteLength bounds the note of a window, the reason of an
+// exclusion
+const MaxWindowNoteLength = 500
+
+// MaintenanceWindowCreateRequest represents a request to schedule maintenance
+type MaintenanceWindowCreateRequest struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Note     string    `json:"note"`
+}
+
+// ExclusionCreateRequest represents a request to annotate a range excluded
+// from uptime; Note, the reason, is required
+type ExclusionCreateRequest struct {
+	StartsAt time.Time `json:"startsAt"`
+	EndsAt   time.Time `json:"endsAt"`
+	Note     string    `json:"note"`
+}
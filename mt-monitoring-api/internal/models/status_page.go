@@ -0,0 +1,191 @@
+package models
+
+import "time"
+
+// ComponentStatus is the public status of a status page component
+type ComponentStatus string
+
+const (
+	ComponentOperational         ComponentStatus = "operational"
+	ComponentUnderMaintenance    ComponentStatus = "under_maintenance"
+	ComponentDegradedPerformance ComponentStatus = "degraded_performance"
+	ComponentPartialOutage       ComponentStatus = "partial_outage"
+	ComponentMajorOutage         ComponentStatus = "major_outage"
+)
+
+// componentStatusSeverity orders statuses from best to worst
+var componentStatusSeverity = map[ComponentStatus]int{
+	ComponentOperational:         0,
+	ComponentUnderMaintenance:    1,
+	ComponentDegradedPerformance: 2,
+	ComponentPartialOutage:       3,
+	ComponentMajorOutage:         4,
+}
+
+// IsValid reports whether s is a known component status
+func (s ComponentStatus) IsValid() bool {
+	_, ok := componentStatusSeverity[s]
+	return ok
+}
+
+// Worse reports whether s is worse than other
+func (s ComponentStatus) Worse(other ComponentStatus) bool {
+	return componentStatusSeverity[s] > componentStatusSeverity[other]
+}
+
+// StatusComponent is a publicly named group of services on the status page
+type StatusComponent struct {
+	ID              string          `json:"id"`
+	Name            string          `json:"name"`
+	Description     string          `json:"description,omitempty"`
+	Position        int             `json:"position"`
+	ServiceIDs      []string        `json:"serviceIds"`
+	StatusOverride  ComponentStatus `json:"statusOverride,omitempty"` // set manually, replaces the computed status
+	OverrideMessage string          `json:"overrideMessage,omitempty"`
+	CreatedAt       time.Time       `json:"createdAt"`
+	UpdatedAt       time.Time       `json:"updatedAt"`
+
+	// Computed fields (not stored)
+	Status ComponentStatus `json:"status,omitempty"`
+}
+
+// StatusComponentRequest represents a request to create or update a component
+type StatusComponentRequest struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Position    int      `json:"position"`
+	ServiceIDs  []string `json:"serviceIds"`
+}
+
+// StatusOverrideRequest sets a component's status manually
+type StatusOverrideRequest struct {
+	Status  ComponentStatus `json:"status"`
+	Message string          `json:"message"`
+}
+
+// SubscriberType is how a status page subscriber is notified
+type SubscriberType string
+
+const (
+	SubscriberEmail   SubscriberType = "email"
+	SubscriberWebhook SubscriberType = "webhook"
+)
+
+// StatusSubscriber is notified when incidents on its components are
+// created, updated or resolved. No component IDs means all components.
+type StatusSubscriber struct {
+	ID           int64          `json:"id"`
+	Type         SubscriberType `json:"type"`
+	Target       string         `json:"target"` // email address or webhook URL
+	ComponentIDs []string       `json:"componentIds"`
+	Token        string         `json:"token"` // authorizes unsubscribing
+	CreatedAt    time.Time      `json:"createdAt"`
+}
+
+// StatusSubscriberCreateRequest represents a request to add a subscriber
+type StatusSubscriberCreateRequest struct {
+	Type         SubscriberType `json:"type"`
+	Target       string         `json:"target"`
+	ComponentIDs []string       `json:"componentIds"`
+}
+
+// IncidentUpdate is a message posted on an incident, shown on the status
+// page and sent to subscribers
+type IncidentUpdate struct {
+	ID         int64     `json:"id"`
+	IncidentID int64     `json:"incidentId"`
+	Message    string    `json:"message"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// MaintenanceStatus is the phase of an announced maintenance, derived from
+// its start and end
+type MaintenanceStatus string
+
+const (
+	MaintenanceScheduled  MaintenanceStatus = "scheduled"
+	MaintenanceInProgress MaintenanceStatus = "in_progress"
+	MaintenanceCompleted  MaintenanceStatus = "completed"
+)
+
+// StatusMaintenance is maintenance announced on the status page. While in
+// progress its components are under maintenance and the alerts of their
+// services are held.
+type StatusMaintenance struct {
+	ID           int64     `json:"id"`
+	Title        string    `json:"title"`
+	Message      string    `json:"message,omitempty"`
+	ComponentIDs []string  `json:"componentIds"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UpdatedAt    time.Time `json:"updatedAt"`
+
+	// Computed fields (not stored)
+	Status MaintenanceStatus `json:"status,omitempty"`
+}
+
+// StatusAt returns the phase of the maintenance at t
+func (m *StatusMaintenance) StatusAt(t time.Time) MaintenanceStatus {
+	switch {
+	case t.Before(m.StartsAt):
+		return MaintenanceScheduled
+	case t.Before(m.EndsAt):
+		return MaintenanceInProgress
+	default:
+		return MaintenanceCompleted
+	}
+}
+
+// StatusMaintenanceRequest represents a request to announce or update
+// maintenance
+type StatusMaintenanceRequest struct {
+	Title        string    `json:"title"`
+	Message      string    `json:"message"`
+	ComponentIDs []string  `json:"componentIds"`
+	StartsAt     time.Time `json:"startsAt"`
+	EndsAt       time.Time `json:"endsAt"`
+}
+
+// StatusPage is the public view of the status page. Incidents only name
+// the affected components, not services or check errors.
+type StatusPage struct {
+	Title        string                  `json:"title"`
+	Status       ComponentStatus         `json:"status"`
+	Components   []PublicStatusComponent `json:"components"`
+	Incidents    []PublicIncident        `json:"incidents"`
+	Maintenances []PublicMaintenance     `json:"maintenances"`
+	UpdatedAt    time.Time               `json:"updatedAt"`
+}
+
+// PublicStatusComponent is a component as shown on the status page
+type PublicStatusComponent struct {
+	ID          string          `json:"id"`
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Status      ComponentStatus `json:"status"`
+	Message     string          `json:"message,omitempty"`
+}
+
+// PublicIncident is an incident as shown on the status page
+type PublicIncident struct {
+	ID           int64            `json:"id"`
+	Type         IncidentType     `json:"type"`
+	ComponentIDs []string         `json:"componentIds"`
+	StartedAt    time.Time        `json:"startedAt"`
+	ResolvedAt   *time.Time       `json:"resolvedAt,omitempty"`
+	Updates      []IncidentUpdate `json:"updates"`
+}
+
+// PublicMaintenance is an announced maintenance as shown on the status page
+type PublicMaintenance struct {
+	ID           int64             `json:"id"`
+	Title        string            `json:"title"`
+	Message      string            `json:"message,omitempty"`
+	Status       MaintenanceStatus `json:"status"`
+	ComponentIDs []string          `json:"componentIds"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	UpdatedAt    time.Time         `json:"updatedAt"`
+}
@@ -52,6 +52,12 @@ type SystemMetric struct {
 	NetIn     float64   `json:"netIn"`
 	NetOut    float64   `json:"netOut"`
 	CreatedAt time.Time `json:"createdAt"`
+
+	// Ping results since the previous point, nil when the host was not
+	// pinged. Points of hosts that could not be collected carry only these.
+	PingRTT    *float64 `json:"pingRtt,omitempty"`    // average ms, nil when every ping was lost
+	PacketLoss *float64 `json:"packetLoss,omitempty"` // percent
+	PingOnly   bool     `json:"pingOnly,omitempty"`
 }
 
 // SystemMetricPoint represents a time-series point for chart rendering
@@ -79,3 +85,15 @@ type ProcessInfo struct {
 	MemoryBytes uint64 `json:"memoryBytes"`
 	Status      string `json:"status"`
 }
+
+// ProcessSample is the top processes of a host at one time
+type ProcessSample struct {
+	Timestamp string        `json:"timestamp"`
+	Processes []ProcessInfo `json:"processes"`
+}
+
+// ProcessHistory represents the process history response
+type ProcessHistory struct {
+	Range   string          `json:"range"`
+	Samples []ProcessSample `json:"samples"`
+}
@@ -0,0 +1,39 @@
+package models
+
+import "strings"
+
+// Tag is a service tag with the number of services carrying it
+type Tag struct {
+	Name     string `json:"name"`
+	Services int    `json:"services"`
+}
+
+// TagRenameRequest renames a tag on every service
+type TagRenameRequest struct {
+	Name string `json:"name"`
+}
+
+// TagMergeRequest replaces several tags with one on every service
+type TagMergeRequest struct {
+	Tags []string `json:"tags"`
+	Into string   `json:"into"`
+}
+
+// NormalizeTags trims tags and drops empty and repeated ones, keeping the
+// order. nil stays nil.
+func NormalizeTags(tags []string) []string {
+	if tags == nil {
+		return nil
+	}
+	seen := make(map[string]bool, len(tags))
+	list := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		list = append(list, tag)
+	}
+	return list
+}
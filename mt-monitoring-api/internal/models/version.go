@@ -0,0 +1,13 @@
+package models
+
+// DataVersion marks the state of the data read responses are computed
+// from, for their ETags. Each part changes whenever its data does; the
+// values mean nothing beyond that.
+type DataVersion struct {
+	Services    string // services and their channels
+	Hosts       string
+	Checks      string // service check results
+	HostMetrics string
+	Incidents   string
+	Windows     string // pause and maintenance windows
+}
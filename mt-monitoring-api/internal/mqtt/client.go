@@ -0,0 +1,252 @@
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// MQTT 3.1.1 control packet types (upper nibble of the fixed header)
+const (
+	packetConnect    = 1
+	packetConnack    = 2
+	packetPublish    = 3
+	packetPuback     = 4
+	packetPingreq    = 12
+	packetPingresp   = 13
+	packetDisconnect = 14
+)
+
+// ackTimeout bounds the wait for CONNACK, PUBACK and PINGRESP
+const ackTimeout = 10 * time.Second
+
+// conn is a connection to an MQTT 3.1.1 broker. Only what a publisher
+// needs is implemented: CONNECT, PUBLISH at QoS 0 and 1, PINGREQ and
+// DISCONNECT. It is used from one goroutine and never subscribes, so the
+// broker only ever sends answers to our own requests.
+type conn struct {
+	nc       net.Conn
+	r        *bufio.Reader
+	packetID uint16
+}
+
+// dial connects and logs in to the broker
+func dial(cfg config.MQTTConfig) (*conn, error) {
+	u, err := url.Parse(cfg.Broker)
+	if err != nil {
+		return nil, err
+	}
+
+	dialer := &net.Dialer{Timeout: ackTimeout}
+	var nc net.Conn
+	switch u.Scheme {
+	case "tcp", "mqtt":
+		nc, err = dialer.Dial("tcp", hostPort(u, "1883"))
+	case "ssl", "tls", "mqtts":
+		nc, err = tls.DialWithDialer(dialer, "tcp", hostPort(u, "8883"), &tls.Config{ServerName: u.Hostname()})
+	default:
+		return nil, fmt.Errorf("unsupported broker scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	c := &conn{nc: nc, r: bufio.NewReader(nc)}
+	if err := c.connect(cfg); err != nil {
+		nc.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func hostPort(u *url.URL, defaultPort string) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	return net.JoinHostPort(u.Hostname(), defaultPort)
+}
+
+// connect sends CONNECT with a clean session and checks the CONNACK
+func (c *conn) connect(cfg config.MQTTConfig) error {
+	var body []byte
+	body = appendString(body, "MQTT")
+	body = append(body, 4) // protocol level 3.1.1
+
+	flags := byte(0x02) // clean session
+	if cfg.Username != "" {
+		flags |= 0x80
+		if cfg.Password != "" {
+			flags |= 0x40
+		}
+	}
+	body = append(body, flags)
+	body = binary.BigEndian.AppendUint16(body, uint16(cfg.KeepAlive))
+
+	body = appendString(body, cfg.ClientID)
+	if cfg.Username != "" {
+		body = appendString(body, cfg.Username)
+		if cfg.Password != "" {
+			body = appendString(body, cfg.Password)
+		}
+	}
+
+	if err := c.write(packetConnect<<4, body); err != nil {
+		return err
+	}
+	packet, payload, err := c.read()
+	if err != nil {
+		return err
+	}
+	if packet != packetConnack || len(payload) != 2 {
+		return fmt.Errorf("expected CONNACK, got packet type %d", packet)
+	}
+	if code := payload[1]; code != 0 {
+		return fmt.Errorf("connection refused: %s", connackReason(code))
+	}
+	return nil
+}
+
+// publish sends a message. At QoS 1 it waits for the broker's PUBACK.
+func (c *conn) publish(topic string, payload []byte, qos int, retain bool) error {
+	header := byte(packetPublish<<4) | byte(qos)<<1
+	if retain {
+		header |= 0x01
+	}
+
+	body := appendString(nil, topic)
+	var id uint16
+	if qos > 0 {
+		c.packetID++
+		if c.packetID == 0 {
+			c.packetID = 1
+		}
+		id = c.packetID
+		body = binary.BigEndian.AppendUint16(body, id)
+	}
+	body = append(body, payload...)
+
+	if err := c.write(header, body); err != nil {
+		return err
+	}
+	if qos == 0 {
+		return nil
+	}
+
+	for {
+		packet, ack, err := c.read()
+		if err != nil {
+			return err
+		}
+		if packet == packetPuback && len(ack) == 2 && binary.BigEndian.Uint16(ack) == id {
+			return nil
+		}
+	}
+}
+
+// ping sends PINGREQ and waits for PINGRESP, keeping the session alive
+func (c *conn) ping() error {
+	if err := c.write(packetPingreq<<4, nil); err != nil {
+		return err
+	}
+	for {
+		packet, _, err := c.read()
+		if err != nil {
+			return err
+		}
+		if packet == packetPingresp {
+			return nil
+		}
+	}
+}
+
+// close sends DISCONNECT and closes the connection
+func (c *conn) close() {
+	c.nc.SetWriteDeadline(time.Now().Add(time.Second))
+	c.write(packetDisconnect<<4, nil)
+	c.nc.Close()
+}
+
+// write sends a packet: fixed header, remaining length and body
+func (c *conn) write(header byte, body []byte) error {
+	if len(body) > 268435455 {
+		return errors.New("packet too large")
+	}
+	packet := []byte{header}
+	for n := len(body); ; {
+		b := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			b |= 0x80
+		}
+		packet = append(packet, b)
+		if n == 0 {
+			break
+		}
+	}
+	packet = append(packet, body...)
+
+	c.nc.SetWriteDeadline(time.Now().Add(ackTimeout))
+	_, err := c.nc.Write(packet)
+	return err
+}
+
+// read receives a packet and returns its type and body
+func (c *conn) read() (byte, []byte, error) {
+	c.nc.SetReadDeadline(time.Now().Add(ackTimeout))
+
+	header, err := c.r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	length, multiplier := 0, 1
+	for i := 0; ; i++ {
+		b, err := c.r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		length += int(b&0x7f) * multiplier
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("malformed remaining length")
+		}
+		multiplier *= 128
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(c.r, body); err != nil {
+		return 0, nil, err
+	}
+	return header >> 4, body, nil
+}
+
+// appendString appends a length-prefixed UTF-8 string
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
+
+func connackReason(code byte) string {
+	switch code {
+	case 1:
+		return "unacceptable protocol version"
+	case 2:
+		return "client identifier rejected"
+	case 3:
+		return "server unavailable"
+	case 4:
+		return "bad user name or password"
+	case 5:
+		return "not authorized"
+	}
+	return fmt.Sprintf("return code %d", code)
+}
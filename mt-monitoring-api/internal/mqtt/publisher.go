@@ -0,0 +1,293 @@
+// Package mqtt publishes service status changes, incidents and alerts to
+// an MQTT broker so home-automation and edge systems can react to them.
+// Messages are JSON. Publishing never blocks checks: messages are queued
+// and sent by a background connection that reconnects on its own.
+package mqtt
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/events"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Incident events
+const (
+	IncidentCreated  = "created"
+	IncidentResolved = "resolved"
+)
+
+const maxReconnectDelay = 2 * time.Minute
+
+// StatusMessage is published when a service changes status. It is
+// retained when mqtt.retainStatus is set, so new subscribers get the
+// current status right away.
+type StatusMessage struct {
+	ServiceID      string               `json:"serviceId"`
+	ServiceName    string               `json:"serviceName"`
+	Status         models.ServiceStatus `json:"status"`
+	PreviousStatus models.ServiceStatus `json:"previousStatus,omitempty"`
+	Message        string               `json:"message,omitempty"`
+	Time           time.Time            `json:"time"`
+}
+
+// IncidentMessage is published when an incident opens or resolves
+type IncidentMessage struct {
+	Event string `json:"event"` // "created" | "resolved"
+	models.Incident
+}
+
+// AlertMessage is published for resource, endpoint, log, system and
+// Alertmanager alerts
+type AlertMessage struct {
+	Type        string    `json:"type"`
+	Severity    string    `json:"severity,omitempty"`
+	Status      string    `json:"status,omitempty"`
+	HostID      string    `json:"hostId,omitempty"`
+	HostName    string    `json:"hostName,omitempty"`
+	ServiceID   string    `json:"serviceId,omitempty"`
+	ServiceName string    `json:"serviceName,omitempty"`
+	Metric      string    `json:"metric,omitempty"`
+	Value       float64   `json:"value,omitempty"`
+	Threshold   float64   `json:"threshold,omitempty"`
+	Message     string    `json:"message"`
+	Time        time.Time `json:"time"`
+}
+
+// message is a queued publication
+type message struct {
+	topic   string
+	payload []byte
+	retain  bool
+}
+
+// Publisher sends queued messages to the broker over one connection
+type Publisher struct {
+	cfg    config.MQTTConfig
+	queue  chan message
+	stopCh chan struct{}
+	done   chan struct{}
+
+	dropped atomic.Int64
+}
+
+// current is the publisher installed by Setup, nil while disabled
+var current atomic.Pointer[Publisher]
+
+// Enabled reports whether messages are being published
+func Enabled() bool {
+	return current.Load() != nil
+}
+
+// Setup starts publishing when mqtt.enabled is set. The server entry point
+// calls it at startup and the returned shutdown on exit, which sends what
+// is still queued if connected. Without MQTT shutdown does nothing.
+func Setup() (shutdown func(context.Context) error) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.MQTT.Enabled {
+		return func(context.Context) error { return nil }
+	}
+
+	queueSize := cfg.MQTT.QueueSize
+	if queueSize <= 0 {
+		queueSize = 1000
+	}
+	p := &Publisher{
+		cfg:    cfg.MQTT,
+		queue:  make(chan message, queueSize),
+		stopCh: make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	current.Store(p)
+	unsubscribe := subscribe()
+	log.Printf("MQTT publishing enabled (broker %s, QoS %d)", cfg.MQTT.Broker, cfg.MQTT.QoS)
+
+	var once sync.Once
+	return func(ctx context.Context) error {
+		once.Do(func() {
+			unsubscribe()
+			current.CompareAndSwap(p, nil)
+			close(p.stopCh)
+		})
+		select {
+		case <-p.done:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// subscribe publishes status changes and incidents from the event bus
+func subscribe() (unsubscribe func()) {
+	unsubscribes := []func(){
+		events.CheckCompleted.Subscribe(func(c events.Check) {
+			if c.Status != c.PrevStatus {
+				PublishStatus(c.Service, c.Status, c.PrevStatus, c.Metric.ErrorMessage)
+			}
+		}),
+		events.IncidentCreated.Subscribe(func(incident *models.Incident) {
+			PublishIncident(IncidentCreated, incident)
+		}),
+		events.IncidentResolved.Subscribe(func(incident *models.Incident) {
+			PublishIncident(IncidentResolved, incident)
+		}),
+	}
+	return func() {
+		for _, unsubscribe := range unsubscribes {
+			unsubscribe()
+		}
+	}
+}
+
+// PublishStatus publishes a status change of a service
+func PublishStatus(service *models.Service, status, previous models.ServiceStatus, message string) {
+	p := current.Load()
+	if p == nil || p.cfg.Topics.Status == "" {
+		return
+	}
+	p.enqueue(topic(p.cfg.Topics.Status, service.ID, "", ""), StatusMessage{
+		ServiceID:      service.ID,
+		ServiceName:    service.Name,
+		Status:         status,
+		PreviousStatus: previous,
+		Message:        message,
+		Time:           time.Now(),
+	}, p.cfg.RetainStatus)
+}
+
+// PublishIncident publishes an incident event
+func PublishIncident(event string, incident *models.Incident) {
+	p := current.Load()
+	if p == nil || p.cfg.Topics.Incidents == "" {
+		return
+	}
+	p.enqueue(topic(p.cfg.Topics.Incidents, incident.ServiceID, "", ""), IncidentMessage{
+		Event:    event,
+		Incident: *incident,
+	}, false)
+}
+
+// PublishAlert publishes an alert
+func PublishAlert(alert AlertMessage) {
+	p := current.Load()
+	if p == nil || p.cfg.Topics.Alerts == "" {
+		return
+	}
+	p.enqueue(topic(p.cfg.Topics.Alerts, alert.ServiceID, alert.HostID, alert.Type), alert, false)
+}
+
+// topic fills in the placeholders of a configured topic. IDs are user
+// defined, so characters with a meaning in topic names are replaced.
+func topic(pattern, serviceID, hostID, alertType string) string {
+	clean := strings.NewReplacer("/", "_", "+", "_", "#", "_")
+	return strings.NewReplacer(
+		"{serviceId}", clean.Replace(serviceID),
+		"{hostId}", clean.Replace(hostID),
+		"{type}", clean.Replace(alertType),
+	).Replace(pattern)
+}
+
+// enqueue adds a message without blocking; a full queue drops it
+func (p *Publisher) enqueue(topic string, v interface{}, retain bool) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		log.Printf("[MQTT] Failed to encode message for %s: %v", topic, err)
+		return
+	}
+	select {
+	case p.queue <- message{topic: topic, payload: payload, retain: retain}:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// run keeps a connection to the broker and sends queued messages in order.
+// A message whose publish failed is sent again after reconnecting.
+func (p *Publisher) run() {
+	defer close(p.done)
+
+	var keepAlive <-chan time.Time
+	if p.cfg.KeepAlive > 0 {
+		ticker := time.NewTicker(time.Duration(p.cfg.KeepAlive) * time.Second * 3 / 4)
+		defer ticker.Stop()
+		keepAlive = ticker.C
+	}
+	report := time.NewTicker(time.Minute)
+	defer report.Stop()
+
+	var c *conn
+	var pending *message
+	delay := time.Second
+	for {
+		if c == nil {
+			var err error
+			if c, err = dial(p.cfg); err != nil {
+				log.Printf("[MQTT] Failed to connect to %s: %v (retrying in %v)", p.cfg.Broker, err, delay)
+				select {
+				case <-time.After(delay):
+				case <-p.stopCh:
+					return
+				}
+				delay = min(delay*2, maxReconnectDelay)
+				continue
+			}
+			delay = time.Second
+			log.Printf("[MQTT] Connected to %s", p.cfg.Broker)
+		}
+
+		if pending == nil {
+			select {
+			case m := <-p.queue:
+				pending = &m
+			case <-keepAlive:
+				if err := c.ping(); err != nil {
+					log.Printf("[MQTT] Keepalive failed: %v", err)
+					c.nc.Close()
+					c = nil
+				}
+				continue
+			case <-report.C:
+				if n := p.dropped.Swap(0); n > 0 {
+					log.Printf("[MQTT] Queue full, dropped %d messages", n)
+				}
+				continue
+			case <-p.stopCh:
+				p.drain(c)
+				c.close()
+				return
+			}
+		}
+
+		if err := c.publish(pending.topic, pending.payload, p.cfg.QoS, pending.retain); err != nil {
+			log.Printf("[MQTT] Failed to publish to %s: %v", pending.topic, err)
+			c.nc.Close()
+			c = nil
+			continue
+		}
+		pending = nil
+	}
+}
+
+// drain sends the messages still queued at shutdown
+func (p *Publisher) drain(c *conn) {
+	for {
+		select {
+		case m := <-p.queue:
+			if err := c.publish(m.topic, m.payload, p.cfg.QoS, m.retain); err != nil {
+				log.Printf("[MQTT] Failed to publish to %s: %v", m.topic, err)
+				return
+			}
+		default:
+			return
+		}
+	}
+}
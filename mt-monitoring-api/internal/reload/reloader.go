@@ -0,0 +1,188 @@
+package reload
+
+import (
+	"errors"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/mt-monitoring/api/internal/checker"
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// debounce coalesces the burst of events editors produce on save
+const debounce = 500 * time.Millisecond
+
+// Result describes what a reload changed
+type Result struct {
+	Services           checker.ServiceChanges `json:"services"`
+	CollectorIntervals bool                   `json:"collectorIntervals"`
+}
+
+// Reloader re-reads the configuration on SIGHUP or when a config file
+// changes, and applies service and collector changes to the running
+// scheduler and collector manager. Settings read through config.Get() at
+// use time (alerts, retention, backups, ...) pick up the new values without
+// further action.
+type Reloader struct {
+	scheduler    *checker.Scheduler
+	collectorMgr *collector.CollectorManager
+
+	mu      sync.Mutex // one reload at a time
+	watcher *fsnotify.Watcher
+	watched map[string]bool
+	signals chan os.Signal
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewReloader creates a new reloader. collectorMgr may be nil when system
+// metric collection is disabled.
+func NewReloader(scheduler *checker.Scheduler, collectorMgr *collector.CollectorManager) *Reloader {
+	return &Reloader{
+		scheduler:    scheduler,
+		collectorMgr: collectorMgr,
+		watched:      make(map[string]bool),
+		signals:      make(chan os.Signal, 1),
+		stopCh:       make(chan struct{}),
+	}
+}
+
+// Start listens for SIGHUP and watches the config directories. A watcher
+// that cannot be created is logged and SIGHUP keeps working.
+func (r *Reloader) Start() {
+	signal.Notify(r.signals, syscall.SIGHUP)
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		log.Printf("[Reload] File watching disabled: %v", err)
+	} else {
+		r.watcher = watcher
+		r.watchConfigDirs()
+	}
+
+	r.wg.Add(1)
+	go r.run()
+	log.Println("Config reloader started (SIGHUP or file change)")
+}
+
+// Stop stops listening for reload triggers
+func (r *Reloader) Stop() {
+	signal.Stop(r.signals)
+	close(r.stopCh)
+	r.wg.Wait()
+	if r.watcher != nil {
+		r.watcher.Close()
+	}
+}
+
+func (r *Reloader) run() {
+	defer r.wg.Done()
+
+	var events <-chan fsnotify.Event
+	var errs <-chan error
+	if r.watcher != nil {
+		events = r.watcher.Events
+		errs = r.watcher.Errors
+	}
+
+	timer := time.NewTimer(debounce)
+	timer.Stop()
+
+	for {
+		select {
+		case <-r.signals:
+			log.Println("[Reload] SIGHUP received")
+			r.reloadAndLog()
+		case ev := <-events:
+			if isConfigFile(ev.Name) && ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) != 0 {
+				timer.Reset(debounce)
+			}
+		case err := <-errs:
+			log.Printf("[Reload] Watcher error: %v", err)
+		case <-timer.C:
+			log.Println("[Reload] Config file changed")
+			r.reloadAndLog()
+		case <-r.stopCh:
+			timer.Stop()
+			return
+		}
+	}
+}
+
+func (r *Reloader) reloadAndLog() {
+	result, err := r.Reload()
+	if err != nil {
+		log.Printf("[Reload] Failed, keeping previous config: %v", err)
+		return
+	}
+	s := result.Services
+	log.Printf("[Reload] Config reloaded (services added: %d, updated: %d, removed: %d)",
+		len(s.Added), len(s.Updated), len(s.Removed))
+}
+
+// Reload re-reads the configuration and applies it
+func (r *Reloader) Reload() (*Result, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	old := config.Get()
+	if old == nil {
+		return nil, errors.New("config not initialized")
+	}
+	updated, err := config.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	result.Services, err = r.scheduler.ApplyServices(old.Services, updated.Services)
+	if err != nil {
+		return result, err
+	}
+
+	if r.collectorMgr != nil &&
+		(old.System.CollectInterval != updated.System.CollectInterval ||
+			old.System.StoreInterval != updated.System.StoreInterval) {
+		r.collectorMgr.SetIntervals(updated.System.CollectInterval, updated.System.StoreInterval)
+		result.CollectorIntervals = true
+	}
+
+	// Includes may point at new directories
+	r.watchConfigDirs()
+	return result, nil
+}
+
+// watchConfigDirs adds watches for config directories not yet watched.
+// Directories are watched rather than files so editors that replace the
+// file on save are still noticed.
+func (r *Reloader) watchConfigDirs() {
+	if r.watcher == nil {
+		return
+	}
+	for _, dir := range config.WatchDirs() {
+		if r.watched[dir] {
+			continue
+		}
+		if err := r.watcher.Add(dir); err != nil {
+			log.Printf("[Reload] Failed to watch %s: %v", dir, err)
+			continue
+		}
+		r.watched[dir] = true
+	}
+}
+
+// isConfigFile filters out events for unrelated files in watched directories
+func isConfigFile(name string) bool {
+	switch filepath.Ext(name) {
+	case ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
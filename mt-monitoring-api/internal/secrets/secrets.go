@@ -0,0 +1,147 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// Reference prefixes for the supported secret stores
+const (
+	PrefixVault = "vault:"
+	PrefixAWS   = "aws:"
+)
+
+var ErrInvalidRef = errors.New(`secret reference must be "vault:<path>" or "aws:<secret-id>"`)
+
+// Credentials are SSH credentials read from a secret store. A secret holds
+// a JSON object with "password" and/or "privateKey" (plus an optional
+// "username"); a plain-text AWS secret is taken as a PEM key when it looks
+// like one and as a password otherwise.
+type Credentials struct {
+	Username   string
+	Password   string
+	PrivateKey string
+}
+
+// Equal reports whether two credential sets are identical
+func (c *Credentials) Equal(other *Credentials) bool {
+	return other != nil && *c == *other
+}
+
+// AuthMethods builds SSH auth methods, preferring the private key
+func (c *Credentials) AuthMethods() ([]ssh.AuthMethod, error) {
+	var methods []ssh.AuthMethod
+	if c.PrivateKey != "" {
+		signer, err := ssh.ParsePrivateKey([]byte(c.PrivateKey))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSH key from secret: %w", err)
+		}
+		methods = append(methods, ssh.PublicKeys(signer))
+	}
+	if c.Password != "" {
+		methods = append(methods, ssh.Password(c.Password))
+	}
+	if len(methods) == 0 {
+		return nil, errors.New("secret has neither password nor privateKey")
+	}
+	return methods, nil
+}
+
+// ValidRef reports whether ref names a supported secret store and a path
+func ValidRef(ref string) bool {
+	_, _, err := splitRef(ref)
+	return err == nil
+}
+
+// Fetch reads SSH credentials for a reference such as
+// "vault:secret/data/hosts/web-1" or "aws:prod/ssh/web-1"
+func Fetch(ctx context.Context, ref string) (*Credentials, error) {
+	prefix, path, err := splitRef(ref)
+	if err != nil {
+		return nil, err
+	}
+
+	cfg := secretsConfig()
+	var fields map[string]string
+	switch prefix {
+	case PrefixVault:
+		fields, err = fetchVault(ctx, cfg.Vault, path)
+	case PrefixAWS:
+		fields, err = fetchAWS(ctx, cfg.AWS, path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", ref, err)
+	}
+	return credentialsFrom(fields), nil
+}
+
+// RefreshInterval returns how often referenced secrets are re-fetched
+func RefreshInterval() time.Duration {
+	return time.Duration(secretsConfig().RefreshInterval) * time.Second
+}
+
+func splitRef(ref string) (prefix, path string, err error) {
+	for _, p := range []string{PrefixVault, PrefixAWS} {
+		if strings.HasPrefix(ref, p) {
+			path = strings.TrimSpace(strings.TrimPrefix(ref, p))
+			if path == "" {
+				return "", "", ErrInvalidRef
+			}
+			return p, path, nil
+		}
+	}
+	return "", "", ErrInvalidRef
+}
+
+// credentialsFrom maps secret fields to credentials, accepting the common
+// spellings of each key
+func credentialsFrom(fields map[string]string) *Credentials {
+	pick := func(keys ...string) string {
+		for _, k := range keys {
+			if v := fields[k]; v != "" {
+				return v
+			}
+		}
+		return ""
+	}
+	return &Credentials{
+		Username:   pick("username", "user"),
+		Password:   pick("password"),
+		PrivateKey: pick("privateKey", "private_key", "key"),
+	}
+}
+
+// decodeFields decodes a JSON object of strings, ignoring non-string values
+func decodeFields(data []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	fields := make(map[string]string, len(raw))
+	for k, v := range raw {
+		if s, ok := v.(string); ok {
+			fields[k] = s
+		}
+	}
+	return fields, nil
+}
+
+// secretsConfig returns the secrets configuration with defaults applied
+func secretsConfig() config.SecretsConfig {
+	var cfg config.SecretsConfig
+	if c := config.Get(); c != nil {
+		cfg = c.Secrets
+	}
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = 300
+	}
+	return cfg
+}
@@ -0,0 +1,81 @@
+package secrets
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+var httpClient = &http.Client{Timeout: 15 * time.Second}
+
+// fetchVault reads a secret through the Vault HTTP API. The path is used
+// as-is, so KV v2 paths include the "data/" segment
+// (e.g. secret/data/hosts/web-1); both KV versions are understood.
+func fetchVault(ctx context.Context, cfg config.VaultConfig, path string) (map[string]string, error) {
+	addr := cfg.Address
+	if addr == "" {
+		addr = os.Getenv("VAULT_ADDR")
+	}
+	token := cfg.Token
+	if token == "" {
+		token = os.Getenv("VAULT_TOKEN")
+	}
+	if addr == "" || token == "" {
+		return nil, errors.New("vault address and token are not configured")
+	}
+
+	target := strings.TrimSuffix(addr, "/") + "/v1/" + strings.TrimPrefix(path, "/")
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if cfg.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", cfg.Namespace)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("vault returned status %d", resp.StatusCode)
+	}
+
+	var envelope struct {
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid vault response: %w", err)
+	}
+
+	// KV v2 nests the secret under data.data next to data.metadata
+	var v2 struct {
+		Data     json.RawMessage `json:"data"`
+		Metadata json.RawMessage `json:"metadata"`
+	}
+	data := envelope.Data
+	if err := json.Unmarshal(data, &v2); err == nil && v2.Data != nil && v2.Metadata != nil {
+		data = v2.Data
+	}
+
+	fields, err := decodeFields(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault secret: %w", err)
+	}
+	return fields, nil
+}
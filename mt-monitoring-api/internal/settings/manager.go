@@ -0,0 +1,149 @@
+package settings
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"github.com/mt-monitoring/api/internal/collector"
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+)
+
+// ErrInvalidSetting wraps errors for unknown keys and invalid values
+var ErrInvalidSetting = errors.New("invalid setting")
+
+// InvalidSettingError is returned by Update for an unknown key or an
+// invalid value. It matches ErrInvalidSetting with errors.Is.
+type InvalidSettingError struct {
+	Key    string
+	Reason string
+}
+
+func (e *InvalidSettingError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrInvalidSetting, e.Reason)
+}
+
+func (e *InvalidSettingError) Unwrap() error { return ErrInvalidSetting }
+
+// Setting describes a runtime setting and where its value comes from
+type Setting struct {
+	config.RuntimeSetting
+	Value      interface{} `json:"value"`
+	Default    interface{} `json:"default"` // from the config file or environment
+	Overridden bool        `json:"overridden"`
+}
+
+// Manager persists runtime settings in the database and applies them to
+// the running configuration. The scheduler, alerting and retention cleanup
+// read config.Get() at use time; collector intervals are pushed to the
+// collector manager.
+type Manager struct {
+	repo         database.SettingRepository
+	collectorMgr *collector.CollectorManager
+	mu           sync.Mutex // one update at a time
+}
+
+// NewManager creates a new settings manager. collectorMgr may be nil when
+// system metric collection is disabled.
+func NewManager(store *database.Store, collectorMgr *collector.CollectorManager) *Manager {
+	return &Manager{repo: store.Settings, collectorMgr: collectorMgr}
+}
+
+// Load applies the stored settings on top of the loaded configuration.
+// Call it after config.Load and before starting the collectors. Stored
+// values that are unknown or no longer valid are skipped.
+func (m *Manager) Load(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	stored, err := m.repo.GetAll(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load settings: %w", err)
+	}
+
+	values := make(map[string]string, len(stored))
+	for key, value := range stored {
+		s, ok := config.LookupSetting(key)
+		if !ok {
+			log.Printf("Ignoring unknown stored setting %q", key)
+			continue
+		}
+		if err := s.Validate(value); err != nil {
+			log.Printf("Ignoring invalid stored setting: %v", err)
+			continue
+		}
+		values[key] = value
+	}
+
+	if _, err := config.SetOverrides(values); err != nil {
+		return err
+	}
+	if len(values) > 0 {
+		log.Printf("Applied %d runtime setting(s) from the database", len(values))
+	}
+	return nil
+}
+
+// Update validates, stores and applies settings. A key mapped to an empty
+// string resets it to the config file value. Nothing is applied when a
+// value is invalid or cannot be stored.
+func (m *Manager) Update(ctx context.Context, values map[string]string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, value := range values {
+		s, ok := config.LookupSetting(key)
+		if !ok {
+			return &InvalidSettingError{Key: key, Reason: fmt.Sprintf("unknown setting %q", key)}
+		}
+		if value == "" {
+			continue
+		}
+		if err := s.Validate(value); err != nil {
+			return &InvalidSettingError{Key: key, Reason: err.Error()}
+		}
+	}
+
+	old := config.Get()
+	if err := m.repo.Set(ctx, values); err != nil {
+		return fmt.Errorf("failed to save settings: %w", err)
+	}
+	updated, err := config.SetOverrides(values)
+	if err != nil {
+		return err
+	}
+
+	if m.collectorMgr != nil && old != nil &&
+		(old.System.CollectInterval != updated.System.CollectInterval ||
+			old.System.StoreInterval != updated.System.StoreInterval) {
+		m.collectorMgr.SetIntervals(updated.System.CollectInterval, updated.System.StoreInterval)
+	}
+	return nil
+}
+
+// List returns every runtime setting with its effective and default value
+func (m *Manager) List() []Setting {
+	current := config.Get()
+	defaults := config.Defaults()
+	if current == nil || defaults == nil {
+		return []Setting{}
+	}
+	overrides := config.Overrides()
+
+	list := make([]Setting, 0, len(config.RuntimeSettings()))
+	for _, s := range config.RuntimeSettings() {
+		_, overridden := overrides[s.Key]
+		list = append(list, Setting{
+			RuntimeSetting: s,
+			Value:          s.Value(current),
+			Default:        s.Value(defaults),
+			Overridden:     overridden,
+		})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Key < list[j].Key })
+	return list
+}
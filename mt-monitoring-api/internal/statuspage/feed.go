@@ -0,0 +1,302 @@
+package statuspage
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// feedEntry is an incident or maintenance as a feed item or calendar event
+type feedEntry struct {
+	id      string // "incident-12", "maintenance-3"
+	title   string
+	summary string
+	start   time.Time
+	end     *time.Time
+	updated time.Time
+}
+
+// entries returns the incidents and maintenances of the page, most
+// recently updated first
+func entries(page *models.StatusPage) []feedEntry {
+	names := make(map[string]string, len(page.Components))
+	for _, c := range page.Components {
+		names[c.ID] = c.Name
+	}
+	componentNames := func(ids []string) string {
+		list := make([]string, len(ids))
+		for i, id := range ids {
+			list[i] = names[id]
+		}
+		return strings.Join(list, ", ")
+	}
+
+	var list []feedEntry
+	for _, incident := range page.Incidents {
+		e := feedEntry{
+			id:      "incident-" + strconv.FormatInt(incident.ID, 10),
+			start:   incident.StartedAt,
+			end:     incident.ResolvedAt,
+			updated: incident.StartedAt,
+		}
+		kind := "Outage"
+		if incident.Type == models.IncidentTypeDegraded {
+			kind = "Degraded performance"
+		}
+		e.title = kind + ": " + componentNames(incident.ComponentIDs)
+
+		var summary strings.Builder
+		fmt.Fprintf(&summary, "Started %s.", incident.StartedAt.UTC().Format(time.RFC1123))
+		for _, u := range incident.Updates {
+			fmt.Fprintf(&summary, "\n%s: %s", u.CreatedAt.UTC().Format(time.RFC1123), u.Message)
+			if u.CreatedAt.After(e.updated) {
+				e.updated = u.CreatedAt
+			}
+		}
+		if incident.ResolvedAt != nil {
+			e.title = "Resolved: " + e.title
+			fmt.Fprintf(&summary, "\nResolved %s.", incident.ResolvedAt.UTC().Format(time.RFC1123))
+			if incident.ResolvedAt.After(e.updated) {
+				e.updated = *incident.ResolvedAt
+			}
+		}
+		e.summary = summary.String()
+		list = append(list, e)
+	}
+
+	for _, m := range page.Maintenances {
+		end := m.EndsAt
+		e := feedEntry{
+			id:      "maintenance-" + strconv.FormatInt(m.ID, 10),
+			title:   "Maintenance: " + m.Title,
+			start:   m.StartsAt,
+			end:     &end,
+			updated: m.UpdatedAt,
+		}
+		// Announced when saved, updated again when it starts and ends
+		switch m.Status {
+		case models.MaintenanceScheduled:
+			e.title = "Scheduled maintenance: " + m.Title
+		case models.MaintenanceInProgress:
+			if m.StartsAt.After(e.updated) {
+				e.updated = m.StartsAt
+			}
+		case models.MaintenanceCompleted:
+			e.title = "Completed maintenance: " + m.Title
+			if m.EndsAt.After(e.updated) {
+				e.updated = m.EndsAt
+			}
+		}
+		e.summary = fmt.Sprintf("%s from %s to %s.", componentNames(m.ComponentIDs),
+			m.StartsAt.UTC().Format(time.RFC1123), m.EndsAt.UTC().Format(time.RFC1123))
+		if m.Message != "" {
+			e.summary = m.Message + "\n" + e.summary
+		}
+		list = append(list, e)
+	}
+
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].updated.After(list[j].updated)
+	})
+	return list
+}
+
+// publicURL returns the configured public URL of the page without a
+// trailing slash
+func publicURL() string {
+	if cfg := config.Get(); cfg != nil {
+		return strings.TrimRight(cfg.StatusPage.PublicURL, "/")
+	}
+	return ""
+}
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+}
+
+type rssGUID struct {
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+// RSS renders the incidents and maintenances of the page as an RSS 2.0 feed
+func RSS(page *models.StatusPage) ([]byte, error) {
+	link := publicURL()
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         page.Title,
+			Link:          link,
+			Description:   "Incidents and maintenance of " + page.Title,
+			LastBuildDate: page.UpdatedAt.UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, e := range entries(page) {
+		item := rssItem{
+			Title:       e.title,
+			Description: e.summary,
+			GUID:        rssGUID{Value: "urn:mt-monitoring:status:" + e.id},
+			PubDate:     e.updated.UTC().Format(time.RFC1123Z),
+		}
+		if link != "" {
+			item.Link = link + "#" + e.id
+		}
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return marshalXML(feed)
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Updated   string     `xml:"updated"`
+	Published string     `xml:"published"`
+	Links     []atomLink `xml:"link"`
+	Summary   string     `xml:"summary"`
+}
+
+// Atom renders the incidents and maintenances of the page as an Atom feed
+func Atom(page *models.StatusPage) ([]byte, error) {
+	link := publicURL()
+	feed := atomFeed{
+		ID:      "urn:mt-monitoring:status",
+		Title:   page.Title,
+		Updated: page.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+	if link != "" {
+		feed.Links = []atomLink{
+			{Href: link},
+			{Href: link + "/api/v1/status/feed.atom", Rel: "self"},
+		}
+	}
+	for _, e := range entries(page) {
+		entry := atomEntry{
+			ID:        "urn:mt-monitoring:status:" + e.id,
+			Title:     e.title,
+			Updated:   e.updated.UTC().Format(time.RFC3339),
+			Published: e.start.UTC().Format(time.RFC3339),
+			Summary:   e.summary,
+		}
+		if link != "" {
+			entry.Links = []atomLink{{Href: link + "#" + e.id}}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return marshalXML(feed)
+}
+
+func marshalXML(v interface{}) ([]byte, error) {
+	body, err := xml.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+// ICal renders the incidents and maintenances of the page as an iCalendar
+// (RFC 5545) calendar. Open incidents end at the time of rendering.
+func ICal(page *models.StatusPage) []byte {
+	host := "mt-monitoring"
+	if u, err := url.Parse(publicURL()); err == nil && u.Host != "" {
+		host = u.Host
+	}
+	stamp := icalTime(page.UpdatedAt)
+
+	var b bytes.Buffer
+	line := func(name, value string) {
+		icalLine(&b, name+":"+value)
+	}
+	line("BEGIN", "VCALENDAR")
+	line("VERSION", "2.0")
+	line("PRODID", "-//mt-monitoring//Status Page//EN")
+	line("CALSCALE", "GREGORIAN")
+	line("METHOD", "PUBLISH")
+	line("X-WR-CALNAME", icalEscape(page.Title))
+	for _, e := range entries(page) {
+		end := page.UpdatedAt
+		if e.end != nil {
+			end = *e.end
+		}
+		line("BEGIN", "VEVENT")
+		line("UID", e.id+"@"+host)
+		line("DTSTAMP", stamp)
+		line("DTSTART", icalTime(e.start))
+		line("DTEND", icalTime(end))
+		line("LAST-MODIFIED", icalTime(e.updated))
+		line("SUMMARY", icalEscape(e.title))
+		line("DESCRIPTION", icalEscape(e.summary))
+		if link := publicURL(); link != "" {
+			line("URL", link+"#"+e.id)
+		}
+		line("END", "VEVENT")
+	}
+	line("END", "VCALENDAR")
+	return b.Bytes()
+}
+
+func icalTime(t time.Time) string {
+	return t.UTC().Format("20060102T150405Z")
+}
+
+// icalEscape escapes a TEXT value
+func icalEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+// icalLine writes a content line folded at 75 octets without splitting
+// UTF-8 sequences
+func icalLine(b *bytes.Buffer, s string) {
+	limit := 75
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && s[cut]&0xC0 == 0x80 {
+			cut--
+		}
+		b.WriteString(s[:cut])
+		b.WriteString("\r\n ")
+		s = s[cut:]
+		limit = 74 // the leading space of a continuation line counts
+	}
+	b.WriteString(s)
+	b.WriteString("\r\n")
+}
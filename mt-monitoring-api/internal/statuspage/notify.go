@@ -0,0 +1,209 @@
+package statuspage
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// Incident events sent to subscribers
+const (
+	EventCreated  = "created"
+	EventUpdated  = "updated"
+	EventResolved = "resolved"
+)
+
+// Notification is the JSON body posted to webhook subscribers
+type Notification struct {
+	Event          string                         `json:"event"` // "created" | "updated" | "resolved"
+	Page           string                         `json:"page"`
+	IncidentID     int64                          `json:"incidentId"`
+	IncidentType   models.IncidentType            `json:"incidentType"`
+	StartedAt      time.Time                      `json:"startedAt"`
+	ResolvedAt     *time.Time                     `json:"resolvedAt,omitempty"`
+	Message        string                         `json:"message,omitempty"` // the update of an "updated" event
+	Components     []models.PublicStatusComponent `json:"components"`
+	URL            string                         `json:"url,omitempty"`
+	UnsubscribeURL string                         `json:"unsubscribeUrl,omitempty"`
+	Time           time.Time                      `json:"time"`
+}
+
+// Notifier sends incident notifications to status page subscribers
+type Notifier struct {
+	store  *database.Store
+	client *http.Client
+}
+
+// NewNotifier creates a new notifier
+func NewNotifier(store *database.Store) *Notifier {
+	return &Notifier{
+		store:  store,
+		client: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify tells the subscribers of the components containing the
+// incident's service about an incident event; update is the message of an
+// "updated" event. Incidents of services on no component are not
+// announced. It blocks while sending, so callers run it in a goroutine.
+func (n *Notifier) Notify(event string, incident *models.Incident, update string) {
+	ctx := context.Background()
+	components, err := Components(ctx, n.store)
+	if err != nil {
+		log.Printf("[StatusPage] Failed to load components: %v", err)
+		return
+	}
+
+	var affected []models.PublicStatusComponent
+	affectedIDs := make(map[string]bool)
+	for _, id := range componentsOf(components, incident.ServiceID) {
+		affectedIDs[id] = true
+	}
+	for _, c := range components {
+		if affectedIDs[c.ID] {
+			affected = append(affected, models.PublicStatusComponent{ID: c.ID, Name: c.Name, Status: c.Status})
+		}
+	}
+	if len(affected) == 0 {
+		return
+	}
+
+	subscribers, err := n.store.StatusPage.GetSubscribers(ctx)
+	if err != nil {
+		log.Printf("[StatusPage] Failed to load subscribers: %v", err)
+		return
+	}
+
+	publicURL := ""
+	if cfg := config.Get(); cfg != nil {
+		publicURL = strings.TrimRight(cfg.StatusPage.PublicURL, "/")
+	}
+	for _, s := range subscribers {
+		if !subscribed(s, affectedIDs) {
+			continue
+		}
+		notification := Notification{
+			Event:        event,
+			Page:         Title(),
+			IncidentID:   incident.ID,
+			IncidentType: incident.Type,
+			StartedAt:    incident.StartedAt,
+			ResolvedAt:   incident.ResolvedAt,
+			Message:      update,
+			Components:   affected,
+			Time:         time.Now(),
+		}
+		if publicURL != "" {
+			notification.URL = publicURL
+			notification.UnsubscribeURL = publicURL + "/api/v1/status/unsubscribe/" + s.Token
+		}
+
+		var err error
+		switch s.Type {
+		case models.SubscriberEmail:
+			err = sendEmail(s.Target, notification)
+		case models.SubscriberWebhook:
+			err = n.postWebhook(s.Target, notification)
+		}
+		if err != nil {
+			log.Printf("[StatusPage] Failed to notify %s subscriber %d: %v", s.Type, s.ID, err)
+		}
+	}
+}
+
+// subscribed reports whether a subscriber follows any affected component
+func subscribed(s models.StatusSubscriber, affected map[string]bool) bool {
+	if len(s.ComponentIDs) == 0 {
+		return true
+	}
+	for _, id := range s.ComponentIDs {
+		if affected[id] {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Notifier) postWebhook(url string, notification Notification) error {
+	payload, err := json.Marshal(notification)
+	if err != nil {
+		return err
+	}
+	resp, err := n.client.Post(url, "application/json", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// sendEmail sends a plain-text notification through the SMTP server of
+// the email alert channel
+func sendEmail(to string, notification Notification) error {
+	cfg := config.Get()
+	if cfg == nil || cfg.Alerts.Channels.Email.SMTP.Host == "" {
+		return errors.New("alerts.channels.email.smtp is not configured")
+	}
+	server := cfg.Alerts.Channels.Email.SMTP
+	from := cfg.StatusPage.EmailFrom
+	if from == "" {
+		from = server.Username
+	}
+	if from == "" {
+		return errors.New("statusPage.emailFrom is not configured")
+	}
+
+	names := make([]string, len(notification.Components))
+	for i, c := range notification.Components {
+		names[i] = c.Name
+	}
+	subject := fmt.Sprintf("[%s] Incident %s: %s", notification.Page, notification.Event, strings.Join(names, ", "))
+
+	var body strings.Builder
+	fmt.Fprintf(&body, "Incident #%d (%s) on %s was %s.\r\n\r\n", notification.IncidentID, notification.IncidentType, strings.Join(names, ", "), notification.Event)
+	if notification.Message != "" {
+		fmt.Fprintf(&body, "%s\r\n\r\n", notification.Message)
+	}
+	fmt.Fprintf(&body, "Started: %s\r\n", notification.StartedAt.UTC().Format(time.RFC1123))
+	if notification.ResolvedAt != nil {
+		fmt.Fprintf(&body, "Resolved: %s\r\n", notification.ResolvedAt.UTC().Format(time.RFC1123))
+	}
+	for _, c := range notification.Components {
+		fmt.Fprintf(&body, "%s: %s\r\n", c.Name, strings.ReplaceAll(string(c.Status), "_", " "))
+	}
+	if notification.URL != "" {
+		fmt.Fprintf(&body, "\r\nStatus page: %s\r\nUnsubscribe: %s\r\n", notification.URL, notification.UnsubscribeURL)
+	}
+
+	msg := "From: " + from + "\r\n" +
+		"To: " + to + "\r\n" +
+		"Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n" +
+		"Date: " + time.Now().Format(time.RFC1123Z) + "\r\n" +
+		"MIME-Version: 1.0\r\n" +
+		"Content-Type: text/plain; charset=utf-8\r\n" +
+		"\r\n" + body.String()
+
+	var auth smtp.Auth
+	if server.Username != "" {
+		auth = smtp.PlainAuth("", server.Username, server.Password, server.Host)
+	}
+	addr := net.JoinHostPort(server.Host, strconv.Itoa(server.Port))
+	return smtp.SendMail(addr, auth, from, []string{to}, []byte(msg))
+}
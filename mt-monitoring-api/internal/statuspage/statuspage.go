@@ -0,0 +1,218 @@
+// Package statuspage builds the public status page from components (named
+// groups of services), their incidents and announced maintenance, and
+// notifies the page's subscribers when incidents are created, updated or
+// resolved.
+package statuspage
+
+import (
+	"context"
+	"time"
+
+	"github.com/mt-monitoring/api/internal/config"
+	"github.com/mt-monitoring/api/internal/database"
+	"github.com/mt-monitoring/api/internal/models"
+)
+
+// recentIncidents is how long resolved incidents stay on the page
+const recentIncidents = 7 * 24 * time.Hour
+
+// Title returns the configured page title
+func Title() string {
+	if cfg := config.Get(); cfg != nil && cfg.StatusPage.Title != "" {
+		return cfg.StatusPage.Title
+	}
+	return "Service Status"
+}
+
+// ComputeStatus sets the status of each component: its override when set,
+// under maintenance while a maintenance on it is in progress, otherwise
+// derived from the open incidents of its services. All services down is a
+// major outage, some a partial outage.
+func ComputeStatus(components []models.StatusComponent, active []models.Incident, inProgress []models.StatusMaintenance) {
+	byService := make(map[string]models.IncidentType, len(active))
+	for _, incident := range active {
+		if byService[incident.ServiceID] != models.IncidentTypeDown {
+			byService[incident.ServiceID] = incident.Type
+		}
+	}
+
+	maintained := make(map[string]bool)
+	for _, m := range inProgress {
+		for _, id := range m.ComponentIDs {
+			maintained[id] = true
+		}
+	}
+
+	for i := range components {
+		c := &components[i]
+		if c.StatusOverride != "" {
+			c.Status = c.StatusOverride
+			continue
+		}
+		if maintained[c.ID] {
+			c.Status = models.ComponentUnderMaintenance
+			continue
+		}
+
+		down, degraded := 0, 0
+		for _, id := range c.ServiceIDs {
+			switch byService[id] {
+			case models.IncidentTypeDown:
+				down++
+			case models.IncidentTypeDegraded:
+				degraded++
+			}
+		}
+		switch {
+		case down > 0 && down == len(c.ServiceIDs):
+			c.Status = models.ComponentMajorOutage
+		case down > 0:
+			c.Status = models.ComponentPartialOutage
+		case degraded > 0:
+			c.Status = models.ComponentDegradedPerformance
+		default:
+			c.Status = models.ComponentOperational
+		}
+	}
+}
+
+// Components returns all components with their current status
+func Components(ctx context.Context, store *database.Store) ([]models.StatusComponent, error) {
+	components, err := store.StatusPage.GetComponents(ctx)
+	if err != nil {
+		return nil, err
+	}
+	active, err := store.Incidents.GetActive(ctx)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	maintenances, err := store.StatusPage.GetMaintenances(ctx, now)
+	if err != nil {
+		return nil, err
+	}
+	var inProgress []models.StatusMaintenance
+	for _, m := range maintenances {
+		if m.StatusAt(now) == models.MaintenanceInProgress {
+			inProgress = append(inProgress, m)
+		}
+	}
+	ComputeStatus(components, active, inProgress)
+	return components, nil
+}
+
+// Maintenances returns the maintenances ending at or after endedSince with
+// their phase at now
+func Maintenances(ctx context.Context, store *database.Store, endedSince, now time.Time) ([]models.StatusMaintenance, error) {
+	maintenances, err := store.StatusPage.GetMaintenances(ctx, endedSince)
+	if err != nil {
+		return nil, err
+	}
+	for i := range maintenances {
+		maintenances[i].Status = maintenances[i].StatusAt(now)
+	}
+	return maintenances, nil
+}
+
+// Build returns the public status page: the components, the overall
+// status (the worst component's), the open and recently resolved
+// incidents of services on the page, newest first, with their updates, and
+// the scheduled, in progress and recently completed maintenances.
+func Build(ctx context.Context, store *database.Store) (*models.StatusPage, error) {
+	components, err := Components(ctx, store)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	incidents, err := store.Incidents.GetRange(ctx, now.Add(-recentIncidents), now)
+	if err != nil {
+		return nil, err
+	}
+
+	maintenances, err := Maintenances(ctx, store, now.Add(-recentIncidents), now)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.StatusPage{
+		Title:        Title(),
+		Status:       models.ComponentOperational,
+		Components:   make([]models.PublicStatusComponent, 0, len(components)),
+		Incidents:    []models.PublicIncident{},
+		Maintenances: make([]models.PublicMaintenance, 0, len(maintenances)),
+		UpdatedAt:    now,
+	}
+	for _, m := range maintenances {
+		page.Maintenances = append(page.Maintenances, models.PublicMaintenance{
+			ID:           m.ID,
+			Title:        m.Title,
+			Message:      m.Message,
+			Status:       m.Status,
+			ComponentIDs: m.ComponentIDs,
+			StartsAt:     m.StartsAt,
+			EndsAt:       m.EndsAt,
+			UpdatedAt:    m.UpdatedAt,
+		})
+	}
+	for _, c := range components {
+		public := models.PublicStatusComponent{
+			ID:          c.ID,
+			Name:        c.Name,
+			Description: c.Description,
+			Status:      c.Status,
+		}
+		if c.StatusOverride != "" {
+			public.Message = c.OverrideMessage
+		}
+		page.Components = append(page.Components, public)
+		if c.Status.Worse(page.Status) {
+			page.Status = c.Status
+		}
+	}
+
+	var ids []int64
+	for i := len(incidents) - 1; i >= 0; i-- {
+		incident := incidents[i]
+		componentIDs := componentsOf(components, incident.ServiceID)
+		if len(componentIDs) == 0 {
+			continue
+		}
+		page.Incidents = append(page.Incidents, models.PublicIncident{
+			ID:           incident.ID,
+			Type:         incident.Type,
+			ComponentIDs: componentIDs,
+			StartedAt:    incident.StartedAt,
+			ResolvedAt:   incident.ResolvedAt,
+			Updates:      []models.IncidentUpdate{},
+		})
+		ids = append(ids, incident.ID)
+	}
+
+	updates, err := store.StatusPage.GetIncidentUpdates(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	index := make(map[int64]int, len(page.Incidents))
+	for i, incident := range page.Incidents {
+		index[incident.ID] = i
+	}
+	for _, u := range updates {
+		i := index[u.IncidentID]
+		page.Incidents[i].Updates = append(page.Incidents[i].Updates, u)
+	}
+	return page, nil
+}
+
+// componentsOf returns the IDs of the components containing a service
+func componentsOf(components []models.StatusComponent, serviceID string) []string {
+	var ids []string
+	for _, c := range components {
+		for _, id := range c.ServiceIDs {
+			if id == serviceID {
+				ids = append(ids, c.ID)
+				break
+			}
+		}
+	}
+	return ids
+}
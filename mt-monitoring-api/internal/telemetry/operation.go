@@ -0,0 +1,126 @@
+package telemetry
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Instruments are created against the global meter, which forwards to the
+// provider installed by Setup
+var (
+	tracer = otel.Tracer(instrumentationName)
+	meter  = otel.Meter(instrumentationName)
+
+	checkDuration        = histogram("mt.check.duration", "Duration of service checks, including storing the result")
+	collectDuration      = histogram("mt.collector.duration", "Duration of system metric collection per host")
+	queryDuration        = histogram("mt.db.query.duration", "Duration of database repository calls")
+	notificationDuration = histogram("mt.notification.duration", "Duration of notification delivery per channel, including retries")
+)
+
+func histogram(name, description string) metric.Float64Histogram {
+	h, err := meter.Float64Histogram(name, metric.WithDescription(description), metric.WithUnit("ms"))
+	if err != nil {
+		otel.Handle(err)
+	}
+	return h
+}
+
+// Gauge registers a gauge read from value at every metric export
+func Gauge(name, description string, value func() int64) {
+	_, err := meter.Int64ObservableGauge(name, metric.WithDescription(description),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			o.Observe(value())
+			return nil
+		}))
+	if err != nil {
+		otel.Handle(err)
+	}
+}
+
+// Operation is an instrumented unit of work: a span and a duration sample
+// recorded when it ends. A nil Operation, returned while telemetry is
+// disabled, ignores every call.
+type Operation struct {
+	span      trace.Span
+	start     time.Time
+	histogram metric.Float64Histogram
+	attrs     []attribute.KeyValue
+	failed    bool
+}
+
+func start(ctx context.Context, name string, histogram metric.Float64Histogram, attrs ...attribute.KeyValue) (context.Context, *Operation) {
+	if !Enabled() {
+		return ctx, nil
+	}
+	ctx, span := tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+	return ctx, &Operation{
+		span:      span,
+		start:     time.Now(),
+		histogram: histogram,
+		attrs:     attrs,
+	}
+}
+
+// StartCheck starts the span of a service check
+func StartCheck(ctx context.Context, serviceID, serviceType string) (context.Context, *Operation) {
+	return start(ctx, "check "+serviceType, checkDuration,
+		attribute.String("service.id", serviceID),
+		attribute.String("service.type", serviceType))
+}
+
+// StartCollect starts the span of one collection from a host. kind is
+// "local", "ssh" or "node_exporter".
+func StartCollect(ctx context.Context, hostID, kind string) (context.Context, *Operation) {
+	return start(ctx, "collect "+kind, collectDuration,
+		attribute.String("host.id", hostID),
+		attribute.String("collector.kind", kind))
+}
+
+// StartQuery starts the span of a repository call, e.g.
+// "metricRepository.GetRange"
+func StartQuery(ctx context.Context, operation string) (context.Context, *Operation) {
+	return start(ctx, operation, queryDuration,
+		attribute.String("db.system", "sqlite"),
+		attribute.String("db.operation", operation))
+}
+
+// StartNotification starts the span of delivering a notification to a
+// channel
+func StartNotification(ctx context.Context, channelType, alertType string) (context.Context, *Operation) {
+	return start(ctx, "notify "+channelType, notificationDuration,
+		attribute.String("channel.type", channelType),
+		attribute.String("alert.type", alertType))
+}
+
+// Fail marks the operation as failed with the given reason
+func (o *Operation) Fail(reason string) {
+	if o == nil {
+		return
+	}
+	o.failed = true
+	o.span.SetStatus(codes.Error, reason)
+}
+
+// End ends the span and records the duration with an outcome of "ok" or
+// "error"
+func (o *Operation) End() {
+	if o == nil {
+		return
+	}
+	outcome := "ok"
+	if o.failed {
+		outcome = "error"
+	}
+	o.span.End()
+	if o.histogram != nil {
+		elapsed := float64(time.Since(o.start)) / float64(time.Millisecond)
+		o.histogram.Record(context.Background(), elapsed,
+			metric.WithAttributes(append(o.attrs, attribute.String("outcome", outcome))...))
+	}
+}
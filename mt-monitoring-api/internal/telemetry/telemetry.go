@@ -0,0 +1,99 @@
+// Package telemetry instruments the monitoring server itself with
+// OpenTelemetry: spans and duration histograms for service checks, system
+// metric collection, database queries and notification dispatch, exported
+// via OTLP/HTTP.
+package telemetry
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlpmetric/otlpmetrichttp"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+
+	"github.com/mt-monitoring/api/internal/config"
+)
+
+// instrumentationName identifies the tracer and meter of this module
+const instrumentationName = "github.com/mt-monitoring/api"
+
+// enabled is set once Setup installed the exporters; instrumentation is
+// skipped entirely before that
+var enabled atomic.Bool
+
+// Enabled reports whether telemetry is being exported
+func Enabled() bool {
+	return enabled.Load()
+}
+
+// Setup installs OTLP trace and metric exporters as the global providers
+// when telemetry.enabled is set. The server entry point calls it at
+// startup and the returned shutdown on exit, which flushes pending data.
+// Without telemetry shutdown does nothing.
+func Setup(ctx context.Context, version string) (shutdown func(context.Context) error, err error) {
+	cfg := config.Get()
+	if cfg == nil || !cfg.Telemetry.Enabled {
+		return func(context.Context) error { return nil }, nil
+	}
+	t := cfg.Telemetry
+
+	res, err := resource.Merge(resource.Default(), resource.NewSchemaless(
+		attribute.String("service.name", t.ServiceName),
+		attribute.String("service.version", version),
+	))
+	if err != nil {
+		return nil, err
+	}
+
+	traceOpts := []otlptracehttp.Option{}
+	metricOpts := []otlpmetrichttp.Option{}
+	if t.Endpoint != "" {
+		traceOpts = append(traceOpts, otlptracehttp.WithEndpointURL(t.Endpoint))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithEndpointURL(t.Endpoint))
+	}
+	if len(t.Headers) > 0 {
+		traceOpts = append(traceOpts, otlptracehttp.WithHeaders(t.Headers))
+		metricOpts = append(metricOpts, otlpmetrichttp.WithHeaders(t.Headers))
+	}
+
+	traceExporter, err := otlptracehttp.New(ctx, traceOpts...)
+	if err != nil {
+		return nil, err
+	}
+	metricExporter, err := otlpmetrichttp.New(ctx, metricOpts...)
+	if err != nil {
+		traceExporter.Shutdown(ctx)
+		return nil, err
+	}
+
+	tracerProvider := sdktrace.NewTracerProvider(
+		sdktrace.WithResource(res),
+		sdktrace.WithBatcher(traceExporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(t.SampleRatio))),
+	)
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithResource(res),
+		sdkmetric.WithReader(sdkmetric.NewPeriodicReader(metricExporter,
+			sdkmetric.WithInterval(time.Duration(t.MetricInterval)*time.Second))),
+	)
+
+	otel.SetTracerProvider(tracerProvider)
+	otel.SetMeterProvider(meterProvider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	enabled.Store(true)
+	log.Printf("OpenTelemetry export enabled (service %s, sample ratio %.2f)", t.ServiceName, t.SampleRatio)
+
+	return func(ctx context.Context) error {
+		enabled.Store(false)
+		return errors.Join(tracerProvider.Shutdown(ctx), meterProvider.Shutdown(ctx))
+	}, nil
+}